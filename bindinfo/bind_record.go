@@ -0,0 +1,174 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+const (
+	// Using is the bind info's in use status.
+	Using = "using"
+	// deleted is the bind info's deleted status.
+	deleted = "deleted"
+)
+
+// TimeFormat is the format of the create time, the update time and the last
+// used time of a binding. The times have a fixed format, so they can be
+// compared as strings.
+const TimeFormat = "2006-01-02 15:04:05.000"
+
+// BindRecord represents a sql bind record retrieved from the storage. A
+// statement has at most one binding in a scope.
+type BindRecord struct {
+	OriginalSQL string
+	Db          string
+	BindSQL     string
+	// Status represents the status of the binding. It can only be one of the following values:
+	// 1. deleted: the binding is deleted, can not be used anymore.
+	// 2. using: the binding is in the normal active mode.
+	Status     string
+	CreateTime string
+	UpdateTime string
+	Charset    string
+	Collation  string
+
+	// hints are the optimizer hints of BindSQL, grouped by the query blocks
+	// in the order they appear in the statement.
+	hints [][]*ast.TableOptimizerHint
+	usage *bindUsage
+}
+
+// bindUsage records how the binding is used on the current server.
+type bindUsage struct {
+	useCount int64
+	// lastUsedTime is the unix nano time the binding is used last time.
+	lastUsedTime int64
+}
+
+// isUsing checks if the binding is in the normal active mode.
+func (br *BindRecord) isUsing() bool {
+	return br.Status == Using
+}
+
+// prepareHints parses the BindSQL and extracts the hints of each query block.
+func (br *BindRecord) prepareHints(p *parser.Parser) error {
+	if br.Status == deleted {
+		return nil
+	}
+	stmt, err := p.ParseOneStmt(br.BindSQL, br.Charset, br.Collation)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	br.hints = CollectHints(stmt)
+	if br.usage == nil {
+		br.usage = &bindUsage{}
+	}
+	return nil
+}
+
+// RecordUsage records one use of the binding.
+func (br *BindRecord) RecordUsage() {
+	if br.usage == nil {
+		return
+	}
+	atomic.AddInt64(&br.usage.useCount, 1)
+	atomic.StoreInt64(&br.usage.lastUsedTime, time.Now().UnixNano())
+}
+
+// UseCount returns how many times the binding is used on the current server.
+func (br *BindRecord) UseCount() int64 {
+	if br.usage == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&br.usage.useCount)
+}
+
+// LastUsedTime returns the time the binding is used last time on the current
+// server, it is empty if the binding has never been used.
+func (br *BindRecord) LastUsedTime() string {
+	if br.usage == nil {
+		return ""
+	}
+	lastUsed := atomic.LoadInt64(&br.usage.lastUsedTime)
+	if lastUsed == 0 {
+		return ""
+	}
+	return time.Unix(0, lastUsed).Format(TimeFormat)
+}
+
+// Hints returns the hints of the binding, grouped by the query blocks.
+func (br *BindRecord) Hints() [][]*ast.TableOptimizerHint {
+	return br.hints
+}
+
+// hintsCollector collects the hints of the query blocks in a statement.
+type hintsCollector struct {
+	hints [][]*ast.TableOptimizerHint
+}
+
+// Enter implements the ast.Visitor interface.
+func (c *hintsCollector) Enter(in ast.Node) (ast.Node, bool) {
+	if sel, ok := in.(*ast.SelectStmt); ok {
+		c.hints = append(c.hints, sel.TableHints)
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface.
+func (c *hintsCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// CollectHints collects the hints of the query blocks in the statement, in
+// the order the query blocks appear in the statement.
+func CollectHints(stmt ast.Node) [][]*ast.TableOptimizerHint {
+	c := &hintsCollector{}
+	stmt.Accept(c)
+	return c.hints
+}
+
+// hintsBinder replaces the hints of the query blocks in a statement.
+type hintsBinder struct {
+	hints  [][]*ast.TableOptimizerHint
+	offset int
+}
+
+// Enter implements the ast.Visitor interface.
+func (b *hintsBinder) Enter(in ast.Node) (ast.Node, bool) {
+	if sel, ok := in.(*ast.SelectStmt); ok {
+		if b.offset < len(b.hints) {
+			sel.TableHints = b.hints[b.offset]
+		}
+		b.offset++
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface.
+func (b *hintsBinder) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// BindHint replaces the hints of the query blocks in the statement with the
+// hints of the binding. The statement must have the same normalized SQL as
+// the bind SQL, so their query blocks are in the same order.
+func BindHint(stmt ast.Node, hints [][]*ast.TableOptimizerHint) {
+	stmt.Accept(&hintsBinder{hints: hints})
+}
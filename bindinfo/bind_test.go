@@ -0,0 +1,274 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/failpoint"
+	"github.com/pingcap/tidb/bindinfo"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/store/mockstore"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testSuite{})
+
+type testSuite struct {
+	store kv.Storage
+	dom   *domain.Domain
+}
+
+func (s *testSuite) SetUpTest(c *C) {
+	var err error
+	s.store, err = mockstore.NewMockTikvStore()
+	c.Assert(err, IsNil)
+	session.SetSchemaLease(0)
+	session.DisableStats4Test()
+	bindinfo.Lease = 0
+	s.dom, err = session.BootstrapSession(s.store)
+	c.Assert(err, IsNil)
+}
+
+func (s *testSuite) TearDownTest(c *C) {
+	s.dom.Close()
+	c.Assert(s.store.Close(), IsNil)
+}
+
+// planContains checks whether any operator of the explained plan has the prefix.
+func planContains(tk *testkit.TestKit, sql, prefix string) bool {
+	for _, row := range tk.MustQuery("explain " + sql).Rows() {
+		if strings.HasPrefix(strings.TrimLeft(fmt.Sprintf("%v", row[0]), "└─│ "), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *testSuite) TestSessionBinding(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+	tk.MustExec("insert into t values(1, 1), (2, 2)")
+
+	sql := "select a from t where a = 1"
+	c.Assert(planContains(tk, sql, "IndexReader"), IsTrue)
+
+	tk.MustExec("create session binding for select a from t where a = 2 using select /*+ IGNORE_INDEX(t, idx) */ a from t where a = 2")
+	c.Assert(planContains(tk, sql, "IndexReader"), IsFalse)
+	c.Assert(planContains(tk, sql, "TableReader"), IsTrue)
+	tk.MustQuery(sql).Check(testkit.Rows("1"))
+
+	rows := tk.MustQuery("show session bindings").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][0], Equals, "select a from t where a = ?")
+	c.Assert(rows[0][1], Equals, "select /*+ IGNORE_INDEX(t, idx) */ a from t where a = 2")
+	c.Assert(rows[0][2], Equals, "test")
+	c.Assert(rows[0][3], Equals, bindinfo.Using)
+	c.Assert(rows[0][8], Equals, "3")
+	c.Assert(rows[0][9], Not(Equals), "")
+
+	// The binding is not used when the plan baselines are disabled.
+	tk.MustExec("set @@tidb_use_plan_baselines = 0")
+	c.Assert(planContains(tk, sql, "IndexReader"), IsTrue)
+	tk.MustExec("set @@tidb_use_plan_baselines = 1")
+
+	// The binding is only visible in the current session.
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	c.Assert(planContains(tk1, sql, "IndexReader"), IsTrue)
+	tk1.MustQuery("show session bindings").Check(testkit.Rows())
+
+	tk.MustExec("drop session binding for select a from t where a = 3")
+	tk.MustQuery("show session bindings").Check(testkit.Rows())
+	c.Assert(planContains(tk, sql, "IndexReader"), IsTrue)
+
+	_, err := tk.Exec("create session binding for select a from t where a = 1 using select a from t where b = 1")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*hinted sql and original sql don't match.*")
+}
+
+func (s *testSuite) TestGlobalBinding(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+
+	sql := "select a from t where a = 1"
+	tk.MustExec("create global binding for select a from t where a = 2 using select /*+ IGNORE_INDEX(t, idx) */ a from t where a = 2")
+	tk.MustQuery("select original_sql, default_db, status from mysql.bind_info").Check(testkit.Rows(
+		"select a from t where a = ? test using",
+	))
+
+	// The global binding is used by the other sessions.
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	c.Assert(planContains(tk1, sql, "TableReader"), IsTrue)
+	rows := tk1.MustQuery("show global bindings").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][0], Equals, "select a from t where a = ?")
+	c.Assert(rows[0][8], Equals, "1")
+
+	// The session binding takes precedence over the global one.
+	tk1.MustExec("create binding for select a from t where a = 2 using select /*+ USE_INDEX(t, idx) */ a from t where a = 2")
+	c.Assert(planContains(tk1, sql, "IndexReader"), IsTrue)
+
+	// Another server loads the binding from the storage.
+	handle := bindinfo.NewBindHandle(tk.Se)
+	c.Assert(handle.Update(true), IsNil)
+	c.Assert(handle.GetBindRecord("select a from t where a = ?", "test"), NotNil)
+	c.Assert(handle.GetBindRecord("select a from t where a = ?", "mysql"), IsNil)
+
+	tk.MustExec("drop global binding for select a from t where a = 3")
+	tk.MustQuery("show global bindings").Check(testkit.Rows())
+	tk.MustQuery("select original_sql, default_db, status from mysql.bind_info").Check(testkit.Rows(
+		"select a from t where a = ? test deleted",
+	))
+	c.Assert(planContains(tk, sql, "IndexReader"), IsTrue)
+
+	c.Assert(handle.Update(false), IsNil)
+	c.Assert(handle.GetBindRecord("select a from t where a = ?", "test"), IsNil)
+	c.Assert(handle.GetAllBindRecord(), HasLen, 0)
+
+	// Dropping a binding which doesn't exist writes nothing.
+	tk.MustExec("drop global binding for select a from t where a = 3")
+	tk.MustExec("drop global binding for select b from t where b = 1")
+	tk.MustQuery("select original_sql, default_db, status from mysql.bind_info").Check(testkit.Rows(
+		"select a from t where a = ? test deleted",
+	))
+}
+
+func (s *testSuite) TestCaptureBaselines(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+	se, err := session.CreateSession4Test(s.store)
+	c.Assert(err, IsNil)
+	se.GetSessionVars().InRestrictedSQL = true
+	handle := s.dom.BindHandle()
+
+	tk.MustQuery("select a from t where a = 1")
+	handle.CaptureBaselines(se)
+	c.Assert(handle.CaptureEnabled(), IsFalse)
+
+	tk.MustExec("set global tidb_capture_plan_baselines = 1")
+	handle.CaptureBaselines(se)
+	c.Assert(handle.CaptureEnabled(), IsTrue)
+	tk.MustQuery("select a from t where a = 1")
+	tk.MustQuery("select a from t where a = 2")
+	// The statements executed once, explained or with hints are not captured.
+	tk.MustQuery("select b from t where b = 1")
+	tk.MustQuery("explain select a, b from t where a = 1")
+	tk.MustQuery("explain select a, b from t where a = 1")
+	tk.MustQuery("select /*+ USE_INDEX(t) */ b from t where a = 1")
+	tk.MustQuery("select /*+ USE_INDEX(t) */ b from t where a = 1")
+	handle.CaptureBaselines(se)
+	rows := tk.MustQuery("show global bindings").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][0], Equals, "select a from t where a = ?")
+	c.Assert(rows[0][1], Equals, "select /*+ use_index(`t`, `idx`) */ a from t where a = 1")
+	c.Assert(rows[0][2], Equals, "test")
+
+	// The bound statements are not captured again.
+	tk.MustQuery("select a from t where a = 3")
+	tk.MustQuery("select a from t where a = 4")
+	handle.CaptureBaselines(se)
+	rows = tk.MustQuery("show global bindings").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][8], Equals, "2")
+
+	tk.MustExec("set global tidb_capture_plan_baselines = 0")
+	handle.CaptureBaselines(se)
+	c.Assert(handle.CaptureEnabled(), IsFalse)
+}
+
+func (s *testSuite) TestEvolveBaselines(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+	tk.MustExec("insert into t values(1, 1), (2, 2), (3, 3)")
+	tk.MustExec("create global binding for select a from t where a = 1 using select /*+ use_index(t) */ a from t where a = 1")
+	se, err := session.CreateSession4Test(s.store)
+	c.Assert(err, IsNil)
+	se.GetSessionVars().InRestrictedSQL = true
+	handle := s.dom.BindHandle()
+
+	c.Assert(handle.HandleEvolvePlanTask(se), IsNil)
+	tk.MustQuery("select count(*) from mysql.plan_evolution_history").Check(testkit.Rows("0"))
+
+	tk.MustExec("set global tidb_evolve_plan_baselines = 1")
+	c.Assert(failpoint.Enable("github.com/pingcap/tidb/bindinfo/mockCurrentPlanExecTime", "return(100)"), IsNil)
+	c.Assert(failpoint.Enable("github.com/pingcap/tidb/bindinfo/mockNewPlanExecTime", "return(10)"), IsNil)
+	err = handle.HandleEvolvePlanTask(se)
+	c.Assert(failpoint.Disable("github.com/pingcap/tidb/bindinfo/mockNewPlanExecTime"), IsNil)
+	c.Assert(failpoint.Disable("github.com/pingcap/tidb/bindinfo/mockCurrentPlanExecTime"), IsNil)
+	c.Assert(err, IsNil)
+	rows := tk.MustQuery("select original_sql, default_db, current_bind_sql, new_bind_sql, current_exec_time, new_exec_time, result from mysql.plan_evolution_history").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][0], Equals, "select a from t where a = ?")
+	c.Assert(rows[0][1], Equals, "test")
+	c.Assert(rows[0][2], Equals, "select /*+ use_index(t) */ a from t where a = 1")
+	c.Assert(rows[0][3], Equals, "select /*+ use_index(`t`, `idx`) */ a from t where a = 1")
+	c.Assert(rows[0][4], Equals, "100")
+	c.Assert(rows[0][5], Equals, "10")
+	c.Assert(rows[0][6], Equals, bindinfo.EvolveAccepted)
+	rows = tk.MustQuery("show global bindings").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][1], Equals, "select /*+ use_index(`t`, `idx`) */ a from t where a = 1")
+
+	// The binding is not evolved again in a short time.
+	c.Assert(handle.HandleEvolvePlanTask(se), IsNil)
+	tk.MustQuery("select count(*) from mysql.plan_evolution_history").Check(testkit.Rows("1"))
+}
+
+func (s *testSuite) TestEvolveBaselinesRejected(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+	tk.MustExec("insert into t values(1, 1), (2, 2), (3, 3)")
+	tk.MustExec("create global binding for select a from t where a = 1 using select /*+ use_index(t) */ a from t where a = 1")
+	tk.MustExec("set global tidb_evolve_plan_baselines = 1")
+	se, err := session.CreateSession4Test(s.store)
+	c.Assert(err, IsNil)
+	se.GetSessionVars().InRestrictedSQL = true
+
+	// The new plan is faster but not fast enough to be accepted.
+	c.Assert(failpoint.Enable("github.com/pingcap/tidb/bindinfo/mockCurrentPlanExecTime", "return(100)"), IsNil)
+	c.Assert(failpoint.Enable("github.com/pingcap/tidb/bindinfo/mockNewPlanExecTime", "return(90)"), IsNil)
+	err = s.dom.BindHandle().HandleEvolvePlanTask(se)
+	c.Assert(failpoint.Disable("github.com/pingcap/tidb/bindinfo/mockNewPlanExecTime"), IsNil)
+	c.Assert(failpoint.Disable("github.com/pingcap/tidb/bindinfo/mockCurrentPlanExecTime"), IsNil)
+	c.Assert(err, IsNil)
+	rows := tk.MustQuery("select new_bind_sql, result from mysql.plan_evolution_history").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][0], Equals, "select /*+ use_index(`t`, `idx`) */ a from t where a = 1")
+	c.Assert(rows[0][1], Equals, bindinfo.EvolveRejected)
+	rows = tk.MustQuery("show global bindings").Rows()
+	c.Assert(len(rows), Equals, 1)
+	c.Assert(rows[0][1], Equals, "select /*+ use_index(t) */ a from t where a = 1")
+}
@@ -0,0 +1,247 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"go.uber.org/zap"
+)
+
+const (
+	// captureMinCount is the number of executions for a statement to be
+	// captured as a baseline.
+	captureMinCount = 2
+	// captureMaxStmts is the max number of the statements counted for capturing.
+	captureMaxStmts = 1000
+)
+
+// CaptureWindow is the duration a statement should be executed captureMinCount
+// times in to be captured as a baseline.
+var CaptureWindow = 30 * time.Minute
+
+// captureStmt is a statement which may be captured as a baseline.
+type captureStmt struct {
+	sql       string
+	charset   string
+	collation string
+	count     int
+	firstSeen time.Time
+}
+
+// CaptureEnabled checks whether the frequent statements are captured as baselines.
+func (h *BindHandle) CaptureEnabled() bool {
+	return atomic.LoadInt32(&h.captureEnabled) == 1
+}
+
+// CaptureStmt counts an execution of the statement which has no binding, the
+// statement is captured as a baseline by CaptureBaselines when it is executed
+// frequently. Only the statements of a single query block without hints are
+// captured, since the hints generated from the plan can only be put into the
+// first query block.
+func (h *BindHandle) CaptureStmt(sel *ast.SelectStmt, db, charset, collation string) {
+	if db == "" || strings.EqualFold(db, mysql.SystemDB) || sel.From == nil || len(sel.TableHints) > 0 {
+		return
+	}
+	if sel.Text() == "" || len(CollectHints(sel)) != 1 {
+		return
+	}
+	key := bindKey{normdOrigSQL: parser.NormalizeForBinding(sel.Text()), db: db}
+	h.captureInfo.Lock()
+	defer h.captureInfo.Unlock()
+	stmt, ok := h.captureInfo.stmts[key]
+	if !ok {
+		if len(h.captureInfo.stmts) >= captureMaxStmts {
+			return
+		}
+		stmt = &captureStmt{sql: sel.Text(), charset: charset, collation: collation, firstSeen: time.Now()}
+		h.captureInfo.stmts[key] = stmt
+	}
+	stmt.count++
+}
+
+// CaptureBaselines creates the global bindings of the frequent statements
+// with the hints of their current plans, if tidb_capture_plan_baselines is
+// enabled. The plans are generated in sctx.
+func (h *BindHandle) CaptureBaselines(sctx sessionctx.Context) {
+	if !getGlobalBoolVar(sctx, variable.TiDBCapturePlanBaselines) {
+		if atomic.SwapInt32(&h.captureEnabled, 0) == 1 {
+			h.captureInfo.Lock()
+			h.captureInfo.stmts = make(map[bindKey]*captureStmt)
+			h.captureInfo.Unlock()
+		}
+		return
+	}
+	atomic.StoreInt32(&h.captureEnabled, 1)
+
+	var captured map[bindKey]*captureStmt
+	h.captureInfo.Lock()
+	for key, stmt := range h.captureInfo.stmts {
+		if stmt.count >= captureMinCount {
+			if captured == nil {
+				captured = make(map[bindKey]*captureStmt)
+			}
+			captured[key] = stmt
+			delete(h.captureInfo.stmts, key)
+		} else if time.Since(stmt.firstSeen) > CaptureWindow {
+			delete(h.captureInfo.stmts, key)
+		}
+	}
+	h.captureInfo.Unlock()
+
+	for key, stmt := range captured {
+		if h.GetBindRecord(key.normdOrigSQL, key.db) != nil {
+			continue
+		}
+		hints, err := getPlanHints(sctx, key.db, stmt.sql)
+		if err != nil {
+			logutil.BgLogger().Warn("generate hints for capturing baseline failed", zap.String("sql", stmt.sql), zap.Error(err))
+			continue
+		}
+		bindSQL := genBindSQL(stmt.sql, hints)
+		if bindSQL == "" {
+			continue
+		}
+		record := &BindRecord{
+			OriginalSQL: key.normdOrigSQL,
+			Db:          key.db,
+			BindSQL:     bindSQL,
+			Charset:     stmt.charset,
+			Collation:   stmt.collation,
+		}
+		err = h.AddBindRecord(record)
+		if err != nil {
+			logutil.BgLogger().Warn("capture baseline failed", zap.String("bindSQL", bindSQL), zap.Error(err))
+		}
+	}
+}
+
+// getPlanHints returns the hints of the plan of the statement, the bindings
+// are not used when planning it.
+func getPlanHints(sctx sessionctx.Context, db, sql string) (string, error) {
+	sessVars := sctx.GetSessionVars()
+	sessVars.CurrentDB = db
+	sessVars.UsePlanBaselines = false
+	rows, err := execRows(sctx, "explain format='hint' "+sql)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) != 1 || len(rows[0]) != 1 {
+		return "", errors.Errorf("unexpected result of explaining the hints of %s", sql)
+	}
+	return rows[0][0], nil
+}
+
+// execRows executes the statement in sctx and returns the result as strings.
+func execRows(sctx sessionctx.Context, sql string) (rows [][]string, err error) {
+	ctx := context.Background()
+	recordSets, err := sctx.(sqlexec.SQLExecutor).Execute(ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, rs := range recordSets {
+		if i == 0 {
+			rows, err = drainRows(ctx, rs)
+		}
+		if err1 := rs.Close(); err == nil {
+			err = err1
+		}
+	}
+	return rows, errors.Trace(err)
+}
+
+func drainRows(ctx context.Context, rs sqlexec.RecordSet) ([][]string, error) {
+	var rows [][]string
+	req := rs.NewChunk()
+	for {
+		err := rs.Next(ctx, req)
+		if err != nil || req.NumRows() == 0 {
+			return rows, err
+		}
+		for i := 0; i < req.NumRows(); i++ {
+			row := req.GetRow(i)
+			strs := make([]string, 0, row.Len())
+			for j := 0; j < row.Len(); j++ {
+				strs = append(strs, row.GetString(j))
+			}
+			rows = append(rows, strs)
+		}
+		// The strings refer to the memory of the chunk, so it can not be reused.
+		req = rs.NewChunk()
+	}
+}
+
+// getGlobalBoolVar returns the value of the global boolean variable, false
+// is returned if it fails to get the value.
+func getGlobalBoolVar(sctx sessionctx.Context, name string) bool {
+	val, err := sctx.GetSessionVars().GlobalVarsAccessor.GetGlobalSysVar(name)
+	if err != nil {
+		logutil.BgLogger().Warn("get global variable failed", zap.String("name", name), zap.Error(err))
+		return false
+	}
+	return variable.TiDBOptOn(val)
+}
+
+// selectKeywordEnd returns the end offset of the SELECT keyword of the first
+// query block in the statement, or -1 if the statement does not start with it.
+func selectKeywordEnd(sql string) int {
+	i := 0
+	for i < len(sql) && (sql[i] == '(' || sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\r' || sql[i] == '\n') {
+		i++
+	}
+	if len(sql)-i < len("select") || !strings.EqualFold(sql[i:i+len("select")], "select") {
+		return -1
+	}
+	return i + len("select")
+}
+
+// genBindSQL puts the hints into the first query block of the statement,
+// which must not have hints. An empty string is returned if the statement
+// can not be bound with the hints.
+func genBindSQL(sql, hints string) string {
+	end := selectKeywordEnd(sql)
+	if end < 0 || hints == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s /*+ %s */%s", sql[:end], hints, sql[end:])
+}
+
+// removeHints removes the hints of the first query block in the statement.
+func removeHints(sql string) string {
+	end := selectKeywordEnd(sql)
+	if end < 0 {
+		return sql
+	}
+	rest := strings.TrimLeft(sql[end:], " \t\r\n")
+	if !strings.HasPrefix(rest, "/*+") {
+		return sql
+	}
+	hintEnd := strings.Index(rest, "*/")
+	if hintEnd < 0 {
+		return sql
+	}
+	return sql[:end] + rest[hintEnd+len("*/"):]
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/failpoint"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+const (
+	// acceptFactor is how many times the new plan should be faster than the
+	// current one to be accepted.
+	acceptFactor = 1.5
+	// verifyRounds is how many times each plan is executed in the
+	// verification, the best execution times of the plans are compared.
+	verifyRounds = 2
+
+	// EvolveAccepted is the result of the verification which accepts the new plan.
+	EvolveAccepted = "accepted"
+	// EvolveRejected is the result of the verification which rejects the new plan.
+	EvolveRejected = "rejected"
+)
+
+// EvolveInterval is the min interval to evolve a binding again.
+var EvolveInterval = time.Hour
+
+// HandleEvolvePlanTask evolves a global binding if tidb_evolve_plan_baselines
+// is enabled. The statement is planned again in sctx without the binding, so
+// the latest statistics are used. If the new plan differs from the bound one,
+// both of them are executed within the time budget specified by
+// tidb_evolve_plan_task_max_time, and the binding is replaced by the new plan
+// only if the new plan is verified to be faster. The result of the
+// verification is recorded in mysql.plan_evolution_history.
+func (h *BindHandle) HandleEvolvePlanTask(sctx sessionctx.Context) error {
+	if !getGlobalBoolVar(sctx, variable.TiDBEvolvePlanBaselines) {
+		return nil
+	}
+	record := h.getEvolveRecord()
+	if record == nil {
+		return nil
+	}
+	h.lastEvolveTime[bindKey{normdOrigSQL: record.OriginalSQL, db: record.Db}] = time.Now()
+	maxTime, err := getEvolveMaxTime(sctx)
+	if err != nil {
+		return err
+	}
+
+	origSQL := removeHints(record.BindSQL)
+	currentHints, err := getPlanHints(sctx, record.Db, record.BindSQL)
+	if err != nil {
+		return err
+	}
+	newHints, err := getPlanHints(sctx, record.Db, origSQL)
+	if err != nil {
+		return err
+	}
+	if newHints == currentHints {
+		return nil
+	}
+	newBindSQL := genBindSQL(origSQL, newHints)
+	if newBindSQL == "" {
+		return nil
+	}
+
+	current, newPlan, err := verifyPlans(sctx, record.BindSQL, newBindSQL, maxTime)
+	if err != nil {
+		return err
+	}
+	result := EvolveRejected
+	if newPlan.finished && (!current.finished || float64(newPlan.execTime)*acceptFactor < float64(current.execTime)) {
+		result = EvolveAccepted
+	}
+	err = recordEvolveHistory(sctx, record, newBindSQL, current.execTime, newPlan.execTime, result)
+	if err != nil || result != EvolveAccepted {
+		return err
+	}
+	return h.AddBindRecord(&BindRecord{
+		OriginalSQL: record.OriginalSQL,
+		Db:          record.Db,
+		BindSQL:     newBindSQL,
+		Charset:     record.Charset,
+		Collation:   record.Collation,
+	})
+}
+
+// getEvolveRecord returns the global binding which is evolved least recently.
+// The bindings evolved in EvolveInterval are skipped, so are the ones of
+// multiple query blocks, since the hints generated from the plan can only be
+// put into the first query block.
+func (h *BindHandle) getEvolveRecord() *BindRecord {
+	var (
+		evolveRecord   *BindRecord
+		lastEvolveTime time.Time
+	)
+	for _, record := range h.GetAllBindRecord() {
+		if len(record.Hints()) != 1 {
+			continue
+		}
+		lastTime := h.lastEvolveTime[bindKey{normdOrigSQL: record.OriginalSQL, db: record.Db}]
+		if time.Since(lastTime) < EvolveInterval {
+			continue
+		}
+		if evolveRecord == nil || lastTime.Before(lastEvolveTime) {
+			evolveRecord, lastEvolveTime = record, lastTime
+		}
+	}
+	return evolveRecord
+}
+
+func getEvolveMaxTime(sctx sessionctx.Context) (time.Duration, error) {
+	val, err := sctx.GetSessionVars().GlobalVarsAccessor.GetGlobalSysVar(variable.TiDBEvolvePlanTaskMaxTime)
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// verifyResult is the result of verifying a plan, execTime is the best
+// execution time of the plan, or the max time if it never finishes.
+type verifyResult struct {
+	execTime time.Duration
+	finished bool
+}
+
+// verifyPlans executes the statements of the current and the new plans
+// verifyRounds times within maxTime. The plans are executed in turn and the
+// first one is alternated in every round, so neither of them always benefits
+// from the cache warmed by the other. The new plan can take no longer than
+// the best time of the current plan, since it should be faster to be accepted.
+func verifyPlans(sctx sessionctx.Context, currentSQL, newSQL string, maxTime time.Duration) (current, newPlan verifyResult, err error) {
+	runMaxTime := maxTime / (2 * verifyRounds)
+	if runMaxTime < time.Millisecond {
+		runMaxTime = time.Millisecond
+	}
+	current.execTime, newPlan.execTime = runMaxTime, runMaxTime
+	for i := 0; i < verifyRounds*2; i++ {
+		// The order is current, new, new, current, current, new...
+		isNew := (i/2+i)%2 == 1
+		if !isNew {
+			err = runAndVerify(sctx, currentSQL, runMaxTime, &current)
+			failpoint.Inject("mockCurrentPlanExecTime", func(val failpoint.Value) {
+				current = verifyResult{execTime: time.Duration(val.(int)) * time.Millisecond, finished: true}
+			})
+		} else {
+			newMaxTime := runMaxTime
+			if current.finished && current.execTime < newMaxTime {
+				newMaxTime = current.execTime
+			}
+			err = runAndVerify(sctx, newSQL, newMaxTime, &newPlan)
+			failpoint.Inject("mockNewPlanExecTime", func(val failpoint.Value) {
+				newPlan = verifyResult{execTime: time.Duration(val.(int)) * time.Millisecond, finished: true}
+			})
+		}
+		if err != nil {
+			return current, newPlan, err
+		}
+	}
+	return current, newPlan, nil
+}
+
+// runAndVerify executes the statement within maxTime, and updates the result
+// if the statement finishes faster than before.
+func runAndVerify(sctx sessionctx.Context, sql string, maxTime time.Duration, result *verifyResult) error {
+	if maxTime < time.Millisecond {
+		maxTime = time.Millisecond
+	}
+	execTime, finished, err := runWithMaxTime(sctx, sql, maxTime)
+	if err != nil || !finished {
+		return err
+	}
+	if !result.finished || execTime < result.execTime {
+		result.execTime, result.finished = execTime, true
+	}
+	return nil
+}
+
+// runWithMaxTime executes the statement in sctx and drains the result, the
+// statement is killed if it runs longer than maxTime. It returns how long
+// the execution takes and whether it finishes in maxTime.
+func runWithMaxTime(sctx sessionctx.Context, sql string, maxTime time.Duration) (time.Duration, bool, error) {
+	sessVars := sctx.GetSessionVars()
+	sessVars.MaxExecutionTime = uint64(maxTime / time.Millisecond)
+	defer func() {
+		sessVars.MaxExecutionTime = 0
+	}()
+	start := time.Now()
+	err := execAndDrain(sctx, sql)
+	elapsed := time.Since(start)
+	if elapsed >= maxTime {
+		// The error is expected if the statement is killed.
+		return maxTime, false, nil
+	}
+	return elapsed, err == nil, err
+}
+
+// execAndDrain executes the statement in sctx and discards the result.
+func execAndDrain(sctx sessionctx.Context, sql string) (err error) {
+	ctx := context.Background()
+	recordSets, err := sctx.(sqlexec.SQLExecutor).Execute(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, rs := range recordSets {
+		req := rs.NewChunk()
+		for err == nil {
+			err = rs.Next(ctx, req)
+			if req.NumRows() == 0 {
+				break
+			}
+		}
+		if err1 := rs.Close(); err == nil {
+			err = err1
+		}
+	}
+	return errors.Trace(err)
+}
+
+// recordEvolveHistory records the result of the verification, the execution
+// times are in milliseconds.
+func recordEvolveHistory(sctx sessionctx.Context, record *BindRecord, newBindSQL string,
+	currentTime, newTime time.Duration, result string) error {
+	sql := fmt.Sprintf("insert into mysql.plan_evolution_history values (%s, %s, %s, %s, %d, %d, %s, %s)",
+		quote(record.OriginalSQL), quote(record.Db), quote(record.BindSQL), quote(newBindSQL),
+		int64(currentTime/time.Millisecond), int64(newTime/time.Millisecond), quote(result), quote(time.Now().Format(TimeFormat)))
+	return execAndDrain(sctx, sql)
+}
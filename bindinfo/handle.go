@@ -0,0 +1,347 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/terror"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"go.uber.org/zap"
+)
+
+// BindHandle is used to handle all global sql bind operations.
+type BindHandle struct {
+	sctx struct {
+		sync.Mutex
+		sessionctx.Context
+	}
+
+	// bindInfo caches the sql bind info from storage.
+	//
+	// The Mutex protects that there is only one goroutine changes the content
+	// of atmoic.Value.
+	//
+	// NOTE: Concurrent Value Write:
+	//
+	//    bindInfo.Lock()
+	//    newCache := bindInfo.Value.Load()
+	//    do the write operation on the newCache
+	//    bindInfo.Value.Store(newCache)
+	//    bindInfo.Unlock()
+	//
+	// NOTE: Concurrent Value Read:
+	//
+	//    cache := bindInfo.Load().
+	//    read the content
+	//
+	bindInfo struct {
+		sync.Mutex
+		atomic.Value
+		parser         *parser.Parser
+		lastUpdateTime string
+	}
+
+	// captureInfo counts the executions of the statements which may be
+	// captured as baselines.
+	captureInfo struct {
+		sync.Mutex
+		stmts map[bindKey]*captureStmt
+	}
+	captureEnabled int32
+
+	// lastEvolveTime records when the bindings are evolved last time, it is
+	// only accessed by the goroutine evolving the bindings.
+	lastEvolveTime map[bindKey]time.Time
+}
+
+// bindKey identifies the binding of a statement.
+type bindKey struct {
+	normdOrigSQL string
+	db           string
+}
+
+// Lease influences the duration of loading bind info and handling invalid bind.
+var Lease = 3 * time.Second
+
+// cache is a k-v map, key is the normalized sql, value is the bind records of
+// the statements which have the same normalized sql but different default
+// databases.
+type cache map[string][]*BindRecord
+
+// NewBindHandle creates a new BindHandle.
+func NewBindHandle(ctx sessionctx.Context) *BindHandle {
+	handle := &BindHandle{}
+	handle.sctx.Context = ctx
+	handle.bindInfo.Value.Store(make(cache))
+	handle.bindInfo.parser = parser.New()
+	handle.captureInfo.stmts = make(map[bindKey]*captureStmt)
+	handle.lastEvolveTime = make(map[bindKey]time.Time)
+	return handle
+}
+
+// Update updates the global sql bind cache. If fullLoad is false, only the
+// records updated since the last update are loaded.
+func (h *BindHandle) Update(fullLoad bool) (err error) {
+	h.bindInfo.Lock()
+	lastUpdateTime := h.bindInfo.lastUpdateTime
+	h.bindInfo.Unlock()
+
+	sql := "select original_sql, bind_sql, default_db, status, create_time, update_time, charset, collation from mysql.bind_info"
+	if !fullLoad {
+		// The records updated at the same time as the last update are loaded
+		// again, because the time has a limited precision.
+		sql += fmt.Sprintf(" where update_time >= %s", quote(lastUpdateTime))
+	}
+	exec := h.sctx.Context.(sqlexec.RestrictedSQLExecutor)
+	rows, _, err := exec.ExecRestrictedSQL(sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	h.bindInfo.Lock()
+	defer h.bindInfo.Unlock()
+	newCache := h.bindInfo.Value.Load().(cache).copy()
+	if fullLoad {
+		newCache = make(cache)
+	}
+	for _, row := range rows {
+		record := newBindRecord(row)
+		if record.UpdateTime > h.bindInfo.lastUpdateTime {
+			h.bindInfo.lastUpdateTime = record.UpdateTime
+		}
+		err = record.prepareHints(h.bindInfo.parser)
+		if err != nil {
+			logutil.BgLogger().Error("update bind info failed", zap.String("bindSQL", record.BindSQL), zap.Error(err))
+			continue
+		}
+		newCache.merge(record)
+	}
+	h.bindInfo.Value.Store(newCache)
+	return nil
+}
+
+// AddBindRecord adds a BindRecord to the storage and the cache. The existing
+// binding of the same statement is replaced.
+func (h *BindHandle) AddBindRecord(record *BindRecord) (err error) {
+	err = record.prepareHints(h.bindInfo.parser)
+	if err != nil {
+		return err
+	}
+	record.Status = Using
+	record.CreateTime = time.Now().Format(TimeFormat)
+	record.UpdateTime = record.CreateTime
+	err = h.replaceRecordInStorage(record)
+	if err != nil {
+		return err
+	}
+
+	h.bindInfo.Lock()
+	newCache := h.bindInfo.Value.Load().(cache).copy()
+	newCache.remove(record.OriginalSQL, record.Db)
+	newCache.merge(record)
+	h.bindInfo.Value.Store(newCache)
+	h.bindInfo.Unlock()
+	return nil
+}
+
+// DropBindRecord drops the binding of the statement from the storage and the
+// cache. A record with the deleted status is kept in the storage, so that the
+// other servers can remove the binding from their caches when updating. It
+// does nothing if the statement has no binding.
+func (h *BindHandle) DropBindRecord(originalSQL, db string) (err error) {
+	exec := h.sctx.Context.(sqlexec.RestrictedSQLExecutor)
+	rows, _, err := exec.ExecRestrictedSQL(fmt.Sprintf("select 1 from mysql.bind_info where original_sql = %s and default_db = %s and status = %s",
+		quote(originalSQL), quote(db), quote(Using)))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	record := &BindRecord{
+		OriginalSQL: originalSQL,
+		Db:          db,
+		Status:      deleted,
+	}
+	record.CreateTime = time.Now().Format(TimeFormat)
+	record.UpdateTime = record.CreateTime
+	err = h.replaceRecordInStorage(record)
+	if err != nil {
+		return err
+	}
+
+	h.bindInfo.Lock()
+	newCache := h.bindInfo.Value.Load().(cache).copy()
+	newCache.remove(record.OriginalSQL, record.Db)
+	newCache.merge(record)
+	h.bindInfo.Value.Store(newCache)
+	h.bindInfo.Unlock()
+	return nil
+}
+
+// replaceRecordInStorage replaces the records of the statement in
+// mysql.bind_info with the record in a transaction.
+func (h *BindHandle) replaceRecordInStorage(record *BindRecord) (err error) {
+	h.sctx.Lock()
+	defer h.sctx.Unlock()
+	ctx := context.Background()
+	exec := h.sctx.Context.(sqlexec.SQLExecutor)
+	_, err = exec.Execute(ctx, "begin")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if err == nil {
+			_, err = exec.Execute(ctx, "commit")
+		} else {
+			_, err1 := exec.Execute(ctx, "rollback")
+			terror.Log(errors.Trace(err1))
+		}
+		err = errors.Trace(err)
+	}()
+	_, err = exec.Execute(ctx, fmt.Sprintf("delete from mysql.bind_info where original_sql = %s and default_db = %s",
+		quote(record.OriginalSQL), quote(record.Db)))
+	if err != nil {
+		return
+	}
+	_, err = exec.Execute(ctx, fmt.Sprintf("insert into mysql.bind_info values (%s, %s, %s, %s, %s, %s, %s, %s)",
+		quote(record.OriginalSQL), quote(record.BindSQL), quote(record.Db), quote(record.Status),
+		quote(record.CreateTime), quote(record.UpdateTime), quote(record.Charset), quote(record.Collation)))
+	return
+}
+
+// GetBindRecord returns the BindRecord of the (normdOrigSQL,db) if BindRecord exist.
+func (h *BindHandle) GetBindRecord(normdOrigSQL, db string) *BindRecord {
+	return h.bindInfo.Value.Load().(cache).getBindRecord(normdOrigSQL, db)
+}
+
+// GetAllBindRecord returns all bind records in cache.
+func (h *BindHandle) GetAllBindRecord() (bindRecords []*BindRecord) {
+	return h.bindInfo.Value.Load().(cache).allRecords()
+}
+
+func newBindRecord(row chunk.Row) *BindRecord {
+	return &BindRecord{
+		OriginalSQL: row.GetString(0),
+		BindSQL:     row.GetString(1),
+		Db:          row.GetString(2),
+		Status:      row.GetString(3),
+		CreateTime:  row.GetString(4),
+		UpdateTime:  row.GetString(5),
+		Charset:     row.GetString(6),
+		Collation:   row.GetString(7),
+	}
+}
+
+// copy copies the cache, the bind records are shared since they are never
+// changed after put into the cache.
+func (c cache) copy() cache {
+	newCache := make(cache, len(c))
+	for k, v := range c {
+		records := make([]*BindRecord, len(v))
+		copy(records, v)
+		newCache[k] = records
+	}
+	return newCache
+}
+
+// merge puts the record into the cache, it replaces the existing record of
+// the same statement unless it is older, the deleted record removes the
+// existing one. The existing record is kept if it is loaded again, so its
+// usage is not lost.
+func (c cache) merge(record *BindRecord) {
+	oldRecord := c.getBindRecord(record.OriginalSQL, record.Db)
+	if oldRecord != nil {
+		if oldRecord.UpdateTime > record.UpdateTime {
+			return
+		}
+		if oldRecord.UpdateTime == record.UpdateTime && record.isUsing() && oldRecord.BindSQL == record.BindSQL {
+			return
+		}
+	}
+	c.remove(record.OriginalSQL, record.Db)
+	if record.isUsing() {
+		c[record.OriginalSQL] = append(c[record.OriginalSQL], record)
+	}
+}
+
+// remove removes the record of the statement from the cache.
+func (c cache) remove(normdOrigSQL, db string) {
+	records := c[normdOrigSQL]
+	for i, record := range records {
+		if record.Db == db {
+			records = append(records[:i], records[i+1:]...)
+			break
+		}
+	}
+	if len(records) == 0 {
+		delete(c, normdOrigSQL)
+		return
+	}
+	c[normdOrigSQL] = records
+}
+
+func (c cache) getBindRecord(normdOrigSQL, db string) *BindRecord {
+	for _, record := range c[normdOrigSQL] {
+		if record.Db == db {
+			return record
+		}
+	}
+	return nil
+}
+
+func (c cache) allRecords() []*BindRecord {
+	records := make([]*BindRecord, 0, len(c))
+	for _, v := range c {
+		records = append(records, v...)
+	}
+	return records
+}
+
+// quote quotes the string as a SQL string literal.
+func quote(str string) string {
+	var b strings.Builder
+	b.Grow(len(str) + 2)
+	b.WriteByte('\'')
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(str[i])
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb/parser"
+)
+
+// SessionHandle is used to handle all session sql bind operations.
+type SessionHandle struct {
+	ch     cache
+	parser *parser.Parser
+}
+
+// NewSessionBindHandle creates a new SessionBindHandle.
+func NewSessionBindHandle(parser *parser.Parser) *SessionHandle {
+	sessionHandle := &SessionHandle{parser: parser}
+	sessionHandle.ch = make(cache)
+	return sessionHandle
+}
+
+// AddBindRecord new a BindRecord with BindMeta, add it to the cache.
+func (h *SessionHandle) AddBindRecord(record *BindRecord) error {
+	err := record.prepareHints(h.parser)
+	if err != nil {
+		return err
+	}
+	record.Status = Using
+	record.CreateTime = time.Now().Format(TimeFormat)
+	record.UpdateTime = record.CreateTime
+	h.ch.remove(record.OriginalSQL, record.Db)
+	h.ch.merge(record)
+	return nil
+}
+
+// DropBindRecord drops a BindRecord in the cache.
+func (h *SessionHandle) DropBindRecord(originalSQL, db string) {
+	h.ch.remove(originalSQL, db)
+}
+
+// GetBindRecord return the BindRecord of the (normdOrigSQL,db) if BindRecord exist.
+func (h *SessionHandle) GetBindRecord(normdOrigSQL, db string) *BindRecord {
+	return h.ch.getBindRecord(normdOrigSQL, db)
+}
+
+// GetAllBindRecord return all session bind info.
+func (h *SessionHandle) GetAllBindRecord() (bindRecords []*BindRecord) {
+	return h.ch.allRecords()
+}
+
+// sessionBindInfoKeyType is a dummy type to avoid naming collision in context.
+type sessionBindInfoKeyType int
+
+// String defines a Stringer function for debugging and pretty printing.
+func (k sessionBindInfoKeyType) String() string {
+	return "session_bindinfo"
+}
+
+// SessionBindInfoKeyType is a variable key for store session bind info.
+const SessionBindInfoKeyType sessionBindInfoKeyType = 0
@@ -29,6 +29,14 @@ const (
 	DefTxnTotalSizeLimit = 1024 * 1024 * 1024
 )
 
+// The actions taken when the memory usage of a query exceeds tidb_mem_quota_query.
+const (
+	// OOMActionCancel cancels the query.
+	OOMActionCancel = "cancel"
+	// OOMActionLog only logs the query.
+	OOMActionLog = "log"
+)
+
 // Valid config maps
 var (
 	ValidStorage = map[string]bool{
@@ -50,6 +58,8 @@ type Config struct {
 	Store            string `toml:"store" json:"store"`
 	Path             string `toml:"path" json:"path"`
 	Lease            string `toml:"lease" json:"lease"`
+	OOMAction        string `toml:"oom-action" json:"oom-action"`
+	OOMUseTmpStorage bool   `toml:"oom-use-tmp-storage" json:"oom-use-tmp-storage"`
 	Log              Log    `toml:"log" json:"log"`
 	Status           Status `toml:"status" json:"status"`
 }
@@ -60,6 +70,9 @@ type Log struct {
 	Level string `toml:"level" json:"level"`
 	// File log config.
 	File logutil.FileLogConfig `toml:"file" json:"file"`
+
+	SlowQueryFile string `toml:"slow-query-file" json:"slow-query-file"`
+	SlowThreshold uint64 `toml:"slow-threshold" json:"slow-threshold"`
 }
 
 // The ErrConfigValidationFailed error is used so that external callers can do a type assertion
@@ -92,9 +105,13 @@ var defaultConf = Config{
 	Store:            "mocktikv",
 	Path:             "/tmp/tinysql",
 	Lease:            "45s",
+	OOMAction:        OOMActionLog,
+	OOMUseTmpStorage: true,
 	Log: Log{
-		Level: "info",
-		File:  logutil.NewFileLogConfig(logutil.DefaultLogMaxSize),
+		Level:         "info",
+		File:          logutil.NewFileLogConfig(logutil.DefaultLogMaxSize),
+		SlowQueryFile: "tidb-slow.log",
+		SlowThreshold: logutil.DefaultSlowThreshold,
 	},
 	Status: Status{
 		ReportStatus: true,
@@ -144,7 +161,9 @@ func (c *Config) Load(confFile string) error {
 
 // ToLogConfig converts *Log to *logutil.LogConfig.
 func (l *Log) ToLogConfig() *logutil.LogConfig {
-	return logutil.NewLogConfig(l.Level, "test", l.File, false, func(config *zaplog.Config) { config.DisableErrorVerbose = false })
+	logConfig := logutil.NewLogConfig(l.Level, "test", l.File, false, func(config *zaplog.Config) { config.DisableErrorVerbose = false })
+	logConfig.SlowQueryFile = l.SlowQueryFile
+	return logConfig
 }
 
 func init() {
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,6 +32,7 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/owner"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/model"
@@ -40,6 +42,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	tidbutil "github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/admin"
 	"github.com/pingcap/tidb/util/logutil"
 	"go.uber.org/zap"
 )
@@ -87,6 +90,7 @@ var (
 	errTooManyFields            = terror.ClassDDL.New(mysql.ErrTooManyFields, mysql.MySQLErrName[mysql.ErrTooManyFields])
 	errInvalidSplitRegionRanges = terror.ClassDDL.New(mysql.ErrInvalidSplitRegionRanges, mysql.MySQLErrName[mysql.ErrInvalidSplitRegionRanges])
 	errReorgPanic               = terror.ClassDDL.New(mysql.ErrReorgPanic, mysql.MySQLErrName[mysql.ErrReorgPanic])
+	errQueryInterrupted         = terror.ClassDDL.New(mysql.ErrQueryInterrupted, mysql.MySQLErrName[mysql.ErrQueryInterrupted])
 
 	// errWrongKeyColumn is for table column cannot be indexed.
 	errWrongKeyColumn = terror.ClassDDL.New(mysql.ErrWrongKeyColumn, mysql.MySQLErrName[mysql.ErrWrongKeyColumn])
@@ -418,9 +422,13 @@ func (d *ddl) asyncNotifyWorker(jobTp model.ActionType) {
 	}
 }
 
-func (d *ddl) doDDLJob(ctx sessionctx.Context, job *model.Job) error {
+func (d *ddl) doDDLJob(ctx sessionctx.Context, job *model.Job) (err error) {
+	startTime := time.Now()
+	defer func() {
+		metrics.DDLJobHistogram.WithLabelValues(job.Type.String(), metrics.RetLabel(err)).Observe(time.Since(startTime).Seconds())
+	}()
 	// Get a global job ID and put the DDL job in the queue.
-	err := d.addDDLJob(ctx, job)
+	err = d.addDDLJob(ctx, job)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -440,12 +448,20 @@ func (d *ddl) doDDLJob(ctx sessionctx.Context, job *model.Job) error {
 	defer func() {
 		ticker.Stop()
 	}()
+	sessVars := ctx.GetSessionVars()
+	killed := false
 	for {
 		select {
 		case <-d.ddlJobDoneCh:
 		case <-ticker.C:
 		}
 
+		// If the connection is killed, try to cancel the DDL job and wait for it to be rolled back.
+		if !killed && atomic.LoadUint32(&sessVars.Killed) == 1 {
+			killed = true
+			d.cancelKilledJob(jobID)
+		}
+
 		historyJob, err = d.getHistoryDDLJob(jobID)
 		if err != nil {
 			logutil.BgLogger().Error("[ddl] get history DDL job failed, check again", zap.Error(err))
@@ -462,12 +478,32 @@ func (d *ddl) doDDLJob(ctx sessionctx.Context, job *model.Job) error {
 		}
 
 		if historyJob.Error != nil {
+			if killed && errCancelledDDLJob.Equal(historyJob.Error) {
+				return errQueryInterrupted
+			}
 			return errors.Trace(historyJob.Error)
 		}
 		panic("When the state is JobStateRollbackDone or JobStateCancelled, historyJob.Error should never be nil")
 	}
 }
 
+// cancelKilledJob cancels the DDL job whose connection is killed. A job that
+// can't be rolled back is left to run to the end.
+func (d *ddl) cancelKilledJob(jobID int64) {
+	err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+		errs, err := admin.CancelJobs(txn, []int64{jobID})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(errs[0])
+	})
+	if err != nil {
+		logutil.BgLogger().Warn("[ddl] cancel killed DDL job failed", zap.Int64("jobID", jobID), zap.Error(err))
+		return
+	}
+	logutil.BgLogger().Info("[ddl] cancel killed DDL job", zap.Int64("jobID", jobID))
+}
+
 func (d *ddl) callHookOnChanged(err error) error {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -492,6 +528,7 @@ func init() {
 		mysql.ErrBlobCantHaveDefault:                  mysql.ErrBlobCantHaveDefault,
 		mysql.ErrBlobKeyWithoutLength:                 mysql.ErrBlobKeyWithoutLength,
 		mysql.ErrCancelledDDLJob:                      mysql.ErrCancelledDDLJob,
+		mysql.ErrQueryInterrupted:                     mysql.ErrQueryInterrupted,
 		mysql.ErrCantDecodeIndex:                      mysql.ErrCantDecodeIndex,
 		mysql.ErrCantDropFieldOrKey:                   mysql.ErrCantDropFieldOrKey,
 		mysql.ErrCantRemoveAllFields:                  mysql.ErrCantRemoveAllFields,
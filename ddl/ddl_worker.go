@@ -348,6 +348,7 @@ func (w *worker) handleDDLJobQueue(d *ddlCtx) error {
 
 			// If running job meets error, we will save this error in job Error
 			// and retry later if the job is not cancelled.
+			startTime := time.Now()
 			tidbutil.WithRecovery(func() {
 				schemaVer, runJobErr = w.runDDLJob(d, t, job)
 			}, func(r interface{}) {
@@ -356,6 +357,9 @@ func (w *worker) handleDDLJobQueue(d *ddlCtx) error {
 					job.State = model.JobStateCancelling
 				}
 			})
+			if elapsed := time.Since(startTime); elapsed > time.Duration(atomic.LoadUint32(&variable.DDLSlowOprThreshold))*time.Millisecond {
+				logutil.Logger(w.logCtx).Info("[ddl] run DDL job slowly", zap.Duration("take time", elapsed), zap.String("job", job.String()))
+			}
 			if job.IsCancelled() {
 				txn.Reset()
 				err = w.finishDDLJob(t, job)
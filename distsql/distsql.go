@@ -15,16 +15,21 @@ package distsql
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/tracing"
 )
 
 // Select sends a DAG request, returns SelectResult.
 // In kvReq, KeyRanges is required, Concurrency/KeepOrder/Desc/IsolationLevel/Priority are optional.
 func Select(ctx context.Context, sctx sessionctx.Context, kvReq *kv.Request, fieldTypes []*types.FieldType) (SelectResult, error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "distsql.Select")
+	defer span.Finish()
+
 	// For testing purpose.
 	if hook := ctx.Value("CheckSelectRequestHook"); hook != nil {
 		hook.(func(*kv.Request))(kvReq)
@@ -42,9 +47,27 @@ func Select(ctx context.Context, sctx sessionctx.Context, kvReq *kv.Request, fie
 		rowLen:     len(fieldTypes),
 		fieldTypes: fieldTypes,
 		ctx:        sctx,
+		memTracker: kvReq.MemTracker,
 	}, nil
 }
 
+// SelectWithRuntimeStats sends a DAG request, returns SelectResult. The
+// difference from Select is that the runtime statistics of the coprocessor
+// tasks are collected for the reader executor and the coprocessor executors
+// if the statement is EXPLAIN ANALYZE.
+func SelectWithRuntimeStats(ctx context.Context, sctx sessionctx.Context, kvReq *kv.Request,
+	fieldTypes []*types.FieldType, copPlanIDs []fmt.Stringer, rootPlanID fmt.Stringer) (SelectResult, error) {
+	sr, err := Select(ctx, sctx, kvReq, fieldTypes)
+	if err != nil {
+		return nil, err
+	}
+	if result, ok := sr.(*selectResult); ok {
+		result.copPlanIDs = copPlanIDs
+		result.rootPlanID = rootPlanID
+	}
+	return sr, nil
+}
+
 // Analyze do a analyze request.
 func Analyze(ctx context.Context, client kv.Client, kvReq *kv.Request, vars *kv.Variables) (SelectResult, error) {
 	resp := client.Send(ctx, kvReq, vars)
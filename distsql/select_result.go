@@ -15,6 +15,7 @@ package distsql
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -24,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
@@ -55,11 +57,21 @@ type selectResult struct {
 
 	fetchDuration    time.Duration
 	durationReported bool
+	memTracker       *memory.Tracker
+
+	// copPlanIDs are the explain IDs of the coprocessor executors, in the
+	// same order as the execution summaries in the responses. The ID is nil
+	// if the executor is not in the plan.
+	copPlanIDs []fmt.Stringer
+	rootPlanID fmt.Stringer
 }
 
 func (r *selectResult) fetchResp(ctx context.Context) error {
 	for {
 		r.respChkIdx = 0
+		if r.selectResp != nil {
+			r.memConsume(-int64(r.selectRespSize))
+		}
 		startTime := time.Now()
 		resultSubset, err := r.resp.Next(ctx)
 		duration := time.Since(startTime)
@@ -84,6 +96,7 @@ func (r *selectResult) fetchResp(ctx context.Context) error {
 			return errors.Trace(err)
 		}
 		r.selectRespSize = r.selectResp.Size()
+		r.memConsume(int64(r.selectRespSize))
 		if err := r.selectResp.Error; err != nil {
 			return terror.ClassTiKV.New(terror.ErrCode(err.Code), err.Msg)
 		}
@@ -91,6 +104,8 @@ func (r *selectResult) fetchResp(ctx context.Context) error {
 		for _, warning := range r.selectResp.Warnings {
 			sc.AppendWarning(terror.ClassTiKV.New(terror.ErrCode(warning.Code), warning.Msg))
 		}
+		sc.MergeExecDetails(resultSubset.GetExecDetails())
+		r.updateCopRuntimeStats(resultSubset)
 		r.partialCount++
 		if len(r.selectResp.Chunks) != 0 {
 			break
@@ -99,6 +114,26 @@ func (r *selectResult) fetchResp(ctx context.Context) error {
 	return nil
 }
 
+// updateCopRuntimeStats records the coprocessor task of a response into the
+// runtime statistics collector of the statement.
+func (r *selectResult) updateCopRuntimeStats(resultSubset kv.ResultSubset) {
+	coll := r.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl
+	if coll == nil || r.rootPlanID == nil {
+		return
+	}
+	coll.RecordOneReaderTask(r.rootPlanID.String(), resultSubset.RespTime(), resultSubset.GetExecDetails())
+	// The summaries may be missing if the storage doesn't support them.
+	if len(r.selectResp.ExecutionSummaries) != len(r.copPlanIDs) {
+		return
+	}
+	for i, summary := range r.selectResp.ExecutionSummaries {
+		// The executors which are not in the plan have no IDs.
+		if summary != nil && r.copPlanIDs[i] != nil {
+			coll.RecordOneCopTask(r.copPlanIDs[i].String(), summary)
+		}
+	}
+}
+
 func (r *selectResult) Next(ctx context.Context, chk *chunk.Chunk) error {
 	chk.Reset()
 	if r.selectResp == nil || r.respChkIdx == len(r.selectResp.Chunks) {
@@ -153,7 +188,17 @@ func (r *selectResult) readRowsData(chk *chunk.Chunk) (err error) {
 	return nil
 }
 
+func (r *selectResult) memConsume(bytes int64) {
+	if r.memTracker != nil {
+		r.memTracker.Consume(bytes)
+	}
+}
+
 // Close closes selectResult.
 func (r *selectResult) Close() error {
+	if r.selectResp != nil {
+		r.memConsume(-int64(r.selectRespSize))
+		r.selectResp = nil
+	}
 	return r.resp.Close()
 }
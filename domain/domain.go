@@ -24,6 +24,7 @@ import (
 	"github.com/ngaut/pools"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/tidb/bindinfo"
 	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
@@ -50,6 +51,7 @@ type Domain struct {
 	infoHandle      *infoschema.Handle
 	statsHandle     unsafe.Pointer
 	statsLease      time.Duration
+	bindHandle      *bindinfo.BindHandle
 	ddl             ddl.DDL
 	m               sync.Mutex
 	SchemaValidator SchemaValidator
@@ -651,6 +653,8 @@ func (do *Domain) loadStatsWorker() {
 	}
 	loadTicker := time.NewTicker(lease)
 	defer loadTicker.Stop()
+	dumpIndexUsageTicker := time.NewTicker(10 * lease)
+	defer dumpIndexUsageTicker.Stop()
 	statsHandle := do.StatsHandle()
 	for {
 		select {
@@ -659,6 +663,96 @@ func (do *Domain) loadStatsWorker() {
 			if err != nil {
 				logutil.BgLogger().Debug("update stats info failed", zap.Error(err))
 			}
+		case <-dumpIndexUsageTicker.C:
+			err := statsHandle.DumpIndexUsageToKV()
+			if err != nil {
+				logutil.BgLogger().Warn("dump index usage failed", zap.Error(err))
+			}
+		case <-do.exit:
+			return
+		}
+	}
+}
+
+// BindHandle returns domain's bindHandle.
+func (do *Domain) BindHandle() *bindinfo.BindHandle {
+	return do.bindHandle
+}
+
+// LoadBindInfoLoop creates a goroutine loads BindInfo in a loop, a goroutine
+// captures the baselines in ctxForCapture in a loop, and a goroutine evolves
+// the baselines in ctxForEvolve in a loop. It should be called only once in
+// BootstrapSession.
+func (do *Domain) LoadBindInfoLoop(ctxForHandle, ctxForCapture, ctxForEvolve sessionctx.Context) error {
+	ctxForHandle.GetSessionVars().InRestrictedSQL = true
+	ctxForCapture.GetSessionVars().InRestrictedSQL = true
+	ctxForEvolve.GetSessionVars().InRestrictedSQL = true
+	do.bindHandle = bindinfo.NewBindHandle(ctxForHandle)
+	err := do.bindHandle.Update(true)
+	if err != nil || bindinfo.Lease == 0 {
+		return err
+	}
+
+	do.wg.Add(3)
+	go do.loadBindInfoWorker()
+	go do.captureBaselinesWorker(ctxForCapture)
+	go do.evolveBaselinesWorker(ctxForEvolve)
+	return nil
+}
+
+func (do *Domain) loadBindInfoWorker() {
+	defer recoverInDomain("loadBindInfoWorker", false)
+	defer do.wg.Done()
+	ticker := time.NewTicker(bindinfo.Lease)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := do.bindHandle.Update(false)
+			if err != nil {
+				logutil.BgLogger().Error("update bindinfo failed", zap.Error(err))
+			}
+		case <-do.exit:
+			return
+		}
+	}
+}
+
+func (do *Domain) captureBaselinesWorker(ctxForCapture sessionctx.Context) {
+	defer recoverInDomain("captureBaselinesWorker", false)
+	defer do.wg.Done()
+	ticker := time.NewTicker(bindinfo.Lease)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			do.bindHandle.CaptureBaselines(ctxForCapture)
+		case <-do.exit:
+			return
+		}
+	}
+}
+
+// evolveBaselinesWorker evolves the baselines. It runs apart from the other
+// workers of the bindings, since verifying the plans may take a long time.
+// The ticks missed during a verification are dropped by the ticker.
+func (do *Domain) evolveBaselinesWorker(ctxForEvolve sessionctx.Context) {
+	defer recoverInDomain("evolveBaselinesWorker", false)
+	defer do.wg.Done()
+	ticker := time.NewTicker(bindinfo.Lease)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Only the owner evolves the baselines, so a binding is not
+			// verified by multiple servers at the same time.
+			if !do.ddl.OwnerManager().IsOwner() {
+				continue
+			}
+			err := do.bindHandle.HandleEvolvePlanTask(ctxForEvolve)
+			if err != nil {
+				logutil.BgLogger().Error("evolve plan baselines failed", zap.Error(err))
+			}
 		case <-do.exit:
 			return
 		}
@@ -695,4 +789,27 @@ func init() {
 		mysql.ErrInfoSchemaChanged: mysql.ErrInfoSchemaChanged,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassDomain] = domainMySQLErrCodes
+	infoschema.GetIndexUsage = getIndexUsage
+}
+
+// getIndexUsage returns the index usage collected by the statistics handle of
+// the domain of ctx.
+func getIndexUsage(ctx sessionctx.Context) (map[infoschema.IndexUsageID]infoschema.IndexUsage, error) {
+	dom := GetDomain(ctx)
+	if dom == nil || dom.StatsHandle() == nil {
+		return nil, nil
+	}
+	usage, err := dom.StatsHandle().GetIndexUsage()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[infoschema.IndexUsageID]infoschema.IndexUsage, len(usage))
+	for id, info := range usage {
+		result[infoschema.IndexUsageID{TableID: id.TableID, IndexID: id.IndexID}] = infoschema.IndexUsage{
+			QueryCount:   info.QueryCount,
+			RowsSelected: info.RowsSelected,
+			LastUsedAt:   info.LastUsedAt,
+		}
+	}
+	return result, nil
 }
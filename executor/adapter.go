@@ -17,21 +17,29 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/metrics"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/model"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/stmtsummary"
 	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tidb/util/tracing"
 	"go.uber.org/zap"
 )
 
@@ -42,6 +50,8 @@ type recordSet struct {
 	stmt       *ExecStmt
 	lastErr    error
 	txnStartTS uint64
+	// maxExecTimer kills the statement when it runs longer than max_execution_time.
+	maxExecTimer *time.Timer
 }
 
 func (a *recordSet) Fields() []*ast.ResultField {
@@ -124,7 +134,11 @@ func (a *recordSet) NewChunk() *chunk.Chunk {
 }
 
 func (a *recordSet) Close() error {
+	if a.maxExecTimer != nil {
+		a.maxExecTimer.Stop()
+	}
 	err := a.executor.Close()
+	a.stmt.FinishExecuteStmt(a.txnStartTS, a.lastErr == nil)
 	sessVars := a.stmt.Ctx.GetSessionVars()
 	sessVars.PrevStmt = FormatSQL(a.stmt.OriginText())
 	return err
@@ -148,6 +162,9 @@ type ExecStmt struct {
 
 	// OutputNames will be set if using cached plan
 	OutputNames []*types.FieldName
+
+	normalizedSQL string
+	digest        string
 }
 
 // OriginText returns original statement as a string.
@@ -182,30 +199,68 @@ func (a *ExecStmt) Exec(ctx context.Context) (_ sqlexec.RecordSet, err error) {
 		return nil, err
 	}
 
-	if err = e.Open(ctx); err != nil {
+	maxExecTimer := a.startMaxExecTimer()
+	defer func() {
+		if err != nil && maxExecTimer != nil {
+			maxExecTimer.Stop()
+		}
+	}()
+
+	span, openCtx := tracing.ChildSpanFromContext(ctx, "executor.Open")
+	err = e.Open(openCtx)
+	span.Finish()
+	if err != nil {
 		terror.Call(e.Close)
 		return nil, err
 	}
 
 	if handled, result, err := a.handleNoDelay(ctx, e); handled {
+		if maxExecTimer != nil {
+			maxExecTimer.Stop()
+		}
 		return result, err
 	}
 
 	var txnStartTS uint64
 	txn, err := sctx.Txn(false)
 	if err != nil {
+		terror.Call(e.Close)
 		return nil, err
 	}
 	if txn.Valid() {
 		txnStartTS = txn.StartTS()
 	}
 	return &recordSet{
-		executor:   e,
-		stmt:       a,
-		txnStartTS: txnStartTS,
+		executor:     e,
+		stmt:         a,
+		txnStartTS:   txnStartTS,
+		maxExecTimer: maxExecTimer,
 	}, nil
 }
 
+// startMaxExecTimer starts a timer to kill the statement when it runs longer than
+// the MAX_EXECUTION_TIME hint or the max_execution_time variable. Like MySQL, the
+// timeout only applies to SELECT statements, nil is returned if there's no timeout.
+func (a *ExecStmt) startMaxExecTimer() *time.Timer {
+	if _, ok := a.StmtNode.(*ast.SelectStmt); !ok {
+		return nil
+	}
+	sessVars := a.Ctx.GetSessionVars()
+	maxExecutionTime := sessVars.MaxExecutionTime
+	if sessVars.StmtCtx.HasMaxExecutionTime {
+		maxExecutionTime = sessVars.StmtCtx.MaxExecutionTime
+	}
+	if maxExecutionTime == 0 {
+		return nil
+	}
+	connID := sessVars.ConnectionID
+	return time.AfterFunc(time.Duration(maxExecutionTime)*time.Millisecond, func() {
+		logutil.BgLogger().Info("kill the statement for exceeding max_execution_time",
+			zap.Uint64("connID", connID), zap.Uint64("maxExecutionTime", maxExecutionTime))
+		atomic.CompareAndSwapUint32(&sessVars.Killed, 0, 1)
+	})
+}
+
 func (a *ExecStmt) handleNoDelay(ctx context.Context, e Executor) (bool, sqlexec.RecordSet, error) {
 	toCheck := e
 
@@ -244,6 +299,104 @@ func (a *ExecStmt) buildExecutor() (Executor, error) {
 	return e, nil
 }
 
+// LogSlowQuery is used to print the slow query in the log files.
+func (a *ExecStmt) LogSlowQuery(txnTS uint64, succ bool) {
+	sessVars := a.Ctx.GetSessionVars()
+	costTime := time.Since(sessVars.StartTime)
+	threshold := time.Duration(atomic.LoadUint64(&config.GetGlobalConfig().Log.SlowThreshold)) * time.Millisecond
+	if costTime < threshold {
+		return
+	}
+	sql := FormatSQL(a.Text).String()
+	_, digest := a.getSQLDigest()
+
+	sc := sessVars.StmtCtx
+	var indexNames string
+	if len(sc.IndexNames) > 0 {
+		indexNames = "[" + strings.Join(sc.IndexNames, ",") + "]"
+	}
+	var memMax int64
+	if sc.MemTracker != nil {
+		memMax = sc.MemTracker.MaxConsumed()
+	}
+	var planStr string
+	if encoded := plannercore.EncodePlan(a.Plan); len(encoded) > 0 {
+		planStr = "tidb_decode_plan('" + encoded + "')"
+	}
+	slowItems := &variable.SlowQueryLogItems{
+		TxnTS:       txnTS,
+		SQL:         sql,
+		Digest:      digest,
+		TimeTotal:   costTime,
+		TimeParse:   sessVars.DurationParse,
+		TimeCompile: sessVars.DurationCompile,
+		IndexNames:  indexNames,
+		ExecDetail:  sc.GetExecDetails(),
+		CopTasks:    sc.CopTasksDetails(),
+		MemMax:      memMax,
+		Succ:        succ,
+		Plan:        planStr,
+	}
+	logutil.SlowQueryLogger.Warn(sessVars.SlowLogFormat(slowItems))
+}
+
+// FinishExecuteStmt is used to record the slow log and the statement summary
+// after the statement finishes.
+func (a *ExecStmt) FinishExecuteStmt(txnTS uint64, succ bool) {
+	sessVars := a.Ctx.GetSessionVars()
+	sqlType := metrics.LblInternal
+	if !sessVars.InRestrictedSQL {
+		sqlType = GetStmtLabel(a.StmtNode)
+	}
+	metrics.QueryDurationHistogram.WithLabelValues(sqlType).Observe(time.Since(sessVars.StartTime).Seconds())
+	a.LogSlowQuery(txnTS, succ)
+	a.SummaryStmt(succ)
+}
+
+// SummaryStmt collects statements for information_schema.statements_summary.
+func (a *ExecStmt) SummaryStmt(succ bool) {
+	if !stmtsummary.StmtSummaryByDigestMap.Enabled() {
+		return
+	}
+	sessVars := a.Ctx.GetSessionVars()
+	sc := sessVars.StmtCtx
+	normalizedSQL, digest := a.getSQLDigest()
+	_, planDigest := plannercore.NormalizePlan(a.Plan)
+	var memMax int64
+	if sc.MemTracker != nil {
+		memMax = sc.MemTracker.MaxConsumed()
+	}
+	execDetail := sc.GetExecDetails()
+	stmtsummary.StmtSummaryByDigestMap.AddStatement(&stmtsummary.StmtExecInfo{
+		SchemaName:     strings.ToLower(sessVars.CurrentDB),
+		OriginalSQL:    FormatSQL(a.Text).String(),
+		NormalizedSQL:  normalizedSQL,
+		Digest:         digest,
+		PlanDigest:     planDigest,
+		EncodedPlan:    plannercore.EncodePlan(a.Plan),
+		StmtType:       GetStmtLabel(a.StmtNode),
+		User:           sessVars.User,
+		TotalLatency:   time.Since(sessVars.StartTime),
+		ParseLatency:   sessVars.DurationParse,
+		CompileLatency: sessVars.DurationCompile,
+		StmtCtx:        sc,
+		CopTasks:       sc.CopTasksDetails(),
+		ExecDetail:     &execDetail,
+		MemMax:         memMax,
+		StartTime:      sessVars.StartTime,
+		Succeed:        succ,
+	})
+}
+
+// getSQLDigest returns the normalized SQL and its digest, they are computed
+// only once for a statement.
+func (a *ExecStmt) getSQLDigest() (string, string) {
+	if len(a.digest) == 0 {
+		a.normalizedSQL, a.digest = parser.NormalizeDigest(a.Text)
+	}
+	return a.normalizedSQL, a.digest
+}
+
 // QueryReplacer replaces new line and tab for grep result including query string.
 var QueryReplacer = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ")
 
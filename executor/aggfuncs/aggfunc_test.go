@@ -116,7 +116,12 @@ func (s *testSuite) testMergePartialResult(c *C, p aggTest) {
 	result, err = dt.CompareDatum(s.ctx.GetSessionVars().StmtCtx, &p.results[1])
 	c.Assert(err, IsNil)
 	c.Assert(result, Equals, 0)
-	err = finalFunc.MergePartialResult(s.ctx, partialResult, finalPr)
+	// the partial result spilled by the partial func can be restored by the final func.
+	restored, remained, err := finalFunc.DeserializePartialResult(partialFunc.SerializePartialResult(partialResult, nil))
+	c.Assert(err, IsNil)
+	c.Assert(remained, HasLen, 0)
+	c.Assert(finalFunc.PartialResultMemUsage(restored), Equals, partialFunc.PartialResultMemUsage(partialResult))
+	err = finalFunc.MergePartialResult(s.ctx, restored, finalPr)
 	c.Assert(err, IsNil)
 
 	resultChk.Reset()
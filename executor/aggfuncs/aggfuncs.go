@@ -94,6 +94,24 @@ type AggFunc interface {
 	// partial result and then calculates the final result and append that
 	// final result to the chunk provided.
 	AppendFinalResult2Chunk(sctx sessionctx.Context, pr PartialResult, chk *chunk.Chunk) error
+
+	// SerializePartialResult encodes the partial result and appends it to
+	// buf. It is used by the aggregate operators to spill partial results
+	// to disk when they run out of memory. The partial and the final
+	// functions of one aggregation share the same encoding, so a partial
+	// result serialized by a partial function can be deserialized by the
+	// corresponding final function.
+	SerializePartialResult(pr PartialResult, buf []byte) []byte
+
+	// DeserializePartialResult decodes a partial result encoded by
+	// SerializePartialResult from the head of buf, and returns the decoded
+	// partial result together with the remaining bytes.
+	DeserializePartialResult(buf []byte) (PartialResult, []byte, error)
+
+	// PartialResultMemUsage returns the memory usage of the partial result in
+	// bytes, including the variable-length data referenced by it. It is used
+	// by the aggregate operators to track the memory usage of the groups.
+	PartialResultMemUsage(pr PartialResult) int64
 }
 
 type baseAggFunc struct {
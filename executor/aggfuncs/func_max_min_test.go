@@ -15,9 +15,13 @@ package aggfuncs_test
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/executor/aggfuncs"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/expression/aggregation"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
 )
 
 func (s *testSuite) TestMergePartialResult4MaxMin(c *C) {
@@ -61,3 +65,22 @@ func (s *testSuite) TestMaxMin(c *C) {
 		s.testAggFunc(c, test)
 	}
 }
+
+func (s *testSuite) TestMaxMinMemUsage(c *C) {
+	args := []expression.Expression{&expression.Column{RetType: types.NewFieldType(mysql.TypeString), Index: 0}}
+	desc, err := aggregation.NewAggFuncDesc(s.ctx, ast.AggFuncMax, args)
+	c.Assert(err, IsNil)
+	maxFunc := aggfuncs.Build(s.ctx, desc, 0)
+	pr := maxFunc.AllocPartialResult()
+	emptyUsage := maxFunc.PartialResultMemUsage(pr)
+	c.Assert(emptyUsage > 0, IsTrue)
+
+	// The memory usage grows with the length of the max value.
+	chk := chunk.NewChunkWithCapacity([]*types.FieldType{args[0].GetType()}, 2)
+	chk.AppendString(0, "a")
+	chk.AppendString(0, "bbbb")
+	c.Assert(maxFunc.UpdatePartialResult(s.ctx, []chunk.Row{chk.GetRow(0)}, pr), IsNil)
+	c.Assert(maxFunc.PartialResultMemUsage(pr), Equals, emptyUsage+1)
+	c.Assert(maxFunc.UpdatePartialResult(s.ctx, []chunk.Row{chk.GetRow(1)}, pr), IsNil)
+	c.Assert(maxFunc.PartialResultMemUsage(pr), Equals, emptyUsage+4)
+}
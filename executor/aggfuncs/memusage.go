@@ -0,0 +1,97 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggfuncs
+
+import (
+	"unsafe"
+)
+
+// This file contains the PartialResultMemUsage implementations of all the
+// AggFuncs. The memory usage of a partial result is the size of its data
+// structure, plus the size of the variable-length data it references.
+
+const (
+	sizePartialResult4Count           = int64(unsafe.Sizeof(partialResult4Count(0)))
+	sizePartialResult4Int64           = int64(unsafe.Sizeof(partialResult4Int64{}))
+	sizePartialResult4SumFloat64      = int64(unsafe.Sizeof(partialResult4SumFloat64{}))
+	sizePartialResult4AvgInt64        = int64(unsafe.Sizeof(partialResult4AvgInt64{}))
+	sizePartialResult4AvgFloat64      = int64(unsafe.Sizeof(partialResult4AvgFloat64{}))
+	sizePartialResult4FirstRowInt     = int64(unsafe.Sizeof(partialResult4FirstRowInt{}))
+	sizePartialResult4FirstRowFloat32 = int64(unsafe.Sizeof(partialResult4FirstRowFloat32{}))
+	sizePartialResult4FirstRowFloat64 = int64(unsafe.Sizeof(partialResult4FirstRowFloat64{}))
+	sizePartialResult4FirstRowString  = int64(unsafe.Sizeof(partialResult4FirstRowString{}))
+	sizePartialResult4MaxMinInt       = int64(unsafe.Sizeof(partialResult4MaxMinInt{}))
+	sizePartialResult4MaxMinUint      = int64(unsafe.Sizeof(partialResult4MaxMinUint{}))
+	sizePartialResult4MaxMinFloat32   = int64(unsafe.Sizeof(partialResult4MaxMinFloat32{}))
+	sizePartialResult4MaxMinFloat64   = int64(unsafe.Sizeof(partialResult4MaxMinFloat64{}))
+	sizePartialResult4MaxMinString    = int64(unsafe.Sizeof(partialResult4MaxMinString{}))
+)
+
+func (e *baseCount) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4Count
+}
+
+func (e *sum4Int64) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4Int64
+}
+
+func (e *sum4Float64) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4SumFloat64
+}
+
+func (e *baseAvgInt64) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4AvgInt64
+}
+
+func (e *baseAvgFloat64) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4AvgFloat64
+}
+
+func (e *firstRow4Int) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4FirstRowInt
+}
+
+func (e *firstRow4Float32) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4FirstRowFloat32
+}
+
+func (e *firstRow4Float64) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4FirstRowFloat64
+}
+
+func (e *firstRow4String) PartialResultMemUsage(pr PartialResult) int64 {
+	p := (*partialResult4FirstRowString)(pr)
+	return sizePartialResult4FirstRowString + int64(len(p.val))
+}
+
+func (e *maxMin4Int) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4MaxMinInt
+}
+
+func (e *maxMin4Uint) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4MaxMinUint
+}
+
+func (e *maxMin4Float32) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4MaxMinFloat32
+}
+
+func (e *maxMin4Float64) PartialResultMemUsage(pr PartialResult) int64 {
+	return sizePartialResult4MaxMinFloat64
+}
+
+func (e *maxMin4String) PartialResultMemUsage(pr PartialResult) int64 {
+	p := (*partialResult4MaxMinString)(pr)
+	return sizePartialResult4MaxMinString + int64(len(p.val))
+}
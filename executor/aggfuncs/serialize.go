@@ -0,0 +1,337 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggfuncs
+
+import (
+	"math"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// This file contains the SerializePartialResult and DeserializePartialResult
+// implementations of all the AggFuncs. The encoded partial results are only
+// written to the temporary spill files of one query, so the encoding doesn't
+// need to be compatible among versions.
+
+func encodeBool(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+func decodeBool(buf []byte) ([]byte, bool, error) {
+	if len(buf) < 1 {
+		return nil, false, errors.New("insufficient bytes to decode value")
+	}
+	return buf[1:], buf[0] != 0, nil
+}
+
+func encodeFloat32(buf []byte, v float32) []byte {
+	return codec.EncodeUint(buf, uint64(math.Float32bits(v)))
+}
+
+func decodeFloat32(buf []byte) ([]byte, float32, error) {
+	buf, u, err := codec.DecodeUint(buf)
+	return buf, math.Float32frombits(uint32(u)), err
+}
+
+func encodeString(buf []byte, v string) []byte {
+	return codec.EncodeCompactBytes(buf, []byte(v))
+}
+
+func decodeString(buf []byte) ([]byte, string, error) {
+	buf, b, err := codec.DecodeCompactBytes(buf)
+	return buf, string(b), err
+}
+
+func (e *baseCount) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4Count)(pr)
+	return codec.EncodeInt(buf, *p)
+}
+
+func (e *baseCount) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4Count)(pr)
+	buf, v, err := codec.DecodeInt(buf)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	*p = v
+	return pr, buf, nil
+}
+
+func (e *sum4Int64) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4Int64)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return codec.EncodeInt(buf, p.val)
+}
+
+func (e *sum4Int64) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4Int64)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = codec.DecodeInt(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *sum4Float64) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4SumFloat64)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return codec.EncodeFloat(buf, p.val)
+}
+
+func (e *sum4Float64) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4SumFloat64)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = codec.DecodeFloat(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *baseAvgInt64) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4AvgInt64)(pr)
+	buf = codec.EncodeInt(buf, p.sum)
+	return codec.EncodeInt(buf, p.count)
+}
+
+func (e *baseAvgInt64) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4AvgInt64)(pr)
+	var err error
+	if buf, p.sum, err = codec.DecodeInt(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.count, err = codec.DecodeInt(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *baseAvgFloat64) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4AvgFloat64)(pr)
+	buf = codec.EncodeFloat(buf, p.sum)
+	return codec.EncodeInt(buf, p.count)
+}
+
+func (e *baseAvgFloat64) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4AvgFloat64)(pr)
+	var err error
+	if buf, p.sum, err = codec.DecodeFloat(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.count, err = codec.DecodeInt(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func serializeFirstRowBase(p *basePartialResult4FirstRow, buf []byte) []byte {
+	buf = encodeBool(buf, p.isNull)
+	return encodeBool(buf, p.gotFirstRow)
+}
+
+func deserializeFirstRowBase(p *basePartialResult4FirstRow, buf []byte) ([]byte, error) {
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if buf, p.gotFirstRow, err = decodeBool(buf); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf, nil
+}
+
+func (e *firstRow4Int) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4FirstRowInt)(pr)
+	buf = serializeFirstRowBase(&p.basePartialResult4FirstRow, buf)
+	return codec.EncodeInt(buf, p.val)
+}
+
+func (e *firstRow4Int) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4FirstRowInt)(pr)
+	var err error
+	if buf, err = deserializeFirstRowBase(&p.basePartialResult4FirstRow, buf); err != nil {
+		return nil, nil, err
+	}
+	if buf, p.val, err = codec.DecodeInt(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *firstRow4Float32) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4FirstRowFloat32)(pr)
+	buf = serializeFirstRowBase(&p.basePartialResult4FirstRow, buf)
+	return encodeFloat32(buf, p.val)
+}
+
+func (e *firstRow4Float32) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4FirstRowFloat32)(pr)
+	var err error
+	if buf, err = deserializeFirstRowBase(&p.basePartialResult4FirstRow, buf); err != nil {
+		return nil, nil, err
+	}
+	if buf, p.val, err = decodeFloat32(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *firstRow4Float64) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4FirstRowFloat64)(pr)
+	buf = serializeFirstRowBase(&p.basePartialResult4FirstRow, buf)
+	return codec.EncodeFloat(buf, p.val)
+}
+
+func (e *firstRow4Float64) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4FirstRowFloat64)(pr)
+	var err error
+	if buf, err = deserializeFirstRowBase(&p.basePartialResult4FirstRow, buf); err != nil {
+		return nil, nil, err
+	}
+	if buf, p.val, err = codec.DecodeFloat(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *firstRow4String) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4FirstRowString)(pr)
+	buf = serializeFirstRowBase(&p.basePartialResult4FirstRow, buf)
+	return encodeString(buf, p.val)
+}
+
+func (e *firstRow4String) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4FirstRowString)(pr)
+	var err error
+	if buf, err = deserializeFirstRowBase(&p.basePartialResult4FirstRow, buf); err != nil {
+		return nil, nil, err
+	}
+	if buf, p.val, err = decodeString(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *maxMin4Int) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4MaxMinInt)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return codec.EncodeInt(buf, p.val)
+}
+
+func (e *maxMin4Int) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4MaxMinInt)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = codec.DecodeInt(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *maxMin4Uint) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4MaxMinUint)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return codec.EncodeUint(buf, p.val)
+}
+
+func (e *maxMin4Uint) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4MaxMinUint)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = codec.DecodeUint(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *maxMin4Float32) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4MaxMinFloat32)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return encodeFloat32(buf, p.val)
+}
+
+func (e *maxMin4Float32) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4MaxMinFloat32)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = decodeFloat32(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *maxMin4Float64) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4MaxMinFloat64)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return codec.EncodeFloat(buf, p.val)
+}
+
+func (e *maxMin4Float64) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4MaxMinFloat64)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = codec.DecodeFloat(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
+
+func (e *maxMin4String) SerializePartialResult(pr PartialResult, buf []byte) []byte {
+	p := (*partialResult4MaxMinString)(pr)
+	buf = encodeBool(buf, p.isNull)
+	return encodeString(buf, p.val)
+}
+
+func (e *maxMin4String) DeserializePartialResult(buf []byte) (PartialResult, []byte, error) {
+	pr := e.AllocPartialResult()
+	p := (*partialResult4MaxMinString)(pr)
+	var err error
+	if buf, p.isNull, err = decodeBool(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if buf, p.val, err = decodeString(buf); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return pr, buf, nil
+}
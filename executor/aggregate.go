@@ -19,6 +19,7 @@ import (
 
 	"github.com/cznic/mathutil"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/executor/aggfuncs"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/parser/mysql"
@@ -28,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/set"
 	"github.com/spaolacci/murmur3"
 	"go.uber.org/zap"
@@ -67,6 +69,13 @@ type HashAggPartialWorker struct {
 	// chk stores the input data from child,
 	// and is reused by childExec and partial worker.
 	chk *chunk.Chunk
+
+	// spiller spills partialResultsMap to disk once memUsage exceeds memQuota,
+	// or the memory usage of the query exceeds its quota.
+	spiller    *hashAggSpiller
+	memUsage   int64
+	memQuota   int64
+	memTracker *memory.Tracker
 }
 
 // HashAggFinalWorker indicates the final workers of parallel hash agg execution,
@@ -82,6 +91,11 @@ type HashAggFinalWorker struct {
 	outputCh            chan *AfFinalResult
 	finalResultHolderCh chan *chunk.Chunk
 	groupKeys           [][]byte
+
+	// idx is the index of this worker, the worker finalizes the spilled
+	// partitions whose index modulo the final concurrency equals to it.
+	idx     int
+	spiller *hashAggSpiller
 }
 
 // AfFinalResult indicates aggregation functions final result.
@@ -148,6 +162,12 @@ type HashAggExec struct {
 	partialWorkers   []HashAggPartialWorker
 	finalWorkers     []HashAggFinalWorker
 	defaultVal       *chunk.Chunk
+	spiller          *hashAggSpiller
+	// spillAction is registered to the memory tracker of the statement once,
+	// and switched to the spiller of the latest execution when reopened.
+	spillAction *hashAggSpillAction
+	memTracker  *memory.Tracker // track memory usage.
+	diskTracker *memory.Tracker // track disk usage of the spilled partial results.
 
 	// isChildReturnEmpty indicates whether the child executor only returns an empty input.
 	isChildReturnEmpty bool
@@ -207,7 +227,16 @@ func (e *HashAggExec) Close() error {
 	}
 	for range e.finalOutputCh {
 	}
+	e.spiller.close()
 	e.executed = false
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
+	if e.diskTracker != nil {
+		e.diskTracker.Detach()
+		e.diskTracker = nil
+	}
 
 	return e.baseExecutor.Close()
 }
@@ -243,6 +272,22 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 
 	e.partialWorkers = make([]HashAggPartialWorker, partialConcurrency)
 	e.finalWorkers = make([]HashAggFinalWorker, finalConcurrency)
+	e.diskTracker = memory.NewTracker(e.id, -1)
+	if sessionVars.StmtCtx.DiskTracker != nil {
+		e.diskTracker.AttachTo(sessionVars.StmtCtx.DiskTracker)
+	}
+	e.spiller = newHashAggSpiller(finalConcurrency, e.diskTracker)
+	memQuota := sessionVars.MemQuotaHashAgg / int64(partialConcurrency)
+	e.memTracker = memory.NewTracker(e.id, -1)
+	e.memTracker.AttachTo(sessionVars.StmtCtx.MemTracker)
+	if config.GetGlobalConfig().OOMUseTmpStorage {
+		if e.spillAction == nil {
+			e.spillAction = &hashAggSpillAction{spiller: e.spiller}
+			sessionVars.StmtCtx.MemTracker.FallbackOldAndSetNewAction(e.spillAction)
+		} else {
+			e.spillAction.setSpiller(e.spiller)
+		}
+	}
 
 	// Init partial workers.
 	for i := 0; i < partialConcurrency; i++ {
@@ -256,6 +301,9 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 			groupByItems:      e.GroupByItems,
 			chk:               newFirstChunk(e.children[0]),
 			groupKey:          make([][]byte, 0, 8),
+			spiller:           e.spiller,
+			memQuota:          memQuota,
+			memTracker:        e.memTracker,
 		}
 
 		e.partialWorkers[i] = w
@@ -277,6 +325,8 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 			rowBuffer:           make([]types.Datum, 0, e.Schema().Len()),
 			mutableRow:          chunk.MutRowFromTypes(retTypes(e)),
 			groupKeys:           make([][]byte, 0, 8),
+			idx:                 i,
+			spiller:             e.spiller,
 		}
 		e.finalWorkers[i].finalResultHolderCh <- newFirstChunk(e)
 	}
@@ -312,7 +362,16 @@ func (w *HashAggPartialWorker) run(ctx sessionctx.Context, waitGroup *sync.WaitG
 			recoveryHashAgg(w.globalOutputCh, r)
 		}
 		if needShuffle {
-			w.shuffleIntermData(sc, finalConcurrency)
+			// Once any partial worker has spilled, the remaining partial results
+			// are spilled too, so that most groups can be finalized partition by
+			// partition instead of being held in memory all together.
+			if w.spiller.isSpilled() {
+				if err := w.spillPartialResults(); err != nil {
+					w.globalOutputCh <- &AfFinalResult{err: err}
+				}
+			} else {
+				w.shuffleIntermData(sc, finalConcurrency)
+			}
 		}
 		waitGroup.Done()
 	}()
@@ -324,6 +383,12 @@ func (w *HashAggPartialWorker) run(ctx sessionctx.Context, waitGroup *sync.WaitG
 			w.globalOutputCh <- &AfFinalResult{err: err}
 			return
 		}
+		if w.memUsage > w.memQuota || w.spiller.isTriggered() {
+			if err := w.spillPartialResults(); err != nil {
+				w.globalOutputCh <- &AfFinalResult{err: err}
+				return
+			}
+		}
 		// The intermData can be promised to be not empty if reaching here,
 		// so we set needShuffle to be true.
 		needShuffle = true
@@ -336,17 +401,40 @@ func (w *HashAggPartialWorker) updatePartialResult(ctx sessionctx.Context, sc *s
 		return err
 	}
 
-	partialResults := w.getPartialResult(sc, w.groupKey, w.partialResultsMap)
+	partialResults, memDelta := w.getPartialResult(sc, w.groupKey, w.partialResultsMap)
 	numRows := chk.NumRows()
 	rows := make([]chunk.Row, 1)
 	for i := 0; i < numRows; i++ {
 		for j, af := range w.aggFuncs {
 			rows[0] = chk.GetRow(i)
+			// The partial results of variable-length values may grow or shrink.
+			memUsage := af.PartialResultMemUsage(partialResults[i][j])
 			if err = af.UpdatePartialResult(ctx, rows, partialResults[i][j]); err != nil {
-				return err
+				break
 			}
+			memDelta += af.PartialResultMemUsage(partialResults[i][j]) - memUsage
 		}
+		if err != nil {
+			break
+		}
+	}
+	w.memUsage += memDelta
+	w.memTracker.Consume(memDelta)
+	return err
+}
+
+// spillPartialResults spills all the partial results of this worker to disk
+// and releases them from memory.
+func (w *HashAggPartialWorker) spillPartialResults() error {
+	if len(w.partialResultsMap) == 0 {
+		return nil
+	}
+	if err := w.spiller.spill(w.partialResultsMap, w.aggFuncs); err != nil {
+		return err
 	}
+	w.partialResultsMap = make(aggPartialResultMapper)
+	w.memTracker.Consume(-w.memUsage)
+	w.memUsage = 0
 	return nil
 }
 
@@ -411,20 +499,27 @@ func getGroupKey(ctx sessionctx.Context, input *chunk.Chunk, groupKey [][]byte,
 	return groupKey, nil
 }
 
-func (w baseHashAggWorker) getPartialResult(sc *stmtctx.StatementContext, groupKey [][]byte, mapper aggPartialResultMapper) [][]aggfuncs.PartialResult {
+// getPartialResult returns the partial results of the group keys, the partial
+// results of the new groups are allocated and put into mapper. The memory
+// usage of the new groups is returned as well.
+func (w baseHashAggWorker) getPartialResult(sc *stmtctx.StatementContext, groupKey [][]byte, mapper aggPartialResultMapper) ([][]aggfuncs.PartialResult, int64) {
 	n := len(groupKey)
 	partialResults := make([][]aggfuncs.PartialResult, n)
+	var memUsage int64
 	for i := 0; i < n; i++ {
 		var ok bool
 		if partialResults[i], ok = mapper[string(groupKey[i])]; ok {
 			continue
 		}
+		memUsage += int64(len(groupKey[i])) + hashAggGroupOverhead
 		for _, af := range w.aggFuncs {
-			partialResults[i] = append(partialResults[i], af.AllocPartialResult())
+			pr := af.AllocPartialResult()
+			partialResults[i] = append(partialResults[i], pr)
+			memUsage += af.PartialResultMemUsage(pr)
 		}
 		mapper[string(groupKey[i])] = partialResults[i]
 	}
-	return partialResults
+	return partialResults, memUsage
 }
 
 func (w *HashAggFinalWorker) getPartialInput() (input *HashAggIntermData, ok bool) {
@@ -462,7 +557,7 @@ func (w *HashAggFinalWorker) consumeIntermData(sctx sessionctx.Context) (err err
 			for i := 0; i < groupKeysLen; i++ {
 				w.groupKeys = append(w.groupKeys, []byte(groupKeys[i]))
 			}
-			finalPartialResults := w.getPartialResult(sc, w.groupKeys, w.partialResultMap)
+			finalPartialResults, _ := w.getPartialResult(sc, w.groupKeys, w.partialResultMap)
 			for i, groupKey := range groupKeys {
 				if !w.groupSet.Exist(groupKey) {
 					w.groupSet.Insert(groupKey)
@@ -483,12 +578,76 @@ func (w *HashAggFinalWorker) getFinalResult(sctx sessionctx.Context) {
 	if finished {
 		return
 	}
+	if w.spiller.isSpilled() {
+		var err error
+		result, finished, err = w.getSpilledFinalResult(sctx, result)
+		if err != nil {
+			w.outputCh <- &AfFinalResult{err: err}
+			return
+		}
+		if finished {
+			return
+		}
+	}
 	w.groupKeys = w.groupKeys[:0]
 	for groupKey := range w.groupSet {
 		w.groupKeys = append(w.groupKeys, []byte(groupKey))
 	}
-	partialResults := w.getPartialResult(sctx.GetSessionVars().StmtCtx, w.groupKeys, w.partialResultMap)
-	for i := 0; i < len(w.groupSet); i++ {
+	partialResults, _ := w.getPartialResult(sctx.GetSessionVars().StmtCtx, w.groupKeys, w.partialResultMap)
+	if result, finished = w.appendFinalResults(sctx, result, partialResults); finished {
+		return
+	}
+	w.outputCh <- &AfFinalResult{chk: result, giveBackCh: w.finalResultHolderCh}
+}
+
+// getSpilledFinalResult finalizes the spilled partitions owned by this worker
+// one at a time. The in-memory partial results of a spilled partition, which
+// were shuffled by the partial workers finished before the first spill, are
+// merged into the partition and removed from groupSet.
+func (w *HashAggFinalWorker) getSpilledFinalResult(sctx sessionctx.Context, result *chunk.Chunk) (_ *chunk.Chunk, finished bool, err error) {
+	inMemKeys := make(map[int][]string)
+	for groupKey := range w.groupSet {
+		idx := w.spiller.partitionIdx(groupKey)
+		inMemKeys[idx] = append(inMemKeys[idx], groupKey)
+	}
+	for idx := w.idx; idx < len(w.spiller.partitions); idx += w.spiller.finalConcurrency {
+		mapper, err := w.spiller.load(sctx, idx, w.aggFuncs)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(mapper) == 0 {
+			continue
+		}
+		for _, groupKey := range inMemKeys[idx] {
+			src := w.partialResultMap[groupKey]
+			if dst, ok := mapper[groupKey]; ok {
+				for j, af := range w.aggFuncs {
+					if err = af.MergePartialResult(sctx, src[j], dst[j]); err != nil {
+						return nil, false, err
+					}
+				}
+			} else {
+				mapper[groupKey] = src
+			}
+			delete(w.partialResultMap, groupKey)
+			delete(w.groupSet, groupKey)
+		}
+		partialResults := make([][]aggfuncs.PartialResult, 0, len(mapper))
+		for _, prs := range mapper {
+			partialResults = append(partialResults, prs)
+		}
+		if result, finished = w.appendFinalResults(sctx, result, partialResults); finished {
+			return nil, true, nil
+		}
+	}
+	return result, false, nil
+}
+
+// appendFinalResults appends the final results of partialResults to result,
+// full chunks are sent to the main thread. It returns the chunk which is not
+// sent yet, and whether the executor is finished.
+func (w *HashAggFinalWorker) appendFinalResults(sctx sessionctx.Context, result *chunk.Chunk, partialResults [][]aggfuncs.PartialResult) (_ *chunk.Chunk, finished bool) {
+	for i := 0; i < len(partialResults); i++ {
 		for j, af := range w.aggFuncs {
 			if err := af.AppendFinalResult2Chunk(sctx, partialResults[i][j], result); err != nil {
 				logutil.BgLogger().Error("HashAggFinalWorker failed to append final result to Chunk", zap.Error(err))
@@ -501,11 +660,11 @@ func (w *HashAggFinalWorker) getFinalResult(sctx sessionctx.Context) {
 			w.outputCh <- &AfFinalResult{chk: result, giveBackCh: w.finalResultHolderCh}
 			result, finished = w.receiveFinalResultHolder()
 			if finished {
-				return
+				return nil, true
 			}
 		}
 	}
-	w.outputCh <- &AfFinalResult{chk: result, giveBackCh: w.finalResultHolderCh}
+	return result, false
 }
 
 func (w *HashAggFinalWorker) receiveFinalResultHolder() (*chunk.Chunk, bool) {
@@ -0,0 +1,309 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/executor/aggfuncs"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/spaolacci/murmur3"
+	"go.uber.org/zap"
+)
+
+const (
+	// hashAggSpillPartitionsPerWorker is the number of spill partitions owned
+	// by every final worker. Finer partitions make a single partition smaller
+	// when it is loaded back into memory.
+	hashAggSpillPartitionsPerWorker = 4
+	// hashAggSpillFlushSize is the size of the buffered records of a partition
+	// which triggers a write to the spill file.
+	hashAggSpillFlushSize = 1 << 20
+	// hashAggSpillReadSize is the buffer size to read a spill file.
+	hashAggSpillReadSize = 1 << 16
+	// hashAggGroupOverhead is the estimated memory usage of a group besides its
+	// group key and partial results, including the map entry and the partial
+	// result slice header.
+	hashAggGroupOverhead = 64
+)
+
+// hashAggSpillPartition is a temporary file which stores the spilled partial
+// results of the group keys belonging to the same hash partition.
+type hashAggSpillPartition struct {
+	sync.Mutex
+
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func (p *hashAggSpillPartition) write(data []byte) (err error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.file == nil {
+		if p.file, err = ioutil.TempFile("", "tidb-hashagg-spill"); err != nil {
+			return errors.Trace(err)
+		}
+		p.writer = bufio.NewWriter(p.file)
+	}
+	_, err = p.writer.Write(data)
+	return errors.Trace(err)
+}
+
+// newReader flushes the buffered records and opens the spill file for
+// reading, it returns nil if nothing is spilled to this partition.
+func (p *hashAggSpillPartition) newReader() (*hashAggSpillReader, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.file == nil {
+		return nil, nil
+	}
+	if err := p.writer.Flush(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	file, err := os.Open(p.file.Name())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &hashAggSpillReader{file: file, reader: bufio.NewReaderSize(file, hashAggSpillReadSize)}, nil
+}
+
+func (p *hashAggSpillPartition) close() {
+	p.Lock()
+	defer p.Unlock()
+	if p.file == nil {
+		return
+	}
+	name := p.file.Name()
+	if err := p.file.Close(); err != nil {
+		logutil.BgLogger().Warn("close hash agg spill file failed", zap.String("file", name), zap.Error(err))
+	}
+	if err := os.Remove(name); err != nil {
+		logutil.BgLogger().Warn("remove hash agg spill file failed", zap.String("file", name), zap.Error(err))
+	}
+	p.file, p.writer = nil, nil
+}
+
+// hashAggSpillReader reads the records of a spill file one by one, so a spill
+// file is never entirely read into memory.
+type hashAggSpillReader struct {
+	file   *os.File
+	reader *bufio.Reader
+}
+
+// next reads the next field encoded by codec.EncodeCompactBytes into buf, it
+// returns io.EOF if there is no more record.
+func (r *hashAggSpillReader) next(buf []byte) ([]byte, error) {
+	n, err := binary.ReadVarint(r.reader)
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, errors.Trace(err)
+	}
+	if n < 0 {
+		return nil, errors.New("invalid length of hash agg spill record")
+	}
+	if int64(cap(buf)) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	if _, err = io.ReadFull(r.reader, buf); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf, nil
+}
+
+func (r *hashAggSpillReader) close() {
+	if err := r.file.Close(); err != nil {
+		logutil.BgLogger().Warn("close hash agg spill file failed", zap.String("file", r.file.Name()), zap.Error(err))
+	}
+}
+
+// hashAggSpiller spills the partial results of HashAggExec to disk. The
+// group keys are hash partitioned in the same way as they are shuffled to the
+// final workers, so the i-th final worker owns the partitions whose index
+// modulo the final concurrency equals to i, and finalizes them one by one.
+type hashAggSpiller struct {
+	spilled uint32
+	// triggered is set by hashAggSpillAction when the memory usage of the
+	// query exceeds its quota, the partial workers spill all their partial
+	// results since then.
+	triggered        uint32
+	finalConcurrency int
+	partitions       []hashAggSpillPartition
+	// diskTracker tracks the size of the spill files.
+	diskTracker *memory.Tracker
+}
+
+func newHashAggSpiller(finalConcurrency int, diskTracker *memory.Tracker) *hashAggSpiller {
+	return &hashAggSpiller{
+		finalConcurrency: finalConcurrency,
+		partitions:       make([]hashAggSpillPartition, finalConcurrency*hashAggSpillPartitionsPerWorker),
+		diskTracker:      diskTracker,
+	}
+}
+
+// isSpilled indicates whether any partial result has been spilled to disk.
+func (s *hashAggSpiller) isSpilled() bool {
+	return atomic.LoadUint32(&s.spilled) == 1
+}
+
+// isTriggered indicates whether the spilling is triggered by the memory
+// tracker of the query.
+func (s *hashAggSpiller) isTriggered() bool {
+	return atomic.LoadUint32(&s.triggered) == 1
+}
+
+// hashAggSpillAction triggers the spilling of a HashAggExec when the memory
+// usage of the query exceeds its quota. If the spilling has been triggered,
+// the fallback action is taken.
+type hashAggSpillAction struct {
+	mu       sync.Mutex
+	spiller  *hashAggSpiller
+	fallback memory.ActionOnExceed
+}
+
+// setSpiller switches the action to the spiller of a reopened HashAggExec.
+func (a *hashAggSpillAction) setSpiller(spiller *hashAggSpiller) {
+	a.mu.Lock()
+	a.spiller = spiller
+	a.mu.Unlock()
+}
+
+// Action implements the memory.ActionOnExceed interface.
+func (a *hashAggSpillAction) Action(t *memory.Tracker) {
+	a.mu.Lock()
+	spiller := a.spiller
+	a.mu.Unlock()
+	if atomic.CompareAndSwapUint32(&spiller.triggered, 0, 1) {
+		logutil.BgLogger().Info("memory exceeds quota, spill hash aggregation partial results to disk",
+			zap.Int64("consumed", t.BytesConsumed()), zap.Int64("quota", t.GetBytesLimit()))
+		return
+	}
+	if a.fallback != nil {
+		a.fallback.Action(t)
+	}
+}
+
+// SetFallback implements the memory.ActionOnExceed interface.
+func (a *hashAggSpillAction) SetFallback(fallback memory.ActionOnExceed) {
+	a.fallback = fallback
+}
+
+func (s *hashAggSpiller) partitionIdx(groupKey string) int {
+	return int(murmur3.Sum32(hack.Slice(groupKey))) % len(s.partitions)
+}
+
+// spill encodes all the partial results in mapper and appends them to the
+// spill files of their partitions. Every record consists of the group key
+// and the serialized partial results of all the aggregate functions.
+func (s *hashAggSpiller) spill(mapper aggPartialResultMapper, aggFuncs []aggfuncs.AggFunc) error {
+	atomic.StoreUint32(&s.spilled, 1)
+	bufs := make([][]byte, len(s.partitions))
+	var payload []byte
+	for groupKey, prs := range mapper {
+		payload = payload[:0]
+		for i, af := range aggFuncs {
+			payload = af.SerializePartialResult(prs[i], payload)
+		}
+		idx := s.partitionIdx(groupKey)
+		bufs[idx] = codec.EncodeCompactBytes(bufs[idx], hack.Slice(groupKey))
+		bufs[idx] = codec.EncodeCompactBytes(bufs[idx], payload)
+		if len(bufs[idx]) >= hashAggSpillFlushSize {
+			if err := s.write(idx, bufs[idx]); err != nil {
+				return err
+			}
+			bufs[idx] = bufs[idx][:0]
+		}
+	}
+	for idx, buf := range bufs {
+		if len(buf) == 0 {
+			continue
+		}
+		if err := s.write(idx, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *hashAggSpiller) write(idx int, data []byte) error {
+	if err := s.partitions[idx].write(data); err != nil {
+		return err
+	}
+	s.diskTracker.Consume(int64(len(data)))
+	return nil
+}
+
+// load reads the spilled partial results of the idx-th partition back into
+// memory, the partial results of the same group key are merged.
+func (s *hashAggSpiller) load(sctx sessionctx.Context, idx int, aggFuncs []aggfuncs.AggFunc) (aggPartialResultMapper, error) {
+	reader, err := s.partitions[idx].newReader()
+	if err != nil || reader == nil {
+		return nil, err
+	}
+	defer reader.close()
+	var groupKey, payload []byte
+	mapper := make(aggPartialResultMapper)
+	for {
+		if groupKey, err = reader.next(groupKey); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if payload, err = reader.next(payload); err != nil {
+			if err == io.EOF {
+				err = errors.New("incomplete hash agg spill record")
+			}
+			return nil, err
+		}
+		prs := make([]aggfuncs.PartialResult, len(aggFuncs))
+		remained := payload
+		for i, af := range aggFuncs {
+			if prs[i], remained, err = af.DeserializePartialResult(remained); err != nil {
+				return nil, err
+			}
+		}
+		dst, ok := mapper[string(groupKey)]
+		if !ok {
+			mapper[string(groupKey)] = prs
+			continue
+		}
+		for i, af := range aggFuncs {
+			if err = af.MergePartialResult(sctx, prs[i], dst[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return mapper, nil
+}
+
+// close closes and removes all the spill files.
+func (s *hashAggSpiller) close() {
+	for i := range s.partitions {
+		s.partitions[i].close()
+	}
+}
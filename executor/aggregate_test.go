@@ -14,6 +14,8 @@
 package executor_test
 
 import (
+	"fmt"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testutil"
@@ -97,3 +99,32 @@ func (s *testSuiteAgg) TestInjectProjBelowTopN(c *C) {
 		tk.MustQuery(tt).Check(testkit.Rows(output[i]...))
 	}
 }
+
+func (s *testSuiteAgg) TestHashAggSpill(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b double, c varchar(20))")
+	for i := 0; i < 200; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (%d, %d, '%d')", i%50, i, i))
+	}
+	sql := "select a, count(*), sum(b), avg(b), max(c), min(c) from t group by a"
+	expected := tk.MustQuery(sql).Sort().Rows()
+	// Every partial worker spills its partial results once it holds a group.
+	tk.MustExec("set @@tidb_mem_quota_hashagg = 1")
+	tk.MustQuery(sql).Sort().Check(expected)
+	tk.MustExec("set @@tidb_hashagg_partial_concurrency = 1")
+	tk.MustExec("set @@tidb_hashagg_final_concurrency = 1")
+	tk.MustQuery(sql).Sort().Check(expected)
+
+	// The spill files larger than the read buffer are read back in pieces.
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1 (a int, c varchar(2000))")
+	for i := 0; i < 400; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t1 values (%d, lpad('%d', 1500, 'x'))", i%300, i))
+	}
+	tk.MustExec("set @@tidb_mem_quota_hashagg = 34359738368")
+	sql = "select a, count(*), max(c) from t1 group by a"
+	expected = tk.MustQuery(sql).Sort().Rows()
+	tk.MustExec("set @@tidb_mem_quota_hashagg = 1")
+	tk.MustQuery(sql).Sort().Check(expected)
+}
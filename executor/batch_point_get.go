@@ -0,0 +1,208 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/parser/model"
+	plannercore "github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+func (b *executorBuilder) buildBatchPointGet(p *plannercore.BatchPointGetPlan) Executor {
+	startTS, err := b.getStartTS()
+	if err != nil {
+		b.err = err
+		return nil
+	}
+	e := &BatchPointGetExec{
+		baseExecutor: newBaseExecutor(b.ctx, p.Schema(), p.ExplainID()),
+		tblInfo:      p.TblInfo,
+		idxInfo:      p.IndexInfo,
+		idxVals:      p.IndexValues,
+		handles:      p.Handles,
+		columns:      p.Columns,
+		startTS:      startTS,
+	}
+	sctx := b.ctx.GetSessionVars().StmtCtx
+	sctx.TableIDs = append(sctx.TableIDs, p.TblInfo.ID)
+	if p.IndexInfo != nil {
+		sctx.IndexNames = append(sctx.IndexNames, p.TblInfo.Name.O+":"+p.IndexInfo.Name.O)
+	}
+	return e
+}
+
+// BatchPointGetExec executes the batch point get plan. It reads the rows by a list of handles, or by
+// a list of unique index values, with kv.Snapshot.BatchGet instead of sending coprocessor requests.
+type BatchPointGetExec struct {
+	baseExecutor
+
+	tblInfo *model.TableInfo
+	idxInfo *model.IndexInfo
+	idxVals [][]types.Datum
+	handles []int64
+	columns []*model.ColumnInfo
+	startTS uint64
+
+	fetched bool
+	// rowHandles and rowValues are the rows found, in the order of the handles or the index values.
+	rowHandles []int64
+	rowValues  [][]byte
+	index      int
+}
+
+// Open implements the Executor interface.
+func (e *BatchPointGetExec) Open(ctx context.Context) error {
+	e.fetched = false
+	e.index = 0
+	return nil
+}
+
+// Close implements the Executor interface.
+func (e *BatchPointGetExec) Close() error {
+	e.rowHandles = nil
+	e.rowValues = nil
+	return nil
+}
+
+// Next implements the Executor interface.
+func (e *BatchPointGetExec) Next(ctx context.Context, req *chunk.Chunk) error {
+	req.Reset()
+	if !e.fetched {
+		if err := e.fetchRows(ctx); err != nil {
+			return err
+		}
+		e.fetched = true
+	}
+	for !req.IsFull() && e.index < len(e.rowValues) {
+		err := decodeRowValToChunk(e.ctx, e.tblInfo, e.columns, e.rowHandles[e.index], e.rowValues[e.index], req)
+		if err != nil {
+			return err
+		}
+		e.index++
+	}
+	return nil
+}
+
+func (e *BatchPointGetExec) fetchRows(ctx context.Context) error {
+	txn, err := e.ctx.Txn(false)
+	if err != nil {
+		return err
+	}
+	if !txn.Valid() {
+		txn = nil
+	}
+	snapshot, err := e.ctx.GetStore().GetSnapshot(kv.Version{Ver: e.startTS})
+	if err != nil {
+		return err
+	}
+
+	handles := e.handles
+	if e.idxInfo != nil {
+		sc := e.ctx.GetSessionVars().StmtCtx
+		idxKeys := make([]kv.Key, 0, len(e.idxVals))
+		for _, idxVals := range e.idxVals {
+			idxKey, err := encodeUniqueIndexKey(sc, e.tblInfo, e.idxInfo, idxVals)
+			if err != nil {
+				return err
+			}
+			idxKeys = append(idxKeys, idxKey)
+		}
+		handleVals, err := batchGetValues(ctx, txn, snapshot, idxKeys)
+		if err != nil {
+			return err
+		}
+		recordIndexUsage(e.ctx, e.tblInfo.ID, e.idxInfo.ID, int64(len(handleVals)))
+		handles = make([]int64, 0, len(idxKeys))
+		for _, idxKey := range idxKeys {
+			handleVal, ok := handleVals[string(idxKey)]
+			if !ok {
+				continue
+			}
+			handle, err := tables.DecodeHandle(handleVal)
+			if err != nil {
+				return err
+			}
+			handles = append(handles, handle)
+		}
+	}
+
+	// The same handle may be listed more than once, but the row is returned only once.
+	rowKeys := make([]kv.Key, 0, len(handles))
+	rowHandles := make([]int64, 0, len(handles))
+	dedup := make(map[int64]struct{}, len(handles))
+	for _, handle := range handles {
+		if _, ok := dedup[handle]; ok {
+			continue
+		}
+		dedup[handle] = struct{}{}
+		rowKeys = append(rowKeys, tablecodec.EncodeRowKeyWithHandle(e.tblInfo.ID, handle))
+		rowHandles = append(rowHandles, handle)
+	}
+	values, err := batchGetValues(ctx, txn, snapshot, rowKeys)
+	if err != nil {
+		return err
+	}
+	e.rowHandles = make([]int64, 0, len(values))
+	e.rowValues = make([][]byte, 0, len(values))
+	for i, rowKey := range rowKeys {
+		val, ok := values[string(rowKey)]
+		if !ok {
+			continue
+		}
+		e.rowHandles = append(e.rowHandles, rowHandles[i])
+		e.rowValues = append(e.rowValues, val)
+	}
+	return nil
+}
+
+// batchGetValues reads the keys from the modifications of the transaction first, and reads the others
+// from the snapshot in one batch. The keys not found or deleted are absent from the result.
+func batchGetValues(ctx context.Context, txn kv.Transaction, snapshot kv.Snapshot, keys []kv.Key) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	snapshotKeys := keys
+	if txn != nil {
+		snapshotKeys = make([]kv.Key, 0, len(keys))
+		memBuffer := txn.GetMemBuffer()
+		for _, key := range keys {
+			val, err := memBuffer.Get(ctx, key)
+			if kv.IsErrNotFound(err) {
+				snapshotKeys = append(snapshotKeys, key)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(val) > 0 {
+				values[string(key)] = val
+			}
+		}
+	}
+	if len(snapshotKeys) == 0 {
+		return values, nil
+	}
+	snapshotValues, err := snapshot.BatchGet(ctx, snapshotKeys)
+	if err != nil {
+		return nil, err
+	}
+	for key, val := range snapshotValues {
+		values[key] = val
+	}
+	return values, nil
+}
@@ -15,10 +15,12 @@ package executor
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"sort"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/cznic/mathutil"
 	"github.com/cznic/sortutil"
@@ -31,12 +33,15 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/admin"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
@@ -74,6 +79,12 @@ func (b *executorBuilder) build(p plannercore.Plan) Executor {
 		return b.buildDelete(v)
 	case *plannercore.Explain:
 		return b.buildExplain(v)
+	case *plannercore.Trace:
+		return b.buildTrace(v)
+	case *plannercore.PlanReplayer:
+		return b.buildPlanReplayer(v)
+	case *plannercore.SQLBindPlan:
+		return b.buildSQLBindExec(v)
 	case *plannercore.Insert:
 		return b.buildInsert(v)
 	case *plannercore.PhysicalLimit:
@@ -98,6 +109,10 @@ func (b *executorBuilder) build(p plannercore.Plan) Executor {
 		return b.buildHashJoin(v)
 	case *plannercore.PhysicalMergeJoin:
 		return b.buildMergeJoin(v)
+	case *plannercore.PhysicalShuffle:
+		return b.buildShuffle(v)
+	case *plannercore.PhysicalShuffleReceiverStub:
+		return b.buildShuffleReceiverStub(v)
 	case *plannercore.PhysicalSelection:
 		return b.buildSelection(v)
 	case *plannercore.PhysicalHashAgg:
@@ -116,6 +131,12 @@ func (b *executorBuilder) build(p plannercore.Plan) Executor {
 		return b.buildIndexReader(v)
 	case *plannercore.PhysicalIndexLookUpReader:
 		return b.buildIndexLookUpReader(v)
+	case *plannercore.PhysicalIndexMergeReader:
+		return b.buildIndexMergeReader(v)
+	case *plannercore.PointGetPlan:
+		return b.buildPointGet(v)
+	case *plannercore.BatchPointGetPlan:
+		return b.buildBatchPointGet(v)
 	default:
 		if mp, ok := p.(MockPhysicalPlan); ok {
 			return mp.GetExecutor()
@@ -283,9 +304,54 @@ func (b *executorBuilder) buildExplain(v *plannercore.Explain) Executor {
 		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ExplainID()),
 		explain:      v,
 	}
+	if v.Analyze {
+		// The collector must be set before building the target executors, so
+		// that they and their coprocessor requests collect runtime statistics.
+		b.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl = execdetails.NewRuntimeStatsColl()
+		explainExec.analyzeExec = b.build(v.TargetPlan)
+	}
 	return explainExec
 }
 
+func (b *executorBuilder) buildTrace(v *plannercore.Trace) Executor {
+	return &TraceExec{
+		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ExplainID()),
+		stmtNode:     v.StmtNode,
+		format:       v.Format,
+	}
+}
+
+func (b *executorBuilder) buildPlanReplayer(v *plannercore.PlanReplayer) Executor {
+	if v.Load {
+		return &PlanReplayerLoadExec{
+			baseExecutor: newBaseExecutor(b.ctx, nil, v.ExplainID()),
+			file:         v.File,
+		}
+	}
+	return &PlanReplayerDumpExec{
+		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ExplainID()),
+		stmtNode:     v.ExecStmt,
+		analyze:      v.Analyze,
+	}
+}
+
+func (b *executorBuilder) buildSQLBindExec(v *plannercore.SQLBindPlan) Executor {
+	base := newBaseExecutor(b.ctx, v.Schema(), v.ExplainID())
+	base.initCap = chunk.ZeroCapacity
+
+	e := &SQLBindExec{
+		baseExecutor: base,
+		sqlBindOp:    v.SQLBindOp,
+		normdOrigSQL: v.NormdOrigSQL,
+		bindSQL:      v.BindSQL,
+		charset:      v.Charset,
+		collation:    v.Collation,
+		db:           v.Db,
+		isGlobal:     v.IsGlobal,
+	}
+	return e
+}
+
 func (b *executorBuilder) buildUnionScanExec(v *plannercore.PhysicalUnionScan) Executor {
 	reader := b.build(v.Children()[0])
 	if b.err != nil {
@@ -428,6 +494,63 @@ func (b *executorBuilder) buildMergeJoin(v *plannercore.PhysicalMergeJoin) Execu
 	return e
 }
 
+func (b *executorBuilder) buildShuffle(v *plannercore.PhysicalShuffle) Executor {
+	dataSourcePlans := v.Tail.Children()
+	dataSources := make([]Executor, 0, len(dataSourcePlans))
+	for _, dataSourcePlan := range dataSourcePlans {
+		dataSource := b.build(dataSourcePlan)
+		if b.err != nil {
+			return nil
+		}
+		dataSources = append(dataSources, dataSource)
+	}
+
+	shuffle := &ShuffleExec{
+		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ExplainID(), dataSources...),
+		concurrency:  v.Concurrency,
+	}
+	shuffle.splitters = make([]partitionSplitter, 0, len(dataSources))
+	for i := range dataSources {
+		shuffle.splitters = append(shuffle.splitters, &partitionHashSplitter{
+			byItems:    v.HashByItemArrays[i],
+			numWorkers: shuffle.concurrency,
+		})
+	}
+
+	// Every worker builds its own child executors from the tail, whose data
+	// sources are replaced by the receivers of the worker.
+	defer v.Tail.SetChildren(dataSourcePlans...)
+	shuffle.workers = make([]*shuffleWorker, 0, shuffle.concurrency)
+	for i := 0; i < shuffle.concurrency; i++ {
+		w := &shuffleWorker{
+			receivers: make([]*shuffleReceiver, 0, len(dataSources)),
+		}
+		stubs := make([]plannercore.PhysicalPlan, 0, len(dataSources))
+		for j, dataSource := range dataSources {
+			receiver := &shuffleReceiver{
+				baseExecutor: newBaseExecutor(b.ctx, dataSource.Schema(), dataSource.base().id),
+			}
+			w.receivers = append(w.receivers, receiver)
+			stub := plannercore.PhysicalShuffleReceiverStub{
+				Receiver: unsafe.Pointer(receiver),
+			}.Init(b.ctx, dataSourcePlans[j].Stats())
+			stub.SetSchema(dataSource.Schema())
+			stubs = append(stubs, stub)
+		}
+		v.Tail.SetChildren(stubs...)
+		w.childExec = b.build(v.Tail)
+		if b.err != nil {
+			return nil
+		}
+		shuffle.workers = append(shuffle.workers, w)
+	}
+	return shuffle
+}
+
+func (b *executorBuilder) buildShuffleReceiverStub(v *plannercore.PhysicalShuffleReceiverStub) Executor {
+	return (*shuffleReceiver)(v.Receiver)
+}
+
 func (b *executorBuilder) buildHashJoin(v *plannercore.PhysicalHashJoin) Executor {
 	leftExec := b.build(v.Children()[0])
 	if b.err != nil {
@@ -480,6 +603,13 @@ func (b *executorBuilder) buildHashJoin(v *plannercore.PhysicalHashJoin) Executo
 		e.joiners[i] = newJoiner(b.ctx, v.JoinType, v.InnerChildIdx == 0, defaultValues,
 			v.OtherConditions, lhsTypes, rhsTypes)
 	}
+	if reader, ok := e.outerSideExec.(*TableReaderExecutor); ok && len(v.RuntimeFilterKeyIdx) > 0 {
+		e.runtimeFilterReader = reader
+		for _, idx := range v.RuntimeFilterKeyIdx {
+			e.runtimeFilterBuildKeys = append(e.runtimeFilterBuildKeys, e.innerKeys[idx])
+			e.runtimeFilterProbeKeys = append(e.runtimeFilterProbeKeys, e.outerKeys[idx])
+		}
+	}
 	return e
 }
 
@@ -598,6 +728,9 @@ func (b *executorBuilder) getStartTS() (uint64, error) {
 		return 0, err
 	}
 	b.startTS = txn.StartTS()
+	if snapshotTS := b.ctx.GetSessionVars().SnapshotTS; snapshotTS != 0 {
+		b.startTS = snapshotTS
+	}
 	if b.startTS == 0 {
 		return 0, errors.Trace(ErrGetStartTS)
 	}
@@ -626,6 +759,7 @@ func (b *executorBuilder) buildSort(v *plannercore.PhysicalSort) Executor {
 		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ExplainID(), childExec),
 		ByItems:      v.ByItems,
 		schema:       v.Schema(),
+		concurrency:  b.ctx.GetSessionVars().SortConcurrency,
 	}
 	return &sortExec
 }
@@ -639,6 +773,7 @@ func (b *executorBuilder) buildTopN(v *plannercore.PhysicalTopN) Executor {
 		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ExplainID(), childExec),
 		ByItems:      v.ByItems,
 		schema:       v.Schema(),
+		concurrency:  b.ctx.GetSessionVars().SortConcurrency,
 	}
 	return &TopNExec{
 		SortExec: sortExec,
@@ -754,10 +889,22 @@ func constructDistExec(sctx sessionctx.Context, plans []plannercore.PhysicalPlan
 	return executors, nil
 }
 
+func getPhysicalPlanIDs(plans []plannercore.PhysicalPlan) []fmt.Stringer {
+	planIDs := make([]fmt.Stringer, 0, len(plans))
+	for _, p := range plans {
+		planIDs = append(planIDs, p.ExplainID())
+	}
+	return planIDs
+}
+
 func (b *executorBuilder) constructDAGReq(plans []plannercore.PhysicalPlan) (dagReq *tipb.DAGRequest, err error) {
 	dagReq = &tipb.DAGRequest{}
 	sc := b.ctx.GetSessionVars().StmtCtx
 	dagReq.Flags = sc.PushDownFlags()
+	if sc.RuntimeStatsColl != nil {
+		collExec := true
+		dagReq.CollectExecutionSummaries = &collExec
+	}
 	dagReq.Executors, err = constructDistExec(b.ctx, plans)
 	return dagReq, err
 }
@@ -917,6 +1064,82 @@ func (b *executorBuilder) buildIndexLookUpReader(v *plannercore.PhysicalIndexLoo
 	return ret
 }
 
+func buildNoRangeIndexMergeReader(b *executorBuilder, v *plannercore.PhysicalIndexMergeReader) (*IndexMergeReaderExecutor, error) {
+	partialPlanCount := len(v.PartialPlans)
+	partialReqs := make([]*tipb.DAGRequest, 0, partialPlanCount)
+	indexes := make([]*model.IndexInfo, 0, partialPlanCount)
+	for i := 0; i < partialPlanCount; i++ {
+		req, err := b.constructDAGReq(v.PartialPlans[i])
+		if err != nil {
+			return nil, err
+		}
+		if is, ok := v.PartialPlans[i][0].(*plannercore.PhysicalIndexScan); ok {
+			req.OutputOffsets = []uint32{uint32(len(is.Index.Columns))}
+			indexes = append(indexes, is.Index)
+		} else {
+			ts := v.PartialPlans[i][0].(*plannercore.PhysicalTableScan)
+			for j, col := range ts.Columns {
+				if mysql.HasPriKeyFlag(col.Flag) || col.ID == model.ExtraHandleID {
+					req.OutputOffsets = []uint32{uint32(j)}
+					break
+				}
+			}
+			indexes = append(indexes, nil)
+		}
+		partialReqs = append(partialReqs, req)
+	}
+	tableReq, err := b.constructDAGReq(v.TablePlans)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < v.Schema().Len(); i++ {
+		tableReq.OutputOffsets = append(tableReq.OutputOffsets, uint32(i))
+	}
+
+	ts := v.TablePlans[0].(*plannercore.PhysicalTableScan)
+	tbl, _ := b.is.TableByID(ts.Table.ID)
+	startTS, err := b.getStartTS()
+	if err != nil {
+		return nil, err
+	}
+	e := &IndexMergeReaderExecutor{
+		baseExecutor:      newBaseExecutor(b.ctx, v.Schema(), v.ExplainID()),
+		table:             tbl,
+		isIntersection:    v.IsIntersection,
+		indexes:           indexes,
+		dagPBs:            partialReqs,
+		startTS:           startTS,
+		tableRequest:      tableReq,
+		columns:           ts.Columns,
+		dataReaderBuilder: &dataReaderBuilder{executorBuilder: b},
+		partialPlans:      v.PartialPlans,
+		tblPlans:          v.TablePlans,
+	}
+	return e, nil
+}
+
+func (b *executorBuilder) buildIndexMergeReader(v *plannercore.PhysicalIndexMergeReader) *IndexMergeReaderExecutor {
+	ret, err := buildNoRangeIndexMergeReader(b, v)
+	if err != nil {
+		b.err = err
+		return nil
+	}
+
+	ret.ranges = make([][]*ranger.Range, 0, len(v.PartialPlans))
+	sctx := b.ctx.GetSessionVars().StmtCtx
+	for i := 0; i < len(v.PartialPlans); i++ {
+		if is, ok := v.PartialPlans[i][0].(*plannercore.PhysicalIndexScan); ok {
+			ret.ranges = append(ret.ranges, is.Ranges)
+			sctx.IndexNames = append(sctx.IndexNames, is.Table.Name.O+":"+is.Index.Name.O)
+		} else {
+			ret.ranges = append(ret.ranges, v.PartialPlans[i][0].(*plannercore.PhysicalTableScan).Ranges)
+		}
+	}
+	ts := v.TablePlans[0].(*plannercore.PhysicalTableScan)
+	sctx.TableIDs = append(sctx.TableIDs, ts.Table.ID)
+	return ret
+}
+
 // dataReaderBuilder build an executor.
 // The executor can be used to read data in the ranges which are constructed by datums.
 // Differences from executorBuilder:
@@ -951,7 +1174,7 @@ func (builder *dataReaderBuilder) buildTableReaderFromHandles(ctx context.Contex
 	}
 	e.kvRanges = append(e.kvRanges, kvReq.KeyRanges...)
 	e.resultHandler = &tableResultHandler{}
-	result, err := distsql.Select(ctx, builder.ctx, kvReq, retTypes(e))
+	result, err := distsql.SelectWithRuntimeStats(ctx, builder.ctx, kvReq, retTypes(e), getPhysicalPlanIDs(e.plans), e.id)
 	if err != nil {
 		return nil, err
 	}
@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/tidb/planner"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/tracing"
 )
 
 // Compiler compiles an ast.StmtNode to a physical plan.
@@ -30,8 +31,14 @@ type Compiler struct {
 
 // Compile compiles an ast.StmtNode to a physical plan.
 func (c *Compiler) Compile(ctx context.Context, stmtNode ast.StmtNode) (*ExecStmt, error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "executor.Compile")
+	defer span.Finish()
+
 	infoSchema := infoschema.GetInfoSchema(c.Ctx)
-	if err := plannercore.Preprocess(c.Ctx, stmtNode, infoSchema); err != nil {
+	preprocessSpan, _ := tracing.ChildSpanFromContext(ctx, "planner.Preprocess")
+	err := plannercore.Preprocess(c.Ctx, stmtNode, infoSchema)
+	preprocessSpan.Finish()
+	if err != nil {
 		return nil, err
 	}
 
@@ -48,3 +55,63 @@ func (c *Compiler) Compile(ctx context.Context, stmtNode ast.StmtNode) (*ExecStm
 		OutputNames: names,
 	}, nil
 }
+
+// GetStmtLabel generates a label for a statement.
+func GetStmtLabel(stmtNode ast.StmtNode) string {
+	switch x := stmtNode.(type) {
+	case *ast.AdminStmt:
+		return "Admin"
+	case *ast.AlterTableStmt:
+		return "AlterTable"
+	case *ast.AnalyzeTableStmt:
+		return "AnalyzeTable"
+	case *ast.BeginStmt:
+		return "Begin"
+	case *ast.CommitStmt:
+		return "Commit"
+	case *ast.CreateBindingStmt:
+		return "CreateBinding"
+	case *ast.CreateDatabaseStmt:
+		return "CreateDatabase"
+	case *ast.CreateIndexStmt:
+		return "CreateIndex"
+	case *ast.CreateTableStmt:
+		return "CreateTable"
+	case *ast.DeleteStmt:
+		return "Delete"
+	case *ast.DropBindingStmt:
+		return "DropBinding"
+	case *ast.DropDatabaseStmt:
+		return "DropDatabase"
+	case *ast.DropIndexStmt:
+		return "DropIndex"
+	case *ast.DropTableStmt:
+		return "DropTable"
+	case *ast.ExplainStmt:
+		return "Explain"
+	case *ast.InsertStmt:
+		if x.IsReplace {
+			return "Replace"
+		}
+		return "Insert"
+	case *ast.KillStmt:
+		return "Kill"
+	case *ast.PlanReplayerStmt:
+		return "PlanReplayer"
+	case *ast.RollbackStmt:
+		return "Rollback"
+	case *ast.SelectStmt:
+		return "Select"
+	case *ast.SetStmt:
+		return "Set"
+	case *ast.ShowStmt:
+		return "Show"
+	case *ast.TraceStmt:
+		return "Trace"
+	case *ast.TruncateTableStmt:
+		return "TruncateTable"
+	case *ast.UseStmt:
+		return "Use"
+	}
+	return "other"
+}
@@ -24,16 +24,19 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/distsql"
+	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/parser/model"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
 	plannercore "github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tipb/go-tipb"
@@ -189,18 +192,31 @@ type IndexReaderExecutor struct {
 	idxCols []*expression.Column
 	colLens []int
 	plans   []plannercore.PhysicalPlan
+
+	memTracker *memory.Tracker
+
+	// rowsRead is the number of rows read from the index, it's used for the index usage.
+	rowsRead int64
 }
 
 // Close clears all resources hold by current object.
 func (e *IndexReaderExecutor) Close() error {
+	if e.result != nil {
+		recordIndexUsage(e.ctx, e.physicalTableID, e.index.ID, e.rowsRead)
+	}
 	err := e.result.Close()
 	e.result = nil
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
 	return err
 }
 
 // Next implements the Executor Next interface.
 func (e *IndexReaderExecutor) Next(ctx context.Context, req *chunk.Chunk) error {
 	err := e.result.Next(ctx, req)
+	e.rowsRead += int64(req.NumRows())
 	return err
 }
 
@@ -218,6 +234,8 @@ func (e *IndexReaderExecutor) open(ctx context.Context, kvRanges []kv.KeyRange)
 	var err error
 	e.kvRanges = kvRanges
 
+	e.memTracker = memory.NewTracker(e.id, -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	var builder distsql.RequestBuilder
 	kvReq, err := builder.SetKeyRanges(kvRanges).
 		SetDAGRequest(e.dagPB).
@@ -225,14 +243,30 @@ func (e *IndexReaderExecutor) open(ctx context.Context, kvRanges []kv.KeyRange)
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
 		SetFromSessionVars(e.ctx.GetSessionVars()).
+		SetMemTracker(e.memTracker).
 		Build()
 	if err != nil {
 		return err
 	}
-	e.result, err = distsql.Select(ctx, e.ctx, kvReq, retTypes(e))
+	e.result, err = distsql.SelectWithRuntimeStats(ctx, e.ctx, kvReq, retTypes(e), getPhysicalPlanIDs(e.plans), e.id)
 	return err
 }
 
+// recordIndexUsage records an access of the index for the index usage statistics,
+// the internal queries are not recorded.
+func recordIndexUsage(sctx sessionctx.Context, tableID, indexID, rowsRead int64) {
+	if sctx.GetSessionVars().InRestrictedSQL {
+		return
+	}
+	do := domain.GetDomain(sctx)
+	if do == nil {
+		return
+	}
+	if statsHandle := do.StatsHandle(); statsHandle != nil {
+		statsHandle.UpdateIndexUsage(tableID, indexID, rowsRead)
+	}
+}
+
 // IndexLookUpExecutor implements double read for index scan.
 type IndexLookUpExecutor struct {
 	baseExecutor
@@ -266,6 +300,11 @@ type IndexLookUpExecutor struct {
 	tblPlans []plannercore.PhysicalPlan
 	idxCols  []*expression.Column
 	colLens  []int
+
+	memTracker *memory.Tracker
+
+	// idxRowsRead is the number of handles read from the index, it's used for the index usage.
+	idxRowsRead int64
 }
 
 // Open implements the Executor Open interface.
@@ -280,6 +319,8 @@ func (e *IndexLookUpExecutor) Open(ctx context.Context) error {
 }
 
 func (e *IndexLookUpExecutor) open(ctx context.Context) error {
+	e.memTracker = memory.NewTracker(e.id, -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	e.finished = make(chan struct{})
 	e.resultCh = make(chan *lookupTableTask, atomic.LoadInt32(&LookupTableTaskChannelSize))
 	return nil
@@ -306,12 +347,13 @@ func (e *IndexLookUpExecutor) startIndexWorker(ctx context.Context, kvRanges []k
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
 		SetFromSessionVars(e.ctx.GetSessionVars()).
+		SetMemTracker(e.memTracker).
 		Build()
 	if err != nil {
 		return err
 	}
 	tps := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}
-	result, err := distsql.Select(ctx, e.ctx, kvReq, tps)
+	result, err := distsql.SelectWithRuntimeStats(ctx, e.ctx, kvReq, tps, getPhysicalPlanIDs(e.idxPlans), e.id)
 	if err != nil {
 		return err
 	}
@@ -387,19 +429,22 @@ func (e *IndexLookUpExecutor) buildTableReader(ctx context.Context, handles []in
 
 // Close implements Exec Close interface.
 func (e *IndexLookUpExecutor) Close() error {
-	if !e.workerStarted || e.finished == nil {
-		return nil
+	if e.workerStarted && e.finished != nil {
+		close(e.finished)
+		// Drain the resultCh and discard the result, in case that Next() doesn't fully
+		// consume the data, background worker still writing to resultCh and block forever.
+		for range e.resultCh {
+		}
+		e.idxWorkerWg.Wait()
+		e.tblWorkerWg.Wait()
+		e.finished = nil
+		e.workerStarted = false
 	}
-
-	close(e.finished)
-	// Drain the resultCh and discard the result, in case that Next() doesn't fully
-	// consume the data, background worker still writing to resultCh and block forever.
-	for range e.resultCh {
+	if e.memTracker != nil {
+		recordIndexUsage(e.ctx, getPhysicalTableID(e.table), e.index.ID, e.idxRowsRead)
+		e.memTracker.Detach()
+		e.memTracker = nil
 	}
-	e.idxWorkerWg.Wait()
-	e.tblWorkerWg.Wait()
-	e.finished = nil
-	e.workerStarted = false
 	return nil
 }
 
@@ -441,6 +486,7 @@ func (e *IndexLookUpExecutor) getResultTask() (*lookupTableTask, error) {
 		return nil, err
 	}
 
+	e.idxRowsRead += int64(len(task.handles))
 	e.resultCurr = task
 	return e.resultCurr, nil
 }
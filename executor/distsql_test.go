@@ -158,3 +158,31 @@ func (s *testSuite3) TestPushLimitDownIndexLookUpReader(c *C) {
 	tk.MustQuery("select * from tbl use index(idx_b_c) where b > 1 order by b desc limit 2,1").Check(testkit.Rows("3 3 3"))
 	tk.MustQuery("select * from tbl use index(idx_b_c) where b > 1 and c > 1 limit 2,1").Check(testkit.Rows("4 4 4"))
 }
+
+func (s *testSuite3) TestIndexMergeReader(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int, key(b), key(c))")
+	tk.MustExec("insert into t values(1,1,1),(2,2,1),(3,1,3),(4,4,4),(5,5,2)")
+	// The handles read by both the partial plans are only returned once.
+	tk.MustQuery("select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 1 or c = 1").Sort().Check(testkit.Rows("1 1 1", "2 2 1", "3 1 3"))
+	tk.MustQuery("select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 1 and c = 1").Check(testkit.Rows("1 1 1"))
+	tk.MustQuery("select /*+ USE_INDEX_MERGE(t, primary, c) */ a from t where a > 4 or c < 2").Sort().Check(testkit.Rows("1", "2", "5"))
+	tk.MustQuery("select /*+ USE_INDEX_MERGE(t, b, c) */ count(*) from t where (b > 3 or c = 3) and a < 5").Check(testkit.Rows("2"))
+	tk.MustQuery("select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 6 or c = 6").Check(testkit.Rows())
+}
+
+func (s *testSuite3) TestIndexMergeReaderIndexUsage(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database index_merge_usage")
+	defer tk.MustExec("drop database index_merge_usage")
+	tk.MustExec("use index_merge_usage")
+	tk.MustExec("create table t(a int primary key, b int, c int, key(b), key(c))")
+	tk.MustExec("insert into t values(1,1,1),(2,2,1),(3,1,3),(4,4,4),(5,5,2)")
+	tk.MustQuery("select /*+ USE_INDEX_MERGE(t, b, c) */ a from t where b = 1 or c = 1").Sort().Check(testkit.Rows("1", "2", "3"))
+	c.Assert(domain.GetDomain(tk.Se).StatsHandle().DumpIndexUsageToKV(), IsNil)
+	tk.MustQuery("select index_name, query_count, rows_selected from information_schema.tidb_index_usage where table_schema = 'index_merge_usage'").Sort().Check(
+		testkit.Rows("b 1 2", "c 1 2"))
+	tk.MustQuery("show unused indexes from index_merge_usage").Check(testkit.Rows())
+}
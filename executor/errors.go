@@ -41,6 +41,8 @@ var (
 	ErrWrongObject                 = terror.ClassExecutor.New(mysql.ErrWrongObject, mysql.MySQLErrName[mysql.ErrWrongObject])
 	ErrRoleNotGranted              = terror.ClassPrivilege.New(mysql.ErrRoleNotGranted, mysql.MySQLErrName[mysql.ErrRoleNotGranted])
 	ErrQueryInterrupted            = terror.ClassExecutor.New(mysql.ErrQueryInterrupted, mysql.MySQLErrName[mysql.ErrQueryInterrupted])
+	ErrNoSuchThread                = terror.ClassExecutor.New(mysql.ErrNoSuchThread, mysql.MySQLErrName[mysql.ErrNoSuchThread])
+	ErrKillDenied                  = terror.ClassExecutor.New(mysql.ErrKillDenied, mysql.MySQLErrName[mysql.ErrKillDenied])
 )
 
 func init() {
@@ -67,6 +69,8 @@ func init() {
 		mysql.ErrWrongObject:                 mysql.ErrWrongObject,
 		mysql.ErrRoleNotGranted:              mysql.ErrRoleNotGranted,
 		mysql.ErrQueryInterrupted:            mysql.ErrQueryInterrupted,
+		mysql.ErrNoSuchThread:                mysql.ErrNoSuchThread,
+		mysql.ErrKillDenied:                  mysql.ErrKillDenied,
 		mysql.ErrWrongValueCountOnRow:        mysql.ErrWrongValueCountOnRow,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = tableMySQLErrCodes
@@ -17,9 +17,11 @@ import (
 	"context"
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/cznic/mathutil"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
@@ -31,6 +33,10 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/admin"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tidb/util/tracing"
 )
 
 var (
@@ -60,6 +66,7 @@ type baseExecutor struct {
 	maxChunkSize  int
 	children      []Executor
 	retFieldTypes []*types.FieldType
+	runtimeStats  *execdetails.RuntimeStats
 }
 
 // base returns the baseExecutor of an executor, don't override this method!
@@ -123,6 +130,9 @@ func newBaseExecutor(ctx sessionctx.Context, schema *expression.Schema, id fmt.S
 		initCap:      ctx.GetSessionVars().InitChunkSize,
 		maxChunkSize: ctx.GetSessionVars().MaxChunkSize,
 	}
+	if coll := ctx.GetSessionVars().StmtCtx.RuntimeStatsColl; coll != nil && id != nil {
+		e.runtimeStats = coll.GetRootStats(id.String())
+	}
 	if schema != nil {
 		cols := schema.Columns
 		e.retFieldTypes = make([]*types.FieldType, len(cols))
@@ -158,6 +168,20 @@ func Next(ctx context.Context, e Executor, req *chunk.Chunk) error {
 	if atomic.CompareAndSwapUint32(&sessVars.Killed, 1, 0) {
 		return ErrQueryInterrupted
 	}
+	if err := sessVars.StmtCtx.MemTracker.Err(); err != nil {
+		return err
+	}
+	if base.runtimeStats != nil {
+		start := time.Now()
+		defer func() { base.runtimeStats.Record(time.Since(start), req.NumRows()) }()
+	}
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		// The Next calls of an executor are recorded in one span, otherwise
+		// the trace of a large result would be too large.
+		span1 := span.StartAggregatedChild(fmt.Sprintf("%p", e), fmt.Sprintf("%T.Next", e))
+		defer span1.Finish()
+		ctx = tracing.ContextWithSpan(ctx, span1)
+	}
 	return e.Next(ctx, req)
 }
 
@@ -552,8 +576,8 @@ func handleStmtHints(hints []*ast.TableOptimizerHint) (stmtHints stmtctx.StmtHin
 	if len(hints) == 0 {
 		return
 	}
-	var memoryQuotaHint, useToJAHint *ast.TableOptimizerHint
-	var memoryQuotaHintCnt, useToJAHintCnt, readReplicaHintCnt int
+	var memoryQuotaHint, useToJAHint, maxExecutionTime *ast.TableOptimizerHint
+	var memoryQuotaHintCnt, useToJAHintCnt, readReplicaHintCnt, maxExecutionTimeCnt int
 	for _, hint := range hints {
 		switch hint.HintName.L {
 		case "memory_quota":
@@ -564,6 +588,9 @@ func handleStmtHints(hints []*ast.TableOptimizerHint) (stmtHints stmtctx.StmtHin
 			useToJAHintCnt++
 		case "read_consistent_replica":
 			readReplicaHintCnt++
+		case "max_execution_time":
+			maxExecutionTimeCnt++
+			maxExecutionTime = hint
 		}
 	}
 	// Handle MEMORY_QUOTA
@@ -603,6 +630,15 @@ func handleStmtHints(hints []*ast.TableOptimizerHint) (stmtHints stmtctx.StmtHin
 		stmtHints.HasReplicaReadHint = true
 		stmtHints.ReplicaRead = byte(kv.ReplicaReadFollower)
 	}
+	// Handle MAX_EXECUTION_TIME
+	if maxExecutionTimeCnt != 0 {
+		if maxExecutionTimeCnt > 1 {
+			warn := errors.New("There are multiple MAX_EXECUTION_TIME hints, only the last one will take effect")
+			warns = append(warns, warn)
+		}
+		stmtHints.HasMaxExecutionTime = true
+		stmtHints.MaxExecutionTime = maxExecutionTime.MaxExecutionTime
+	}
 	return
 }
 
@@ -616,6 +652,20 @@ func ResetContextOfStmt(ctx sessionctx.Context, s ast.StmtNode) (err error) {
 		StmtHints: stmtHints,
 		TimeZone:  vars.Location(),
 	}
+	memQuota := vars.MemQuotaQuery
+	if sc.HasMemQuotaHint {
+		memQuota = sc.MemQuotaQuery
+	}
+	sc.MemTracker = memory.NewTracker(stringutil.MemoizeStr(s.Text), memQuota)
+	sc.DiskTracker = memory.NewTracker(stringutil.MemoizeStr(s.Text), -1)
+	switch config.GetGlobalConfig().OOMAction {
+	case config.OOMActionCancel:
+		sc.MemTracker.SetActionOnExceed(&memory.CancelOnExceed{ConnID: vars.ConnectionID})
+	case config.OOMActionLog:
+		fallthrough
+	default:
+		sc.MemTracker.SetActionOnExceed(&memory.LogOnExceed{ConnID: vars.ConnectionID})
+	}
 	if explainStmt, ok := s.(*ast.ExplainStmt); ok {
 		sc.InExplainStmt = true
 		sc.CastStrToIntStrict = true
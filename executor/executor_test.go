@@ -15,10 +15,12 @@ package executor_test
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
@@ -37,6 +39,7 @@ import (
 	"math"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -581,6 +584,47 @@ func (s *testSuiteP1) TestOrderBy(c *C) {
 	tk.MustQuery("select c1 as c2 from t order by c2 + 1").Check(testkit.Rows("2", "1"))
 }
 
+func (s *testSuiteP1) TestParallelSortAndTopN(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c varchar(20))")
+	for i := 0; i < 30; i++ {
+		values := make([]string, 0, 100)
+		for j := i * 100; j < (i+1)*100; j++ {
+			values = append(values, fmt.Sprintf("(%d, %d, '%d')", j%7, j, j%13))
+		}
+		tk.MustExec("insert into t values " + strings.Join(values, ","))
+	}
+
+	queries := []string{
+		"select b from t order by a",
+		"select b from t order by a desc, c",
+		"select b from t order by c, a limit 100",
+		"select b from t order by a desc limit 1000, 500",
+		"select b from t order by a limit 3000",
+		"select b from t order by c desc limit 10000",
+	}
+	for _, query := range queries {
+		tk.MustExec("set @@tidb_sort_concurrency = 1")
+		expected := tk.MustQuery(query).Rows()
+		c.Assert(len(expected) > 0, IsTrue)
+		tk.MustExec("set @@tidb_sort_concurrency = 4")
+		tk.MustQuery(query).Check(expected)
+	}
+
+	// Every worker keeps up to N rows, so a large N is processed by one worker.
+	fpName := "github.com/pingcap/tidb/executor/checkTopNConcurrency"
+	c.Assert(failpoint.Enable(fpName, "return(4)"), IsNil)
+	tk.MustQuery("select b from t order by a limit 4096")
+	c.Assert(failpoint.Disable(fpName), IsNil)
+	c.Assert(failpoint.Enable(fpName, "return(1)"), IsNil)
+	tk.MustQuery("select b from t order by a limit 4097")
+	tk.MustQuery("select b from t order by a limit 4000, 100")
+	c.Assert(failpoint.Disable(fpName), IsNil)
+	// The sort is stable, the rows with equal keys are kept in the order of the table scan.
+	tk.MustQuery("select b from t order by a").Check(tk.MustQuery("select b from t order by a, b").Rows())
+}
+
 func (s *testSuiteP1) TestSelectErrorRow(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -1247,3 +1291,90 @@ func (s *testSuite8) TearDownTest(c *C) {
 		tk.MustExec(fmt.Sprintf("drop table %v", tableName))
 	}
 }
+
+func (s *testSuite1) TestTiDBMVCCInfo(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b varchar(10), c int)")
+	tk.MustExec("insert into t values (1, 'x', 10)")
+	tk.MustExec("delete from t where a = 1")
+	tk.MustExec("insert into t values (1, 'y', null)")
+
+	var info struct {
+		Key    string `json:"key"`
+		Writes []struct {
+			Type     string                 `json:"type"`
+			StartTS  uint64                 `json:"start_ts"`
+			CommitTS uint64                 `json:"commit_ts"`
+			Row      map[string]interface{} `json:"row"`
+		} `json:"writes"`
+	}
+	rows := tk.MustQuery("select tidb_mvcc_info('test', 't', 1)").Rows()
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), &info), IsNil)
+	c.Assert(info.Key, Not(Equals), "")
+	// The newer version comes first.
+	c.Assert(info.Writes, HasLen, 3)
+	c.Assert(info.Writes[0].Type, Equals, "Put")
+	c.Assert(info.Writes[0].Row, DeepEquals, map[string]interface{}{"a": float64(1), "b": "y", "c": nil})
+	c.Assert(info.Writes[1].Type, Equals, "Del")
+	c.Assert(info.Writes[1].Row, IsNil)
+	c.Assert(info.Writes[2].Type, Equals, "Put")
+	c.Assert(info.Writes[2].Row, DeepEquals, map[string]interface{}{"a": float64(1), "b": "x", "c": "10"})
+	c.Assert(info.Writes[0].StartTS > info.Writes[2].CommitTS, IsTrue)
+
+	rows = tk.MustQuery("select tidb_mvcc_info('test', 't', 2)").Rows()
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), &info), IsNil)
+	c.Assert(info.Writes, HasLen, 0)
+
+	tk.MustQuery("select tidb_mvcc_info('test', 't', null)").Check(testkit.Rows("<nil>"))
+	c.Assert(tk.QueryToErr("select tidb_mvcc_info('test', 'not_exist', 1)"), NotNil)
+}
+
+func (s *testSuite1) TestPointGet(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c varchar(20), unique key(b), unique key(c))")
+	tk.MustExec("insert into t values(1, 10, 'a'), (2, 20, 'b'), (3, 30, 'c')")
+
+	tk.MustQuery("select * from t where a = 1").Check(testkit.Rows("1 10 a"))
+	tk.MustQuery("select c from t where a = 4").Check(testkit.Rows())
+	tk.MustQuery("select a, c from t where b = 20").Check(testkit.Rows("2 b"))
+	tk.MustQuery("select * from t where c = 'c'").Check(testkit.Rows("3 30 c"))
+	tk.MustQuery("select * from t where c = 'd'").Check(testkit.Rows())
+	// The rows are returned in the order of the values, and only once for the duplicated values.
+	tk.MustQuery("select * from t where a in (3, 1, 3, 5)").Check(testkit.Rows("3 30 c", "1 10 a"))
+	tk.MustQuery("select a from t where b in (20, 10, 20)").Check(testkit.Rows("2", "1"))
+	tk.MustQuery("select a from t where c in ('x', 'y')").Check(testkit.Rows())
+
+	// The column added later is filled with its default value.
+	tk.MustExec("alter table t add column d int default 7")
+	tk.MustQuery("select * from t where a = 2").Check(testkit.Rows("2 20 b 7"))
+	tk.MustQuery("select d from t where a in (1, 2)").Check(testkit.Rows("7", "7"))
+
+	// The modifications of the transaction are visible.
+	tk.MustExec("begin")
+	tk.MustExec("insert into t values(4, 40, 'd', 0)")
+	tk.MustExec("delete from t where a = 1")
+	tk.MustQuery("select a from t where a = 4").Check(testkit.Rows("4"))
+	tk.MustQuery("select a from t where b = 10").Check(testkit.Rows())
+	tk.MustQuery("select a from t where a in (1, 2, 4)").Check(testkit.Rows("2", "4"))
+	tk.MustQuery("select a from t where c in ('a', 'd')").Check(testkit.Rows("4"))
+	tk.MustExec("rollback")
+	tk.MustQuery("select a from t where a in (1, 4)").Check(testkit.Rows("1"))
+
+	tk.MustExec("delete from t where b = 20")
+	tk.MustExec("delete from t where a in (1, 5)")
+	tk.MustQuery("select * from t").Check(testkit.Rows("3 30 c 7"))
+	tk.MustQuery("select * from t where c = 'c'").Check(testkit.Rows("3 30 c 7"))
+	tk.MustQuery("select * from t where b = 20").Check(testkit.Rows())
+
+	// The delete on a table without the integer primary key reads the extra handle.
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1(a varchar(10), b int, unique key(b))")
+	tk.MustExec("insert into t1 values('x', 1), ('y', 2)")
+	tk.MustExec("delete from t1 where b = 1")
+	tk.MustQuery("select * from t1").Check(testkit.Rows("y 2"))
+	tk.MustQuery("select * from t1 where b = 2").Check(testkit.Rows("y 2"))
+}
@@ -26,18 +26,30 @@ type ExplainExec struct {
 	baseExecutor
 
 	explain *core.Explain
-	rows    [][]string
-	cursor  int
+	// analyzeExec is the executor of the target plan for EXPLAIN ANALYZE.
+	analyzeExec Executor
+	// analyzeExecClosed indicates whether analyzeExec has been closed.
+	analyzeExecClosed bool
+	rows              [][]string
+	cursor            int
 }
 
 // Open implements the Executor Open interface.
 func (e *ExplainExec) Open(ctx context.Context) error {
+	if e.analyzeExec != nil {
+		e.analyzeExecClosed = false
+		return e.analyzeExec.Open(ctx)
+	}
 	return nil
 }
 
 // Close implements the Executor Close interface.
 func (e *ExplainExec) Close() error {
 	e.rows = nil
+	if e.analyzeExec != nil && !e.analyzeExecClosed {
+		e.analyzeExecClosed = true
+		return e.analyzeExec.Close()
+	}
 	return nil
 }
 
@@ -67,8 +79,36 @@ func (e *ExplainExec) Next(ctx context.Context, req *chunk.Chunk) error {
 }
 
 func (e *ExplainExec) generateExplainInfo(ctx context.Context) ([][]string, error) {
-	if err := e.explain.RenderResult(); err != nil {
+	if e.analyzeExec != nil {
+		if err := e.executeAnalyzeExec(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// The result is rendered before closing the target executors, whose memory
+	// trackers are detached from the statement once they are closed.
+	err := e.explain.RenderResult()
+	if e.analyzeExec != nil && !e.analyzeExecClosed {
+		e.analyzeExecClosed = true
+		if closeErr := e.analyzeExec.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
 	return e.explain.Rows, nil
 }
+
+// executeAnalyzeExec runs the target executors to the end, the result rows
+// are discarded.
+func (e *ExplainExec) executeAnalyzeExec(ctx context.Context) error {
+	chk := newFirstChunk(e.analyzeExec)
+	for {
+		if err := Next(ctx, e.analyzeExec, chk); err != nil {
+			return err
+		}
+		if chk.NumRows() == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor_test
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func (s *testSuite2) TestExplainAnalyze(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, index idx(a))")
+	tk.MustExec("insert into t values (1, 1), (2, 2), (3, 3), (4, 4), (5, 5)")
+
+	rows := tk.MustQuery("explain analyze select * from t where b > 1").Rows()
+	c.Assert(len(rows) > 0, IsTrue)
+	for _, row := range rows {
+		c.Assert(row, HasLen, 7)
+		id, task, execInfo := row[0].(string), row[2].(string), row[4].(string)
+		switch task {
+		case "root":
+			c.Assert(strings.Contains(execInfo, "loops:"), IsTrue, Commentf("%v", row))
+		case "cop":
+			c.Assert(strings.HasPrefix(execInfo, "proc max:"), IsTrue, Commentf("%v", row))
+		}
+		if strings.Contains(id, "TableReader") {
+			c.Assert(strings.Contains(execInfo, "rows:4"), IsTrue, Commentf("%v", row))
+			c.Assert(strings.Contains(execInfo, "cop_task: {num: 1"), IsTrue, Commentf("%v", row))
+			c.Assert(row[5], Not(Equals), "N/A")
+		}
+	}
+
+	rows = tk.MustQuery("explain analyze select count(*) from t group by b").Rows()
+	c.Assert(strings.Contains(rows[0][4].(string), "rows:5"), IsTrue, Commentf("%v", rows[0]))
+
+	// The statement is executed.
+	tk.MustQuery("explain analyze insert into t values (6, 6)")
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("6"))
+
+	// The runtime statistics are only collected for EXPLAIN ANALYZE.
+	rows = tk.MustQuery("explain select * from t").Rows()
+	c.Assert(rows[0], HasLen, 4)
+}
+
+func (s *testSuite2) TestTrace(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int)")
+
+	// The statement is executed.
+	rows := tk.MustQuery("trace insert into t values (1, 1), (2, 2)").Rows()
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("2"))
+	c.Assert(rows[0][0], Equals, "trace")
+	operations := make([]string, 0, len(rows))
+	for _, row := range rows {
+		c.Assert(row, HasLen, 3)
+		operations = append(operations, strings.TrimLeft(row[0].(string), "│├└─ "))
+	}
+	ops := strings.Join(operations, ",")
+	for _, op := range []string{"session.Execute", "session.ParseSQL", "executor.Compile", "planner.Optimize",
+		"executor.Open", "twoPhaseCommitter.prewriteKeys", "twoPhaseCommitter.commitKeys", "regionRequest.sendReqToRegion"} {
+		c.Assert(strings.Contains(ops, op), IsTrue, Commentf("%s not in %s", op, ops))
+	}
+
+	rows = tk.MustQuery("trace select * from t").Rows()
+	operations = operations[:0]
+	for _, row := range rows {
+		operations = append(operations, strings.TrimLeft(row[0].(string), "│├└─ "))
+	}
+	ops = strings.Join(operations, ",")
+	for _, op := range []string{"*executor.TableReaderExecutor.Next", "distsql.Select", "copIteratorWorker.handleTask"} {
+		c.Assert(strings.Contains(ops, op), IsTrue, Commentf("%s not in %s", op, ops))
+	}
+
+	// The Next calls of an executor are recorded in one span.
+	for i := 0; i < 100; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (%d, %d)", i, i))
+	}
+	tk.MustExec("set @@tidb_max_chunk_size = 32")
+	rows = tk.MustQuery("trace select * from t").Rows()
+	nextSpans := 0
+	for _, row := range rows {
+		if strings.Contains(row[0].(string), "*executor.TableReaderExecutor.Next") {
+			nextSpans++
+			c.Assert(row[0], Matches, `.*\(calls:[0-9]+\)`)
+		}
+	}
+	c.Assert(nextSpans, Equals, 1)
+
+	rows = tk.MustQuery("trace format = 'json' select * from t").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(strings.HasPrefix(rows[0][0].(string), `{"operation":"trace"`), IsTrue)
+	c.Assert(strings.Contains(rows[0][0].(string), `"operation":"distsql.Select"`), IsTrue)
+
+	_, err := tk.Exec("trace format = 'dot' select * from t")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite2) TestPlanReplayer(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, index idx(a))")
+	tk.MustExec("insert into t values (1, 1), (2, 2), (3, 3), (4, 4), (5, 5)")
+	tk.MustExec("analyze table t")
+	tk.MustExec("set @@tidb_distsql_scan_concurrency = 5")
+
+	rows := tk.MustQuery("plan replayer dump explain select * from t where a > 1").Rows()
+	c.Assert(rows, HasLen, 1)
+	fileName := rows[0][0].(string)
+	defer os.Remove(fileName)
+
+	zr, err := zip.OpenReader(fileName)
+	c.Assert(err, IsNil)
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		r, err := f.Open()
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(r)
+		c.Assert(err, IsNil)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+	zr.Close()
+	for _, name := range []string{"config.toml", "variables.toml", "global_variables.toml", "sql/sql0.sql",
+		"explain.txt", "schema/test/t.schema.txt", "stats/test/t.json"} {
+		_, ok := files[name]
+		c.Assert(ok, IsTrue, Commentf("%s is not dumped", name))
+	}
+	c.Assert(files["sql/sql0.sql"], Equals, "select * from t where a > 1")
+	c.Assert(strings.HasPrefix(files["schema/test/t.schema.txt"], "CREATE TABLE `t`"), IsTrue)
+	c.Assert(strings.Contains(files["variables.toml"], "tidb_distsql_scan_concurrency = \"5\""), IsTrue)
+	explainRows := tk.MustQuery("explain select * from t where a > 1").Rows()
+	c.Assert(strings.Count(files["explain.txt"], "\n"), Equals, len(explainRows)+1)
+
+	// Load the file into an environment without the table.
+	tk.MustExec("drop table t")
+	tk.MustExec("set @@tidb_distsql_scan_concurrency = 15")
+	tk.MustExec(fmt.Sprintf("plan replayer load '%s'", fileName))
+	tk.MustQuery("select @@tidb_distsql_scan_concurrency").Check(testkit.Rows("5"))
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("0"))
+	// The estimated row counts come from the loaded statistics.
+	newExplainRows := tk.MustQuery("explain select * from t where a > 1").Rows()
+	c.Assert(newExplainRows, HasLen, len(explainRows))
+	for i := range explainRows {
+		c.Assert(newExplainRows[i][1], Equals, explainRows[i][1])
+	}
+
+	_, err = tk.Exec("plan replayer load 'not_exists.zip'")
+	c.Assert(err, NotNil)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/memory"
 )
 
 const (
@@ -41,6 +42,10 @@ const (
 	// estCountDivisor defines the divisor of innerSideEstCount.
 	// Set this divisor to prevent innerSideEstCount being too large and causing a performance regression.
 	estCountDivisor = 8
+
+	// hashTableEntrySize is the estimated memory usage of a row in rowHashMap,
+	// including the entry in entryStore and the amortized size of the map.
+	hashTableEntrySize = 32
 )
 
 // hashContext keeps the needed hash context of a db table in hash join.
@@ -79,6 +84,10 @@ type hashRowContainer struct {
 
 	sc   *stmtctx.StatementContext
 	hCtx *hashContext
+
+	// memTracker tracks the memory usage of hashTable, the memory usage
+	// of records is tracked by the tracker of the chunk.List.
+	memTracker *memory.Tracker
 }
 
 func newHashRowContainer(sctx sessionctx.Context, estCount int, hCtx *hashContext, initList *chunk.List) *hashRowContainer {
@@ -100,6 +109,8 @@ func newHashRowContainer(sctx sessionctx.Context, estCount int, hCtx *hashContex
 
 		sc:   sctx.GetSessionVars().StmtCtx,
 		hCtx: hCtx,
+
+		memTracker: memory.NewTracker(hashTableLabel, -1),
 	}
 	return c
 }
@@ -157,6 +168,7 @@ func (c *hashRowContainer) PutChunk(chk *chunk.Chunk) error {
 			return errors.Trace(err)
 		}
 	}
+	oldLen := c.hashTable.Len()
 	for i := 0; i < numRows; i++ {
 		if c.hCtx.hasNull[i] {
 			continue
@@ -165,6 +177,7 @@ func (c *hashRowContainer) PutChunk(chk *chunk.Chunk) error {
 		rowPtr := chunk.RowPtr{ChkIdx: chkIdx, RowIdx: uint32(i)}
 		c.hashTable.Put(key, rowPtr)
 	}
+	c.memTracker.Consume(int64(c.hashTable.Len()-oldLen) * hashTableEntrySize)
 	return nil
 }
 
@@ -0,0 +1,453 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/distsql"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
+	plannercore "github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/ranger"
+	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tipb/go-tipb"
+	"go.uber.org/zap"
+)
+
+var _ Executor = &IndexMergeReaderExecutor{}
+
+// handleCountEntrySize is the estimated memory usage of a handle merged by the
+// processWorker, including the amortized size of the map.
+const handleCountEntrySize = 32
+
+// IndexMergeReaderExecutor accesses a table with multiple index/table scans.
+// Every partial plan is read by its own partialWorker in parallel, which only
+// reads the handles. The processWorker merges the handles, the duplicated ones
+// are removed for union, and only the ones read by all the partial plans are
+// kept for intersection. Then the rows of the merged handles are read by the
+// tableWorkers, which is the same as IndexLookUpExecutor.
+type IndexMergeReaderExecutor struct {
+	baseExecutor
+
+	table          table.Table
+	isIntersection bool
+	// indexes are the indexes the partial plans read, nil means the partial
+	// plan reads the table.
+	indexes      []*model.IndexInfo
+	ranges       [][]*ranger.Range
+	dagPBs       []*tipb.DAGRequest
+	startTS      uint64
+	tableRequest *tipb.DAGRequest
+	columns      []*model.ColumnInfo
+	*dataReaderBuilder
+	// All fields above are immutable.
+
+	partialWorkerWg sync.WaitGroup
+	processWorkerWg sync.WaitGroup
+	tblWorkerWg     sync.WaitGroup
+	finished        chan struct{}
+
+	keyRanges     [][]kv.KeyRange
+	workerStarted bool
+
+	resultCh   chan *lookupTableTask
+	resultCurr *lookupTableTask
+
+	partialPlans [][]plannercore.PhysicalPlan
+	tblPlans     []plannercore.PhysicalPlan
+
+	memTracker *memory.Tracker
+}
+
+// Open implements the Executor Open interface.
+func (e *IndexMergeReaderExecutor) Open(ctx context.Context) error {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	physicalTableID := getPhysicalTableID(e.table)
+	e.keyRanges = make([][]kv.KeyRange, 0, len(e.partialPlans))
+	for i, index := range e.indexes {
+		if index == nil {
+			// The order of the handles doesn't matter, so the ranges of the
+			// unsigned handle can be read in one request.
+			ranges, _ := splitRanges(e.ranges[i], false, false)
+			e.keyRanges = append(e.keyRanges, distsql.TableRangesToKVRanges(physicalTableID, ranges))
+			continue
+		}
+		keyRanges, err := distsql.IndexRangesToKVRanges(sc, physicalTableID, index.ID, e.ranges[i])
+		if err != nil {
+			return err
+		}
+		e.keyRanges = append(e.keyRanges, keyRanges)
+	}
+	e.memTracker = memory.NewTracker(e.id, -1)
+	e.memTracker.AttachTo(sc.MemTracker)
+	e.finished = make(chan struct{})
+	e.resultCh = make(chan *lookupTableTask, atomic.LoadInt32(&LookupTableTaskChannelSize))
+	return nil
+}
+
+func (e *IndexMergeReaderExecutor) startWorkers(ctx context.Context) error {
+	fetchCh := make(chan []int64, len(e.partialPlans))
+	workCh := make(chan *lookupTableTask, 1)
+	for i := range e.partialPlans {
+		if err := e.startPartialWorker(ctx, i, fetchCh); err != nil {
+			// The started partial workers exit when the executor is closed.
+			e.startProcessWorker(ctx, fetchCh, workCh)
+			e.workerStarted = true
+			return err
+		}
+	}
+	e.startProcessWorker(ctx, fetchCh, workCh)
+	e.startTableWorker(ctx, workCh)
+	e.workerStarted = true
+	return nil
+}
+
+// startPartialWorker launches a background goroutine to read the handles of
+// the workID-th partial plan, the handles are sent to fetchCh.
+func (e *IndexMergeReaderExecutor) startPartialWorker(ctx context.Context, workID int, fetchCh chan<- []int64) error {
+	var builder distsql.RequestBuilder
+	kvReq, err := builder.SetKeyRanges(e.keyRanges[workID]).
+		SetDAGRequest(e.dagPBs[workID]).
+		SetStartTS(e.startTS).
+		SetFromSessionVars(e.ctx.GetSessionVars()).
+		SetMemTracker(e.memTracker).
+		Build()
+	if err != nil {
+		return err
+	}
+	tps := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}
+	result, err := distsql.SelectWithRuntimeStats(ctx, e.ctx, kvReq, tps, getPhysicalPlanIDs(e.partialPlans[workID]), e.id)
+	if err != nil {
+		return err
+	}
+	worker := &partialWorker{
+		fetchCh:      fetchCh,
+		finished:     e.finished,
+		resultCh:     e.resultCh,
+		maxChunkSize: e.maxChunkSize,
+	}
+	index := e.indexes[workID]
+	e.partialWorkerWg.Add(1)
+	go func() {
+		ctx1, cancel := context.WithCancel(ctx)
+		err := worker.fetchHandles(ctx1, result)
+		if err != nil {
+			logutil.Logger(ctx).Error("Fetch handles failed", zap.Error(err))
+		}
+		cancel()
+		if index != nil {
+			recordIndexUsage(e.ctx, getPhysicalTableID(e.table), index.ID, worker.rowsRead)
+		}
+		if err := result.Close(); err != nil {
+			logutil.Logger(ctx).Error("close Select result failed", zap.Error(err))
+		}
+		e.partialWorkerWg.Done()
+	}()
+	return nil
+}
+
+// startProcessWorker launches a background goroutine to merge the handles
+// from fetchCh, the table tasks of the merged handles are sent to workCh and
+// e.resultCh.
+func (e *IndexMergeReaderExecutor) startProcessWorker(ctx context.Context, fetchCh chan []int64, workCh chan<- *lookupTableTask) {
+	worker := &processWorker{
+		fetchCh:        fetchCh,
+		workCh:         workCh,
+		finished:       e.finished,
+		resultCh:       e.resultCh,
+		isIntersection: e.isIntersection,
+		partialCount:   len(e.partialPlans),
+		batchSize:      e.ctx.GetSessionVars().IndexLookupSize,
+		memTracker:     e.memTracker,
+	}
+	go func() {
+		e.partialWorkerWg.Wait()
+		close(fetchCh)
+	}()
+	e.processWorkerWg.Add(1)
+	go func() {
+		ctx1, cancel := context.WithCancel(ctx)
+		worker.fetchLoop(ctx1)
+		cancel()
+		close(workCh)
+		// The partial workers may send the errors to e.resultCh, so it is
+		// closed after all of them exit.
+		e.partialWorkerWg.Wait()
+		close(e.resultCh)
+		e.processWorkerWg.Done()
+	}()
+}
+
+// startTableWorker launches some background goroutines which pick tasks from workCh and execute the task.
+func (e *IndexMergeReaderExecutor) startTableWorker(ctx context.Context, workCh <-chan *lookupTableTask) {
+	lookupConcurrencyLimit := e.ctx.GetSessionVars().IndexLookupConcurrency
+	e.tblWorkerWg.Add(lookupConcurrencyLimit)
+	for i := 0; i < lookupConcurrencyLimit; i++ {
+		worker := &tableWorker{
+			workCh:         workCh,
+			finished:       e.finished,
+			buildTblReader: e.buildTableReader,
+		}
+		ctx1, cancel := context.WithCancel(ctx)
+		go func() {
+			worker.pickAndExecTask(ctx1)
+			cancel()
+			e.tblWorkerWg.Done()
+		}()
+	}
+}
+
+func (e *IndexMergeReaderExecutor) buildTableReader(ctx context.Context, handles []int64) (Executor, error) {
+	tableReaderExec := &TableReaderExecutor{
+		baseExecutor: newBaseExecutor(e.ctx, e.schema, stringutil.MemoizeStr(func() string { return e.id.String() + "_tableReader" })),
+		table:        e.table,
+		dagPB:        e.tableRequest,
+		startTS:      e.startTS,
+		columns:      e.columns,
+		plans:        e.tblPlans,
+	}
+	tableReader, err := e.dataReaderBuilder.buildTableReaderFromHandles(ctx, tableReaderExec, handles)
+	if err != nil {
+		logutil.Logger(ctx).Error("build table reader from handles failed", zap.Error(err))
+		return nil, err
+	}
+	return tableReader, nil
+}
+
+// Next implements Exec Next interface.
+func (e *IndexMergeReaderExecutor) Next(ctx context.Context, req *chunk.Chunk) error {
+	if !e.workerStarted {
+		if err := e.startWorkers(ctx); err != nil {
+			return err
+		}
+	}
+	req.Reset()
+	for {
+		resultTask, err := e.getResultTask()
+		if err != nil {
+			return err
+		}
+		if resultTask == nil {
+			return nil
+		}
+		for resultTask.cursor < len(resultTask.rows) {
+			req.AppendRow(resultTask.rows[resultTask.cursor])
+			resultTask.cursor++
+			if req.IsFull() {
+				return nil
+			}
+		}
+	}
+}
+
+func (e *IndexMergeReaderExecutor) getResultTask() (*lookupTableTask, error) {
+	if e.resultCurr != nil && e.resultCurr.cursor < len(e.resultCurr.rows) {
+		return e.resultCurr, nil
+	}
+	task, ok := <-e.resultCh
+	if !ok {
+		return nil, nil
+	}
+	if err := <-task.doneCh; err != nil {
+		return nil, err
+	}
+	e.resultCurr = task
+	return e.resultCurr, nil
+}
+
+// Close implements Exec Close interface.
+func (e *IndexMergeReaderExecutor) Close() error {
+	if e.workerStarted && e.finished != nil {
+		close(e.finished)
+		// Drain the resultCh and discard the result, in case that Next() doesn't fully
+		// consume the data, background worker still writing to resultCh and block forever.
+		for range e.resultCh {
+		}
+		e.processWorkerWg.Wait()
+		e.tblWorkerWg.Wait()
+		e.finished = nil
+		e.workerStarted = false
+	}
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
+	return nil
+}
+
+// partialWorker is used by IndexMergeReaderExecutor to read the handles of a partial plan.
+type partialWorker struct {
+	fetchCh      chan<- []int64
+	finished     <-chan struct{}
+	resultCh     chan<- *lookupTableTask
+	maxChunkSize int
+
+	// rowsRead is the number of handles read, it's used for the index usage.
+	rowsRead int64
+}
+
+// fetchHandles reads the handles from the result in batches and sends them to
+// fetchCh. The error is sent to resultCh, so that it is returned by Next.
+func (w *partialWorker) fetchHandles(ctx context.Context, result distsql.SelectResult) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			buf := make([]byte, 4096)
+			stackSize := runtime.Stack(buf, false)
+			buf = buf[:stackSize]
+			logutil.Logger(ctx).Error("partialWorker in IndexMergeReaderExecutor panicked", zap.String("stack", string(buf)))
+			err = errors.Errorf("%v", r)
+			w.sendError(err)
+		}
+	}()
+	chk := chunk.NewChunkWithCapacity([]*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}, w.maxChunkSize)
+	for {
+		err = errors.Trace(result.Next(ctx, chk))
+		if err != nil {
+			w.sendError(err)
+			return err
+		}
+		if chk.NumRows() == 0 {
+			return nil
+		}
+		w.rowsRead += int64(chk.NumRows())
+		handles := make([]int64, 0, chk.NumRows())
+		for i := 0; i < chk.NumRows(); i++ {
+			handles = append(handles, chk.GetRow(i).GetInt64(0))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.finished:
+			return nil
+		case w.fetchCh <- handles:
+		}
+	}
+}
+
+func (w *partialWorker) sendError(err error) {
+	doneCh := make(chan error, 1)
+	doneCh <- err
+	w.resultCh <- &lookupTableTask{
+		doneCh: doneCh,
+	}
+}
+
+// processWorker is used by IndexMergeReaderExecutor to merge the handles of
+// the partial workers and build the table tasks.
+type processWorker struct {
+	fetchCh        <-chan []int64
+	workCh         chan<- *lookupTableTask
+	finished       <-chan struct{}
+	resultCh       chan<- *lookupTableTask
+	isIntersection bool
+	partialCount   int
+	batchSize      int
+	memTracker     *memory.Tracker
+}
+
+// fetchLoop merges the handles from fetchCh until all the partial workers exit.
+// For union, the table tasks are built as soon as the new handles arrive. For
+// intersection, a handle is kept only if it is read by all the partial workers,
+// which read every handle at most once since the ranges of a partial plan never
+// overlap, so the tasks are built after all the handles are read.
+func (w *processWorker) fetchLoop(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			buf := make([]byte, 4096)
+			stackSize := runtime.Stack(buf, false)
+			buf = buf[:stackSize]
+			logutil.Logger(ctx).Error("processWorker in IndexMergeReaderExecutor panicked", zap.String("stack", string(buf)))
+			doneCh := make(chan error, 1)
+			doneCh <- errors.Errorf("%v", r)
+			w.resultCh <- &lookupTableTask{
+				doneCh: doneCh,
+			}
+		}
+	}()
+	handleCounts := make(map[int64]int)
+	// The handles are kept until all the partial workers exit, so the memory of
+	// the map is released only when the worker returns.
+	defer func() {
+		w.memTracker.Consume(-int64(len(handleCounts)) * handleCountEntrySize)
+	}()
+	for handles := range w.fetchCh {
+		oldLen := len(handleCounts)
+		if w.isIntersection {
+			for _, h := range handles {
+				handleCounts[h]++
+			}
+			w.memTracker.Consume(int64(len(handleCounts)-oldLen) * handleCountEntrySize)
+			continue
+		}
+		newHandles := make([]int64, 0, len(handles))
+		for _, h := range handles {
+			if _, ok := handleCounts[h]; !ok {
+				handleCounts[h] = 1
+				newHandles = append(newHandles, h)
+			}
+		}
+		w.memTracker.Consume(int64(len(handleCounts)-oldLen) * handleCountEntrySize)
+		if len(newHandles) > 0 && !w.sendTask(ctx, newHandles) {
+			return
+		}
+	}
+	if !w.isIntersection {
+		return
+	}
+	handles := make([]int64, 0, w.batchSize)
+	for h, count := range handleCounts {
+		if count < w.partialCount {
+			continue
+		}
+		handles = append(handles, h)
+		if len(handles) >= w.batchSize {
+			if !w.sendTask(ctx, handles) {
+				return
+			}
+			handles = make([]int64, 0, w.batchSize)
+		}
+	}
+	if len(handles) > 0 {
+		w.sendTask(ctx, handles)
+	}
+}
+
+// sendTask sends the table task of the handles to the table workers and
+// resultCh. It returns false if the executor is closed.
+func (w *processWorker) sendTask(ctx context.Context, handles []int64) bool {
+	task := &lookupTableTask{
+		handles: handles,
+		doneCh:  make(chan error, 1),
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-w.finished:
+		return false
+	case w.workCh <- task:
+		w.resultCh <- task
+	}
+	return true
+}
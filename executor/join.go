@@ -15,6 +15,7 @@ package executor
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/pingcap/errors"
@@ -23,9 +24,16 @@ import (
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/stringutil"
 )
 
-var _ Executor = &HashJoinExec{}
+var (
+	_ Executor = &HashJoinExec{}
+
+	innerResultLabel fmt.Stringer = stringutil.StringerStr("innerResult")
+	hashTableLabel   fmt.Stringer = stringutil.StringerStr("hashTable")
+)
 
 // HashJoinExec implements the hash join algorithm.
 type HashJoinExec struct {
@@ -56,7 +64,14 @@ type HashJoinExec struct {
 	joinChkResourceCh  []chan *chunk.Chunk
 	joinResultCh       chan *hashjoinWorkerResult
 
-	prepared bool
+	memTracker *memory.Tracker // track memory usage.
+	prepared   bool
+
+	// runtimeFilterReader is the probe side table reader which the runtime
+	// filters built from runtimeFilterBuildKeys are pushed down to.
+	runtimeFilterReader    *TableReaderExecutor
+	runtimeFilterBuildKeys []*expression.Column
+	runtimeFilterProbeKeys []*expression.Column
 }
 
 // outerChkResource stores the result of the join outer side fetch worker,
@@ -102,17 +117,27 @@ func (e *HashJoinExec) Close() error {
 		e.outerChkResourceCh = nil
 		e.joinChkResourceCh = nil
 	}
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
 	err := e.baseExecutor.Close()
 	return err
 }
 
 // Open implements the Executor Open interface.
 func (e *HashJoinExec) Open(ctx context.Context) error {
+	if e.runtimeFilterReader != nil {
+		// The probe side reader waits for the runtime filters before sending requests.
+		e.runtimeFilterReader.waitRuntimeFilter = true
+	}
 	if err := e.baseExecutor.Open(ctx); err != nil {
 		return err
 	}
 
 	e.prepared = false
+	e.memTracker = memory.NewTracker(e.id, -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	e.closeCh = make(chan struct{})
 	e.joinWorkerWaitGroup = sync.WaitGroup{}
 	return nil
@@ -128,6 +153,12 @@ func (e *HashJoinExec) Next(ctx context.Context, req *chunk.Chunk) (err error) {
 		if err != nil {
 			return err
 		}
+		if e.runtimeFilterReader != nil {
+			err = e.pushDownRuntimeFilters()
+			if err != nil {
+				return err
+			}
+		}
 		e.fetchAndProbeHashTable(ctx)
 		e.prepared = true
 	}
@@ -156,7 +187,10 @@ func (e *HashJoinExec) fetchAndBuildHashTable(ctx context.Context) error {
 		keyColIdx: buildKeyColIdx,
 	}
 	initList := chunk.NewList(allTypes, e.initCap, e.maxChunkSize)
+	initList.GetMemTracker().SetLabel(innerResultLabel)
+	initList.GetMemTracker().AttachTo(e.memTracker)
 	e.rowContainer = newHashRowContainer(e.ctx, int(e.innerSideEstCount), hCtx, initList)
+	e.rowContainer.memTracker.AttachTo(e.memTracker)
 
 	for {
 		chk := chunk.NewChunkWithCapacity(e.innerSideExec.base().retFieldTypes, e.ctx.GetSessionVars().MaxChunkSize)
@@ -174,6 +208,18 @@ func (e *HashJoinExec) fetchAndBuildHashTable(ctx context.Context) error {
 	}
 }
 
+// pushDownRuntimeFilters builds the runtime filters from the build side rows
+// and pushes them down to the probe side table reader, it must be called
+// before the probe side reader sends its requests.
+func (e *HashJoinExec) pushDownRuntimeFilters() error {
+	filters, err := buildRuntimeFilters(e.ctx, e.rowContainer.records, e.runtimeFilterBuildKeys, e.runtimeFilterProbeKeys)
+	if err != nil {
+		return err
+	}
+	e.runtimeFilterReader.applyRuntimeFilters(filters)
+	return nil
+}
+
 func (e *HashJoinExec) initializeForOuter() {
 	// e.outerResultChs is for transmitting the chunks which store the data of
 	// outerSideExec, it'll be written by outer side worker goroutine, and read by join
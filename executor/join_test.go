@@ -15,6 +15,8 @@ package executor_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -412,3 +414,74 @@ func (s *testSuiteJoin1) TestInjectProjOnTopN(c *C) {
 		"2",
 	))
 }
+
+// hashJoinInfo returns the operator info of the first hash join in the plan.
+func hashJoinInfo(tk *testkit.TestKit, sql string) string {
+	for _, row := range tk.MustQuery("explain " + sql).Rows() {
+		if strings.Contains(row[0].(string), "HashJoin") {
+			return row[3].(string)
+		}
+	}
+	return ""
+}
+
+// probeReaderInfo returns the execution info of the table reader which reads
+// the table, the table scan is right under its reader in the explained rows.
+func probeReaderInfo(tk *testkit.TestKit, sql, table string) string {
+	var readerInfo string
+	for _, row := range tk.MustQuery("explain analyze " + sql).Rows() {
+		if strings.Contains(row[0].(string), "TableReader") {
+			readerInfo = row[4].(string)
+		} else if strings.Contains(row[0].(string), "TableScan") && strings.Contains(row[3].(string), table) {
+			return readerInfo
+		}
+	}
+	return ""
+}
+
+func (s *testSuiteJoin1) TestHashJoinRuntimeFilter(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int, b int, c varchar(10))")
+	tk.MustExec("create table t2(a int, b varchar(10))")
+	tk.MustExec("insert into t1 values(1, 1, 'a'), (2, 1, 'c'), (3, 2, 'b'), (null, 1, null)")
+	tk.MustExec("insert into t2 values(1, 'a'), (2, 'b'), (3, 'c'), (4, 'd'), (null, 'e')")
+
+	for _, enable := range []string{"1", "0"} {
+		tk.MustExec("set @@tidb_enable_runtime_filter = " + enable)
+		// IN-list filters.
+		tk.MustQuery("select t1.a, t2.b from t1 join t2 on t1.a = t2.a where t1.b = 1 order by t1.a").Check(testkit.Rows("1 a", "2 b"))
+		tk.MustQuery("select t1.a, t2.a from t1 join t2 on t1.c = t2.b where t1.b = 1 order by t1.a").Check(testkit.Rows("1 1", "2 3"))
+		tk.MustQuery("select t1.a, t2.b from t1 join t2 on t1.a = t2.a and t1.c = t2.b where t1.b = 1").Check(testkit.Rows("1 a"))
+		tk.MustQuery("select t2.a, t2.b from t2 where t2.a > 0 and t2.a in (select a from t1 where b = 2)").Check(testkit.Rows("3 c"))
+		// No build side row.
+		tk.MustQuery("select t1.a, t2.b from t1 join t2 on t1.a = t2.a where t1.b = 3").Check(testkit.Rows())
+		tk.MustQuery("select t1.a, t2.b from t1 join t2 on t1.a = t2.a where t1.b = 1 and t1.a is null").Check(testkit.Rows())
+	}
+
+	// The runtime filters are disabled by default.
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustQuery("select @@tidb_enable_runtime_filter").Check(testkit.Rows("0"))
+
+	// The filter is pushed down to the probe side and reduces the rows read from it.
+	sql := "select t1.a, t2.b from t1 join t2 on t1.a = t2.a where t1.b = 1"
+	tk.MustExec("set @@tidb_enable_runtime_filter = 1")
+	c.Assert(hashJoinInfo(tk, sql), Matches, ".*runtime filter:\\[test.t2.a\\]")
+	c.Assert(probeReaderInfo(tk, sql, "table:t2"), Matches, ".*rows:2(,.*)?")
+	tk.MustExec("set @@tidb_enable_runtime_filter = 0")
+	c.Assert(hashJoinInfo(tk, sql), Not(Matches), ".*runtime filter.*")
+	c.Assert(probeReaderInfo(tk, sql, "table:t2"), Matches, ".*rows:5(,.*)?")
+
+	// Min/max range filters are built when there are too many distinct build side values.
+	tk.MustExec("set @@tidb_enable_runtime_filter = 1")
+	tk.MustExec("truncate table t1")
+	tk.MustExec("truncate table t2")
+	for i := 0; i < 300; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t1 values(%d, 1, null)", i*2))
+	}
+	for i := -10; i < 610; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t2 values(%d, null)", i))
+	}
+	tk.MustQuery("select count(*), min(t2.a), max(t2.a) from t1 join t2 on t1.a = t2.a where t1.b = 1").Check(testkit.Rows("300 0 598"))
+}
@@ -364,3 +364,40 @@ func (s *testSuite2) Test3WaysMergeJoin(c *C) {
 	result = checkPlanAndRun(tk, c, plan3, "select /*+ TIDB_SMJ(t1,t2,t3) */ * from t1 right outer join t2 on t1.c1 = t2.c1 join t3 on t1.c1 = t3.c1 order by 1")
 	result.Check(testkit.Rows("2 2 2 3 2 4", "3 3 3 4 3 10"))
 }
+
+func (s *testSuite2) TestShuffleMergeJoin(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("drop table if exists t2")
+	tk.MustExec("create table t1(a int, b int)")
+	tk.MustExec("create table t2(a int, b int)")
+	for i := 0; i < 10; i++ {
+		values1 := make([]string, 0, 100)
+		values2 := make([]string, 0, 100)
+		for j := i * 100; j < (i+1)*100; j++ {
+			values1 = append(values1, fmt.Sprintf("(%d, %d)", j%97, j))
+			values2 = append(values2, fmt.Sprintf("(%d, %d)", j%89, j))
+		}
+		tk.MustExec("insert into t1 values " + strings.Join(values1, ","))
+		tk.MustExec("insert into t2 values " + strings.Join(values2, ","))
+	}
+	tk.MustExec("insert into t1 values (null, 1000)")
+	tk.MustExec("insert into t2 values (null, 1000)")
+
+	queries := []string{
+		"select /*+ TIDB_SMJ(t1, t2) */ * from t1 join t2 on t1.a = t2.a",
+		"select /*+ TIDB_SMJ(t1, t2) */ * from t1 left join t2 on t1.a = t2.a and t1.b > t2.b",
+		"select /*+ TIDB_SMJ(t1, t2) */ * from t1 right join t2 on t1.a = t2.a and t1.b < 500",
+	}
+	for _, query := range queries {
+		tk.MustExec("set @@tidb_merge_join_concurrency = 1")
+		expected := tk.MustQuery(query).Sort().Rows()
+		tk.MustExec("set @@tidb_merge_join_concurrency = 4")
+		result := tk.MustQuery("explain " + query)
+		c.Assert(strings.Contains(fmt.Sprintf("%v", result.Rows()), "Shuffle"), IsTrue)
+		tk.MustQuery(query).Sort().Check(expected)
+	}
+
+	tk.MustExec("set @@tidb_merge_join_concurrency = 1")
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap-incubator/tinykv/proto/pkg/kvrpcpb"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/store/helper"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+)
+
+func init() {
+	expression.GetMVCCInfo = getMVCCInfo
+}
+
+// mvccRowVersion is a lock or a write record of a row, the value is decoded as column name to value.
+type mvccRowVersion struct {
+	Type     string                 `json:"type"`
+	StartTS  uint64                 `json:"start_ts"`
+	CommitTS uint64                 `json:"commit_ts,omitempty"`
+	Primary  string                 `json:"primary,omitempty"`
+	Row      map[string]interface{} `json:"row,omitempty"`
+}
+
+// mvccRowInfo is the result of the builtin function tidb_mvcc_info.
+type mvccRowInfo struct {
+	Key    string            `json:"key"`
+	Lock   *mvccRowVersion   `json:"lock,omitempty"`
+	Writes []*mvccRowVersion `json:"writes"`
+}
+
+// getMVCCInfo gets the lock and all the write records of the row with the handle in the table,
+// and encodes them as a JSON string.
+func getMVCCInfo(sctx sessionctx.Context, dbName, tableName string, handle int64) (string, error) {
+	store, ok := sctx.GetStore().(tikv.Storage)
+	if !ok {
+		return "", errors.New("tidb_mvcc_info is only supported by the TiKV store")
+	}
+	is := infoschema.GetInfoSchema(sctx)
+	tbl, err := is.TableByName(model.NewCIStr(dbName), model.NewCIStr(tableName))
+	if err != nil {
+		return "", err
+	}
+	tblInfo := tbl.Meta()
+
+	key := tablecodec.EncodeRowKeyWithHandle(tblInfo.ID, handle)
+	resp, err := helper.NewHelper(store).GetMvccByEncodedKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	info := &mvccRowInfo{
+		Key:    hex.EncodeToString(key),
+		Writes: []*mvccRowVersion{},
+	}
+	if resp.Info != nil {
+		if lock := resp.Info.Lock; lock != nil {
+			info.Lock = &mvccRowVersion{
+				Type:    lock.Type.String(),
+				StartTS: lock.StartTs,
+				Primary: hex.EncodeToString(lock.Primary),
+			}
+			if lock.Type == kvrpcpb.Op_Put {
+				info.Lock.Row, err = decodeMVCCRow(tblInfo, handle, lock.ShortValue)
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+		for _, write := range resp.Info.Writes {
+			version := &mvccRowVersion{
+				Type:     write.Type.String(),
+				StartTS:  write.StartTs,
+				CommitTS: write.CommitTs,
+			}
+			if write.Type == kvrpcpb.Op_Put {
+				version.Row, err = decodeMVCCRow(tblInfo, handle, write.ShortValue)
+				if err != nil {
+					return "", err
+				}
+			}
+			info.Writes = append(info.Writes, version)
+		}
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(data), nil
+}
+
+// decodeMVCCRow decodes a row value into column name to value with the table schema.
+func decodeMVCCRow(tblInfo *model.TableInfo, handle int64, value []byte) (map[string]interface{}, error) {
+	colFts := make(map[int64]*types.FieldType, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		colFts[col.ID] = &col.FieldType
+	}
+	// The time values are stored in UTC.
+	datums, err := tablecodec.DecodeRow(value, colFts, time.UTC)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	row := make(map[string]interface{}, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if tblInfo.PKIsHandle && mysql.HasPriKeyFlag(col.Flag) {
+			row[col.Name.O] = handle
+			continue
+		}
+		d, ok := datums[col.ID]
+		if !ok || d.IsNull() {
+			row[col.Name.O] = nil
+			continue
+		}
+		str, err := d.ToString()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		row[col.Name.O] = str
+	}
+	return row, nil
+}
@@ -1,7 +1,18 @@
 package executor
 
 import (
+	"context"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/expression/aggregation"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/stringutil"
 )
 
 var _ = Suite(&pkgTestSuite{})
@@ -38,3 +49,45 @@ func (s *pkgTestSuite) TestMoveInfoSchemaToFront(c *C) {
 		}
 	}
 }
+
+type countOnExceed struct {
+	memory.LogOnExceed
+	count int
+}
+
+func (a *countOnExceed) Action(t *memory.Tracker) {
+	a.count++
+}
+
+func (s *pkgTestSuite) TestHashAggSpillActionOnReopen(c *C) {
+	ctx := mock.NewContext()
+	ctx.GetSessionVars().InitChunkSize = variable.DefInitChunkSize
+	ctx.GetSessionVars().MaxChunkSize = variable.DefMaxChunkSize
+	tracker := memory.NewTracker(stringutil.StringerStr("stmt"), 1)
+	action := &countOnExceed{}
+	tracker.SetActionOnExceed(action)
+	ctx.GetSessionVars().StmtCtx.MemTracker = tracker
+
+	cols := []*expression.Column{
+		{Index: 0, RetType: types.NewFieldType(mysql.TypeDouble)},
+		{Index: 1, RetType: types.NewFieldType(mysql.TypeLonglong)},
+	}
+	schema := expression.NewSchema(cols...)
+	src := buildMockDataSource(mockDataSourceParameters{schema: schema, rows: 0, ctx: ctx})
+	aggFunc, err := aggregation.NewAggFuncDesc(ctx, ast.AggFuncSum, []expression.Expression{cols[0]})
+	c.Assert(err, IsNil)
+	exec := buildHashAggExecutor(ctx, src, schema, []*aggregation.AggFuncDesc{aggFunc}, []expression.Expression{cols[1]}).(*HashAggExec)
+	// The spill action is registered only once however many times the executor is reopened.
+	for i := 0; i < 3; i++ {
+		c.Assert(exec.Open(context.Background()), IsNil)
+		c.Assert(exec.Close(), IsNil)
+	}
+	c.Assert(exec.Open(context.Background()), IsNil)
+	tracker.Consume(1)
+	c.Assert(exec.spiller.isTriggered(), IsTrue)
+	c.Assert(action.count, Equals, 0)
+	// The original action is taken once the spilling has been triggered.
+	tracker.Consume(1)
+	c.Assert(action.count, Equals, 1)
+	c.Assert(exec.Close(), IsNil)
+}
@@ -0,0 +1,476 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// ReplayerPath is the directory where the plan replayer files are stored.
+var ReplayerPath = filepath.Join(os.TempDir(), "replayer")
+
+// The layout of the plan replayer file:
+// |-config.toml
+// |-variables.toml
+// |-global_variables.toml
+// |-sql/sql0.sql
+// |-explain.txt
+// |-schema/{db}/{table}.schema.txt
+// |-stats/{db}/{table}.json
+const (
+	replayerConfigFile     = "config.toml"
+	replayerVariablesFile  = "variables.toml"
+	replayerGlobalVarsFile = "global_variables.toml"
+	replayerSQLFile        = "sql/sql0.sql"
+	replayerExplainFile    = "explain.txt"
+	replayerSchemaDir      = "schema/"
+	replayerStatsDir       = "stats/"
+	replayerSchemaSuffix   = ".schema.txt"
+	replayerStatsSuffix    = ".json"
+)
+
+// replayerIgnoredVars are the variables that reflect the state of the process
+// or the transaction rather than the environment of the plan, they are neither
+// dumped nor loaded.
+var replayerIgnoredVars = map[string]struct{}{
+	variable.TiDBSnapshot:         {},
+	variable.TiDBCurrentTS:        {},
+	variable.TiDBConfig:           {},
+	variable.TiDBGeneralLog:       {},
+	variable.TiDBSlowLogThreshold: {},
+}
+
+// PlanReplayerDumpExec dumps the information needed to reproduce the plan of a
+// statement into a zip file, and returns the path of the file.
+type PlanReplayerDumpExec struct {
+	baseExecutor
+
+	stmtNode ast.StmtNode
+	analyze  bool
+	done     bool
+}
+
+// Next implements the Executor Next interface.
+func (e *PlanReplayerDumpExec) Next(ctx context.Context, req *chunk.Chunk) error {
+	req.GrowAndReset(e.maxChunkSize)
+	if e.done {
+		return nil
+	}
+	e.done = true
+	fileName, err := e.dump(ctx)
+	if err != nil {
+		return err
+	}
+	req.AppendString(0, fileName)
+	return nil
+}
+
+func (e *PlanReplayerDumpExec) dump(ctx context.Context) (fileName string, err error) {
+	if err = os.MkdirAll(ReplayerPath, os.ModePerm); err != nil {
+		return "", errors.Trace(err)
+	}
+	fileName = filepath.Join(ReplayerPath, fmt.Sprintf("replayer_%d_%d.zip",
+		e.ctx.GetSessionVars().ConnectionID, time.Now().UnixNano()))
+	f, err := os.Create(fileName)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	zw := zip.NewWriter(f)
+	err = e.dumpToZip(ctx, zw)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fileName)
+		return "", errors.Trace(err)
+	}
+	return fileName, nil
+}
+
+func (e *PlanReplayerDumpExec) dumpToZip(ctx context.Context, zw *zip.Writer) error {
+	if err := dumpTOML(zw, replayerConfigFile, config.GetGlobalConfig()); err != nil {
+		return err
+	}
+	sessionVars, globalVars, err := e.collectVariables()
+	if err != nil {
+		return err
+	}
+	if err = dumpTOML(zw, replayerVariablesFile, sessionVars); err != nil {
+		return err
+	}
+	if err = dumpTOML(zw, replayerGlobalVarsFile, globalVars); err != nil {
+		return err
+	}
+
+	is := infoschema.GetInfoSchema(e.ctx)
+	statsHandle := domain.GetDomain(e.ctx).StatsHandle()
+	for _, tn := range extractTableNames(e.stmtNode) {
+		tbl, ok := is.TableByID(tn.TableInfo.ID)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err = ConstructResultOfShowCreateTable(e.ctx, tbl.Meta(), tbl.Allocator(e.ctx), &buf); err != nil {
+			return err
+		}
+		name := path.Join(tn.Schema.L, tn.Name.L)
+		if err = dumpFile(zw, replayerSchemaDir+name+replayerSchemaSuffix, buf.Bytes()); err != nil {
+			return err
+		}
+		jsonTbl, err := statsHandle.DumpStatsToJSON(tn.Schema.L, tbl.Meta())
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(jsonTbl)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = dumpFile(zw, replayerStatsDir+name+replayerStatsSuffix, data); err != nil {
+			return err
+		}
+	}
+
+	sql := e.stmtNode.Text()
+	if err = dumpFile(zw, replayerSQLFile, []byte(sql)); err != nil {
+		return err
+	}
+	explain, err := e.explain(ctx, sql)
+	if err != nil {
+		return err
+	}
+	return dumpFile(zw, replayerExplainFile, explain)
+}
+
+// collectVariables returns the system variables set in the current session
+// and the values of all the global variables.
+func (e *PlanReplayerDumpExec) collectVariables() (map[string]string, map[string]string, error) {
+	vars := e.ctx.GetSessionVars()
+	sessionVars := make(map[string]string)
+	for name, sysVar := range variable.SysVars {
+		if _, ok := replayerIgnoredVars[name]; ok || sysVar.Scope&variable.ScopeSession == 0 {
+			continue
+		}
+		if val, ok := vars.GetSystemVar(name); ok {
+			sessionVars[name] = val
+		}
+	}
+	globalVars, err := vars.GlobalVarsAccessor.GetAllSysVars()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	for name := range replayerIgnoredVars {
+		delete(globalVars, name)
+	}
+	return sessionVars, globalVars, nil
+}
+
+// explain executes the EXPLAIN of the statement, and renders the result in the
+// same way as the mysql client with the tab separated format.
+func (e *PlanReplayerDumpExec) explain(ctx context.Context, sql string) ([]byte, error) {
+	exec, ok := e.ctx.(sqlexec.SQLExecutor)
+	if !ok {
+		return nil, errors.New("plan replayer is not supported in this context")
+	}
+	explainSQL := "explain " + sql
+	if e.analyze {
+		explainSQL = "explain analyze " + sql
+	}
+	recordSets, err := exec.Execute(ctx, explainSQL)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, rs := range recordSets {
+		err = writeRecordSet(ctx, &buf, rs)
+		if closeErr := rs.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeRecordSet(ctx context.Context, buf *bytes.Buffer, rs sqlexec.RecordSet) error {
+	fields := rs.Fields()
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.ColumnAsName.O)
+	}
+	buf.WriteString(strings.Join(names, "\t"))
+	buf.WriteByte('\n')
+	req := rs.NewChunk()
+	for {
+		if err := rs.Next(ctx, req); err != nil {
+			return err
+		}
+		if req.NumRows() == 0 {
+			return nil
+		}
+		it := chunk.NewIterator4Chunk(req)
+		for row := it.Begin(); row != it.End(); row = it.Next() {
+			values := make([]string, 0, len(fields))
+			for i, field := range fields {
+				if row.IsNull(i) {
+					values = append(values, "NULL")
+					continue
+				}
+				d := row.GetDatum(i, &field.Column.FieldType)
+				str, err := d.ToString()
+				if err != nil {
+					return errors.Trace(err)
+				}
+				values = append(values, str)
+			}
+			buf.WriteString(strings.Join(values, "\t"))
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+type tableNameExtractor struct {
+	tableNames []*ast.TableName
+	seen       map[int64]struct{}
+}
+
+// Enter implements Visitor interface.
+func (t *tableNameExtractor) Enter(in ast.Node) (ast.Node, bool) {
+	if tn, ok := in.(*ast.TableName); ok && tn.TableInfo != nil {
+		if _, ok := t.seen[tn.TableInfo.ID]; !ok {
+			t.seen[tn.TableInfo.ID] = struct{}{}
+			t.tableNames = append(t.tableNames, tn)
+		}
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (t *tableNameExtractor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// extractTableNames returns the resolved tables referenced by the statement.
+func extractTableNames(node ast.StmtNode) []*ast.TableName {
+	extractor := &tableNameExtractor{seen: make(map[int64]struct{})}
+	node.Accept(extractor)
+	return extractor.tableNames
+}
+
+func dumpTOML(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(toml.NewEncoder(w).Encode(v))
+}
+
+func dumpFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = w.Write(data)
+	return errors.Trace(err)
+}
+
+// PlanReplayerLoadExec loads a file generated by the plan replayer dump, it
+// creates the tables, loads the statistics and sets the variables. The config
+// in the file is only for reference and is not loaded.
+type PlanReplayerLoadExec struct {
+	baseExecutor
+
+	file string
+}
+
+// Next implements the Executor Next interface.
+func (e *PlanReplayerLoadExec) Next(ctx context.Context, req *chunk.Chunk) error {
+	req.GrowAndReset(e.maxChunkSize)
+	if len(e.file) == 0 {
+		return errors.New("plan replayer: file path is empty")
+	}
+	zr, err := zip.OpenReader(e.file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	names := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	// The tables must be created before loading their statistics.
+	for _, name := range names {
+		if strings.HasPrefix(name, replayerSchemaDir) && strings.HasSuffix(name, replayerSchemaSuffix) {
+			if err = e.loadSchema(ctx, name, files[name]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, replayerStatsDir) && strings.HasSuffix(name, replayerStatsSuffix) {
+			if err = e.loadStats(files[name]); err != nil {
+				return err
+			}
+		}
+	}
+	if f, ok := files[replayerGlobalVarsFile]; ok {
+		if err = e.loadGlobalVariables(f); err != nil {
+			return err
+		}
+	}
+	if f, ok := files[replayerVariablesFile]; ok {
+		if err = e.loadSessionVariables(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *PlanReplayerLoadExec) loadSchema(ctx context.Context, name string, f *zip.File) error {
+	exec, ok := e.ctx.(sqlexec.SQLExecutor)
+	if !ok {
+		return errors.New("plan replayer is not supported in this context")
+	}
+	dbName := path.Dir(strings.TrimPrefix(name, replayerSchemaDir))
+	createTable, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	vars := e.ctx.GetSessionVars()
+	_, err = exec.Execute(ctx, "CREATE DATABASE IF NOT EXISTS "+escape(model.NewCIStr(dbName), vars.SQLMode))
+	if err != nil {
+		return err
+	}
+	// The table name in the SHOW CREATE TABLE result is not qualified by the database name.
+	currentDB := vars.CurrentDB
+	vars.CurrentDB = dbName
+	defer func() { vars.CurrentDB = currentDB }()
+	_, err = exec.Execute(ctx, string(createTable))
+	return err
+}
+
+func (e *PlanReplayerLoadExec) loadStats(f *zip.File) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	jsonTbl := &statistics.JSONTable{}
+	if err = json.Unmarshal(data, jsonTbl); err != nil {
+		return errors.Trace(err)
+	}
+	do := domain.GetDomain(e.ctx)
+	return do.StatsHandle().LoadStatsFromJSON(do.InfoSchema(), jsonTbl)
+}
+
+func (e *PlanReplayerLoadExec) loadGlobalVariables(f *zip.File) error {
+	varMap, err := readVariables(f)
+	if err != nil {
+		return err
+	}
+	accessor := e.ctx.GetSessionVars().GlobalVarsAccessor
+	for name, value := range varMap {
+		sysVar := variable.GetSysVar(name)
+		if sysVar == nil || sysVar.Scope&variable.ScopeGlobal == 0 {
+			continue
+		}
+		if _, ok := replayerIgnoredVars[sysVar.Name]; ok {
+			continue
+		}
+		oldValue, err := accessor.GetGlobalSysVar(sysVar.Name)
+		if err != nil {
+			return err
+		}
+		if oldValue == value {
+			continue
+		}
+		if err = accessor.SetGlobalSysVar(sysVar.Name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *PlanReplayerLoadExec) loadSessionVariables(f *zip.File) error {
+	varMap, err := readVariables(f)
+	if err != nil {
+		return err
+	}
+	vars := e.ctx.GetSessionVars()
+	for name, value := range varMap {
+		sysVar := variable.GetSysVar(name)
+		if sysVar == nil {
+			return variable.ErrUnknownSystemVar.GenWithStackByArgs(name)
+		}
+		if _, ok := replayerIgnoredVars[sysVar.Name]; ok || sysVar.Scope&variable.ScopeSession == 0 {
+			continue
+		}
+		if err = variable.SetSessionSystemVar(vars, sysVar.Name, types.NewStringDatum(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readVariables(f *zip.File) (map[string]string, error) {
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+	varMap := make(map[string]string)
+	if _, err = toml.Decode(string(data), &varMap); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return varMap, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	return data, errors.Trace(err)
+}
@@ -0,0 +1,188 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/parser/model"
+	plannercore "github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+func (b *executorBuilder) buildPointGet(p *plannercore.PointGetPlan) Executor {
+	startTS, err := b.getStartTS()
+	if err != nil {
+		b.err = err
+		return nil
+	}
+	e := &PointGetExecutor{
+		baseExecutor: newBaseExecutor(b.ctx, p.Schema(), p.ExplainID()),
+		tblInfo:      p.TblInfo,
+		idxInfo:      p.IndexInfo,
+		idxVals:      p.IndexValues,
+		handle:       p.Handle,
+		columns:      p.Columns,
+		startTS:      startTS,
+	}
+	e.base().initCap = 1
+	e.base().maxChunkSize = 1
+	sctx := b.ctx.GetSessionVars().StmtCtx
+	sctx.TableIDs = append(sctx.TableIDs, p.TblInfo.ID)
+	if p.IndexInfo != nil {
+		sctx.IndexNames = append(sctx.IndexNames, p.TblInfo.Name.O+":"+p.IndexInfo.Name.O)
+	}
+	return e
+}
+
+// PointGetExecutor executes the point get plan. It reads at most one row by the handle, or by the
+// value of a unique index, with kv.Snapshot.Get instead of sending coprocessor requests.
+type PointGetExecutor struct {
+	baseExecutor
+
+	tblInfo *model.TableInfo
+	idxInfo *model.IndexInfo
+	idxVals []types.Datum
+	handle  int64
+	columns []*model.ColumnInfo
+	startTS uint64
+
+	txn      kv.Transaction
+	snapshot kv.Snapshot
+	done     bool
+}
+
+// Open implements the Executor interface.
+func (e *PointGetExecutor) Open(ctx context.Context) error {
+	txn, err := e.ctx.Txn(false)
+	if err != nil {
+		return err
+	}
+	if txn.Valid() {
+		e.txn = txn
+	}
+	e.snapshot, err = e.ctx.GetStore().GetSnapshot(kv.Version{Ver: e.startTS})
+	if err != nil {
+		return err
+	}
+	e.done = false
+	return nil
+}
+
+// Close implements the Executor interface.
+func (e *PointGetExecutor) Close() error {
+	e.txn = nil
+	e.snapshot = nil
+	return nil
+}
+
+// Next implements the Executor interface.
+func (e *PointGetExecutor) Next(ctx context.Context, req *chunk.Chunk) error {
+	req.Reset()
+	if e.done {
+		return nil
+	}
+	e.done = true
+
+	handle := e.handle
+	if e.idxInfo != nil {
+		idxKey, err := encodeUniqueIndexKey(e.ctx.GetSessionVars().StmtCtx, e.tblInfo, e.idxInfo, e.idxVals)
+		if err != nil {
+			return err
+		}
+		handleVal, err := e.get(ctx, idxKey)
+		if err != nil {
+			if kv.IsErrNotFound(err) {
+				recordIndexUsage(e.ctx, e.tblInfo.ID, e.idxInfo.ID, 0)
+				return nil
+			}
+			return err
+		}
+		recordIndexUsage(e.ctx, e.tblInfo.ID, e.idxInfo.ID, 1)
+		handle, err = tables.DecodeHandle(handleVal)
+		if err != nil {
+			return err
+		}
+	}
+
+	val, err := e.get(ctx, tablecodec.EncodeRowKeyWithHandle(e.tblInfo.ID, handle))
+	if err != nil {
+		if kv.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return decodeRowValToChunk(e.ctx, e.tblInfo, e.columns, handle, val, req)
+}
+
+// get reads the key from the modifications of the current transaction first, and then from the snapshot.
+func (e *PointGetExecutor) get(ctx context.Context, key kv.Key) ([]byte, error) {
+	if e.txn != nil {
+		val, err := e.txn.GetMemBuffer().Get(ctx, key)
+		if err == nil {
+			if len(val) == 0 {
+				// The key is deleted in the transaction.
+				return nil, kv.ErrNotExist
+			}
+			return val, nil
+		}
+		if !kv.IsErrNotFound(err) {
+			return nil, err
+		}
+	}
+	return e.snapshot.Get(ctx, key)
+}
+
+// encodeUniqueIndexKey encodes the key of a unique index entry, the index values must not be null.
+func encodeUniqueIndexKey(sc *stmtctx.StatementContext, tblInfo *model.TableInfo, idxInfo *model.IndexInfo, idxVals []types.Datum) (kv.Key, error) {
+	encodedIdxVals, err := codec.EncodeKey(sc, nil, idxVals...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return tablecodec.EncodeIndexSeekKey(tblInfo.ID, idxInfo.ID, encodedIdxVals), nil
+}
+
+// decodeRowValToChunk decodes the row value and appends the columns to the chunk,
+// the columns missing in the row value are filled with their default values.
+func decodeRowValToChunk(sctx sessionctx.Context, tblInfo *model.TableInfo, columns []*model.ColumnInfo, handle int64, value []byte, req *chunk.Chunk) error {
+	// The columns are placed by their offsets in the table, as the default values are cached by the offsets.
+	cols := make([]*table.Column, len(tblInfo.Columns))
+	for _, col := range columns {
+		if col.ID != model.ExtraHandleID {
+			cols[col.Offset] = table.ToColumn(col)
+		}
+	}
+	row, _, err := tables.DecodeRawRowData(sctx, tblInfo, handle, cols, value)
+	if err != nil {
+		return err
+	}
+	for i, col := range columns {
+		if col.ID == model.ExtraHandleID {
+			handleDatum := types.NewIntDatum(handle)
+			req.AppendDatum(i, &handleDatum)
+			continue
+		}
+		req.AppendDatum(i, &row[col.Offset])
+	}
+	return nil
+}
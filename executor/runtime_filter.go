@@ -0,0 +1,171 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// runtimeFilterMaxInListLen is the max number of distinct values of an IN-list
+// runtime filter. If the build side key has more distinct values, a min/max
+// range filter is built instead.
+const runtimeFilterMaxInListLen = 256
+
+// runtimeFilterBuilder collects the values of a build side join key to build
+// a runtime filter on the corresponding probe side join key.
+type runtimeFilterBuilder struct {
+	sc       *stmtctx.StatementContext
+	buildKey *expression.Column
+	probeKey *expression.Column
+
+	hasValue bool
+	minValue types.Datum
+	maxValue types.Datum
+
+	// values are the distinct values of the build side key, it's set to nil
+	// once the number of values exceeds runtimeFilterMaxInListLen.
+	values   []types.Datum
+	distinct map[string]struct{}
+	buf      []byte
+}
+
+func newRuntimeFilterBuilder(sc *stmtctx.StatementContext, buildKey, probeKey *expression.Column) *runtimeFilterBuilder {
+	return &runtimeFilterBuilder{
+		sc:       sc,
+		buildKey: buildKey,
+		probeKey: probeKey,
+		distinct: make(map[string]struct{}),
+	}
+}
+
+func (b *runtimeFilterBuilder) appendChunk(chk *chunk.Chunk) error {
+	for i, numRows := 0, chk.NumRows(); i < numRows; i++ {
+		d := chk.GetRow(i).GetDatum(b.buildKey.Index, b.buildKey.RetType)
+		// NULL never matches any probe side row in an equal condition.
+		if d.IsNull() {
+			continue
+		}
+		if err := b.appendDatum(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *runtimeFilterBuilder) appendDatum(d types.Datum) error {
+	if !b.hasValue {
+		b.hasValue = true
+		b.minValue, b.maxValue = *d.Copy(), *d.Copy()
+	} else {
+		cmp, err := d.CompareDatum(b.sc, &b.minValue)
+		if err != nil {
+			return err
+		}
+		if cmp < 0 {
+			b.minValue = *d.Copy()
+		}
+		cmp, err = d.CompareDatum(b.sc, &b.maxValue)
+		if err != nil {
+			return err
+		}
+		if cmp > 0 {
+			b.maxValue = *d.Copy()
+		}
+	}
+
+	if b.distinct == nil {
+		return nil
+	}
+	var err error
+	b.buf, err = codec.EncodeKey(b.sc, b.buf[:0], d)
+	if err != nil {
+		return err
+	}
+	if _, ok := b.distinct[string(b.buf)]; ok {
+		return nil
+	}
+	if len(b.values) >= runtimeFilterMaxInListLen {
+		b.values, b.distinct = nil, nil
+		return nil
+	}
+	b.distinct[string(b.buf)] = struct{}{}
+	b.values = append(b.values, *d.Copy())
+	return nil
+}
+
+// build builds the filters on the probe side key:
+// 1. "false" if there is no non-null build side value;
+// 2. "probeKey in (values...)" if the number of distinct values is small;
+// 3. "probeKey >= min and probeKey <= max" otherwise.
+func (b *runtimeFilterBuilder) build(sctx sessionctx.Context) ([]expression.Expression, error) {
+	if !b.hasValue {
+		return []expression.Expression{expression.Zero}, nil
+	}
+	retTp := types.NewFieldType(mysql.TypeLonglong)
+	if b.values != nil {
+		args := make([]expression.Expression, 0, len(b.values)+1)
+		args = append(args, b.probeKey)
+		for _, v := range b.values {
+			args = append(args, &expression.Constant{Value: v, RetType: b.buildKey.RetType})
+		}
+		filter, err := expression.NewFunction(sctx, ast.In, retTp, args...)
+		if err != nil {
+			return nil, err
+		}
+		return []expression.Expression{filter}, nil
+	}
+	minFilter, err := expression.NewFunction(sctx, ast.GE, retTp, b.probeKey, &expression.Constant{Value: b.minValue, RetType: b.buildKey.RetType})
+	if err != nil {
+		return nil, err
+	}
+	maxFilter, err := expression.NewFunction(sctx, ast.LE, retTp, b.probeKey, &expression.Constant{Value: b.maxValue, RetType: b.buildKey.RetType})
+	if err != nil {
+		return nil, err
+	}
+	return []expression.Expression{minFilter, maxFilter}, nil
+}
+
+// buildRuntimeFilters builds the runtime filters of the probe side keys from
+// the build side rows, the filters which can't be pushed down are discarded.
+func buildRuntimeFilters(sctx sessionctx.Context, records *chunk.List, buildKeys, probeKeys []*expression.Column) ([]*tipb.Expr, error) {
+	sc := sctx.GetSessionVars().StmtCtx
+	pc := expression.NewPBConverter(sctx.GetClient(), sc)
+	pbFilters := make([]*tipb.Expr, 0, len(buildKeys))
+	for i := range buildKeys {
+		builder := newRuntimeFilterBuilder(sc, buildKeys[i], probeKeys[i])
+		for j := 0; j < records.NumChunks(); j++ {
+			if err := builder.appendChunk(records.GetChunk(j)); err != nil {
+				return nil, err
+			}
+		}
+		filters, err := builder.build(sctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, filter := range filters {
+			if pbFilter := pc.ExprToPB(filter); pbFilter != nil {
+				pbFilters = append(pbFilters, pbFilter)
+			}
+		}
+	}
+	return pbFilters, nil
+}
@@ -23,6 +23,8 @@ import (
 
 	"github.com/cznic/mathutil"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/bindinfo"
+	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/parser/ast"
@@ -33,8 +35,10 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/format"
 )
@@ -107,6 +111,44 @@ func (e *ShowExec) fetchAll(ctx context.Context) error {
 		return e.fetchShowWarnings(false)
 	case ast.ShowErrors:
 		return e.fetchShowWarnings(true)
+	case ast.ShowProcessList:
+		return e.fetchShowProcessList()
+	case ast.ShowUnusedIndexes:
+		return e.fetchShowUnusedIndexes()
+	case ast.ShowBindings:
+		return e.fetchShowBind()
+	}
+	return nil
+}
+
+// fetchShowBind lists the session bindings, or the global bindings if GlobalScope is set.
+func (e *ShowExec) fetchShowBind() error {
+	var bindRecords []*bindinfo.BindRecord
+	if !e.GlobalScope {
+		handle := e.ctx.Value(bindinfo.SessionBindInfoKeyType).(*bindinfo.SessionHandle)
+		bindRecords = handle.GetAllBindRecord()
+	} else {
+		bindRecords = domain.GetDomain(e.ctx).BindHandle().GetAllBindRecord()
+	}
+	sort.Slice(bindRecords, func(i, j int) bool {
+		if bindRecords[i].OriginalSQL != bindRecords[j].OriginalSQL {
+			return bindRecords[i].OriginalSQL < bindRecords[j].OriginalSQL
+		}
+		return bindRecords[i].Db < bindRecords[j].Db
+	})
+	for _, record := range bindRecords {
+		e.appendRow([]interface{}{
+			record.OriginalSQL,
+			record.BindSQL,
+			record.Db,
+			record.Status,
+			record.CreateTime,
+			record.UpdateTime,
+			record.Charset,
+			record.Collation,
+			record.UseCount(),
+			record.LastUsedTime(),
+		})
 	}
 	return nil
 }
@@ -137,6 +179,60 @@ func (e *ShowExec) fetchShowDatabases() error {
 	return nil
 }
 
+func (e *ShowExec) fetchShowProcessList() error {
+	sm := e.ctx.GetSessionManager()
+	if sm == nil {
+		return nil
+	}
+
+	pl := sm.ShowProcessList()
+	for _, pi := range pl {
+		e.appendRow(pi.ToRow(e.Full))
+	}
+	return nil
+}
+
+// fetchShowUnusedIndexes lists the indexes which have never been used since the usage is recorded.
+// If no database is selected, the indexes of all the user databases are listed.
+func (e *ShowExec) fetchShowUnusedIndexes() error {
+	dom := domain.GetDomain(e.ctx)
+	if dom == nil || dom.StatsHandle() == nil {
+		return nil
+	}
+	usage, err := dom.StatsHandle().GetIndexUsage()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var dbs []string
+	if e.DBName.L != "" {
+		if !e.is.SchemaExists(e.DBName) {
+			return ErrBadDB.GenWithStackByArgs(e.DBName)
+		}
+		dbs = []string{e.DBName.O}
+	} else {
+		for _, db := range e.is.AllSchemaNames() {
+			if !util.IsMemOrSysDB(strings.ToLower(db)) {
+				dbs = append(dbs, db)
+			}
+		}
+		sort.Strings(dbs)
+	}
+	for _, db := range dbs {
+		tbls := e.is.SchemaTables(model.NewCIStr(db))
+		sort.Slice(tbls, func(i, j int) bool { return tbls[i].Meta().Name.L < tbls[j].Meta().Name.L })
+		for _, tbl := range tbls {
+			tblInfo := tbl.Meta()
+			for _, idx := range tblInfo.Indices {
+				if _, ok := usage[statistics.GlobalIndexID{TableID: tblInfo.ID, IndexID: idx.ID}]; ok {
+					continue
+				}
+				e.appendRow([]interface{}{db, tblInfo.Name.O, idx.Name.O})
+			}
+		}
+	}
+	return nil
+}
+
 func (e *ShowExec) fetchShowTables() error {
 	if !e.is.SchemaExists(e.DBName) {
 		return ErrBadDB.GenWithStackByArgs(e.DBName)
@@ -15,6 +15,8 @@ package executor_test
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testutil"
 )
@@ -79,3 +81,50 @@ func (s *testSuite5) TestShowEscape(c *C) {
 	tk.MustExec("drop table \"t`abl\"\"e\"")
 	tk.MustExec("set sql_mode=@old_sql_mode")
 }
+
+func (s *testSuite5) TestShowUnusedIndexes(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database index_usage")
+	defer tk.MustExec("drop database index_usage")
+	tk.MustExec("use index_usage")
+	tk.MustExec("create table t (a int, b int, c int, index idx_a(a), index idx_b(b))")
+	tk.MustExec("insert into t values (1, 1, 1), (2, 2, 2), (3, 3, 3)")
+	tk.MustQuery("show unused indexes").Check(testkit.Rows("index_usage t idx_a", "index_usage t idx_b"))
+
+	tk.MustQuery("select a from t use index(idx_a) where a > 1").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select c from t use index(idx_a) where a = 1").Check(testkit.Rows("1"))
+	// The usage not dumped yet is shown as well.
+	tk.MustQuery("select table_name, index_name, query_count, rows_selected from information_schema.tidb_index_usage where table_schema = 'index_usage'").Check(
+		testkit.Rows("t idx_a 2 3"))
+	tk.MustQuery("show unused indexes").Check(testkit.Rows("index_usage t idx_b"))
+	c.Assert(domain.GetDomain(tk.Se).StatsHandle().DumpIndexUsageToKV(), IsNil)
+	tk.MustQuery("select table_name, index_name, query_count, rows_selected from information_schema.tidb_index_usage where table_schema = 'index_usage'").Check(
+		testkit.Rows("t idx_a 2 3"))
+	tk.MustQuery("show unused indexes").Check(testkit.Rows("index_usage t idx_b"))
+	// The usage in memory is merged into the dumped one.
+	tk.MustQuery("select a from t use index(idx_a) where a = 3").Check(testkit.Rows("3"))
+	tk.MustQuery("select table_name, index_name, query_count, rows_selected from information_schema.tidb_index_usage where table_schema = 'index_usage'").Check(
+		testkit.Rows("t idx_a 3 4"))
+	tk.MustQuery("show unused indexes from index_usage").Check(testkit.Rows("index_usage t idx_b"))
+	tk.MustGetErrCode("show unused indexes from not_exist", mysql.ErrBadDB)
+}
+
+func (s *testSuite5) TestPointGetIndexUsage(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database point_get_usage")
+	defer tk.MustExec("drop database point_get_usage")
+	tk.MustExec("use point_get_usage")
+	tk.MustExec("create table t (a int, b int, c int, unique index uk_a(a), unique index uk_b(b))")
+	tk.MustExec("insert into t values (1, 1, 1), (2, 2, 2), (3, 3, 3)")
+	tk.MustQuery("show unused indexes from point_get_usage").Check(testkit.Rows("point_get_usage t uk_a", "point_get_usage t uk_b"))
+
+	tk.MustQuery("select c from t where a = 1").Check(testkit.Rows("1"))
+	c.Assert(domain.GetDomain(tk.Se).StatsHandle().DumpIndexUsageToKV(), IsNil)
+	tk.MustQuery("show unused indexes from point_get_usage").Check(testkit.Rows("point_get_usage t uk_b"))
+
+	tk.MustQuery("select c from t where b in (2, 3, 4)").Sort().Check(testkit.Rows("2", "3"))
+	c.Assert(domain.GetDomain(tk.Se).StatsHandle().DumpIndexUsageToKV(), IsNil)
+	tk.MustQuery("select table_name, index_name, query_count, rows_selected from information_schema.tidb_index_usage where table_schema = 'point_get_usage'").Sort().Check(
+		testkit.Rows("t uk_a 1 1", "t uk_b 1 2"))
+	tk.MustQuery("show unused indexes from point_get_usage").Check(testkit.Rows())
+}
@@ -0,0 +1,376 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/spaolacci/murmur3"
+	"go.uber.org/zap"
+)
+
+// ShuffleExec is the executor to run other executors in a parallel manner.
+// Every data source, which is a child of the ShuffleExec, is fetched by a
+// fetcher goroutine, which splits the rows into N partitions by the hash of
+// the by items. N workers run in parallel, each of them runs a copy
+// of the child executors, e.g. a merge join, whose leaves are the receivers of
+// the partitions of all the data sources. The main goroutine collects the
+// outputs of the workers and sends them to the parent.
+type ShuffleExec struct {
+	baseExecutor
+	concurrency int
+	workers     []*shuffleWorker
+
+	prepared bool
+	executed bool
+
+	// each data source, which is a child of the ShuffleExec, has a corresponding splitter.
+	splitters []partitionSplitter
+
+	finishCh chan struct{}
+	outputCh chan *shuffleOutput
+
+	memTracker *memory.Tracker
+}
+
+type shuffleOutput struct {
+	chk        *chunk.Chunk
+	err        error
+	giveBackCh chan *chunk.Chunk
+}
+
+// Open implements the Executor Open interface.
+func (e *ShuffleExec) Open(ctx context.Context) error {
+	if err := e.baseExecutor.Open(ctx); err != nil {
+		return err
+	}
+	e.prepared = false
+	e.executed = false
+	if e.memTracker == nil {
+		e.memTracker = memory.NewTracker(e.id, -1)
+		e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
+	}
+
+	e.finishCh = make(chan struct{}, 1)
+	// Every worker has at most one output in the channel, and every fetcher
+	// sends at most one error, so the goroutines never block on the channel.
+	e.outputCh = make(chan *shuffleOutput, e.concurrency+len(e.children))
+	for _, w := range e.workers {
+		w.finishCh = e.finishCh
+		w.outputCh = e.outputCh
+		w.outputHolderCh = make(chan *chunk.Chunk, 1)
+		for _, r := range w.receivers {
+			r.reset(e.memTracker)
+		}
+		if err := w.childExec.Open(ctx); err != nil {
+			return err
+		}
+		w.outputHolderCh <- newFirstChunk(w.childExec)
+	}
+	return nil
+}
+
+// Close implements the Executor Close interface.
+func (e *ShuffleExec) Close() error {
+	if e.prepared {
+		close(e.finishCh)
+		for _, w := range e.workers {
+			for _, r := range w.receivers {
+				r.close()
+			}
+		}
+		// The output channel is closed after all the goroutines exit.
+		for range e.outputCh {
+		}
+	}
+	e.prepared = false
+	e.executed = false
+
+	firstErr := e.baseExecutor.Close()
+	for _, w := range e.workers {
+		if err := w.childExec.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
+	return firstErr
+}
+
+func (e *ShuffleExec) prepare4ParallelExec(ctx context.Context) {
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(len(e.children) + len(e.workers))
+	for i := range e.children {
+		go e.fetchDataAndSplit(ctx, i, waitGroup)
+	}
+	for _, w := range e.workers {
+		go w.run(ctx, waitGroup)
+	}
+	go e.waitAndCloseOutput(waitGroup)
+}
+
+func (e *ShuffleExec) waitAndCloseOutput(waitGroup *sync.WaitGroup) {
+	waitGroup.Wait()
+	close(e.outputCh)
+}
+
+// Next implements the Executor Next interface.
+func (e *ShuffleExec) Next(ctx context.Context, req *chunk.Chunk) error {
+	req.Reset()
+	if !e.prepared {
+		e.prepare4ParallelExec(ctx)
+		e.prepared = true
+	}
+	if e.executed {
+		return nil
+	}
+	result, ok := <-e.outputCh
+	if !ok {
+		e.executed = true
+		return nil
+	}
+	if result.err != nil {
+		return result.err
+	}
+	// The workers never send an empty chunk.
+	req.SwapColumns(result.chk)
+	result.giveBackCh <- result.chk
+	return nil
+}
+
+func recoveryShuffleExec(output chan *shuffleOutput, r interface{}) {
+	err := errors.Errorf("%v", r)
+	output <- &shuffleOutput{err: err}
+	logutil.BgLogger().Error("shuffle panicked", zap.Error(err))
+}
+
+// fetchDataAndSplit fetches the rows of a data source, and sends every row to
+// the receiver of the worker chosen by the splitter.
+func (e *ShuffleExec) fetchDataAndSplit(ctx context.Context, dataSourceIndex int, waitGroup *sync.WaitGroup) {
+	var (
+		err           error
+		workerIndices []int
+	)
+	dataSource := e.children[dataSourceIndex]
+	results := make([]*chunk.Chunk, len(e.workers))
+	chk := newFirstChunk(dataSource)
+
+	defer func() {
+		if r := recover(); r != nil {
+			recoveryShuffleExec(e.outputCh, r)
+		}
+		for _, w := range e.workers {
+			w.receivers[dataSourceIndex].close()
+		}
+		waitGroup.Done()
+	}()
+
+	for {
+		select {
+		case <-e.finishCh:
+			return
+		default:
+		}
+		err = Next(ctx, dataSource, chk)
+		if err != nil {
+			e.outputCh <- &shuffleOutput{err: err}
+			return
+		}
+		if chk.NumRows() == 0 {
+			break
+		}
+
+		workerIndices, err = e.splitters[dataSourceIndex].split(e.ctx, chk, workerIndices)
+		if err != nil {
+			e.outputCh <- &shuffleOutput{err: err}
+			return
+		}
+		numRows := chk.NumRows()
+		for i := 0; i < numRows; i++ {
+			workerIdx := workerIndices[i]
+			receiver := e.workers[workerIdx].receivers[dataSourceIndex]
+			if results[workerIdx] == nil {
+				results[workerIdx] = receiver.allocChunk()
+			}
+			results[workerIdx].AppendRow(chk.GetRow(i))
+			if results[workerIdx].IsFull() {
+				receiver.send(results[workerIdx])
+				results[workerIdx] = nil
+			}
+		}
+	}
+	for i, w := range e.workers {
+		if results[i] != nil {
+			w.receivers[dataSourceIndex].send(results[i])
+			results[i] = nil
+		}
+	}
+}
+
+// shuffleWorker runs a copy of the child executors of ShuffleExec, the leaves
+// of the child executors are its receivers.
+type shuffleWorker struct {
+	childExec Executor
+	// each receiver corresponds to a data source.
+	receivers []*shuffleReceiver
+
+	finishCh <-chan struct{}
+	outputCh chan *shuffleOutput
+	// outputHolderCh is used to reuse the output chunk.
+	outputHolderCh chan *chunk.Chunk
+}
+
+func (w *shuffleWorker) run(ctx context.Context, waitGroup *sync.WaitGroup) {
+	defer func() {
+		if r := recover(); r != nil {
+			recoveryShuffleExec(w.outputCh, r)
+		}
+		waitGroup.Done()
+	}()
+
+	for {
+		select {
+		case <-w.finishCh:
+			return
+		case chk := <-w.outputHolderCh:
+			if err := Next(ctx, w.childExec, chk); err != nil {
+				w.outputCh <- &shuffleOutput{err: err}
+				return
+			}
+			if chk.NumRows() == 0 {
+				return
+			}
+			w.outputCh <- &shuffleOutput{chk: chk, giveBackCh: w.outputHolderCh}
+		}
+	}
+}
+
+// shuffleReceiver receives the rows of a partition of a data source for a
+// worker, it's a leaf of the child executors of the worker.
+type shuffleReceiver struct {
+	baseExecutor
+
+	mu struct {
+		sync.Mutex
+		cond *sync.Cond
+		// chks are the chunks sent by the fetcher but not received yet. The
+		// queue is unbounded, so a fetcher never waits for a slow worker,
+		// otherwise the workers may wait for each other through the fetchers,
+		// e.g. a merge join worker waits for its inner rows while the fetcher
+		// of the inner side waits for another worker, which waits for its outer
+		// rows from the fetcher of the outer side, which waits for the first
+		// worker.
+		chks []*chunk.Chunk
+		// free are the received chunks, which are reused by the fetcher.
+		free   []*chunk.Chunk
+		closed bool
+	}
+
+	memTracker *memory.Tracker
+}
+
+func (r *shuffleReceiver) reset(memTracker *memory.Tracker) {
+	r.mu.cond = sync.NewCond(&r.mu)
+	r.mu.chks = nil
+	r.mu.free = nil
+	r.mu.closed = false
+	r.memTracker = memTracker
+}
+
+// allocChunk returns a chunk for the fetcher to fill, the received chunks are
+// reused if possible.
+func (r *shuffleReceiver) allocChunk() *chunk.Chunk {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n := len(r.mu.free); n > 0 {
+		chk := r.mu.free[n-1]
+		r.mu.free = r.mu.free[:n-1]
+		chk.Reset()
+		return chk
+	}
+	return newFirstChunk(r)
+}
+
+// send is called by the fetcher to send a chunk to the receiver.
+func (r *shuffleReceiver) send(chk *chunk.Chunk) {
+	r.memTracker.Consume(chk.MemoryUsage())
+	r.mu.Lock()
+	r.mu.chks = append(r.mu.chks, chk)
+	r.mu.Unlock()
+	r.mu.cond.Signal()
+}
+
+// close marks that no more chunks are sent to the receiver.
+func (r *shuffleReceiver) close() {
+	r.mu.Lock()
+	r.mu.closed = true
+	r.mu.Unlock()
+	r.mu.cond.Broadcast()
+}
+
+// Next implements the Executor Next interface.
+func (r *shuffleReceiver) Next(ctx context.Context, req *chunk.Chunk) error {
+	req.Reset()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.mu.chks) == 0 && !r.mu.closed {
+		r.mu.cond.Wait()
+	}
+	if len(r.mu.chks) == 0 {
+		return nil
+	}
+	chk := r.mu.chks[0]
+	r.mu.chks = r.mu.chks[1:]
+	r.memTracker.Consume(-chk.MemoryUsage())
+	req.SwapColumns(chk)
+	r.mu.free = append(r.mu.free, chk)
+	return nil
+}
+
+// partitionSplitter splits the rows of a data source into partitions.
+type partitionSplitter interface {
+	// split returns the partition index of every row of the input.
+	split(ctx sessionctx.Context, input *chunk.Chunk, workerIndices []int) ([]int, error)
+}
+
+// partitionHashSplitter splits the rows by the hash of the by items, so the
+// rows with equal by items are always in the same partition.
+type partitionHashSplitter struct {
+	byItems    []expression.Expression
+	numWorkers int
+	hashKeys   [][]byte
+}
+
+func (s *partitionHashSplitter) split(ctx sessionctx.Context, input *chunk.Chunk, workerIndices []int) ([]int, error) {
+	var err error
+	s.hashKeys, err = getGroupKey(ctx, input, s.hashKeys, s.byItems)
+	if err != nil {
+		return workerIndices, err
+	}
+	workerIndices = workerIndices[:0]
+	numRows := input.NumRows()
+	for i := 0; i < numRows; i++ {
+		workerIndices = append(workerIndices, int(murmur3.Sum32(s.hashKeys[i]))%s.numWorkers)
+	}
+	return workerIndices, nil
+}
@@ -15,6 +15,7 @@ package executor
 
 import (
 	"context"
+	"strings"
 
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/parser/ast"
@@ -29,7 +30,7 @@ import (
 
 // SimpleExec represents simple statement executor.
 // For statements do simple execution.
-// includes `UseStmt`,`BeginStmt`, `CommitStmt` and `RollbackStmt`.
+// includes `UseStmt`,`BeginStmt`, `CommitStmt`, `RollbackStmt` and `KillStmt`.
 type SimpleExec struct {
 	baseExecutor
 
@@ -53,6 +54,8 @@ func (e *SimpleExec) Next(ctx context.Context, req *chunk.Chunk) (err error) {
 		e.executeCommit(x)
 	case *ast.RollbackStmt:
 		err = e.executeRollback(x)
+	case *ast.KillStmt:
+		err = e.executeKill(x)
 	}
 	e.done = true
 	return err
@@ -112,3 +115,33 @@ func (e *SimpleExec) executeRollback(s *ast.RollbackStmt) error {
 	}
 	return nil
 }
+
+// superUser is regarded as the user with the SUPER privilege, since there is
+// no privilege system and it is the only built-in user.
+const superUser = "root"
+
+func (e *SimpleExec) executeKill(s *ast.KillStmt) error {
+	sm := e.ctx.GetSessionManager()
+	if sm == nil {
+		return nil
+	}
+	pi, ok := sm.GetProcessInfo(s.ConnectionID)
+	if !ok {
+		return ErrNoSuchThread.GenWithStackByArgs(s.ConnectionID)
+	}
+	// A user can only kill its own connections unless it has the SUPER privilege.
+	if user := e.currentUserName(); user != superUser && user != pi.User {
+		return ErrKillDenied.GenWithStackByArgs(s.ConnectionID)
+	}
+	return sm.Kill(s.ConnectionID, s.Query)
+}
+
+// currentUserName returns the name of the user of the session, the host is
+// not included.
+func (e *SimpleExec) currentUserName() string {
+	user := e.ctx.GetSessionVars().User
+	if idx := strings.LastIndexByte(user, '@'); idx >= 0 {
+		return user[:idx]
+	}
+	return user
+}
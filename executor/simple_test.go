@@ -15,10 +15,12 @@ package executor_test
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
 	"github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/testkit"
 )
 
@@ -82,3 +84,63 @@ func (s *testSuite3) TestUseDB(c *C) {
 	_, err = tk.Exec("USE ``")
 	c.Assert(terror.ErrorEqual(core.ErrNoDB, err), IsTrue, Commentf("err %v", err))
 }
+
+type mockSessionManager struct {
+	processes    map[uint64]*util.ProcessInfo
+	killedConnID uint64
+	killedQuery  bool
+}
+
+// ShowProcessList implements the SessionManager.ShowProcessList interface.
+func (msm *mockSessionManager) ShowProcessList() map[uint64]*util.ProcessInfo {
+	return msm.processes
+}
+
+// GetProcessInfo implements the SessionManager.GetProcessInfo interface.
+func (msm *mockSessionManager) GetProcessInfo(id uint64) (*util.ProcessInfo, bool) {
+	pi, ok := msm.processes[id]
+	return pi, ok
+}
+
+// Kill implements the SessionManager.Kill interface.
+func (msm *mockSessionManager) Kill(connectionID uint64, query bool) error {
+	msm.killedConnID = connectionID
+	msm.killedQuery = query
+	return nil
+}
+
+func (s *testSuite3) TestKillStmt(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	sm := &mockSessionManager{processes: make(map[uint64]*util.ProcessInfo)}
+	for id := uint64(1); id <= 3; id++ {
+		sm.processes[id] = &util.ProcessInfo{ID: id, User: "u1"}
+	}
+	sm.processes[4] = &util.ProcessInfo{ID: 4, User: "u2"}
+	tk.Se.SetSessionManager(sm)
+	tk.Se.GetSessionVars().User = "u1@127.0.0.1"
+
+	tk.MustExec("kill 1")
+	c.Assert(sm.killedConnID, Equals, uint64(1))
+	c.Assert(sm.killedQuery, IsFalse)
+
+	tk.MustExec("kill connection 2")
+	c.Assert(sm.killedConnID, Equals, uint64(2))
+	c.Assert(sm.killedQuery, IsFalse)
+
+	tk.MustExec("kill query 3")
+	c.Assert(sm.killedConnID, Equals, uint64(3))
+	c.Assert(sm.killedQuery, IsTrue)
+
+	// The connection doesn't exist.
+	_, err := tk.Exec("kill 5")
+	c.Assert(executor.ErrNoSuchThread.Equal(err), IsTrue, Commentf("err %v", err))
+	// The connection is owned by another user.
+	_, err = tk.Exec("kill 4")
+	c.Assert(executor.ErrKillDenied.Equal(err), IsTrue, Commentf("err %v", err))
+	c.Assert(sm.killedConnID, Equals, uint64(3))
+
+	// The super user can kill the connections of the other users.
+	tk.Se.GetSessionVars().User = "root@127.0.0.1"
+	tk.MustExec("kill 4")
+	c.Assert(sm.killedConnID, Equals, uint64(4))
+}
@@ -16,13 +16,23 @@ package executor
 import (
 	"container/heap"
 	"context"
+	"fmt"
 	"sort"
+	"sync"
 
+	"github.com/pingcap/errors"
+	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/expression"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/stringutil"
+	"go.uber.org/zap"
 )
 
+var rowChunksLabel fmt.Stringer = stringutil.StringerStr("rowChunks")
+
 // SortExec represents sorting executor.
 type SortExec struct {
 	baseExecutor
@@ -32,6 +42,9 @@ type SortExec struct {
 	fetched bool
 	schema  *expression.Schema
 
+	// concurrency is the number of workers to sort the rows in parallel.
+	concurrency int
+
 	// keyColumns is the column index of the by items.
 	keyColumns []int
 	// keyCmpFuncs is used to compare each ByItem.
@@ -40,10 +53,16 @@ type SortExec struct {
 	rowChunks *chunk.List
 	// rowPointer store the chunk index and row index for each row.
 	rowPtrs []chunk.RowPtr
+
+	memTracker *memory.Tracker
 }
 
 // Close implements the Executor Close interface.
 func (e *SortExec) Close() error {
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
 	return e.children[0].Close()
 }
 
@@ -51,6 +70,12 @@ func (e *SortExec) Close() error {
 func (e *SortExec) Open(ctx context.Context) error {
 	e.fetched = false
 	e.Idx = 0
+
+	// To avoid duplicated initialization for TopNExec.
+	if e.memTracker == nil {
+		e.memTracker = memory.NewTracker(e.id, -1)
+		e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
+	}
 	return e.children[0].Open(ctx)
 }
 
@@ -65,7 +90,10 @@ func (e *SortExec) Next(ctx context.Context, req *chunk.Chunk) error {
 		e.initPointers()
 		e.initCompareFuncs()
 		e.buildKeyColumns()
-		sort.Slice(e.rowPtrs, e.keyColumnsLess)
+		err = e.sortRowPtrs()
+		if err != nil {
+			return err
+		}
 		e.fetched = true
 	}
 	for !req.IsFull() && e.Idx < len(e.rowPtrs) {
@@ -79,6 +107,8 @@ func (e *SortExec) Next(ctx context.Context, req *chunk.Chunk) error {
 func (e *SortExec) fetchRowChunks(ctx context.Context) error {
 	fields := retTypes(e)
 	e.rowChunks = chunk.NewList(fields, e.initCap, e.maxChunkSize)
+	e.rowChunks.GetMemTracker().AttachTo(e.memTracker)
+	e.rowChunks.GetMemTracker().SetLabel(rowChunksLabel)
 	for {
 		chk := newFirstChunk(e.children[0])
 		err := Next(ctx, e.children[0], chk)
@@ -96,6 +126,7 @@ func (e *SortExec) fetchRowChunks(ctx context.Context) error {
 
 func (e *SortExec) initPointers() {
 	e.rowPtrs = make([]chunk.RowPtr, 0, e.rowChunks.Len())
+	e.memTracker.Consume(int64(8 * e.rowChunks.Len()))
 	for chkIdx := 0; chkIdx < e.rowChunks.NumChunks(); chkIdx++ {
 		rowChk := e.rowChunks.GetChunk(chkIdx)
 		for rowIdx := 0; rowIdx < rowChk.NumRows(); rowIdx++ {
@@ -120,20 +151,25 @@ func (e *SortExec) buildKeyColumns() {
 	}
 }
 
-func (e *SortExec) lessRow(rowI, rowJ chunk.Row) bool {
+// compareRow compares two rows by the key columns, it returns a negative value
+// if rowI should be placed before rowJ, a positive value if after and 0 if
+// their keys are equal.
+func (e *SortExec) compareRow(rowI, rowJ chunk.Row) int {
 	for i, colIdx := range e.keyColumns {
 		cmpFunc := e.keyCmpFuncs[i]
 		cmp := cmpFunc(rowI, colIdx, rowJ, colIdx)
 		if e.ByItems[i].Desc {
 			cmp = -cmp
 		}
-		if cmp < 0 {
-			return true
-		} else if cmp > 0 {
-			return false
+		if cmp != 0 {
+			return cmp
 		}
 	}
-	return false
+	return 0
+}
+
+func (e *SortExec) lessRow(rowI, rowJ chunk.Row) bool {
+	return e.compareRow(rowI, rowJ) < 0
 }
 
 // keyColumnsLess is the less function for key columns.
@@ -143,47 +179,165 @@ func (e *SortExec) keyColumnsLess(i, j int) bool {
 	return e.lessRow(rowI, rowJ)
 }
 
+// sortMinRowsPerWorker is the min number of rows sorted by a sort worker, the
+// rows are sorted in parallel only if there are enough rows for two workers.
+const sortMinRowsPerWorker = 1024
+
+// sortRowPtrs sorts the row pointers stably, so the rows with equal keys are
+// kept in the order they are fetched from the child. The row pointers are split
+// into continuous runs which are sorted by the workers concurrently, then the
+// sorted runs are merged. A tie in the merge is broken by the order of the runs,
+// so the result is the same as sorting all the rows in one goroutine.
+func (e *SortExec) sortRowPtrs() error {
+	concurrency := e.concurrency
+	if maxConcurrency := len(e.rowPtrs) / sortMinRowsPerWorker; concurrency > maxConcurrency {
+		concurrency = maxConcurrency
+	}
+	if concurrency <= 1 {
+		sort.SliceStable(e.rowPtrs, e.keyColumnsLess)
+		return nil
+	}
+
+	runs := make([][]chunk.RowPtr, 0, concurrency)
+	runSize := (len(e.rowPtrs) + concurrency - 1) / concurrency
+	for start := 0; start < len(e.rowPtrs); start += runSize {
+		end := start + runSize
+		if end > len(e.rowPtrs) {
+			end = len(e.rowPtrs)
+		}
+		runs = append(runs, e.rowPtrs[start:end])
+	}
+	errs := make([]error, len(runs))
+	wg := &sync.WaitGroup{}
+	for i := range runs {
+		wg.Add(1)
+		go e.runSortWorker(wg, runs[i], &errs[i])
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	sortedPtrs := make([]chunk.RowPtr, 0, len(e.rowPtrs))
+	e.memTracker.Consume(int64(8 * len(e.rowPtrs)))
+	h := &sortedRunsHeap{SortExec: e, runs: runs}
+	for i := range runs {
+		h.heads = append(h.heads, i)
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		runIdx := h.heads[0]
+		sortedPtrs = append(sortedPtrs, h.runs[runIdx][0])
+		h.runs[runIdx] = h.runs[runIdx][1:]
+		if len(h.runs[runIdx]) == 0 {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+	e.memTracker.Consume(int64(-8 * len(e.rowPtrs)))
+	e.rowPtrs = sortedPtrs
+	return nil
+}
+
+func (e *SortExec) runSortWorker(wg *sync.WaitGroup, run []chunk.RowPtr, err *error) {
+	defer func() {
+		if r := recover(); r != nil {
+			*err = errors.Errorf("%v", r)
+			logutil.BgLogger().Error("parallel sort panicked", zap.Error(*err))
+		}
+		wg.Done()
+	}()
+	sort.SliceStable(run, func(i, j int) bool {
+		return e.lessRow(e.rowChunks.GetRow(run[i]), e.rowChunks.GetRow(run[j]))
+	})
+}
+
+// sortedRunsHeap implements heap.Interface, it's used to merge the sorted runs.
+// The heap top is the run whose first row should be output next.
+type sortedRunsHeap struct {
+	*SortExec
+	runs [][]chunk.RowPtr
+	// heads are the indexes of the runs which still have rows to merge.
+	heads []int
+}
+
+func (h *sortedRunsHeap) Less(i, j int) bool {
+	runI, runJ := h.heads[i], h.heads[j]
+	rowI := h.rowChunks.GetRow(h.runs[runI][0])
+	rowJ := h.rowChunks.GetRow(h.runs[runJ][0])
+	if cmp := h.compareRow(rowI, rowJ); cmp != 0 {
+		return cmp < 0
+	}
+	return runI < runJ
+}
+
+func (h *sortedRunsHeap) Len() int {
+	return len(h.heads)
+}
+
+func (h *sortedRunsHeap) Push(x interface{}) {
+	// Should never be called.
+}
+
+func (h *sortedRunsHeap) Pop() interface{} {
+	h.heads = h.heads[:len(h.heads)-1]
+	// We don't need the popped value, return nil to avoid memory allocation.
+	return nil
+}
+
+func (h *sortedRunsHeap) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+
 // TopNExec implements a Top-N algorithm and it is built from a SELECT statement with ORDER BY and LIMIT.
 // Instead of sorting all the rows fetched from the table, it keeps the Top-N elements only in a heap to reduce memory usage.
+// The rows fetched from the child are dispatched to several workers, each worker keeps the Top-N elements of its
+// own rows in a heap, and the heaps are merged to get the final Top-N elements after all the rows are processed.
+// All the heaps are tracked by the memory tracker of the executor, and only one worker is used if N is large.
 type TopNExec struct {
 	SortExec
 	limit      *plannercore.PhysicalLimit
 	totalLimit uint64
-
-	chkHeap *topNChunkHeap
 }
 
 // topNChunkHeap implements heap.Interface.
 type topNChunkHeap struct {
 	*TopNExec
+
+	chunks *chunk.List
+	ptrs   []chunk.RowPtr
+	// seqs are the sequence numbers of the rows in the child's output. They
+	// break the ties of the rows with equal keys, so the Top-N elements are
+	// the same no matter how the rows are dispatched to the workers.
+	seqs []uint64
+}
+
+func (e *TopNExec) newTopNChunkHeap() *topNChunkHeap {
+	h := &topNChunkHeap{
+		TopNExec: e,
+		chunks:   chunk.NewList(retTypes(e), e.initCap, e.maxChunkSize),
+	}
+	h.chunks.GetMemTracker().AttachTo(e.memTracker)
+	h.chunks.GetMemTracker().SetLabel(rowChunksLabel)
+	return h
 }
 
 // Less implement heap.Interface, but since we mantains a max heap,
 // this function returns true if row i is greater than row j.
 func (h *topNChunkHeap) Less(i, j int) bool {
-	rowI := h.rowChunks.GetRow(h.rowPtrs[i])
-	rowJ := h.rowChunks.GetRow(h.rowPtrs[j])
-	return h.greaterRow(rowI, rowJ)
-}
-
-func (h *topNChunkHeap) greaterRow(rowI, rowJ chunk.Row) bool {
-	for i, colIdx := range h.keyColumns {
-		cmpFunc := h.keyCmpFuncs[i]
-		cmp := cmpFunc(rowI, colIdx, rowJ, colIdx)
-		if h.ByItems[i].Desc {
-			cmp = -cmp
-		}
-		if cmp > 0 {
-			return true
-		} else if cmp < 0 {
-			return false
-		}
+	rowI := h.chunks.GetRow(h.ptrs[i])
+	rowJ := h.chunks.GetRow(h.ptrs[j])
+	if cmp := h.compareRow(rowI, rowJ); cmp != 0 {
+		return cmp > 0
 	}
-	return false
+	return h.seqs[i] > h.seqs[j]
 }
 
 func (h *topNChunkHeap) Len() int {
-	return len(h.rowPtrs)
+	return len(h.ptrs)
 }
 
 func (h *topNChunkHeap) Push(x interface{}) {
@@ -191,13 +345,97 @@ func (h *topNChunkHeap) Push(x interface{}) {
 }
 
 func (h *topNChunkHeap) Pop() interface{} {
-	h.rowPtrs = h.rowPtrs[:len(h.rowPtrs)-1]
+	h.ptrs = h.ptrs[:len(h.ptrs)-1]
+	h.seqs = h.seqs[:len(h.seqs)-1]
 	// We don't need the popped value, return nil to avoid memory allocation.
 	return nil
 }
 
 func (h *topNChunkHeap) Swap(i, j int) {
-	h.rowPtrs[i], h.rowPtrs[j] = h.rowPtrs[j], h.rowPtrs[i]
+	h.ptrs[i], h.ptrs[j] = h.ptrs[j], h.ptrs[i]
+	h.seqs[i], h.seqs[j] = h.seqs[j], h.seqs[i]
+}
+
+// processChk keeps the Top-N rows of the heap and the chunk in the heap, seq
+// is the sequence number of the first row of the chunk in the child's output.
+func (h *topNChunkHeap) processChk(chk *chunk.Chunk, seq uint64) {
+	numRows, i := chk.NumRows(), 0
+	for ; i < numRows && uint64(len(h.ptrs)) < h.totalLimit; i++ {
+		h.ptrs = append(h.ptrs, h.chunks.AppendRow(chk.GetRow(i)))
+		h.seqs = append(h.seqs, seq+uint64(i))
+		if uint64(len(h.ptrs)) == h.totalLimit {
+			heap.Init(h)
+		}
+	}
+	h.memTracker.Consume(int64(16 * i))
+	for ; i < numRows; i++ {
+		row := chk.GetRow(i)
+		// The chunks are processed in the order of the child's output, so the
+		// sequence number of the row is greater than the ones in the heap, and
+		// the row is kept only if it's less than the heap max.
+		if h.compareRow(h.chunks.GetRow(h.ptrs[0]), row) > 0 {
+			// Evict heap max, keep the next row.
+			h.ptrs[0] = h.chunks.AppendRow(row)
+			h.seqs[0] = seq + uint64(i)
+			heap.Fix(h, 0)
+		}
+	}
+	if h.chunks.Len() > len(h.ptrs)*topNCompactionFactor {
+		h.doCompaction()
+	}
+}
+
+const topNCompactionFactor = 4
+
+// doCompaction rebuild the chunks and row pointers to release memory.
+// If we don't do compaction, in a extreme case like the child data is already ascending sorted
+// but we want descending top N, then we will keep all data in memory.
+// But if data is distributed randomly, this function will be called log(n) times.
+func (h *topNChunkHeap) doCompaction() {
+	newChunks := chunk.NewList(retTypes(h.TopNExec), h.initCap, h.maxChunkSize)
+	for i, ptr := range h.ptrs {
+		h.ptrs[i] = newChunks.AppendRow(h.chunks.GetRow(ptr))
+	}
+	newChunks.GetMemTracker().SetLabel(rowChunksLabel)
+	h.memTracker.ReplaceChild(h.chunks.GetMemTracker(), newChunks.GetMemTracker())
+	h.chunks = newChunks
+}
+
+// topNWorkerTask is a chunk of the child's output to be processed by a topN worker.
+type topNWorkerTask struct {
+	chk *chunk.Chunk
+	// seq is the sequence number of the first row of chk in the child's output.
+	seq uint64
+}
+
+// topNWorker keeps the Top-N rows of the chunks dispatched to it in its heap.
+type topNWorker struct {
+	heap   *topNChunkHeap
+	taskCh chan *topNWorkerTask
+	// giveBackCh is used to give the processed chunks back to the main
+	// goroutine, so that they can be reused to fetch the child's output.
+	giveBackCh chan<- *chunk.Chunk
+	err        error
+}
+
+func (w *topNWorker) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for task := range w.taskCh {
+		if w.err == nil {
+			w.processTask(task)
+		}
+		w.giveBackCh <- task.chk
+	}
+}
+
+func (w *topNWorker) processTask(task *topNWorkerTask) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.err = errors.Errorf("%v", r)
+			logutil.BgLogger().Error("parallel topN panicked", zap.Error(w.err))
+		}
+	}()
+	w.heap.processChk(task.chk, task.seq)
 }
 
 // Open implements the Executor Open interface.
@@ -211,13 +449,14 @@ func (e *TopNExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	if !e.fetched {
 		e.totalLimit = e.limit.Offset + e.limit.Count
 		e.Idx = int(e.limit.Offset)
-		err := e.loadChunksUntilTotalLimit(ctx)
-		if err != nil {
-			return err
-		}
-		err = e.executeTopN(ctx)
-		if err != nil {
-			return err
+		e.rowPtrs = nil
+		e.initCompareFuncs()
+		e.buildKeyColumns()
+		if e.totalLimit > 0 {
+			err := e.executeTopN(ctx)
+			if err != nil {
+				return err
+			}
 		}
 		e.fetched = true
 	}
@@ -232,87 +471,125 @@ func (e *TopNExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	return nil
 }
 
-func (e *TopNExec) loadChunksUntilTotalLimit(ctx context.Context) error {
-	e.chkHeap = &topNChunkHeap{e}
-	e.rowChunks = chunk.NewList(retTypes(e), e.initCap, e.maxChunkSize)
-	for uint64(e.rowChunks.Len()) < e.totalLimit {
-		srcChk := newFirstChunk(e.children[0])
-		// adjust required rows by total limit
-		srcChk.SetRequiredRows(int(e.totalLimit-uint64(e.rowChunks.Len())), e.maxChunkSize)
-		err := Next(ctx, e.children[0], srcChk)
-		if err != nil {
-			return err
+// topNMaxParallelLimit is the max total limit to process the rows in
+// parallel. Every worker keeps up to total limit rows in its heap, so the
+// rows are processed by one worker if the limit is large, to avoid keeping
+// concurrency times of the rows in memory.
+const topNMaxParallelLimit = 4096
+
+func (e *TopNExec) executeTopN(ctx context.Context) error {
+	concurrency := e.concurrency
+	if concurrency < 1 || e.totalLimit > topNMaxParallelLimit {
+		concurrency = 1
+	}
+	failpoint.Inject("checkTopNConcurrency", func(val failpoint.Value) {
+		if val.(int) != concurrency {
+			failpoint.Return(errors.Errorf("mock topN concurrency %d, expected %d", concurrency, val.(int)))
 		}
-		if srcChk.NumRows() == 0 {
-			break
+	})
+	// Every worker has at most one chunk being processed and one chunk waiting
+	// in its task channel, so the give back channel never blocks the workers.
+	giveBackCh := make(chan *chunk.Chunk, 2*concurrency)
+	workers := make([]*topNWorker, concurrency)
+	wg := &sync.WaitGroup{}
+	for i := range workers {
+		workers[i] = &topNWorker{
+			heap:       e.newTopNChunkHeap(),
+			taskCh:     make(chan *topNWorkerTask, 1),
+			giveBackCh: giveBackCh,
 		}
-		e.rowChunks.Add(srcChk)
+		giveBackCh <- newFirstChunk(e.children[0])
+		giveBackCh <- newFirstChunk(e.children[0])
+		wg.Add(1)
+		go workers[i].run(wg)
+	}
+	err := e.dispatchChildChunks(ctx, workers, giveBackCh)
+	for _, w := range workers {
+		close(w.taskCh)
 	}
-	e.initPointers()
-	e.initCompareFuncs()
-	e.buildKeyColumns()
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	heaps := make([]*topNChunkHeap, 0, len(workers))
+	for _, w := range workers {
+		if w.err != nil {
+			return w.err
+		}
+		heaps = append(heaps, w.heap)
+	}
+	e.mergeHeaps(heaps)
 	return nil
 }
 
-const topNCompactionFactor = 4
-
-func (e *TopNExec) executeTopN(ctx context.Context) error {
-	heap.Init(e.chkHeap)
-	for uint64(len(e.rowPtrs)) > e.totalLimit {
-		// The number of rows we loaded may exceeds total limit, remove greatest rows by Pop.
-		heap.Pop(e.chkHeap)
-	}
-	childRowChk := newFirstChunk(e.children[0])
-	for {
-		err := Next(ctx, e.children[0], childRowChk)
-		if err != nil {
-			return err
+// dispatchChildChunks fetches the child's output and dispatches the chunks to
+// the workers in turn.
+func (e *TopNExec) dispatchChildChunks(ctx context.Context, workers []*topNWorker, giveBackCh <-chan *chunk.Chunk) error {
+	var seq uint64
+	for i := 0; ; i++ {
+		chk := <-giveBackCh
+		if seq < e.totalLimit {
+			// adjust required rows by total limit
+			chk.SetRequiredRows(int(e.totalLimit-seq), e.maxChunkSize)
+		} else {
+			chk.SetRequiredRows(e.maxChunkSize, e.maxChunkSize)
 		}
-		if childRowChk.NumRows() == 0 {
-			break
-		}
-		err = e.processChildChk(childRowChk)
+		err := Next(ctx, e.children[0], chk)
 		if err != nil {
 			return err
 		}
-		if e.rowChunks.Len() > len(e.rowPtrs)*topNCompactionFactor {
-			err = e.doCompaction()
-			if err != nil {
-				return err
-			}
+		if chk.NumRows() == 0 {
+			return nil
 		}
+		workers[i%len(workers)].taskCh <- &topNWorkerTask{chk: chk, seq: seq}
+		seq += uint64(chk.NumRows())
 	}
-	sort.Slice(e.rowPtrs, e.keyColumnsLess)
-	return nil
 }
 
-func (e *TopNExec) processChildChk(childRowChk *chunk.Chunk) error {
-	for i := 0; i < childRowChk.NumRows(); i++ {
-		heapMaxPtr := e.rowPtrs[0]
-		var heapMax, next chunk.Row
-		heapMax = e.rowChunks.GetRow(heapMaxPtr)
-		next = childRowChk.GetRow(i)
-		if e.chkHeap.greaterRow(heapMax, next) {
-			// Evict heap max, keep the next row.
-			e.rowPtrs[0] = e.rowChunks.AppendRow(childRowChk.GetRow(i))
-			heap.Fix(e.chkHeap, 0)
+// mergeHeaps sorts the rows kept in the heaps, and keeps the first totalLimit
+// rows as the result. The rows are ordered by their keys and then by their
+// sequence numbers, so the result is the same as processing all the rows in
+// one heap.
+func (e *TopNExec) mergeHeaps(heaps []*topNChunkHeap) {
+	type heapRow struct {
+		h   *topNChunkHeap
+		idx int
+	}
+	numRows := 0
+	for _, h := range heaps {
+		numRows += len(h.ptrs)
+	}
+	rows := make([]heapRow, 0, numRows)
+	e.memTracker.Consume(int64(16 * numRows))
+	defer e.memTracker.Consume(int64(-16 * numRows))
+	for _, h := range heaps {
+		for i := range h.ptrs {
+			rows = append(rows, heapRow{h: h, idx: i})
 		}
 	}
-	return nil
-}
+	sort.Slice(rows, func(i, j int) bool {
+		hI, hJ := rows[i].h, rows[j].h
+		rowI := hI.chunks.GetRow(hI.ptrs[rows[i].idx])
+		rowJ := hJ.chunks.GetRow(hJ.ptrs[rows[j].idx])
+		if cmp := e.compareRow(rowI, rowJ); cmp != 0 {
+			return cmp < 0
+		}
+		return hI.seqs[rows[i].idx] < hJ.seqs[rows[j].idx]
+	})
+	if uint64(len(rows)) > e.totalLimit {
+		rows = rows[:e.totalLimit]
+	}
 
-// doCompaction rebuild the chunks and row pointers to release memory.
-// If we don't do compaction, in a extreme case like the child data is already ascending sorted
-// but we want descending top N, then we will keep all data in memory.
-// But if data is distributed randomly, this function will be called log(n) times.
-func (e *TopNExec) doCompaction() error {
-	newRowChunks := chunk.NewList(retTypes(e), e.initCap, e.maxChunkSize)
-	newRowPtrs := make([]chunk.RowPtr, 0, e.rowChunks.Len())
-	for _, rowPtr := range e.rowPtrs {
-		newRowPtr := newRowChunks.AppendRow(e.rowChunks.GetRow(rowPtr))
-		newRowPtrs = append(newRowPtrs, newRowPtr)
-	}
-	e.rowChunks = newRowChunks
-	e.rowPtrs = newRowPtrs
-	return nil
+	e.rowChunks = chunk.NewList(retTypes(e), e.initCap, e.maxChunkSize)
+	e.rowChunks.GetMemTracker().AttachTo(e.memTracker)
+	e.rowChunks.GetMemTracker().SetLabel(rowChunksLabel)
+	e.rowPtrs = make([]chunk.RowPtr, 0, len(rows))
+	for _, row := range rows {
+		e.rowPtrs = append(e.rowPtrs, e.rowChunks.AppendRow(row.h.chunks.GetRow(row.h.ptrs[row.idx])))
+	}
+	e.memTracker.Consume(int64(8 * len(e.rowPtrs)))
+	for _, h := range heaps {
+		h.chunks.GetMemTracker().Detach()
+		e.memTracker.Consume(int64(-16 * len(h.ptrs)))
+	}
 }
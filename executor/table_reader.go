@@ -15,12 +15,14 @@ package executor
 
 import (
 	"context"
+	"fmt"
 	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/parser/model"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -48,11 +50,30 @@ type TableReaderExecutor struct {
 
 	keepOrder bool
 	desc      bool
+
+	memTracker *memory.Tracker
+
+	// waitRuntimeFilter is set when this reader is the probe side of a hash join
+	// with runtime filters, the requests are not sent until the first call of
+	// Next, after the filters are applied by the join.
+	waitRuntimeFilter bool
+	runtimeFilters    []*tipb.Expr
 }
 
 // Open initialzes necessary variables for using this executor.
 func (e *TableReaderExecutor) Open(ctx context.Context) error {
+	e.memTracker = memory.NewTracker(e.id, -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	e.resultHandler = &tableResultHandler{}
+	e.runtimeFilters = nil
+	if e.waitRuntimeFilter {
+		return nil
+	}
+	return e.sendRequests(ctx)
+}
+
+// sendRequests builds and sends the requests of all the ranges.
+func (e *TableReaderExecutor) sendRequests(ctx context.Context) error {
 	firstPartRanges, secondPartRanges := splitRanges(e.ranges, e.keepOrder, e.desc)
 	firstResult, err := e.buildResp(ctx, firstPartRanges)
 	if err != nil {
@@ -74,34 +95,81 @@ func (e *TableReaderExecutor) Open(ctx context.Context) error {
 // Next fills data into the chunk passed by its caller.
 // The task was actually done by tableReaderHandler.
 func (e *TableReaderExecutor) Next(ctx context.Context, req *chunk.Chunk) error {
+	if e.waitRuntimeFilter {
+		e.waitRuntimeFilter = false
+		if err := e.sendRequests(ctx); err != nil {
+			return err
+		}
+	}
 	return e.resultHandler.nextChunk(ctx, req)
 }
 
+// applyRuntimeFilters sets the runtime filters which are pushed down along with
+// the requests sent later.
+func (e *TableReaderExecutor) applyRuntimeFilters(filters []*tipb.Expr) {
+	e.runtimeFilters = filters
+}
+
+// buildDAGReq returns the DAG request to send and the plan IDs of its
+// executors. The runtime filters are appended to the selection right after the
+// table scan, so the coprocessor filters out the rows before the other
+// executors. The ID of the selection added for the filters is nil.
+func (e *TableReaderExecutor) buildDAGReq() (*tipb.DAGRequest, []fmt.Stringer) {
+	planIDs := getPhysicalPlanIDs(e.plans)
+	if len(e.runtimeFilters) == 0 {
+		return e.dagPB, planIDs
+	}
+	// Copy the DAG request so that the original one can be reused when reopened.
+	dagReq := *e.dagPB
+	executors := make([]*tipb.Executor, 0, len(dagReq.Executors)+1)
+	executors = append(executors, dagReq.Executors[0])
+	remained := dagReq.Executors[1:]
+	conditions := e.runtimeFilters
+	if len(remained) > 0 && remained[0].Tp == tipb.ExecType_TypeSelection {
+		conditions = append(append([]*tipb.Expr{}, remained[0].Selection.Conditions...), e.runtimeFilters...)
+		remained = remained[1:]
+	} else {
+		planIDs = append(planIDs[:1], append([]fmt.Stringer{nil}, planIDs[1:]...)...)
+	}
+	executors = append(executors, &tipb.Executor{
+		Tp:        tipb.ExecType_TypeSelection,
+		Selection: &tipb.Selection{Conditions: conditions},
+	})
+	dagReq.Executors = append(executors, remained...)
+	return &dagReq, planIDs
+}
+
 // Close implements the Executor Close interface.
 func (e *TableReaderExecutor) Close() error {
 	var err error
 	if e.resultHandler != nil {
 		err = e.resultHandler.Close()
 	}
+	if e.memTracker != nil {
+		e.memTracker.Detach()
+		e.memTracker = nil
+	}
 	return err
 }
 
 // buildResp first builds request and sends it to tikv using distsql.Select. It uses SelectResut returned by the callee
 // to fetch all results.
 func (e *TableReaderExecutor) buildResp(ctx context.Context, ranges []*ranger.Range) (distsql.SelectResult, error) {
+	dagReq, planIDs := e.buildDAGReq()
 	var builder distsql.RequestBuilder
 	kvReq, err := builder.SetTableRanges(getPhysicalTableID(e.table), ranges).
-		SetDAGRequest(e.dagPB).
+		SetDAGRequest(dagReq).
 		SetStartTS(e.startTS).
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
 		SetFromSessionVars(e.ctx.GetSessionVars()).
+		SetMemTracker(e.memTracker).
 		Build()
 	if err != nil {
 		return nil, err
 	}
 	e.kvRanges = append(e.kvRanges, kvReq.KeyRanges...)
-	return distsql.Select(ctx, e.ctx, kvReq, retTypes(e))
+	return distsql.SelectWithRuntimeStats(ctx, e.ctx, kvReq, retTypes(e), planIDs, e.id)
 }
 
 type tableResultHandler struct {
@@ -0,0 +1,159 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cznic/mathutil"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tracing"
+)
+
+// TraceExec represents a root executor of trace query.
+type TraceExec struct {
+	baseExecutor
+
+	// stmtNode is the real query ast tree and it is used for building real query's plan.
+	stmtNode ast.StmtNode
+	// format is the format of the result, it's 'row' or 'json'.
+	format string
+
+	rows   [][]string
+	cursor int
+}
+
+// Next executes the traced statement and returns the recorded spans.
+func (e *TraceExec) Next(ctx context.Context, req *chunk.Chunk) error {
+	if e.rows == nil {
+		var err error
+		e.rows, err = e.traceStmt(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	req.GrowAndReset(e.maxChunkSize)
+	if e.cursor >= len(e.rows) {
+		return nil
+	}
+
+	numCurRows := mathutil.Min(req.Capacity(), len(e.rows)-e.cursor)
+	for i := e.cursor; i < e.cursor+numCurRows; i++ {
+		for j := range e.rows[i] {
+			req.AppendString(j, e.rows[i][j])
+		}
+	}
+	e.cursor += numCurRows
+	return nil
+}
+
+// traceStmt executes the traced statement to the end with a recorded trace,
+// and renders the spans in the specified format.
+func (e *TraceExec) traceStmt(ctx context.Context) ([][]string, error) {
+	se, ok := e.ctx.(sqlexec.SQLExecutor)
+	if !ok {
+		return nil, errors.New("trace is not supported in this context")
+	}
+
+	root := tracing.NewRecordedTrace("trace")
+	ctx = tracing.ContextWithSpan(ctx, root)
+	recordSets, err := se.Execute(ctx, e.stmtNode.Text())
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range recordSets {
+		err = drainRecordSet(ctx, rs)
+		if closeErr := rs.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	root.Finish()
+
+	if e.format == ast.TraceFormatJSON {
+		data, err := json.Marshal(spanToJSON(root, root.StartTime()))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return [][]string{{string(data)}}, nil
+	}
+	return appendSpanRows(nil, root, root.StartTime(), "", true, true), nil
+}
+
+func drainRecordSet(ctx context.Context, rs sqlexec.RecordSet) error {
+	req := rs.NewChunk()
+	for {
+		err := rs.Next(ctx, req)
+		if err != nil || req.NumRows() == 0 {
+			return err
+		}
+	}
+}
+
+// appendSpanRows renders the span tree as rows, the operations are indented
+// in the same way as the explain results.
+func appendSpanRows(rows [][]string, span *tracing.Span, traceStart time.Time, indent string, isRoot, isLast bool) [][]string {
+	operation := span.Name()
+	if calls := span.Calls(); calls > 1 {
+		operation = fmt.Sprintf("%s (calls:%d)", operation, calls)
+	}
+	childIndent := indent
+	if !isRoot {
+		if isLast {
+			operation = indent + "└─" + operation
+			childIndent = indent + "  "
+		} else {
+			operation = indent + "├─" + operation
+			childIndent = indent + "│ "
+		}
+	}
+	rows = append(rows, []string{operation, span.StartTime().Sub(traceStart).String(), span.Duration().String()})
+	children := span.Children()
+	for i, child := range children {
+		rows = appendSpanRows(rows, child, traceStart, childIndent, false, i == len(children)-1)
+	}
+	return rows
+}
+
+type jsonSpan struct {
+	Operation   string      `json:"operation"`
+	StartOffset string      `json:"startOffset"`
+	Duration    string      `json:"duration"`
+	Calls       int         `json:"calls,omitempty"`
+	Children    []*jsonSpan `json:"children,omitempty"`
+}
+
+func spanToJSON(span *tracing.Span, traceStart time.Time) *jsonSpan {
+	js := &jsonSpan{
+		Operation:   span.Name(),
+		StartOffset: span.StartTime().Sub(traceStart).String(),
+		Duration:    span.Duration().String(),
+	}
+	if calls := span.Calls(); calls > 1 {
+		js.Calls = calls
+	}
+	for _, child := range span.Children() {
+		js.Children = append(js.Children, spanToJSON(child, traceStart))
+	}
+	return js
+}
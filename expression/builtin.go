@@ -360,6 +360,10 @@ var funcs = map[string]functionClass{
 	ast.RowFunc:    &rowFunctionClass{baseFunctionClass{ast.RowFunc, 2, -1}},
 	ast.SetVar:     &setVarFunctionClass{baseFunctionClass{ast.SetVar, 2, 2}},
 	ast.GetVar:     &getVarFunctionClass{baseFunctionClass{ast.GetVar, 1, 1}},
+
+	// information functions
+	ast.TiDBDecodePlan: &tidbDecodePlanFunctionClass{baseFunctionClass{ast.TiDBDecodePlan, 1, 1}},
+	ast.TiDBMVCCInfo:   &tidbMVCCInfoFunctionClass{baseFunctionClass{ast.TiDBMVCCInfo, 3, 3}},
 }
 
 // IsFunctionSupported check if given function name is a builtin sql function.
@@ -0,0 +1,118 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/plancodec"
+)
+
+var (
+	_ functionClass = &tidbDecodePlanFunctionClass{}
+	_ functionClass = &tidbMVCCInfoFunctionClass{}
+)
+
+var (
+	_ builtinFunc = &builtinTiDBDecodePlanSig{}
+	_ builtinFunc = &builtinTiDBMVCCInfoSig{}
+)
+
+// GetMVCCInfo gets the MVCC info of the row with the handle in the table as a JSON string.
+// It's set by the executor package to avoid import cycle.
+var GetMVCCInfo func(sctx sessionctx.Context, dbName, tableName string, handle int64) (string, error)
+
+type tidbDecodePlanFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *tidbDecodePlanFunctionClass) getFunction(ctx sessionctx.Context, args []Expression) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, err
+	}
+	bf := newBaseBuiltinFuncWithTp(ctx, args, types.ETString, types.ETString)
+	bf.tp.Flen = mysql.MaxBlobWidth
+	sig := &builtinTiDBDecodePlanSig{bf}
+	return sig, nil
+}
+
+// builtinTiDBDecodePlanSig decodes a plan encoded by planner/core.EncodePlan,
+// e.g. the plan in the slow query log, into a readable table.
+type builtinTiDBDecodePlanSig struct {
+	baseBuiltinFunc
+}
+
+func (b *builtinTiDBDecodePlanSig) Clone() builtinFunc {
+	newSig := &builtinTiDBDecodePlanSig{}
+	newSig.cloneFrom(&b.baseBuiltinFunc)
+	return newSig
+}
+
+func (b *builtinTiDBDecodePlanSig) evalString(row chunk.Row) (string, bool, error) {
+	planString, isNull, err := b.args[0].EvalString(b.ctx, row)
+	if isNull || err != nil {
+		return "", isNull, err
+	}
+	planTree, err := plancodec.DecodePlan(planString)
+	return planTree, false, err
+}
+
+type tidbMVCCInfoFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *tidbMVCCInfoFunctionClass) getFunction(ctx sessionctx.Context, args []Expression) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, err
+	}
+	bf := newBaseBuiltinFuncWithTp(ctx, args, types.ETString, types.ETString, types.ETString, types.ETInt)
+	bf.tp.Flen = mysql.MaxBlobWidth
+	sig := &builtinTiDBMVCCInfoSig{bf}
+	return sig, nil
+}
+
+// builtinTiDBMVCCInfoSig returns the lock and all the write records of a row,
+// the row values are decoded by the table schema.
+type builtinTiDBMVCCInfoSig struct {
+	baseBuiltinFunc
+}
+
+func (b *builtinTiDBMVCCInfoSig) Clone() builtinFunc {
+	newSig := &builtinTiDBMVCCInfoSig{}
+	newSig.cloneFrom(&b.baseBuiltinFunc)
+	return newSig
+}
+
+func (b *builtinTiDBMVCCInfoSig) evalString(row chunk.Row) (string, bool, error) {
+	dbName, isNull, err := b.args[0].EvalString(b.ctx, row)
+	if isNull || err != nil {
+		return "", isNull, err
+	}
+	tableName, isNull, err := b.args[1].EvalString(b.ctx, row)
+	if isNull || err != nil {
+		return "", isNull, err
+	}
+	handle, isNull, err := b.args[2].EvalInt(b.ctx, row)
+	if isNull || err != nil {
+		return "", isNull, err
+	}
+	if GetMVCCInfo == nil {
+		return "", true, errors.New("tidb_mvcc_info is not supported")
+	}
+	info, err := GetMVCCInfo(b.ctx, dbName, tableName, handle)
+	return info, false, err
+}
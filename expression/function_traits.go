@@ -19,9 +19,10 @@ import (
 
 // unFoldableFunctions stores functions which can not be folded duration constant folding stage.
 var unFoldableFunctions = map[string]struct{}{
-	ast.RowFunc: {},
-	ast.SetVar:  {},
-	ast.GetVar:  {},
+	ast.RowFunc:      {},
+	ast.SetVar:       {},
+	ast.GetVar:       {},
+	ast.TiDBMVCCInfo: {},
 }
 
 // inequalFunctions stores functions which cannot be propagated from column equal condition.
@@ -32,6 +33,7 @@ var inequalFunctions = map[string]struct{}{
 // mutableEffectsFunctions stores functions which are mutable or have side effects, specifically,
 // we cannot remove them from filter even if they have duplicates.
 var mutableEffectsFunctions = map[string]struct{}{
-	ast.SetVar: {},
-	ast.GetVar: {},
+	ast.SetVar:       {},
+	ast.GetVar:       {},
+	ast.TiDBMVCCInfo: {},
 }
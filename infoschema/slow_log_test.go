@@ -0,0 +1,99 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infoschema_test
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/infoschema"
+)
+
+var _ = Suite(&testSlowLogSuite{})
+
+type testSlowLogSuite struct{}
+
+func (s *testSlowLogSuite) TestParseSlowLogFile(c *C) {
+	slowLog := bytes.NewBufferString(
+		`# Time: 2020-04-28T15:24:04.309074+08:00
+# Txn_start_ts: 405888132465033227
+# User: root@127.0.0.1
+# Conn_ID: 1
+# Query_time: 0.216905
+# Parse_time: 0.000025
+# Compile_time: 0.00132
+# Process_time: 0.021 Wait_time: 0.001 Backoff_time: 0.002
+# DB: test
+# Index_names: [t:idx]
+# Is_internal: false
+# Digest: 42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772
+# Num_cop_tasks: 1
+# Cop_proc_avg: 0.1 Cop_proc_p90: 0.2 Cop_proc_max: 0.03 Cop_proc_addr: 127.0.0.1:20160
+# Cop_wait_avg: 0.05 Cop_wait_p90: 0.6 Cop_wait_max: 0.8 Cop_wait_addr: 127.0.0.1:20160
+# Mem_max: 70724
+# Succ: false
+# Plan: tidb_decode_plan('xxx')
+select * from t;
+# Time: 2020-04-28T15:24:05.309074+08:00
+this line breaks the entry
+select 1;
+# Time: 2020-04-28T15:24:06.309074+08:00
+# Query_time: 1.5
+# Succ: true
+select 2;`)
+	rows, err := infoschema.ParseSlowLog(time.UTC, bufio.NewReader(slowLog))
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	recordString := ""
+	for i, value := range rows[0] {
+		str, err := value.ToString()
+		c.Assert(err, IsNil)
+		if i > 0 {
+			recordString += ","
+		}
+		recordString += str
+	}
+	expectRecordString := "2020-04-28 07:24:04.309074,405888132465033227,root@127.0.0.1,1,0.216905,0.000025,0.00132," +
+		"0.021,0.001,0.002,test,[t:idx],0,42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772," +
+		"1,0.1,0.2,0.03,127.0.0.1:20160,0.05,0.6,0.8,127.0.0.1:20160,70724,0,tidb_decode_plan('xxx'),select * from t;"
+	c.Assert(expectRecordString, Equals, recordString)
+
+	str, err := rows[1][0].ToString()
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, "2020-04-28 07:24:06.309074")
+	str, err = rows[1][len(rows[1])-1].ToString()
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, "select 2;")
+
+	slowLog = bytes.NewBufferString(
+		`# Time: 2020-04-28T15:24:04.309074+08:00
+# Query_time: abc
+select * from t;`)
+	_, err = infoschema.ParseSlowLog(time.UTC, bufio.NewReader(slowLog))
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "parse slow log failed `Query_time` error: strconv.ParseFloat: parsing \"abc\": invalid syntax")
+
+	// Test for a line exceeding the buffer size of the reader.
+	sql := "select * from " + strings.Repeat("x", 5000) + ";"
+	slowLog = bytes.NewBufferString("# Time: 2020-04-28T15:24:04.309074+08:00\n" + sql)
+	rows, err = infoschema.ParseSlowLog(time.UTC, bufio.NewReaderSize(slowLog, 16))
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 1)
+	str, err = rows[0][len(rows[0])-1].ToString()
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, sql)
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/stmtsummary"
 )
 
 const (
@@ -65,6 +66,10 @@ const (
 	tableOptimizerTrace                     = "OPTIMIZER_TRACE"
 	tableTableSpaces                        = "TABLESPACES"
 	tableCollationCharacterSetApplicability = "COLLATION_CHARACTER_SET_APPLICABILITY"
+	tableSlowQuery                          = "SLOW_QUERY"
+	tableStatementsSummary                  = "STATEMENTS_SUMMARY"
+	tableStatementsSummaryHistory           = "STATEMENTS_SUMMARY_HISTORY"
+	tableTiDBIndexUsage                     = "TIDB_INDEX_USAGE"
 )
 
 var tableIDMap = map[string]int64{
@@ -99,6 +104,10 @@ var tableIDMap = map[string]int64{
 	tableOptimizerTrace:                     autoid.InformationSchemaDBID + 30,
 	tableTableSpaces:                        autoid.InformationSchemaDBID + 31,
 	tableCollationCharacterSetApplicability: autoid.InformationSchemaDBID + 32,
+	tableSlowQuery:                          autoid.InformationSchemaDBID + 33,
+	tableStatementsSummary:                  autoid.InformationSchemaDBID + 34,
+	tableStatementsSummaryHistory:           autoid.InformationSchemaDBID + 35,
+	tableTiDBIndexUsage:                     autoid.InformationSchemaDBID + 36,
 }
 
 type columnInfo struct {
@@ -533,6 +542,63 @@ var tableCollationCharacterSetApplicabilityCols = []columnInfo{
 	{"CHARACTER_SET_NAME", mysql.TypeVarchar, 32, mysql.NotNullFlag, nil, nil},
 }
 
+// tableStatementsSummaryCols is shared by STATEMENTS_SUMMARY and STATEMENTS_SUMMARY_HISTORY.
+// The latencies and the process times are in nanoseconds.
+var tableStatementsSummaryCols = []columnInfo{
+	{"SUMMARY_BEGIN_TIME", mysql.TypeVarchar, 26, mysql.NotNullFlag, nil, nil},
+	{"SUMMARY_END_TIME", mysql.TypeVarchar, 26, mysql.NotNullFlag, nil, nil},
+	{"STMT_TYPE", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"SCHEMA_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"DIGEST", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"DIGEST_TEXT", mysql.TypeBlob, types.UnspecifiedLength, mysql.NotNullFlag, nil, nil},
+	{"INDEX_NAMES", mysql.TypeBlob, types.UnspecifiedLength, 0, nil, nil},
+	{"SAMPLE_USER", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"EXEC_COUNT", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"SUM_ERRORS", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"SUM_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MIN_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"P50_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"P95_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"P99_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_PARSE_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_PARSE_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_COMPILE_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_COMPILE_LATENCY", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"SUM_COP_TASK_NUM", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_COP_PROCESS_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_COP_PROCESS_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_COP_PROCESS_ADDRESS", mysql.TypeVarchar, 256, 0, nil, nil},
+	{"AVG_COP_WAIT_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_COP_WAIT_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_COP_WAIT_ADDRESS", mysql.TypeVarchar, 256, 0, nil, nil},
+	{"AVG_PROCESS_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_PROCESS_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_WAIT_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_WAIT_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_BACKOFF_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_BACKOFF_TIME", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_MEM", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"MAX_MEM", mysql.TypeLonglong, 20, mysql.NotNullFlag, nil, nil},
+	{"AVG_AFFECTED_ROWS", mysql.TypeDouble, 22, mysql.NotNullFlag, nil, nil},
+	{"FIRST_SEEN", mysql.TypeVarchar, 26, mysql.NotNullFlag, nil, nil},
+	{"LAST_SEEN", mysql.TypeVarchar, 26, mysql.NotNullFlag, nil, nil},
+	{"QUERY_SAMPLE_TEXT", mysql.TypeBlob, types.UnspecifiedLength, 0, nil, nil},
+	{"PLAN_DIGEST", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"PLAN", mysql.TypeBlob, types.UnspecifiedLength, 0, nil, nil},
+}
+
+// tableTiDBIndexUsageCols is the usage of the indexes, including the usage not dumped to mysql.schema_index_usage yet.
+var tableTiDBIndexUsageCols = []columnInfo{
+	{"TABLE_SCHEMA", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"TABLE_NAME", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"INDEX_NAME", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"QUERY_COUNT", mysql.TypeLonglong, 21, mysql.NotNullFlag, nil, nil},
+	{"ROWS_SELECTED", mysql.TypeLonglong, 21, mysql.NotNullFlag, nil, nil},
+	{"LAST_USED_AT", mysql.TypeVarchar, 19, 0, nil, nil},
+}
+
 func dataForCharacterSets() (records [][]types.Datum) {
 
 	charsets := charset.GetSupportedCharsets()
@@ -724,6 +790,55 @@ func getColLengthAllTables(ctx sessionctx.Context) (map[tableHistID]uint64, erro
 	return colLengthMap, nil
 }
 
+// IndexUsageID is the key of the usage of an index, the table ID is the physical table ID.
+type IndexUsageID struct {
+	TableID int64
+	IndexID int64
+}
+
+// IndexUsage is the usage of an index shown in TIDB_INDEX_USAGE.
+type IndexUsage struct {
+	QueryCount   int64
+	RowsSelected int64
+	LastUsedAt   string
+}
+
+// GetIndexUsage returns the usage of the indexes, including the usage
+// collected in memory which is not dumped to mysql.schema_index_usage yet.
+// The usage is collected by the statistics handle, which can't be imported
+// here, so it is set by the domain package.
+var GetIndexUsage func(ctx sessionctx.Context) (map[IndexUsageID]IndexUsage, error)
+
+func dataForIndexUsage(ctx sessionctx.Context, schemas []*model.DBInfo) ([][]types.Datum, error) {
+	if GetIndexUsage == nil {
+		return nil, nil
+	}
+	usageMap, err := GetIndexUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records [][]types.Datum
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			for _, index := range table.Indices {
+				usage, ok := usageMap[IndexUsageID{TableID: table.ID, IndexID: index.ID}]
+				if !ok {
+					continue
+				}
+				records = append(records, types.MakeDatums(
+					schema.Name.O,      // TABLE_SCHEMA
+					table.Name.O,       // TABLE_NAME
+					index.Name.O,       // INDEX_NAME
+					usage.QueryCount,   // QUERY_COUNT
+					usage.RowsSelected, // ROWS_SELECTED
+					usage.LastUsedAt,   // LAST_USED_AT
+				))
+			}
+		}
+	}
+	return records, nil
+}
+
 func getDataAndIndexLength(info *model.TableInfo, physicalID int64, rowCount uint64, columnLengthMap map[tableHistID]uint64) (uint64, uint64) {
 	columnLength := make(map[string]uint64)
 	for _, col := range info.Columns {
@@ -1262,6 +1377,10 @@ var tableNameToColumns = map[string][]columnInfo{
 	tableOptimizerTrace:                     tableOptimizerTraceCols,
 	tableTableSpaces:                        tableTableSpacesCols,
 	tableCollationCharacterSetApplicability: tableCollationCharacterSetApplicabilityCols,
+	tableSlowQuery:                          slowQueryCols,
+	tableStatementsSummary:                  tableStatementsSummaryCols,
+	tableStatementsSummaryHistory:           tableStatementsSummaryCols,
+	tableTiDBIndexUsage:                     tableTiDBIndexUsageCols,
 }
 
 func createInfoSchemaTable(_ autoid.Allocator, meta *model.TableInfo) (table.Table, error) {
@@ -1343,6 +1462,14 @@ func (it *infoschemaTable) getRows(ctx sessionctx.Context, cols []*table.Column)
 	case tableTableSpaces:
 	case tableCollationCharacterSetApplicability:
 		fullRows = dataForCollationCharacterSetApplicability()
+	case tableSlowQuery:
+		fullRows, err = dataForSlowLog(ctx)
+	case tableStatementsSummary:
+		fullRows = stmtsummary.StmtSummaryByDigestMap.ToCurrentDatum()
+	case tableStatementsSummaryHistory:
+		fullRows = stmtsummary.StmtSummaryByDigestMap.ToHistoryDatum()
+	case tableTiDBIndexUsage:
+		fullRows, err = dataForIndexUsage(ctx, dbs)
 	}
 	if err != nil {
 		return nil, err
@@ -14,7 +14,11 @@
 package infoschema_test
 
 import (
+	"fmt"
+	"os"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
@@ -72,3 +76,91 @@ func (s *testTableSuite) TestReloadDropDatabase(c *C) {
 	_, ok := is.TableByID(t2.Meta().ID)
 	c.Assert(ok, IsFalse)
 }
+
+func (s *testTableSuite) TestSlowQuery(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	// Prepare slow log file.
+	slowLogFileName := "tidb_slow.log"
+	f, err := os.OpenFile(slowLogFileName, os.O_CREATE|os.O_WRONLY, 0644)
+	c.Assert(err, IsNil)
+	_, err = f.Write([]byte(`# Time: 2020-04-28T15:24:04.309074+08:00
+# Txn_start_ts: 406315658548871171
+# User: root@127.0.0.1
+# Conn_ID: 6
+# Query_time: 4.895492
+# Process_time: 0.161 Wait_time: 0.025 Backoff_time: 0.02
+# DB: test
+# Is_internal: false
+# Digest: 42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772
+# Num_cop_tasks: 1
+# Mem_max: 70724
+# Succ: true
+select * from t_slim;`))
+	c.Assert(f.Close(), IsNil)
+	c.Assert(err, IsNil)
+	defer os.Remove(slowLogFileName)
+
+	tk.MustExec(fmt.Sprintf("set @@tidb_slow_query_file='%v'", slowLogFileName))
+	tk.MustQuery("select Txn_start_ts, User, Conn_ID, Query_time, Process_time, Wait_time, Backoff_time, DB, Is_internal, Num_cop_tasks, Mem_max, Succ, Query from information_schema.slow_query").Check(testkit.Rows(
+		"406315658548871171 root@127.0.0.1 6 4.895492 0.161 0.025 0.02 test 0 1 70724 1 select * from t_slim;"))
+	tk.MustQuery("select count(*) from information_schema.slow_query where digest = '42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772'").Check(testkit.Rows("1"))
+
+	tk.MustExec("set @@tidb_slow_query_file='not_exist.log'")
+	_, err = tk.Exec("select * from information_schema.slow_query")
+	c.Assert(err, NotNil)
+}
+
+func (s *testTableSuite) TestSlowLogThreshold(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	originThreshold := config.GetGlobalConfig().Log.SlowThreshold
+	defer func() {
+		config.GetGlobalConfig().Log.SlowThreshold = originThreshold
+	}()
+	tk.MustExec("set @@tidb_slow_log_threshold = 100")
+	tk.MustQuery("select @@tidb_slow_log_threshold").Check(testkit.Rows("100"))
+	c.Assert(config.GetGlobalConfig().Log.SlowThreshold, Equals, uint64(100))
+	tk.MustExec("set @@tidb_slow_log_threshold = 0")
+	tk.MustQuery("select @@tidb_slow_log_threshold").Check(testkit.Rows("0"))
+}
+
+func (s *testTableSuite) TestStmtSummaryTable(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b varchar(10))")
+
+	// Clear the summaries by disabling and enabling it.
+	tk.MustExec("set @@tidb_enable_stmt_summary = 0")
+	tk.MustQuery("select @@tidb_enable_stmt_summary").Check(testkit.Rows("0"))
+	tk.MustExec("set @@tidb_enable_stmt_summary = 1")
+	tk.MustQuery("select @@tidb_enable_stmt_summary").Check(testkit.Rows("1"))
+
+	tk.MustExec("insert into t values(1, 'a')")
+	tk.MustExec("insert into t values(2, 'b')")
+	tk.MustQuery("select * from t where a = 1").Check(testkit.Rows("1 a"))
+	tk.MustQuery("select * from t where a = 2").Check(testkit.Rows("2 b"))
+	tk.MustQuery(`select stmt_type, schema_name, digest_text, exec_count, sum_errors, query_sample_text
+		from information_schema.statements_summary where digest_text like 'insert into t%'`).Check(
+		testkit.Rows("Insert test insert into t values ( ... ) 2 0 insert into t values(2, 'b')"))
+	tk.MustQuery(`select stmt_type, schema_name, digest_text, exec_count, sum_errors, query_sample_text
+		from information_schema.statements_summary where digest_text like 'select * from t%'`).Check(
+		testkit.Rows("Select test select * from t where a = ? 2 0 select * from t where a = 2"))
+	tk.MustQuery(`select exec_count from information_schema.statements_summary_history
+		where digest_text like 'select * from t%'`).Check(testkit.Rows("2"))
+
+	// The statements are not summarized when it's disabled.
+	tk.MustExec("set @@tidb_enable_stmt_summary = 0")
+	tk.MustQuery("select * from t where a = 1").Check(testkit.Rows("1 a"))
+	tk.MustQuery(`select count(*) from information_schema.statements_summary`).Check(testkit.Rows("0"))
+	tk.MustExec("set @@tidb_enable_stmt_summary = 1")
+
+	tk.MustExec("set @@tidb_stmt_summary_refresh_interval = 60")
+	tk.MustQuery("select @@tidb_stmt_summary_refresh_interval").Check(testkit.Rows("60"))
+	tk.MustExec("set @@tidb_stmt_summary_history_size = 10")
+	tk.MustQuery("select @@tidb_stmt_summary_history_size").Check(testkit.Rows("10"))
+	tk.MustExec("set @@tidb_stmt_summary_max_stmt_count = 100")
+	tk.MustQuery("select @@tidb_stmt_summary_max_stmt_count").Check(testkit.Rows("100"))
+	tk.MustExec("set @@tidb_stmt_summary_refresh_interval = 1800")
+	tk.MustExec("set @@tidb_stmt_summary_history_size = 24")
+	tk.MustExec("set @@tidb_stmt_summary_max_stmt_count = 200")
+}
@@ -19,6 +19,8 @@ import (
 
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/store/tikv/oracle"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/memory"
 )
 
 // Transaction options
@@ -233,6 +235,8 @@ type Request struct {
 	SyncLog bool
 	// ReplicaRead is used for reading data from replicas, only follower is supported at this time.
 	ReplicaRead ReplicaReadType
+	// MemTracker is used to trace and control memory usage in co-processor layer.
+	MemTracker *memory.Tracker
 }
 
 // ResultSubset represents a result subset from a single storage unit.
@@ -244,6 +248,8 @@ type ResultSubset interface {
 	MemSize() int64
 	// RespTime returns the response time for the request.
 	RespTime() time.Duration
+	// GetExecDetails gets the execution details of the request.
+	GetExecDetails() *execdetails.ExecDetails
 }
 
 // Response represents the response returned from KV layer.
@@ -258,6 +264,9 @@ type Response interface {
 // Snapshot defines the interface for the snapshot fetched from KV store.
 type Snapshot interface {
 	Retriever
+	// BatchGet gets a batch of values from snapshot, the keys which don't
+	// exist are not in the result.
+	BatchGet(ctx context.Context, keys []Key) (map[string][]byte, error)
 }
 
 // Driver is the interface that must be implemented by a KV storage.
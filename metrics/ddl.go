@@ -0,0 +1,30 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics for the DDL package.
+var (
+	DDLJobHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "ddl",
+			Name:      "handle_job_duration_seconds",
+			Help:      "Bucketed histogram of processing time (s) of handle jobs",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 20), // 10ms ~ 1.5hours
+		}, []string{LblType, LblResult})
+)
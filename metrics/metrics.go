@@ -0,0 +1,52 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics labels.
+const (
+	LblOK       = "ok"
+	LblError    = "error"
+	LblCommit   = "commit"
+	LblRollback = "rollback"
+	LblInternal = "internal"
+	LblType     = "type"
+	LblResult   = "result"
+	LblSQLType  = "sql_type"
+)
+
+// RetLabel returns "ok" when err == nil and "error" otherwise.
+func RetLabel(err error) string {
+	if err == nil {
+		return LblOK
+	}
+	return LblError
+}
+
+// RegisterMetrics registers the metrics which are ONLY used in TiDB server.
+func RegisterMetrics() {
+	prometheus.MustRegister(ConnGauge)
+	prometheus.MustRegister(QueryDurationHistogram)
+	prometheus.MustRegister(TransactionCounter)
+	prometheus.MustRegister(DDLJobHistogram)
+	prometheus.MustRegister(TiKVTxnCmdHistogram)
+	prometheus.MustRegister(TiKVBackoffCounter)
+	prometheus.MustRegister(TiKVRegionCacheCounter)
+	prometheus.MustRegister(TiKVCoprocessorHistogram)
+	prometheus.MustRegister(TiKVLoadSafepointCounter)
+	prometheus.MustRegister(TiKVSafePointGauge)
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TiKVClient metrics.
+var (
+	// TiKVTxnCmdHistogram records the latency of each phase of the two-phase commit,
+	// the types are prewrite, get_commit_ts, commit and cleanup.
+	TiKVTxnCmdHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "txn_cmd_duration_seconds",
+			Help:      "Bucketed histogram of processing time of txn cmds.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 20), // 0.5ms ~ 262s
+		}, []string{LblType})
+
+	TiKVBackoffCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "backoff_total",
+			Help:      "Counter of backoff.",
+		}, []string{LblType})
+
+	// TiKVRegionCacheCounter counts the lookups of the region cache, the
+	// types are hit, miss and reload.
+	TiKVRegionCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "region_cache_operations_total",
+			Help:      "Counter of region cache.",
+		}, []string{LblType})
+
+	TiKVCoprocessorHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "cop_duration_seconds",
+			Help:      "Run duration of a single coprocessor task, includes backoff time.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 20), // 0.5ms ~ 262s
+		})
+
+	TiKVLoadSafepointCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "load_safepoint_total",
+			Help:      "Counter of load safepoint.",
+		}, []string{LblType})
+
+	// TiKVSafePointGauge shows the progress of GC, it's the physical time of
+	// the latest GC safe point in seconds.
+	TiKVSafePointGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "gc_safe_point_seconds",
+			Help:      "Physical time of the GC safe point.",
+		})
+)
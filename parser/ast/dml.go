@@ -691,6 +691,8 @@ const (
 	ShowProcessList
 	ShowCreateDatabase
 	ShowErrors
+	ShowUnusedIndexes
+	ShowBindings
 )
 
 // ShowStmt is a statement to provide information about databases, tables, columns and so on.
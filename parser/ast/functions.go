@@ -52,6 +52,10 @@ const (
 	SetVar      = "setvar"
 	GetVar      = "getvar"
 	Values      = "values"
+
+	// information functions
+	TiDBDecodePlan = "tidb_decode_plan"
+	TiDBMVCCInfo   = "tidb_mvcc_info"
 )
 
 // FuncCallExpr is for function expression.
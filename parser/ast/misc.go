@@ -24,9 +24,14 @@ var (
 	_ StmtNode = &AdminStmt{}
 	_ StmtNode = &BeginStmt{}
 	_ StmtNode = &CommitStmt{}
+	_ StmtNode = &CreateBindingStmt{}
+	_ StmtNode = &DropBindingStmt{}
 	_ StmtNode = &ExplainStmt{}
+	_ StmtNode = &KillStmt{}
+	_ StmtNode = &PlanReplayerStmt{}
 	_ StmtNode = &RollbackStmt{}
 	_ StmtNode = &SetStmt{}
+	_ StmtNode = &TraceStmt{}
 	_ StmtNode = &UseStmt{}
 
 	_ Node = &VariableAssignment{}
@@ -34,8 +39,11 @@ var (
 
 const (
 	// Valid formats for explain statement.
-	ExplainFormatROW = "row"
-	ExplainFormatDOT = "dot"
+	ExplainFormatROW  = "row"
+	ExplainFormatDOT  = "dot"
+	ExplainFormatJSON = "json"
+	ExplainFormatMemo = "memo"
+	ExplainFormatHint = "hint"
 )
 
 var (
@@ -43,9 +51,18 @@ var (
 	ExplainFormats = []string{
 		ExplainFormatROW,
 		ExplainFormatDOT,
+		ExplainFormatJSON,
+		ExplainFormatMemo,
+		ExplainFormatHint,
 	}
 )
 
+const (
+	// Valid formats for trace statement.
+	TraceFormatRow  = "row"
+	TraceFormatJSON = "json"
+)
+
 // TypeOpt is used for parsing data type option from SQL.
 type TypeOpt struct {
 	IsUnsigned bool
@@ -65,8 +82,9 @@ type FloatOpt struct {
 type ExplainStmt struct {
 	stmtNode
 
-	Stmt   StmtNode
-	Format string
+	Stmt    StmtNode
+	Format  string
+	Analyze bool
 }
 
 // Accept implements Node Accept interface.
@@ -84,6 +102,109 @@ func (n *ExplainStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// TraceStmt is a statement to trace what sql actually does at background.
+type TraceStmt struct {
+	stmtNode
+
+	Stmt   StmtNode
+	Format string
+}
+
+// Accept implements Node Accept interface.
+func (n *TraceStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*TraceStmt)
+	node, ok := n.Stmt.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Stmt = node.(DMLNode)
+	return v.Leave(n)
+}
+
+// PlanReplayerStmt is a statement to dump or load the information needed to reproduce a plan.
+type PlanReplayerStmt struct {
+	stmtNode
+
+	Stmt    StmtNode
+	Analyze bool
+	Load    bool
+	File    string
+}
+
+// Accept implements Node Accept interface.
+func (n *PlanReplayerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*PlanReplayerStmt)
+	if n.Load {
+		return v.Leave(n)
+	}
+	node, ok := n.Stmt.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Stmt = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// CreateBindingStmt creates sql binding hint.
+type CreateBindingStmt struct {
+	stmtNode
+
+	GlobalScope bool
+	OriginSel   StmtNode
+	HintedSel   StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateBindingStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateBindingStmt)
+	origNode, ok := n.OriginSel.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.OriginSel = origNode.(*SelectStmt)
+	hintedNode, ok := n.HintedSel.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.HintedSel = hintedNode.(*SelectStmt)
+	return v.Leave(n)
+}
+
+// DropBindingStmt deletes sql binding hint.
+type DropBindingStmt struct {
+	stmtNode
+
+	GlobalScope bool
+	OriginSel   StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *DropBindingStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropBindingStmt)
+	origNode, ok := n.OriginSel.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.OriginSel = origNode.(*SelectStmt)
+	return v.Leave(n)
+}
+
 // BeginStmt is a statement to start a new transaction.
 // See https://dev.mysql.com/doc/refman/5.7/en/commit.html
 type BeginStmt struct {
@@ -149,6 +270,28 @@ func (n *UseStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// KillStmt is a statement to kill a query or connection.
+// See https://dev.mysql.com/doc/refman/5.7/en/kill.html
+type KillStmt struct {
+	stmtNode
+
+	// Query indicates whether terminate a single query on this connection or the whole connection.
+	// If Query is true, terminates the statement the connection is currently executing, but leaves the connection itself intact.
+	// If Query is false, terminates the connection associated with the given ConnectionID, after terminating any statement the connection is executing.
+	Query        bool
+	ConnectionID uint64
+}
+
+// Accept implements Node Accept interface.
+func (n *KillStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*KillStmt)
+	return v.Leave(n)
+}
+
 // VariableAssignment is a variable assignment struct.
 type VariableAssignment struct {
 	node
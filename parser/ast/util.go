@@ -25,6 +25,8 @@ func IsReadOnly(node Node) bool {
 		return checker.readOnly
 	case *ExplainStmt:
 		return IsReadOnly(st.Stmt)
+	case *TraceStmt:
+		return IsReadOnly(st.Stmt)
 	default:
 		return false
 	}
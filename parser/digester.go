@@ -0,0 +1,156 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Normalize generates the normalized statement of a SQL, the statements
+// which differ only in the literals, the comments, the white spaces or the
+// case of the keywords and the identifiers have the same normalized statement.
+// For example, both "SELECT * FROM t WHERE a IN (1, 2)" and
+// "select * from T where a in (3,4,5) -- comment" are normalized to
+// "select * from t where a in ( ... )".
+func Normalize(sql string) string {
+	return strings.Join(normalizeTokens(sql, true), " ")
+}
+
+// NormalizeForBinding generates the normalized statement of a SQL for the
+// SQL bindings, it differs from Normalize in that the optimizer hints and the
+// trailing semicolon are removed, so a statement and its hinted version have
+// the same normalized statement.
+func NormalizeForBinding(sql string) string {
+	tokens := normalizeTokens(sql, false)
+	for len(tokens) > 0 && tokens[len(tokens)-1] == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return strings.Join(tokens, " ")
+}
+
+// NormalizeDigestForBinding generates the normalized statement for the SQL
+// bindings and its digest.
+func NormalizeDigestForBinding(sql string) (normalized, digest string) {
+	normalized = NormalizeForBinding(sql)
+	hash := sha256.Sum256([]byte(normalized))
+	return normalized, hex.EncodeToString(hash[:])
+}
+
+// DigestHash generates the digest of the normalized statement of a SQL, it's
+// a hex string of the sha256 hash.
+func DigestHash(sql string) string {
+	_, digest := NormalizeDigest(sql)
+	return digest
+}
+
+// NormalizeDigest generates the normalized statement and its digest.
+func NormalizeDigest(sql string) (normalized, digest string) {
+	normalized = Normalize(sql)
+	hash := sha256.Sum256([]byte(normalized))
+	return normalized, hex.EncodeToString(hash[:])
+}
+
+const (
+	normalizedLiteral = "?"
+	normalizedList    = "..."
+)
+
+// normalizeTokens scans the SQL and generates the normalized tokens. The
+// literals are replaced by "?", and a parenthesized list of literals, or
+// consecutive ones like the rows of "VALUES (1, 2), (3, 4)", are folded into
+// "( ... )", so the digest doesn't depend on the number of the values. The
+// optimizer hints are removed if keepHints is false.
+func normalizeTokens(sql string, keepHints bool) []string {
+	s := NewScanner(sql)
+	tokens := make([]string, 0, 32)
+	inHint := false
+	for {
+		tok, pos, lit := s.scan()
+		if !keepHints {
+			switch {
+			case tok == hintBegin:
+				inHint = true
+				continue
+			case inHint && tok == hintEnd:
+				inHint = false
+				continue
+			case inHint && tok != 0 && tok != invalid:
+				continue
+			}
+		}
+		switch tok {
+		case 0:
+			return tokens
+		case invalid:
+			// The rest of the SQL can't be scanned, it's kept as it is.
+			if pos.Offset < len(sql) {
+				if rest := strings.TrimSpace(sql[pos.Offset:]); len(rest) > 0 {
+					tokens = append(tokens, rest)
+				}
+			}
+			return tokens
+		case intLit, floatLit, decLit, stringLit, hexLit, bitLit:
+			tokens = append(tokens, normalizedLiteral)
+		case singleAtIdentifier:
+			tokens = append(tokens, "@"+strings.ToLower(lit))
+		case quotedIdentifier:
+			tokens = append(tokens, "`"+strings.ToLower(lit)+"`")
+		case hintBegin:
+			tokens = append(tokens, "/*+")
+		case hintEnd:
+			tokens = append(tokens, "*/")
+		case jss:
+			tokens = append(tokens, "->")
+		case juss:
+			tokens = append(tokens, "->>")
+		case ')':
+			tokens = append(tokens, ")")
+			tokens = foldLiteralList(tokens)
+		default:
+			tokens = append(tokens, strings.ToLower(lit))
+		}
+	}
+}
+
+// foldLiteralList folds the literal list at the end of the tokens, which ends
+// with ")", into "( ... )". If the folded list follows another folded list
+// and a comma, the two lists are merged.
+func foldLiteralList(tokens []string) []string {
+	n := len(tokens)
+	start := n - 2
+	for ; start >= 0; start-- {
+		if tokens[start] == "(" {
+			break
+		}
+		isLiteral := (n-2-start)%2 == 0
+		if isLiteral && tokens[start] != normalizedLiteral {
+			return tokens
+		}
+		if !isLiteral && tokens[start] != "," {
+			return tokens
+		}
+	}
+	// The list must be non-empty and start with a literal.
+	if start < 0 || start == n-2 || tokens[start+1] != normalizedLiteral {
+		return tokens
+	}
+	tokens = append(tokens[:start], "(", normalizedList, ")")
+	n = len(tokens)
+	if n >= 7 && tokens[n-4] == "," && tokens[n-5] == ")" && tokens[n-6] == normalizedList && tokens[n-7] == "(" {
+		tokens = tokens[:n-4]
+	}
+	return tokens
+}
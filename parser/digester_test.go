@@ -0,0 +1,82 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testSQLDigestSuite{})
+
+type testSQLDigestSuite struct {
+}
+
+func (s *testSQLDigestSuite) TestNormalize(c *C) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"select 1 from b where a = 1", "select ? from b where a = ?"},
+		{"SELECT * FROM `T` WHERE a = 'x' -- comment", "select * from `t` where a = ?"},
+		{"select * from t /* comment */ where a in (1, 2, 3)", "select * from t where a in ( ... )"},
+		{"select * from t where a in (1)", "select * from t where a in ( ... )"},
+		{"select * from t where (a, b) in ((1, 2), (3, 4))", "select * from t where ( a , b ) in ( ( ... ) )"},
+		{"insert into t values (1, 'a'), (2, 'b'), (3, 'c')", "insert into t values ( ... )"},
+		{"insert into t (a, b) values (1, 2)", "insert into t ( a , b ) values ( ... )"},
+		{"select count(*) from t where b > 1.5e3 and c < 2.5", "select count ( * ) from t where b > ? and c < ?"},
+		{"select /*+ hash_join(t1) */ * from t1 where @a = 0x1f", "select /*+ hash_join ( t1 ) */ * from t1 where @a = ?"},
+		{"select a from t limit 10, 20", "select a from t limit ? , ?"},
+		{"select a from t where a = f(b)", "select a from t where a = f ( b )"},
+	}
+	for _, test := range tests {
+		normalized := Normalize(test.input)
+		c.Assert(normalized, Equals, test.expect, Commentf("%s", test.input))
+	}
+}
+
+func (s *testSQLDigestSuite) TestDigest(c *C) {
+	sameDigests := [][]string{
+		{"select * from t where a = 1", "SELECT * FROM t WHERE a = 2", "select * from t   where a='x' # comment"},
+		{"insert into t values (1), (2)", "insert into t values (3, 4, 5)"},
+	}
+	for _, sqls := range sameDigests {
+		normalized, digest := NormalizeDigest(sqls[0])
+		c.Assert(digest, HasLen, 64)
+		for _, sql := range sqls[1:] {
+			normalized1, digest1 := NormalizeDigest(sql)
+			c.Assert(normalized1, Equals, normalized)
+			c.Assert(digest1, Equals, digest)
+		}
+	}
+	c.Assert(DigestHash("select * from t where a = 1"), Not(Equals), DigestHash("select * from t where b = 1"))
+}
+
+func (s *testSQLDigestSuite) TestNormalizeForBinding(c *C) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"select /*+ hash_join(t1) */ * from t1, t2 where t1.a = 1;", "select * from t1 , t2 where t1 . a = ?"},
+		{"SELECT * FROM t1, t2 WHERE t1.a = 2", "select * from t1 , t2 where t1 . a = ?"},
+		{"select a from t use index(idx) where a in (1, 2)", "select a from t use index ( idx ) where a in ( ... )"},
+		{"select /*+ QB_NAME(qb) */ a from t where a in (select /*+ USE_INDEX(t2, idx) */ b from t2)", "select a from t where a in ( select b from t2 )"},
+	}
+	for _, test := range tests {
+		normalized := NormalizeForBinding(test.input)
+		c.Assert(normalized, Equals, test.expect, Commentf("%s", test.input))
+	}
+	_, digest1 := NormalizeDigestForBinding("select * from t where a = 1")
+	_, digest2 := NormalizeDigestForBinding("select /*+ use_index(t, a) */ * from t where a = 2")
+	c.Assert(digest1, Equals, digest2)
+}
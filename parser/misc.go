@@ -289,6 +289,7 @@ var tokenMap = map[string]int{
 	"DROP":                     drop,
 	"DUAL":                     dual,
 	"DUPLICATE":                duplicate,
+	"DUMP":                     dump,
 	"DYNAMIC":                  dynamic,
 	"ELSE":                     elseKwd,
 	"ENABLE":                   enable,
@@ -327,6 +328,7 @@ var tokenMap = map[string]int{
 	"FOLLOWING":                following,
 	"FOR":                      forKwd,
 	"FORCE":                    force,
+	"FORCE_INDEX":              hintForceIndex,
 	"FOREIGN":                  foreign,
 	"FORMAT":                   format,
 	"FROM":                     from,
@@ -495,6 +497,7 @@ var tokenMap = map[string]int{
 	"PESSIMISTIC":              pessimistic,
 	"PER_TABLE":                per_table,
 	"PER_DB":                   per_db,
+	"PLAN":                     plan,
 	"PLUGINS":                  plugins,
 	"POSITION":                 position,
 	"PRECEDING":                preceding,
@@ -540,6 +543,7 @@ var tokenMap = map[string]int{
 	"RESPECT":                  respect,
 	"REPLICA":                  replica,
 	"REPLICATION":              replication,
+	"REPLAYER":                 replayer,
 	"REQUIRE":                  require,
 	"RESTRICT":                 restrict,
 	"REVERSE":                  reverse,
@@ -688,6 +692,7 @@ var tokenMap = map[string]int{
 	"UNLOCK":                   unlock,
 	"UNSIGNED":                 unsigned,
 	"UNTIL":                    until,
+	"UNUSED":                   unused,
 	"UPDATE":                   update,
 	"USAGE":                    usage,
 	"USE":                      use,
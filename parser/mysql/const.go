@@ -27,6 +27,9 @@ var (
 	// TiDBReleaseVersion is initialized by (git describe --tags) in Makefile.
 	TiDBReleaseVersion = "None"
 
+	// TiDBGitHash is initialized by (git rev-parse HEAD) in Makefile.
+	TiDBGitHash = "None"
+
 	// ServerVersion is the version information of this tidb-server in MySQL's format.
 	ServerVersion = fmt.Sprintf("5.7.25-TiDB-%s", TiDBReleaseVersion)
 )
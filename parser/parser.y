@@ -321,6 +321,7 @@ import (
 	disk		"DISK"
 	do		"DO"
 	duplicate	"DUPLICATE"
+	dump		"DUMP"
 	dynamic		"DYNAMIC"
 	enable		"ENABLE"
 	encryption	"ENCRYPTION"
@@ -412,6 +413,7 @@ import (
 	partitioning	"PARTITIONING"
 	partitions	"PARTITIONS"
 	pipesAsOr
+	plan		"PLAN"
 	plugins		"PLUGINS"
 	preceding	"PRECEDING"
 	prepare		"PREPARE"
@@ -437,6 +439,7 @@ import (
 	respect		"RESPECT"
 	replica		"REPLICA"
 	replication	"REPLICATION"
+	replayer	"REPLAYER"
 	reverse		"REVERSE"
 	role		"ROLE"
 	rollback	"ROLLBACK"
@@ -509,6 +512,7 @@ import (
 	uncommitted	"UNCOMMITTED"
 	unicodeSym	"UNICODE"
 	unknown 	"UNKNOWN"
+	unused		"UNUSED"
 	user		"USER"
 	undefined	"UNDEFINED"
 	validation	"VALIDATION"
@@ -616,6 +620,7 @@ import (
 	hintSTREAMAGG	"STREAM_AGG"
 	hintUseIndex 		"USE_INDEX"
 	hintIgnoreIndex 	"IGNORE_INDEX"
+	hintForceIndex 		"FORCE_INDEX"
 	hintUseIndexMerge	"USE_INDEX_MERGE"
 	hintNoIndexMerge	"NO_INDEX_MERGE"
 	hintUseToja	"USE_TOJA"
@@ -718,8 +723,10 @@ import (
 
 	CommitStmt			"COMMIT statement"
 	CreateTableStmt			"CREATE TABLE statement"
+	CreateBindingStmt		"CREATE BINDING statement"
 	CreateDatabaseStmt		"Create Database Statement"
 	CreateIndexStmt			"CREATE INDEX statement"
+	DropBindingStmt			"DROP BINDING statement"
 	DropDatabaseStmt		"DROP DATABASE statement"
 	DropIndexStmt			"DROP INDEX statement"
 	DropTableStmt			"DROP TABLE statement"
@@ -728,12 +735,16 @@ import (
 	ExplainStmt			"EXPLAIN statement"
 	ExplainableStmt			"explainable statement"
 	InsertIntoStmt			"INSERT INTO statement"
+	KillStmt			"Kill statement"
 	SelectStmt			"SELECT statement"
 	ReplaceIntoStmt			"REPLACE INTO statement"
 	RollbackStmt			"ROLLBACK statement"
+	PlanReplayerStmt		"PLAN REPLAYER statement"
 	SetStmt				"Set variable statement"
 	ShowStmt			"Show engines/databases/tables/user/columns/warnings/status statement"
 	Statement			"statement"
+	TraceStmt			"TRACE statement"
+	TraceableStmt			"traceable statement"
 	TruncateTableStmt		"TRUNCATE TABLE statement"
 	UseStmt				"USE statement"
 
@@ -2135,6 +2146,18 @@ ExplainStmt:
 			Stmt:	$2,
 			Format: "row",
 		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$2.SetText(parser.src[startOffset:])
+	}
+|	ExplainSym "ANALYZE" ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:	$3,
+			Format: "row",
+			Analyze: true,
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$3.SetText(parser.src[startOffset:])
 	}
 |	ExplainSym "FORMAT" "=" stringLit ExplainableStmt
 	{
@@ -2142,6 +2165,8 @@ ExplainStmt:
 			Stmt:	$5,
 			Format: $4,
 		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$5.SetText(parser.src[startOffset:])
 	}
 |	ExplainSym "FORMAT" "=" ExplainFormatType ExplainableStmt
 	{
@@ -2149,6 +2174,100 @@ ExplainStmt:
 			Stmt:	$5,
 			Format: $4.(string),
 		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$5.SetText(parser.src[startOffset:])
+	}
+
+TraceStmt:
+	"TRACE" TraceableStmt
+	{
+		$$ = &ast.TraceStmt{
+			Stmt:	$2,
+			Format: "row",
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$2.SetText(parser.src[startOffset:])
+	}
+|	"TRACE" "FORMAT" "=" stringLit TraceableStmt
+	{
+		$$ = &ast.TraceStmt{
+			Stmt:	$5,
+			Format: $4,
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$5.SetText(parser.src[startOffset:])
+	}
+
+/*******************************************************************
+ *
+ *  Create Binding Statement
+ *
+ *  Example:
+ *      CREATE GLOBAL BINDING FOR select Col1,Col2 from table USING select Col1,Col2 from table use index(Col1)
+ *******************************************************************/
+CreateBindingStmt:
+	"CREATE" GlobalScope "BINDING" "FOR" SelectStmt "USING" SelectStmt
+	{
+		startOffset := parser.startOffset(&yyS[yypt-2])
+		endOffset := parser.endOffset(&yyS[yypt-1])
+		originSel := $5.(*ast.SelectStmt)
+		originSel.SetText(strings.TrimSpace(parser.src[startOffset:endOffset]))
+
+		startOffset = parser.startOffset(&yyS[yypt])
+		hintedSel := $7.(*ast.SelectStmt)
+		hintedSel.SetText(strings.TrimSpace(parser.src[startOffset:]))
+
+		$$ = &ast.CreateBindingStmt{
+			GlobalScope: $2.(bool),
+			OriginSel:   originSel,
+			HintedSel:   hintedSel,
+		}
+	}
+
+/*******************************************************************
+ *
+ *  Drop Binding Statement
+ *
+ *  Example:
+ *      DROP GLOBAL BINDING FOR select Col1,Col2 from table
+ *******************************************************************/
+DropBindingStmt:
+	"DROP" GlobalScope "BINDING" "FOR" SelectStmt
+	{
+		startOffset := parser.startOffset(&yyS[yypt])
+		originSel := $5.(*ast.SelectStmt)
+		originSel.SetText(strings.TrimSpace(parser.src[startOffset:]))
+
+		$$ = &ast.DropBindingStmt{
+			GlobalScope: $2.(bool),
+			OriginSel:   originSel,
+		}
+	}
+
+PlanReplayerStmt:
+	"PLAN" "REPLAYER" "DUMP" "EXPLAIN" ExplainableStmt
+	{
+		$$ = &ast.PlanReplayerStmt{
+			Stmt: $5,
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$5.SetText(parser.src[startOffset:])
+	}
+|	"PLAN" "REPLAYER" "DUMP" "EXPLAIN" "ANALYZE" ExplainableStmt
+	{
+		$$ = &ast.PlanReplayerStmt{
+			Stmt:    $6,
+			Analyze: true,
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$6.SetText(parser.src[startOffset:])
+	}
+|	"PLAN" "REPLAYER" "LOAD" stringLit
+	{
+		$$ = &ast.PlanReplayerStmt{
+			Load: true,
+			File: $4,
+		}
 	}
 
 ExplainFormatType:
@@ -2642,7 +2761,7 @@ UnReservedKeyword:
 | "DYNAMIC" | "ENCRYPTION" | "END" | "ENFORCED" | "ENGINE" | "ENGINES" | "ENUM" | "ERRORS" | "ESCAPE" | "EVOLVE" | "EXECUTE" | "EXTENDED" | "FIELDS" | "FIRST" | "FIXED" | "FLUSH" | "FOLLOWING" | "FORMAT" | "FULL" |"GLOBAL"
 | "HASH" | "HOUR" | "INSERT_METHOD" | "LESS" | "LOCAL" | "LAST" | "NAMES" | "OFFSET" | "PASSWORD" %prec lowerThanEq | "PREPARE" | "QUICK" | "REBUILD" | "REDUNDANT" | "REORGANIZE"
 | "ROLE" |"ROLLBACK" | "SESSION" | "SIGNED" | "SHUTDOWN" | "SNAPSHOT" | "START" | "STATUS" | "OPEN"| "SUBPARTITIONS" | "SUBPARTITION" | "TABLES" | "TABLESPACE" | "TEXT" | "THAN" | "TIME" %prec lowerThanStringLitToken
-| "TIMESTAMP" %prec lowerThanStringLitToken | "TRACE" | "TRANSACTION" | "TRUNCATE" | "UNBOUNDED" | "UNKNOWN" | "VALUE" | "WARNINGS" | "YEAR" | "MODE"  | "WEEK"  | "ANY" | "SOME" | "USER" | "IDENTIFIED"
+| "TIMESTAMP" %prec lowerThanStringLitToken | "TRACE" | "TRANSACTION" | "TRUNCATE" | "UNBOUNDED" | "UNKNOWN" | "UNUSED" | "VALUE" | "WARNINGS" | "YEAR" | "MODE"  | "WEEK"  | "ANY" | "SOME" | "USER" | "IDENTIFIED"
 | "COLLATION" | "COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MASTER" | "MAX_ROWS"
 | "MIN_ROWS" | "NATIONAL" | "NCHAR" | "ROW_FORMAT" | "QUARTER" | "GRANTS" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION" | "JSON"
 | "REPEATABLE" | "RESPECT" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIAL" | "SERIALIZABLE" | "LEVEL" | "VARIABLES" | "SQL_CACHE" | "INDEXES" | "PROCESSLIST"
@@ -2650,7 +2769,7 @@ UnReservedKeyword:
 | "NONE" | "NULLS" | "SUPER" | "EXCLUSIVE" | "STATS_PERSISTENT" | "STATS_AUTO_RECALC" | "ROW_COUNT" | "COALESCE" | "MONTH" | "PROCESS" | "PROFILE" | "PROFILES"
 | "MICROSECOND" | "MINUTE" | "PLUGINS" | "PRECEDING" | "QUERY" | "QUERIES" | "SECOND" | "SEPARATOR" | "SHARE" | "SHARED" | "SLOW" | "MAX_CONNECTIONS_PER_HOUR" | "MAX_QUERIES_PER_HOUR" | "MAX_UPDATES_PER_HOUR"
 | "MAX_USER_CONNECTIONS" | "REPLICATION" | "CLIENT" | "SLAVE" | "RELOAD" | "TEMPORARY" | "ROUTINE" | "EVENT" | "ALGORITHM" | "DEFINER" | "INVOKER" | "MERGE" | "TEMPTABLE" | "UNDEFINED" | "SECURITY" | "CASCADED"
-| "RECOVER" | "CIPHER" | "SUBJECT" | "ISSUER" | "X509" | "NEVER" | "EXPIRE" | "ACCOUNT" | "INCREMENTAL" | "CPU" | "MEMORY" | "BLOCK" | "IO" | "CONTEXT" | "SWITCHES" | "PAGE" | "FAULTS" | "IPC" | "SWAPS" | "SOURCE"
+| "PLAN" | "REPLAYER" | "DUMP" | "RECOVER" | "CIPHER" | "SUBJECT" | "ISSUER" | "X509" | "NEVER" | "EXPIRE" | "ACCOUNT" | "INCREMENTAL" | "CPU" | "MEMORY" | "BLOCK" | "IO" | "CONTEXT" | "SWITCHES" | "PAGE" | "FAULTS" | "IPC" | "SWAPS" | "SOURCE"
 | "TRADITIONAL" | "SQL_BUFFER_RESULT" | "DIRECTORY" | "HISTORY" | "LIST" | "NODEGROUP" | "SYSTEM_TIME" | "PARTIAL" | "SIMPLE" | "REMOVE" | "PARTITIONING" | "STORAGE" | "DISK" | "STATS_SAMPLE_PAGES" | "SECONDARY_ENGINE" | "SECONDARY_LOAD" | "SECONDARY_UNLOAD" | "VALIDATION"
 | "WITHOUT" | "RTREE" | "EXCHANGE" | "COLUMN_FORMAT" | "REPAIR" | "IMPORT" | "DISCARD" | "TABLE_CHECKSUM" | "UNICODE" | "AUTO_RANDOM"
 | "SQL_TSI_DAY" | "SQL_TSI_HOUR" | "SQL_TSI_MINUTE" | "SQL_TSI_MONTH" | "SQL_TSI_QUARTER" | "SQL_TSI_SECOND" |
@@ -2659,7 +2778,7 @@ UnReservedKeyword:
 
 TiDBKeyword:
  "ADMIN" | "AGG_TO_COP" |"BUCKETS" | "BUILTINS" | "CANCEL" | "CMSKETCH" | "DDL" | "DEPTH" | "DRAINER" | "JOBS" | "JOB" | "NODE_ID" | "NODE_STATE" | "PUMP" | "SAMPLES" | "STATS" | "STATS_META" | "STATS_HISTOGRAMS" | "STATS_BUCKETS" | "STATS_HEALTHY" | "TIDB"
-| "HASH_JOIN" | "SM_JOIN" | "INL_JOIN" | "INL_HASH_JOIN"| "INL_MERGE_JOIN" | "SWAP_JOIN_INPUTS" | "NO_SWAP_JOIN_INPUTS" | "HASH_AGG" | "STREAM_AGG" | "USE_INDEX" | "IGNORE_INDEX" | "USE_INDEX_MERGE" | "NO_INDEX_MERGE" | "USE_TOJA" | "ENABLE_PLAN_CACHE" | "USE_PLAN_CACHE"
+| "HASH_JOIN" | "SM_JOIN" | "INL_JOIN" | "INL_HASH_JOIN"| "INL_MERGE_JOIN" | "SWAP_JOIN_INPUTS" | "NO_SWAP_JOIN_INPUTS" | "HASH_AGG" | "STREAM_AGG" | "USE_INDEX" | "IGNORE_INDEX" | "FORCE_INDEX" | "USE_INDEX_MERGE" | "NO_INDEX_MERGE" | "USE_TOJA" | "ENABLE_PLAN_CACHE" | "USE_PLAN_CACHE"
 | "READ_CONSISTENT_REPLICA" | "READ_FROM_STORAGE" | "QB_NAME" | "QUERY_TYPE" | "MEMORY_QUOTA" | "OLAP" | "OLTP" | "TOPN" | "TIKV" | "TIFLASH" | "SPLIT" | "OPTIMISTIC" | "PESSIMISTIC" | "WIDTH" | "REGIONS" | "REGION"
 
 NotKeywordToken:
@@ -3968,6 +4087,23 @@ TableOptimizerHintOpt:
 			Indexes:  $5.([]model.CIStr),
 		}
 	}
+|	hintForceIndex '(' QueryBlockOpt HintTable IndexNameList ')'
+	{
+		$$ = &ast.TableOptimizerHint{
+			HintName: model.NewCIStr($1),
+			QBName:   $3.(model.CIStr),
+			Tables:   []ast.HintTable{$4.(ast.HintTable)},
+			Indexes:  $5.([]model.CIStr),
+		}
+	}
+|	"LEADING" '(' QueryBlockOpt HintTableList ')'
+	{
+		$$ = &ast.TableOptimizerHint{HintName: model.NewCIStr($1), QBName: $3.(model.CIStr), Tables: $4.([]ast.HintTable)}
+	}
+|	"STRAIGHT_JOIN" '(' QueryBlockOpt ')'
+	{
+		$$ = &ast.TableOptimizerHint{HintName: model.NewCIStr($1), QBName: $3.(model.CIStr)}
+	}
 |	hintSMJ '(' QueryBlockOpt HintTableList ')'
 	{
 		$$ = &ast.TableOptimizerHint{HintName: model.NewCIStr($1), QBName: $3.(model.CIStr), Tables: $4.([]ast.HintTable)}
@@ -4454,6 +4590,13 @@ ShowTargetFilterable:
 			Full:	$1.(bool),
 		}
 	}
+|	"UNUSED" "INDEXES" ShowDatabaseNameOpt
+	{
+		$$ = &ast.ShowStmt{
+			Tp:	ast.ShowUnusedIndexes,
+			DBName:	$3.(string),
+		}
+	}
 |	"WARNINGS"
 	{
 		$$ = &ast.ShowStmt{Tp: ast.ShowWarnings}
@@ -4469,6 +4612,13 @@ ShowTargetFilterable:
 			GlobalScope: $1.(bool),
 		}
 	}
+|	GlobalScope "BINDINGS"
+	{
+		$$ = &ast.ShowStmt{
+			Tp: ast.ShowBindings,
+			GlobalScope: $1.(bool),
+		}
+	}
 
 ShowLikeOrWhereOpt:
 	{
@@ -4536,21 +4686,32 @@ Statement:
 |	CommitStmt
 |	DeleteFromStmt
 |	ExplainStmt
+|	CreateBindingStmt
 |	CreateDatabaseStmt
 |	CreateIndexStmt
 |	CreateTableStmt
+|	DropBindingStmt
 |	DropDatabaseStmt
 |	DropIndexStmt
 |	DropTableStmt
 |	InsertIntoStmt
+|	KillStmt
+|	PlanReplayerStmt
 |	RollbackStmt
 |	ReplaceIntoStmt
 |	SelectStmt
 |	SetStmt
 |	ShowStmt
+|	TraceStmt
 |	TruncateTableStmt
 |	UseStmt
 
+TraceableStmt:
+	SelectStmt
+|	DeleteFromStmt
+|	InsertIntoStmt
+|	ReplaceIntoStmt
+
 ExplainableStmt:
 	SelectStmt
 |	DeleteFromStmt
@@ -5281,6 +5442,32 @@ StringName:
 		$$ = $1
 	}
 
+/**************************************KillStmt********************************************
+ * See https://dev.mysql.com/doc/refman/5.7/en/kill.html
+ *
+ * KILL [CONNECTION | QUERY] processlist_id
+ *******************************************************************************************/
+KillStmt:
+	"KILL" NUM
+	{
+		$$ = &ast.KillStmt{
+			ConnectionID: getUint64FromNUM($2),
+		}
+	}
+|	"KILL" "CONNECTION" NUM
+	{
+		$$ = &ast.KillStmt{
+			ConnectionID: getUint64FromNUM($3),
+		}
+	}
+|	"KILL" "QUERY" NUM
+	{
+		$$ = &ast.KillStmt{
+			ConnectionID: getUint64FromNUM($3),
+			Query:        true,
+		}
+	}
+
 UseStmt:
 	"USE" DBName
 	{
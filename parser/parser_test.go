@@ -259,6 +259,58 @@ func (s *testParserSuite) TestSpecialComments(c *C) {
 	c.Assert(sel.TableHints, HasLen, 0)
 }
 
+func (s *testParserSuite) TestOptimizerHints(c *C) {
+	parser := parser.New()
+
+	st, err := parser.ParseOneStmt("select /*+ FORCE_INDEX(T1@sel_2 c1, c2), LEADING(@sel_1 t2, test.t1), STRAIGHT_JOIN() */ * from t1, t2", "", "")
+	c.Assert(err, IsNil)
+	hints := st.(*ast.SelectStmt).TableHints
+	c.Assert(hints, HasLen, 3)
+
+	c.Assert(hints[0].HintName.L, Equals, "force_index")
+	c.Assert(hints[0].Tables, HasLen, 1)
+	c.Assert(hints[0].Tables[0].TableName.L, Equals, "t1")
+	c.Assert(hints[0].Tables[0].QBName.L, Equals, "sel_2")
+	c.Assert(hints[0].Indexes, HasLen, 2)
+	c.Assert(hints[0].Indexes[0].L, Equals, "c1")
+	c.Assert(hints[0].Indexes[1].L, Equals, "c2")
+
+	c.Assert(hints[1].HintName.L, Equals, "leading")
+	c.Assert(hints[1].QBName.L, Equals, "sel_1")
+	c.Assert(hints[1].Tables, HasLen, 2)
+	c.Assert(hints[1].Tables[0].TableName.L, Equals, "t2")
+	c.Assert(hints[1].Tables[1].DBName.L, Equals, "test")
+	c.Assert(hints[1].Tables[1].TableName.L, Equals, "t1")
+
+	c.Assert(hints[2].HintName.L, Equals, "straight_join")
+	c.Assert(hints[2].Tables, HasLen, 0)
+}
+
+func (s *testParserSuite) TestBindingStmt(c *C) {
+	parser := parser.New()
+
+	st, err := parser.ParseOneStmt("create global binding for select * from t where a = 1 using select /*+ use_index(t, a) */ * from t where a = 1", "", "")
+	c.Assert(err, IsNil)
+	createBinding, ok := st.(*ast.CreateBindingStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(createBinding.GlobalScope, IsTrue)
+	c.Assert(createBinding.OriginSel.Text(), Equals, "select * from t where a = 1")
+	c.Assert(createBinding.HintedSel.Text(), Equals, "select /*+ use_index(t, a) */ * from t where a = 1")
+	c.Assert(createBinding.HintedSel.(*ast.SelectStmt).TableHints, HasLen, 1)
+
+	st, err = parser.ParseOneStmt("drop binding for select * from t where a = 1", "", "")
+	c.Assert(err, IsNil)
+	dropBinding, ok := st.(*ast.DropBindingStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(dropBinding.GlobalScope, IsFalse)
+	c.Assert(dropBinding.OriginSel.Text(), Equals, "select * from t where a = 1")
+
+	st, err = parser.ParseOneStmt("show global bindings", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(st.(*ast.ShowStmt).Tp, Equals, ast.ShowStmtType(ast.ShowBindings))
+	c.Assert(st.(*ast.ShowStmt).GlobalScope, IsTrue)
+}
+
 type testCase struct {
 	src     string
 	ok      bool
@@ -399,6 +451,13 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"admin show ddl jobs 20 where id=0;", true, "ADMIN SHOW DDL JOBS 20 WHERE `id`=0"},
 		{"admin show ddl jobs -1;", false, ""},
 
+		// for kill statement
+		{"kill 23123", true, "KILL 23123"},
+		{"kill connection 23123", true, "KILL 23123"},
+		{"kill query 23123", true, "KILL QUERY 23123"},
+		{"kill", false, ""},
+		{"kill query", false, ""},
+
 		// for insert ... set
 		{"INSERT INTO t SET a=1,b=2", true, "INSERT INTO `t` SET `a`=1,`b`=2"},
 		{"INSERT INTO t (a) SET a=1", false, ""},
@@ -422,6 +481,23 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{`/* 20180417 **/ show databases;`, true, "SHOW DATABASES"},
 		{`/** 20180417 */ show databases;`, true, "SHOW DATABASES"},
 		{`/** 20180417 ******/ show databases;`, true, "SHOW DATABASES"},
+
+		// for show unused indexes
+		{"show unused indexes", true, "SHOW UNUSED INDEXES"},
+		{"show unused indexes from test", true, "SHOW UNUSED INDEXES IN `test`"},
+		{"show unused indexes in test", true, "SHOW UNUSED INDEXES IN `test`"},
+		{"show unused indexes from test.t", false, ""},
+
+		// for bindings
+		{"create global binding for select * from t using select * from t use index(a)", true, ""},
+		{"create session binding for select * from t using select /*+ use_index(t, a) */ * from t", true, ""},
+		{"create binding for select * from t where a = 1 using select * from t use index(a) where a = 1", true, ""},
+		{"drop global binding for select * from t", true, ""},
+		{"drop binding for select * from t", true, ""},
+		{"create global binding for insert into t values (1) using insert into t values (1)", false, ""},
+		{"show bindings", true, ""},
+		{"show global bindings", true, ""},
+		{"show session bindings where default_db = 'test'", true, ""},
 	}
 	s.RunTest(c, table)
 }
@@ -1363,11 +1439,63 @@ func (s *testParserSuite) TestExplain(c *C) {
 		{"EXPLAIN FORMAT = 'ROW' SELECT 1", true, "EXPLAIN FORMAT = 'ROW' SELECT 1"},
 		{"EXPLAIN SELECT 1", true, "EXPLAIN FORMAT = 'row' SELECT 1"},
 		{"EXPLAIN FORMAT = JSON SELECT 1", true, "EXPLAIN FORMAT = 'json' SELECT 1"},
+		{"EXPLAIN FORMAT = 'json' SELECT 1", true, "EXPLAIN FORMAT = 'json' SELECT 1"},
+		{"EXPLAIN FORMAT = 'memo' SELECT 1", true, "EXPLAIN FORMAT = 'memo' SELECT 1"},
 		{"EXPLAIN FORMAT = 'hint' SELECT 1", true, "EXPLAIN FORMAT = 'hint' SELECT 1"},
+		{"EXPLAIN ANALYZE SELECT 1", true, "EXPLAIN ANALYZE SELECT 1"},
+		{"EXPLAIN ANALYZE select c1 from t1", true, "EXPLAIN ANALYZE SELECT `c1` FROM `t1`"},
+		{"EXPLAIN ANALYZE FORMAT = 'row' SELECT 1", false, ""},
+		{"EXPLAIN ANALYZE ANALYZE TABLE t", false, ""},
 	}
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestTrace(c *C) {
+	table := []testCase{
+		{"trace select c1 from t1", true, "TRACE SELECT `c1` FROM `t1`"},
+		{"trace insert into t values (1), (2), (3)", true, "TRACE INSERT INTO `t` VALUES (1),(2),(3)"},
+		{"trace replace into foo values (1 || 2)", true, "TRACE REPLACE INTO `foo` VALUES (1 OR 2)"},
+		{"trace delete from t where a = 1", true, "TRACE DELETE FROM `t` WHERE `a`=1"},
+		{"trace format = 'row' select 1", true, "TRACE SELECT 1"},
+		{"trace format = 'json' select 1", true, "TRACE FORMAT = 'json' SELECT 1"},
+		{"trace analyze table t", false, ""},
+		{"trace trace select 1", false, ""},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := parser.New().ParseOneStmt("trace format = 'json' select * from t", "", "")
+	c.Assert(err, IsNil)
+	traceStmt := stmt.(*ast.TraceStmt)
+	c.Assert(traceStmt.Format, Equals, "json")
+	c.Assert(traceStmt.Stmt.Text(), Equals, "select * from t")
+}
+
+func (s *testParserSuite) TestPlanReplayer(c *C) {
+	table := []testCase{
+		{"plan replayer dump explain select a from t", true, ""},
+		{"plan replayer dump explain analyze select a from t", true, ""},
+		{"plan replayer dump explain delete from t where a = 1", true, ""},
+		{"plan replayer load '/tmp/replayer.zip'", true, ""},
+		{"plan replayer dump select a from t", false, ""},
+		{"plan replayer load", false, ""},
+		{"create table plan (replayer int, dump int)", true, ""},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := parser.New().ParseOneStmt("plan replayer dump explain analyze select * from t", "", "")
+	c.Assert(err, IsNil)
+	replayerStmt := stmt.(*ast.PlanReplayerStmt)
+	c.Assert(replayerStmt.Analyze, IsTrue)
+	c.Assert(replayerStmt.Load, IsFalse)
+	c.Assert(replayerStmt.Stmt.Text(), Equals, "select * from t")
+
+	stmt, err = parser.New().ParseOneStmt("plan replayer load 'replayer.zip'", "", "")
+	c.Assert(err, IsNil)
+	replayerStmt = stmt.(*ast.PlanReplayerStmt)
+	c.Assert(replayerStmt.Load, IsTrue)
+	c.Assert(replayerStmt.File, Equals, "replayer.zip")
+}
+
 func (s *testParserSuite) TestSQLModeANSIQuotes(c *C) {
 	parser := parser.New()
 	parser.SetSQLMode(mysql.ModeANSIQuotes)
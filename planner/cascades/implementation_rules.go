@@ -27,9 +27,10 @@ import (
 type ImplementationRule interface {
 	// Match checks if current GroupExpr matches this rule under required physical property.
 	Match(expr *memo.GroupExpr, prop *property.PhysicalProperty) (matched bool)
-	// OnImplement generates physical plan using this rule for current GroupExpr. Note that
-	// childrenReqProps of generated physical plan should be set correspondingly in this function.
-	OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error)
+	// OnImplement generates physical plans using this rule for current GroupExpr. Note that
+	// childrenReqProps of generated physical plans should be set correspondingly in this function.
+	// A rule may generate several physical plans, e.g. the merge joins on different join keys.
+	OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error)
 }
 
 var defaultImplementationMap = map[memo.Operand][]ImplementationRule{
@@ -70,6 +71,7 @@ var defaultImplementationMap = map[memo.Operand][]ImplementationRule{
 	memo.OperandJoin: {
 		&ImplHashJoinBuildLeft{},
 		&ImplHashJoinBuildRight{},
+		&ImplMergeJoin{},
 	},
 }
 
@@ -86,12 +88,12 @@ func (r *ImplTableDual) Match(expr *memo.GroupExpr, prop *property.PhysicalPrope
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplTableDual) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplTableDual) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicProp := expr.Group.Prop
 	logicDual := expr.ExprNode.(*plannercore.LogicalTableDual)
 	dual := plannercore.PhysicalTableDual{RowCount: logicDual.RowCount}.Init(logicDual.SCtx(), logicProp.Stats)
 	dual.SetSchema(logicProp.Schema)
-	return impl.NewTableDualImpl(dual), nil
+	return []memo.Implementation{impl.NewTableDualImpl(dual)}, nil
 }
 
 // ImplProjection implements LogicalProjection as PhysicalProjection.
@@ -104,7 +106,7 @@ func (r *ImplProjection) Match(expr *memo.GroupExpr, prop *property.PhysicalProp
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplProjection) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplProjection) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicProp := expr.Group.Prop
 	logicProj := expr.ExprNode.(*plannercore.LogicalProjection)
 	childProp, ok := logicProj.TryToGetChildProp(reqProp)
@@ -115,7 +117,7 @@ func (r *ImplProjection) OnImplement(expr *memo.GroupExpr, reqProp *property.Phy
 		Exprs: logicProj.Exprs,
 	}.Init(logicProj.SCtx(), logicProp.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt), childProp)
 	proj.SetSchema(logicProp.Schema)
-	return impl.NewProjectionImpl(proj), nil
+	return []memo.Implementation{impl.NewProjectionImpl(proj)}, nil
 }
 
 // ImplTiKVSingleReadGather implements TiKVSingleGather
@@ -129,15 +131,15 @@ func (r *ImplTiKVSingleReadGather) Match(expr *memo.GroupExpr, prop *property.Ph
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplTiKVSingleReadGather) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplTiKVSingleReadGather) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicProp := expr.Group.Prop
 	sg := expr.ExprNode.(*plannercore.TiKVSingleGather)
 	if sg.IsIndexGather {
 		reader := sg.GetPhysicalIndexReader(logicProp.Schema, logicProp.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt), reqProp)
-		return impl.NewIndexReaderImpl(reader, sg.Source.TblColHists), nil
+		return []memo.Implementation{impl.NewIndexReaderImpl(reader, sg.Source.TblColHists)}, nil
 	}
 	reader := sg.GetPhysicalTableReader(logicProp.Schema, logicProp.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt), reqProp)
-	return impl.NewTableReaderImpl(reader, sg.Source.TblColHists), nil
+	return []memo.Implementation{impl.NewTableReaderImpl(reader, sg.Source.TblColHists)}, nil
 }
 
 // ImplTableScan implements TableScan as PhysicalTableScan.
@@ -151,7 +153,7 @@ func (r *ImplTableScan) Match(expr *memo.GroupExpr, prop *property.PhysicalPrope
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplTableScan) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplTableScan) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicProp := expr.Group.Prop
 	logicalScan := expr.ExprNode.(*plannercore.LogicalTableScan)
 	ts := logicalScan.GetPhysicalScan(logicProp.Schema, logicProp.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt))
@@ -160,7 +162,7 @@ func (r *ImplTableScan) OnImplement(expr *memo.GroupExpr, reqProp *property.Phys
 		ts.Desc = reqProp.Items[0].Desc
 	}
 	tblCols, tblColHists := logicalScan.Source.TblCols, logicalScan.Source.TblColHists
-	return impl.NewTableScanImpl(ts, tblCols, tblColHists), nil
+	return []memo.Implementation{impl.NewTableScanImpl(ts, tblCols, tblColHists)}, nil
 }
 
 // ImplIndexScan implements IndexScan as PhysicalIndexScan.
@@ -174,7 +176,7 @@ func (r *ImplIndexScan) Match(expr *memo.GroupExpr, prop *property.PhysicalPrope
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplIndexScan) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplIndexScan) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicalScan := expr.ExprNode.(*plannercore.LogicalIndexScan)
 	is := logicalScan.GetPhysicalIndexScan(expr.Group.Prop.Schema, expr.Group.Prop.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt))
 	if !reqProp.IsEmpty() {
@@ -183,7 +185,7 @@ func (r *ImplIndexScan) OnImplement(expr *memo.GroupExpr, reqProp *property.Phys
 			is.Desc = true
 		}
 	}
-	return impl.NewIndexScanImpl(is, logicalScan.Source.TblColHists), nil
+	return []memo.Implementation{impl.NewIndexScanImpl(is, logicalScan.Source.TblColHists)}, nil
 }
 
 // ImplShow is the implementation rule which implements LogicalShow to
@@ -197,7 +199,7 @@ func (r *ImplShow) Match(expr *memo.GroupExpr, prop *property.PhysicalProperty)
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplShow) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplShow) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicProp := expr.Group.Prop
 	show := expr.ExprNode.(*plannercore.LogicalShow)
 
@@ -207,7 +209,7 @@ func (r *ImplShow) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalP
 	// overall performance.
 	showPhys := plannercore.PhysicalShow{ShowContents: show.ShowContents}.Init(show.SCtx())
 	showPhys.SetSchema(logicProp.Schema)
-	return impl.NewShowImpl(showPhys), nil
+	return []memo.Implementation{impl.NewShowImpl(showPhys)}, nil
 }
 
 // ImplSelection is the implementation rule which implements LogicalSelection
@@ -221,16 +223,16 @@ func (r *ImplSelection) Match(expr *memo.GroupExpr, prop *property.PhysicalPrope
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplSelection) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplSelection) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicalSel := expr.ExprNode.(*plannercore.LogicalSelection)
 	physicalSel := plannercore.PhysicalSelection{
 		Conditions: logicalSel.Conditions,
 	}.Init(logicalSel.SCtx(), expr.Group.Prop.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt), reqProp.Clone())
 	switch expr.Group.EngineType {
 	case memo.EngineTiDB:
-		return impl.NewTiDBSelectionImpl(physicalSel), nil
+		return []memo.Implementation{impl.NewTiDBSelectionImpl(physicalSel)}, nil
 	case memo.EngineTiKV:
-		return impl.NewTiKVSelectionImpl(physicalSel), nil
+		return []memo.Implementation{impl.NewTiKVSelectionImpl(physicalSel)}, nil
 	default:
 		return nil, plannercore.ErrInternal.GenWithStack("Unsupported EngineType '%s' for Selection.", expr.Group.EngineType.String())
 	}
@@ -250,19 +252,19 @@ func (r *ImplSort) Match(expr *memo.GroupExpr, prop *property.PhysicalProperty)
 // OnImplement implements ImplementationRule OnImplement interface.
 // If all of the sort items are columns, generate a NominalSort, otherwise
 // generate a PhysicalSort.
-func (r *ImplSort) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplSort) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	ls := expr.ExprNode.(*plannercore.LogicalSort)
 	if newProp, canUseNominal := plannercore.GetPropByOrderByItems(ls.ByItems); canUseNominal {
 		newProp.ExpectedCnt = reqProp.ExpectedCnt
 		ns := plannercore.NominalSort{}.Init(ls.SCtx(), newProp)
-		return impl.NewNominalSortImpl(ns), nil
+		return []memo.Implementation{impl.NewNominalSortImpl(ns)}, nil
 	}
 	ps := plannercore.PhysicalSort{ByItems: ls.ByItems}.Init(
 		ls.SCtx(),
 		expr.Group.Prop.Stats.ScaleByExpectCnt(reqProp.ExpectedCnt),
 		&property.PhysicalProperty{ExpectedCnt: math.MaxFloat64},
 	)
-	return impl.NewSortImpl(ps), nil
+	return []memo.Implementation{impl.NewSortImpl(ps)}, nil
 }
 
 // ImplHashAgg is the implementation rule which implements LogicalAggregation
@@ -277,7 +279,7 @@ func (r *ImplHashAgg) Match(expr *memo.GroupExpr, prop *property.PhysicalPropert
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplHashAgg) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplHashAgg) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	la := expr.ExprNode.(*plannercore.LogicalAggregation)
 	hashAgg := plannercore.NewPhysicalHashAgg(
 		la,
@@ -287,9 +289,9 @@ func (r *ImplHashAgg) OnImplement(expr *memo.GroupExpr, reqProp *property.Physic
 	hashAgg.SetSchema(expr.Group.Prop.Schema.Clone())
 	switch expr.Group.EngineType {
 	case memo.EngineTiDB:
-		return impl.NewTiDBHashAggImpl(hashAgg), nil
+		return []memo.Implementation{impl.NewTiDBHashAggImpl(hashAgg)}, nil
 	case memo.EngineTiKV:
-		return impl.NewTiKVHashAggImpl(hashAgg), nil
+		return []memo.Implementation{impl.NewTiKVHashAggImpl(hashAgg)}, nil
 	default:
 		return nil, plannercore.ErrInternal.GenWithStack("Unsupported EngineType '%s' for HashAggregation.", expr.Group.EngineType.String())
 	}
@@ -306,14 +308,14 @@ func (r *ImplLimit) Match(expr *memo.GroupExpr, prop *property.PhysicalProperty)
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplLimit) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplLimit) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	logicalLimit := expr.ExprNode.(*plannercore.LogicalLimit)
 	newProp := &property.PhysicalProperty{ExpectedCnt: float64(logicalLimit.Count + logicalLimit.Offset)}
 	physicalLimit := plannercore.PhysicalLimit{
 		Offset: logicalLimit.Offset,
 		Count:  logicalLimit.Count,
 	}.Init(logicalLimit.SCtx(), expr.Group.Prop.Stats, newProp)
-	return impl.NewLimitImpl(physicalLimit), nil
+	return []memo.Implementation{impl.NewLimitImpl(physicalLimit)}, nil
 }
 
 // ImplTopN is the implementation rule which implements LogicalTopN
@@ -328,7 +330,7 @@ func (r *ImplTopN) Match(expr *memo.GroupExpr, prop *property.PhysicalProperty)
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplTopN) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplTopN) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	lt := expr.ExprNode.(*plannercore.LogicalTopN)
 	resultProp := &property.PhysicalProperty{ExpectedCnt: math.MaxFloat64}
 	topN := plannercore.PhysicalTopN{
@@ -338,7 +340,7 @@ func (r *ImplTopN) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalP
 	}.Init(lt.SCtx(), expr.Group.Prop.Stats, resultProp)
 	switch expr.Group.EngineType {
 	case memo.EngineTiDB:
-		return impl.NewTiDBTopNImpl(topN), nil
+		return []memo.Implementation{impl.NewTiDBTopNImpl(topN)}, nil
 	default:
 		// TODO: return TiKVTopNImpl after we have implemented push topN down gather.
 		return nil, plannercore.ErrInternal.GenWithStack("Unsupported EngineType '%s' for TopN.", expr.Group.EngineType.String())
@@ -358,7 +360,7 @@ func (r *ImplTopNAsLimit) Match(expr *memo.GroupExpr, prop *property.PhysicalPro
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplTopNAsLimit) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplTopNAsLimit) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	lt := expr.ExprNode.(*plannercore.LogicalTopN)
 	newProp := &property.PhysicalProperty{ExpectedCnt: float64(lt.Count + lt.Offset)}
 	newProp.Items = make([]property.Item, len(lt.ByItems))
@@ -370,7 +372,7 @@ func (r *ImplTopNAsLimit) OnImplement(expr *memo.GroupExpr, reqProp *property.Ph
 		Offset: lt.Offset,
 		Count:  lt.Count,
 	}.Init(lt.SCtx(), expr.Group.Prop.Stats, newProp)
-	return impl.NewLimitImpl(physicalLimit), nil
+	return []memo.Implementation{impl.NewLimitImpl(physicalLimit)}, nil
 }
 
 func getImplForHashJoin(expr *memo.GroupExpr, prop *property.PhysicalProperty, innerIdx int) memo.Implementation {
@@ -384,7 +386,7 @@ func getImplForHashJoin(expr *memo.GroupExpr, prop *property.PhysicalProperty, i
 		chReqProps[1-innerIdx].ExpectedCnt = expr.Children[1-innerIdx].Prop.Stats.RowCount * expCntScale
 	}
 	hashJoin := plannercore.NewPhysicalHashJoin(join, innerIdx, stats.ScaleByExpectCnt(prop.ExpectedCnt), chReqProps...)
-	hashJoin.SetSchema(expr.Group.Prop.Schema)
+	hashJoin.SetSchema(join.Schema())
 	return impl.NewHashJoinImpl(hashJoin)
 }
 
@@ -398,11 +400,11 @@ func (r *ImplHashJoinBuildLeft) Match(expr *memo.GroupExpr, prop *property.Physi
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplHashJoinBuildLeft) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplHashJoinBuildLeft) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	join := expr.ExprNode.(*plannercore.LogicalJoin)
 	switch join.JoinType {
 	case plannercore.InnerJoin:
-		return getImplForHashJoin(expr, reqProp, 0), nil
+		return []memo.Implementation{getImplForHashJoin(expr, reqProp, 0)}, nil
 	default:
 		// TODO: deal with other join type.
 		return nil, nil
@@ -419,13 +421,34 @@ func (r *ImplHashJoinBuildRight) Match(expr *memo.GroupExpr, prop *property.Phys
 }
 
 // OnImplement implements ImplementationRule OnImplement interface.
-func (r *ImplHashJoinBuildRight) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) (memo.Implementation, error) {
+func (r *ImplHashJoinBuildRight) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
 	join := expr.ExprNode.(*plannercore.LogicalJoin)
 	switch join.JoinType {
 	case plannercore.InnerJoin:
-		return getImplForHashJoin(expr, reqProp, 1), nil
+		return []memo.Implementation{getImplForHashJoin(expr, reqProp, 1)}, nil
 	default:
 		// TODO: deal with other join type.
 		return nil, nil
 	}
 }
+
+// ImplMergeJoin implements LogicalJoin to PhysicalMergeJoin which requires
+// both children to be sorted by the join keys.
+type ImplMergeJoin struct {
+}
+
+// Match implements ImplementationRule Match interface.
+func (r *ImplMergeJoin) Match(expr *memo.GroupExpr, prop *property.PhysicalProperty) (matched bool) {
+	return true
+}
+
+// OnImplement implements ImplementationRule OnImplement interface.
+func (r *ImplMergeJoin) OnImplement(expr *memo.GroupExpr, reqProp *property.PhysicalProperty) ([]memo.Implementation, error) {
+	join := expr.ExprNode.(*plannercore.LogicalJoin)
+	mergeJoins := join.GetMergeJoin(reqProp, join.Schema(), expr.Group.Prop.Stats, expr.Children[0].Prop.Stats, expr.Children[1].Prop.Stats)
+	impls := make([]memo.Implementation, 0, len(mergeJoins))
+	for _, plan := range mergeJoins {
+		impls = append(impls, impl.NewMergeJoinImpl(plan.(*plannercore.PhysicalMergeJoin)))
+	}
+	return impls, nil
+}
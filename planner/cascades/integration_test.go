@@ -14,9 +14,15 @@
 package cascades_test
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/mockstore"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testutil"
@@ -210,3 +216,138 @@ func (s *testIntegrationSuite) TestJoin(c *C) {
 		tk.MustQuery(sql).Check(testkit.Rows(output[i].Result...))
 	}
 }
+
+var planIDRegexp = regexp.MustCompile(`_\d+\b`)
+
+// explainWithoutIDs returns the explained plan of sql with the plan IDs removed.
+func explainWithoutIDs(tk *testkit.TestKit, sql string) []string {
+	rows := tk.MustQuery("explain " + sql).Rows()
+	plan := make([]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, fmt.Sprint(cell))
+		}
+		plan = append(plan, planIDRegexp.ReplaceAllString(strings.Join(cells, " "), ""))
+	}
+	return plan
+}
+
+func (s *testIntegrationSuite) TestJoinReorder(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t1, t2, t3")
+	tk.MustExec("create table t1(a int primary key, b int)")
+	tk.MustExec("create table t2(a int primary key, b int)")
+	tk.MustExec("create table t3(a int primary key, b int)")
+	tk.MustExec("insert into t1 values (1, 11), (4, 44), (2, 22), (3, 33)")
+	tk.MustExec("insert into t2 values (1, 111), (2, 222), (3, 333)")
+	tk.MustExec("insert into t3 values (2, 2), (3, 3)")
+	tk.MustExec("set session tidb_enable_cascades_planner = 1")
+
+	// The joins of two tables are not changed by the reorder rules, only the
+	// plan IDs are, since the IDs of the joins generated in the exploration
+	// are allocated before the physical plans are built.
+	for _, sql := range []string{
+		"select t1.a, t1.b from t1, t2 where t1.a = t2.a and t1.a > 2",
+		"select t1.a, t1.b from t1, t2 where t1.a > t2.a and t2.b > 200",
+	} {
+		tk.MustExec("set session tidb_opt_cascades_join_reorder_budget = 0")
+		expected := explainWithoutIDs(tk, sql)
+		tk.MustExec("set session tidb_opt_cascades_join_reorder_budget = " + strconv.Itoa(variable.DefOptCascadesJoinReorderBudget))
+		c.Assert(explainWithoutIDs(tk, sql), DeepEquals, expected, Commentf("sql: %s", sql))
+	}
+
+	// The reordered joins return the same results.
+	sql := "select t1.a, t2.b, t3.b from t1, t2, t3 where t1.a = t3.a and t2.a = t3.a and t1.b > 20 order by t1.a"
+	tk.MustExec("set session tidb_opt_cascades_join_reorder_budget = 0")
+	expected := tk.MustQuery(sql).Rows()
+	tk.MustExec("set session tidb_opt_cascades_join_reorder_budget = " + strconv.Itoa(variable.DefOptCascadesJoinReorderBudget))
+	tk.MustQuery(sql).Check(expected)
+	tk.MustQuery(sql).Check(testkit.Rows("2 222 2", "3 333 3"))
+}
+
+func (s *testIntegrationSuite) TestJoinReorderColumnOrder(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t1, t2, t3, t4")
+	tk.MustExec("create table t1(a int, b varchar(10))")
+	tk.MustExec("create table t2(c int, d decimal(10, 2))")
+	tk.MustExec("create table t3(e int, f varchar(10))")
+	tk.MustExec("create table t4(g int, h double)")
+	tk.MustExec("insert into t1 values (1, 'a'), (2, 'b'), (3, 'c')")
+	tk.MustExec("insert into t2 values (1, 1.5), (2, 2.5)")
+	tk.MustExec("insert into t3 values (1, 'x'), (2, 'y'), (3, 'z')")
+	tk.MustExec("insert into t4 values (1, 0.5), (2, 1.5), (4, 2.5)")
+	tk.MustExec("set session tidb_enable_cascades_planner = 1")
+
+	// t1 and t2 are joined by a cartesian product in the original order, the
+	// reordered joins like `(t3 join t1) join (t2 join t4)` are cheaper, and
+	// they output the columns in a different order from the original join.
+	sql := "select * from t1, t2, t3, t4 where t1.a = t3.e and t2.c = t4.g and t3.e = t4.g order by t1.a"
+	expected := testkit.Rows("1 a 1 1.50 1 x 1 0.5", "2 b 2 2.50 2 y 2 1.5")
+	tk.MustExec("set session tidb_opt_cascades_join_reorder_budget = 0")
+	c.Assert(strings.Join(explainWithoutIDs(tk, sql), "\n"), Matches, "(?s).*CARTESIAN.*")
+	tk.MustQuery(sql).Check(expected)
+	tk.MustExec("set session tidb_opt_cascades_join_reorder_budget = " + strconv.Itoa(variable.DefOptCascadesJoinReorderBudget))
+	c.Assert(strings.Join(explainWithoutIDs(tk, sql), "\n"), Not(Matches), "(?s).*CARTESIAN.*")
+	tk.MustQuery(sql).Check(expected)
+	tk.MustQuery("select t4.h, t1.b, t2.d from t1, t2, t3, t4 where t1.a = t3.e and t2.c = t4.g and t3.e = t4.g order by t1.a").
+		Check(testkit.Rows("0.5 a 1.50", "1.5 b 2.50"))
+	tk.MustQuery("select count(*), sum(t2.d), max(t3.f) from t1, t2, t3, t4 where t1.a = t3.e and t2.c = t4.g and t3.e = t4.g").
+		Check(testkit.Rows("2 4.00 y"))
+}
+
+func (s *testIntegrationSuite) TestMergeJoin(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int primary key, b int)")
+	tk.MustExec("create table t2(a int primary key, b int)")
+	tk.MustExec("insert into t1 values (1, 11), (4, 44), (2, 22), (3, 33)")
+	tk.MustExec("insert into t2 values (1, 111), (2, 222), (3, 333)")
+
+	// The handles are read in order without any Sort, so the merge join
+	// saves building the hash table and is chosen by its cost.
+	sql := "select t1.a, t1.b from t1, t2 where t1.a = t2.a and t1.a > 2"
+	tk.MustExec("set session tidb_enable_cascades_planner = 1")
+	plan := explainWithoutIDs(tk, sql)
+	c.Assert(plan, HasLen, 6)
+	c.Assert(plan[1], Matches, ".*MergeJoin.*left key:test.t1.a, right key:test.t2.a")
+	for _, row := range plan[2:] {
+		c.Assert(row, Not(Matches), ".*Sort.*")
+		c.Assert(row, Not(Matches), ".*keep order:false.*")
+	}
+
+	// The merge joins return the same results as the hash joins.
+	for _, sql := range []string{
+		"select t1.a, t1.b, t2.b from t1, t2 where t1.a = t2.a",
+		"select t1.a, t1.b, t2.b from t1, t2 where t1.a = t2.a and t1.a > 2",
+		"select t1.a, t1.b, t2.b from t1, t2 where t1.a = t2.a and t1.b < t2.b and t2.a < 3",
+		"select t1.a, t1.b, t2.b from t1, t2 where t1.a = t2.a order by t1.a desc",
+	} {
+		tk.MustExec("set session tidb_enable_cascades_planner = 0")
+		expected := tk.MustQuery(sql).Sort().Rows()
+		tk.MustExec("set session tidb_enable_cascades_planner = 1")
+		tk.MustQuery(sql).Sort().Check(expected)
+	}
+}
+
+func (s *testIntegrationSuite) TestExplainMemo(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int)")
+	// The memo is shown even if the cascades planner is not enabled.
+	rows := tk.MustQuery("explain format = 'memo' select b from t where a > 1 and b < 1").Rows()
+	c.Assert(len(rows), Greater, 0)
+	c.Assert(rows[0][0], Equals, "Group#0 Schema:[test.t.b]")
+	var hasRule, hasImpl bool
+	for _, row := range rows {
+		line := row[0].(string)
+		hasRule = hasRule || strings.Contains(line, " rule:PushSelDown")
+		hasImpl = hasImpl || strings.HasPrefix(line, "    Impl ")
+	}
+	c.Assert(hasRule, IsTrue)
+	c.Assert(hasImpl, IsTrue)
+
+	_, err := tk.Exec("explain format = 'memo' insert into t values (1, 1)")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "explain format 'memo' only supports queries")
+}
@@ -16,9 +16,11 @@ package cascades
 import (
 	"container/list"
 	"math"
+	"reflect"
 
 	"github.com/pingcap/tidb/expression"
 	plannercore "github.com/pingcap/tidb/planner/core"
+	impl "github.com/pingcap/tidb/planner/implementation"
 	"github.com/pingcap/tidb/planner/memo"
 	"github.com/pingcap/tidb/planner/property"
 	"github.com/pingcap/tidb/sessionctx"
@@ -103,21 +105,64 @@ func (opt *Optimizer) GetImplementationRules(node plannercore.LogicalPlan) []Imp
 // memo structure is used for a group to reduce the repeated search on the same
 // required physical property.
 func (opt *Optimizer) FindBestPlan(sctx sessionctx.Context, logical plannercore.LogicalPlan) (p plannercore.PhysicalPlan, err error) {
+	p, _, err = opt.FindBestPlanAndMemo(sctx, logical)
+	return p, err
+}
+
+// FindBestPlanAndMemo is the same as FindBestPlan, but it returns the root
+// Group of the memo as well, which can be used to show how the plan is chosen.
+func (opt *Optimizer) FindBestPlanAndMemo(sctx sessionctx.Context, logical plannercore.LogicalPlan) (p plannercore.PhysicalPlan, rootGroup *memo.Group, err error) {
 	logical, err = opt.onPhasePreprocessing(sctx, logical)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	rootGroup := memo.Convert2Group(logical)
+	rootGroup = memo.Convert2Group(logical)
 	err = opt.onPhaseExploration(sctx, rootGroup)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	p, err = opt.onPhaseImplementation(sctx, rootGroup)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	err = p.ResolveIndices()
-	return p, err
+	return p, rootGroup, err
+}
+
+// needColumnReorder checks whether the columns of the schema of a physical
+// plan are the columns of the schema of its Group in a different order.
+func needColumnReorder(planSchema, groupSchema *expression.Schema) bool {
+	if planSchema.Len() != groupSchema.Len() {
+		return false
+	}
+	sameOrder := true
+	for i, col := range planSchema.Columns {
+		if !col.Equal(nil, groupSchema.Columns[i]) {
+			sameOrder = false
+		}
+		if !groupSchema.Contains(col) {
+			return false
+		}
+	}
+	return !sameOrder
+}
+
+// keepColumnOrder adds a Projection on the Implementation to output the
+// columns in the order of the schema. The Joins generated by the join reorder
+// rules output the columns in the order of their children, which may differ
+// from the order of the schema of their Group.
+func keepColumnOrder(childImpl memo.Implementation, schema *expression.Schema, cost float64) memo.Implementation {
+	child := childImpl.GetPlan()
+	exprs := make([]expression.Expression, 0, schema.Len())
+	for _, col := range schema.Columns {
+		exprs = append(exprs, col)
+	}
+	proj := plannercore.PhysicalProjection{Exprs: exprs}.Init(child.SCtx(), child.Stats(), &property.PhysicalProperty{ExpectedCnt: math.MaxFloat64})
+	proj.SetSchema(schema.Clone())
+	proj.SetChildren(child)
+	projImpl := impl.NewProjectionImpl(proj)
+	projImpl.SetCost(cost)
+	return projImpl
 }
 
 func (opt *Optimizer) onPhasePreprocessing(sctx sessionctx.Context, plan plannercore.LogicalPlan) (plannercore.LogicalPlan, error) {
@@ -175,6 +220,9 @@ func (opt *Optimizer) exploreGroup(g *memo.Group) error {
 func (opt *Optimizer) findMoreEquiv(g *memo.Group, elem *list.Element) (eraseCur bool, err error) {
 	expr := elem.Value.(*memo.GroupExpr)
 	for _, rule := range opt.GetTransformationRules(expr.ExprNode) {
+		if expr.IsRuleDisabled(rule) {
+			continue
+		}
 		pattern := rule.GetPattern()
 		if !pattern.Operand.Match(memo.GetOperand(expr.ExprNode)) {
 			continue
@@ -192,6 +240,9 @@ func (opt *Optimizer) findMoreEquiv(g *memo.Group, elem *list.Element) (eraseCur
 				return false, err
 			}
 
+			for _, e := range newExprs {
+				e.AppliedRule = ruleName(rule)
+			}
 			if eraseAll {
 				g.DeleteAll()
 				for _, e := range newExprs {
@@ -217,6 +268,11 @@ func (opt *Optimizer) findMoreEquiv(g *memo.Group, elem *list.Element) (eraseCur
 	return eraseCur, nil
 }
 
+// ruleName returns the name of the rule, which is the name of its type.
+func ruleName(rule Transformation) string {
+	return reflect.Indirect(reflect.ValueOf(rule)).Type().Name()
+}
+
 // fillGroupStats computes Stats property for each Group recursively.
 func (opt *Optimizer) fillGroupStats(g *memo.Group) (err error) {
 	if g.Prop.Stats != nil {
@@ -306,11 +362,18 @@ func (opt *Optimizer) implGroup(g *memo.Group, reqPhysProp *property.PhysicalPro
 				continue
 			}
 			cumCost = impl.CalcCost(outCount, childImpls...)
+			reorderColumns := needColumnReorder(impl.GetPlan().Schema(), g.Prop.Schema)
+			if reorderColumns {
+				cumCost += plannercore.GetProjectionCost(impl.GetPlan().SCtx(), outCount)
+			}
 			if cumCost > costLimit {
 				continue
 			}
 			if groupImpl == nil || groupImpl.GetCost() > cumCost {
 				groupImpl = impl.AttachChildren(childImpls...)
+				if reorderColumns {
+					groupImpl = keepColumnOrder(groupImpl, g.Prop.Schema, cumCost)
+				}
 				costLimit = cumCost
 			}
 		}
@@ -337,6 +400,9 @@ func (opt *Optimizer) implGroup(g *memo.Group, reqPhysProp *property.PhysicalPro
 	if groupImpl == nil || groupImpl.GetCost() == math.MaxFloat64 {
 		return nil, nil
 	}
+	// The cost is kept on the Implementation, set it on the physical plan as
+	// well so that it can be shown by EXPLAIN FORMAT = 'json'.
+	groupImpl.GetPlan().SetCost(groupImpl.GetCost())
 	g.InsertImpl(reqPhysProp, groupImpl)
 	return groupImpl, nil
 }
@@ -346,13 +412,11 @@ func (opt *Optimizer) implGroupExpr(cur *memo.GroupExpr, reqPhysProp *property.P
 		if !rule.Match(cur, reqPhysProp) {
 			continue
 		}
-		impl, err := rule.OnImplement(cur, reqPhysProp)
+		ruleImpls, err := rule.OnImplement(cur, reqPhysProp)
 		if err != nil {
 			return nil, err
 		}
-		if impl != nil {
-			impls = append(impls, impl)
-		}
+		impls = append(impls, ruleImpls...)
 	}
 	return impls, nil
 }
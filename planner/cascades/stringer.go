@@ -16,6 +16,7 @@ package cascades
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pingcap/tidb/planner/memo"
@@ -25,11 +26,21 @@ import (
 func ToString(g *memo.Group) []string {
 	idMap := make(map[*memo.Group]int)
 	idMap[g] = 0
-	return toString(g, idMap, map[*memo.Group]struct{}{}, []string{})
+	return toString(g, idMap, map[*memo.Group]struct{}{}, []string{}, false)
+}
+
+// MemoToString stringifies a Group Tree after the optimization. Besides the
+// Groups and Group expressions, it shows the transformation rule which
+// generates each Group expression, and the best Implementation and its cost
+// for each required physical property of the Groups.
+func MemoToString(g *memo.Group) []string {
+	idMap := make(map[*memo.Group]int)
+	idMap[g] = 0
+	return toString(g, idMap, map[*memo.Group]struct{}{}, []string{}, true)
 }
 
 // toString recursively stringifies a Group Tree using a preorder traversal method.
-func toString(g *memo.Group, idMap map[*memo.Group]int, visited map[*memo.Group]struct{}, strs []string) []string {
+func toString(g *memo.Group, idMap map[*memo.Group]int, visited map[*memo.Group]struct{}, strs []string, withImpl bool) []string {
 	if _, exists := visited[g]; exists {
 		return strs
 	}
@@ -44,12 +55,12 @@ func toString(g *memo.Group, idMap map[*memo.Group]int, visited map[*memo.Group]
 		}
 	}
 	// Visit self first.
-	strs = append(strs, groupToString(g, idMap)...)
+	strs = append(strs, groupToString(g, idMap, withImpl)...)
 	// Visit children then.
 	for item := g.Equivalents.Front(); item != nil; item = item.Next() {
 		expr := item.Value.(*memo.GroupExpr)
 		for _, childGroup := range expr.Children {
-			strs = toString(childGroup, idMap, visited, strs)
+			strs = toString(childGroup, idMap, visited, strs, withImpl)
 		}
 	}
 	return strs
@@ -60,7 +71,12 @@ func toString(g *memo.Group, idMap map[*memo.Group]int, visited map[*memo.Group]
 // Group#1 Column: [Column#1,Column#2,Column#13] Unique key: []
 //     Selection_4 input:[Group#2], eq(Column#13, Column#2), gt(Column#1, 10)
 //     Projection_15 input:Group#3 Column#1, Column#2
-func groupToString(g *memo.Group, idMap map[*memo.Group]int) []string {
+// If withImpl is true, the rules and the Implementations are shown as well:
+// Group#1 Schema:[Column#1,Column#2]
+//     Selection_4 input:[Group#2], gt(Column#1, 10)
+//     Selection_9 input:[Group#3], gt(Column#1, 10) rule:PushSelDownTiKVSingleGather
+//     Impl Prop{cols: [], TaskTp: rootTask, expectedCount: 10}: Selection_12 input:[TableReader_11], cost:1200.00
+func groupToString(g *memo.Group, idMap map[*memo.Group]int, withImpl bool) []string {
 	schema := g.Prop.Schema
 	colStrs := make([]string, 0, len(schema.Columns))
 	for _, col := range schema.Columns {
@@ -86,8 +102,41 @@ func groupToString(g *memo.Group, idMap map[*memo.Group]int) []string {
 	result = append(result, groupLine.String())
 	for item := g.Equivalents.Front(); item != nil; item = item.Next() {
 		expr := item.Value.(*memo.GroupExpr)
-		result = append(result, "    "+groupExprToString(expr, idMap))
+		exprStr := groupExprToString(expr, idMap)
+		if withImpl && expr.AppliedRule != "" {
+			exprStr += " rule:" + expr.AppliedRule
+		}
+		result = append(result, "    "+exprStr)
+	}
+	if withImpl {
+		result = append(result, groupImplsToString(g)...)
+	}
+	return result
+}
+
+// groupImplsToString stringifies the best Implementations of a Group, they
+// are ordered by the required physical properties.
+// Format:
+// Impl Prop{cols: [], TaskTp: rootTask, expectedCount: 10}: Selection_12 input:[TableReader_11], cost:1200.00
+func groupImplsToString(g *memo.Group) []string {
+	props := g.GetImplProps()
+	result := make([]string, 0, len(props))
+	for _, prop := range props {
+		impl := g.GetImpl(prop)
+		plan := impl.GetPlan()
+		buffer := bytes.NewBufferString("    Impl ")
+		fmt.Fprintf(buffer, "%s: %s", prop.String(), plan.ExplainID().String())
+		if len(plan.Children()) > 0 {
+			children := make([]string, 0, len(plan.Children()))
+			for _, child := range plan.Children() {
+				children = append(children, child.ExplainID().String())
+			}
+			fmt.Fprintf(buffer, " input:[%s]", strings.Join(children, ","))
+		}
+		fmt.Fprintf(buffer, ", cost:%.2f", impl.GetCost())
+		result = append(result, buffer.String())
 	}
+	sort.Strings(result)
 	return result
 }
 
@@ -14,11 +14,18 @@
 package cascades
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/expression/aggregation"
+	"github.com/pingcap/tidb/parser/ast"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/planner/memo"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/util/ranger"
+	"github.com/pingcap/tidb/util/set"
 )
 
 // Transformation defines the interface for the transformation rules.
@@ -58,16 +65,29 @@ var defaultTransformationMap = map[memo.Operand][]Transformation{
 	memo.OperandAggregation: {
 		NewRulePushAggDownGather(),
 		NewRuleMergeAggregationProjection(),
+		NewRuleEliminateOuterJoinBelowAggregation(),
+		NewRuleTransformAggToProj(),
+		NewRulePushAggDownJoin(),
 	},
 	memo.OperandLimit: {
 		NewRuleTransformLimitToTopN(),
+		NewRulePushLimitDownProjection(),
+		NewRulePushLimitDownOuterJoin(),
 	},
 	memo.OperandProjection: {
 		NewRuleEliminateProjection(),
 		NewRuleMergeAdjacentProjection(),
+		NewRuleEliminateOuterJoinBelowProjection(),
 	},
 	memo.OperandTopN: {
 		NewRulePushTopNDownProjection(),
+		NewRulePushTopNDownOuterJoin(),
+	},
+	memo.OperandJoin: {
+		NewRuleJoinCommutative(),
+		NewRuleJoinRightAssociate(),
+		NewRuleJoinLeftAssociate(),
+		NewRuleJoinExchange(),
 	},
 }
 
@@ -662,6 +682,7 @@ func (r *PushSelDownJoin) OnTransform(old *memo.ExprIter) (newExprs []*memo.Grou
 	}
 	leftCond = expression.RemoveDupExprs(sctx, leftCond)
 	rightCond = expression.RemoveDupExprs(sctx, rightCond)
+	join.LeftJoinKeys, join.RightJoinKeys = nil, nil
 	for _, eqCond := range join.EqualConditions {
 		join.LeftJoinKeys = append(join.LeftJoinKeys, eqCond.GetArgs()[0].(*expression.Column))
 		join.RightJoinKeys = append(join.RightJoinKeys, eqCond.GetArgs()[1].(*expression.Column))
@@ -884,3 +905,936 @@ func (r *MergeAggregationProjection) OnTransform(old *memo.ExprIter) (newExprs [
 	newAggExpr.SetChildren(old.Children[0].GetExpr().Children...)
 	return []*memo.GroupExpr{newAggExpr}, true, false, nil
 }
+
+// PushLimitDownProjection pushes Limit to Projection.
+type PushLimitDownProjection struct {
+	baseRule
+}
+
+// NewRulePushLimitDownProjection creates a new Transformation PushLimitDownProjection.
+// The pattern of this rule is `Limit->Projection->X` to `Projection->Limit->X`.
+func NewRulePushLimitDownProjection() Transformation {
+	rule := &PushLimitDownProjection{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandLimit,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandProjection, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *PushLimitDownProjection) Match(expr *memo.ExprIter) bool {
+	proj := expr.Children[0].GetExpr().ExprNode.(*plannercore.LogicalProjection)
+	for _, expr := range proj.Exprs {
+		if expression.HasAssignSetVarFunc(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// OnTransform implements Transformation interface.
+// This rule tries to pushes the Limit through Projection.
+func (r *PushLimitDownProjection) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	proj := old.Children[0].GetExpr().ExprNode.(*plannercore.LogicalProjection)
+	childGroup := old.Children[0].GetExpr().Children[0]
+
+	projExpr := memo.NewGroupExpr(proj)
+	limitExpr := memo.NewGroupExpr(old.GetExpr().ExprNode)
+	limitExpr.SetChildren(childGroup)
+	limitGroup := memo.NewGroupWithSchema(limitExpr, childGroup.Prop.Schema)
+	projExpr.SetChildren(limitGroup)
+	return []*memo.GroupExpr{projExpr}, true, false, nil
+}
+
+// getOuterChildIdx returns the index of the outer child of the outer Join, or -1 if the Join is not an outer Join.
+func getOuterChildIdx(join *plannercore.LogicalJoin) int {
+	switch join.JoinType {
+	case plannercore.LeftOuterJoin:
+		return 0
+	case plannercore.RightOuterJoin:
+		return 1
+	}
+	return -1
+}
+
+// pushDownToOuterChild builds `Join->X` of the old Join GroupExpr with the node X inserted above
+// its outer child Group, and returns the new Join Group.
+func pushDownToOuterChild(joinExpr *memo.GroupExpr, node plannercore.LogicalPlan, outerIdx int) *memo.Group {
+	children := make([]*memo.Group, len(joinExpr.Children))
+	copy(children, joinExpr.Children)
+	outerGroup := children[outerIdx]
+	nodeExpr := memo.NewGroupExpr(node)
+	nodeExpr.SetChildren(outerGroup)
+	children[outerIdx] = memo.NewGroupWithSchema(nodeExpr, outerGroup.Prop.Schema)
+	newJoinExpr := memo.NewGroupExpr(joinExpr.ExprNode)
+	newJoinExpr.SetChildren(children...)
+	return memo.NewGroupWithSchema(newJoinExpr, joinExpr.Group.Prop.Schema)
+}
+
+// PushLimitDownOuterJoin pushes Limit through the outer Join.
+type PushLimitDownOuterJoin struct {
+	baseRule
+}
+
+// NewRulePushLimitDownOuterJoin creates a new Transformation PushLimitDownOuterJoin.
+// The pattern of this rule is `Limit -> Join`.
+func NewRulePushLimitDownOuterJoin() Transformation {
+	rule := &PushLimitDownOuterJoin{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandLimit,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandJoin, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *PushLimitDownOuterJoin) Match(expr *memo.ExprIter) bool {
+	join := expr.Children[0].GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	return join.JoinType.IsOuterJoin()
+}
+
+// OnTransform implements Transformation interface.
+// This rule tries to push a Limit with `Count+Offset` rows down to the outer child of the outer Join,
+// since every row of the outer child produces at least one row of the Join.
+// It will transform `Limit->Join(X, Y)` to `Limit->Join(Limit->X, Y)` for left outer Join.
+func (r *PushLimitDownOuterJoin) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	limit := old.GetExpr().ExprNode.(*plannercore.LogicalLimit)
+	joinExpr := old.Children[0].GetExpr()
+	outerIdx := getOuterChildIdx(joinExpr.ExprNode.(*plannercore.LogicalJoin))
+
+	newLimit := plannercore.LogicalLimit{
+		Count: limit.Count + limit.Offset,
+	}.Init(limit.SCtx())
+	newJoinGroup := pushDownToOuterChild(joinExpr, newLimit, outerIdx)
+	newLimitExpr := memo.NewGroupExpr(limit)
+	newLimitExpr.SetChildren(newJoinGroup)
+	// The Limit has been pushed down, so we disable this rule on the new
+	// Limit to avoid pushing it down repeatedly.
+	newLimitExpr.DisableRules(r)
+	return []*memo.GroupExpr{newLimitExpr}, true, false, nil
+}
+
+// PushTopNDownOuterJoin pushes TopN through the outer Join.
+type PushTopNDownOuterJoin struct {
+	baseRule
+}
+
+// NewRulePushTopNDownOuterJoin creates a new Transformation PushTopNDownOuterJoin.
+// The pattern of this rule is `TopN -> Join`.
+func NewRulePushTopNDownOuterJoin() Transformation {
+	rule := &PushTopNDownOuterJoin{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandTopN,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandJoin, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+// The TopN can only be pushed down when all the ByItems are from the outer child.
+func (r *PushTopNDownOuterJoin) Match(expr *memo.ExprIter) bool {
+	topN := expr.GetExpr().ExprNode.(*plannercore.LogicalTopN)
+	joinExpr := expr.Children[0].GetExpr()
+	outerIdx := getOuterChildIdx(joinExpr.ExprNode.(*plannercore.LogicalJoin))
+	if outerIdx < 0 {
+		return false
+	}
+	outerSchema := joinExpr.Children[outerIdx].Prop.Schema
+	for _, by := range topN.ByItems {
+		for _, col := range expression.ExtractColumns(by.Expr) {
+			if !outerSchema.Contains(col) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// OnTransform implements Transformation interface.
+// This rule tries to push a TopN with `Count+Offset` rows down to the outer child of the outer Join.
+// It will transform `TopN->Join(X, Y)` to `TopN->Join(TopN->X, Y)` for left outer Join.
+func (r *PushTopNDownOuterJoin) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	topN := old.GetExpr().ExprNode.(*plannercore.LogicalTopN)
+	joinExpr := old.Children[0].GetExpr()
+	outerIdx := getOuterChildIdx(joinExpr.ExprNode.(*plannercore.LogicalJoin))
+
+	newTopN := plannercore.LogicalTopN{
+		Count:   topN.Count + topN.Offset,
+		ByItems: make([]*plannercore.ByItems, 0, len(topN.ByItems)),
+	}.Init(topN.SCtx())
+	for _, by := range topN.ByItems {
+		newTopN.ByItems = append(newTopN.ByItems, by.Clone())
+	}
+	newJoinGroup := pushDownToOuterChild(joinExpr, newTopN, outerIdx)
+	newTopNExpr := memo.NewGroupExpr(topN)
+	newTopNExpr.SetChildren(newJoinGroup)
+	// The TopN has been pushed down, so we disable this rule on the new
+	// TopN to avoid pushing it down repeatedly.
+	newTopNExpr.DisableRules(r)
+	return []*memo.GroupExpr{newTopNExpr}, true, false, nil
+}
+
+// tryToEliminateOuterJoin returns the outer child Group of the outer Join if the
+// Join can be eliminated, otherwise nil. The parentCols are the columns used by the
+// parent of the Join, and the aggCols are the columns used by the duplicate agnostic
+// aggregate functions of the parent.
+func tryToEliminateOuterJoin(joinExpr *memo.GroupExpr, parentCols, aggCols []*expression.Column) *memo.Group {
+	join := joinExpr.ExprNode.(*plannercore.LogicalJoin)
+	outerIdx := getOuterChildIdx(join)
+	if outerIdx < 0 {
+		return nil
+	}
+	innerIdx := 1 ^ outerIdx
+	outerGroup := joinExpr.Children[outerIdx]
+	innerGroup := joinExpr.Children[innerIdx]
+	outerUniqueIDs := set.NewInt64Set()
+	for _, outerCol := range outerGroup.Prop.Schema.Columns {
+		outerUniqueIDs.Insert(outerCol.UniqueID)
+	}
+	if !plannercore.IsColsAllFromOuterTable(parentCols, outerUniqueIDs) {
+		return nil
+	}
+	// outer join elimination with duplicate agnostic aggregate functions
+	if plannercore.IsColsAllFromOuterTable(aggCols, outerUniqueIDs) {
+		return outerGroup
+	}
+	// outer join elimination without duplicate agnostic aggregate functions
+	innerJoinKeys := plannercore.ExtractInnerJoinKeys(join, innerIdx)
+	innerGroup.BuildKeyInfo()
+	if plannercore.IsInnerJoinKeysContainUniqueKey(innerGroup.Prop.Schema, innerJoinKeys) {
+		return outerGroup
+	}
+	for elem := innerGroup.Equivalents.Front(); elem != nil; elem = elem.Next() {
+		var ds *plannercore.DataSource
+		switch x := elem.Value.(*memo.GroupExpr).ExprNode.(type) {
+		case *plannercore.DataSource:
+			ds = x
+		case *plannercore.TiKVSingleGather:
+			ds = x.Source
+		default:
+			continue
+		}
+		if plannercore.IsInnerJoinKeysContainIndex(ds, innerJoinKeys) {
+			return outerGroup
+		}
+	}
+	return nil
+}
+
+// EliminateOuterJoinBelowAggregation eliminates the outer Join below the Aggregation.
+type EliminateOuterJoinBelowAggregation struct {
+	baseRule
+}
+
+// NewRuleEliminateOuterJoinBelowAggregation creates a new Transformation EliminateOuterJoinBelowAggregation.
+// The pattern of this rule is `Aggregation -> Join`.
+func NewRuleEliminateOuterJoinBelowAggregation() Transformation {
+	rule := &EliminateOuterJoinBelowAggregation{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandAggregation,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandJoin, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *EliminateOuterJoinBelowAggregation) Match(expr *memo.ExprIter) bool {
+	join := expr.Children[0].GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	return join.JoinType.IsOuterJoin()
+}
+
+// OnTransform implements Transformation interface.
+// This rule tries to eliminate the outer Join if the Aggregation only uses the columns
+// of the outer child, and either the aggregate functions are duplicate agnostic or the
+// join keys of the inner child contain a unique key. It will transform
+// `Aggregation->Join(X, Y)` to `Aggregation->X` for left outer Join.
+func (r *EliminateOuterJoinBelowAggregation) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	agg := old.GetExpr().ExprNode.(*plannercore.LogicalAggregation)
+	parentCols := expression.ExtractColumnsFromExpressions(nil, agg.GroupByItems, nil)
+	for _, aggFunc := range agg.AggFuncs {
+		parentCols = expression.ExtractColumnsFromExpressions(parentCols, aggFunc.Args, nil)
+	}
+	_, aggCols := plannercore.GetDupAgnosticAggCols(agg, nil)
+	outerGroup := tryToEliminateOuterJoin(old.Children[0].GetExpr(), parentCols, aggCols)
+	if outerGroup == nil {
+		return nil, false, false, nil
+	}
+	newAggExpr := memo.NewGroupExpr(agg)
+	newAggExpr.SetChildren(outerGroup)
+	return []*memo.GroupExpr{newAggExpr}, true, false, nil
+}
+
+// EliminateOuterJoinBelowProjection eliminates the outer Join below the Projection.
+type EliminateOuterJoinBelowProjection struct {
+	baseRule
+}
+
+// NewRuleEliminateOuterJoinBelowProjection creates a new Transformation EliminateOuterJoinBelowProjection.
+// The pattern of this rule is `Projection -> Join`.
+func NewRuleEliminateOuterJoinBelowProjection() Transformation {
+	rule := &EliminateOuterJoinBelowProjection{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandProjection,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandJoin, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *EliminateOuterJoinBelowProjection) Match(expr *memo.ExprIter) bool {
+	join := expr.Children[0].GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	return join.JoinType.IsOuterJoin()
+}
+
+// OnTransform implements Transformation interface.
+// This rule tries to eliminate the outer Join if the Projection only uses the columns
+// of the outer child and the join keys of the inner child contain a unique key.
+// It will transform `Projection->Join(X, Y)` to `Projection->X` for left outer Join.
+func (r *EliminateOuterJoinBelowProjection) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	proj := old.GetExpr().ExprNode.(*plannercore.LogicalProjection)
+	parentCols := expression.ExtractColumnsFromExpressions(nil, proj.Exprs, nil)
+	outerGroup := tryToEliminateOuterJoin(old.Children[0].GetExpr(), parentCols, nil)
+	if outerGroup == nil {
+		return nil, false, false, nil
+	}
+	newProjExpr := memo.NewGroupExpr(proj)
+	newProjExpr.SetChildren(outerGroup)
+	return []*memo.GroupExpr{newProjExpr}, true, false, nil
+}
+
+// TransformAggToProj converts the Aggregation grouped by a unique key of its child to a Projection.
+type TransformAggToProj struct {
+	baseRule
+}
+
+// NewRuleTransformAggToProj creates a new Transformation TransformAggToProj.
+// The pattern of this rule is `Aggregation -> Any`.
+func NewRuleTransformAggToProj() Transformation {
+	rule := &TransformAggToProj{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandAggregation,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandAny, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *TransformAggToProj) Match(expr *memo.ExprIter) bool {
+	agg := expr.GetExpr().ExprNode.(*plannercore.LogicalAggregation)
+	for _, aggFunc := range agg.AggFuncs {
+		if aggFunc.Mode != aggregation.CompleteMode {
+			return false
+		}
+		switch aggFunc.Name {
+		case ast.AggFuncCount, ast.AggFuncSum, ast.AggFuncAvg, ast.AggFuncFirstRow, ast.AggFuncMax, ast.AggFuncMin:
+		default:
+			return false
+		}
+	}
+	childGroup := expr.Children[0].Group
+	childGroup.BuildKeyInfo()
+	schemaByGroupby := expression.NewSchema(agg.GetGroupByCols()...)
+	for _, key := range childGroup.Prop.Schema.Keys {
+		if schemaByGroupby.ColumnsIndices(key) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// OnTransform implements Transformation interface.
+// Every group of the Aggregation only contains one row since it is grouped by a unique key,
+// so the Aggregation is redundant. It will transform `Aggregation->X` to `Projection->X`.
+func (r *TransformAggToProj) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	agg := old.GetExpr().ExprNode.(*plannercore.LogicalAggregation)
+	proj := plannercore.ConvertAggToProj(agg, old.GetExpr().Group.Prop.Schema)
+	newProjExpr := memo.NewGroupExpr(proj)
+	newProjExpr.SetChildren(old.Children[0].Group)
+	return []*memo.GroupExpr{newProjExpr}, true, false, nil
+}
+
+// PushAggDownJoin pushes the Aggregation down to the children of the Join, this
+// is also known as eager aggregation.
+type PushAggDownJoin struct {
+	baseRule
+}
+
+// NewRulePushAggDownJoin creates a new Transformation PushAggDownJoin.
+// The pattern of this rule is `Aggregation -> Join`.
+func NewRulePushAggDownJoin() Transformation {
+	rule := &PushAggDownJoin{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandAggregation,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandJoin, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *PushAggDownJoin) Match(expr *memo.ExprIter) bool {
+	agg := expr.GetExpr().ExprNode.(*plannercore.LogicalAggregation)
+	if !agg.SCtx().GetSessionVars().AllowAggPushDown {
+		return false
+	}
+	for _, aggFunc := range agg.AggFuncs {
+		if aggFunc.Mode != aggregation.CompleteMode {
+			return false
+		}
+	}
+	return true
+}
+
+// OnTransform implements Transformation interface.
+// It will transform `Agg->Join(X, Y)` to `Agg(Final)->Join(Agg->X, Y)` or
+// `Agg(Final)->Join(X, Agg->Y)` or `Agg(Final)->Join(Agg->X, Agg->Y)`. The
+// group by columns of the pushed down Aggregation contain the columns used
+// by the join conditions.
+func (r *PushAggDownJoin) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	agg := old.GetExpr().ExprNode.(*plannercore.LogicalAggregation)
+	joinExpr := old.Children[0].GetExpr()
+	// The DefaultValues of the Join may be changed below, so we need to copy the Join.
+	join := joinExpr.ExprNode.(*plannercore.LogicalJoin).Shallow()
+	// The aggregate functions are changed to the final mode ones when they are
+	// pushed down, so we build a new Aggregation for them.
+	finalAggFuncs := make([]*aggregation.AggFuncDesc, 0, len(agg.AggFuncs))
+	for _, aggFunc := range agg.AggFuncs {
+		finalAggFuncs = append(finalAggFuncs, aggFunc.Clone())
+	}
+	finalAgg := plannercore.LogicalAggregation{
+		AggFuncs:     finalAggFuncs,
+		GroupByItems: agg.GroupByItems,
+	}.Init(agg.SCtx())
+	valid, leftAggFuncs, rightAggFuncs, leftGbyCols, rightGbyCols :=
+		plannercore.SplitAggFuncsAndGbyCols(finalAgg, join, joinExpr.Children[0].Prop.Schema)
+	if !valid {
+		return nil, false, false, nil
+	}
+	aggFuncs := [2][]*aggregation.AggFuncDesc{leftAggFuncs, rightAggFuncs}
+	gbyCols := [2][]*expression.Column{leftGbyCols, rightGbyCols}
+	children := make([]*memo.Group, len(joinExpr.Children))
+	copy(children, joinExpr.Children)
+	pushed := false
+	for i, childGroup := range joinExpr.Children {
+		// If there exist count or sum functions in one child, we can't push any
+		// aggregate function into the other child.
+		if plannercore.CheckAnyCountAndSum(aggFuncs[1-i]) {
+			continue
+		}
+		// If the join is multiway-join, we forbid pushing down.
+		if childGroup.GetFirstElem(memo.OperandJoin) != nil {
+			continue
+		}
+		childGroup.BuildKeyInfo()
+		pushedAgg, err := plannercore.PushDownAggToJoinChild(aggFuncs[i], gbyCols[i], join, i, childGroup.Prop.Schema)
+		if err != nil {
+			return nil, false, false, err
+		}
+		if pushedAgg == nil {
+			if len(aggFuncs[i]) > 0 && aggFuncs[i][0].Mode == aggregation.FinalMode {
+				// The aggregate functions have been changed to the final mode, but the
+				// Aggregation can't be pushed down because the DefaultValues of the outer
+				// Join don't exist, so the new final Aggregation is invalid.
+				return nil, false, false, nil
+			}
+			continue
+		}
+		pushedAggExpr := memo.NewGroupExpr(pushedAgg)
+		pushedAggExpr.SetChildren(childGroup)
+		children[i] = memo.NewGroupWithSchema(pushedAggExpr, pushedAgg.Schema())
+		pushed = true
+	}
+	if !pushed {
+		return nil, false, false, nil
+	}
+	newJoinExpr := memo.NewGroupExpr(join)
+	newJoinExpr.SetChildren(children...)
+	newJoinGroup := memo.NewGroupWithSchema(newJoinExpr, expression.MergeSchema(children[0].Prop.Schema, children[1].Prop.Schema))
+	finalAggExpr := memo.NewGroupExpr(finalAgg)
+	finalAggExpr.SetChildren(newJoinGroup)
+	// We don't erase the old Aggregation because this transformation would not always be better.
+	return []*memo.GroupExpr{finalAggExpr}, false, false, nil
+}
+
+// The join reorder rules below explore the equivalent join orders of the inner
+// joins. To avoid generating the same join tree repeatedly, the rules disable
+// some of them on the newly generated GroupExprs following the rule set RS-B2 in
+// "The Complexity of Transformation-Based Join Enumeration", and the Groups of
+// the same sub join tree are shared by the GroupExprs generated by the rules.
+
+// JoinCommutative swaps the children of an inner Join.
+type JoinCommutative struct {
+	baseRule
+}
+
+// NewRuleJoinCommutative creates a new Transformation JoinCommutative.
+// The pattern of this rule is: `Join -> (Any, Any)`.
+func NewRuleJoinCommutative() Transformation {
+	rule := &JoinCommutative{}
+	rule.pattern = memo.BuildPattern(
+		memo.OperandJoin,
+		memo.EngineTiDBOnly,
+		memo.NewPattern(memo.OperandAny, memo.EngineTiDBOnly),
+		memo.NewPattern(memo.OperandAny, memo.EngineTiDBOnly),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *JoinCommutative) Match(expr *memo.ExprIter) bool {
+	join := getReorderableJoin(expr.GetExpr())
+	return join != nil && !isCartesianJoin(join) && withinJoinReorderBudget(expr)
+}
+
+// OnTransform implements Transformation interface.
+// It will transform `x0 join x1` to `x1 join x0`.
+func (r *JoinCommutative) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	join := old.GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	group := old.GetExpr().Group
+	left, right := old.Children[0].Group, old.Children[1].Group
+	if joinExprExists(group, right, left) {
+		return nil, false, false, nil
+	}
+	newJoinExpr := newInnerJoinExpr(join.SCtx(), getJoinConditions(join), right, left)
+	newJoinExpr.DisableRules(joinReorderRules...)
+	return []*memo.GroupExpr{newJoinExpr}, false, false, nil
+}
+
+// JoinRightAssociate changes a left deep join tree to a right deep one.
+type JoinRightAssociate struct {
+	baseRule
+}
+
+// NewRuleJoinRightAssociate creates a new Transformation JoinRightAssociate.
+// The pattern of this rule is: `Join -> (Join -> (Any, Any), Any)`.
+func NewRuleJoinRightAssociate() Transformation {
+	rule := &JoinRightAssociate{}
+	any := memo.NewPattern(memo.OperandAny, memo.EngineTiDBOnly)
+	rule.pattern = memo.BuildPattern(
+		memo.OperandJoin,
+		memo.EngineTiDBOnly,
+		memo.BuildPattern(memo.OperandJoin, memo.EngineTiDBOnly, any, any),
+		any,
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *JoinRightAssociate) Match(expr *memo.ExprIter) bool {
+	return matchReorderableJoins(expr, expr.GetExpr(), expr.Children[0].GetExpr())
+}
+
+// OnTransform implements Transformation interface.
+// It will transform `(x0 join x1) join x2` to `x0 join (x1 join x2)`.
+func (r *JoinRightAssociate) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	x0, x1 := old.Children[0].GetExpr().Children[0], old.Children[0].GetExpr().Children[1]
+	x2 := old.Children[1].Group
+	newJoinExpr := associateJoins(old.GetExpr(), old.Children[0].GetExpr(), x0, x1, x2, false)
+	if newJoinExpr == nil {
+		return nil, false, false, nil
+	}
+	return []*memo.GroupExpr{newJoinExpr}, false, false, nil
+}
+
+// JoinLeftAssociate changes a right deep join tree to a left deep one.
+type JoinLeftAssociate struct {
+	baseRule
+}
+
+// NewRuleJoinLeftAssociate creates a new Transformation JoinLeftAssociate.
+// The pattern of this rule is: `Join -> (Any, Join -> (Any, Any))`.
+func NewRuleJoinLeftAssociate() Transformation {
+	rule := &JoinLeftAssociate{}
+	any := memo.NewPattern(memo.OperandAny, memo.EngineTiDBOnly)
+	rule.pattern = memo.BuildPattern(
+		memo.OperandJoin,
+		memo.EngineTiDBOnly,
+		any,
+		memo.BuildPattern(memo.OperandJoin, memo.EngineTiDBOnly, any, any),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *JoinLeftAssociate) Match(expr *memo.ExprIter) bool {
+	return matchReorderableJoins(expr, expr.GetExpr(), expr.Children[1].GetExpr())
+}
+
+// OnTransform implements Transformation interface.
+// It will transform `x0 join (x1 join x2)` to `(x0 join x1) join x2`.
+func (r *JoinLeftAssociate) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	x0 := old.Children[0].Group
+	x1, x2 := old.Children[1].GetExpr().Children[0], old.Children[1].GetExpr().Children[1]
+	newJoinExpr := associateJoins(old.GetExpr(), old.Children[1].GetExpr(), x0, x1, x2, true)
+	if newJoinExpr == nil {
+		return nil, false, false, nil
+	}
+	return []*memo.GroupExpr{newJoinExpr}, false, false, nil
+}
+
+// JoinExchange exchanges the children of two sibling Joins, which generates
+// the bushy join trees.
+type JoinExchange struct {
+	baseRule
+}
+
+// NewRuleJoinExchange creates a new Transformation JoinExchange.
+// The pattern of this rule is: `Join -> (Join -> (Any, Any), Join -> (Any, Any))`.
+func NewRuleJoinExchange() Transformation {
+	rule := &JoinExchange{}
+	any := memo.NewPattern(memo.OperandAny, memo.EngineTiDBOnly)
+	rule.pattern = memo.BuildPattern(
+		memo.OperandJoin,
+		memo.EngineTiDBOnly,
+		memo.BuildPattern(memo.OperandJoin, memo.EngineTiDBOnly, any, any),
+		memo.BuildPattern(memo.OperandJoin, memo.EngineTiDBOnly, any, any),
+	)
+	return rule
+}
+
+// Match implements Transformation interface.
+func (r *JoinExchange) Match(expr *memo.ExprIter) bool {
+	return matchReorderableJoins(expr, expr.GetExpr(), expr.Children[0].GetExpr(), expr.Children[1].GetExpr())
+}
+
+// OnTransform implements Transformation interface.
+// It will transform `(x0 join x1) join (x2 join x3)` to `(x0 join x2) join (x1 join x3)`.
+func (r *JoinExchange) OnTransform(old *memo.ExprIter) (newExprs []*memo.GroupExpr, eraseOld bool, eraseAll bool, err error) {
+	group := old.GetExpr().Group
+	topJoin := old.GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	leftJoin := old.Children[0].GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	rightJoin := old.Children[1].GetExpr().ExprNode.(*plannercore.LogicalJoin)
+	x0, x1 := old.Children[0].GetExpr().Children[0], old.Children[0].GetExpr().Children[1]
+	x2, x3 := old.Children[1].GetExpr().Children[0], old.Children[1].GetExpr().Children[1]
+	sctx := topJoin.SCtx()
+
+	conds := getJoinConditions(topJoin, leftJoin, rightJoin)
+	newLeftSchema := expression.MergeSchema(x0.Prop.Schema, x2.Prop.Schema)
+	newLeftConds, conds := splitJoinConditions(conds, newLeftSchema)
+	newRightSchema := expression.MergeSchema(x1.Prop.Schema, x3.Prop.Schema)
+	newRightConds, newTopConds := splitJoinConditions(conds, newRightSchema)
+	newLeftExpr := newInnerJoinExpr(sctx, newLeftConds, x0, x2)
+	newRightExpr := newInnerJoinExpr(sctx, newRightConds, x1, x3)
+	if countCartesianJoins(newLeftExpr, newRightExpr)+countCartesianConds(newTopConds, newLeftSchema, newRightSchema) >
+		countCartesianJoins(old.GetExpr(), old.Children[0].GetExpr(), old.Children[1].GetExpr()) {
+		return nil, false, false, nil
+	}
+
+	finder := newJoinGroupFinder(sctx)
+	newLeftGroup := finder.findGroup(group, finder.getExprKey(newLeftExpr))
+	newRightGroup := finder.findGroup(group, finder.getExprKey(newRightExpr))
+	if newLeftGroup != nil && newRightGroup != nil && joinExprExists(group, newLeftGroup, newRightGroup) {
+		return nil, false, false, nil
+	}
+	newLeftGroup = getOrCreateJoinGroup(newLeftGroup, newLeftExpr, newLeftSchema)
+	newRightGroup = getOrCreateJoinGroup(newRightGroup, newRightExpr, newRightSchema)
+	newTopExpr := newInnerJoinExpr(sctx, newTopConds, newLeftGroup, newRightGroup)
+	newTopExpr.DisableRules(joinReorderRules...)
+	return []*memo.GroupExpr{newTopExpr}, false, false, nil
+}
+
+// joinReorderRules contains the types of all the join reorder rules, which is
+// used to disable them on the GroupExprs.
+var joinReorderRules = []interface{}{
+	&JoinCommutative{},
+	&JoinRightAssociate{},
+	&JoinLeftAssociate{},
+	&JoinExchange{},
+}
+
+// joinAssociateDisabledRules contains the rules which are disabled on the top
+// Join generated by JoinRightAssociate and JoinLeftAssociate.
+var joinAssociateDisabledRules = []interface{}{
+	&JoinRightAssociate{},
+	&JoinLeftAssociate{},
+	&JoinExchange{},
+}
+
+// associateJoins generates `x0 join (x1 join x2)`, or `(x0 join x1) join x2`
+// if `leftDeep` is true, from the conditions of the two old Joins.
+func associateJoins(topExpr, childExpr *memo.GroupExpr, x0, x1, x2 *memo.Group, leftDeep bool) *memo.GroupExpr {
+	group := topExpr.Group
+	topJoin := topExpr.ExprNode.(*plannercore.LogicalJoin)
+	childJoin := childExpr.ExprNode.(*plannercore.LogicalJoin)
+	sctx := topJoin.SCtx()
+	conds := getJoinConditions(topJoin, childJoin)
+	newChildLeft, newChildRight, newTopOther := x1, x2, x0
+	if leftDeep {
+		newChildLeft, newChildRight, newTopOther = x0, x1, x2
+	}
+	newChildSchema := expression.MergeSchema(newChildLeft.Prop.Schema, newChildRight.Prop.Schema)
+	newChildConds, newTopConds := splitJoinConditions(conds, newChildSchema)
+	newChildExpr := newInnerJoinExpr(sctx, newChildConds, newChildLeft, newChildRight)
+	if countCartesianJoins(newChildExpr)+countCartesianConds(newTopConds, newChildSchema, newTopOther.Prop.Schema) >
+		countCartesianJoins(topExpr, childExpr) {
+		return nil
+	}
+
+	finder := newJoinGroupFinder(sctx)
+	newChildGroup := finder.findGroup(group, finder.getExprKey(newChildExpr))
+	if newChildGroup != nil {
+		if leftDeep && joinExprExists(group, newChildGroup, x2) || !leftDeep && joinExprExists(group, x0, newChildGroup) {
+			return nil
+		}
+	}
+	newChildGroup = getOrCreateJoinGroup(newChildGroup, newChildExpr, newChildSchema)
+	var newTopExpr *memo.GroupExpr
+	if leftDeep {
+		newTopExpr = newInnerJoinExpr(sctx, newTopConds, newChildGroup, x2)
+	} else {
+		newTopExpr = newInnerJoinExpr(sctx, newTopConds, x0, newChildGroup)
+	}
+	newTopExpr.DisableRules(joinAssociateDisabledRules...)
+	return newTopExpr
+}
+
+// getReorderableJoin returns the LogicalJoin of the GroupExpr if it can be reordered.
+func getReorderableJoin(expr *memo.GroupExpr) *plannercore.LogicalJoin {
+	join, ok := expr.ExprNode.(*plannercore.LogicalJoin)
+	if !ok || !join.IsReorderable() {
+		return nil
+	}
+	return join
+}
+
+// matchReorderableJoins checks whether all the Joins can be reordered and not
+// all of them are cartesian products.
+func matchReorderableJoins(iter *memo.ExprIter, exprs ...*memo.GroupExpr) bool {
+	allCartesian := true
+	for _, expr := range exprs {
+		join := getReorderableJoin(expr)
+		if join == nil {
+			return false
+		}
+		allCartesian = allCartesian && isCartesianJoin(join)
+	}
+	return !allCartesian && withinJoinReorderBudget(iter)
+}
+
+// withinJoinReorderBudget checks whether the join reorder rules can generate
+// more Join GroupExprs in the Group.
+func withinJoinReorderBudget(iter *memo.ExprIter) bool {
+	expr := iter.GetExpr()
+	budget := expr.ExprNode.SCtx().GetSessionVars().CascadesJoinReorderBudget
+	return countJoinExprs(expr.Group) < budget
+}
+
+// countJoinExprs returns the number of the Join GroupExprs in the Group.
+func countJoinExprs(g *memo.Group) int {
+	cnt := 0
+	for elem := g.GetFirstElem(memo.OperandJoin); elem != nil; elem = elem.Next() {
+		if memo.GetOperand(elem.Value.(*memo.GroupExpr).ExprNode) != memo.OperandJoin {
+			break
+		}
+		cnt++
+	}
+	return cnt
+}
+
+// joinExprExists checks whether the Group contains a Join GroupExpr whose
+// children are `left` and `right`.
+func joinExprExists(g *memo.Group, left, right *memo.Group) bool {
+	for elem := g.GetFirstElem(memo.OperandJoin); elem != nil; elem = elem.Next() {
+		expr := elem.Value.(*memo.GroupExpr)
+		if memo.GetOperand(expr.ExprNode) != memo.OperandJoin {
+			break
+		}
+		if expr.Children[0] == left && expr.Children[1] == right {
+			return true
+		}
+	}
+	return false
+}
+
+func isCartesianJoin(join *plannercore.LogicalJoin) bool {
+	return len(join.EqualConditions) == 0 && len(join.OtherConditions) == 0
+}
+
+// countCartesianJoins returns the number of the Joins which are cartesian products.
+func countCartesianJoins(exprs ...*memo.GroupExpr) int {
+	cnt := 0
+	for _, expr := range exprs {
+		if isCartesianJoin(expr.ExprNode.(*plannercore.LogicalJoin)) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// countCartesianConds returns 1 if the Join built on the conditions is a
+// cartesian product, i.e. none of the conditions refers to both sides.
+func countCartesianConds(conds []expression.Expression, leftSchema, rightSchema *expression.Schema) int {
+	for _, cond := range conds {
+		if !expression.ExprFromSchema(cond, leftSchema) && !expression.ExprFromSchema(cond, rightSchema) {
+			return 0
+		}
+	}
+	return 1
+}
+
+// getJoinConditions returns all the conditions of the inner Joins.
+func getJoinConditions(joins ...*plannercore.LogicalJoin) []expression.Expression {
+	var conds []expression.Expression
+	for _, join := range joins {
+		conds = append(conds, expression.ScalarFuncs2Exprs(join.EqualConditions)...)
+		conds = append(conds, join.LeftConditions...)
+		conds = append(conds, join.RightConditions...)
+		conds = append(conds, join.OtherConditions...)
+	}
+	if len(joins) > 0 {
+		conds = expression.RemoveDupExprs(joins[0].SCtx(), conds)
+	}
+	return conds
+}
+
+// splitJoinConditions splits the conditions into two parts, the first part
+// only refers to the columns in the schema.
+func splitJoinConditions(conds []expression.Expression, schema *expression.Schema) (inSchema, remained []expression.Expression) {
+	for _, cond := range conds {
+		if expression.ExprFromSchema(cond, schema) {
+			inSchema = append(inSchema, cond)
+		} else {
+			remained = append(remained, cond)
+		}
+	}
+	return inSchema, remained
+}
+
+// newInnerJoinExpr creates a new inner Join GroupExpr on the two Groups.
+func newInnerJoinExpr(sctx sessionctx.Context, conds []expression.Expression, left, right *memo.Group) *memo.GroupExpr {
+	join := plannercore.LogicalJoin{JoinType: plannercore.InnerJoin}.Init(sctx)
+	join.SetSchema(expression.MergeSchema(left.Prop.Schema, right.Prop.Schema))
+	eqConds, leftConds, rightConds, otherConds := join.ExtractOnCondition(conds, left.Prop.Schema, right.Prop.Schema, false, false)
+	join.EqualConditions = eqConds
+	join.LeftConditions = leftConds
+	join.RightConditions = rightConds
+	join.OtherConditions = otherConds
+	for _, eqCond := range join.EqualConditions {
+		join.LeftJoinKeys = append(join.LeftJoinKeys, eqCond.GetArgs()[0].(*expression.Column))
+		join.RightJoinKeys = append(join.RightJoinKeys, eqCond.GetArgs()[1].(*expression.Column))
+	}
+	joinExpr := memo.NewGroupExpr(join)
+	joinExpr.SetChildren(left, right)
+	return joinExpr
+}
+
+// getOrCreateJoinGroup inserts the Join GroupExpr into the existing Group, or
+// creates a new Group for it if the Group does not exist.
+func getOrCreateJoinGroup(g *memo.Group, expr *memo.GroupExpr, schema *expression.Schema) *memo.Group {
+	if g == nil {
+		return memo.NewGroupWithSchema(expr, schema)
+	}
+	if !joinExprExists(g, expr.Children[0], expr.Children[1]) && g.Insert(expr) {
+		g.Explored = false
+	}
+	return g
+}
+
+// joinGroupFinder finds the Group of a sub join tree among the descendants of
+// a Group. A join tree is identified by its leaf Groups and its join conditions.
+type joinGroupFinder struct {
+	sc   *stmtctx.StatementContext
+	keys map[*memo.Group]string
+}
+
+func newJoinGroupFinder(sctx sessionctx.Context) *joinGroupFinder {
+	return &joinGroupFinder{
+		sc:   sctx.GetSessionVars().StmtCtx,
+		keys: make(map[*memo.Group]string),
+	}
+}
+
+// findGroup searches the Group whose key is `key` in the join tree under `root`.
+func (f *joinGroupFinder) findGroup(root *memo.Group, key string) *memo.Group {
+	visited := make(map[*memo.Group]struct{})
+	var find func(g *memo.Group) *memo.Group
+	find = func(g *memo.Group) *memo.Group {
+		if _, ok := visited[g]; ok {
+			return nil
+		}
+		visited[g] = struct{}{}
+		if f.getGroupKey(g) == key {
+			return g
+		}
+		for elem := g.GetFirstElem(memo.OperandJoin); elem != nil; elem = elem.Next() {
+			expr := elem.Value.(*memo.GroupExpr)
+			if memo.GetOperand(expr.ExprNode) != memo.OperandJoin {
+				break
+			}
+			if getReorderableJoin(expr) == nil {
+				continue
+			}
+			for _, child := range expr.Children {
+				if found := find(child); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	return find(root)
+}
+
+// getGroupKey returns the key of the join tree represented by the Group.
+func (f *joinGroupFinder) getGroupKey(g *memo.Group) string {
+	if key, ok := f.keys[g]; ok {
+		return key
+	}
+	leaves, conds := f.collectGroup(g, nil, nil)
+	key := f.buildKey(leaves, conds)
+	f.keys[g] = key
+	return key
+}
+
+// getExprKey returns the key of the join tree represented by the Join GroupExpr.
+func (f *joinGroupFinder) getExprKey(expr *memo.GroupExpr) string {
+	leaves, conds := f.collectExpr(expr, nil, nil)
+	return f.buildKey(leaves, conds)
+}
+
+func (f *joinGroupFinder) buildKey(leaves, conds []string) string {
+	sort.Strings(leaves)
+	sort.Strings(conds)
+	return strings.Join(leaves, ",") + "|" + strings.Join(conds, ",")
+}
+
+func (f *joinGroupFinder) collectGroup(g *memo.Group, leaves, conds []string) ([]string, []string) {
+	for elem := g.GetFirstElem(memo.OperandJoin); elem != nil; elem = elem.Next() {
+		expr := elem.Value.(*memo.GroupExpr)
+		if memo.GetOperand(expr.ExprNode) != memo.OperandJoin {
+			break
+		}
+		if getReorderableJoin(expr) != nil {
+			return f.collectExpr(expr, leaves, conds)
+		}
+	}
+	return append(leaves, g.FingerPrint()), conds
+}
+
+func (f *joinGroupFinder) collectExpr(expr *memo.GroupExpr, leaves, conds []string) ([]string, []string) {
+	for _, cond := range getJoinConditions(expr.ExprNode.(*plannercore.LogicalJoin)) {
+		conds = append(conds, f.condKey(cond))
+	}
+	for _, child := range expr.Children {
+		leaves, conds = f.collectGroup(child, leaves, conds)
+	}
+	return leaves, conds
+}
+
+// condKey returns the key of the condition, the equal conditions with
+// swapped arguments have the same key.
+func (f *joinGroupFinder) condKey(cond expression.Expression) string {
+	if sf, ok := cond.(*expression.ScalarFunction); ok && sf.FuncName.L == ast.EQ && len(sf.GetArgs()) == 2 {
+		args := []string{string(sf.GetArgs()[0].HashCode(f.sc)), string(sf.GetArgs()[1].HashCode(f.sc))}
+		sort.Strings(args)
+		return ast.EQ + "(" + args[0] + "," + args[1] + ")"
+	}
+	return string(cond.HashCode(f.sc))
+}
@@ -17,12 +17,14 @@ import (
 	"context"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/expression/aggregation"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/model"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/planner/memo"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/testutil"
 )
 
@@ -198,3 +200,235 @@ func (s *testTransformationRuleSuite) TestMergeAggregationProjection(c *C) {
 	s.testData.GetTestCases(c, &input, &output)
 	testGroupToString(input, output, s, c)
 }
+
+func (s *testTransformationRuleSuite) TestJoinReorder(c *C) {
+	s.optimizer.ResetTransformationRules(map[memo.Operand][]Transformation{
+		memo.OperandSelection: {
+			NewRulePushSelDownJoin(),
+		},
+		memo.OperandDataSource: {
+			NewRuleEnumeratePaths(),
+		},
+		memo.OperandJoin: {
+			NewRuleJoinCommutative(),
+			NewRuleJoinRightAssociate(),
+			NewRuleJoinLeftAssociate(),
+			NewRuleJoinExchange(),
+		},
+	})
+	defer func() {
+		s.optimizer.ResetTransformationRules(defaultTransformationMap)
+		s.sctx.GetSessionVars().CascadesJoinReorderBudget = variable.DefOptCascadesJoinReorderBudget
+	}()
+	exploreJoinGroup := func(sql string) *memo.Group {
+		stmt, err := s.ParseOneStmt(sql, "", "")
+		c.Assert(err, IsNil)
+		p, _, err := plannercore.BuildLogicalPlan(context.Background(), s.sctx, stmt, s.is)
+		c.Assert(err, IsNil)
+		logic, ok := p.(plannercore.LogicalPlan)
+		c.Assert(ok, IsTrue)
+		logic, err = s.optimizer.onPhasePreprocessing(s.sctx, logic)
+		c.Assert(err, IsNil)
+		group := memo.Convert2Group(logic)
+		err = s.optimizer.onPhaseExploration(s.sctx, group)
+		c.Assert(err, IsNil)
+		// The root Group is the Projection of `select *`.
+		return group.Equivalents.Front().Value.(*memo.GroupExpr).Children[0]
+	}
+	sql := "select * from t t1, t t2, t t3 where t1.a = t2.a and t2.b = t3.b"
+
+	// `(t1 join t2) join t3` can be reordered to `t3 join (t1 join t2)`,
+	// `t1 join (t2 join t3)` and `(t2 join t3) join t1`. `t1 join t3` is
+	// not generated because it is a cartesian product.
+	joinGroup := exploreJoinGroup(sql)
+	c.Assert(countJoinExprs(joinGroup), Equals, 4)
+	type childPair struct {
+		left, right *memo.Group
+	}
+	pairs := make(map[childPair]struct{})
+	for elem := joinGroup.Equivalents.Front(); elem != nil; elem = elem.Next() {
+		expr := elem.Value.(*memo.GroupExpr)
+		c.Assert(expr.Children, HasLen, 2)
+		pairs[childPair{expr.Children[0], expr.Children[1]}] = struct{}{}
+	}
+	c.Assert(pairs, HasLen, 4)
+
+	// The joins are not reordered if the budget is 0.
+	s.sctx.GetSessionVars().CascadesJoinReorderBudget = 0
+	joinGroup = exploreJoinGroup(sql)
+	c.Assert(countJoinExprs(joinGroup), Equals, 1)
+}
+
+// buildExploredGroup builds the memo of the sql and explores it.
+func buildExploredGroup(sql string, s *testTransformationRuleSuite, c *C) *memo.Group {
+	stmt, err := s.ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	p, _, err := plannercore.BuildLogicalPlan(context.Background(), s.sctx, stmt, s.is)
+	c.Assert(err, IsNil)
+	logic, ok := p.(plannercore.LogicalPlan)
+	c.Assert(ok, IsTrue)
+	logic, err = s.optimizer.onPhasePreprocessing(s.sctx, logic)
+	c.Assert(err, IsNil)
+	group := memo.Convert2Group(logic)
+	err = s.optimizer.onPhaseExploration(s.sctx, group)
+	c.Assert(err, IsNil)
+	return group
+}
+
+// findGroupExpr finds the first GroupExpr of the operand in the Group and its descendants.
+func findGroupExpr(g *memo.Group, operand memo.Operand) *memo.GroupExpr {
+	for elem := g.Equivalents.Front(); elem != nil; elem = elem.Next() {
+		expr := elem.Value.(*memo.GroupExpr)
+		if memo.GetOperand(expr.ExprNode) == operand {
+			return expr
+		}
+		for _, child := range expr.Children {
+			if found := findGroupExpr(child, operand); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func (s *testTransformationRuleSuite) TestOuterJoinElimination(c *C) {
+	s.optimizer.ResetTransformationRules(map[memo.Operand][]Transformation{
+		memo.OperandProjection: {
+			NewRuleEliminateOuterJoinBelowProjection(),
+		},
+		memo.OperandAggregation: {
+			NewRuleEliminateOuterJoinBelowAggregation(),
+		},
+		memo.OperandDataSource: {
+			NewRuleEnumeratePaths(),
+		},
+	})
+	defer func() {
+		s.optimizer.ResetTransformationRules(defaultTransformationMap)
+	}()
+	tests := []struct {
+		sql        string
+		eliminated bool
+	}{
+		// The join key of the inner child is the primary key.
+		{"select t1.a, t1.b from t t1 left join t t2 on t1.a = t2.a", true},
+		// The join key of the inner child is a unique index.
+		{"select t2.a, t2.b from t t1 right join t t2 on t1.e = t2.e", true},
+		// The aggregate functions are duplicate agnostic.
+		{"select max(t1.a), min(t1.b) from t t1 left join t t2 on t1.b = t2.b", true},
+		{"select t1.a, t2.b from t t1 left join t t2 on t1.a = t2.a", false},
+		{"select sum(t1.a) from t t1 left join t t2 on t1.b = t2.b", false},
+		// The join key of the inner child is not indexed.
+		{"select t1.a, t1.b from t t1 left join t t2 on t1.b = t2.b", false},
+		// The join key of the inner child is only a prefix of the unique index c_d_e.
+		{"select t1.a, t1.b from t t1 left join t t2 on t1.c = t2.c", false},
+		{"select t1.a, t1.b from t t1 left join t t2 on t1.c = t2.c and t1.d = t2.d and t1.e = t2.e", true},
+	}
+	for _, tt := range tests {
+		group := buildExploredGroup(tt.sql, s, c)
+		c.Assert(findGroupExpr(group, memo.OperandJoin) == nil, Equals, tt.eliminated, Commentf("sql: %s", tt.sql))
+	}
+}
+
+func (s *testTransformationRuleSuite) TestPushTopNDownOuterJoin(c *C) {
+	s.optimizer.ResetTransformationRules(map[memo.Operand][]Transformation{
+		memo.OperandLimit: {
+			NewRuleTransformLimitToTopN(),
+			NewRulePushLimitDownProjection(),
+			NewRulePushLimitDownOuterJoin(),
+		},
+		memo.OperandTopN: {
+			NewRulePushTopNDownProjection(),
+			NewRulePushTopNDownOuterJoin(),
+		},
+		memo.OperandDataSource: {
+			NewRuleEnumeratePaths(),
+		},
+	})
+	defer func() {
+		s.optimizer.ResetTransformationRules(defaultTransformationMap)
+	}()
+	// The TopN is pushed down to the outer child with `Count+Offset` rows.
+	group := buildExploredGroup("select t1.a, t2.b from t t1 left join t t2 on t1.b = t2.b order by t1.a limit 3 offset 2", s, c)
+	join := findGroupExpr(group, memo.OperandJoin)
+	c.Assert(join, NotNil)
+	topN, ok := join.Children[0].Equivalents.Front().Value.(*memo.GroupExpr).ExprNode.(*plannercore.LogicalTopN)
+	c.Assert(ok, IsTrue)
+	c.Assert(topN.Count, Equals, uint64(5))
+	c.Assert(topN.Offset, Equals, uint64(0))
+	c.Assert(join.Children[1].GetFirstElem(memo.OperandTopN), IsNil)
+
+	// The Limit is pushed down to the outer child with `Count+Offset` rows.
+	group = buildExploredGroup("select t1.a, t2.b from t t1 right join t t2 on t1.b = t2.b limit 3 offset 2", s, c)
+	join = findGroupExpr(group, memo.OperandJoin)
+	c.Assert(join, NotNil)
+	limit, ok := join.Children[1].Equivalents.Front().Value.(*memo.GroupExpr).ExprNode.(*plannercore.LogicalLimit)
+	c.Assert(ok, IsTrue)
+	c.Assert(limit.Count, Equals, uint64(5))
+	c.Assert(limit.Offset, Equals, uint64(0))
+	c.Assert(join.Children[0].GetFirstElem(memo.OperandLimit), IsNil)
+
+	// The TopN can't be pushed down if it is ordered by the columns of the inner child.
+	group = buildExploredGroup("select t1.a, t2.b from t t1 left join t t2 on t1.b = t2.b order by t2.b limit 3", s, c)
+	join = findGroupExpr(group, memo.OperandJoin)
+	c.Assert(join, NotNil)
+	c.Assert(join.Children[0].GetFirstElem(memo.OperandTopN), IsNil)
+	c.Assert(join.Children[1].GetFirstElem(memo.OperandTopN), IsNil)
+}
+
+func (s *testTransformationRuleSuite) TestTransformAggToProj(c *C) {
+	s.optimizer.ResetTransformationRules(map[memo.Operand][]Transformation{
+		memo.OperandAggregation: {
+			NewRuleTransformAggToProj(),
+		},
+		memo.OperandDataSource: {
+			NewRuleEnumeratePaths(),
+		},
+	})
+	defer func() {
+		s.optimizer.ResetTransformationRules(defaultTransformationMap)
+	}()
+	// The Aggregation grouped by the primary key is eliminated.
+	group := buildExploredGroup("select a, sum(b) from t group by a", s, c)
+	c.Assert(findGroupExpr(group, memo.OperandAggregation), IsNil)
+
+	group = buildExploredGroup("select b, sum(a) from t group by b", s, c)
+	c.Assert(findGroupExpr(group, memo.OperandAggregation), NotNil)
+}
+
+func (s *testTransformationRuleSuite) TestPushAggDownJoin(c *C) {
+	s.optimizer.ResetTransformationRules(map[memo.Operand][]Transformation{
+		memo.OperandAggregation: {
+			NewRulePushAggDownJoin(),
+		},
+		memo.OperandDataSource: {
+			NewRuleEnumeratePaths(),
+		},
+	})
+	s.sctx.GetSessionVars().AllowAggPushDown = true
+	defer func() {
+		s.optimizer.ResetTransformationRules(defaultTransformationMap)
+		s.sctx.GetSessionVars().AllowAggPushDown = false
+	}()
+	// The sum is pushed down to t1 and grouped by the join key t1.b.
+	group := buildExploredGroup("select sum(t1.a) from t t1 join t t2 on t1.b = t2.b", s, c)
+	aggGroup := findGroupExpr(group, memo.OperandAggregation).Group
+	c.Assert(aggGroup.Equivalents.Len(), Equals, 2)
+	finalAggExpr := aggGroup.Equivalents.Back().Value.(*memo.GroupExpr)
+	finalAgg := finalAggExpr.ExprNode.(*plannercore.LogicalAggregation)
+	c.Assert(finalAgg.AggFuncs[0].Mode, Equals, aggregation.FinalMode)
+	join := finalAggExpr.Children[0].Equivalents.Front().Value.(*memo.GroupExpr)
+	c.Assert(join.Children[0].GetFirstElem(memo.OperandAggregation), NotNil)
+	c.Assert(join.Children[1].GetFirstElem(memo.OperandAggregation), IsNil)
+
+	// The sum is not pushed down since the group by column t1.a is a unique key of t1.
+	group = buildExploredGroup("select sum(t1.b) from t t1 join t t2 on t1.a = t2.b", s, c)
+	aggGroup = findGroupExpr(group, memo.OperandAggregation).Group
+	c.Assert(aggGroup.Equivalents.Len(), Equals, 1)
+
+	// The Aggregation is not pushed down if it's not allowed.
+	s.sctx.GetSessionVars().AllowAggPushDown = false
+	group = buildExploredGroup("select sum(t1.a) from t t1 join t t2 on t1.b = t2.b", s, c)
+	aggGroup = findGroupExpr(group, memo.OperandAggregation).Group
+	c.Assert(aggGroup.Equivalents.Len(), Equals, 1)
+}
@@ -15,6 +15,7 @@ package core
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/memory"
 )
 
 // ShowDDL is for showing DDL information.
@@ -114,13 +116,60 @@ type DDL struct {
 	Statement ast.DDLNode
 }
 
+// Trace represents a trace plan.
+type Trace struct {
+	baseSchemaProducer
+
+	StmtNode ast.StmtNode
+	Format   string
+}
+
+// PlanReplayer represents a plan replayer plan.
+type PlanReplayer struct {
+	baseSchemaProducer
+
+	ExecStmt ast.StmtNode
+	Analyze  bool
+	Load     bool
+	File     string
+}
+
+// SQLBindOpType repreents the SQL bind type
+type SQLBindOpType int
+
+const (
+	// OpSQLBindCreate represents the operation to create a SQL bind.
+	OpSQLBindCreate SQLBindOpType = iota
+	// OpSQLBindDrop represents the operation to drop a SQL bind.
+	OpSQLBindDrop
+)
+
+// SQLBindPlan represents a plan for SQL bind.
+type SQLBindPlan struct {
+	baseSchemaProducer
+
+	SQLBindOp    SQLBindOpType
+	NormdOrigSQL string
+	BindSQL      string
+	IsGlobal     bool
+	Db           string
+	Charset      string
+	Collation    string
+}
+
 // Explain represents a explain plan.
 type Explain struct {
 	baseSchemaProducer
 
 	TargetPlan Plan
 	Format     string
-	ExecStmt   ast.StmtNode
+	// Analyze indicates the target plan is executed, and the runtime
+	// statistics of the operators are appended to the explain result.
+	Analyze  bool
+	ExecStmt ast.StmtNode
+	// Memo is the stringified memo of the cascades planner, it is only
+	// filled for EXPLAIN FORMAT='memo'.
+	Memo []string
 
 	Rows           [][]string
 	explainedPlans map[int]bool
@@ -132,10 +181,18 @@ func (e *Explain) prepareSchema() error {
 	format := strings.ToLower(e.Format)
 
 	switch {
-	case format == ast.ExplainFormatROW:
+	case format == ast.ExplainFormatROW && !e.Analyze:
 		fieldNames = []string{"id", "count", "task", "operator info"}
+	case format == ast.ExplainFormatROW && e.Analyze:
+		fieldNames = []string{"id", "count", "task", "operator info", "execution info", "memory", "disk"}
 	case format == ast.ExplainFormatDOT:
 		fieldNames = []string{"dot contents"}
+	case format == ast.ExplainFormatJSON:
+		fieldNames = []string{"json contents"}
+	case format == ast.ExplainFormatMemo && !e.Analyze:
+		fieldNames = []string{"memo contents"}
+	case format == ast.ExplainFormatHint && !e.Analyze:
+		fieldNames = []string{"hint"}
 	default:
 		return errors.Errorf("explain format '%s' is not supported now", e.Format)
 	}
@@ -167,6 +224,18 @@ func (e *Explain) RenderResult() error {
 		}
 	case ast.ExplainFormatDOT:
 		e.prepareDotInfo(e.TargetPlan.(PhysicalPlan))
+	case ast.ExplainFormatJSON:
+		contents, err := json.MarshalIndent(e.explainPlanInJSONFormat(e.TargetPlan, "root"), "", "  ")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.Rows = append(e.Rows, []string{string(contents)})
+	case ast.ExplainFormatMemo:
+		for _, line := range e.Memo {
+			e.Rows = append(e.Rows, []string{line})
+		}
+	case ast.ExplainFormatHint:
+		e.Rows = append(e.Rows, []string{GenHintsFromPhysicalPlan(e.TargetPlan)})
 	default:
 		return errors.Errorf("explain format '%s' is not supported now", e.Format)
 	}
@@ -201,6 +270,14 @@ func (e *Explain) explainPlanInRowFormat(p Plan, taskType, indent string, isLast
 	case *PhysicalIndexLookUpReader:
 		err = e.explainPlanInRowFormat(x.indexPlan, "cop", childIndent, false)
 		err = e.explainPlanInRowFormat(x.tablePlan, "cop", childIndent, true)
+	case *PhysicalIndexMergeReader:
+		for _, partialPlan := range x.partialPlans {
+			err = e.explainPlanInRowFormat(partialPlan, "cop", childIndent, false)
+			if err != nil {
+				return
+			}
+		}
+		err = e.explainPlanInRowFormat(x.tablePlan, "cop", childIndent, true)
 	case *Insert:
 		if x.SelectPlan != nil {
 			err = e.explainPlanInRowFormat(x.SelectPlan, "root", childIndent, true)
@@ -213,6 +290,105 @@ func (e *Explain) explainPlanInRowFormat(p Plan, taskType, indent string, isLast
 	return
 }
 
+// jsonPlanNode is an operator in the result of EXPLAIN FORMAT='json'. The
+// estimated row count and the cost are formatted with two decimal places, so
+// the plans of different releases can be compared textually.
+type jsonPlanNode struct {
+	ID           string          `json:"id"`
+	EstRows      string          `json:"estRows"`
+	Cost         string          `json:"cost"`
+	TaskType     string          `json:"taskType"`
+	AccessObject string          `json:"accessObject"`
+	OperatorInfo string          `json:"operatorInfo"`
+	Children     []*jsonPlanNode `json:"children"`
+}
+
+// explainPlanInJSONFormat generates the JSON tree of the plan, the children
+// are in the same order as the rows of explainPlanInRowFormat.
+func (e *Explain) explainPlanInJSONFormat(p Plan, taskType string) *jsonPlanNode {
+	node := &jsonPlanNode{
+		ID:           p.ExplainID().String(),
+		EstRows:      "N/A",
+		Cost:         "N/A",
+		TaskType:     taskType,
+		AccessObject: accessObject(p),
+		OperatorInfo: p.ExplainInfo(),
+		Children:     []*jsonPlanNode{},
+	}
+	if si := p.statsInfo(); si != nil {
+		node.EstRows = strconv.FormatFloat(si.RowCount, 'f', 2, 64)
+	}
+
+	if physPlan, ok := p.(PhysicalPlan); ok {
+		node.Cost = strconv.FormatFloat(physPlan.Cost(), 'f', 2, 64)
+		for _, child := range physPlan.Children() {
+			node.Children = append(node.Children, e.explainPlanInJSONFormat(child, taskType))
+		}
+	}
+
+	switch x := p.(type) {
+	case *PhysicalTableReader:
+		node.Children = append(node.Children, e.explainPlanInJSONFormat(x.tablePlan, "cop"))
+	case *PhysicalIndexReader:
+		node.Children = append(node.Children, e.explainPlanInJSONFormat(x.indexPlan, "cop"))
+	case *PhysicalIndexLookUpReader:
+		node.Children = append(node.Children, e.explainPlanInJSONFormat(x.indexPlan, "cop"))
+		node.Children = append(node.Children, e.explainPlanInJSONFormat(x.tablePlan, "cop"))
+	case *PhysicalIndexMergeReader:
+		for _, partialPlan := range x.partialPlans {
+			node.Children = append(node.Children, e.explainPlanInJSONFormat(partialPlan, "cop"))
+		}
+		node.Children = append(node.Children, e.explainPlanInJSONFormat(x.tablePlan, "cop"))
+	case *Insert:
+		if x.SelectPlan != nil {
+			node.Children = append(node.Children, e.explainPlanInJSONFormat(x.SelectPlan, "root"))
+		}
+	case *Delete:
+		if x.SelectPlan != nil {
+			node.Children = append(node.Children, e.explainPlanInJSONFormat(x.SelectPlan, "root"))
+		}
+	}
+	return node
+}
+
+// accessObject returns the table and the index an operator reads, it's empty
+// if the operator doesn't read the storage directly.
+func accessObject(p Plan) string {
+	switch x := p.(type) {
+	case *PhysicalTableScan:
+		return "table:" + scanTableName(x.Table, x.TableAsName)
+	case *PhysicalIndexScan:
+		cols := make([]string, 0, len(x.Index.Columns))
+		for _, idxCol := range x.Index.Columns {
+			cols = append(cols, idxCol.Name.O)
+		}
+		return fmt.Sprintf("table:%s, index:%s(%s)", scanTableName(x.Table, x.TableAsName), x.Index.Name.O, strings.Join(cols, ", "))
+	case *PointGetPlan:
+		return pointGetAccessObject(x.TblInfo, x.TableAsName, x.IndexInfo)
+	case *BatchPointGetPlan:
+		return pointGetAccessObject(x.TblInfo, x.TableAsName, x.IndexInfo)
+	}
+	return ""
+}
+
+func pointGetAccessObject(tblInfo *model.TableInfo, asName *model.CIStr, idxInfo *model.IndexInfo) string {
+	if idxInfo == nil {
+		return "table:" + scanTableName(tblInfo, asName)
+	}
+	cols := make([]string, 0, len(idxInfo.Columns))
+	for _, idxCol := range idxInfo.Columns {
+		cols = append(cols, idxCol.Name.O)
+	}
+	return fmt.Sprintf("table:%s, index:%s(%s)", scanTableName(tblInfo, asName), idxInfo.Name.O, strings.Join(cols, ", "))
+}
+
+func scanTableName(tbl *model.TableInfo, asName *model.CIStr) string {
+	if asName != nil && asName.O != "" {
+		return asName.O
+	}
+	return tbl.Name.O
+}
+
 const (
 	// TreeBody indicates the current operator sub-tree is not finished, still
 	// has child operators to be attached on.
@@ -289,9 +465,44 @@ func (e *Explain) prepareOperatorInfo(p Plan, taskType string, indent string, is
 	}
 	explainID := p.ExplainID().String()
 	row := []string{PrettyIdentifier(explainID, indent, isLastChild), count, taskType, operatorInfo}
+	if e.Analyze {
+		row = append(row, e.prepareAnalyzeInfo(explainID)...)
+	}
 	e.Rows = append(e.Rows, row)
 }
 
+// prepareAnalyzeInfo generates the execution info, the memory usage and the
+// disk usage of an operator for EXPLAIN ANALYZE. The execution info of a
+// reader also contains the statistics of the coprocessor tasks it sends.
+func (e *Explain) prepareAnalyzeInfo(explainID string) []string {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	runtimeStatsColl := sc.RuntimeStatsColl
+	analyzeInfo := "time:0s, loops:0, rows:0"
+	if runtimeStatsColl != nil {
+		if runtimeStatsColl.ExistsRootStats(explainID) {
+			analyzeInfo = runtimeStatsColl.GetRootStats(explainID).String()
+		} else if runtimeStatsColl.ExistsCopStats(explainID) {
+			analyzeInfo = runtimeStatsColl.GetCopStats(explainID).String()
+		}
+		if runtimeStatsColl.ExistsReaderStats(explainID) {
+			analyzeInfo += ", " + runtimeStatsColl.GetReaderStats(explainID).String()
+		}
+	}
+
+	memoryInfo, diskInfo := "N/A", "N/A"
+	if sc.MemTracker != nil {
+		if tracker := sc.MemTracker.SearchTracker(explainID); tracker != nil {
+			memoryInfo = memory.FormatBytes(tracker.MaxConsumed())
+		}
+	}
+	if sc.DiskTracker != nil {
+		if tracker := sc.DiskTracker.SearchTracker(explainID); tracker != nil {
+			diskInfo = memory.FormatBytes(tracker.MaxConsumed())
+		}
+	}
+	return []string{analyzeInfo, memoryInfo, diskInfo}
+}
+
 func (e *Explain) prepareDotInfo(p PhysicalPlan) {
 	buffer := bytes.NewBufferString("")
 	fmt.Fprintf(buffer, "\ndigraph %s {\n", p.ExplainID())
@@ -332,6 +543,13 @@ func (e *Explain) prepareTaskDot(p PhysicalPlan, taskTp string, buffer *bytes.Bu
 			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.indexPlan.ExplainID()))
 			copTasks = append(copTasks, copPlan.tablePlan)
 			copTasks = append(copTasks, copPlan.indexPlan)
+		case *PhysicalIndexMergeReader:
+			for _, partialPlan := range copPlan.partialPlans {
+				pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), partialPlan.ExplainID()))
+				copTasks = append(copTasks, partialPlan)
+			}
+			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.tablePlan.ExplainID()))
+			copTasks = append(copTasks, copPlan.tablePlan)
 		}
 	}
 	buffer.WriteString("}\n")
@@ -0,0 +1,103 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/pingcap/tidb/util/plancodec"
+)
+
+// EncodePlan encodes a plan into a compact string, which can be decoded by
+// the builtin function tidb_decode_plan. An empty string is returned if the
+// plan can't be encoded.
+func EncodePlan(p Plan) string {
+	if p == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	walkPlanTree(p, "root", 0, func(p Plan, taskType string, depth int) {
+		estRows := float64(0)
+		if si := p.statsInfo(); si != nil {
+			estRows = si.RowCount
+		}
+		plancodec.EncodePlanNode(depth, p.ExplainID().String(), taskType, estRows, p.ExplainInfo(), &buf)
+	})
+	encoded, err := plancodec.Compress(buf.Bytes())
+	if err != nil {
+		return ""
+	}
+	return encoded
+}
+
+// NormalizePlan generates the normalized plan and its digest. The normalized
+// plan keeps the shape of the plan tree, the operator types, the task types
+// and the accessed tables and indexes, but drops the plan ids, the estimated
+// row counts and the operator info, which may differ among the executions of
+// the same statement with different literals.
+func NormalizePlan(p Plan) (normalized, digest string) {
+	if p == nil {
+		return "", ""
+	}
+	var buf bytes.Buffer
+	walkPlanTree(p, "root", 0, func(p Plan, taskType string, depth int) {
+		buf.WriteString(strconv.Itoa(depth))
+		buf.WriteByte('\t')
+		buf.WriteString(p.TP())
+		buf.WriteByte('\t')
+		buf.WriteString(taskType)
+		buf.WriteByte('\t')
+		buf.WriteString(accessObject(p))
+		buf.WriteByte('\n')
+	})
+	hash := sha256.Sum256(buf.Bytes())
+	return buf.String(), hex.EncodeToString(hash[:])
+}
+
+// walkPlanTree visits the plan tree in the same order as explainPlanInRowFormat.
+func walkPlanTree(p Plan, taskType string, depth int, visit func(p Plan, taskType string, depth int)) {
+	visit(p, taskType, depth)
+
+	if physPlan, ok := p.(PhysicalPlan); ok {
+		for _, child := range physPlan.Children() {
+			walkPlanTree(child, taskType, depth+1, visit)
+		}
+	}
+
+	switch x := p.(type) {
+	case *PhysicalTableReader:
+		walkPlanTree(x.tablePlan, "cop", depth+1, visit)
+	case *PhysicalIndexReader:
+		walkPlanTree(x.indexPlan, "cop", depth+1, visit)
+	case *PhysicalIndexLookUpReader:
+		walkPlanTree(x.indexPlan, "cop", depth+1, visit)
+		walkPlanTree(x.tablePlan, "cop", depth+1, visit)
+	case *PhysicalIndexMergeReader:
+		for _, partialPlan := range x.partialPlans {
+			walkPlanTree(partialPlan, "cop", depth+1, visit)
+		}
+		walkPlanTree(x.tablePlan, "cop", depth+1, visit)
+	case *Insert:
+		if x.SelectPlan != nil {
+			walkPlanTree(x.SelectPlan, "root", depth+1, visit)
+		}
+	case *Delete:
+		if x.SelectPlan != nil {
+			walkPlanTree(x.SelectPlan, "root", depth+1, visit)
+		}
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"math"
 
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/planner/property"
+	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/set"
 )
 
@@ -106,7 +108,10 @@ func (p *PhysicalMergeJoin) tryToGetChildReqProp(prop *property.PhysicalProperty
 	return []*property.PhysicalProperty{lProp, rProp}, true
 }
 
-func (p *LogicalJoin) getMergeJoin(prop *property.PhysicalProperty) []PhysicalPlan {
+// GetMergeJoin converts the LogicalJoin to PhysicalMergeJoins which satisfy the
+// required property. The schema and the stats info of the join and its children
+// are passed in, so that it can be used by the cascades planner as well.
+func (p *LogicalJoin) GetMergeJoin(prop *property.PhysicalProperty, schema *expression.Schema, statsInfo *property.StatsInfo, leftStatsInfo *property.StatsInfo, rightStatsInfo *property.StatsInfo) []PhysicalPlan {
 	joins := make([]PhysicalPlan, 0, len(p.leftProperties)+1)
 	// The leftProperties caches all the possible properties that are provided by its children.
 	for _, lhsChildProperty := range p.leftProperties {
@@ -134,16 +139,16 @@ func (p *LogicalJoin) getMergeJoin(prop *property.PhysicalProperty) []PhysicalPl
 			LeftJoinKeys:    leftKeys,
 			RightJoinKeys:   rightKeys,
 		}
-		mergeJoin := PhysicalMergeJoin{basePhysicalJoin: baseJoin}.Init(p.ctx, p.stats.ScaleByExpectCnt(prop.ExpectedCnt))
-		mergeJoin.SetSchema(p.schema)
+		mergeJoin := PhysicalMergeJoin{basePhysicalJoin: baseJoin}.Init(p.ctx, statsInfo.ScaleByExpectCnt(prop.ExpectedCnt))
+		mergeJoin.SetSchema(schema)
 		mergeJoin.OtherConditions = p.moveEqualToOtherConditions(offsets)
 		mergeJoin.initCompareFuncs()
 		if reqProps, ok := mergeJoin.tryToGetChildReqProp(prop); ok {
 			// Adjust expected count for children nodes.
-			if prop.ExpectedCnt < p.stats.RowCount {
-				expCntScale := prop.ExpectedCnt / p.stats.RowCount
-				reqProps[0].ExpectedCnt = p.children[0].statsInfo().RowCount * expCntScale
-				reqProps[1].ExpectedCnt = p.children[1].statsInfo().RowCount * expCntScale
+			if prop.ExpectedCnt < statsInfo.RowCount {
+				expCntScale := prop.ExpectedCnt / statsInfo.RowCount
+				reqProps[0].ExpectedCnt = leftStatsInfo.RowCount * expCntScale
+				reqProps[1].ExpectedCnt = rightStatsInfo.RowCount * expCntScale
 			}
 			mergeJoin.childrenReqProps = reqProps
 			joins = append(joins, mergeJoin)
@@ -152,7 +157,7 @@ func (p *LogicalJoin) getMergeJoin(prop *property.PhysicalProperty) []PhysicalPl
 	// If TiDB_SMJ hint is existed, it should consider enforce merge join,
 	// because we can't trust lhsChildProperty completely.
 	if (p.preferJoinType & preferMergeJoin) > 0 {
-		joins = append(joins, p.getEnforcedMergeJoin(prop)...)
+		joins = append(joins, p.getEnforcedMergeJoin(prop, schema, statsInfo)...)
 	}
 
 	return joins
@@ -180,7 +185,7 @@ func getNewJoinKeysByOffsets(oldJoinKeys []*expression.Column, offsets []int) []
 	return newKeys
 }
 
-func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty) []PhysicalPlan {
+func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty, schema *expression.Schema, statsInfo *property.StatsInfo) []PhysicalPlan {
 	// Check whether SMJ can satisfy the required property
 	offsets := make([]int, 0, len(p.LeftJoinKeys))
 	all, desc := prop.AllSameOrder()
@@ -230,13 +235,74 @@ func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty) []Ph
 		RightJoinKeys:   rightKeys,
 		OtherConditions: p.OtherConditions,
 	}
-	enforcedPhysicalMergeJoin := PhysicalMergeJoin{basePhysicalJoin: baseJoin}.Init(p.ctx, p.stats.ScaleByExpectCnt(prop.ExpectedCnt))
-	enforcedPhysicalMergeJoin.SetSchema(p.schema)
+	enforcedPhysicalMergeJoin := PhysicalMergeJoin{basePhysicalJoin: baseJoin}.Init(p.ctx, statsInfo.ScaleByExpectCnt(prop.ExpectedCnt))
+	enforcedPhysicalMergeJoin.SetSchema(schema)
 	enforcedPhysicalMergeJoin.childrenReqProps = []*property.PhysicalProperty{lProp, rProp}
 	enforcedPhysicalMergeJoin.initCompareFuncs()
 	return []PhysicalPlan{enforcedPhysicalMergeJoin}
 }
 
+// getShuffledMergeJoins wraps the merge joins into shuffles, the rows of both
+// sides are hash partitioned by the join keys, and the partitions are joined by
+// the merge joins in parallel. Every partition keeps the order of its data
+// source, but the output of a shuffle doesn't, so it's only used when no order
+// is required.
+func (p *LogicalJoin) getShuffledMergeJoins(prop *property.PhysicalProperty, mergeJoins []PhysicalPlan) []PhysicalPlan {
+	concurrency := p.ctx.GetSessionVars().MergeJoinConcurrency
+	if concurrency <= 1 || !prop.IsEmpty() {
+		return nil
+	}
+	shuffles := make([]PhysicalPlan, 0, len(mergeJoins))
+	for _, plan := range mergeJoins {
+		mergeJoin := plan.(*PhysicalMergeJoin)
+		if !canBeShuffledByJoinKeys(mergeJoin.LeftJoinKeys, mergeJoin.RightJoinKeys) {
+			continue
+		}
+		// A plan can't be shared by two candidates, so a new merge join is built
+		// for the shuffle.
+		tail := PhysicalMergeJoin{
+			basePhysicalJoin: mergeJoin.basePhysicalJoin,
+			CompareFuncs:     mergeJoin.CompareFuncs,
+		}.Init(p.ctx, mergeJoin.stats)
+		tail.SetSchema(mergeJoin.Schema())
+		shuffle := PhysicalShuffle{
+			Concurrency: concurrency,
+			Tail:        tail,
+			HashByItemArrays: [][]expression.Expression{
+				expression.Column2Exprs(tail.LeftJoinKeys),
+				expression.Column2Exprs(tail.RightJoinKeys),
+			},
+		}.Init(p.ctx, mergeJoin.stats, mergeJoin.childrenReqProps...)
+		shuffles = append(shuffles, shuffle)
+	}
+	return shuffles
+}
+
+// canBeShuffledByJoinKeys checks whether the equal values of the left and right
+// join keys are always hashed to the same partition.
+func canBeShuffledByJoinKeys(leftKeys, rightKeys []*expression.Column) bool {
+	for i := range leftKeys {
+		lTp, rTp := leftKeys[i].RetType, rightKeys[i].RetType
+		if lTp.EvalType() != rTp.EvalType() {
+			return false
+		}
+		switch lTp.EvalType() {
+		case types.ETInt:
+			if mysql.HasUnsignedFlag(lTp.Flag) != mysql.HasUnsignedFlag(rTp.Flag) {
+				return false
+			}
+		case types.ETReal:
+		case types.ETString:
+			if lTp.Collate != rTp.Collate {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func (p *PhysicalMergeJoin) initCompareFuncs() {
 	p.CompareFuncs = make([]expression.CompareFunc, 0, len(p.LeftJoinKeys))
 	for i := range p.LeftJoinKeys {
@@ -279,7 +345,8 @@ func (p *LogicalJoin) getHashJoin(prop *property.PhysicalProperty, innerIdx int)
 // If the hint is not matched, it will get other candidates.
 // If the hint is not figured, we will pick all candidates.
 func (p *LogicalJoin) exhaustPhysicalPlans(prop *property.PhysicalProperty) []PhysicalPlan {
-	mergeJoins := p.getMergeJoin(prop)
+	mergeJoins := p.GetMergeJoin(prop, p.schema, p.stats, p.children[0].statsInfo(), p.children[1].statsInfo())
+	mergeJoins = append(mergeJoins, p.getShuffledMergeJoins(prop, mergeJoins)...)
 	if (p.preferJoinType & preferMergeJoin) > 0 {
 		return mergeJoins
 	}
@@ -387,7 +454,10 @@ func (la *LogicalAggregation) getHashAggs(prop *property.PhysicalProperty) []Phy
 		return nil
 	}
 	hashAggs := make([]PhysicalPlan, 0, len(wholeTaskTypes))
-	taskTypes := []property.TaskType{property.CopSingleReadTaskType, property.CopDoubleReadTaskType, property.RootTaskType}
+	taskTypes := []property.TaskType{property.CopSingleReadTaskType, property.CopDoubleReadTaskType}
+	if !la.aggHints.preferAggToCop {
+		taskTypes = append(taskTypes, property.RootTaskType)
+	}
 	for _, taskTp := range taskTypes {
 		agg := NewPhysicalHashAgg(la, la.stats.ScaleByExpectCnt(prop.ExpectedCnt), &property.PhysicalProperty{ExpectedCnt: math.MaxFloat64, TaskTp: taskTp})
 		agg.SetSchema(la.schema.Clone())
@@ -396,7 +466,34 @@ func (la *LogicalAggregation) getHashAggs(prop *property.PhysicalProperty) []Phy
 	return hashAggs
 }
 
+// canPushToCop checks if the aggregation can be pushed down to coprocessor,
+// which requires its child to be a DataSource with pushed down selections.
+func (la *LogicalAggregation) canPushToCop() bool {
+	sc := la.ctx.GetSessionVars().StmtCtx
+	client := la.ctx.GetClient()
+	p := la.children[0]
+	for {
+		switch x := p.(type) {
+		case *DataSource:
+			return CheckAggCanPushCop(la.ctx, la.AggFuncs, la.GroupByItems)
+		case *LogicalSelection:
+			if _, _, remained := expression.ExpressionsToPB(sc, x.Conditions, client); len(remained) > 0 {
+				return false
+			}
+			p = x.children[0]
+		default:
+			return false
+		}
+	}
+}
+
 func (la *LogicalAggregation) exhaustPhysicalPlans(prop *property.PhysicalProperty) []PhysicalPlan {
+	if la.aggHints.preferAggToCop && !la.canPushToCop() {
+		errMsg := "Optimizer Hint AGG_TO_COP is inapplicable"
+		warning := ErrInternal.GenWithStack(errMsg)
+		la.ctx.GetSessionVars().StmtCtx.AppendWarning(warning)
+		la.aggHints.preferAggToCop = false
+	}
 	return la.getHashAggs(prop)
 }
 
@@ -140,6 +140,14 @@ func (p *PhysicalIndexLookUpReader) ExplainInfo() string {
 	return ""
 }
 
+// ExplainInfo implements Plan interface.
+func (p *PhysicalIndexMergeReader) ExplainInfo() string {
+	if p.IsIntersection {
+		return "type:intersection"
+	}
+	return "type:union"
+}
+
 // ExplainInfo implements Plan interface.
 func (p *PhysicalUnionScan) ExplainInfo() string {
 	return string(expression.SortedExplainExpressionList(p.Conditions))
@@ -257,6 +265,17 @@ func (p *PhysicalHashJoin) explainInfo(normalized bool) string {
 		fmt.Fprintf(buffer, ", other cond:%s",
 			sortedExplainExpressionList(p.OtherConditions))
 	}
+	if len(p.RuntimeFilterKeyIdx) > 0 {
+		probeKeys := p.LeftJoinKeys
+		if p.InnerChildIdx == 0 {
+			probeKeys = p.RightJoinKeys
+		}
+		filterKeys := make([]*expression.Column, 0, len(p.RuntimeFilterKeyIdx))
+		for _, idx := range p.RuntimeFilterKeyIdx {
+			filterKeys = append(filterKeys, probeKeys[idx])
+		}
+		fmt.Fprintf(buffer, ", runtime filter:%v", filterKeys)
+	}
 	return buffer.String()
 }
 
@@ -303,6 +322,17 @@ func (p *PhysicalMergeJoin) ExplainNormalizedInfo() string {
 	return p.explainInfo(true)
 }
 
+// ExplainInfo implements Plan interface.
+func (p *PhysicalShuffle) ExplainInfo() string {
+	buffer := bytes.NewBufferString("")
+	fmt.Fprintf(buffer, "concurrency:%v", p.Concurrency)
+	for i, dataSource := range p.Tail.Children() {
+		fmt.Fprintf(buffer, ", %s hash by:%s", dataSource.ExplainID(),
+			expression.SortedExplainExpressionList(p.HashByItemArrays[i]))
+	}
+	return buffer.String()
+}
+
 // ExplainInfo implements Plan interface.
 func (p *PhysicalTopN) ExplainInfo() string {
 	buffer := bytes.NewBufferString("")
@@ -24,6 +24,8 @@ import (
 	"github.com/pingcap/tidb/planner/util"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
 	"golang.org/x/tools/container/intsets"
 )
 
@@ -152,6 +154,9 @@ func (p *baseLogicalPlan) findBestTask(prop *property.PhysicalProperty) (bestTas
 		if prop.Enforced {
 			curTask = enforceProperty(prop, curTask, p.basePlan.ctx)
 		}
+		if !curTask.invalid() {
+			curTask.plan().SetCost(curTask.cost())
+		}
 
 		// get the most efficient one.
 		if curTask.cost() < bestTask.cost() {
@@ -364,6 +369,9 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty) (t task, err
 			prop.Items = oldPropCols
 			t = enforceProperty(prop, t, ds.basePlan.ctx)
 		}
+		if !t.invalid() {
+			t.plan().SetCost(t.cost())
+		}
 		ds.storeTask(prop, t)
 	}()
 
@@ -373,6 +381,19 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty) (t task, err
 	}
 
 	t = invalidTask
+	for _, path := range ds.indexMergePaths {
+		imTask, err := ds.convertToIndexMergeScan(prop, path)
+		if err != nil {
+			return nil, err
+		}
+		if imTask.cost() < t.cost() {
+			t = imTask
+		}
+	}
+	// The index merge paths are preferred if the USE_INDEX_MERGE hint is specified.
+	if len(ds.indexMergeHints) > 0 && !t.invalid() {
+		return t, nil
+	}
 	candidates := ds.skylinePruning(prop)
 
 	for _, candidate := range candidates {
@@ -460,6 +481,7 @@ func (ds *DataSource) convertToIndexScan(prop *property.PhysicalProperty, candid
 	}
 	path := candidate.path
 	is, cost, _ := ds.getOriginalPhysicalIndexScan(prop, path, candidate.isMatchProp, candidate.isSingleScan)
+	is.SetCost(cost)
 	cop := &copTask{
 		indexPlan:   is,
 		tblColHists: ds.TblColHists,
@@ -497,6 +519,124 @@ func (ds *DataSource) convertToIndexScan(prop *property.PhysicalProperty, candid
 	return task, nil
 }
 
+// convertToIndexMergeScan converts the DataSource to the index merge reader of
+// the index merge path. The handles read by the partial plans are merged in
+// tidb, so the task can't be pushed down and can't keep order.
+func (ds *DataSource) convertToIndexMergeScan(prop *property.PhysicalProperty, path *util.AccessPath) (task, error) {
+	if prop.TaskTp != property.RootTaskType || !prop.IsEmpty() {
+		return invalidTask, nil
+	}
+	sessVars := ds.ctx.GetSessionVars()
+	// The partial plans are not limited by the expected count, since all of
+	// them must be read to merge the handles.
+	partialProp := &property.PhysicalProperty{ExpectedCnt: math.MaxFloat64}
+	partialPlans := make([]PhysicalPlan, 0, len(path.PartialIndexPaths))
+	var partialCost, handleCount float64
+	for _, partialPath := range path.PartialIndexPaths {
+		var (
+			partialPlan    PhysicalPlan
+			cost, rowCount float64
+		)
+		if partialPath.IsTablePath {
+			partialPlan, cost, rowCount = ds.convertToPartialTableScan(partialProp, partialPath)
+		} else {
+			partialPlan, cost, rowCount = ds.convertToPartialIndexScan(partialProp, partialPath)
+		}
+		partialPlans = append(partialPlans, partialPlan)
+		partialCost += cost
+		handleCount += rowCount
+	}
+	tableRowCount := math.Min(handleCount, float64(ds.statisticTable.Count))
+	if path.IndexMergeIsIntersection {
+		tableRowCount = path.CountAfterAccess
+	}
+
+	ts := PhysicalTableScan{
+		Table:       ds.tableInfo,
+		Columns:     ds.Columns,
+		TableAsName: ds.TableAsName,
+		DBName:      ds.DBName,
+	}.Init(ds.ctx)
+	ts.SetSchema(ds.schema.Clone())
+	ts.stats = ds.tableStats.ScaleByExpectCnt(tableRowCount)
+	var tablePlan PhysicalPlan = ts
+	tableCost := tableRowCount * ds.TblColHists.GetTableAvgRowSize(ds.TblCols) * sessVars.ScanFactor
+	finalStats := ds.stats.ScaleByExpectCnt(prop.ExpectedCnt)
+	if len(path.TableFilters) > 0 {
+		tableCost += tableRowCount * sessVars.CopCPUFactor
+		sel := PhysicalSelection{Conditions: path.TableFilters}.Init(ds.ctx, finalStats)
+		sel.SetChildren(ts)
+		tablePlan = sel
+	}
+	tableCost += finalStats.RowCount * sessVars.NetworkFactor * ds.TblColHists.GetAvgRowSize(ds.schema.Columns, false)
+
+	reader := PhysicalIndexMergeReader{
+		IsIntersection: path.IndexMergeIsIntersection,
+		partialPlans:   partialPlans,
+		tablePlan:      tablePlan,
+	}.Init(ds.ctx)
+	reader.stats = finalStats
+	// The partial plans and the table plan are run in parallel by the cop iterator
+	// workers like the other readers.
+	cost := (partialCost + tableCost) / float64(sessVars.DistSQLScanConcurrency)
+	// Add the cost of merging the handles and building the table tasks.
+	cost += handleCount * sessVars.CPUFactor
+	// Add the cost of the partial workers and the table workers.
+	cost += float64(len(partialPlans)+sessVars.IndexLookupConcurrency+1) * sessVars.ConcurrencyFactor
+	return &rootTask{p: reader, cst: cost}, nil
+}
+
+// convertToPartialIndexScan builds the partial plan of the index merge reader
+// which reads the handles by an index, the index filters are pushed down with it.
+func (ds *DataSource) convertToPartialIndexScan(prop *property.PhysicalProperty, path *util.AccessPath) (PhysicalPlan, float64, float64) {
+	is, cost, rowCount := ds.getOriginalPhysicalIndexScan(prop, path, false, false)
+	is.SetCost(cost)
+	var partialPlan PhysicalPlan = is
+	sessVars := ds.ctx.GetSessionVars()
+	if len(path.IndexFilters) > 0 {
+		cost += rowCount * sessVars.CopCPUFactor
+		if path.CountAfterAccess > 0 {
+			rowCount *= path.CountAfterIndex / path.CountAfterAccess
+		}
+		sel := PhysicalSelection{Conditions: path.IndexFilters}.Init(ds.ctx, ds.tableStats.ScaleByExpectCnt(rowCount))
+		sel.SetChildren(is)
+		sel.SetCost(cost)
+		partialPlan = sel
+	}
+	// Only the handles are transferred to tidb.
+	handleCol := is.schema.Columns[is.schema.Len()-1]
+	cost += rowCount * sessVars.NetworkFactor * ds.TblColHists.GetAvgRowSize([]*expression.Column{handleCol}, false)
+	return partialPlan, cost, rowCount
+}
+
+// convertToPartialTableScan builds the partial plan of the index merge reader
+// which reads the handles by the table ranges, the table filters are pushed
+// down with it.
+func (ds *DataSource) convertToPartialTableScan(prop *property.PhysicalProperty, path *util.AccessPath) (PhysicalPlan, float64, float64) {
+	ts, cost, rowCount := ds.getOriginalPhysicalTableScan(prop, path, false)
+	ts.SetCost(cost)
+	var partialPlan PhysicalPlan = ts
+	sessVars := ds.ctx.GetSessionVars()
+	if len(ts.filterCondition) > 0 {
+		cost += rowCount * sessVars.CopCPUFactor
+		selectivity, err := ds.tableStats.HistColl.Selectivity(ds.ctx, ts.filterCondition, nil)
+		if err != nil {
+			logutil.BgLogger().Debug("calculate selectivity failed, use selection factor", zap.Error(err))
+			selectivity = selectionFactor
+		}
+		rowCount *= selectivity
+		sel := PhysicalSelection{Conditions: ts.filterCondition}.Init(ds.ctx, ds.tableStats.ScaleByExpectCnt(rowCount))
+		sel.SetChildren(ts)
+		sel.SetCost(cost)
+		partialPlan = sel
+	}
+	// Only the handles are transferred to tidb.
+	if handleCol := ds.getPKIsHandleCol(); handleCol != nil {
+		cost += rowCount * sessVars.NetworkFactor * ds.TblColHists.GetAvgRowSize([]*expression.Column{handleCol}, false)
+	}
+	return partialPlan, cost, rowCount
+}
+
 func (is *PhysicalIndexScan) indexScanRowSize(idx *model.IndexInfo, ds *DataSource, isForScan bool) float64 {
 	scanCols := make([]*expression.Column, 0, len(idx.Columns)+1)
 	// If `initSchema` has already appended the handle column in schema, just use schema columns, otherwise, add extra handle column.
@@ -567,6 +707,7 @@ func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSou
 		stats := p.tableStats.ScaleByExpectCnt(count)
 		indexSel := PhysicalSelection{Conditions: indexConds}.Init(is.ctx, stats)
 		indexSel.SetChildren(is)
+		indexSel.SetCost(copTask.cst)
 		copTask.indexPlan = indexSel
 	}
 	if len(tableConds) > 0 {
@@ -574,6 +715,7 @@ func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSou
 		copTask.cst += copTask.count() * sessVars.CopCPUFactor
 		tableSel := PhysicalSelection{Conditions: tableConds}.Init(is.ctx, finalStats)
 		tableSel.SetChildren(copTask.tablePlan)
+		tableSel.SetCost(copTask.cst)
 		copTask.tablePlan = tableSel
 	}
 }
@@ -649,6 +791,7 @@ func (ds *DataSource) convertToTableScan(prop *property.PhysicalProperty, candid
 		return invalidTask, nil
 	}
 	ts, cost, _ := ds.getOriginalPhysicalTableScan(prop, candidate.path, candidate.isMatchProp)
+	ts.SetCost(cost)
 	copTask := &copTask{
 		tablePlan:         ts,
 		indexPlanFinished: true,
@@ -675,6 +818,7 @@ func (ts *PhysicalTableScan) addPushedDownSelection(copTask *copTask, stats *pro
 		copTask.cst += copTask.count() * sessVars.CopCPUFactor
 		sel := PhysicalSelection{Conditions: ts.filterCondition}.Init(ts.ctx, stats)
 		sel.SetChildren(ts)
+		sel.SetCost(copTask.cst)
 		copTask.tablePlan = sel
 	}
 }
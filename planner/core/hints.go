@@ -0,0 +1,323 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/sessionctx"
+)
+
+// defaultSelectBlockPrefix is the prefix of the default query block names,
+// the query blocks are named as "sel_1", "sel_2", ... in the order they appear
+// in the statement.
+const defaultSelectBlockPrefix = "sel_"
+
+// BlockHintProcessor processes the hints of the query blocks in a statement.
+// A hint can be specified in one query block but take effect in another one,
+// by naming the query block with QB_NAME and referencing it by "@qb_name".
+type BlockHintProcessor struct {
+	ctx sessionctx.Context
+	// selectOffsets stores the offset of each SELECT statement, starting from 1.
+	selectOffsets map[*ast.SelectStmt]int
+	// qbNameMap maps the query block names to the offsets of the query blocks.
+	qbNameMap map[string]int
+	// qbHints groups the hints by the query block they take effect in.
+	qbHints map[int][]*ast.TableOptimizerHint
+
+	selectStmts []*ast.SelectStmt
+}
+
+// NewBlockHintProcessor walks the statement to collect the query blocks and
+// dispatches the hints to the query blocks they take effect in.
+func NewBlockHintProcessor(ctx sessionctx.Context, node ast.Node) *BlockHintProcessor {
+	p := &BlockHintProcessor{
+		ctx:           ctx,
+		selectOffsets: make(map[*ast.SelectStmt]int),
+		qbNameMap:     make(map[string]int),
+		qbHints:       make(map[int][]*ast.TableOptimizerHint),
+	}
+	node.Accept(p)
+	for _, sel := range p.selectStmts {
+		p.dispatchHints(sel.TableHints, p.selectOffsets[sel])
+	}
+	return p
+}
+
+// Enter implements the ast.Visitor interface.
+func (p *BlockHintProcessor) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ExplainStmt:
+		// The explained statement is planned by another PlanBuilder.
+		return in, true
+	case *ast.CreateBindingStmt, *ast.DropBindingStmt:
+		// The statements of the bindings are not planned.
+		return in, true
+	case *ast.SelectStmt:
+		offset := len(p.selectStmts) + 1
+		p.selectStmts = append(p.selectStmts, x)
+		p.selectOffsets[x] = offset
+		p.collectQBName(x.TableHints, offset)
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface.
+func (p *BlockHintProcessor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// collectQBName records the name of the query block specified by QB_NAME.
+func (p *BlockHintProcessor) collectQBName(hints []*ast.TableOptimizerHint, offset int) {
+	var qbName string
+	for _, hint := range hints {
+		if hint.HintName.L != HintQBName {
+			continue
+		}
+		if qbName != "" {
+			p.appendWarning(fmt.Sprintf("There are more than two query names in same query block, using the first one %s", qbName))
+			continue
+		}
+		qbName = hint.QBName.L
+	}
+	if qbName == "" {
+		return
+	}
+	if _, ok := p.qbNameMap[qbName]; ok {
+		p.appendWarning(fmt.Sprintf("Duplicate query block name %s, only the first one is effective", qbName))
+		return
+	}
+	p.qbNameMap[qbName] = offset
+}
+
+// dispatchHints puts the hints specified in the query block at currentOffset
+// into the query blocks they take effect in.
+func (p *BlockHintProcessor) dispatchHints(hints []*ast.TableOptimizerHint, currentOffset int) {
+	for _, hint := range hints {
+		if hint.HintName.L == HintQBName {
+			continue
+		}
+		offset := p.getHintOffset(hint, currentOffset)
+		if offset < 0 {
+			p.appendWarning(fmt.Sprintf("Hint %s is ignored due to unknown query block name", restoreHintName(hint)))
+			continue
+		}
+		p.qbHints[offset] = append(p.qbHints[offset], hint)
+	}
+}
+
+// getHintOffset returns the offset of the query block which the hint takes
+// effect in. The query block is specified by the hint itself like
+// "HASH_JOIN(@qb t1, t2)", or by the tables like "HASH_JOIN(t1@qb, t2@qb)".
+// It returns -1 if the query block is unknown or the tables are not in it.
+func (p *BlockHintProcessor) getHintOffset(hint *ast.TableOptimizerHint, currentOffset int) int {
+	offset := currentOffset
+	if hint.QBName.L != "" {
+		offset = p.getBlockOffset(hint.QBName)
+	} else if len(hint.Tables) > 0 && hint.Tables[0].QBName.L != "" {
+		offset = p.getBlockOffset(hint.Tables[0].QBName)
+	}
+	if offset < 0 {
+		return -1
+	}
+	for _, table := range hint.Tables {
+		if table.QBName.L != "" && p.getBlockOffset(table.QBName) != offset {
+			return -1
+		}
+	}
+	return offset
+}
+
+// getBlockOffset returns the offset of the query block with the name, or -1
+// if there is no such query block.
+func (p *BlockHintProcessor) getBlockOffset(qbName model.CIStr) int {
+	if offset, ok := p.qbNameMap[qbName.L]; ok {
+		return offset
+	}
+	if strings.HasPrefix(qbName.L, defaultSelectBlockPrefix) {
+		offset, err := strconv.Atoi(qbName.L[len(defaultSelectBlockPrefix):])
+		if err == nil && offset > 0 && offset <= len(p.selectStmts) {
+			return offset
+		}
+	}
+	return -1
+}
+
+// GetCurrentStmtHints returns the hints which take effect in the SELECT statement.
+func (p *BlockHintProcessor) GetCurrentStmtHints(sel *ast.SelectStmt) []*ast.TableOptimizerHint {
+	offset, ok := p.selectOffsets[sel]
+	if !ok {
+		// The statement is not walked by the processor, so only its own hints take effect.
+		return sel.TableHints
+	}
+	return p.qbHints[offset]
+}
+
+func (p *BlockHintProcessor) appendWarning(msg string) {
+	p.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(msg))
+}
+
+// restoreHintName restores the hint name and the query block it specified, e.g. "HASH_JOIN(@qb)".
+func restoreHintName(hint *ast.TableOptimizerHint) string {
+	if hint.QBName.L == "" {
+		return strings.ToUpper(hint.HintName.L)
+	}
+	return fmt.Sprintf("%s(@%s)", strings.ToUpper(hint.HintName.L), hint.QBName.L)
+}
+
+// GenHintsFromPhysicalPlan generates the hints which reproduce the physical
+// plan: the join order, the join algorithms and the access paths of the
+// tables. The operators do not record the query blocks they are built from,
+// so the hints are only accurate for a plan of a single query block.
+func GenHintsFromPhysicalPlan(p Plan) string {
+	g := &planHintsGenerator{currentDB: p.SCtx().GetSessionVars().CurrentDB}
+	tables := g.collect(p)
+	hints := make([]string, 0, len(g.joinHints)+len(g.accessHints)+1)
+	if len(tables) > 1 && !g.hasNonInnerJoin {
+		hints = append(hints, fmt.Sprintf("%s(%s)", HintLeading, strings.Join(tables, ", ")))
+	}
+	hints = append(hints, g.joinHints...)
+	hints = append(hints, g.accessHints...)
+	return strings.Join(hints, ", ")
+}
+
+// planHintsGenerator collects the hints of the operators in a physical plan.
+type planHintsGenerator struct {
+	currentDB       string
+	hasNonInnerJoin bool
+	joinHints       []string
+	accessHints     []string
+}
+
+// collect collects the hints of the plan and returns the tables it reads, in
+// the order they are joined.
+func (g *planHintsGenerator) collect(p Plan) []string {
+	switch x := p.(type) {
+	case *PhysicalTableReader:
+		for _, child := range x.TablePlans {
+			if ts, ok := child.(*PhysicalTableScan); ok {
+				table := g.hintTable(ts.DBName, ts.Table, ts.TableAsName)
+				g.accessHints = append(g.accessHints, fmt.Sprintf("%s(%s)", HintUseIndex, table))
+				return []string{table}
+			}
+		}
+		return nil
+	case *PhysicalIndexReader:
+		return g.collectIndexScan(x.IndexPlans)
+	case *PhysicalIndexLookUpReader:
+		return g.collectIndexScan(x.IndexPlans)
+	case *PhysicalIndexMergeReader:
+		return g.collectIndexMerge(x)
+	case *PointGetPlan:
+		return g.collectPointGet(x.DBName, x.TblInfo, x.TableAsName, x.IndexInfo)
+	case *BatchPointGetPlan:
+		return g.collectPointGet(x.DBName, x.TblInfo, x.TableAsName, x.IndexInfo)
+	case *PhysicalHashJoin:
+		return g.collectJoin(HintHJ, x.JoinType, x.children)
+	case *PhysicalMergeJoin:
+		return g.collectJoin(HintSMJ, x.JoinType, x.children)
+	}
+	var tables []string
+	if physicalPlan, ok := p.(PhysicalPlan); ok {
+		for _, child := range physicalPlan.Children() {
+			tables = append(tables, g.collect(child)...)
+		}
+	}
+	return tables
+}
+
+func (g *planHintsGenerator) collectIndexScan(indexPlans []PhysicalPlan) []string {
+	for _, child := range indexPlans {
+		if is, ok := child.(*PhysicalIndexScan); ok {
+			table := g.hintTable(is.DBName, is.Table, is.TableAsName)
+			g.accessHints = append(g.accessHints, fmt.Sprintf("%s(%s, %s)", HintUseIndex, table, quoteHintIdentifier(is.Index.Name.O)))
+			return []string{table}
+		}
+	}
+	return nil
+}
+
+// collectIndexMerge collects the USE_INDEX_MERGE hint, the partial table scan
+// is specified by the primary key.
+func (g *planHintsGenerator) collectIndexMerge(reader *PhysicalIndexMergeReader) []string {
+	ts, ok := reader.TablePlans[0].(*PhysicalTableScan)
+	if !ok {
+		return nil
+	}
+	table := g.hintTable(ts.DBName, ts.Table, ts.TableAsName)
+	args := make([]string, 0, len(reader.PartialPlans)+1)
+	args = append(args, table)
+	for _, partialPlans := range reader.PartialPlans {
+		if is, ok := partialPlans[0].(*PhysicalIndexScan); ok {
+			args = append(args, quoteHintIdentifier(is.Index.Name.O))
+		} else {
+			args = append(args, quoteHintIdentifier("primary"))
+		}
+	}
+	g.accessHints = append(g.accessHints, fmt.Sprintf("%s(%s)", HintUseIndexMerge, strings.Join(args, ", ")))
+	return []string{table}
+}
+
+// collectPointGet collects the USE_INDEX hint of the index the fast plan
+// reads, the hint without any index is used if it reads by the handle.
+func (g *planHintsGenerator) collectPointGet(dbName model.CIStr, tblInfo *model.TableInfo, asName *model.CIStr, idxInfo *model.IndexInfo) []string {
+	table := g.hintTable(dbName, tblInfo, asName)
+	if idxInfo == nil {
+		g.accessHints = append(g.accessHints, fmt.Sprintf("%s(%s)", HintUseIndex, table))
+	} else {
+		g.accessHints = append(g.accessHints, fmt.Sprintf("%s(%s, %s)", HintUseIndex, table, quoteHintIdentifier(idxInfo.Name.O)))
+	}
+	return []string{table}
+}
+
+// collectJoin collects the hint of the join algorithm, the hint takes effect
+// on the join whose child reads only one of the tables in the hint.
+func (g *planHintsGenerator) collectJoin(hintName string, joinType JoinType, children []PhysicalPlan) []string {
+	if joinType != InnerJoin {
+		g.hasNonInnerJoin = true
+	}
+	var tables, hintTables []string
+	for _, child := range children {
+		childTables := g.collect(child)
+		if len(childTables) == 1 {
+			hintTables = append(hintTables, childTables[0])
+		}
+		tables = append(tables, childTables...)
+	}
+	if len(hintTables) > 0 {
+		g.joinHints = append(g.joinHints, fmt.Sprintf("%s(%s)", hintName, strings.Join(hintTables, ", ")))
+	}
+	return tables
+}
+
+// hintTable returns the table name used in the hints, the database name is
+// omitted if it is the current database.
+func (g *planHintsGenerator) hintTable(dbName model.CIStr, tblInfo *model.TableInfo, asName *model.CIStr) string {
+	tblName := tblInfo.Name
+	if asName != nil && asName.L != "" {
+		tblName = *asName
+	}
+	if dbName.L == "" || dbName.L == strings.ToLower(g.currentDB) {
+		return quoteHintIdentifier(tblName.O)
+	}
+	return quoteHintIdentifier(dbName.O) + "." + quoteHintIdentifier(tblName.O)
+}
+
+func quoteHintIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
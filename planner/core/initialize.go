@@ -63,6 +63,8 @@ const (
 	TypeDelete = "Delete"
 	// TypeIndexLookUp is the type of IndexLookUp.
 	TypeIndexLookUp = "IndexLookUp"
+	// TypeIndexMerge is the type of IndexMergeReader.
+	TypeIndexMerge = "IndexMerge"
 	// TypeTableReader is the type of TableReader.
 	TypeTableReader = "TableReader"
 	// TypeIndexReader is the type of IndexReader.
@@ -71,6 +73,14 @@ const (
 	TypeTiKVSingleGather = "TiKVSingleGather"
 	// TypeShowDDLJobs is the type of show ddl jobs.
 	TypeShowDDLJobs = "ShowDDLJobs"
+	// TypeShuffle is the type of Shuffle.
+	TypeShuffle = "Shuffle"
+	// TypeShuffleReceiver is the type of ShuffleReceiver.
+	TypeShuffleReceiver = "ShuffleReceiver"
+	// TypePointGet is the type of PointGetPlan.
+	TypePointGet = "PointGet"
+	// TypeBatchPointGet is the type of BatchPointGetPlan.
+	TypeBatchPointGet = "BatchPointGet"
 )
 
 // Init initializes LogicalAggregation.
@@ -289,6 +299,21 @@ func (p PhysicalMergeJoin) Init(ctx sessionctx.Context, stats *property.StatsInf
 	return &p
 }
 
+// Init initializes PhysicalShuffle.
+func (p PhysicalShuffle) Init(ctx sessionctx.Context, stats *property.StatsInfo, props ...*property.PhysicalProperty) *PhysicalShuffle {
+	p.basePhysicalPlan = newBasePhysicalPlan(ctx, TypeShuffle, &p)
+	p.childrenReqProps = props
+	p.stats = stats
+	return &p
+}
+
+// Init initializes PhysicalShuffleReceiverStub.
+func (p PhysicalShuffleReceiverStub) Init(ctx sessionctx.Context, stats *property.StatsInfo) *PhysicalShuffleReceiverStub {
+	p.basePhysicalPlan = newBasePhysicalPlan(ctx, TypeShuffleReceiver, &p)
+	p.stats = stats
+	return &p
+}
+
 // Init initializes basePhysicalAgg.
 func (base basePhysicalAgg) Init(ctx sessionctx.Context, stats *property.StatsInfo) *basePhysicalAgg {
 	base.basePhysicalPlan = newBasePhysicalPlan(ctx, TypeHashAgg, &base)
@@ -321,6 +346,30 @@ func (p PhysicalIndexLookUpReader) Init(ctx sessionctx.Context) *PhysicalIndexLo
 	return &p
 }
 
+// Init initializes PointGetPlan.
+func (p PointGetPlan) Init(ctx sessionctx.Context) *PointGetPlan {
+	p.basePhysicalPlan = newBasePhysicalPlan(ctx, TypePointGet, &p)
+	return &p
+}
+
+// Init initializes BatchPointGetPlan.
+func (p BatchPointGetPlan) Init(ctx sessionctx.Context) *BatchPointGetPlan {
+	p.basePhysicalPlan = newBasePhysicalPlan(ctx, TypeBatchPointGet, &p)
+	return &p
+}
+
+// Init initializes PhysicalIndexMergeReader.
+func (p PhysicalIndexMergeReader) Init(ctx sessionctx.Context) *PhysicalIndexMergeReader {
+	p.basePhysicalPlan = newBasePhysicalPlan(ctx, TypeIndexMerge, &p)
+	p.TablePlans = flattenPushDownPlan(p.tablePlan)
+	p.PartialPlans = make([][]PhysicalPlan, 0, len(p.partialPlans))
+	for _, partialPlan := range p.partialPlans {
+		p.PartialPlans = append(p.PartialPlans, flattenPushDownPlan(partialPlan))
+	}
+	p.schema = p.tablePlan.Schema()
+	return &p
+}
+
 // Init initializes PhysicalTableReader.
 func (p PhysicalTableReader) Init(ctx sessionctx.Context) *PhysicalTableReader {
 	p.basePhysicalPlan = newBasePhysicalPlan(ctx, TypeTableReader, &p)
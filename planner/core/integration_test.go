@@ -14,10 +14,21 @@
 package core_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/planner"
+	"github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testutil"
 )
@@ -88,3 +99,444 @@ func (s *testIntegrationSuite) TestPushLimitDownIndexLookUpReader(c *C) {
 		tk.MustQuery(tt).Check(testkit.Rows(output[i].Plan...))
 	}
 }
+
+func (s *testIntegrationSuite) TestExplainJSONFormat(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+
+	type jsonPlanNode struct {
+		ID           string          `json:"id"`
+		EstRows      string          `json:"estRows"`
+		Cost         string          `json:"cost"`
+		TaskType     string          `json:"taskType"`
+		AccessObject string          `json:"accessObject"`
+		OperatorInfo string          `json:"operatorInfo"`
+		Children     []*jsonPlanNode `json:"children"`
+	}
+	explainJSON := func(sql string) *jsonPlanNode {
+		rows := tk.MustQuery("explain format = 'json' " + sql).Rows()
+		c.Assert(rows, HasLen, 1)
+		c.Assert(rows[0], HasLen, 1)
+		root := &jsonPlanNode{}
+		c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), root), IsNil)
+		return root
+	}
+
+	root := explainJSON("select * from t where b > 1")
+	c.Assert(strings.HasPrefix(root.ID, "TableReader"), IsTrue, Commentf("%v", root.ID))
+	c.Assert(root.TaskType, Equals, "root")
+	c.Assert(root.AccessObject, Equals, "")
+	c.Assert(root.Cost, Not(Equals), "0.00")
+	c.Assert(root.Children, HasLen, 1)
+	sel := root.Children[0]
+	c.Assert(strings.HasPrefix(sel.ID, "Selection"), IsTrue, Commentf("%v", sel.ID))
+	c.Assert(sel.TaskType, Equals, "cop")
+	c.Assert(sel.OperatorInfo, Equals, "gt(test.t.b, 1)")
+	c.Assert(sel.Children, HasLen, 1)
+	scan := sel.Children[0]
+	c.Assert(scan.AccessObject, Equals, "table:t")
+	c.Assert(scan.EstRows, Equals, "10000.00")
+	c.Assert(scan.Children, HasLen, 0)
+
+	root = explainJSON("select * from t where a = 1")
+	c.Assert(strings.HasPrefix(root.ID, "IndexLookUp"), IsTrue, Commentf("%v", root.ID))
+	c.Assert(root.Children, HasLen, 2)
+	c.Assert(root.Children[0].AccessObject, Equals, "table:t, index:idx(a)")
+	c.Assert(root.Children[1].AccessObject, Equals, "table:t")
+
+	// The cascades planner keeps the costs of the plans as well.
+	tk.MustExec("set session tidb_enable_cascades_planner = 1")
+	defer tk.MustExec("set session tidb_enable_cascades_planner = 0")
+	var checkCost func(node *jsonPlanNode)
+	checkCost = func(node *jsonPlanNode) {
+		c.Assert(node.Cost, Not(Equals), "0.00", Commentf("%v", node.ID))
+		c.Assert(node.Cost, Not(Equals), "N/A", Commentf("%v", node.ID))
+		for _, child := range node.Children {
+			checkCost(child)
+		}
+	}
+	root = explainJSON("select * from t where b > 1")
+	c.Assert(strings.HasPrefix(root.ID, "TableReader"), IsTrue, Commentf("%v", root.ID))
+	checkCost(root)
+	checkCost(explainJSON("select t1.a, count(*) from t t1, t t2 where t1.a = t2.b group by t1.a order by t1.a limit 10"))
+
+	tk.MustGetErrCode("explain format = 'xml' select * from t", mysql.ErrUnknownExplainFormat)
+}
+
+func (s *testIntegrationSuite) TestDecodePlan(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int)")
+
+	ctx := tk.Se.(sessionctx.Context)
+	stmts, err := session.Parse(ctx, "select * from t where b > 1")
+	c.Assert(err, IsNil)
+	is := domain.GetDomain(ctx).InfoSchema()
+	c.Assert(core.Preprocess(ctx, stmts[0], is), IsNil)
+	p, _, err := planner.Optimize(context.TODO(), ctx, stmts[0], is)
+	c.Assert(err, IsNil)
+	encoded := core.EncodePlan(p)
+	c.Assert(encoded, Not(Equals), "")
+
+	rows := tk.MustQuery("select tidb_decode_plan('" + encoded + "')").Rows()
+	lines := strings.Split(rows[0][0].(string), "\n")
+	c.Assert(lines, HasLen, 5)
+	c.Assert(strings.Fields(lines[1])[0], Equals, "id")
+	c.Assert(strings.HasPrefix(strings.TrimSpace(lines[2]), "TableReader"), IsTrue, Commentf("%v", lines[2]))
+	c.Assert(strings.Contains(lines[3], "└─Selection"), IsTrue, Commentf("%v", lines[3]))
+	c.Assert(strings.Contains(lines[3], "gt(test.t.b, 1)"), IsTrue, Commentf("%v", lines[3]))
+	c.Assert(strings.Contains(lines[4], "  └─TableScan"), IsTrue, Commentf("%v", lines[4]))
+
+	tk.MustQuery("select tidb_decode_plan(NULL), tidb_decode_plan('')").Check(testkit.Rows("<nil> "))
+	c.Assert(tk.QueryToErr("select tidb_decode_plan('invalid plan')"), NotNil)
+}
+
+func (s *testIntegrationSuite) TestNormalizePlan(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index idx(a))")
+
+	ctx := tk.Se.(sessionctx.Context)
+	is := domain.GetDomain(ctx).InfoSchema()
+	normalizePlan := func(sql string) (string, string) {
+		stmts, err := session.Parse(ctx, sql)
+		c.Assert(err, IsNil)
+		c.Assert(core.Preprocess(ctx, stmts[0], is), IsNil)
+		p, _, err := planner.Optimize(context.TODO(), ctx, stmts[0], is)
+		c.Assert(err, IsNil)
+		return core.NormalizePlan(p)
+	}
+
+	normalized1, digest1 := normalizePlan("select * from t where b > 1")
+	normalized2, digest2 := normalizePlan("select * from t where b > 100")
+	c.Assert(normalized1, Equals, normalized2)
+	c.Assert(digest1, Equals, digest2)
+	c.Assert(digest1, HasLen, 64)
+	c.Assert(normalized1, Equals, "0\tTableReader\troot\t\n1\tSelection\tcop\t\n2\tTableScan\tcop\ttable:t\n")
+
+	_, digest3 := normalizePlan("select * from t")
+	c.Assert(digest3, Not(Equals), digest1)
+	normalized4, _ := normalizePlan("select * from t where a = 1")
+	c.Assert(strings.Contains(normalized4, "table:t, index:idx(a)"), IsTrue, Commentf("%v", normalized4))
+}
+
+type explainJoin struct {
+	op     string
+	tables []string
+}
+
+var explainTableRegexp = regexp.MustCompile(`table:(\w+)`)
+
+// explainJoins returns the joins in the plan of sql from top to bottom, along
+// with the sorted names of the tables read under each of them.
+func explainJoins(tk *testkit.TestKit, sql string) []explainJoin {
+	var (
+		joins []explainJoin
+		// openDepths are the depths of the joins whose subtrees are being
+		// visited, and -1 for the others.
+		openDepths []int
+	)
+	for _, row := range tk.MustQuery("explain " + sql).Rows() {
+		id := row[0].(string)
+		op := strings.TrimLeft(id, "└─├│ ")
+		depth := len([]rune(id)) - len([]rune(op))
+		for i := range openDepths {
+			if depth <= openDepths[i] {
+				openDepths[i] = -1
+			}
+		}
+		if strings.Contains(op, "Join") {
+			joins = append(joins, explainJoin{op: op})
+			openDepths = append(openDepths, depth)
+			continue
+		}
+		match := explainTableRegexp.FindStringSubmatch(row[3].(string))
+		if match == nil {
+			continue
+		}
+		for i := range joins {
+			// The index and the table of a lookup are read from the same table.
+			tables := joins[i].tables
+			if openDepths[i] >= 0 && (len(tables) == 0 || tables[len(tables)-1] != match[1]) {
+				joins[i].tables = append(tables, match[1])
+			}
+		}
+	}
+	for i := range joins {
+		sort.Strings(joins[i].tables)
+	}
+	return joins
+}
+
+func (s *testIntegrationSuite) TestOptimizerHints(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2, t3")
+	tk.MustExec("create table t1(a int, b int, key(a))")
+	tk.MustExec("create table t2(a int, b int, key(a))")
+	tk.MustExec("create table t3(a int, b int, key(a))")
+	tk.MustExec("insert into t1 values(1, 1), (2, 2), (3, 3)")
+	tk.MustExec("insert into t2 values(1, 1), (2, 2)")
+	tk.MustExec("insert into t3 values(1, 1), (3, 3)")
+
+	tests := []struct {
+		sql      string
+		warnings []string
+	}{
+		{
+			sql: "select /*+ LEADING(t3, t1) */ count(*) from t1, t2, t3 where t1.a = t2.a and t2.a = t3.a",
+		},
+		{
+			sql: "select /*+ LEADING(t2, t1) */ count(*) from t1, t2, t3 where t1.a = t2.a and t2.a = t3.a",
+		},
+		{
+			sql: "select /*+ HASH_JOIN(@sel_2 t1, t2) */ count(*) from t3 where t3.a in (select t1.a from t1, t2 where t1.a = t2.a)",
+		},
+		{
+			sql: "select /*+ HASH_JOIN(@qb t1, t2) */ count(*) from t3 where t3.a in (select /*+ QB_NAME(qb) */ t1.a from t1, t2 where t1.a = t2.a)",
+		},
+		{
+			sql:      "select /*+ QB_NAME(qb) */ count(*) from t3 where t3.a in (select /*+ QB_NAME(qb) */ t1.a from t1)",
+			warnings: []string{"Duplicate query block name qb, only the first one is effective"},
+		},
+		{
+			sql:      "select /*+ HASH_JOIN(@qb t1) */ count(*) from t1, t2 where t1.a = t2.a",
+			warnings: []string{"Hint HASH_JOIN(@qb) is ignored due to unknown query block name"},
+		},
+		{
+			sql:      "select /*+ USE_INDEX(t3, a) */ * from t1",
+			warnings: []string{"There are no matching table names for (t3) in optimizer hint /*+ USE_INDEX(t3) */. Maybe you can use the table alias name"},
+		},
+		{
+			sql:      "select /*+ INL_JOIN(t1) */ count(*) from t1, t2 where t1.a = t2.a",
+			warnings: []string{"Optimizer Hint INL_JOIN is inapplicable, the index join is not supported"},
+		},
+		{
+			sql:      "select /*+ HASH_AGG(), STREAM_AGG() */ count(*) from t1",
+			warnings: []string{"Optimizer aggregation hints are conflicted"},
+		},
+		{
+			sql:      "select /*+ STREAM_AGG() */ count(*) from t1",
+			warnings: []string{"Optimizer Hint STREAM_AGG is inapplicable, the stream aggregation is not supported"},
+		},
+		{
+			sql:      "select /*+ HASH_AGG() */ count(*) from t1",
+			warnings: []string{"Optimizer Hint HASH_AGG is inapplicable, the hash aggregation is the only supported aggregation"},
+		},
+		{
+			sql:      "select /*+ HASH_AGG() */ * from t1",
+			warnings: []string{"Optimizer aggregation hints are inapplicable, there is no aggregation in the query block"},
+		},
+		{
+			sql: "select /*+ READ_FROM_STORAGE(TIKV[t1]) */ * from t1",
+		},
+		{
+			sql:      "select /*+ READ_FROM_STORAGE(TIFLASH[t1]) */ * from t1",
+			warnings: []string{"Storage hint TIFLASH is inapplicable for table t1, the table is read from TiKV since TiFlash is not supported"},
+		},
+		{
+			sql:      "select /*+ READ_FROM_STORAGE(TIKV[t1], TIFLASH[t1]) */ * from t1",
+			warnings: []string{"Storage hints are conflict, you can only specify one storage type of table t1"},
+		},
+		{
+			sql:      "select /*+ AGG_TO_COP() */ count(*) from t1, t2 where t1.a = t2.a",
+			warnings: []string{"Optimizer Hint AGG_TO_COP is inapplicable"},
+		},
+		{
+			sql:      "select /*+ LEADING(t1, t2), STRAIGHT_JOIN() */ count(*) from t1, t2 where t1.a = t2.a",
+			warnings: []string{"Optimizer Hint LEADING is inapplicable when STRAIGHT_JOIN is specified"},
+		},
+		{
+			sql:      "select /*+ LEADING(t1, t4) */ count(*) from t1, t2 where t1.a = t2.a",
+			warnings: []string{"leading hint is inapplicable, check if the leading hint table is valid"},
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("sql: %s", tt.sql)
+		tk.MustQuery(tt.sql)
+		warnings := tk.Se.GetSessionVars().StmtCtx.GetWarnings()
+		c.Assert(warnings, HasLen, len(tt.warnings), comment)
+		for i, warning := range warnings {
+			c.Assert(warning.Err.Error(), Matches, ".*"+regexp.QuoteMeta(tt.warnings[i]), comment)
+		}
+	}
+
+	// The tables of LEADING are joined first in the plan.
+	joins := explainJoins(tk, "select /*+ LEADING(t3, t1) */ count(*) from t1, t2, t3 where t1.a = t2.a and t2.a = t3.a")
+	c.Assert(joins, HasLen, 2)
+	c.Assert(joins[1].tables, DeepEquals, []string{"t1", "t3"})
+	joins = explainJoins(tk, "select /*+ LEADING(t2, t1) */ count(*) from t1, t2, t3 where t1.a = t2.a and t2.a = t3.a")
+	c.Assert(joins[1].tables, DeepEquals, []string{"t1", "t2"})
+	// The tables are joined in the order they appear with STRAIGHT_JOIN.
+	joins = explainJoins(tk, "select /*+ STRAIGHT_JOIN() */ count(*) from t1, t3, t2 where t1.a = t2.a and t2.a = t3.a")
+	c.Assert(joins[1].tables, DeepEquals, []string{"t1", "t3"})
+	joins = explainJoins(tk, "select /*+ STRAIGHT_JOIN() */ count(*) from t3, t2, t1 where t1.a = t2.a and t2.a = t3.a")
+	c.Assert(joins[1].tables, DeepEquals, []string{"t2", "t3"})
+	// The hints of a query block only take effect on the joins in it.
+	sql := "select /*+ SM_JOIN(@sel_2 t1, t2) */ count(*) from t3 where t3.a in (select t1.a from t1, t2 where t1.a = t2.a)"
+	joins = explainJoins(tk, sql)
+	c.Assert(joins, HasLen, 2)
+	c.Assert(joins[0].op, Not(Matches), "MergeJoin.*")
+	c.Assert(joins[1].op, Matches, "MergeJoin.*")
+	c.Assert(joins[1].tables, DeepEquals, []string{"t1", "t2"})
+	sql = "select /*+ SM_JOIN(t3) */ count(*) from t3 where t3.a in (select t1.a from t1, t2 where t1.a = t2.a)"
+	joins = explainJoins(tk, sql)
+	c.Assert(joins[0].op, Matches, "MergeJoin.*")
+	c.Assert(joins[1].op, Not(Matches), "MergeJoin.*")
+
+	tk.MustQuery("select /*+ LEADING(t3, t1) */ t1.a, t2.b, t3.b from t1, t2, t3 where t1.a = t2.a and t2.a = t3.a").Check(testkit.Rows("1 1 1"))
+	tk.MustQuery("select /*+ AGG_TO_COP(), HASH_AGG() */ count(*) from t1 where b > 1").Check(testkit.Rows("2"))
+	tk.MustQuery("select /*+ FORCE_INDEX(t1, a) */ b from t1 where a = 2").Check(testkit.Rows("2"))
+}
+
+func (s *testIntegrationSuite) TestExplainHint(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int, b int, key(a))")
+	tk.MustExec("create table t2(a int, b int, key(a))")
+
+	tk.MustQuery("explain format='hint' select a from t1 where a = 1").Check(testkit.Rows("use_index(`t1`, `a`)"))
+	tk.MustQuery("explain format='hint' select /*+ USE_INDEX(t1) */ a from t1 where a = 1").Check(testkit.Rows("use_index(`t1`)"))
+	tk.MustQuery("explain format='hint' select /*+ USE_INDEX(x) */ b from t1 x where b = 1").Check(testkit.Rows("use_index(`x`)"))
+	tk.MustExec("create database if not exists explain_hint")
+	tk.MustExec("create table if not exists explain_hint.t(a int)")
+	tk.MustQuery("explain format='hint' select a from explain_hint.t").Check(testkit.Rows("use_index(`explain_hint`.`t`)"))
+	tk.MustQuery("explain format='hint' select 1").Check(testkit.Rows(""))
+
+	// The hints reproduce the plan.
+	hints := tk.MustQuery("explain format='hint' select /*+ SM_JOIN(t1) */ t1.b from t1, t2 where t1.a = t2.a").Rows()[0][0].(string)
+	c.Assert(hints, Matches, "leading\\(`t[12]`, `t[12]`\\), sm_join\\(`t[12]`, `t[12]`\\), use_index.*")
+	tk.MustQuery("explain format='hint' select /*+ " + hints + " */ t1.b from t1, t2 where t1.a = t2.a").Check(testkit.Rows(hints))
+}
+
+func (s *testIntegrationSuite) TestIndexMerge(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int, key(b), key(c))")
+
+	rows := tk.MustQuery("explain select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 1 or c = 2").Rows()
+	c.Assert(rows[0][0], Matches, "IndexMerge.*")
+	c.Assert(rows[0][3], Equals, "type:union")
+	rows = tk.MustQuery("explain select /*+ USE_INDEX_MERGE(t, primary, c) */ * from t where a = 1 or c = 2").Rows()
+	c.Assert(rows[0][0], Matches, "IndexMerge.*")
+	rows = tk.MustQuery("explain select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 1 and c = 2").Rows()
+	c.Assert(rows[0][0], Matches, "IndexMerge.*")
+	c.Assert(rows[0][3], Equals, "type:intersection")
+	tk.MustQuery("explain format='hint' select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 1 or c = 2").Check(testkit.Rows("use_index_merge(`t`, `b`, `c`)"))
+
+	// The union path needs an index for every item of the OR condition.
+	tk.MustQuery("explain select /*+ USE_INDEX_MERGE(t, b, c) */ * from t where b = 1 or a > c")
+	warnings := tk.Se.GetSessionVars().StmtCtx.GetWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(warnings[0].Err.Error(), Matches, ".*IndexMerge is inapplicable or disabled")
+
+	tk.MustExec("set @@tidb_enable_index_merge = 1")
+	rows = tk.MustQuery("explain select /*+ NO_INDEX_MERGE() */ * from t where b = 1 or c = 2").Rows()
+	c.Assert(rows[0][0], Not(Matches), "IndexMerge.*")
+}
+
+func (s *testIntegrationSuite) TestIndexMergeIntersectionByCost(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int, d varchar(500), key(b), key(c))")
+	// Every index reads about a tenth of the rows, but only a few rows satisfy
+	// both conditions, and the rows are wide, so reading the table is costly.
+	pad := strings.Repeat("x", 500)
+	values := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d, '%s')", i, i%10, i%7, pad))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ","))
+	tk.MustExec("analyze table t")
+
+	rows := tk.MustQuery("explain select * from t where b = 1 and c = 2").Rows()
+	c.Assert(rows[0][0], Not(Matches), "IndexMerge.*")
+
+	tk.MustExec("set @@tidb_enable_index_merge = 1")
+	rows = tk.MustQuery("explain select * from t where b = 1 and c = 2").Rows()
+	c.Assert(rows[0][0], Matches, "IndexMerge.*")
+	c.Assert(rows[0][3], Equals, "type:intersection")
+	tk.MustQuery("select a from t where b = 1 and c = 2 order by a").Check(testkit.Rows("51", "121", "191", "261", "331", "401", "471", "541", "611", "681", "751", "821", "891", "961"))
+}
+
+func (s *testIntegrationSuite) TestPointGetPlan(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c varchar(20), d int, unique key(b), unique key(c), unique key(b, d))")
+
+	rows := tk.MustQuery("explain select * from t where a = 1").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][0], Matches, "PointGet_.*")
+	c.Assert(rows[0][3], Equals, "table:t, handle:1")
+	rows = tk.MustQuery("explain select a, c from t where b = 2").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][0], Matches, "PointGet_.*")
+	rows = tk.MustQuery("explain select * from t where b = 2 and d = 3").Rows()
+	c.Assert(rows[0][0], Matches, "PointGet_.*")
+	rows = tk.MustQuery("explain select * from t where c = 'x'").Rows()
+	c.Assert(rows[0][0], Matches, "PointGet_.*")
+	rows = tk.MustQuery("explain select * from t where a in (1, 2, 3)").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][0], Matches, "BatchPointGet_.*")
+	c.Assert(rows[0][3], Equals, "table:t, handles:1, 2, 3")
+	rows = tk.MustQuery("explain select * from t where b in (1, 2)").Rows()
+	c.Assert(rows[0][0], Matches, "BatchPointGet_.*")
+	rows = tk.MustQuery("explain delete from t where a = 1").Rows()
+	c.Assert(rows[0][0], Matches, "Delete.*")
+	c.Assert(rows[1][0], Matches, ".*PointGet_.*")
+
+	// The other queries are still optimized as usual.
+	rows = tk.MustQuery("explain select * from t where c = 1").Rows()
+	c.Assert(rows[0][0], Not(Matches), ".*PointGet.*")
+	rows = tk.MustQuery("explain select * from t where d = 1").Rows()
+	c.Assert(rows[0][0], Not(Matches), ".*PointGet.*")
+	rows = tk.MustQuery("explain select * from t where b is null").Rows()
+	c.Assert(rows[0][0], Not(Matches), ".*PointGet.*")
+	rows = tk.MustQuery("explain select * from t where a = 1 or b = 2").Rows()
+	c.Assert(rows[0][0], Not(Matches), ".*PointGet.*")
+	rows = tk.MustQuery("explain select count(*) from t where a = 1").Rows()
+	c.Assert(rows[0][0], Not(Matches), ".*PointGet.*")
+	rows = tk.MustQuery("explain select * from t use index(b) where b = 1").Rows()
+	c.Assert(rows[0][0], Not(Matches), ".*PointGet.*")
+}
+
+func (s *testIntegrationSuite) TestOuterJoinEliminationWithUniqueIndexPrefix(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int)")
+	tk.MustExec("create table t2(a int, b int, c int, unique key(a, b))")
+	tk.MustExec("insert into t1 values (1), (2)")
+	tk.MustExec("insert into t2 values (1, 1, 1), (1, 2, 1)")
+
+	// The join key is only a prefix of the unique index, so the inner rows are
+	// not unique and the join can't be eliminated.
+	sql := "select t1.a from t1 left join t2 on t1.a = t2.a"
+	c.Assert(explainJoins(tk, sql), HasLen, 1)
+	tk.MustQuery(sql + " order by t1.a").Check(testkit.Rows("1", "1", "2"))
+	sql = "select t1.a from t1 left join t2 on t1.a = t2.c"
+	c.Assert(explainJoins(tk, sql), HasLen, 1)
+	tk.MustQuery(sql + " order by t1.a").Check(testkit.Rows("1", "1", "2"))
+
+	// The join keys contain all the columns of the unique index.
+	sql = "select t1.a from t1 left join t2 on t1.a = t2.a and t1.a = t2.b"
+	c.Assert(explainJoins(tk, sql), HasLen, 0)
+	tk.MustQuery(sql + " order by t1.a").Check(testkit.Rows("1", "2"))
+}
@@ -52,6 +52,34 @@ const (
 	HintUseIndex = "use_index"
 	// HintIgnoreIndex is hint enforce ignoring some indexes.
 	HintIgnoreIndex = "ignore_index"
+	// HintForceIndex is hint enforce using some indexes, it is the same as HintUseIndex now.
+	HintForceIndex = "force_index"
+	// TiDBIndexNestedLoopJoin is hint enforce index nested loop join.
+	TiDBIndexNestedLoopJoin = "tidb_inlj"
+	// HintINLJ is hint enforce index nested loop join.
+	HintINLJ = "inl_join"
+	// HintLeading is hint enforce joining the specified tables first in order.
+	HintLeading = "leading"
+	// HintStraightJoin is hint enforce joining the tables in the order they appear.
+	HintStraightJoin = "straight_join"
+	// HintHashAgg is hint enforce hash aggregation.
+	HintHashAgg = "hash_agg"
+	// HintStreamAgg is hint enforce stream aggregation.
+	HintStreamAgg = "stream_agg"
+	// HintAggToCop is hint enforce pushing aggregation to coprocessor.
+	HintAggToCop = "agg_to_cop"
+	// HintUseIndexMerge is hint enforce using index merge.
+	HintUseIndexMerge = "use_index_merge"
+	// HintNoIndexMerge is hint enforce not using index merge.
+	HintNoIndexMerge = "no_index_merge"
+	// HintReadFromStorage is hint enforce reading the tables from specified storage.
+	HintReadFromStorage = "read_from_storage"
+	// HintTiKV is the TiKV storage type of HintReadFromStorage.
+	HintTiKV = "tikv"
+	// HintTiFlash is the TiFlash storage type of HintReadFromStorage.
+	HintTiFlash = "tiflash"
+	// HintQBName is hint to name the query block.
+	HintQBName = "qb_name"
 )
 
 func (la *LogicalAggregation) collectGroupByColumns() {
@@ -76,6 +104,7 @@ func (b *PlanBuilder) buildAggregation(ctx context.Context, p LogicalPlan, aggFu
 	b.optFlag = b.optFlag | flagEliminateProjection
 
 	plan4Agg := LogicalAggregation{AggFuncs: make([]*aggregation.AggFuncDesc, 0, len(aggFuncList))}.Init(b.ctx)
+	b.setAggHints(plan4Agg)
 	schema4Agg := expression.NewSchema(make([]*expression.Column, 0, len(aggFuncList)+p.Schema().Len())...)
 	names := make(types.NameSlice, 0, len(aggFuncList)+p.Schema().Len())
 	// aggIdxMap maps the old index to new index after applying common aggregation functions elimination.
@@ -327,6 +356,11 @@ func (p *LogicalJoin) setPreferredJoinType(hintInfo *tableHintInfo) {
 	if hintInfo.ifPreferHashJoin(lhsAlias, rhsAlias) {
 		p.preferJoinType |= preferHashJoin
 	}
+	if hintInfo.ifPreferINLJ(lhsAlias, rhsAlias) {
+		errMsg := "Optimizer Hint INL_JOIN is inapplicable, the index join is not supported"
+		warning := ErrInternal.GenWithStack(errMsg)
+		p.ctx.GetSessionVars().StmtCtx.AppendWarning(warning)
+	}
 
 	// set hintInfo for further usage if this hint info can be used.
 	if p.preferJoinType != 0 {
@@ -340,6 +374,16 @@ func (p *LogicalJoin) setPreferredJoinType(hintInfo *tableHintInfo) {
 	}
 }
 
+// setLeadingJoinOrder sets the join order specified by the LEADING hint, which
+// is used by the join reorder rule.
+func (p *LogicalJoin) setLeadingJoinOrder(hintInfo *tableHintInfo) {
+	if hintInfo == nil || hintInfo.leadingJoinOrder == nil {
+		return
+	}
+	hintInfo.leadingMatched = true
+	p.leadingJoinOrder = hintInfo.leadingJoinOrder
+}
+
 func resetNotNullFlag(schema *expression.Schema, start, end int) {
 	for i := start; i < end; i++ {
 		col := *schema.Columns[i]
@@ -396,6 +440,7 @@ func (b *PlanBuilder) buildJoin(ctx context.Context, joinNode *ast.Join) (Logica
 	default:
 		b.optFlag = b.optFlag | flagJoinReOrder
 		joinPlan.JoinType = InnerJoin
+		joinPlan.setLeadingJoinOrder(b.TableHints())
 	}
 
 	// Set preferred join algorithm if some join hints is specified by user.
@@ -601,6 +646,29 @@ func (b *PlanBuilder) buildProjection(ctx context.Context, p LogicalPlan, fields
 	return proj, oldLen, nil
 }
 
+// setAggHints sets the aggregation hints of the current query block on the aggregation.
+func (b *PlanBuilder) setAggHints(agg *LogicalAggregation) {
+	hintInfo := b.TableHints()
+	if hintInfo == nil {
+		return
+	}
+	aggHints := hintInfo.aggHints
+	if !hintInfo.aggHintsMatched {
+		hintInfo.aggHintsMatched = true
+		if aggHints.preferAggType&preferHashAgg > 0 && aggHints.preferAggType&preferStreamAgg > 0 {
+			errMsg := "Optimizer aggregation hints are conflicted"
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		} else if aggHints.preferAggType&preferStreamAgg > 0 {
+			errMsg := "Optimizer Hint STREAM_AGG is inapplicable, the stream aggregation is not supported"
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		} else if aggHints.preferAggType&preferHashAgg > 0 {
+			errMsg := "Optimizer Hint HASH_AGG is inapplicable, the hash aggregation is the only supported aggregation"
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		}
+	}
+	agg.aggHints = aggHints
+}
+
 func (b *PlanBuilder) buildDistinct(child LogicalPlan, length int) (*LogicalAggregation, error) {
 	b.optFlag = b.optFlag | flagBuildKeyInfo
 	b.optFlag = b.optFlag | flagPushDownAgg
@@ -609,6 +677,7 @@ func (b *PlanBuilder) buildDistinct(child LogicalPlan, length int) (*LogicalAggr
 		GroupByItems: expression.Column2Exprs(child.Schema().Clone().Columns[:length]),
 	}.Init(b.ctx)
 	plan4Agg.collectGroupByColumns()
+	b.setAggHints(plan4Agg)
 	for _, col := range child.Schema().Columns {
 		aggDesc, err := aggregation.NewAggFuncDesc(b.ctx, ast.AggFuncFirstRow, []expression.Expression{col})
 		if err != nil {
@@ -1094,8 +1163,11 @@ func (b *PlanBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 
 func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint) {
 	var (
-		sortMergeTables, hashJoinTables []hintTableInfo
-		indexHintList                   []indexHintInfo
+		sortMergeTables, hashJoinTables, indexNestedLoopJoinTables []hintTableInfo
+		tikvTables, tiflashTables, leadingJoinOrder                []hintTableInfo
+		indexHintList, indexMergeHintList                          []indexHintInfo
+		aggHints                                                   aggHintInfo
+		straightJoin, noIndexMerge                                 bool
 	)
 	for _, hint := range hints {
 		switch hint.HintName.L {
@@ -1103,47 +1175,89 @@ func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint) {
 			sortMergeTables = append(sortMergeTables, tableNames2HintTableInfo(b.ctx, hint.Tables)...)
 		case TiDBHashJoin, HintHJ:
 			hashJoinTables = append(hashJoinTables, tableNames2HintTableInfo(b.ctx, hint.Tables)...)
-		case HintUseIndex:
+		case TiDBIndexNestedLoopJoin, HintINLJ:
+			indexNestedLoopJoinTables = append(indexNestedLoopJoinTables, tableNames2HintTableInfo(b.ctx, hint.Tables)...)
+		case HintUseIndex, HintIgnoreIndex, HintForceIndex:
 			if len(hint.Tables) != 0 {
 				dbName := hint.Tables[0].DBName
 				if dbName.L == "" {
 					dbName = model.NewCIStr(b.ctx.GetSessionVars().CurrentDB)
 				}
+				hintType := ast.HintUse
+				switch hint.HintName.L {
+				case HintIgnoreIndex:
+					hintType = ast.HintIgnore
+				case HintForceIndex:
+					hintType = ast.HintForce
+				}
 				indexHintList = append(indexHintList, indexHintInfo{
-					dbName:  dbName,
-					tblName: hint.Tables[0].TableName,
+					dbName:   dbName,
+					tblName:  hint.Tables[0].TableName,
+					hintName: hint.HintName.L,
 					indexHint: &ast.IndexHint{
 						IndexNames: hint.Indexes,
-						HintType:   ast.HintUse,
+						HintType:   hintType,
 						HintScope:  ast.HintForScan,
 					},
 				})
 			}
-		case HintIgnoreIndex:
+		case HintLeading:
+			if leadingJoinOrder != nil {
+				errMsg := "We can only use one leading hint at most, when multiple leading hints are used, all leading hints will be invalid"
+				b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+				continue
+			}
+			leadingJoinOrder = tableNames2HintTableInfo(b.ctx, hint.Tables)
+		case HintStraightJoin:
+			straightJoin = true
+		case HintHashAgg:
+			aggHints.preferAggType |= preferHashAgg
+		case HintStreamAgg:
+			aggHints.preferAggType |= preferStreamAgg
+		case HintAggToCop:
+			aggHints.preferAggToCop = true
+		case HintUseIndexMerge:
 			if len(hint.Tables) != 0 {
 				dbName := hint.Tables[0].DBName
 				if dbName.L == "" {
 					dbName = model.NewCIStr(b.ctx.GetSessionVars().CurrentDB)
 				}
-				indexHintList = append(indexHintList, indexHintInfo{
-					dbName:  dbName,
-					tblName: hint.Tables[0].TableName,
+				indexMergeHintList = append(indexMergeHintList, indexHintInfo{
+					dbName:   dbName,
+					tblName:  hint.Tables[0].TableName,
+					hintName: hint.HintName.L,
 					indexHint: &ast.IndexHint{
 						IndexNames: hint.Indexes,
-						HintType:   ast.HintIgnore,
+						HintType:   ast.HintUse,
 						HintScope:  ast.HintForScan,
 					},
 				})
 			}
-
+		case HintNoIndexMerge:
+			noIndexMerge = true
+		case HintReadFromStorage:
+			switch hint.StoreType.L {
+			case HintTiKV:
+				tikvTables = append(tikvTables, tableNames2HintTableInfo(b.ctx, hint.Tables)...)
+			case HintTiFlash:
+				tiflashTables = append(tiflashTables, tableNames2HintTableInfo(b.ctx, hint.Tables)...)
+			}
 		default:
 			// ignore hints that not implemented
 		}
 	}
 	b.tableHintInfo = append(b.tableHintInfo, tableHintInfo{
-		sortMergeJoinTables: sortMergeTables,
-		hashJoinTables:      hashJoinTables,
-		indexHintList:       indexHintList,
+		sortMergeJoinTables:       sortMergeTables,
+		hashJoinTables:            hashJoinTables,
+		indexNestedLoopJoinTables: indexNestedLoopJoinTables,
+		indexHintList:             indexHintList,
+		indexMergeHintList:        indexMergeHintList,
+		tikvTables:                tikvTables,
+		tiflashTables:             tiflashTables,
+		leadingJoinOrder:          leadingJoinOrder,
+		aggHints:                  aggHints,
+		straightJoin:              straightJoin,
+		noIndexMerge:              noIndexMerge,
 	})
 }
 
@@ -1151,9 +1265,43 @@ func (b *PlanBuilder) popTableHints() {
 	hintInfo := b.tableHintInfo[len(b.tableHintInfo)-1]
 	b.appendUnmatchedJoinHintWarning(HintSMJ, TiDBMergeJoin, hintInfo.sortMergeJoinTables)
 	b.appendUnmatchedJoinHintWarning(HintHJ, TiDBHashJoin, hintInfo.hashJoinTables)
+	b.appendUnmatchedJoinHintWarning(HintINLJ, TiDBIndexNestedLoopJoin, hintInfo.indexNestedLoopJoinTables)
+	b.appendUnmatchedIndexHintWarning(hintInfo.indexHintList)
+	b.appendUnmatchedIndexHintWarning(hintInfo.indexMergeHintList)
+	b.appendUnmatchedStorageHintWarning(HintTiKV, hintInfo.tikvTables)
+	b.appendUnmatchedStorageHintWarning(HintTiFlash, hintInfo.tiflashTables)
+	if hintInfo.leadingJoinOrder != nil && !hintInfo.leadingMatched {
+		errMsg := "Optimizer Hint LEADING is inapplicable, there is no inner join in the query block"
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+	}
+	if (hintInfo.aggHints.preferAggType != 0 || hintInfo.aggHints.preferAggToCop) && !hintInfo.aggHintsMatched {
+		errMsg := "Optimizer aggregation hints are inapplicable, there is no aggregation in the query block"
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+	}
 	b.tableHintInfo = b.tableHintInfo[:len(b.tableHintInfo)-1]
 }
 
+func (b *PlanBuilder) appendUnmatchedIndexHintWarning(indexHints []indexHintInfo) {
+	for _, hint := range indexHints {
+		if hint.matched {
+			continue
+		}
+		errMsg := fmt.Sprintf("There are no matching table names for (%s) in optimizer hint /*+ %s(%s) */. Maybe you can use the table alias name",
+			hint.tblName.O, strings.ToUpper(hint.hintName), hint.tblName.O)
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+	}
+}
+
+func (b *PlanBuilder) appendUnmatchedStorageHintWarning(storeType string, hintTables []hintTableInfo) {
+	unMatchedTables := extractUnmatchedTables(hintTables)
+	if len(unMatchedTables) == 0 {
+		return
+	}
+	errMsg := fmt.Sprintf("There are no matching table names for (%s) in optimizer hint /*+ READ_FROM_STORAGE(%s[%s]) */. Maybe you can use the table alias name",
+		strings.Join(unMatchedTables, ", "), strings.ToUpper(storeType), strings.Join(unMatchedTables, ", "))
+	b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+}
+
 func (b *PlanBuilder) appendUnmatchedJoinHintWarning(joinType string, joinTypeAlias string, hintTables []hintTableInfo) {
 	unMatchedTables := extractUnmatchedTables(hintTables)
 	if len(unMatchedTables) == 0 {
@@ -1177,7 +1325,11 @@ func (b *PlanBuilder) TableHints() *tableHintInfo {
 }
 
 func (b *PlanBuilder) buildSelect(ctx context.Context, sel *ast.SelectStmt) (p LogicalPlan, err error) {
-	b.pushTableHints(sel.TableHints)
+	if b.hintProcessor != nil {
+		b.pushTableHints(b.hintProcessor.GetCurrentStmtHints(sel))
+	} else {
+		b.pushTableHints(sel.TableHints)
+	}
 	defer func() {
 		// table hints are only visible in the current SELECT statement.
 		b.popTableHints()
@@ -1188,6 +1340,17 @@ func (b *PlanBuilder) buildSelect(ctx context.Context, sel *ast.SelectStmt) (p L
 		b.inStraightJoin = sel.SelectStmtOpts.StraightJoin
 		defer func() { b.inStraightJoin = origin }()
 	}
+	hintInfo := b.TableHints()
+	if hintInfo.straightJoin {
+		origin := b.inStraightJoin
+		b.inStraightJoin = true
+		defer func() { b.inStraightJoin = origin }()
+	}
+	if hintInfo.leadingJoinOrder != nil && b.inStraightJoin {
+		errMsg := "Optimizer Hint LEADING is inapplicable when STRAIGHT_JOIN is specified"
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		hintInfo.leadingJoinOrder = nil
+	}
 
 	var (
 		aggFuncs                      []*ast.AggregateFuncExpr
@@ -1369,6 +1532,30 @@ func (b *PlanBuilder) buildDataSource(ctx context.Context, tn *ast.TableName, as
 	if tblName.L == "" {
 		tblName = tn.Name
 	}
+	var (
+		indexMergeHints []*ast.IndexHint
+		noIndexMerge    bool
+	)
+	if hintInfo := b.TableHints(); hintInfo != nil {
+		hintTable := &hintTableInfo{dbName: dbName, tblName: tblName}
+		// The tables are always read from TiKV, the storage hints are only
+		// checked for conflicts and inapplicable TiFlash.
+		preferTiKV := hintInfo.ifPreferTiKV(hintTable)
+		if hintInfo.ifPreferTiFlash(hintTable) {
+			errMsg := fmt.Sprintf("Storage hint TIFLASH is inapplicable for table %s, the table is read from TiKV since TiFlash is not supported", tblName.O)
+			if preferTiKV {
+				errMsg = fmt.Sprintf("Storage hints are conflict, you can only specify one storage type of table %s", tblName.O)
+			}
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		}
+		for i, hint := range hintInfo.indexMergeHintList {
+			if hint.dbName.L == dbName.L && hint.tblName.L == tblName.L {
+				hintInfo.indexMergeHintList[i].matched = true
+				indexMergeHints = append(indexMergeHints, hint.indexHint)
+			}
+		}
+		noIndexMerge = hintInfo.noIndexMerge
+	}
 	possiblePaths, err := b.getPossibleAccessPaths(tn.IndexHints, tbl, dbName, tblName)
 	if err != nil {
 		return nil, err
@@ -1382,6 +1569,8 @@ func (b *PlanBuilder) buildDataSource(ctx context.Context, tn *ast.TableName, as
 		tableInfo:           tableInfo,
 		statisticTable:      getStatsTable(b.ctx, tbl.Meta(), tbl.Meta().ID),
 		indexHints:          tn.IndexHints,
+		indexMergeHints:     indexMergeHints,
+		noIndexMerge:        noIndexMerge,
 		possibleAccessPaths: possiblePaths,
 		Columns:             make([]*model.ColumnInfo, 0, len(columns)),
 		TblCols:             make([]*expression.Column, 0, len(columns)),
@@ -1454,6 +1643,12 @@ func (b *PlanBuilder) buildDataSource(ctx context.Context, tn *ast.TableName, as
 		return nil, err
 	}
 	if txn.Valid() && !txn.IsReadOnly() {
+		// The index merge reader can't be merged with the buffered modifications.
+		if len(ds.indexMergeHints) > 0 {
+			errMsg := fmt.Sprintf("Optimizer Hint USE_INDEX_MERGE is inapplicable for table %s in a transaction with modifications", tblName.O)
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		}
+		ds.indexMergeHints, ds.noIndexMerge = nil, true
 		us := LogicalUnionScan{handleCol: handleCol}.Init(b.ctx)
 		us.SetChildren(ds)
 		result = us
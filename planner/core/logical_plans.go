@@ -79,6 +79,11 @@ const (
 	preferMergeJoin
 )
 
+const (
+	preferHashAgg uint = 1 << iota
+	preferStreamAgg
+)
+
 // LogicalJoin is the logical join plan.
 type LogicalJoin struct {
 	logicalSchemaProducer
@@ -91,6 +96,9 @@ type LogicalJoin struct {
 	// hintInfo stores the join algorithm hint information specified by client.
 	hintInfo       *tableHintInfo
 	preferJoinType uint
+	// leadingJoinOrder stores the tables specified by the LEADING hint, which
+	// are joined first in order by the join reorder rule.
+	leadingJoinOrder []hintTableInfo
 
 	EqualConditions []*expression.ScalarFunction
 	LeftConditions  expression.CNFExprs
@@ -112,6 +120,12 @@ type LogicalJoin struct {
 	equalCondOutCnt float64
 }
 
+// Shallow shallow copies a LogicalJoin struct.
+func (p *LogicalJoin) Shallow() *LogicalJoin {
+	join := *p
+	return join.Init(p.ctx)
+}
+
 func (p *LogicalJoin) attachOnConds(onConds []expression.Expression) {
 	eq, left, right, other := p.extractOnCondition(onConds, false, false)
 	p.EqualConditions = append(eq, p.EqualConditions...)
@@ -136,6 +150,9 @@ type LogicalAggregation struct {
 	// groupByCols stores the columns that are group-by items.
 	groupByCols []*expression.Column
 
+	// aggHints stores the aggregation hint information specified by client.
+	aggHints aggHintInfo
+
 	possibleProperties [][]*expression.Column
 	inputCount         float64 // inputCount is the input count of this plan.
 }
@@ -212,6 +229,14 @@ type DataSource struct {
 
 	// possibleAccessPaths stores all the possible access path for physical plan, including table scan.
 	possibleAccessPaths []*util.AccessPath
+	// indexMergeHints are the USE_INDEX_MERGE hints of the table, the index
+	// merge paths are preferred to the other paths if they can be built.
+	indexMergeHints []*ast.IndexHint
+	// noIndexMerge indicates the index merge paths are not considered unless
+	// the USE_INDEX_MERGE hint is specified.
+	noIndexMerge bool
+	// indexMergePaths stores the index merge paths generated in DeriveStats.
+	indexMergePaths []*util.AccessPath
 
 	// handleCol represents the handle column for the datasource, either the
 	// int primary key column or extra handle column.
@@ -347,7 +372,8 @@ func (ds *DataSource) Convert2Gathers() (gathers []LogicalPlan) {
 
 // deriveTablePathStats will fulfill the information that the AccessPath need.
 // And it will check whether the primary key is covered only by point query.
-func (ds *DataSource) deriveTablePathStats(path *util.AccessPath, conds []expression.Expression) (bool, error) {
+// isIm indicates whether this function is called to generate the partial path for IndexMerge.
+func (ds *DataSource) deriveTablePathStats(path *util.AccessPath, conds []expression.Expression, isIm bool) (bool, error) {
 	var err error
 	sc := ds.ctx.GetSessionVars().StmtCtx
 	path.CountAfterAccess = float64(ds.statisticTable.Count)
@@ -380,7 +406,7 @@ func (ds *DataSource) deriveTablePathStats(path *util.AccessPath, conds []expres
 	path.CountAfterAccess, err = ds.statisticTable.GetRowCountByIntColumnRanges(sc, pkCol.ID, path.Ranges)
 	// If the `CountAfterAccess` is less than `stats.RowCount`, there must be some inconsistent stats info.
 	// We prefer the `stats.RowCount` because it could use more stats info to calculate the selectivity.
-	if path.CountAfterAccess < ds.stats.RowCount {
+	if path.CountAfterAccess < ds.stats.RowCount && !isIm {
 		path.CountAfterAccess = math.Min(ds.stats.RowCount/selectionFactor, float64(ds.statisticTable.Count))
 	}
 	// Check whether the primary key is covered by point query.
@@ -432,7 +458,8 @@ func (ds *DataSource) fillIndexPath(path *util.AccessPath, conds []expression.Ex
 // And it will check whether this index is full matched by point query. We will use this check to
 // determine whether we remove other paths or not.
 // conds is the conditions used to generate the DetachRangeResult for path.
-func (ds *DataSource) deriveIndexPathStats(path *util.AccessPath) bool {
+// isIm indicates whether this function is called to generate the partial path for IndexMerge.
+func (ds *DataSource) deriveIndexPathStats(path *util.AccessPath, isIm bool) bool {
 	sc := ds.ctx.GetSessionVars().StmtCtx
 	if path.EqOrInCondCount == len(path.AccessConds) {
 		accesses, remained := path.SplitAccessCondFromFilters(path.EqOrInCondCount)
@@ -456,7 +483,7 @@ func (ds *DataSource) deriveIndexPathStats(path *util.AccessPath) bool {
 	path.IndexFilters, path.TableFilters = splitIndexFilterConditions(path.TableFilters, path.FullIdxCols, path.FullIdxColLens, ds.tableInfo)
 	// If the `CountAfterAccess` is less than `stats.RowCount`, there must be some inconsistent stats info.
 	// We prefer the `stats.RowCount` because it could use more stats info to calculate the selectivity.
-	if path.CountAfterAccess < ds.stats.RowCount {
+	if path.CountAfterAccess < ds.stats.RowCount && !isIm {
 		path.CountAfterAccess = math.Min(ds.stats.RowCount/selectionFactor, float64(ds.statisticTable.Count))
 	}
 	if path.IndexFilters != nil {
@@ -465,7 +492,11 @@ func (ds *DataSource) deriveIndexPathStats(path *util.AccessPath) bool {
 			logutil.BgLogger().Debug("calculate selectivity failed, use selection factor", zap.Error(err))
 			selectivity = selectionFactor
 		}
-		path.CountAfterIndex = math.Max(path.CountAfterAccess*selectivity, ds.stats.RowCount)
+		if isIm {
+			path.CountAfterIndex = path.CountAfterAccess * selectivity
+		} else {
+			path.CountAfterIndex = math.Max(path.CountAfterAccess*selectivity, ds.stats.RowCount)
+		}
 	}
 	// Check whether there's only point query.
 	noIntervalRanges := true
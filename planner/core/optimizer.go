@@ -28,6 +28,10 @@ import (
 // OptimizeAstNode optimizes the query to a physical plan directly.
 var OptimizeAstNode func(ctx context.Context, sctx sessionctx.Context, node ast.Node, is infoschema.InfoSchema) (Plan, types.NameSlice, error)
 
+// OptimizeAstNodeWithMemo optimizes the query by the cascades planner, and
+// returns the stringified memo of the optimization as well.
+var OptimizeAstNodeWithMemo func(ctx context.Context, sctx sessionctx.Context, node ast.Node, is infoschema.InfoSchema) (Plan, []string, error)
+
 const (
 	flagPrunColumns uint64 = 1 << iota
 	flagBuildKeyInfo
@@ -89,6 +93,7 @@ func DoOptimize(ctx context.Context, flag uint64, logic LogicalPlan) (PhysicalPl
 func postOptimize(plan PhysicalPlan) PhysicalPlan {
 	plan = eliminatePhysicalProjection(plan)
 	plan = injectExtraProjection(plan)
+	enableRuntimeFilter(plan)
 	return plan
 }
 
@@ -14,6 +14,8 @@
 package core
 
 import (
+	"unsafe"
+
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/expression/aggregation"
 	"github.com/pingcap/tidb/parser/model"
@@ -36,10 +38,13 @@ var (
 	_ PhysicalPlan = &PhysicalTableReader{}
 	_ PhysicalPlan = &PhysicalIndexReader{}
 	_ PhysicalPlan = &PhysicalIndexLookUpReader{}
+	_ PhysicalPlan = &PhysicalIndexMergeReader{}
 	_ PhysicalPlan = &PhysicalHashAgg{}
 	_ PhysicalPlan = &PhysicalHashJoin{}
 	_ PhysicalPlan = &PhysicalMergeJoin{}
 	_ PhysicalPlan = &PhysicalUnionScan{}
+	_ PhysicalPlan = &PhysicalShuffle{}
+	_ PhysicalPlan = &PhysicalShuffleReceiverStub{}
 )
 
 // PhysicalTableReader is the table reader in tidb.
@@ -122,6 +127,25 @@ type PhysicalIndexLookUpReader struct {
 	ExtraHandleCol *expression.Column
 }
 
+// PhysicalIndexMergeReader is the reader using multiple indexes in tidb. The
+// handles read by the partial plans are merged, then the rows are read by the
+// table plan in the same way as PhysicalIndexLookUpReader.
+type PhysicalIndexMergeReader struct {
+	physicalSchemaProducer
+
+	// IsIntersection indicates the handles read by all the partial plans are
+	// intersected, otherwise they are unioned.
+	IsIntersection bool
+	// PartialPlans flats the partialPlans to construct executor pb.
+	PartialPlans [][]PhysicalPlan
+	// TablePlans flats the tablePlan to construct executor pb.
+	TablePlans []PhysicalPlan
+	// partialPlans are the partial plans that have not been flatted. The type of each element is permitted PhysicalIndexScan or PhysicalTableScan,
+	// with an optional PhysicalSelection above it.
+	partialPlans []PhysicalPlan
+	tablePlan    PhysicalPlan
+}
+
 // PhysicalIndexScan represents an index scan plan.
 type PhysicalIndexScan struct {
 	physicalSchemaProducer
@@ -221,6 +245,10 @@ type PhysicalHashJoin struct {
 
 	Concurrency     uint
 	EqualConditions []*expression.ScalarFunction
+
+	// RuntimeFilterKeyIdx is the offsets of the join keys whose build side values are
+	// collected at runtime and pushed down to the probe side table reader as filters.
+	RuntimeFilterKeyIdx []int
 }
 
 // NewPhysicalHashJoin creates a new PhysicalHashJoin from LogicalJoin.
@@ -250,6 +278,35 @@ type PhysicalMergeJoin struct {
 	CompareFuncs []expression.CompareFunc
 }
 
+// PhysicalShuffle represents a shuffle plan. The children of Tail are the data
+// sources, the rows of every data source are split into Concurrency partitions,
+// and a copy of Tail runs on each group of the partitions in parallel. Take the
+// merge join for example: Shuffle -> MergeJoin -> (TableReader, TableReader) is
+// executed as:
+// ==> Shuffle: merges the outputs of the workers, in the main goroutine;
+// ==> MergeJoin -> (ShuffleReceiver, ShuffleReceiver): one for every worker;
+// ==> TableReader: splits its rows to the receivers of the workers, in a fetcher goroutine.
+type PhysicalShuffle struct {
+	basePhysicalPlan
+
+	Concurrency int
+	Tail        PhysicalPlan
+
+	// HashByItemArrays are the hash by items of the data sources, the rows of
+	// a data source are split into the partitions by the hash of them.
+	HashByItemArrays [][]expression.Expression
+}
+
+// PhysicalShuffleReceiverStub represents a receiver stub of PhysicalShuffle, it
+// only exists when the executors of a shuffle worker are being built, and the
+// receiver is actually executed by the shuffleReceiver of the executor package.
+type PhysicalShuffleReceiverStub struct {
+	physicalSchemaProducer
+
+	// Receiver points to the shuffleReceiver.
+	Receiver unsafe.Pointer
+}
+
 // PhysicalLimit is the physical operator of Limit.
 type PhysicalLimit struct {
 	basePhysicalPlan
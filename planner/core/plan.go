@@ -160,6 +160,12 @@ type PhysicalPlan interface {
 
 	// ExplainNormalizedInfo returns operator normalized information for generating digest.
 	ExplainNormalizedInfo() string
+
+	// Cost returns the estimated cost of the plan, including the cost of its children.
+	Cost() float64
+
+	// SetCost sets the estimated cost of the plan.
+	SetCost(cost float64)
 }
 
 type baseLogicalPlan struct {
@@ -181,6 +187,17 @@ type basePhysicalPlan struct {
 	childrenReqProps []*property.PhysicalProperty
 	self             PhysicalPlan
 	children         []PhysicalPlan
+	cost             float64
+}
+
+// Cost implements PhysicalPlan interface.
+func (p *basePhysicalPlan) Cost() float64 {
+	return p.cost
+}
+
+// SetCost implements PhysicalPlan interface.
+func (p *basePhysicalPlan) SetCost(cost float64) {
+	p.cost = cost
 }
 
 // ExplainInfo implements Plan interface.
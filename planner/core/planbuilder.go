@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/charset"
 	"github.com/pingcap/tidb/parser/model"
@@ -35,9 +36,27 @@ import (
 )
 
 type tableHintInfo struct {
-	sortMergeJoinTables []hintTableInfo
-	hashJoinTables      []hintTableInfo
-	indexHintList       []indexHintInfo
+	sortMergeJoinTables       []hintTableInfo
+	hashJoinTables            []hintTableInfo
+	indexNestedLoopJoinTables []hintTableInfo
+	indexHintList             []indexHintInfo
+	tikvTables                []hintTableInfo
+	tiflashTables             []hintTableInfo
+	// indexMergeHintList is the tables and the indexes specified by the
+	// USE_INDEX_MERGE hints, the index merge paths of the tables are preferred.
+	indexMergeHintList []indexHintInfo
+	// leadingJoinOrder is the tables specified by the LEADING hint, they are
+	// joined first in order by the join reorder rule.
+	leadingJoinOrder []hintTableInfo
+	leadingMatched   bool
+	aggHints         aggHintInfo
+	aggHintsMatched  bool
+	// straightJoin indicates the STRAIGHT_JOIN hint is specified, which has the
+	// same effect as SELECT STRAIGHT_JOIN.
+	straightJoin bool
+	// noIndexMerge indicates the NO_INDEX_MERGE hint is specified, the index
+	// merge paths should not be considered in the query block.
+	noIndexMerge bool
 }
 
 type hintTableInfo struct {
@@ -49,7 +68,14 @@ type hintTableInfo struct {
 type indexHintInfo struct {
 	dbName    model.CIStr
 	tblName   model.CIStr
+	hintName  string
 	indexHint *ast.IndexHint
+	matched   bool
+}
+
+type aggHintInfo struct {
+	preferAggType  uint
+	preferAggToCop bool
 }
 
 func tableNames2HintTableInfo(ctx sessionctx.Context, hintTables []ast.HintTable) []hintTableInfo {
@@ -59,7 +85,7 @@ func tableNames2HintTableInfo(ctx sessionctx.Context, hintTables []ast.HintTable
 	hintTableInfos := make([]hintTableInfo, len(hintTables))
 	defaultDBName := model.NewCIStr(ctx.GetSessionVars().CurrentDB)
 	for i, hintTable := range hintTables {
-		tableInfo := hintTableInfo{tblName: hintTable.TableName}
+		tableInfo := hintTableInfo{dbName: hintTable.DBName, tblName: hintTable.TableName}
 		if tableInfo.dbName.L == "" {
 			tableInfo.dbName = defaultDBName
 		}
@@ -76,6 +102,18 @@ func (info *tableHintInfo) ifPreferHashJoin(tableNames ...*hintTableInfo) bool {
 	return info.matchTableName(tableNames, info.hashJoinTables)
 }
 
+func (info *tableHintInfo) ifPreferINLJ(tableNames ...*hintTableInfo) bool {
+	return info.matchTableName(tableNames, info.indexNestedLoopJoinTables)
+}
+
+func (info *tableHintInfo) ifPreferTiKV(tableName *hintTableInfo) bool {
+	return info.matchTableName([]*hintTableInfo{tableName}, info.tikvTables)
+}
+
+func (info *tableHintInfo) ifPreferTiFlash(tableName *hintTableInfo) bool {
+	return info.matchTableName([]*hintTableInfo{tableName}, info.tiflashTables)
+}
+
 // matchTableName checks whether the hint hit the need.
 // Only need either side matches one on the list.
 // Even though you can put 2 tables on the list,
@@ -161,6 +199,8 @@ type PlanBuilder struct {
 	colMapper map[*ast.ColumnNameExpr]int
 
 	tableHintInfo []tableHintInfo
+	// hintProcessor dispatches the hints to the query blocks they take effect in.
+	hintProcessor *BlockHintProcessor
 	// optFlag indicates the flags of the optimizer rules.
 	optFlag uint64
 
@@ -242,6 +282,9 @@ func NewPlanBuilder(sctx sessionctx.Context, is infoschema.InfoSchema) *PlanBuil
 // Build builds the ast node to a Plan.
 func (b *PlanBuilder) Build(ctx context.Context, node ast.Node) (Plan, error) {
 	b.optFlag = flagPrunColumns
+	if b.hintProcessor == nil {
+		b.hintProcessor = NewBlockHintProcessor(b.ctx, node)
+	}
 	switch x := node.(type) {
 	case *ast.AdminStmt:
 		return b.buildAdmin(ctx, x)
@@ -249,6 +292,14 @@ func (b *PlanBuilder) Build(ctx context.Context, node ast.Node) (Plan, error) {
 		return b.buildDelete(ctx, x)
 	case *ast.ExplainStmt:
 		return b.buildExplain(ctx, x)
+	case *ast.TraceStmt:
+		return b.buildTrace(x)
+	case *ast.PlanReplayerStmt:
+		return b.buildPlanReplayer(x), nil
+	case *ast.CreateBindingStmt:
+		return b.buildCreateBindPlan(x)
+	case *ast.DropBindingStmt:
+		return b.buildDropBindPlan(x), nil
 	case *ast.InsertStmt:
 		return b.buildInsert(ctx, x)
 	case *ast.SelectStmt:
@@ -259,7 +310,7 @@ func (b *PlanBuilder) Build(ctx context.Context, node ast.Node) (Plan, error) {
 		return b.buildSet(ctx, x)
 	case *ast.AnalyzeTableStmt:
 		return b.buildAnalyze(x)
-	case *ast.UseStmt, *ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt:
+	case *ast.UseStmt, *ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.KillStmt:
 		return b.buildSimple(node.(ast.StmtNode))
 	case ast.DDLNode:
 		return b.buildDDL(ctx, x)
@@ -357,8 +408,9 @@ func (b *PlanBuilder) getPossibleAccessPaths(indexHints []*ast.IndexHint, tbl ta
 	// Extract comment-style index hint like /*+ INDEX(t, idx1, idx2) */.
 	indexHintsLen := len(indexHints)
 	if hints := b.TableHints(); hints != nil {
-		for _, hint := range hints.indexHintList {
+		for i, hint := range hints.indexHintList {
 			if hint.dbName.L == dbName.L && hint.tblName.L == tblName.L {
+				hints.indexHintList[i].matched = true
 				indexHints = append(indexHints, hint.indexHint)
 			}
 		}
@@ -911,26 +963,113 @@ func (b *PlanBuilder) buildDDL(ctx context.Context, node ast.DDLNode) (Plan, err
 	return p, nil
 }
 
-func (b *PlanBuilder) buildExplainPlan(targetPlan Plan, format string, execStmt ast.StmtNode) (Plan, error) {
+func (b *PlanBuilder) buildExplainPlan(targetPlan Plan, format string, analyze bool, execStmt ast.StmtNode) (Plan, error) {
 	p := &Explain{
 		TargetPlan: targetPlan,
 		Format:     format,
+		Analyze:    analyze,
 		ExecStmt:   execStmt,
 	}
 	p.ctx = b.ctx
 	return p, p.prepareSchema()
 }
 
+// buildTrace builds a trace plan. The traced statement is compiled and executed
+// by the trace executor, so only the schema of the result is built here.
+func (b *PlanBuilder) buildTrace(trace *ast.TraceStmt) (Plan, error) {
+	p := &Trace{StmtNode: trace.Stmt, Format: strings.ToLower(trace.Format)}
+	p.ctx = b.ctx
+	var fieldNames []string
+	switch p.Format {
+	case ast.TraceFormatRow:
+		fieldNames = []string{"operation", "startOffset", "duration"}
+	case ast.TraceFormatJSON:
+		fieldNames = []string{"operation"}
+	default:
+		return nil, errors.Errorf("trace format '%s' is not supported now", trace.Format)
+	}
+	schema := newColumnsWithNames(len(fieldNames))
+	for _, fieldName := range fieldNames {
+		schema.Append(buildColumnWithName("", fieldName, mysql.TypeString, mysql.MaxBlobWidth))
+	}
+	p.setSchemaAndNames(schema.col2Schema(), schema.names)
+	return p, nil
+}
+
+// buildCreateBindPlan builds a plan to create the binding of the original
+// statement. The hinted statement must be the same as the original one
+// except the hints.
+func (b *PlanBuilder) buildCreateBindPlan(v *ast.CreateBindingStmt) (Plan, error) {
+	normdOrigSQL := parser.NormalizeForBinding(v.OriginSel.Text())
+	if normdOrigSQL != parser.NormalizeForBinding(v.HintedSel.Text()) {
+		return nil, ErrInternal.GenWithStack("hinted sql and original sql don't match when hinted sql erase the hint info")
+	}
+	charSet, collation := b.ctx.GetSessionVars().GetCharsetInfo()
+	p := &SQLBindPlan{
+		SQLBindOp:    OpSQLBindCreate,
+		NormdOrigSQL: normdOrigSQL,
+		BindSQL:      v.HintedSel.Text(),
+		IsGlobal:     v.GlobalScope,
+		Db:           b.ctx.GetSessionVars().CurrentDB,
+		Charset:      charSet,
+		Collation:    collation,
+	}
+	return p, nil
+}
+
+// buildDropBindPlan builds a plan to drop the binding of the original statement.
+func (b *PlanBuilder) buildDropBindPlan(v *ast.DropBindingStmt) Plan {
+	p := &SQLBindPlan{
+		SQLBindOp:    OpSQLBindDrop,
+		NormdOrigSQL: parser.NormalizeForBinding(v.OriginSel.Text()),
+		IsGlobal:     v.GlobalScope,
+		Db:           b.ctx.GetSessionVars().CurrentDB,
+	}
+	return p
+}
+
+// buildPlanReplayer builds a plan replayer plan. The dump returns the path of the
+// generated file, and the load returns nothing.
+func (b *PlanBuilder) buildPlanReplayer(pc *ast.PlanReplayerStmt) Plan {
+	p := &PlanReplayer{ExecStmt: pc.Stmt, Analyze: pc.Analyze, Load: pc.Load, File: pc.File}
+	if pc.Load {
+		return p
+	}
+	schema := newColumnsWithNames(1)
+	schema.Append(buildColumnWithName("", "File_token", mysql.TypeVarchar, 128))
+	p.setSchemaAndNames(schema.col2Schema(), schema.names)
+	return p
+}
+
 func (b *PlanBuilder) buildExplain(ctx context.Context, explain *ast.ExplainStmt) (Plan, error) {
 	if show, ok := explain.Stmt.(*ast.ShowStmt); ok {
 		return b.buildShow(ctx, show)
 	}
+	if strings.ToLower(explain.Format) == ast.ExplainFormatMemo {
+		return b.buildExplainMemo(ctx, explain)
+	}
 	targetPlan, _, err := OptimizeAstNode(ctx, b.ctx, explain.Stmt, b.is)
 	if err != nil {
 		return nil, err
 	}
 
-	return b.buildExplainPlan(targetPlan, explain.Format, explain.Stmt)
+	return b.buildExplainPlan(targetPlan, explain.Format, explain.Analyze, explain.Stmt)
+}
+
+// buildExplainMemo builds the explain plan which shows the memo of the cascades
+// planner, the statement is optimized by the cascades planner even if it is
+// not enabled in the session.
+func (b *PlanBuilder) buildExplainMemo(ctx context.Context, explain *ast.ExplainStmt) (Plan, error) {
+	targetPlan, memo, err := OptimizeAstNodeWithMemo(ctx, b.ctx, explain.Stmt, b.is)
+	if err != nil {
+		return nil, err
+	}
+	p, err := b.buildExplainPlan(targetPlan, explain.Format, explain.Analyze, explain.Stmt)
+	if err != nil {
+		return nil, err
+	}
+	p.(*Explain).Memo = memo
+	return p, nil
 }
 
 func buildShowWarningsSchema() (*expression.Schema, types.NameSlice) {
@@ -962,6 +1101,16 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema, outputNames []
 		names = []string{"Table", "Create Table"}
 	case ast.ShowCreateDatabase:
 		names = []string{"Database", "Create Database"}
+	case ast.ShowUnusedIndexes:
+		names = []string{"Table_schema", "Table_name", "Index_name"}
+	case ast.ShowBindings:
+		names = []string{"Original_sql", "Bind_sql", "Default_db", "Status", "Create_time", "Update_time", "Charset", "Collation", "Use_count", "Last_used_time"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar}
+	case ast.ShowProcessList:
+		names = []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info", "Mem", "Max_mem"}
+		ftypes = []byte{mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar,
+			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLong, mysql.TypeVarchar, mysql.TypeString,
+			mysql.TypeLonglong, mysql.TypeLonglong}
 	}
 
 	schema = expression.NewSchema(make([]*expression.Column, 0, len(names))...)
@@ -0,0 +1,518 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/planner/property"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/types"
+	driver "github.com/pingcap/tidb/types/parser_driver"
+)
+
+// PointGetPlan is a fast plan for simple point get.
+// When we detect that the statement has a unique equal access condition, this plan is used.
+// This plan is much faster to build and to execute because it avoids the optimization and coprocessor cost.
+type PointGetPlan struct {
+	physicalSchemaProducer
+
+	DBName      model.CIStr
+	TableAsName *model.CIStr
+	TblInfo     *model.TableInfo
+	// IndexInfo is nil if the row is read by the handle.
+	IndexInfo   *model.IndexInfo
+	Handle      int64
+	IndexValues []types.Datum
+	// Columns are the columns to read, they are in the same order as the schema.
+	Columns []*model.ColumnInfo
+
+	names []*types.FieldName
+}
+
+// BatchPointGetPlan is a fast plan for the point gets of several handles or
+// unique index values, which reads all the rows in one batch.
+type BatchPointGetPlan struct {
+	physicalSchemaProducer
+
+	DBName      model.CIStr
+	TableAsName *model.CIStr
+	TblInfo     *model.TableInfo
+	// IndexInfo is nil if the rows are read by the handles.
+	IndexInfo   *model.IndexInfo
+	Handles     []int64
+	IndexValues [][]types.Datum
+	// Columns are the columns to read, they are in the same order as the schema.
+	Columns []*model.ColumnInfo
+
+	names []*types.FieldName
+}
+
+// OutputNames returns the outputting names of each column.
+func (p *PointGetPlan) OutputNames() types.NameSlice {
+	return p.names
+}
+
+// SetOutputNames sets the outputting name by the given slice.
+func (p *PointGetPlan) SetOutputNames(names types.NameSlice) {
+	p.names = names
+}
+
+// ExplainInfo implements Plan interface.
+func (p *PointGetPlan) ExplainInfo() string {
+	buffer := bytes.NewBufferString("table:" + scanTableName(p.TblInfo, p.TableAsName))
+	if p.IndexInfo == nil {
+		fmt.Fprintf(buffer, ", handle:%d", p.Handle)
+	} else {
+		fmt.Fprintf(buffer, ", index:%s, value:%s", p.IndexInfo.Name.O, types.DatumsToStrNoErr(p.IndexValues))
+	}
+	return buffer.String()
+}
+
+// OutputNames returns the outputting names of each column.
+func (p *BatchPointGetPlan) OutputNames() types.NameSlice {
+	return p.names
+}
+
+// SetOutputNames sets the outputting name by the given slice.
+func (p *BatchPointGetPlan) SetOutputNames(names types.NameSlice) {
+	p.names = names
+}
+
+// ExplainInfo implements Plan interface.
+func (p *BatchPointGetPlan) ExplainInfo() string {
+	buffer := bytes.NewBufferString("table:" + scanTableName(p.TblInfo, p.TableAsName))
+	if p.IndexInfo == nil {
+		buffer.WriteString(", handles:")
+		for i, handle := range p.Handles {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			fmt.Fprintf(buffer, "%d", handle)
+		}
+	} else {
+		fmt.Fprintf(buffer, ", index:%s, values:", p.IndexInfo.Name.O)
+		for i, values := range p.IndexValues {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			buffer.WriteString(types.DatumsToStrNoErr(values))
+		}
+	}
+	return buffer.String()
+}
+
+// TryFastPlan tries to use the PointGetPlan or the BatchPointGetPlan for the
+// query, it returns nil if the query is not a point get on the primary key or
+// a unique index, then the query should be optimized as usual.
+func TryFastPlan(ctx sessionctx.Context, is infoschema.InfoSchema, node ast.Node) Plan {
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		if fp := tryPointGetPlan(ctx, is, x, false); fp != nil {
+			return fp
+		}
+	case *ast.DeleteStmt:
+		return tryDeletePointPlan(ctx, is, x)
+	}
+	return nil
+}
+
+// tryPointGetPlan builds the fast plan for a query of a single table whose
+// condition is the equal conditions on the handle or a unique index, or the
+// IN condition on the handle or a single column unique index. The handle
+// column is appended to the schema if needHandle is set.
+func tryPointGetPlan(ctx sessionctx.Context, is infoschema.InfoSchema, sel *ast.SelectStmt, needHandle bool) PhysicalPlan {
+	if sel.Where == nil || sel.Distinct || sel.GroupBy != nil || sel.Having != nil ||
+		sel.OrderBy != nil || sel.Limit != nil || len(sel.TableHints) > 0 {
+		return nil
+	}
+	tn, tblAsName := getSingleTableNameAndAlias(sel.From)
+	// The fast plan doesn't take effect on the index hints.
+	if tn == nil || len(tn.IndexHints) > 0 {
+		return nil
+	}
+	dbName := tn.Schema
+	if dbName.L == "" {
+		dbName = model.NewCIStr(ctx.GetSessionVars().CurrentDB)
+	}
+	tbl, err := is.TableByName(dbName, tn.Name)
+	if err != nil || tbl.Type().IsVirtualTable() {
+		return nil
+	}
+	tblName := tn.Name
+	if tblAsName.L != "" {
+		tblName = tblAsName
+	}
+	schema, names, columns := buildPointGetSchema(ctx, dbName, tbl, tblName, sel.Fields.Fields, needHandle)
+	if schema == nil {
+		return nil
+	}
+
+	var asName *model.CIStr
+	if tblAsName.L != "" {
+		asName = &tblAsName
+	}
+	if in, ok := sel.Where.(*ast.PatternInExpr); ok {
+		p := newBatchPointGetPlan(ctx, tbl.Meta(), tblName, in)
+		if p == nil {
+			return nil
+		}
+		p.DBName, p.TableAsName, p.Columns = dbName, asName, columns
+		p.schema, p.names = schema, names
+		p.stats = &property.StatsInfo{RowCount: float64(len(p.Handles) + len(p.IndexValues))}
+		return p
+	}
+	p := newPointGetPlan(ctx, tbl.Meta(), tblName, sel.Where)
+	if p == nil {
+		return nil
+	}
+	p.DBName, p.TableAsName, p.Columns = dbName, asName, columns
+	p.schema, p.names = schema, names
+	p.stats = &property.StatsInfo{RowCount: 1}
+	return p
+}
+
+// tryDeletePointPlan builds the DELETE plan whose SELECT plan is the fast plan.
+func tryDeletePointPlan(ctx sessionctx.Context, is infoschema.InfoSchema, delStmt *ast.DeleteStmt) Plan {
+	if delStmt.Order != nil || delStmt.Limit != nil {
+		return nil
+	}
+	sel := &ast.SelectStmt{
+		Fields: &ast.FieldList{Fields: []*ast.SelectField{{WildCard: &ast.WildCardField{}}}},
+		From:   delStmt.TableRefs,
+		Where:  delStmt.Where,
+	}
+	fp := tryPointGetPlan(ctx, is, sel, true)
+	if fp == nil {
+		return nil
+	}
+	var tblInfo *model.TableInfo
+	switch x := fp.(type) {
+	case *PointGetPlan:
+		tblInfo = x.TblInfo
+	case *BatchPointGetPlan:
+		tblInfo = x.TblInfo
+	}
+	handleOrdinal := fp.Schema().Len() - 1
+	if tblInfo.PKIsHandle {
+		for i, col := range fp.Schema().Columns {
+			if col.ID != model.ExtraHandleID && mysql.HasPriKeyFlag(col.RetType.Flag) {
+				handleOrdinal = i
+				break
+			}
+		}
+	}
+	del := Delete{
+		SelectPlan: fp,
+		TblColPosInfos: TblColPosInfoSlice{
+			{TblID: tblInfo.ID, Start: 0, End: len(fp.OutputNames()), HandleOrdinal: handleOrdinal},
+		},
+	}.Init(ctx)
+	del.names = fp.OutputNames()
+	if !tblInfo.PKIsHandle {
+		// The extra handle column is not a column of the table.
+		del.TblColPosInfos[0].End--
+	}
+	return del
+}
+
+// getSingleTableNameAndAlias returns the table name and its alias if the FROM
+// clause reads a single table.
+func getSingleTableNameAndAlias(from *ast.TableRefsClause) (*ast.TableName, model.CIStr) {
+	if from == nil || from.TableRefs == nil || from.TableRefs.Right != nil {
+		return nil, model.CIStr{}
+	}
+	tblSrc, ok := from.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return nil, model.CIStr{}
+	}
+	tn, ok := tblSrc.Source.(*ast.TableName)
+	if !ok {
+		return nil, model.CIStr{}
+	}
+	return tn, tblSrc.AsName
+}
+
+// buildPointGetSchema builds the schema of the fast plan, only the wildcard
+// and the column names are supported in the field list.
+func buildPointGetSchema(ctx sessionctx.Context, dbName model.CIStr, tbl table.Table, tblName model.CIStr, fields []*ast.SelectField, needHandle bool) (*expression.Schema, types.NameSlice, []*model.ColumnInfo) {
+	tblInfo := tbl.Meta()
+	schema := expression.NewSchema()
+	names := make(types.NameSlice, 0, len(fields))
+	columns := make([]*model.ColumnInfo, 0, len(fields))
+	appendColumn := func(colInfo *model.ColumnInfo, colName model.CIStr) {
+		name := &types.FieldName{
+			DBName:      dbName,
+			OrigTblName: tblInfo.Name,
+			TblName:     tblName,
+			OrigColName: colInfo.Name,
+			ColName:     colName,
+		}
+		col := &expression.Column{
+			UniqueID: ctx.GetSessionVars().AllocPlanColumnID(),
+			ID:       colInfo.ID,
+			RetType:  &colInfo.FieldType,
+			OrigName: name.String(),
+		}
+		if colInfo.ID == model.ExtraHandleID {
+			col.RetType = types.NewFieldType(mysql.TypeLonglong)
+		}
+		schema.Append(col)
+		names = append(names, name)
+		columns = append(columns, colInfo)
+	}
+	for _, field := range fields {
+		if field.WildCard != nil {
+			if (field.WildCard.Schema.L != "" && field.WildCard.Schema.L != dbName.L) ||
+				(field.WildCard.Table.L != "" && field.WildCard.Table.L != tblName.L) {
+				return nil, nil, nil
+			}
+			for _, col := range tbl.Cols() {
+				appendColumn(col.ColumnInfo, col.Name)
+			}
+			continue
+		}
+		colNameExpr, ok := field.Expr.(*ast.ColumnNameExpr)
+		if !ok || !matchTableName(colNameExpr.Name, dbName, tblName) {
+			return nil, nil, nil
+		}
+		col := table.FindCol(tbl.Cols(), colNameExpr.Name.Name.L)
+		if col == nil {
+			return nil, nil, nil
+		}
+		colName := colNameExpr.Name.Name
+		if field.AsName.L != "" {
+			colName = field.AsName
+		}
+		appendColumn(col.ColumnInfo, colName)
+	}
+	if needHandle && !tblInfo.PKIsHandle {
+		appendColumn(model.NewExtraHandleColInfo(), model.ExtraHandleName)
+	}
+	return schema, names, columns
+}
+
+func matchTableName(name *ast.ColumnName, dbName, tblName model.CIStr) bool {
+	return (name.Schema.L == "" || name.Schema.L == dbName.L) && (name.Table.L == "" || name.Table.L == tblName.L)
+}
+
+// newPointGetPlan builds the PointGetPlan if the condition is the equal
+// condition on the handle, or the equal conditions on all the columns of a
+// unique index.
+func newPointGetPlan(ctx sessionctx.Context, tblInfo *model.TableInfo, tblName model.CIStr, where ast.ExprNode) *PointGetPlan {
+	pairs := getNameValuePairs(nil, tblName, where)
+	if len(pairs) == 0 {
+		return nil
+	}
+	sc := &stmtctx.StatementContext{TimeZone: ctx.GetSessionVars().Location()}
+	if pkCol := getPKIsHandleColInfo(tblInfo); pkCol != nil && len(pairs) == 1 && pairs[0].colName == pkCol.Name.L {
+		handle, ok := convertHandleValue(sc, pkCol, pairs[0].value)
+		if !ok {
+			return nil
+		}
+		return PointGetPlan{TblInfo: tblInfo, Handle: handle}.Init(ctx)
+	}
+	for _, idxInfo := range tblInfo.Indices {
+		if !isFastPlanIndex(idxInfo) || len(idxInfo.Columns) != len(pairs) {
+			continue
+		}
+		values := make([]types.Datum, 0, len(idxInfo.Columns))
+		for _, idxCol := range idxInfo.Columns {
+			value, ok := findPairValue(pairs, idxCol.Name.L)
+			if !ok {
+				break
+			}
+			d, ok := convertIndexValue(sc, tblInfo.Columns[idxCol.Offset], value)
+			if !ok {
+				return nil
+			}
+			values = append(values, d)
+		}
+		if len(values) == len(idxInfo.Columns) {
+			return PointGetPlan{TblInfo: tblInfo, IndexInfo: idxInfo, IndexValues: values}.Init(ctx)
+		}
+	}
+	return nil
+}
+
+// newBatchPointGetPlan builds the BatchPointGetPlan if the condition is the
+// IN condition on the handle or a single column unique index.
+func newBatchPointGetPlan(ctx sessionctx.Context, tblInfo *model.TableInfo, tblName model.CIStr, in *ast.PatternInExpr) *BatchPointGetPlan {
+	colNameExpr, ok := in.Expr.(*ast.ColumnNameExpr)
+	if in.Not || !ok || len(in.List) == 0 || colNameExpr.Name.Schema.L != "" ||
+		(colNameExpr.Name.Table.L != "" && colNameExpr.Name.Table.L != tblName.L) {
+		return nil
+	}
+	values := make([]types.Datum, 0, len(in.List))
+	for _, item := range in.List {
+		v, ok := item.(*driver.ValueExpr)
+		if !ok {
+			return nil
+		}
+		values = append(values, v.Datum)
+	}
+	colName := colNameExpr.Name.Name.L
+	sc := &stmtctx.StatementContext{TimeZone: ctx.GetSessionVars().Location()}
+	if pkCol := getPKIsHandleColInfo(tblInfo); pkCol != nil && pkCol.Name.L == colName {
+		handles := make([]int64, 0, len(values))
+		for _, value := range values {
+			handle, ok := convertHandleValue(sc, pkCol, value)
+			if !ok {
+				return nil
+			}
+			handles = append(handles, handle)
+		}
+		return BatchPointGetPlan{TblInfo: tblInfo, Handles: handles}.Init(ctx)
+	}
+	for _, idxInfo := range tblInfo.Indices {
+		if !isFastPlanIndex(idxInfo) || len(idxInfo.Columns) != 1 || idxInfo.Columns[0].Name.L != colName {
+			continue
+		}
+		indexValues := make([][]types.Datum, 0, len(values))
+		for _, value := range values {
+			d, ok := convertIndexValue(sc, tblInfo.Columns[idxInfo.Columns[0].Offset], value)
+			if !ok {
+				return nil
+			}
+			indexValues = append(indexValues, []types.Datum{d})
+		}
+		return BatchPointGetPlan{TblInfo: tblInfo, IndexInfo: idxInfo, IndexValues: indexValues}.Init(ctx)
+	}
+	return nil
+}
+
+type nameValuePair struct {
+	colName string
+	value   types.Datum
+}
+
+// getNameValuePairs extracts the `column = constant` conditions which are
+// connected by AND, nil is returned if there is any other condition.
+func getNameValuePairs(nvPairs []nameValuePair, tblName model.CIStr, expr ast.ExprNode) []nameValuePair {
+	binOp, ok := expr.(*ast.BinaryOperationExpr)
+	if !ok {
+		return nil
+	}
+	if binOp.Op == opcode.LogicAnd {
+		nvPairs = getNameValuePairs(nvPairs, tblName, binOp.L)
+		if nvPairs == nil {
+			return nil
+		}
+		return getNameValuePairs(nvPairs, tblName, binOp.R)
+	}
+	if binOp.Op != opcode.EQ {
+		return nil
+	}
+	colNameExpr, ok := binOp.L.(*ast.ColumnNameExpr)
+	valueExpr, ok1 := binOp.R.(*driver.ValueExpr)
+	if !ok || !ok1 {
+		colNameExpr, ok = binOp.R.(*ast.ColumnNameExpr)
+		valueExpr, ok1 = binOp.L.(*driver.ValueExpr)
+		if !ok || !ok1 {
+			return nil
+		}
+	}
+	if colNameExpr.Name.Schema.L != "" || (colNameExpr.Name.Table.L != "" && colNameExpr.Name.Table.L != tblName.L) {
+		return nil
+	}
+	return append(nvPairs, nameValuePair{colName: colNameExpr.Name.Name.L, value: valueExpr.Datum})
+}
+
+func findPairValue(pairs []nameValuePair, colName string) (types.Datum, bool) {
+	for _, pair := range pairs {
+		if pair.colName == colName {
+			return pair.value, true
+		}
+	}
+	return types.Datum{}, false
+}
+
+func getPKIsHandleColInfo(tblInfo *model.TableInfo) *model.ColumnInfo {
+	if !tblInfo.PKIsHandle {
+		return nil
+	}
+	for _, col := range tblInfo.Columns {
+		if mysql.HasPriKeyFlag(col.Flag) {
+			return col
+		}
+	}
+	return nil
+}
+
+// isFastPlanIndex checks whether the index can be used by the fast plan, the
+// prefix indexes are not used since they are not unique on the whole value.
+func isFastPlanIndex(idxInfo *model.IndexInfo) bool {
+	if !idxInfo.Unique || idxInfo.State != model.StatePublic {
+		return false
+	}
+	for _, idxCol := range idxInfo.Columns {
+		if idxCol.Length != types.UnspecifiedLength {
+			return false
+		}
+	}
+	return true
+}
+
+// convertHandleValue converts the value to the handle. It fails if the value
+// can't be converted to the type of the handle column exactly, then the query
+// is optimized as usual to take care of the conversion.
+func convertHandleValue(sc *stmtctx.StatementContext, pkCol *model.ColumnInfo, value types.Datum) (int64, bool) {
+	d, ok := convertIndexValue(sc, pkCol, value)
+	if !ok {
+		return 0, false
+	}
+	if d.Kind() == types.KindUint64 {
+		return int64(d.GetUint64()), true
+	}
+	return d.GetInt64(), true
+}
+
+// convertIndexValue converts the value to the type of the column. Only the
+// integer columns and the varchar columns are supported, since the values of
+// the other types may be equal to the constant without being converted from
+// it exactly. It fails if the value is NULL or can't be converted exactly, the
+// truncation is reported as an error since sc doesn't ignore it.
+func convertIndexValue(sc *stmtctx.StatementContext, col *model.ColumnInfo, value types.Datum) (types.Datum, bool) {
+	switch col.Tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		if value.Kind() != types.KindInt64 && value.Kind() != types.KindUint64 && value.Kind() != types.KindString {
+			return types.Datum{}, false
+		}
+	case mysql.TypeVarchar, mysql.TypeVarString:
+		// The string is compared with a number as a number.
+		if value.Kind() != types.KindString {
+			return types.Datum{}, false
+		}
+	default:
+		return types.Datum{}, false
+	}
+	d, err := value.ConvertTo(sc, &col.FieldType)
+	if err != nil {
+		return types.Datum{}, false
+	}
+	cmp, err := d.CompareDatum(sc, &value)
+	if err != nil || cmp != 0 {
+		return types.Datum{}, false
+	}
+	return d, true
+}
@@ -113,6 +113,23 @@ func (p *PhysicalHashJoin) ResolveIndices() (err error) {
 	return
 }
 
+// ResolveIndices implements Plan interface.
+func (p *PhysicalShuffle) ResolveIndices() (err error) {
+	err = p.basePhysicalPlan.ResolveIndices()
+	if err != nil {
+		return err
+	}
+	for i, dataSource := range p.Tail.Children() {
+		for j, item := range p.HashByItemArrays[i] {
+			p.HashByItemArrays[i][j], err = item.ResolveIndices(dataSource.Schema())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return
+}
+
 // ResolveIndices implements Plan interface.
 func (p *PhysicalMergeJoin) ResolveIndices() (err error) {
 	err = p.physicalSchemaProducer.ResolveIndices()
@@ -221,6 +238,21 @@ func (p *PhysicalIndexLookUpReader) ResolveIndices() (err error) {
 	return
 }
 
+// ResolveIndices implements Plan interface.
+func (p *PhysicalIndexMergeReader) ResolveIndices() (err error) {
+	err = p.tablePlan.ResolveIndices()
+	if err != nil {
+		return err
+	}
+	for _, partialPlan := range p.partialPlans {
+		err = partialPlan.ResolveIndices()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ResolveIndices implements Plan interface.
 func (p *PhysicalSelection) ResolveIndices() (err error) {
 	err = p.basePhysicalPlan.ResolveIndices()
@@ -45,33 +45,35 @@ func (a *aggregationEliminateChecker) tryToEliminateAggregation(agg *LogicalAggr
 	}
 	if coveredByUniqueKey {
 		// GroupByCols has unique key, so this aggregation can be removed.
-		proj := a.convertAggToProj(agg)
+		proj := ConvertAggToProj(agg, agg.schema)
 		proj.SetChildren(agg.children[0])
 		return proj
 	}
 	return nil
 }
 
-func (a *aggregationEliminateChecker) convertAggToProj(agg *LogicalAggregation) *LogicalProjection {
+// ConvertAggToProj converts the aggregation which is grouped by unique key to a projection with the given schema.
+// The aggregate functions must be the ones supported by rewriteExpr.
+func ConvertAggToProj(agg *LogicalAggregation, schema *expression.Schema) *LogicalProjection {
 	proj := LogicalProjection{
 		Exprs: make([]expression.Expression, 0, len(agg.AggFuncs)),
 	}.Init(agg.ctx)
 	for _, fun := range agg.AggFuncs {
-		expr := a.rewriteExpr(agg.ctx, fun)
+		expr := rewriteExpr(agg.ctx, fun)
 		proj.Exprs = append(proj.Exprs, expr)
 	}
-	proj.SetSchema(agg.schema.Clone())
+	proj.SetSchema(schema.Clone())
 	return proj
 }
 
 // rewriteExpr will rewrite the aggregate function to expression doesn't contain aggregate function.
-func (a *aggregationEliminateChecker) rewriteExpr(ctx sessionctx.Context, aggFunc *aggregation.AggFuncDesc) expression.Expression {
+func rewriteExpr(ctx sessionctx.Context, aggFunc *aggregation.AggFuncDesc) expression.Expression {
 	switch aggFunc.Name {
 	case ast.AggFuncCount:
 		if aggFunc.Mode == aggregation.FinalMode {
 			return aggFunc.Args[0]
 		}
-		return a.rewriteCount(ctx, aggFunc.Args, aggFunc.RetTp)
+		return rewriteCount(ctx, aggFunc.Args, aggFunc.RetTp)
 	case ast.AggFuncSum, ast.AggFuncAvg, ast.AggFuncFirstRow, ast.AggFuncMax, ast.AggFuncMin:
 		return aggFunc.Args[0]
 	default:
@@ -79,7 +81,7 @@ func (a *aggregationEliminateChecker) rewriteExpr(ctx sessionctx.Context, aggFun
 	}
 }
 
-func (a *aggregationEliminateChecker) rewriteCount(ctx sessionctx.Context, exprs []expression.Expression, targetTp *types.FieldType) expression.Expression {
+func rewriteCount(ctx sessionctx.Context, exprs []expression.Expression, targetTp *types.FieldType) expression.Expression {
 	// If is count(expr), we will change it to if(isnull(expr), 0, 1).
 	// If is count(distinct x, y, z) we will change it to if(isnull(x) or isnull(y) or isnull(z), 0, 1).
 	// If is count(expr not null), we will change it to constant 1.
@@ -65,14 +65,13 @@ func (a *aggregationPushDownSolver) getAggFuncChildIdx(aggFunc *aggregation.AggF
 // collectAggFuncs collects all aggregate functions and splits them into two parts: "leftAggFuncs" and "rightAggFuncs" whose
 // arguments are all from left child or right child separately. If some aggregate functions have the arguments that have
 // columns both from left and right children, the whole aggregation is forbidden to push down.
-func (a *aggregationPushDownSolver) collectAggFuncs(agg *LogicalAggregation, join *LogicalJoin) (valid bool, leftAggFuncs, rightAggFuncs []*aggregation.AggFuncDesc) {
+func (a *aggregationPushDownSolver) collectAggFuncs(agg *LogicalAggregation, leftSchema *expression.Schema) (valid bool, leftAggFuncs, rightAggFuncs []*aggregation.AggFuncDesc) {
 	valid = true
-	leftChild := join.children[0]
 	for _, aggFunc := range agg.AggFuncs {
 		if !a.isDecomposable(aggFunc) {
 			return false, nil, nil
 		}
-		index := a.getAggFuncChildIdx(aggFunc, leftChild.Schema())
+		index := a.getAggFuncChildIdx(aggFunc, leftSchema)
 		switch index {
 		case 0:
 			leftAggFuncs = append(leftAggFuncs, aggFunc)
@@ -90,13 +89,12 @@ func (a *aggregationPushDownSolver) collectAggFuncs(agg *LogicalAggregation, joi
 // query should be "SELECT SUM(B.agg) FROM A, (SELECT SUM(id) as agg, c1, c2, c3 FROM B GROUP BY id, c1, c2, c3) as B
 // WHERE A.c1 = B.c1 AND A.c2 != B.c2 GROUP BY B.c3". As you see, all the columns appearing in join-conditions should be
 // treated as group by columns in join subquery.
-func (a *aggregationPushDownSolver) collectGbyCols(agg *LogicalAggregation, join *LogicalJoin) (leftGbyCols, rightGbyCols []*expression.Column) {
-	leftChild := join.children[0]
+func (a *aggregationPushDownSolver) collectGbyCols(agg *LogicalAggregation, join *LogicalJoin, leftSchema *expression.Schema) (leftGbyCols, rightGbyCols []*expression.Column) {
 	ctx := agg.ctx
 	for _, gbyExpr := range agg.GroupByItems {
 		cols := expression.ExtractColumns(gbyExpr)
 		for _, col := range cols {
-			if leftChild.Schema().Contains(col) {
+			if leftSchema.Contains(col) {
 				leftGbyCols = append(leftGbyCols, col)
 			} else {
 				rightGbyCols = append(rightGbyCols, col)
@@ -119,7 +117,7 @@ func (a *aggregationPushDownSolver) collectGbyCols(agg *LogicalAggregation, join
 	for _, otherCond := range join.OtherConditions {
 		cols := expression.ExtractColumns(otherCond)
 		for _, col := range cols {
-			if leftChild.Schema().Contains(col) {
+			if leftSchema.Contains(col) {
 				leftGbyCols = a.addGbyCol(ctx, leftGbyCols, col)
 			} else {
 				rightGbyCols = a.addGbyCol(ctx, rightGbyCols, col)
@@ -129,17 +127,27 @@ func (a *aggregationPushDownSolver) collectGbyCols(agg *LogicalAggregation, join
 	return
 }
 
-func (a *aggregationPushDownSolver) splitAggFuncsAndGbyCols(agg *LogicalAggregation, join *LogicalJoin) (valid bool,
+func (a *aggregationPushDownSolver) splitAggFuncsAndGbyCols(agg *LogicalAggregation, join *LogicalJoin, leftSchema *expression.Schema) (valid bool,
 	leftAggFuncs, rightAggFuncs []*aggregation.AggFuncDesc,
 	leftGbyCols, rightGbyCols []*expression.Column) {
-	valid, leftAggFuncs, rightAggFuncs = a.collectAggFuncs(agg, join)
+	valid, leftAggFuncs, rightAggFuncs = a.collectAggFuncs(agg, leftSchema)
 	if !valid {
 		return
 	}
-	leftGbyCols, rightGbyCols = a.collectGbyCols(agg, join)
+	leftGbyCols, rightGbyCols = a.collectGbyCols(agg, join, leftSchema)
 	return
 }
 
+// SplitAggFuncsAndGbyCols splits the aggregate functions and the group by columns of the aggregation above the join
+// into the parts of the left child and the right child, the schema of the left child is leftSchema. It's used by the
+// cascades planner, in which the children of the join are groups rather than logical plans.
+func SplitAggFuncsAndGbyCols(agg *LogicalAggregation, join *LogicalJoin, leftSchema *expression.Schema) (valid bool,
+	leftAggFuncs, rightAggFuncs []*aggregation.AggFuncDesc,
+	leftGbyCols, rightGbyCols []*expression.Column) {
+	a := &aggregationPushDownSolver{}
+	return a.splitAggFuncsAndGbyCols(agg, join, leftSchema)
+}
+
 // addGbyCol adds a column to gbyCols. If a group by column has existed, it will not be added repeatedly.
 func (a *aggregationPushDownSolver) addGbyCol(ctx sessionctx.Context, gbyCols []*expression.Column, cols ...*expression.Column) []*expression.Column {
 	for _, c := range cols {
@@ -183,24 +191,37 @@ func (a *aggregationPushDownSolver) decompose(ctx sessionctx.Context, aggFunc *a
 // If the pushed aggregation is grouped by unique key, it's no need to push it down.
 func (a *aggregationPushDownSolver) tryToPushDownAgg(aggFuncs []*aggregation.AggFuncDesc, gbyCols []*expression.Column, join *LogicalJoin, childIdx int) (_ LogicalPlan, err error) {
 	child := join.children[childIdx]
-	if aggregation.IsAllFirstRow(aggFuncs) {
-		return child, nil
-	}
 	// If the join is multiway-join, we forbid pushing down.
 	if _, ok := join.children[childIdx].(*LogicalJoin); ok {
 		return child, nil
 	}
+	agg, err := a.makePushedDownAgg(aggFuncs, gbyCols, join, childIdx, child.Schema())
+	if err != nil {
+		return nil, err
+	}
+	if agg == nil {
+		return child, nil
+	}
+	agg.SetChildren(child)
+	return agg, nil
+}
+
+// makePushedDownAgg makes the aggregation which is pushed down to the child of the join, the schema of the child is
+// childSchema. It returns nil if the aggregation is not worth or not able to be pushed down.
+func (a *aggregationPushDownSolver) makePushedDownAgg(aggFuncs []*aggregation.AggFuncDesc, gbyCols []*expression.Column, join *LogicalJoin, childIdx int, childSchema *expression.Schema) (*LogicalAggregation, error) {
+	if aggregation.IsAllFirstRow(aggFuncs) {
+		return nil, nil
+	}
 	tmpSchema := expression.NewSchema(gbyCols...)
-	for _, key := range child.Schema().Keys {
+	for _, key := range childSchema.Keys {
 		if tmpSchema.ColumnsIndices(key) != nil {
-			return child, nil
+			return nil, nil
 		}
 	}
 	agg, err := a.makeNewAgg(join.ctx, aggFuncs, gbyCols)
 	if err != nil {
 		return nil, err
 	}
-	agg.SetChildren(child)
 	// If agg has no group-by item, it will return a default value, which may cause some bugs.
 	// So here we add a group-by item forcely.
 	if len(agg.GroupByItems) == 0 {
@@ -210,18 +231,34 @@ func (a *aggregationPushDownSolver) tryToPushDownAgg(aggFuncs []*aggregation.Agg
 	}
 	if (childIdx == 0 && join.JoinType == RightOuterJoin) || (childIdx == 1 && join.JoinType == LeftOuterJoin) {
 		var existsDefaultValues bool
-		join.DefaultValues, existsDefaultValues = a.getDefaultValues(agg)
+		join.DefaultValues, existsDefaultValues = a.getDefaultValues(agg, childSchema)
 		if !existsDefaultValues {
-			return child, nil
+			return nil, nil
 		}
 	}
 	return agg, nil
 }
 
-func (a *aggregationPushDownSolver) getDefaultValues(agg *LogicalAggregation) ([]types.Datum, bool) {
+// PushDownAggToJoinChild makes the aggregation which is pushed down to the childIdx-th child of the join for the
+// cascades planner, the schema of the child is childSchema, whose unique keys should have been built. The aggFuncs
+// are converted to the final mode ones on the pushed down aggregation, so they should belong to a newly created
+// aggregation above the join. It returns nil if the aggregation is not worth or not able to be pushed down.
+func PushDownAggToJoinChild(aggFuncs []*aggregation.AggFuncDesc, gbyCols []*expression.Column, join *LogicalJoin, childIdx int, childSchema *expression.Schema) (*LogicalAggregation, error) {
+	a := &aggregationPushDownSolver{}
+	return a.makePushedDownAgg(aggFuncs, gbyCols, join, childIdx, childSchema)
+}
+
+// CheckAnyCountAndSum checks whether there is any count or sum function in the aggFuncs. If there exist count or sum
+// functions in one child of the join, no aggregate function can be pushed down to the other child.
+func CheckAnyCountAndSum(aggFuncs []*aggregation.AggFuncDesc) bool {
+	a := &aggregationPushDownSolver{}
+	return a.checkAnyCountAndSum(aggFuncs)
+}
+
+func (a *aggregationPushDownSolver) getDefaultValues(agg *LogicalAggregation, childSchema *expression.Schema) ([]types.Datum, bool) {
 	defaultValues := make([]types.Datum, 0, agg.Schema().Len())
 	for _, aggFunc := range agg.AggFuncs {
-		value, existsDefaultValue := aggFunc.EvalNullValueInOuterJoin(agg.ctx, agg.children[0].Schema())
+		value, existsDefaultValue := aggFunc.EvalNullValueInOuterJoin(agg.ctx, childSchema)
 		if !existsDefaultValue {
 			return nil, false
 		}
@@ -283,7 +320,7 @@ func (a *aggregationPushDownSolver) aggPushDown(p LogicalPlan) (_ LogicalPlan, e
 		} else {
 			child := agg.children[0]
 			if join, ok1 := child.(*LogicalJoin); ok1 && a.checkValidJoin(join) {
-				if valid, leftAggFuncs, rightAggFuncs, leftGbyCols, rightGbyCols := a.splitAggFuncsAndGbyCols(agg, join); valid {
+				if valid, leftAggFuncs, rightAggFuncs, leftGbyCols, rightGbyCols := a.splitAggFuncsAndGbyCols(agg, join, join.children[0].Schema()); valid {
 					var lChild, rChild LogicalPlan
 					// If there exist count or sum functions in left join path, we can't push any
 					// aggregate function into right join path.
@@ -48,37 +48,29 @@ func (o *outerJoinEliminator) tryToEliminateOuterJoin(p *LogicalJoin, aggCols []
 	for _, outerCol := range outerPlan.Schema().Columns {
 		outerUniqueIDs.Insert(outerCol.UniqueID)
 	}
-	matched := o.isColsAllFromOuterTable(parentCols, outerUniqueIDs)
+	matched := IsColsAllFromOuterTable(parentCols, outerUniqueIDs)
 	if !matched {
 		return p, false, nil
 	}
 	// outer join elimination with duplicate agnostic aggregate functions
-	matched = o.isColsAllFromOuterTable(aggCols, outerUniqueIDs)
+	matched = IsColsAllFromOuterTable(aggCols, outerUniqueIDs)
 	if matched {
 		return outerPlan, true, nil
 	}
 	// outer join elimination without duplicate agnostic aggregate functions
-	innerJoinKeys := o.extractInnerJoinKeys(p, innerChildIdx)
-	contain, err := o.isInnerJoinKeysContainUniqueKey(innerPlan, innerJoinKeys)
-	if err != nil {
-		return p, false, err
-	}
-	if contain {
+	innerJoinKeys := ExtractInnerJoinKeys(p, innerChildIdx)
+	if IsInnerJoinKeysContainUniqueKey(innerPlan.Schema(), innerJoinKeys) {
 		return outerPlan, true, nil
 	}
-	contain, err = o.isInnerJoinKeysContainIndex(innerPlan, innerJoinKeys)
-	if err != nil {
-		return p, false, err
-	}
-	if contain {
+	if ds, ok := innerPlan.(*DataSource); ok && IsInnerJoinKeysContainIndex(ds, innerJoinKeys) {
 		return outerPlan, true, nil
 	}
 
 	return p, false, nil
 }
 
-// extract join keys as a schema for inner child of a outer join
-func (o *outerJoinEliminator) extractInnerJoinKeys(join *LogicalJoin, innerChildIdx int) *expression.Schema {
+// ExtractInnerJoinKeys extracts join keys as a schema for inner child of a outer join.
+func ExtractInnerJoinKeys(join *LogicalJoin, innerChildIdx int) *expression.Schema {
 	joinKeys := make([]*expression.Column, 0, len(join.EqualConditions))
 	for _, eqCond := range join.EqualConditions {
 		joinKeys = append(joinKeys, eqCond.GetArgs()[innerChildIdx].(*expression.Column))
@@ -86,8 +78,8 @@ func (o *outerJoinEliminator) extractInnerJoinKeys(join *LogicalJoin, innerChild
 	return expression.NewSchema(joinKeys...)
 }
 
-// check whether the cols all from outer plan
-func (o *outerJoinEliminator) isColsAllFromOuterTable(cols []*expression.Column, outerUniqueIDs set.Int64Set) bool {
+// IsColsAllFromOuterTable checks whether the cols all from outer plan.
+func IsColsAllFromOuterTable(cols []*expression.Column, outerUniqueIDs set.Int64Set) bool {
 	// There are two cases "return false" here:
 	// 1. If cols represents aggCols, then "len(cols) == 0" means not all aggregate functions are duplicate agnostic before.
 	// 2. If cols represents parentCols, then "len(cols) == 0" means no parent logical plan of this join plan.
@@ -102,9 +94,9 @@ func (o *outerJoinEliminator) isColsAllFromOuterTable(cols []*expression.Column,
 	return true
 }
 
-// check whether one of unique keys sets is contained by inner join keys
-func (o *outerJoinEliminator) isInnerJoinKeysContainUniqueKey(innerPlan LogicalPlan, joinKeys *expression.Schema) (bool, error) {
-	for _, keyInfo := range innerPlan.Schema().Keys {
+// IsInnerJoinKeysContainUniqueKey checks whether one of unique keys sets of the inner schema is contained by inner join keys.
+func IsInnerJoinKeysContainUniqueKey(innerSchema *expression.Schema, joinKeys *expression.Schema) bool {
+	for _, keyInfo := range innerSchema.Keys {
 		joinKeysContainKeyInfo := true
 		for _, col := range keyInfo {
 			if !joinKeys.Contains(col) {
@@ -113,18 +105,14 @@ func (o *outerJoinEliminator) isInnerJoinKeysContainUniqueKey(innerPlan LogicalP
 			}
 		}
 		if joinKeysContainKeyInfo {
-			return true, nil
+			return true
 		}
 	}
-	return false, nil
+	return false
 }
 
-// check whether one of index sets is contained by inner join index
-func (o *outerJoinEliminator) isInnerJoinKeysContainIndex(innerPlan LogicalPlan, joinKeys *expression.Schema) (bool, error) {
-	ds, ok := innerPlan.(*DataSource)
-	if !ok {
-		return false, nil
-	}
+// IsInnerJoinKeysContainIndex checks whether one of unique index sets of the DataSource is contained by inner join keys.
+func IsInnerJoinKeysContainIndex(ds *DataSource, joinKeys *expression.Schema) bool {
 	for _, path := range ds.possibleAccessPaths {
 		if path.IsTablePath {
 			continue
@@ -132,28 +120,31 @@ func (o *outerJoinEliminator) isInnerJoinKeysContainIndex(innerPlan LogicalPlan,
 		if !path.Index.Unique {
 			continue
 		}
-		joinKeysContainIndex := true
-		for _, idxCol := range path.IdxCols {
-			if !joinKeys.Contains(idxCol) {
+		// The IdxCols are not filled before the stats are derived, and only
+		// contain the prefix of the index columns in the schema, so all the
+		// index columns are checked instead.
+		joinKeysContainIndex := len(path.FullIdxCols) == len(path.Index.Columns)
+		for _, idxCol := range path.FullIdxCols {
+			if idxCol == nil || !joinKeys.Contains(idxCol) {
 				joinKeysContainIndex = false
 				break
 			}
 		}
 		if joinKeysContainIndex {
-			return true, nil
+			return true
 		}
 	}
-	return false, nil
+	return false
 }
 
-// getDupAgnosticAggCols checks whether a LogicalPlan is LogicalAggregation.
+// GetDupAgnosticAggCols checks whether a LogicalPlan is LogicalAggregation.
 // It extracts all the columns from the duplicate agnostic aggregate functions.
 // The returned column set is nil if not all the aggregate functions are duplicate agnostic.
 // Only the following functions are considered to be duplicate agnostic:
 //   1. MAX(arg)
 //   2. MIN(arg)
 //   3. FIRST_ROW(arg)
-func (o *outerJoinEliminator) getDupAgnosticAggCols(
+func GetDupAgnosticAggCols(
 	p LogicalPlan,
 	oldAggCols []*expression.Column, // Reuse the original buffer.
 ) (isAgg bool, newAggCols []*expression.Column) {
@@ -210,7 +201,7 @@ func (o *outerJoinEliminator) doOptimize(p LogicalPlan, aggCols []*expression.Co
 		parentCols = append(parentCols[:0], p.Schema().Columns...)
 	}
 
-	if ok, newCols := o.getDupAgnosticAggCols(p, aggCols); ok {
+	if ok, newCols := GetDupAgnosticAggCols(p, aggCols); ok {
 		aggCols = newCols
 	}
 
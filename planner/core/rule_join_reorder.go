@@ -17,6 +17,7 @@ import (
 	"context"
 
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/sessionctx"
 )
 
@@ -28,7 +29,7 @@ import (
 // results in a join group {a, b, LeftJoin(c, d)}.
 func extractJoinGroup(p LogicalPlan) (group []LogicalPlan, eqEdges []*expression.ScalarFunction, otherConds []expression.Expression) {
 	join, isJoin := p.(*LogicalJoin)
-	if !isJoin || join.preferJoinType > uint(0) || join.JoinType != InnerJoin || join.StraightJoin {
+	if !isJoin || !join.IsReorderable() {
 		return []LogicalPlan{p}, nil, nil
 	}
 
@@ -46,6 +47,12 @@ func extractJoinGroup(p LogicalPlan) (group []LogicalPlan, eqEdges []*expression
 	return group, eqEdges, otherConds
 }
 
+// IsReorderable checks whether the join can be reordered with its adjacent joins.
+// Only the inner joins without join hints or STRAIGHT_JOIN can be reordered.
+func (p *LogicalJoin) IsReorderable() bool {
+	return p.preferJoinType == 0 && p.JoinType == InnerJoin && !p.StraightJoin
+}
+
 type joinReOrderSolver struct {
 }
 
@@ -69,6 +76,16 @@ func (s *joinReOrderSolver) optimizeRecursive(ctx sessionctx.Context, p LogicalP
 				return nil, err
 			}
 		}
+		if join, ok := p.(*LogicalJoin); ok && join.leadingJoinOrder != nil {
+			var hasApplied bool
+			curJoinGroup, eqEdges, otherConds, hasApplied = s.generateLeadingJoinGroup(ctx, join.leadingJoinOrder, curJoinGroup, eqEdges, otherConds)
+			if !hasApplied {
+				errMsg := "leading hint is inapplicable, check if the leading hint table is valid"
+				ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+			} else if len(curJoinGroup) == 1 {
+				return curJoinGroup[0], nil
+			}
+		}
 		baseGroupSolver := &baseSingleGroupJoinOrderSolver{
 			ctx:        ctx,
 			otherConds: otherConds,
@@ -103,6 +120,57 @@ func (s *joinReOrderSolver) optimizeRecursive(ctx sessionctx.Context, p LogicalP
 	return p, nil
 }
 
+// generateLeadingJoinGroup joins the tables specified by the LEADING hint in
+// order, and replaces them in the join group with the joined plan, so that the
+// reorder algorithms keep them joined first. It returns false if any table of
+// the hint can't be found in the join group.
+func (s *joinReOrderSolver) generateLeadingJoinGroup(ctx sessionctx.Context, leadingJoinOrder []hintTableInfo, curJoinGroup []LogicalPlan,
+	eqEdges []*expression.ScalarFunction, otherConds []expression.Expression) ([]LogicalPlan, []*expression.ScalarFunction, []expression.Expression, bool) {
+	leadingGroup := make([]LogicalPlan, 0, len(leadingJoinOrder))
+	remainedGroup := make([]LogicalPlan, len(curJoinGroup))
+	copy(remainedGroup, curJoinGroup)
+	for _, hintTbl := range leadingJoinOrder {
+		idx := -1
+		for i, node := range remainedGroup {
+			tableAlias := extractTableAlias(node)
+			if tableAlias != nil && tableAlias.dbName.L == hintTbl.dbName.L && tableAlias.tblName.L == hintTbl.tblName.L {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return curJoinGroup, eqEdges, otherConds, false
+		}
+		leadingGroup = append(leadingGroup, remainedGroup[idx])
+		remainedGroup = append(remainedGroup[:idx], remainedGroup[idx+1:]...)
+	}
+	baseGroupSolver := &baseSingleGroupJoinOrderSolver{ctx: ctx}
+	leadingJoin := leadingGroup[0]
+	for _, rightNode := range leadingGroup[1:] {
+		var usedEdges, remainedEdges []*expression.ScalarFunction
+		for _, edge := range eqEdges {
+			lCol := edge.GetArgs()[0].(*expression.Column)
+			rCol := edge.GetArgs()[1].(*expression.Column)
+			if leadingJoin.Schema().Contains(lCol) && rightNode.Schema().Contains(rCol) {
+				usedEdges = append(usedEdges, edge)
+			} else if rightNode.Schema().Contains(lCol) && leadingJoin.Schema().Contains(rCol) {
+				newSf := expression.NewFunctionInternal(ctx, ast.EQ, edge.GetType(), rCol, lCol).(*expression.ScalarFunction)
+				usedEdges = append(usedEdges, newSf)
+			} else {
+				remainedEdges = append(remainedEdges, edge)
+			}
+		}
+		eqEdges = remainedEdges
+		mergedSchema := expression.MergeSchema(leadingJoin.Schema(), rightNode.Schema())
+		var joinOtherConds []expression.Expression
+		otherConds, joinOtherConds = expression.FilterOutInPlace(otherConds, func(expr expression.Expression) bool {
+			return expression.ExprFromSchema(expr, mergedSchema)
+		})
+		leadingJoin = baseGroupSolver.newJoinWithEdges(leadingJoin, rightNode, usedEdges, joinOtherConds)
+	}
+	return append([]LogicalPlan{leadingJoin}, remainedGroup...), eqEdges, otherConds, true
+}
+
 type baseSingleGroupJoinOrderSolver struct {
 	ctx          sessionctx.Context
 	curJoinGroup []*jrNode
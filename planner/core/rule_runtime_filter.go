@@ -0,0 +1,105 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/types"
+)
+
+const (
+	// runtimeFilterMaxBuildRows is the max estimated row count of the build side
+	// of a hash join to enable runtime filters. Filters built from a large build
+	// side are rarely selective and costly to evaluate in the coprocessor.
+	runtimeFilterMaxBuildRows = 1024
+	// runtimeFilterMinProbeRatio is the min ratio of the estimated row count of
+	// the probe side to the build side to enable runtime filters.
+	runtimeFilterMinProbeRatio = 4
+)
+
+// enableRuntimeFilter decides for every hash join whether the join keys of its
+// build side are worth being collected at runtime and pushed down to the table
+// reader of its probe side as extra filters, so that the probe side rows which
+// can never be matched are filtered out in the storage layer.
+func enableRuntimeFilter(plan PhysicalPlan) {
+	for _, child := range plan.Children() {
+		enableRuntimeFilter(child)
+	}
+	join, ok := plan.(*PhysicalHashJoin)
+	if !ok || !join.SCtx().GetSessionVars().EnableRuntimeFilter {
+		return
+	}
+	// Only the unmatched probe side rows of an inner join can be discarded.
+	if join.JoinType != InnerJoin {
+		return
+	}
+	buildSide := join.children[join.InnerChildIdx]
+	probeSide := join.children[1-join.InnerChildIdx]
+	if !canPushRuntimeFilterTo(probeSide) {
+		return
+	}
+	buildCnt, probeCnt := buildSide.statsInfo().RowCount, probeSide.statsInfo().RowCount
+	if buildCnt > runtimeFilterMaxBuildRows || probeCnt < buildCnt*runtimeFilterMinProbeRatio {
+		return
+	}
+
+	buildKeys, probeKeys := join.RightJoinKeys, join.LeftJoinKeys
+	if join.InnerChildIdx == 0 {
+		buildKeys, probeKeys = join.LeftJoinKeys, join.RightJoinKeys
+	}
+	join.RuntimeFilterKeyIdx = join.RuntimeFilterKeyIdx[:0]
+	for i := range buildKeys {
+		if isRuntimeFilterKeySupported(buildKeys[i].RetType, probeKeys[i].RetType) {
+			join.RuntimeFilterKeyIdx = append(join.RuntimeFilterKeyIdx, i)
+		}
+	}
+}
+
+// canPushRuntimeFilterTo checks whether the probe side is a table reader which
+// only scans and filters the table in the coprocessor. The filters are appended
+// to the selection right after the table scan, so the offsets of the columns
+// in the reader's schema are the same as the ones in the scan.
+func canPushRuntimeFilterTo(p PhysicalPlan) bool {
+	reader, ok := p.(*PhysicalTableReader)
+	if !ok {
+		return false
+	}
+	if _, ok := reader.TablePlans[0].(*PhysicalTableScan); !ok {
+		return false
+	}
+	for _, tablePlan := range reader.TablePlans[1:] {
+		if _, ok := tablePlan.(*PhysicalSelection); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isRuntimeFilterKeySupported checks whether the values of the build side key
+// can be compared with the probe side key directly in the coprocessor.
+func isRuntimeFilterKeySupported(buildTp, probeTp *types.FieldType) bool {
+	evalTp := buildTp.EvalType()
+	if evalTp != probeTp.EvalType() {
+		return false
+	}
+	switch evalTp {
+	case types.ETInt:
+		return mysql.HasUnsignedFlag(buildTp.Flag) == mysql.HasUnsignedFlag(probeTp.Flag)
+	case types.ETReal:
+		return true
+	case types.ETString:
+		return buildTp.Collate == probeTp.Collate
+	}
+	return false
+}
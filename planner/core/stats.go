@@ -15,8 +15,11 @@ package core
 
 import (
 	"math"
+	"sort"
 
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/model"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/planner/property"
 	"github.com/pingcap/tidb/planner/util"
@@ -186,7 +189,7 @@ func (ds *DataSource) DeriveStats(childStats []*property.StatsInfo, selfSchema *
 	ds.stats = ds.deriveStatsByFilter(ds.pushedDownConds, ds.possibleAccessPaths)
 	for _, path := range ds.possibleAccessPaths {
 		if path.IsTablePath {
-			noIntervalRanges, err := ds.deriveTablePathStats(path, ds.pushedDownConds)
+			noIntervalRanges, err := ds.deriveTablePathStats(path, ds.pushedDownConds, false)
 			if err != nil {
 				return nil, err
 			}
@@ -198,7 +201,7 @@ func (ds *DataSource) DeriveStats(childStats []*property.StatsInfo, selfSchema *
 			}
 			continue
 		}
-		noIntervalRanges := ds.deriveIndexPathStats(path)
+		noIntervalRanges := ds.deriveIndexPathStats(path, false)
 		// If we have empty range, or point range on unique index, just remove other possible paths.
 		if (noIntervalRanges && path.Index.Unique) || len(path.Ranges) == 0 {
 			ds.possibleAccessPaths[0] = path
@@ -206,9 +209,209 @@ func (ds *DataSource) DeriveStats(childStats []*property.StatsInfo, selfSchema *
 			break
 		}
 	}
+	ds.indexMergePaths = nil
+	// If only one path is left, it is either the only path or a point or empty
+	// range path, so the index merge paths are not needed.
+	if len(ds.possibleAccessPaths) > 1 && len(ds.pushedDownConds) > 0 {
+		if len(ds.indexMergeHints) > 0 || (ds.ctx.GetSessionVars().EnableIndexMerge && !ds.noIndexMerge) {
+			err := ds.generateIndexMergePaths()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	return ds.stats, nil
 }
 
+// generateIndexMergePaths generates the index merge paths of the DataSource.
+// A union path is generated for each condition in DNF whose items can all be
+// read by the indexes, and an intersection path is generated for the indexes
+// reading different conditions in CNF. They are compared with the other paths
+// by the cost unless the USE_INDEX_MERGE hint is specified.
+func (ds *DataSource) generateIndexMergePaths() error {
+	candidatePaths := ds.indexMergeCandidatePaths()
+	for i, cond := range ds.pushedDownConds {
+		sf, ok := cond.(*expression.ScalarFunction)
+		if !ok || sf.FuncName.L != ast.LogicOr {
+			continue
+		}
+		path, err := ds.buildIndexMergeUnionPath(candidatePaths, i, expression.FlattenDNFConditions(sf))
+		if err != nil {
+			return err
+		}
+		if path != nil {
+			ds.indexMergePaths = append(ds.indexMergePaths, path)
+		}
+	}
+	if path := ds.buildIndexMergeIntersectionPath(candidatePaths); path != nil {
+		ds.indexMergePaths = append(ds.indexMergePaths, path)
+	}
+	if len(ds.indexMergeHints) > 0 {
+		if len(ds.indexMergePaths) == 0 {
+			errMsg := "IndexMerge is inapplicable or disabled"
+			ds.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+		}
+	}
+	return nil
+}
+
+// indexMergeCandidatePaths returns the paths which can be the partial paths of
+// the index merge paths. If the USE_INDEX_MERGE hints specify the indexes, only
+// the specified ones are returned, the table path is specified by "primary".
+func (ds *DataSource) indexMergeCandidatePaths() []*util.AccessPath {
+	var names []model.CIStr
+	for _, hint := range ds.indexMergeHints {
+		if len(hint.IndexNames) == 0 {
+			return ds.possibleAccessPaths
+		}
+		names = append(names, hint.IndexNames...)
+	}
+	if len(names) == 0 {
+		return ds.possibleAccessPaths
+	}
+	paths := make([]*util.AccessPath, 0, len(ds.possibleAccessPaths))
+	for _, path := range ds.possibleAccessPaths {
+		for _, name := range names {
+			if (path.IsTablePath && ds.tableInfo.PKIsHandle && isPrimaryIndex(name)) ||
+				(!path.IsTablePath && path.Index.Name.L == name.L) {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// buildIndexMergeUnionPath builds the union path for the condition in DNF,
+// which is the condIdx-th of the pushed down conditions. Each item of the
+// condition is read by the partial path with the least row count, nil is
+// returned if any item can't be read by the candidate paths.
+func (ds *DataSource) buildIndexMergeUnionPath(candidatePaths []*util.AccessPath, condIdx int, dnfItems []expression.Expression) (*util.AccessPath, error) {
+	partialPaths := make([]*util.AccessPath, 0, len(dnfItems))
+	// The condition in DNF is kept as a table filter if any partial path can
+	// not filter all the rows by its item.
+	keepDNFCond := false
+	var rowCount float64
+	for _, item := range dnfItems {
+		partialPath, err := ds.buildIndexMergePartialPath(candidatePaths, expression.SplitCNFItems(item))
+		if err != nil || partialPath == nil {
+			return nil, err
+		}
+		if !partialPath.IsTablePath && len(partialPath.TableFilters) > 0 {
+			keepDNFCond = true
+		}
+		partialPaths = append(partialPaths, partialPath)
+		rowCount += partialPath.CountAfterAccess
+	}
+	tableFilters := make([]expression.Expression, 0, len(ds.pushedDownConds))
+	for i, cond := range ds.pushedDownConds {
+		if i != condIdx || keepDNFCond {
+			tableFilters = append(tableFilters, cond)
+		}
+	}
+	return &util.AccessPath{
+		PartialIndexPaths: partialPaths,
+		TableFilters:      tableFilters,
+		CountAfterAccess:  math.Min(rowCount, float64(ds.statisticTable.Count)),
+	}, nil
+}
+
+// buildIndexMergePartialPath returns the candidate path with the least row
+// count to read the conditions in CNF, the paths which can't use any of the
+// conditions to build the ranges are skipped.
+func (ds *DataSource) buildIndexMergePartialPath(candidatePaths []*util.AccessPath, conds []expression.Expression) (*util.AccessPath, error) {
+	var bestPath *util.AccessPath
+	for _, candidate := range candidatePaths {
+		path := &util.AccessPath{IsTablePath: candidate.IsTablePath, Index: candidate.Index}
+		if path.IsTablePath {
+			_, err := ds.deriveTablePathStats(path, conds, true)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			err := ds.fillIndexPath(path, conds)
+			if err != nil {
+				return nil, err
+			}
+			ds.deriveIndexPathStats(path, true)
+		}
+		if len(path.AccessConds) == 0 {
+			continue
+		}
+		if bestPath == nil || path.CountAfterAccess < bestPath.CountAfterAccess {
+			bestPath = path
+		}
+	}
+	return bestPath, nil
+}
+
+// buildIndexMergeIntersectionPath builds the intersection path of the
+// candidate paths which use different conditions to build the ranges, the
+// ones with less row count are chosen first. nil is returned if less than two
+// paths are chosen.
+func (ds *DataSource) buildIndexMergeIntersectionPath(candidatePaths []*util.AccessPath) *util.AccessPath {
+	paths := make([]*util.AccessPath, 0, len(candidatePaths))
+	for _, path := range candidatePaths {
+		if len(path.AccessConds) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return paths[i].CountAfterAccess < paths[j].CountAfterAccess
+	})
+	var (
+		partialPaths []*util.AccessPath
+		usedConds    []expression.Expression
+	)
+	for _, path := range paths {
+		overlapped := false
+		for _, cond := range path.AccessConds {
+			if expression.Contains(usedConds, cond) {
+				overlapped = true
+				break
+			}
+		}
+		if overlapped {
+			continue
+		}
+		partialPaths = append(partialPaths, path)
+		usedConds = append(usedConds, path.AccessConds...)
+	}
+	if len(partialPaths) < 2 {
+		return nil
+	}
+	// The conditions used to build the ranges are filtered by the partial
+	// paths, unless they are kept as the table filters of the partial paths,
+	// e.g. the conditions on the prefix index columns.
+	tableFilters := make([]expression.Expression, 0, len(ds.pushedDownConds))
+	for _, cond := range ds.pushedDownConds {
+		filtered := false
+		for _, path := range partialPaths {
+			if expression.Contains(path.AccessConds, cond) && !expression.Contains(path.TableFilters, cond) {
+				filtered = true
+				break
+			}
+		}
+		if !filtered {
+			tableFilters = append(tableFilters, cond)
+		}
+	}
+	// The conditions of the partial paths are assumed to be independent, the
+	// estimated row count is never more than the one of any partial path.
+	rowCount := partialPaths[0].CountAfterAccess
+	if totalCount := float64(ds.statisticTable.Count); totalCount > 0 {
+		for _, path := range partialPaths[1:] {
+			rowCount *= path.CountAfterAccess / totalCount
+		}
+	}
+	return &util.AccessPath{
+		PartialIndexPaths:        partialPaths,
+		TableFilters:             tableFilters,
+		CountAfterAccess:         rowCount,
+		IndexMergeIsIntersection: true,
+	}
+}
+
 // DeriveStats implements LogicalPlan DeriveStats interface.
 func (ts *LogicalTableScan) DeriveStats(childStats []*property.StatsInfo, selfSchema *expression.Schema, childSchema []*expression.Schema) (_ *property.StatsInfo, err error) {
 	// PushDownNot here can convert query 'not (a != 1)' to 'a = 1'.
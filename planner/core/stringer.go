@@ -87,6 +87,8 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 			r := x.RightJoinKeys[i].String()
 			str += fmt.Sprintf("(%s,%s)", l, r)
 		}
+	case *PhysicalShuffle:
+		str = "Shuffle"
 	case *LogicalLimit, *PhysicalLimit:
 		str = "Limit"
 	case *ShowDDL:
@@ -144,8 +146,31 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = fmt.Sprintf("IndexReader(%s)", ToString(x.indexPlan))
 	case *PhysicalIndexLookUpReader:
 		str = fmt.Sprintf("IndexLookUp(%s, %s)", ToString(x.indexPlan), ToString(x.tablePlan))
+	case *PhysicalIndexMergeReader:
+		str = "IndexMergeReader(PartialPlans->["
+		for i, partialPlan := range x.partialPlans {
+			if i > 0 {
+				str += ", "
+			}
+			str += ToString(partialPlan)
+		}
+		str += "], TablePlan->" + ToString(x.tablePlan) + ")"
 	case *PhysicalUnionScan:
 		str = fmt.Sprintf("UnionScan(%s)", x.Conditions)
+	case *PointGetPlan:
+		str = "PointGet("
+		if x.IndexInfo != nil {
+			str += fmt.Sprintf("Index(%s.%s)%v)", x.TblInfo.Name.L, x.IndexInfo.Name.L, x.IndexValues)
+		} else {
+			str += fmt.Sprintf("Handle(%s.%s)%v)", x.TblInfo.Name.L, x.TblInfo.GetPkName().L, x.Handle)
+		}
+	case *BatchPointGetPlan:
+		str = "BatchPointGet("
+		if x.IndexInfo != nil {
+			str += fmt.Sprintf("Index(%s.%s)%v)", x.TblInfo.Name.L, x.IndexInfo.Name.L, x.IndexValues)
+		} else {
+			str += fmt.Sprintf("Handle(%s.%s)%v)", x.TblInfo.Name.L, x.TblInfo.GetPkName().L, x.Handles)
+		}
 	case *Analyze:
 		str = "Analyze{"
 		var children []string
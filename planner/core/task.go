@@ -240,6 +240,34 @@ func (p *PhysicalMergeJoin) attach2Task(tasks ...task) task {
 	}
 }
 
+// GetCost computes cost of shuffle operator itself, it includes the cost of
+// splitting the rows of the data sources and the cost of the goroutines.
+func (p *PhysicalShuffle) GetCost(dataSourceCnt float64) float64 {
+	sessVars := p.ctx.GetSessionVars()
+	cpuCost := dataSourceCnt * sessVars.CPUFactor
+	concurrencyCost := float64(p.Concurrency+len(p.Tail.Children())) * sessVars.ConcurrencyFactor
+	return cpuCost + concurrencyCost
+}
+
+func (p *PhysicalShuffle) attach2Task(tasks ...task) task {
+	dataSourceTasks := make([]task, 0, len(tasks))
+	var dataSourceCnt, dataSourceCost float64
+	for _, t := range tasks {
+		t = finishCopTask(p.ctx, t.copy())
+		dataSourceTasks = append(dataSourceTasks, t)
+		dataSourceCnt += t.count()
+		dataSourceCost += t.cost()
+	}
+	tailTask := p.Tail.attach2Task(dataSourceTasks...)
+	p.SetChildren(p.Tail)
+	// The tail runs on every partition in parallel.
+	tailCost := (tailTask.cost() - dataSourceCost) / float64(p.Concurrency)
+	return &rootTask{
+		p:   p,
+		cst: dataSourceCost + tailCost + p.GetCost(dataSourceCnt),
+	}
+}
+
 // splitCopAvg2CountAndSum splits the cop avg function to count and sum.
 // Now it's only used for TableReader.
 func splitCopAvg2CountAndSum(p PhysicalPlan) {
@@ -351,9 +379,11 @@ func finishCopTask(ctx sessionctx.Context, task task) task {
 		newTask.p = p
 	}
 
+	newTask.p.SetCost(newTask.cst)
 	if len(t.rootTaskConds) > 0 {
 		sel := PhysicalSelection{Conditions: t.rootTaskConds}.Init(ctx, newTask.p.statsInfo())
 		sel.SetChildren(newTask.p)
+		sel.SetCost(newTask.cst)
 		newTask.p = sel
 	}
 
@@ -513,7 +543,12 @@ func (p *PhysicalTopN) attach2Task(tasks ...task) task {
 
 // GetCost computes the cost of projection operator itself.
 func (p *PhysicalProjection) GetCost(count float64) float64 {
-	sessVars := p.ctx.GetSessionVars()
+	return GetProjectionCost(p.ctx, count)
+}
+
+// GetProjectionCost computes the cost of a projection operator on count rows.
+func GetProjectionCost(sctx sessionctx.Context, count float64) float64 {
+	sessVars := sctx.GetSessionVars()
 	cpuCost := count * sessVars.CPUFactor
 	concurrency := float64(sessVars.ProjectionConcurrency)
 	if concurrency <= 0 {
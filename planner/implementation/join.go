@@ -29,7 +29,9 @@ func (impl *HashJoinImpl) CalcCost(outCount float64, children ...memo.Implementa
 	// The children here are only used to calculate the cost.
 	hashJoin.SetChildren(children[0].GetPlan(), children[1].GetPlan())
 	selfCost := hashJoin.GetCost(children[0].GetPlan().StatsCount(), children[1].GetPlan().StatsCount())
-	impl.cost = selfCost + children[0].GetCost() + children[1].GetCost()
+	// Sum up the cost of the children first, so that the cost does not depend on
+	// the order of the children, and the commuted joins can get the same cost.
+	impl.cost = selfCost + (children[0].GetCost() + children[1].GetCost())
 	return impl.cost
 }
 
@@ -45,3 +47,31 @@ func (impl *HashJoinImpl) AttachChildren(children ...memo.Implementation) memo.I
 func NewHashJoinImpl(hashJoin *plannercore.PhysicalHashJoin) *HashJoinImpl {
 	return &HashJoinImpl{baseImpl{plan: hashJoin}}
 }
+
+// MergeJoinImpl is the implementation for PhysicalMergeJoin.
+type MergeJoinImpl struct {
+	baseImpl
+}
+
+// CalcCost implements Implementation CalcCost interface.
+func (impl *MergeJoinImpl) CalcCost(outCount float64, children ...memo.Implementation) float64 {
+	mergeJoin := impl.plan.(*plannercore.PhysicalMergeJoin)
+	// The children here are only used to calculate the cost.
+	mergeJoin.SetChildren(children[0].GetPlan(), children[1].GetPlan())
+	selfCost := mergeJoin.GetCost(children[0].GetPlan().StatsCount(), children[1].GetPlan().StatsCount())
+	impl.cost = selfCost + (children[0].GetCost() + children[1].GetCost())
+	return impl.cost
+}
+
+// AttachChildren implements Implementation AttachChildren interface.
+func (impl *MergeJoinImpl) AttachChildren(children ...memo.Implementation) memo.Implementation {
+	mergeJoin := impl.plan.(*plannercore.PhysicalMergeJoin)
+	mergeJoin.SetChildren(children[0].GetPlan(), children[1].GetPlan())
+	mergeJoin.SetSchema(plannercore.BuildPhysicalJoinSchema(mergeJoin.JoinType, mergeJoin))
+	return impl
+}
+
+// NewMergeJoinImpl creates a new MergeJoinImpl.
+func NewMergeJoinImpl(mergeJoin *plannercore.PhysicalMergeJoin) *MergeJoinImpl {
+	return &MergeJoinImpl{baseImpl{plan: mergeJoin}}
+}
@@ -77,6 +77,9 @@ type Group struct {
 	ImplMap map[string]Implementation
 	Prop    *property.LogicalProperty
 
+	// implProps stores the physical properties of the Implementations in ImplMap.
+	implProps map[string]*property.PhysicalProperty
+
 	EngineType EngineType
 
 	//hasBuiltKeyInfo indicates whether this group has called `BuildKeyInfo`.
@@ -94,6 +97,7 @@ func NewGroupWithSchema(e *GroupExpr, s *expression.Schema) *Group {
 		Fingerprints: make(map[string]*list.Element),
 		FirstExpr:    make(map[Operand]*list.Element),
 		ImplMap:      make(map[string]Implementation),
+		implProps:    make(map[string]*property.PhysicalProperty),
 		Prop:         prop,
 		EngineType:   EngineTiDB,
 	}
@@ -195,6 +199,17 @@ func (g *Group) GetImpl(prop *property.PhysicalProperty) Implementation {
 func (g *Group) InsertImpl(prop *property.PhysicalProperty, impl Implementation) {
 	key := prop.HashCode()
 	g.ImplMap[string(key)] = impl
+	g.implProps[string(key)] = prop
+}
+
+// GetImplProps returns all the physical properties which have a best
+// Implementation in the Group.
+func (g *Group) GetImplProps() []*property.PhysicalProperty {
+	props := make([]*property.PhysicalProperty, 0, len(g.implProps))
+	for _, prop := range g.implProps {
+		props = append(props, prop)
+	}
+	return props
 }
 
 // Convert2GroupExpr converts a logical plan to a GroupExpr.
@@ -15,6 +15,7 @@ package memo
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/pingcap/tidb/expression"
 	plannercore "github.com/pingcap/tidb/planner/core"
@@ -31,6 +32,16 @@ type GroupExpr struct {
 	Explored bool
 	Group    *Group
 
+	// disabledRules contains the types of the transformation rules which
+	// should not be applied on this GroupExpr. It is used by the rules which
+	// need to avoid generating duplicate GroupExprs, e.g. the join reorder rules.
+	disabledRules map[reflect.Type]struct{}
+
+	// AppliedRule is the name of the transformation rule which generates this
+	// GroupExpr, it is empty if the GroupExpr is converted from the original
+	// logical plan. It is only used to show the memo for debugging.
+	AppliedRule string
+
 	selfFingerprint string
 }
 
@@ -63,3 +74,19 @@ func (e *GroupExpr) SetChildren(children ...*Group) {
 func (e *GroupExpr) Schema() *expression.Schema {
 	return e.Group.Prop.Schema
 }
+
+// DisableRules disables the transformation rules on this GroupExpr.
+func (e *GroupExpr) DisableRules(rules ...interface{}) {
+	if e.disabledRules == nil {
+		e.disabledRules = make(map[reflect.Type]struct{}, len(rules))
+	}
+	for _, rule := range rules {
+		e.disabledRules[reflect.TypeOf(rule)] = struct{}{}
+	}
+}
+
+// IsRuleDisabled checks whether the transformation rule is disabled on this GroupExpr.
+func (e *GroupExpr) IsRuleDisabled(rule interface{}) bool {
+	_, ok := e.disabledRules[reflect.TypeOf(rule)]
+	return ok
+}
@@ -33,3 +33,14 @@ func (s *testMemoSuite) TestGroupExprFingerprint(c *C) {
 	// we haven't set the id of the created LogicalLimit, so the result is 0.
 	c.Assert(expr.FingerPrint(), Equals, "0")
 }
+
+type fakeRuleA struct{}
+type fakeRuleB struct{}
+
+func (s *testMemoSuite) TestGroupExprDisableRules(c *C) {
+	expr := NewGroupExpr(&plannercore.LogicalLimit{})
+	c.Assert(expr.IsRuleDisabled(&fakeRuleA{}), IsFalse)
+	expr.DisableRules(&fakeRuleA{})
+	c.Assert(expr.IsRuleDisabled(&fakeRuleA{}), IsTrue)
+	c.Assert(expr.IsRuleDisabled(&fakeRuleB{}), IsFalse)
+}
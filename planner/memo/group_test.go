@@ -156,6 +156,10 @@ func (s *testMemoSuite) TestGetInsertGroupImpl(c *C) {
 
 	newImpl = g.GetImpl(orderProp)
 	c.Assert(newImpl, IsNil)
+
+	props := g.GetImplProps()
+	c.Assert(len(props), Equals, 1)
+	c.Assert(props[0], Equals, emptyProp)
 }
 
 func (s *testMemoSuite) TestFirstElemAfterDelete(c *C) {
@@ -16,22 +16,46 @@ package planner
 import (
 	"context"
 
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/bindinfo"
+	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/planner/cascades"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/tracing"
 )
 
 // Optimize does optimization and creates a Plan.
 // The node must be prepared first.
 func Optimize(ctx context.Context, sctx sessionctx.Context, node ast.Node, is infoschema.InfoSchema) (plannercore.Plan, types.NameSlice, error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "planner.Optimize")
+	defer span.Finish()
 	sctx.PrepareTxnFuture(ctx)
 
-	// build logical plan
+	if sel, ok := node.(*ast.SelectStmt); ok {
+		sessVars := sctx.GetSessionVars()
+		if sessVars.UsePlanBaselines && !sessVars.InRestrictedSQL {
+			if record := getBindRecord(sctx, sel); record != nil {
+				bindinfo.BindHint(sel, record.Hints())
+				record.RecordUsage()
+			} else {
+				captureStmt(sctx, sel)
+			}
+		}
+	}
+
 	sctx.GetSessionVars().PlanID = 0
 	sctx.GetSessionVars().PlanColumnID = 0
+	// The point get on the primary key or a unique index skips the optimization.
+	if fp := plannercore.TryFastPlan(sctx, is, node); fp != nil {
+		return fp, fp.OutputNames(), nil
+	}
+
+	// build logical plan
 	builder := plannercore.NewPlanBuilder(sctx, is)
 	p, err := builder.Build(ctx, node)
 	if err != nil {
@@ -55,6 +79,68 @@ func Optimize(ctx context.Context, sctx sessionctx.Context, node ast.Node, is in
 	return finalPlan, names, err
 }
 
+// OptimizeWithMemo optimizes the query by the cascades planner no matter
+// whether it is enabled, and returns the stringified memo as well. It is used
+// by EXPLAIN FORMAT='memo' to debug the decisions of the cascades planner.
+func OptimizeWithMemo(ctx context.Context, sctx sessionctx.Context, node ast.Node, is infoschema.InfoSchema) (plannercore.Plan, []string, error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "planner.OptimizeWithMemo")
+	defer span.Finish()
+	sctx.PrepareTxnFuture(ctx)
+
+	sctx.GetSessionVars().PlanID = 0
+	sctx.GetSessionVars().PlanColumnID = 0
+	builder := plannercore.NewPlanBuilder(sctx, is)
+	p, err := builder.Build(ctx, node)
+	if err != nil {
+		return nil, nil, err
+	}
+	logic, isLogicalPlan := p.(plannercore.LogicalPlan)
+	if !isLogicalPlan {
+		return nil, nil, errors.Errorf("explain format '%s' only supports queries", ast.ExplainFormatMemo)
+	}
+	finalPlan, rootGroup, err := cascades.DefaultOptimizer.FindBestPlanAndMemo(sctx, logic)
+	if err != nil {
+		return nil, nil, err
+	}
+	return finalPlan, cascades.MemoToString(rootGroup), nil
+}
+
+// getBindRecord returns the binding of the statement, the session binding
+// takes precedence over the global one.
+func getBindRecord(sctx sessionctx.Context, stmt ast.StmtNode) *bindinfo.BindRecord {
+	if stmt.Text() == "" {
+		return nil
+	}
+	normalizedSQL := parser.NormalizeForBinding(stmt.Text())
+	db := sctx.GetSessionVars().CurrentDB
+	if sessionHandle, ok := sctx.Value(bindinfo.SessionBindInfoKeyType).(*bindinfo.SessionHandle); ok {
+		if record := sessionHandle.GetBindRecord(normalizedSQL, db); record != nil {
+			return record
+		}
+	}
+	dom := domain.GetDomain(sctx)
+	if dom == nil || dom.BindHandle() == nil {
+		return nil
+	}
+	return dom.BindHandle().GetBindRecord(normalizedSQL, db)
+}
+
+// captureStmt counts the execution of the statement for capturing baselines,
+// the explained statements are not counted since they are not executed.
+func captureStmt(sctx sessionctx.Context, sel *ast.SelectStmt) {
+	sessVars := sctx.GetSessionVars()
+	if sessVars.StmtCtx.InExplainStmt {
+		return
+	}
+	dom := domain.GetDomain(sctx)
+	if dom == nil || dom.BindHandle() == nil || !dom.BindHandle().CaptureEnabled() {
+		return
+	}
+	charset, collation := sessVars.GetCharsetInfo()
+	dom.BindHandle().CaptureStmt(sel, sessVars.CurrentDB, charset, collation)
+}
+
 func init() {
 	plannercore.OptimizeAstNode = Optimize
+	plannercore.OptimizeAstNodeWithMemo = OptimizeWithMemo
 }
@@ -45,6 +45,13 @@ type AccessPath struct {
 	Forced bool
 
 	IsDNFCond bool
+
+	// PartialIndexPaths stores all the partial paths of an index merge path,
+	// each of them reads the handles by an index or the table.
+	PartialIndexPaths []*AccessPath
+	// IndexMergeIsIntersection indicates the handles of the partial paths are
+	// intersected, otherwise they are unioned.
+	IndexMergeIsIntersection bool
 }
 
 // SplitAccessCondFromFilters move the necessary filter in the form of index_col = constant to access conditions.
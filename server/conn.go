@@ -52,9 +52,11 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/arena"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/hack"
@@ -111,6 +113,22 @@ func (cc *clientConn) String() string {
 	)
 }
 
+// processInfo returns the process info of the connection, the user and host
+// are filled by the connection as the session does not know them.
+func (cc *clientConn) processInfo() *util.ProcessInfo {
+	if cc.ctx == nil {
+		return nil
+	}
+	pi := cc.ctx.ShowProcess()
+	if pi == nil {
+		return nil
+	}
+	info := *pi
+	info.User = cc.user
+	info.Host = cc.peerHost
+	return &info
+}
+
 // handshake works like TCP handshake, but in a higher level, it first writes initial packet to client,
 // during handshake, client and server negotiate compatible features and do authentication.
 // After handshake, client can send sql query to server.
@@ -151,7 +169,7 @@ func (cc *clientConn) Close() error {
 }
 
 func closeConn(cc *clientConn, connections int) error {
-
+	metrics.ConnGauge.Set(float64(connections))
 	err := cc.bufReadConn.Close()
 	terror.Log(err)
 	if cc.ctx != nil {
@@ -509,6 +527,13 @@ func (cc *clientConn) openSessionAndDoAuth() error {
 	if err != nil {
 		return err
 	}
+	cc.ctx.SetSessionManager(cc.server)
+	cc.ctx.SetProcessInfo("", time.Now(), mysql.ComSleep)
+	host, err := cc.PeerHost("")
+	if err != nil {
+		host = variable.DefHostname
+	}
+	cc.ctx.GetSessionVars().User = cc.user + "@" + host
 	if cc.dbname != "" {
 		err = cc.useDB(context.Background(), cc.dbname)
 		if err != nil {
@@ -624,6 +649,7 @@ func (cc *clientConn) Run(ctx context.Context) {
 			err1 := cc.writeError(err)
 			terror.Log(err1)
 		}
+		cc.ctx.SetProcessInfo("", time.Now(), mysql.ComSleep)
 
 		cc.pkt.sequence = 0
 	}
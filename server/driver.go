@@ -17,8 +17,11 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"time"
+
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/chunk"
 )
 
@@ -73,6 +76,15 @@ type QueryCtx interface {
 	GetSessionVars() *variable.SessionVars
 
 	SetCommandValue(command byte)
+
+	// ShowProcess returns the process info of the current statement.
+	ShowProcess() *util.ProcessInfo
+
+	// SetProcessInfo sets the process info of the current statement.
+	SetProcessInfo(sql string, t time.Time, command byte)
+
+	// SetSessionManager sets the session manager used by "show processlist".
+	SetSessionManager(util.SessionManager)
 }
 
 // PreparedStatement is the interface to use a prepared statement.
@@ -0,0 +1,1018 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap-incubator/tinykv/proto/pkg/metapb"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/parser/terror"
+	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/store/helper"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/admin"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+)
+
+const (
+	pColumnID   = "colID"
+	pColumnTp   = "colTp"
+	pColumnFlag = "colFlag"
+	pColumnLen  = "colLen"
+	pRowBin     = "rowBin"
+	pDBName     = "db"
+	pHexKey     = "hexKey"
+	pIndexName  = "index"
+	pHandle     = "handle"
+	pRegionID   = "regionID"
+	pStartTS    = "startTS"
+	pTableName  = "table"
+	pTableID    = "tableID"
+	pSnapshot   = "snapshot"
+)
+
+// For query string
+const (
+	qTableID = "table_id"
+	qLimit   = "limit"
+)
+
+const (
+	headerContentType = "Content-Type"
+	contentTypeJSON   = "application/json"
+)
+
+func writeError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	_, err = w.Write([]byte(err.Error()))
+	terror.Log(errors.Trace(err))
+}
+
+func writeInternalError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusInternalServerError)
+	_, err = w.Write([]byte(err.Error()))
+	terror.Log(errors.Trace(err))
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	js, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// write response
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(js)
+	terror.Log(errors.Trace(err))
+}
+
+// tikvHandlerTool is a tool to access the schema and the regions of the TiKV store.
+type tikvHandlerTool struct {
+	regionCache *tikv.RegionCache
+	store       tikv.Storage
+}
+
+// newTikvHandlerTool checks and prepares for tikv handler.
+// It returns an error if the server doesn't run on a TiKV store.
+func (s *Server) newTikvHandlerTool() (*tikvHandlerTool, error) {
+	var tikvStore tikv.Storage
+	store, ok := s.driver.(*TiDBDriver)
+	if !ok {
+		return nil, errors.New("Invalid KvStore with illegal driver")
+	}
+
+	if tikvStore, ok = store.store.(tikv.Storage); !ok {
+		return nil, errors.New("Invalid KvStore with illegal store")
+	}
+
+	return &tikvHandlerTool{
+		regionCache: tikvStore.GetRegionCache(),
+		store:       tikvStore,
+	}, nil
+}
+
+// unavailableHandler is the handler for the APIs which can't work on the store of the server,
+// it responds the reason with an internal server error.
+type unavailableHandler struct {
+	err error
+}
+
+// ServeHTTP handles request of an unavailable API.
+func (h unavailableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	writeInternalError(w, h.err)
+}
+
+// unsupportedHandler is the handler for the APIs which need the PD or the other components
+// that TinyKV doesn't provide, it responds the reason.
+type unsupportedHandler struct {
+	reason string
+}
+
+// ServeHTTP handles request of an unsupported API.
+func (h unsupportedHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	writeError(w, errors.New(h.reason))
+}
+
+func (t *tikvHandlerTool) domain() (*domain.Domain, error) {
+	return session.GetDomain(t.store)
+}
+
+func (t *tikvHandlerTool) schema() (infoschema.InfoSchema, error) {
+	dom, err := t.domain()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return dom.InfoSchema(), nil
+}
+
+func (t *tikvHandlerTool) getTable(dbName, tableName string) (table.Table, error) {
+	schema, err := t.schema()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return schema.TableByName(model.NewCIStr(dbName), model.NewCIStr(tableName))
+}
+
+func (t *tikvHandlerTool) getMvccByHandle(tableID, handle int64) (*tikvrpc.MvccGetByKeyResponse, error) {
+	encodedKey := tablecodec.EncodeRowKeyWithHandle(tableID, handle)
+	return helper.NewHelper(t.store).GetMvccByEncodedKey(encodedKey)
+}
+
+func (t *tikvHandlerTool) getMvccByIdxValue(idx table.Index, values url.Values, idxCols []*model.ColumnInfo, handleStr string) (*tikvrpc.MvccGetByKeyResponse, error) {
+	sc := new(stmtctx.StatementContext)
+	// HTTP request is not a database session, set timezone to UTC directly here.
+	// See docs/tidb_http_api.md for more details.
+	sc.TimeZone = time.UTC
+	idxRow, err := t.formValue2DatumRow(sc, values, idxCols)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	handle, err := strconv.ParseInt(handleStr, 10, 64)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	encodedKey, _, err := idx.GenIndexKey(sc, idxRow, handle, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return helper.NewHelper(t.store).GetMvccByEncodedKey(encodedKey)
+}
+
+// formValue2DatumRow converts URL query string to a Datum Row.
+func (t *tikvHandlerTool) formValue2DatumRow(sc *stmtctx.StatementContext, values url.Values, idxCols []*model.ColumnInfo) ([]types.Datum, error) {
+	data := make([]types.Datum, len(idxCols))
+	for i, col := range idxCols {
+		colName := col.Name.String()
+		vals, ok := values[colName]
+		if !ok {
+			return nil, errors.BadRequestf("Missing value for index column %s.", colName)
+		}
+
+		switch len(vals) {
+		case 0:
+			data[i].SetNull()
+		case 1:
+			bDatum := types.NewStringDatum(vals[0])
+			cDatum, err := bDatum.ConvertTo(sc, &col.FieldType)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			data[i] = cDatum
+		default:
+			return nil, errors.BadRequestf("Invalid query form for column '%s', it's values are %v."+
+				" Column value should be unique for one index record.", colName, vals)
+		}
+	}
+	return data, nil
+}
+
+// RegionMeta contains a region's peer detail
+type RegionMeta struct {
+	ID          uint64              `json:"region_id"`
+	Leader      *metapb.Peer        `json:"leader"`
+	Peers       []*metapb.Peer      `json:"peers"`
+	RegionEpoch *metapb.RegionEpoch `json:"region_epoch"`
+}
+
+// scanRegions returns the metas of all the regions which intersect with [startKey, endKey).
+func (t *tikvHandlerTool) scanRegions(startKey, endKey []byte) ([]RegionMeta, error) {
+	regions, peers, err := t.regionCache.PDClient().ScanRegions(context.Background(), startKey, endKey, 0)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	metas := make([]RegionMeta, 0, len(regions))
+	for i, region := range regions {
+		metas = append(metas, RegionMeta{
+			ID:          region.Id,
+			Leader:      peers[i],
+			Peers:       region.Peers,
+			RegionEpoch: region.RegionEpoch,
+		})
+	}
+	return metas, nil
+}
+
+// IndexRegions is the region info for one index.
+type IndexRegions struct {
+	Name    string       `json:"name"`
+	ID      int64        `json:"id"`
+	Regions []RegionMeta `json:"regions"`
+}
+
+// TableRegions is the response data for list table's regions.
+// It contains regions list for record and indices.
+type TableRegions struct {
+	TableName     string         `json:"name"`
+	TableID       int64          `json:"id"`
+	RecordRegions []RegionMeta   `json:"record_regions"`
+	Indices       []IndexRegions `json:"indices"`
+}
+
+// RegionDetail is the response data for get region by ID.
+// It includes the table and the indices whose data is in the region.
+type RegionDetail struct {
+	RegionID uint64       `json:"region_id"`
+	StartKey []byte       `json:"start_key"`
+	EndKey   []byte       `json:"end_key"`
+	Frames   []*FrameItem `json:"frames"`
+}
+
+// FrameItem includes a index's or record's meta data with table's info.
+type FrameItem struct {
+	DBName    string `json:"db_name"`
+	TableName string `json:"table_name"`
+	TableID   int64  `json:"table_id"`
+	IsRecord  bool   `json:"is_record"`
+	IndexName string `json:"index_name,omitempty"`
+	IndexID   int64  `json:"index_id,omitempty"`
+}
+
+// keyRangeIntersects checks whether [startKey, endKey) intersects with [regionStart, regionEnd),
+// an empty regionEnd means the range is unbounded.
+func keyRangeIntersects(startKey, endKey, regionStart, regionEnd []byte) bool {
+	if len(regionEnd) > 0 && bytes.Compare(startKey, regionEnd) >= 0 {
+		return false
+	}
+	return bytes.Compare(regionStart, endKey) < 0
+}
+
+// addTableFrames appends the frames of the tables and the indices which are in the region.
+func (rt *RegionDetail) addTableFrames(schema infoschema.InfoSchema) {
+	for _, db := range schema.AllSchemas() {
+		for _, tbl := range db.Tables {
+			start, end := tablecodec.GetTableHandleKeyRange(tbl.ID)
+			if keyRangeIntersects(start, end, rt.StartKey, rt.EndKey) {
+				rt.Frames = append(rt.Frames, &FrameItem{
+					DBName:    db.Name.O,
+					TableName: tbl.Name.O,
+					TableID:   tbl.ID,
+					IsRecord:  true,
+				})
+			}
+			for _, idx := range tbl.Indices {
+				start, end = tablecodec.GetTableIndexKeyRange(tbl.ID, idx.ID)
+				if keyRangeIntersects(start, end, rt.StartKey, rt.EndKey) {
+					rt.Frames = append(rt.Frames, &FrameItem{
+						DBName:    db.Name.O,
+						TableName: tbl.Name.O,
+						TableID:   tbl.ID,
+						IndexName: idx.Name.O,
+						IndexID:   idx.ID,
+					})
+				}
+			}
+		}
+	}
+}
+
+// mvccTxnHandler is the handler for txn debugger.
+type mvccTxnHandler struct {
+	*tikvHandlerTool
+	op string
+}
+
+const (
+	opMvccGetByHex = "hex"
+	opMvccGetByKey = "key"
+	opMvccGetByIdx = "idx"
+	opMvccGetByTxn = "txn"
+)
+
+// schemaHandler is the handler for list database or table schemas.
+type schemaHandler struct {
+	*tikvHandlerTool
+}
+
+// dbTableHandler is the handler for query database and table info by a table ID.
+type dbTableHandler struct {
+	*tikvHandlerTool
+}
+
+// tableRegionsHandler is the handler for list the regions of a table.
+type tableRegionsHandler struct {
+	*tikvHandlerTool
+}
+
+// regionHandler is the handler for list all regions' meta or get a region's detail by ID.
+type regionHandler struct {
+	*tikvHandlerTool
+}
+
+// settingsHandler is the handler for list tidb server settings.
+type settingsHandler struct {
+}
+
+// serverInfoHandler is the handler for getting the tidb server info.
+type serverInfoHandler struct {
+	*tikvHandlerTool
+}
+
+// ddlHistoryJobHandler is the handler for list job history.
+type ddlHistoryJobHandler struct {
+	*tikvHandlerTool
+}
+
+// ddlResignOwnerHandler is the handler for resigning ddl owner.
+type ddlResignOwnerHandler struct {
+	*tikvHandlerTool
+}
+
+// allServerInfoHandler is the handler for getting all the tidb servers' info.
+type allServerInfoHandler struct {
+	*tikvHandlerTool
+}
+
+// valueHandler is the handler for get value.
+type valueHandler struct {
+}
+
+// statsHandler is the handler for dumping the statistics of a table.
+type statsHandler struct {
+	*tikvHandlerTool
+}
+
+// ServeHTTP handles request of list a database or table's schemas.
+func (h schemaHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	schema, err := h.schema()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// parse params
+	params := mux.Vars(req)
+
+	if dbName, ok := params[pDBName]; ok {
+		cDBName := model.NewCIStr(dbName)
+		if tableName, ok := params[pTableName]; ok {
+			// table schema of a specified table name
+			cTableName := model.NewCIStr(tableName)
+			data, err := schema.TableByName(cDBName, cTableName)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeData(w, data.Meta())
+			return
+		}
+		// all table schemas in a specified database
+		if schema.SchemaExists(cDBName) {
+			tbs := schema.SchemaTables(cDBName)
+			tbsInfo := make([]*model.TableInfo, len(tbs))
+			for i := range tbsInfo {
+				tbsInfo[i] = tbs[i].Meta()
+			}
+			writeData(w, tbsInfo)
+			return
+		}
+		writeError(w, infoschema.ErrDatabaseNotExists.GenWithStackByArgs(dbName))
+		return
+	}
+
+	if tableID := req.FormValue(qTableID); len(tableID) > 0 {
+		// table schema of a specified tableID
+		tid, err := strconv.Atoi(tableID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if tid < 0 {
+			writeError(w, infoschema.ErrTableNotExists.GenWithStack("Table which ID = %s does not exist.", tableID))
+			return
+		}
+		if data, ok := schema.TableByID(int64(tid)); ok {
+			writeData(w, data.Meta())
+			return
+		}
+		writeError(w, infoschema.ErrTableNotExists.GenWithStack("Table which ID = %s does not exist.", tableID))
+		return
+	}
+
+	// all databases' schemas
+	writeData(w, schema.AllSchemas())
+}
+
+// dbTableInfo is used to report the database, table information and the current schema version.
+type dbTableInfo struct {
+	DBInfo        *model.DBInfo    `json:"db_info"`
+	TableInfo     *model.TableInfo `json:"table_info"`
+	SchemaVersion int64            `json:"schema_version"`
+}
+
+// ServeHTTP handles request of database information and table information by tableID.
+func (h dbTableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	tableID := params[pTableID]
+	physicalID, err := strconv.Atoi(tableID)
+	if err != nil {
+		writeError(w, errors.Errorf("Wrong tableID: %v", tableID))
+		return
+	}
+
+	schema, err := h.schema()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	dbTblInfo := dbTableInfo{
+		SchemaVersion: schema.SchemaMetaVersion(),
+	}
+	tbl, ok := schema.TableByID(int64(physicalID))
+	if !ok {
+		writeError(w, infoschema.ErrTableNotExists.GenWithStack("Table which ID = %s does not exist.", tableID))
+		return
+	}
+	dbTblInfo.TableInfo = tbl.Meta()
+	dbInfo, ok := schema.SchemaByTable(dbTblInfo.TableInfo)
+	if !ok {
+		logutil.BgLogger().Error("can not find the database of the table", zap.Int64("table id", dbTblInfo.TableInfo.ID), zap.String("table name", dbTblInfo.TableInfo.Name.L))
+		writeError(w, infoschema.ErrTableNotExists.GenWithStack("Table which ID = %s does not exist.", tableID))
+		return
+	}
+	dbTblInfo.DBInfo = dbInfo
+	writeData(w, dbTblInfo)
+}
+
+// ServeHTTP handles request of list a table's regions.
+func (h tableRegionsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// parse params
+	params := mux.Vars(req)
+	dbName := params[pDBName]
+	tableName := params[pTableName]
+	schema, err := h.schema()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// get table's schema.
+	tableVal, err := schema.TableByName(model.NewCIStr(dbName), model.NewCIStr(tableName))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tblInfo := tableVal.Meta()
+
+	// for record
+	startKey, endKey := tablecodec.GetTableHandleKeyRange(tblInfo.ID)
+	recordRegions, err := h.scanRegions(startKey, endKey)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// for indices
+	indices := make([]IndexRegions, len(tblInfo.Indices))
+	for i, index := range tblInfo.Indices {
+		indices[i].ID = index.ID
+		indices[i].Name = index.Name.String()
+		startKey, endKey := tablecodec.GetTableIndexKeyRange(tblInfo.ID, index.ID)
+		indices[i].Regions, err = h.scanRegions(startKey, endKey)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	tableRegions := &TableRegions{
+		TableName:     tableName,
+		TableID:       tblInfo.ID,
+		RecordRegions: recordRegions,
+		Indices:       indices,
+	}
+	writeData(w, tableRegions)
+}
+
+// ServeHTTP handles request of get region by ID.
+func (h regionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// parse and check params
+	params := mux.Vars(req)
+	if _, ok := params[pRegionID]; !ok {
+		// list all regions' meta
+		regions, err := h.scanRegions(nil, nil)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeData(w, regions)
+		return
+	}
+
+	regionID, err := strconv.ParseUint(params[pRegionID], 10, 64)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// locate region
+	region, _, err := h.regionCache.PDClient().GetRegionByID(context.Background(), regionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if region == nil {
+		writeError(w, errors.Errorf("region %d does not exist", regionID))
+		return
+	}
+
+	regionDetail := &RegionDetail{
+		RegionID: regionID,
+		StartKey: region.StartKey,
+		EndKey:   region.EndKey,
+	}
+	schema, err := h.schema()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	regionDetail.addTableFrames(schema)
+	writeData(w, regionDetail)
+}
+
+// ServeHTTP handles request of list a tidb server settings.
+func (h settingsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == "POST" {
+		err := req.ParseForm()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if levelStr := req.Form.Get("log_level"); levelStr != "" {
+			err1 := logutil.SetLevel(levelStr)
+			if err1 != nil {
+				writeError(w, err1)
+				return
+			}
+			config.GetGlobalConfig().Log.Level = levelStr
+		}
+		if generalLog := req.Form.Get("tidb_general_log"); generalLog != "" {
+			switch generalLog {
+			case "0":
+				atomic.StoreUint32(&variable.ProcessGeneralLog, 0)
+			case "1":
+				atomic.StoreUint32(&variable.ProcessGeneralLog, 1)
+			default:
+				writeError(w, errors.New("illegal argument"))
+				return
+			}
+		}
+		if threshold := req.Form.Get("ddl_slow_threshold"); threshold != "" {
+			threshold, err1 := strconv.ParseUint(threshold, 10, 32)
+			if err1 != nil {
+				writeError(w, err1)
+				return
+			}
+			atomic.StoreUint32(&variable.DDLSlowOprThreshold, uint32(threshold))
+		}
+		w.WriteHeader(http.StatusOK)
+	} else {
+		writeData(w, config.GetGlobalConfig())
+	}
+}
+
+// serverInfo is used to report the servers info when do http request.
+type serverInfo struct {
+	Version    string `json:"version"`
+	GitHash    string `json:"git_hash"`
+	DDLID      string `json:"ddl_id"`
+	IP         string `json:"ip"`
+	Port       uint   `json:"listening_port"`
+	StatusPort uint   `json:"status_port"`
+	Lease      string `json:"lease"`
+	IsOwner    bool   `json:"is_owner"`
+}
+
+// ServeHTTP handles request of ddl server info.
+func (h serverInfoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	do, err := h.domain()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, newServerInfo(do))
+}
+
+func newServerInfo(do *domain.Domain) serverInfo {
+	cfg := config.GetGlobalConfig()
+	return serverInfo{
+		Version:    mysql.ServerVersion,
+		GitHash:    mysql.TiDBGitHash,
+		DDLID:      do.DDL().GetID(),
+		IP:         cfg.AdvertiseAddress,
+		Port:       cfg.Port,
+		StatusPort: cfg.Status.StatusPort,
+		Lease:      do.DDL().GetLease().String(),
+		IsOwner:    do.DDL().OwnerManager().IsOwner(),
+	}
+}
+
+// clusterServerInfo is used to report cluster servers info when do http request.
+type clusterServerInfo struct {
+	ServersNum                   int                    `json:"servers_num,omitempty"`
+	OwnerID                      string                 `json:"owner_id"`
+	IsAllServerVersionConsistent bool                   `json:"is_all_server_version_consistent,omitempty"`
+	AllServersDiffVersions       []string               `json:"all_servers_diff_versions,omitempty"`
+	AllServersInfo               map[string]*serverInfo `json:"all_servers_info,omitempty"`
+}
+
+// ServeHTTP handles request of all ddl servers info.
+// The servers don't register their info anywhere with TinyKV, so only the server handling the request is listed.
+func (h allServerInfoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	do, err := h.domain()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ownerID, err := do.DDL().OwnerManager().GetOwnerID(context.Background())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	info := newServerInfo(do)
+	clusterInfo := clusterServerInfo{
+		ServersNum:                   1,
+		OwnerID:                      ownerID,
+		IsAllServerVersionConsistent: true,
+		AllServersInfo:               map[string]*serverInfo{info.DDLID: &info},
+	}
+	writeData(w, clusterInfo)
+}
+
+// ServeHTTP handles request of ddl jobs history.
+func (h ddlHistoryJobHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	jobLimit := math.MaxInt32
+	if limitID := req.FormValue(qLimit); len(limitID) > 0 {
+		lid, err := strconv.Atoi(limitID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if lid < 1 {
+			writeError(w, errors.New("ddl history limit must be greater than 1"))
+			return
+		}
+		jobLimit = lid
+	}
+
+	txn, err := h.store.Begin()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer func() {
+		terror.Log(txn.Rollback())
+	}()
+	jobs, err := admin.GetHistoryDDLJobs(txn, jobLimit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, jobs)
+}
+
+// ServeHTTP handles request of resigning ddl owner.
+func (h ddlResignOwnerHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, errors.Errorf("This api only support POST method."))
+		return
+	}
+
+	dom, err := h.domain()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ownerMgr := dom.DDL().OwnerManager()
+	if !ownerMgr.IsOwner() {
+		writeError(w, errors.New("This node is not a ddl owner, can't be resigned."))
+		return
+	}
+	err = ownerMgr.ResignOwner(context.Background())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, "success!")
+}
+
+// ServeHTTP handles request of get the value of a column from an encoded row.
+func (vh valueHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// parse params
+	params := mux.Vars(req)
+
+	colID, err := strconv.ParseInt(params[pColumnID], 0, 64)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	colTp, err := strconv.ParseInt(params[pColumnTp], 0, 64)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	colFlag, err := strconv.ParseUint(params[pColumnFlag], 0, 64)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	colLen, err := strconv.ParseInt(params[pColumnLen], 0, 64)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Get the unchanged binary.
+	if req.URL == nil {
+		writeError(w, errors.BadRequestf("Invalid URL"))
+		return
+	}
+	values := make(url.Values)
+	err = parseQuery(req.URL.RawQuery, values, false)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(values[pRowBin]) != 1 {
+		writeError(w, errors.BadRequestf("Invalid Query:%v", values[pRowBin]))
+		return
+	}
+	valData, err := base64.StdEncoding.DecodeString(values[pRowBin][0])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Construct field type.
+	defaultDecimal := 6
+	ft := &types.FieldType{
+		Tp:      byte(colTp),
+		Flag:    uint(colFlag),
+		Flen:    int(colLen),
+		Decimal: defaultDecimal,
+	}
+	// Decode a column, the HTTP request is not a database session, so the timezone is UTC.
+	m := make(map[int64]*types.FieldType, 1)
+	m[colID] = ft
+	vals, err := tablecodec.DecodeRow(valData, m, time.UTC)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	v := vals[colID]
+	valStr, err := v.ToString()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, valStr)
+}
+
+// ServeHTTP handles request of dump the statistics of a table, the statistics at a history
+// version are dumped if the snapshot time is given.
+func (sh statsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	do, err := sh.domain()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tbl, err := do.InfoSchema().TableByName(model.NewCIStr(params[pDBName]), model.NewCIStr(params[pTableName]))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h := do.StatsHandle()
+	if h == nil {
+		writeInternalError(w, errors.New("the statistics handle is not initialized"))
+		return
+	}
+
+	var jsonTbl *statistics.JSONTable
+	if snapshotStr, ok := params[pSnapshot]; ok {
+		snapshot, err1 := parseSnapshotTS(snapshotStr)
+		if err1 != nil {
+			writeError(w, err1)
+			return
+		}
+		jsonTbl, err = h.DumpStatsToJSONBySnapshot(params[pDBName], tbl.Meta(), snapshot)
+	} else {
+		jsonTbl, err = h.DumpStatsToJSON(params[pDBName], tbl.Meta())
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, jsonTbl)
+}
+
+// parseSnapshotTS parses the time in the format of "yyyyMMddHHmmss" or "yyyy-MM-dd HH:mm:ss"
+// in the local timezone to a timestamp.
+func parseSnapshotTS(snapshot string) (uint64, error) {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", snapshot, time.Local)
+	if err != nil {
+		t, err = time.ParseInLocation("20060102150405", snapshot, time.Local)
+		if err != nil {
+			return 0, errors.BadRequestf("Invalid snapshot time %s", snapshot)
+		}
+	}
+	return variable.GoTimeToTS(t), nil
+}
+
+// ServeHTTP handles request of get the MVCC info of a key.
+func (h mvccTxnHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var data interface{}
+	params := mux.Vars(req)
+	var err error
+	switch h.op {
+	case opMvccGetByHex:
+		data, err = h.handleMvccGetByHex(params)
+	case opMvccGetByIdx:
+		if req.URL == nil {
+			err = errors.BadRequestf("Invalid URL")
+			break
+		}
+		values := make(url.Values)
+		err = parseQuery(req.URL.RawQuery, values, true)
+		if err == nil {
+			data, err = h.handleMvccGetByIdx(params, values)
+		}
+	case opMvccGetByKey:
+		data, err = h.handleMvccGetByKey(params)
+	case opMvccGetByTxn:
+		data, err = h.handleMvccGetByTxn(params)
+	default:
+		err = errors.NotSupportedf("Operation not supported.")
+	}
+	if err != nil {
+		writeError(w, err)
+	} else {
+		writeData(w, data)
+	}
+}
+
+func (h mvccTxnHandler) handleMvccGetByIdx(params map[string]string, values url.Values) (interface{}, error) {
+	dbName := params[pDBName]
+	tableName := params[pTableName]
+	handleStr := params[pHandle]
+
+	t, err := h.getTable(dbName, tableName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var idxCols []*model.ColumnInfo
+	var idx table.Index
+	for _, v := range t.Indices() {
+		if strings.EqualFold(v.Meta().Name.String(), params[pIndexName]) {
+			for _, c := range v.Meta().Columns {
+				idxCols = append(idxCols, t.Meta().Columns[c.Offset])
+			}
+			idx = v
+			break
+		}
+	}
+	if idx == nil {
+		return nil, errors.NotFoundf("Index %s not found!", params[pIndexName])
+	}
+	return h.getMvccByIdxValue(idx, values, idxCols, handleStr)
+}
+
+func (h mvccTxnHandler) handleMvccGetByKey(params map[string]string) (interface{}, error) {
+	handle, err := strconv.ParseInt(params[pHandle], 0, 64)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	tb, err := h.getTable(params[pDBName], params[pTableName])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return h.getMvccByHandle(tb.Meta().ID, handle)
+}
+
+func (h mvccTxnHandler) handleMvccGetByTxn(params map[string]string) (interface{}, error) {
+	startTS, err := strconv.ParseInt(params[pStartTS], 0, 64)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tb, err := h.getTable(params[pDBName], params[pTableName])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	startKey := tablecodec.EncodeTablePrefix(tb.Meta().ID)
+	endKey := tablecodec.EncodeTablePrefix(tb.Meta().ID + 1)
+	return helper.NewHelper(h.store).GetMvccByStartTs(uint64(startTS), startKey, endKey)
+}
+
+func (h mvccTxnHandler) handleMvccGetByHex(params map[string]string) (interface{}, error) {
+	encodedKey, err := hex.DecodeString(params[pHexKey])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return helper.NewHelper(h.store).GetMvccByEncodedKey(encodedKey)
+}
+
+// parseQuery is used to parse query string in URL with shouldUnescape, due to golang http package can not distinguish
+// query like "?a=" and "?a". We rewrite it to separate these two queries. e.g.
+// "?a=" which means that a is an empty string "";
+// "?a"  which means that a is null.
+// If shouldUnescape is true, we use QueryUnescape to handle keys and values that will be put in m.
+// If shouldUnescape is false, we don't use QueryUnescap to handle.
+func parseQuery(query string, m url.Values, shouldUnescape bool) error {
+	var err error
+	for query != "" {
+		key := query
+		if i := strings.IndexAny(key, "&;"); i >= 0 {
+			key, query = key[:i], key[i+1:]
+		} else {
+			query = ""
+		}
+		if key == "" {
+			continue
+		}
+		if i := strings.Index(key, "="); i >= 0 {
+			value := ""
+			key, value = key[:i], key[i+1:]
+			if shouldUnescape {
+				key, err = url.QueryUnescape(key)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				value, err = url.QueryUnescape(value)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
+			m[key] = append(m[key], value)
+		} else {
+			if shouldUnescape {
+				key, err = url.QueryUnescape(key)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
+			if _, ok := m[key]; !ok {
+				m[key] = nil
+			}
+		}
+	}
+	return errors.Trace(err)
+}
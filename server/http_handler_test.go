@@ -0,0 +1,363 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// +build !race
+
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap-incubator/tinykv/proto/pkg/kvrpcpb"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/mock"
+)
+
+func (ts *TidbTestSuite) statusURL(path string) string {
+	return fmt.Sprintf("http://127.0.0.1:10090%s", path)
+}
+
+func (ts *TidbTestSuite) getJSON(c *C, path string, v interface{}) {
+	resp, err := http.Get(ts.statusURL(path))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK, Commentf("%s: %s", path, body))
+	c.Assert(json.Unmarshal(body, v), IsNil)
+}
+
+func (ts *TidbTestSuite) prepareHTTPData(c *C) {
+	se, err := session.CreateSession(ts.store)
+	c.Assert(err, IsNil)
+	defer se.Close()
+	for _, sql := range []string{
+		"create database if not exists tidb_http",
+		"use tidb_http",
+		"drop table if exists t",
+		"create table t (a int, b varchar(20), index idx(b))",
+		"insert into t values (1, 'a'), (2, 'b')",
+	} {
+		_, err = se.Execute(context.Background(), sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	}
+}
+
+func (ts *TidbTestSuite) TestStatusAPI(c *C) {
+	var st status
+	ts.getJSON(c, "/status", &st)
+	c.Assert(st.Version, Not(Equals), "")
+	c.Assert(st.GitHash, Not(Equals), "")
+
+	var info serverInfo
+	ts.getJSON(c, "/info", &info)
+	c.Assert(info.DDLID, Not(Equals), "")
+	c.Assert(info.IsOwner, IsTrue)
+
+	var clusterInfo clusterServerInfo
+	ts.getJSON(c, "/info/all", &clusterInfo)
+	c.Assert(clusterInfo.ServersNum, Equals, 1)
+	c.Assert(clusterInfo.OwnerID, Equals, info.DDLID)
+	c.Assert(clusterInfo.IsAllServerVersionConsistent, IsTrue)
+	c.Assert(clusterInfo.AllServersInfo[info.DDLID].Version, Equals, info.Version)
+}
+
+func (ts *TidbTestSuite) TestUnavailableAPI(c *C) {
+	// The server doesn't run on a TiKV store.
+	_, err := (&Server{driver: NewTiDBDriver(&mock.Store{})}).newTikvHandlerTool()
+	c.Assert(err, NotNil)
+	recorder := httptest.NewRecorder()
+	unavailableHandler{err}.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/schema", nil))
+	c.Assert(recorder.Code, Equals, http.StatusInternalServerError)
+
+	for _, path := range []string{"/regions/hot", "/tables/test/t/scatter", "/tables/test/t/stop-scatter", "/binlog/recover"} {
+		resp, err := http.Get(ts.statusURL(path))
+		c.Assert(err, IsNil)
+		body, err := ioutil.ReadAll(resp.Body)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+		c.Assert(string(body), Matches, ".*not supported.*", Commentf("path: %s", path))
+	}
+}
+
+func (ts *TidbTestSuite) TestSchemaAPI(c *C) {
+	ts.prepareHTTPData(c)
+
+	var dbs []*model.DBInfo
+	ts.getJSON(c, "/schema", &dbs)
+	found := false
+	for _, db := range dbs {
+		if db.Name.L == "tidb_http" {
+			found = true
+		}
+	}
+	c.Assert(found, IsTrue)
+
+	var tbls []*model.TableInfo
+	ts.getJSON(c, "/schema/tidb_http", &tbls)
+	c.Assert(tbls, HasLen, 1)
+	c.Assert(tbls[0].Name.L, Equals, "t")
+
+	var tbl model.TableInfo
+	ts.getJSON(c, "/schema/tidb_http/t", &tbl)
+	c.Assert(tbl.Name.L, Equals, "t")
+	c.Assert(tbl.Columns, HasLen, 2)
+	c.Assert(tbl.Indices, HasLen, 1)
+
+	var tblByID model.TableInfo
+	ts.getJSON(c, fmt.Sprintf("/schema?table_id=%d", tbl.ID), &tblByID)
+	c.Assert(tblByID.Name.L, Equals, "t")
+
+	var dbTbl dbTableInfo
+	ts.getJSON(c, fmt.Sprintf("/db-table/%d", tbl.ID), &dbTbl)
+	c.Assert(dbTbl.DBInfo.Name.L, Equals, "tidb_http")
+	c.Assert(dbTbl.TableInfo.ID, Equals, tbl.ID)
+	c.Assert(dbTbl.SchemaVersion > 0, IsTrue)
+
+	resp, err := http.Get(ts.statusURL("/schema/tidb_http/not_exists"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+}
+
+func (ts *TidbTestSuite) TestRegionsAPI(c *C) {
+	ts.prepareHTTPData(c)
+
+	var regions []RegionMeta
+	ts.getJSON(c, "/regions/meta", &regions)
+	c.Assert(len(regions) > 0, IsTrue)
+	c.Assert(regions[0].Leader, NotNil)
+
+	var tableRegions TableRegions
+	ts.getJSON(c, "/tables/tidb_http/t/regions", &tableRegions)
+	c.Assert(tableRegions.TableName, Equals, "t")
+	c.Assert(len(tableRegions.RecordRegions) > 0, IsTrue)
+	c.Assert(tableRegions.Indices, HasLen, 1)
+	c.Assert(tableRegions.Indices[0].Name, Equals, "idx")
+	c.Assert(len(tableRegions.Indices[0].Regions) > 0, IsTrue)
+
+	var detail RegionDetail
+	ts.getJSON(c, fmt.Sprintf("/regions/%d", tableRegions.RecordRegions[0].ID), &detail)
+	c.Assert(detail.RegionID, Equals, tableRegions.RecordRegions[0].ID)
+	found := false
+	for _, frame := range detail.Frames {
+		if frame.TableID == tableRegions.TableID && frame.IsRecord {
+			found = true
+		}
+	}
+	c.Assert(found, IsTrue)
+}
+
+func (ts *TidbTestSuite) TestKeyRangeIntersects(c *C) {
+	start, end := tablecodec.GetTableHandleKeyRange(10)
+	c.Assert(keyRangeIntersects(start, end, nil, nil), IsTrue)
+	c.Assert(keyRangeIntersects(start, end, tablecodec.EncodeTablePrefix(11), nil), IsFalse)
+	c.Assert(keyRangeIntersects(start, end, nil, tablecodec.EncodeTablePrefix(10)), IsFalse)
+	c.Assert(keyRangeIntersects(start, end, tablecodec.EncodeTablePrefix(10), tablecodec.EncodeTablePrefix(11)), IsTrue)
+}
+
+func (ts *TidbTestSuite) TestSettingsAPI(c *C) {
+	var cfg config.Config
+	ts.getJSON(c, "/settings", &cfg)
+	c.Assert(cfg.Port, Equals, config.GetGlobalConfig().Port)
+
+	form := make(url.Values)
+	form.Set("log_level", "error")
+	form.Set("tidb_general_log", "1")
+	resp, err := http.PostForm(ts.statusURL("/settings"), form)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	resp.Body.Close()
+	c.Assert(config.GetGlobalConfig().Log.Level, Equals, "error")
+
+	form.Set("log_level", "info")
+	form.Set("tidb_general_log", "0")
+	resp, err = http.PostForm(ts.statusURL("/settings"), form)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	resp.Body.Close()
+
+	form = make(url.Values)
+	form.Set("tidb_general_log", "2")
+	resp, err = http.PostForm(ts.statusURL("/settings"), form)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+
+	form = make(url.Values)
+	form.Set("ddl_slow_threshold", "200")
+	resp, err = http.PostForm(ts.statusURL("/settings"), form)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	resp.Body.Close()
+	c.Assert(atomic.LoadUint32(&variable.DDLSlowOprThreshold), Equals, uint32(200))
+	atomic.StoreUint32(&variable.DDLSlowOprThreshold, variable.DefTiDBDDLSlowOprThreshold)
+}
+
+func (ts *TidbTestSuite) TestValueAPI(c *C) {
+	sc := &stmtctx.StatementContext{TimeZone: time.UTC}
+	rowBin, err := tablecodec.EncodeRow(sc, []types.Datum{types.NewIntDatum(5), types.NewStringDatum("abc")}, []int64{1, 2}, nil, nil)
+	c.Assert(err, IsNil)
+	// The row value is not unescaped by the handler, so it is sent as is.
+	encoded := base64.StdEncoding.EncodeToString(rowBin)
+
+	var val string
+	ts.getJSON(c, fmt.Sprintf("/tables/1/%d/0/11?rowBin=%s", mysql.TypeLonglong, encoded), &val)
+	c.Assert(val, Equals, "5")
+	ts.getJSON(c, fmt.Sprintf("/tables/2/%d/0/20?rowBin=%s", mysql.TypeVarchar, encoded), &val)
+	c.Assert(val, Equals, "abc")
+
+	resp, err := http.Get(ts.statusURL("/tables/1/8/0/11"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+}
+
+func (ts *TidbTestSuite) TestStatsAPI(c *C) {
+	ts.prepareHTTPData(c)
+	se, err := session.CreateSession(ts.store)
+	c.Assert(err, IsNil)
+	defer se.Close()
+	_, err = se.Execute(context.Background(), "analyze table tidb_http.t")
+	c.Assert(err, IsNil)
+
+	var jsonTbl statistics.JSONTable
+	ts.getJSON(c, "/stats/dump/tidb_http/t", &jsonTbl)
+	c.Assert(jsonTbl.TableName, Equals, "t")
+	c.Assert(jsonTbl.Count, Equals, int64(2))
+	c.Assert(jsonTbl.Indices["idx"], NotNil)
+
+	// The time is truncated to seconds, so wait for a second to make the snapshot later than the analyze.
+	time.Sleep(time.Second)
+	snapshot := time.Now().Format("20060102150405")
+	time.Sleep(time.Second)
+	_, err = se.Execute(context.Background(), "insert into tidb_http.t values (3, 'c')")
+	c.Assert(err, IsNil)
+	_, err = se.Execute(context.Background(), "analyze table tidb_http.t")
+	c.Assert(err, IsNil)
+
+	ts.getJSON(c, "/stats/dump/tidb_http/t", &jsonTbl)
+	c.Assert(jsonTbl.Count, Equals, int64(3))
+	var historyTbl statistics.JSONTable
+	ts.getJSON(c, "/stats/dump/tidb_http/t/"+snapshot, &historyTbl)
+	c.Assert(historyTbl.Count, Equals, int64(2))
+	c.Assert(historyTbl.Indices["idx"], NotNil)
+
+	resp, err := http.Get(ts.statusURL("/stats/dump/tidb_http/t/yesterday"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+}
+
+func (ts *TidbTestSuite) TestDebugZipAPI(c *C) {
+	resp, err := http.Get(ts.statusURL("/debug/zip?seconds=1"))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	c.Assert(err, IsNil)
+	names := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	c.Assert(names, DeepEquals, []string{"goroutine", "heap", "mutex", "profile", "config", "version"})
+}
+
+func (ts *TidbTestSuite) TestDDLAPI(c *C) {
+	ts.prepareHTTPData(c)
+
+	var jobs []*model.Job
+	ts.getJSON(c, "/ddl/history", &jobs)
+	c.Assert(len(jobs) > 0, IsTrue)
+	var limitedJobs []*model.Job
+	ts.getJSON(c, "/ddl/history?limit=1", &limitedJobs)
+	c.Assert(limitedJobs, HasLen, 1)
+	// The latest job comes first.
+	c.Assert(limitedJobs[0].ID, Equals, jobs[0].ID)
+
+	resp, err := http.Get(ts.statusURL("/ddl/history?limit=0"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.statusURL("/ddl/owner/resign"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+}
+
+func (ts *TidbTestSuite) TestMvccAPI(c *C) {
+	ts.prepareHTTPData(c)
+
+	var byKey tikvrpc.MvccGetByKeyResponse
+	ts.getJSON(c, "/mvcc/key/tidb_http/t/1", &byKey)
+	c.Assert(byKey.Info, NotNil)
+	c.Assert(byKey.Info.Writes, HasLen, 1)
+	c.Assert(byKey.Info.Writes[0].Type, Equals, kvrpcpb.Op_Put)
+	startTS := byKey.Info.Writes[0].StartTs
+
+	var byTxn tikvrpc.MvccGetByStartTsResponse
+	ts.getJSON(c, fmt.Sprintf("/mvcc/txn/%d/tidb_http/t", startTS), &byTxn)
+	c.Assert(byTxn.Key, NotNil)
+	c.Assert(byTxn.Info.Writes[0].StartTs, Equals, startTS)
+
+	var byHex tikvrpc.MvccGetByKeyResponse
+	ts.getJSON(c, "/mvcc/hex/"+hex.EncodeToString(byTxn.Key), &byHex)
+	c.Assert(byHex.Info.Writes, HasLen, 1)
+	c.Assert(byHex.Info.Writes[0].StartTs, Equals, startTS)
+
+	var byIdx tikvrpc.MvccGetByKeyResponse
+	ts.getJSON(c, "/mvcc/index/tidb_http/t/idx/1?b=a", &byIdx)
+	c.Assert(byIdx.Info.Writes, HasLen, 1)
+	c.Assert(byIdx.Info.Writes[0].StartTs, Equals, startTS)
+
+	// The transaction has not written the table.
+	var notFound tikvrpc.MvccGetByStartTsResponse
+	ts.getJSON(c, "/mvcc/txn/1/tidb_http/t", &notFound)
+	c.Assert(notFound.Key, IsNil)
+
+	resp, err := http.Get(ts.statusURL("/mvcc/index/tidb_http/t/idx/1"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp.Body.Close()
+}
+
+func (ts *TidbTestSuite) TestParseQuery(c *C) {
+	values := make(url.Values)
+	c.Assert(parseQuery("a=1&b=&c&d=%20", values, true), IsNil)
+	c.Assert(values, DeepEquals, url.Values{"a": {"1"}, "b": {""}, "c": nil, "d": {" "}})
+}
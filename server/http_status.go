@@ -14,16 +14,28 @@
 package server
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"go.uber.org/zap"
+	"io"
 	"net"
 	"net/http"
 	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/parser/terror"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/soheilhy/cmux"
 )
 
@@ -41,6 +53,57 @@ func (s *Server) startHTTPServer() {
 		addr = fmt.Sprintf("%s:%d", s.cfg.Status.StatusHost, defaultStatusPort)
 	}
 
+	router.HandleFunc("/status", s.handleStatus).Name("Status")
+	router.Handle("/metrics", promhttp.Handler()).Name("Metrics")
+
+	tikvHandlerTool, err := s.newTikvHandlerTool()
+	if err != nil {
+		logutil.BgLogger().Warn("the HTTP APIs which need the TiKV store are unavailable", zap.Error(err))
+	}
+	// handleTiKV registers the handler which needs the TiKV store, the request gets an
+	// internal server error if the server doesn't run on a TiKV store.
+	handleTiKV := func(path string, handler http.Handler) *mux.Route {
+		if tikvHandlerTool == nil {
+			return router.Handle(path, unavailableHandler{err})
+		}
+		return router.Handle(path, handler)
+	}
+
+	// HTTP path for dump server info.
+	handleTiKV("/info", serverInfoHandler{tikvHandlerTool}).Name("Info")
+	handleTiKV("/info/all", allServerInfoHandler{tikvHandlerTool}).Name("InfoALL")
+	router.Handle("/settings", settingsHandler{}).Name("Settings")
+
+	// HTTP path for get the statistics of a table.
+	handleTiKV("/stats/dump/{db}/{table}", statsHandler{tikvHandlerTool}).Name("StatsDump")
+	handleTiKV("/stats/dump/{db}/{table}/{snapshot}", statsHandler{tikvHandlerTool}).Name("StatsHistoryDump")
+
+	// HTTP path for schema and regions.
+	handleTiKV("/schema", schemaHandler{tikvHandlerTool}).Name("Schema")
+	handleTiKV("/schema/{db}", schemaHandler{tikvHandlerTool})
+	handleTiKV("/schema/{db}/{table}", schemaHandler{tikvHandlerTool})
+	handleTiKV("/db-table/{tableID}", dbTableHandler{tikvHandlerTool})
+	handleTiKV("/tables/{db}/{table}/regions", tableRegionsHandler{tikvHandlerTool})
+	router.Handle("/tables/{db}/{table}/scatter", unsupportedHandler{"scattering the regions needs the scheduler of PD, which is not supported by TinyKV"})
+	router.Handle("/tables/{db}/{table}/stop-scatter", unsupportedHandler{"scattering the regions needs the scheduler of PD, which is not supported by TinyKV"})
+	router.Handle("/tables/{colID}/{colTp}/{colFlag}/{colLen}", valueHandler{})
+	handleTiKV("/regions/meta", regionHandler{tikvHandlerTool}).Name("RegionsMeta")
+	router.Handle("/regions/hot", unsupportedHandler{"the hot regions need the statistics of PD, which is not supported by TinyKV"}).Name("RegionHot")
+	handleTiKV("/regions/{regionID}", regionHandler{tikvHandlerTool})
+
+	// HTTP path for get MVCC info
+	handleTiKV("/mvcc/key/{db}/{table}/{handle}", mvccTxnHandler{tikvHandlerTool, opMvccGetByKey})
+	handleTiKV("/mvcc/txn/{startTS}/{db}/{table}", mvccTxnHandler{tikvHandlerTool, opMvccGetByTxn})
+	handleTiKV("/mvcc/hex/{hexKey}", mvccTxnHandler{tikvHandlerTool, opMvccGetByHex})
+	handleTiKV("/mvcc/index/{db}/{table}/{index}/{handle}", mvccTxnHandler{tikvHandlerTool, opMvccGetByIdx})
+
+	// HTTP path for DDL.
+	handleTiKV("/ddl/history", ddlHistoryJobHandler{tikvHandlerTool}).Name("DDL_History")
+	handleTiKV("/ddl/owner/resign", ddlResignOwnerHandler{tikvHandlerTool}).Name("DDL_Owner_Resign")
+
+	// HTTP path for binlog.
+	router.Handle("/binlog/recover", unsupportedHandler{"binlog is not supported by this server, there is no Pump to recover"})
+
 	serverMux := http.NewServeMux()
 	serverMux.Handle("/", router)
 
@@ -49,11 +112,11 @@ func (s *Server) startHTTPServer() {
 	serverMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	serverMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	serverMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	serverMux.HandleFunc("/debug/zip", handleDebugZip)
 
 	var (
 		httpRouterPage bytes.Buffer
 		pathTemplate   string
-		err            error
 	)
 
 	err = router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -83,6 +146,114 @@ func (s *Server) startHTTPServer() {
 	s.setupStatusServer(addr, serverMux)
 }
 
+// handleDebugZip responds a zip file of the profiles, the config and the version of the server,
+// the cpu is profiled for the seconds of the request, 10s by default.
+func handleDebugZip(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tidb_debug%s.zip"`, time.Now().Format("20060102150405")))
+
+	zw := zip.NewWriter(w)
+	writeItem := func(name string, write func(io.Writer) error) bool {
+		fw, err := zw.Create(name)
+		if err == nil {
+			err = write(fw)
+		}
+		if err != nil {
+			logutil.BgLogger().Error("write debug zip failed", zap.String("item", name), zap.Error(err))
+			return false
+		}
+		return true
+	}
+
+	// dump goroutine/heap/mutex
+	items := []struct {
+		name  string
+		gc    bool
+		debug int
+	}{
+		{name: "goroutine", debug: 2},
+		{name: "heap", gc: true},
+		{name: "mutex"},
+	}
+	for _, item := range items {
+		if item.gc {
+			runtime.GC()
+		}
+		p := rpprof.Lookup(item.name)
+		if !writeItem(item.name, func(fw io.Writer) error { return p.WriteTo(fw, item.debug) }) {
+			return
+		}
+	}
+
+	// dump profile
+	sec, err := strconv.ParseInt(r.FormValue("seconds"), 10, 64)
+	if sec <= 0 || err != nil {
+		sec = 10
+	}
+	ok := writeItem("profile", func(fw io.Writer) error {
+		if err := rpprof.StartCPUProfile(fw); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(sec) * time.Second):
+		case <-r.Context().Done():
+		}
+		rpprof.StopCPUProfile()
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	// dump config
+	ok = writeItem("config", func(fw io.Writer) error {
+		js, err := json.MarshalIndent(config.GetGlobalConfig(), "", " ")
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(js)
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	// dump version
+	ok = writeItem("version", func(fw io.Writer) error {
+		_, err := fmt.Fprintf(fw, "Release Version: %s\nGit Commit Hash: %s\n", mysql.TiDBReleaseVersion, mysql.TiDBGitHash)
+		return err
+	})
+	if !ok {
+		return
+	}
+	terror.Log(errors.Trace(zw.Close()))
+}
+
+// status of TiDB.
+type status struct {
+	Connections int    `json:"connections"`
+	Version     string `json:"version"`
+	GitHash     string `json:"git_hash"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	st := status{
+		Connections: s.ConnectionCount(),
+		Version:     mysql.ServerVersion,
+		GitHash:     mysql.TiDBGitHash,
+	}
+	js, err := json.Marshal(st)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logutil.BgLogger().Error("encode json failed", zap.Error(err))
+	} else {
+		_, err = w.Write(js)
+		terror.Log(errors.Trace(err))
+	}
+}
+
 func (s *Server) setupStatusServer(addr string, serverMux *http.ServeMux) {
 	l, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -45,6 +45,8 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
 
@@ -237,7 +239,10 @@ func (s *Server) onConn(conn *clientConn) {
 	}()
 	s.rwlock.Lock()
 	s.clients[conn.connectionID] = conn
+	connections := len(s.clients)
 	s.rwlock.Unlock()
+	metrics.ConnGauge.Set(float64(connections))
+
 	conn.Run(ctx)
 }
 
@@ -246,6 +251,59 @@ func killConn(conn *clientConn) {
 	atomic.CompareAndSwapUint32(&sessVars.Killed, 0, 1)
 }
 
+// ShowProcessList implements the SessionManager interface.
+func (s *Server) ShowProcessList() map[uint64]*util.ProcessInfo {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	rs := make(map[uint64]*util.ProcessInfo, len(s.clients))
+	for _, client := range s.clients {
+		if atomic.LoadInt32(&client.status) == connStatusWaitShutdown {
+			continue
+		}
+		if pi := client.processInfo(); pi != nil {
+			rs[pi.ID] = pi
+		}
+	}
+	return rs
+}
+
+// GetProcessInfo implements the SessionManager interface.
+func (s *Server) GetProcessInfo(id uint64) (*util.ProcessInfo, bool) {
+	s.rwlock.RLock()
+	conn, ok := s.clients[uint32(id)]
+	s.rwlock.RUnlock()
+	if !ok || atomic.LoadInt32(&conn.status) == connStatusWaitShutdown {
+		return nil, false
+	}
+	pi := conn.processInfo()
+	return pi, pi != nil
+}
+
+// Kill implements the SessionManager interface.
+func (s *Server) Kill(connectionID uint64, query bool) error {
+	logutil.BgLogger().Info("kill", zap.Uint64("connID", connectionID), zap.Bool("query", query))
+
+	s.rwlock.Lock()
+	defer s.rwlock.Unlock()
+	conn, ok := s.clients[uint32(connectionID)]
+	if !ok {
+		return executor.ErrNoSuchThread.GenWithStackByArgs(connectionID)
+	}
+
+	if !query {
+		// If the client connection is waiting for the next command, it's safe to close it directly.
+		// Otherwise mark the status as WaitShutdown, when the goroutine detects this, it will end
+		// the dispatch loop and exit.
+		if atomic.CompareAndSwapInt32(&conn.status, connStatusReading, connStatusShutdown) {
+			terror.Log(conn.closeWithoutLock())
+		} else {
+			atomic.StoreInt32(&conn.status, connStatusWaitShutdown)
+		}
+	}
+	killConn(conn)
+	return nil
+}
+
 // KillAllConnections kills all connections when server is not gracefully shutdown.
 func (s *Server) KillAllConnections() {
 	logutil.BgLogger().Info("[server] kill all connections.")
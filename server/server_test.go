@@ -14,11 +14,14 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -148,6 +151,79 @@ func runTestResultFieldTableIsNull(c *C) {
 	})
 }
 
+func runTestKillQuery(c *C) {
+	runTests(c, nil, func(dbt *DBTest) {
+		dbt.mustExec("drop table if exists kill_t")
+		defer dbt.mustExec("drop table if exists kill_t")
+		dbt.mustExec("create table kill_t (a int)")
+		values := make([]string, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			values = append(values, fmt.Sprintf("(%d)", i))
+		}
+		dbt.mustExec("insert into kill_t values " + strings.Join(values, ","))
+
+		ctx := context.Background()
+		conn, err := dbt.db.Conn(ctx)
+		dbt.Assert(err, IsNil)
+		defer conn.Close()
+		// The cross join reads 10^9 rows, it runs until it is killed.
+		query := "select count(*) from kill_t t1, kill_t t2, kill_t t3"
+		errCh := make(chan error, 1)
+		go func() {
+			var count int
+			errCh <- conn.QueryRowContext(ctx, query).Scan(&count)
+		}()
+
+		var connID uint64
+		for i := 0; i < 100 && connID == 0; i++ {
+			time.Sleep(50 * time.Millisecond)
+			connID = findProcessByQuery(dbt, query)
+		}
+		dbt.Assert(connID, Not(Equals), uint64(0))
+		dbt.mustExec(fmt.Sprintf("kill query %d", connID))
+		select {
+		case err = <-errCh:
+			dbt.Assert(err, NotNil)
+			dbt.Assert(err.Error(), Equals, "Error 1317: Query execution was interrupted")
+		case <-time.After(10 * time.Second):
+			dbt.Fatal("the query is not interrupted")
+		}
+		// Only the query is killed, the connection is still alive.
+		var one int
+		dbt.Assert(conn.QueryRowContext(ctx, "select 1").Scan(&one), IsNil)
+
+		_, err = dbt.db.Exec("kill 123456")
+		dbt.Assert(err, NotNil)
+		dbt.Assert(err.Error(), Equals, "Error 1094: Unknown thread id: 123456")
+	})
+}
+
+// findProcessByQuery returns the id of the connection running the query, 0 is
+// returned if it is not found.
+func findProcessByQuery(dbt *DBTest, query string) uint64 {
+	rows, err := dbt.db.Query("show processlist")
+	dbt.Assert(err, IsNil)
+	defer rows.Close()
+	cols, err := rows.Columns()
+	dbt.Assert(err, IsNil)
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		dbt.Assert(rows.Scan(dest...), IsNil)
+		// The columns are Id, User, Host, db, Command, Time, State, Info, ...
+		if values[7].String == query {
+			id, err := strconv.ParseUint(values[0].String, 10, 64)
+			dbt.Assert(err, IsNil)
+			return id
+		}
+	}
+	dbt.Assert(rows.Err(), IsNil)
+	return 0
+}
+
 const retryTime = 100
 
 func waitUntilServerOnline(statusPort uint) {
@@ -72,6 +72,10 @@ func (ts *TidbTestSuite) TestIssues(c *C) {
 	runTestIssue3662(c)
 }
 
+func (ts *TidbTestSuite) TestKillQuery(c *C) {
+	runTestKillQuery(c)
+}
+
 func (ts *TidbTestSuite) TestDBNameEscape(c *C) {
 	c.Parallel()
 	runTestDBNameEscape(c)
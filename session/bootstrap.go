@@ -120,6 +120,43 @@ const (
 		count bigint(64) UNSIGNED NOT NULL,
 		index tbl(table_id, is_index, hist_id)
 	);`
+
+	// CreateSchemaIndexUsageTable stores the index usage of the queries.
+	CreateSchemaIndexUsageTable = `CREATE TABLE IF NOT EXISTS mysql.schema_index_usage (
+		table_id bigint(64) NOT NULL,
+		index_id bigint(64) NOT NULL,
+		query_count bigint(64) NOT NULL DEFAULT 0,
+		rows_selected bigint(64) NOT NULL DEFAULT 0,
+		last_used_at varchar(19) NOT NULL DEFAULT '',
+		unique index idx(table_id, index_id)
+	);`
+
+	// CreateBindInfoTable stores the sql bind info which is used to update globalBindCache.
+	CreateBindInfoTable = `CREATE TABLE IF NOT EXISTS mysql.bind_info (
+		original_sql text NOT NULL,
+		bind_sql text NOT NULL,
+		default_db text NOT NULL,
+		status text NOT NULL,
+		create_time varchar(23) NOT NULL,
+		update_time varchar(23) NOT NULL,
+		charset text NOT NULL,
+		collation text NOT NULL,
+		index time_index(update_time)
+	);`
+
+	// CreatePlanEvolutionHistoryTable stores the results of verifying the new
+	// plans of the sql bindings, the execution times are in milliseconds.
+	CreatePlanEvolutionHistoryTable = `CREATE TABLE IF NOT EXISTS mysql.plan_evolution_history (
+		original_sql text NOT NULL,
+		default_db text NOT NULL,
+		current_bind_sql text NOT NULL,
+		new_bind_sql text NOT NULL,
+		current_exec_time bigint(64) NOT NULL,
+		new_exec_time bigint(64) NOT NULL,
+		result varchar(16) NOT NULL,
+		evolve_time varchar(23) NOT NULL,
+		index time_index(evolve_time)
+	);`
 )
 
 // bootstrap initiates system DB for a store.
@@ -231,6 +268,12 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateGCDeleteRangeDoneTable)
 	// Create stats_topn_store table.
 	mustExecute(s, CreateStatsTopNTable)
+	// Create schema_index_usage table.
+	mustExecute(s, CreateSchemaIndexUsageTable)
+	// Create bind_info table.
+	mustExecute(s, CreateBindInfoTable)
+	// Create plan_evolution_history table.
+	mustExecute(s, CreatePlanEvolutionHistoryTable)
 }
 
 // doDMLWorks executes DML statements in bootstrap stage.
@@ -30,11 +30,13 @@ import (
 	"github.com/ngaut/pools"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/tidb/bindinfo"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/owner"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/ast"
@@ -50,6 +52,7 @@ import (
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tracing"
 	"go.uber.org/zap"
 )
 
@@ -68,11 +71,14 @@ type Session interface {
 	SetCommandValue(byte)
 	SetTLSState(*tls.ConnectionState)
 	SetCollation(coID int) error
+	SetSessionManager(util.SessionManager)
 	Close()
 	// PrePareTxnCtx is exported for test.
 	PrepareTxnCtx(context.Context)
 	// FieldList returns fields list of a table.
 	FieldList(tableName string) (fields []*ast.ResultField, err error)
+	SetProcessInfo(string, time.Time, byte)
+	ShowProcess() *util.ProcessInfo
 }
 
 var (
@@ -122,6 +128,9 @@ type session struct {
 
 	// shared coprocessor client per session
 	client kv.Client
+
+	processInfo    atomic.Value
+	sessionManager util.SessionManager
 }
 
 // DDLOwnerChecker returns s.ddlOwnerChecker.
@@ -163,6 +172,41 @@ func (s *session) SetTLSState(tlsState *tls.ConnectionState) {
 	}
 }
 
+func (s *session) SetSessionManager(sm util.SessionManager) {
+	s.sessionManager = sm
+}
+
+func (s *session) GetSessionManager() util.SessionManager {
+	return s.sessionManager
+}
+
+// SetProcessInfo sets the process info of the current statement, it is
+// shown by "show processlist".
+func (s *session) SetProcessInfo(sql string, t time.Time, command byte) {
+	pi := util.ProcessInfo{
+		ID:      s.sessionVars.ConnectionID,
+		DB:      s.sessionVars.CurrentDB,
+		Command: command,
+		Time:    t,
+		State:   s.Status(),
+		Info:    sql,
+	}
+	if command != mysql.ComSleep {
+		pi.StmtCtx = s.sessionVars.StmtCtx
+	}
+	s.processInfo.Store(&pi)
+}
+
+// ShowProcess returns the process info of the current statement.
+func (s *session) ShowProcess() *util.ProcessInfo {
+	var pi *util.ProcessInfo
+	tmp := s.processInfo.Load()
+	if tmp != nil {
+		pi = tmp.(*util.ProcessInfo)
+	}
+	return pi
+}
+
 func (s *session) SetCommandValue(command byte) {
 	atomic.StoreUint32(&s.sessionVars.CommandValue, uint32(command))
 }
@@ -245,6 +289,7 @@ func (s *session) commitTxn(ctx context.Context) error {
 		return nil
 	}
 	err := s.doCommit(ctx)
+	metrics.TransactionCounter.WithLabelValues(metrics.LblCommit, metrics.RetLabel(err)).Inc()
 
 	if isoLevelOneShot := &s.sessionVars.TxnIsolationLevelOneShot; isoLevelOneShot.State != 0 {
 		switch isoLevelOneShot.State {
@@ -266,6 +311,9 @@ func (s *session) commitTxn(ctx context.Context) error {
 }
 
 func (s *session) CommitTxn(ctx context.Context) error {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "session.CommitTxn")
+	defer span.Finish()
+
 	err := s.commitTxn(ctx)
 
 	failpoint.Inject("keepHistory", func(val failpoint.Value) {
@@ -280,7 +328,9 @@ func (s *session) CommitTxn(ctx context.Context) error {
 
 func (s *session) RollbackTxn(ctx context.Context) {
 	if s.txn.Valid() {
-		terror.Log(s.txn.Rollback())
+		err := s.txn.Rollback()
+		terror.Log(err)
+		metrics.TransactionCounter.WithLabelValues(metrics.LblRollback, metrics.RetLabel(err)).Inc()
 	}
 	s.txn.changeToInvalid()
 	s.sessionVars.TxnCtx.Cleanup()
@@ -358,6 +408,26 @@ func (s *session) ExecRestrictedSQL(sql string) ([]chunk.Row, []*ast.ResultField
 	return execRestrictedSQL(ctx, se, sql)
 }
 
+// ExecRestrictedSQLWithSnapshot implements RestrictedSQLExecutor interface.
+// This is used for executing some restricted sql statements, which read the history data at the snapshot.
+func (s *session) ExecRestrictedSQLWithSnapshot(sql string, snapshot uint64) ([]chunk.Row, []*ast.ResultField, error) {
+	ctx := context.TODO()
+
+	// Use special session to execute the sql.
+	tmp, err := s.sysSessionPool().Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	se := tmp.(*session)
+	defer s.sysSessionPool().Put(tmp)
+
+	se.sessionVars.SnapshotTS = snapshot
+	defer func() {
+		se.sessionVars.SnapshotTS = 0
+	}()
+	return execRestrictedSQL(ctx, se, sql)
+}
+
 func execRestrictedSQL(ctx context.Context, se *session, sql string) ([]chunk.Row, []*ast.ResultField, error) {
 	recordSets, err := se.Execute(ctx, sql)
 	if err != nil {
@@ -522,6 +592,8 @@ func (s *session) SetGlobalSysVar(name, value string) error {
 }
 
 func (s *session) ParseSQL(ctx context.Context, sql, charset, collation string) ([]ast.StmtNode, []error, error) {
+	span, _ := tracing.ChildSpanFromContext(ctx, "session.ParseSQL")
+	defer span.Finish()
 	s.parser.SetSQLMode(s.sessionVars.SQLMode)
 	return s.parser.Parse(sql, charset, collation)
 }
@@ -567,6 +639,9 @@ func (s *session) Execute(ctx context.Context, sql string) (recordSets []sqlexec
 }
 
 func (s *session) execute(ctx context.Context, sql string) (recordSets []sqlexec.RecordSet, err error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "session.Execute")
+	defer span.Finish()
+
 	s.PrepareTxnCtx(ctx)
 	connID := s.sessionVars.ConnectionID
 	err = s.loadCommonGlobalVariablesIfNeeded()
@@ -600,6 +675,7 @@ func (s *session) execute(ctx context.Context, sql string) (recordSets []sqlexec
 		if err := executor.ResetContextOfStmt(s, stmtNode); err != nil {
 			return nil, err
 		}
+		s.SetProcessInfo(stmtNode.Text(), s.sessionVars.StartTime, byte(atomic.LoadUint32(&s.sessionVars.CommandValue)))
 		stmt, err := compiler.Compile(ctx, stmtNode)
 		if err != nil {
 			s.rollbackOnError(ctx)
@@ -764,11 +840,36 @@ func BootstrapSession(store kv.Storage) (*domain.Domain, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The stores bootstrapped by the older versions don't have the tables of
+	// the index usage and the sql bindings, so they are created every time
+	// the server starts.
+	for _, sql := range []string{CreateSchemaIndexUsageTable, CreateBindInfoTable, CreatePlanEvolutionHistoryTable} {
+		if _, err = se1.Execute(context.Background(), sql); err != nil {
+			return nil, err
+		}
+	}
 	err = dom.UpdateTableStatsLoop(se1)
 	if err != nil {
 		return nil, err
 	}
 
+	se2, err := createSession(store)
+	if err != nil {
+		return nil, err
+	}
+	se3, err := createSession(store)
+	if err != nil {
+		return nil, err
+	}
+	se4, err := createSession(store)
+	if err != nil {
+		return nil, err
+	}
+	err = dom.LoadBindInfoLoop(se2, se3, se4)
+	if err != nil {
+		return nil, err
+	}
+
 	return dom, err
 }
 
@@ -811,6 +912,7 @@ func createSession(store kv.Storage) (*session, error) {
 		client:          store.GetClient(),
 	}
 	s.mu.values = make(map[fmt.Stringer]interface{})
+	s.SetValue(bindinfo.SessionBindInfoKeyType, bindinfo.NewSessionBindHandle(parser.New()))
 	domain.BindDomain(s, dom)
 	// session implements variable.GlobalVarAccessor. Bind it to ctx.
 	s.sessionVars.GlobalVarsAccessor = s
@@ -830,6 +932,7 @@ func CreateSessionWithDomain(store kv.Storage, dom *domain.Domain) (*session, er
 		client:      store.GetClient(),
 	}
 	s.mu.values = make(map[fmt.Stringer]interface{})
+	s.SetValue(bindinfo.SessionBindInfoKeyType, bindinfo.NewSessionBindHandle(parser.New()))
 	domain.BindDomain(s, dom)
 	// session implements variable.GlobalVarAccessor. Bind it to ctx.
 	s.sessionVars.GlobalVarsAccessor = s
@@ -923,6 +1026,8 @@ var builtinGlobalVariable = []string{
 	variable.TiDBProjectionConcurrency,
 	variable.TiDBHashAggPartialConcurrency,
 	variable.TiDBHashAggFinalConcurrency,
+	variable.TiDBSortConcurrency,
+	variable.TiDBMergeJoinConcurrency,
 	variable.TiDBBackoffLockFast,
 	variable.TiDBBackOffWeight,
 	variable.TiDBConstraintCheckInPlace,
@@ -944,6 +1049,9 @@ var builtinGlobalVariable = []string{
 	variable.TiDBInitChunkSize,
 	variable.TiDBMaxChunkSize,
 	variable.TiDBEnableCascadesPlanner,
+	variable.TiDBOptCascadesJoinReorderBudget,
+	variable.TiDBUsePlanBaselines,
+	variable.TiDBEnableIndexMerge,
 	variable.TiDBEnableVectorizedExpression,
 	variable.TiDBEnableNoopFuncs,
 	variable.TiDBMaxDeltaSchemaCount,
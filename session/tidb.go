@@ -37,6 +37,7 @@ import (
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tracing"
 	"go.uber.org/zap"
 )
 
@@ -202,12 +203,17 @@ func checkStmtLimit(ctx context.Context, sctx sessionctx.Context, se *session) e
 
 // runStmt executes the sqlexec.Statement and commit or rollback the current transaction.
 func runStmt(ctx context.Context, sctx sessionctx.Context, s sqlexec.Statement) (rs sqlexec.RecordSet, err error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "session.runStmt")
+	defer span.Finish()
+
 	se := sctx.(*session)
 	sessVars := se.GetSessionVars()
+	origTxnCtx := sessVars.TxnCtx
 	defer func() {
-		// If it is not a select statement, we record its slow log here,
-		// then it could include the transaction commit time.
+		// If it is not a select statement, we record its slow log and summary
+		// here, then it could include the transaction commit time.
 		if rs == nil {
+			s.(*executor.ExecStmt).FinishExecuteStmt(origTxnCtx.StartTS, err == nil)
 			sessVars.PrevStmt = executor.FormatSQL(s.OriginText())
 		}
 	}()
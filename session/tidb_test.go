@@ -14,6 +14,7 @@
 package session
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -81,6 +82,36 @@ func removeStore(c *C, dbPath string) {
 	os.RemoveAll(dbPath)
 }
 
+func (s *testMainSuite) TestBootstrapWithoutBindInfoTable(c *C) {
+	store := newStore(c, "test_bind_info_upgrade")
+	defer store.Close()
+	dom, err := BootstrapSession(store)
+	c.Assert(err, IsNil)
+	se, err := createSession(store)
+	c.Assert(err, IsNil)
+	// Mock a store bootstrapped by the versions without the index usage and the sql bindings.
+	for _, sql := range []string{"drop table mysql.schema_index_usage", "drop table mysql.bind_info", "drop table mysql.plan_evolution_history"} {
+		_, err = se.Execute(context.Background(), sql)
+		c.Assert(err, IsNil)
+	}
+	dom.Close()
+	domap.Delete(store)
+
+	dom, err = BootstrapSession(store)
+	c.Assert(err, IsNil)
+	defer func() {
+		dom.Close()
+		domap.Delete(store)
+	}()
+	se, err = createSession(store)
+	c.Assert(err, IsNil)
+	for _, sql := range []string{"select * from mysql.schema_index_usage", "select * from mysql.bind_info", "select * from mysql.plan_evolution_history"} {
+		rs, err := se.Execute(context.Background(), sql)
+		c.Assert(err, IsNil)
+		c.Assert(rs[0].Close(), IsNil)
+	}
+}
+
 func (s *testMainSuite) TestKeysNeedLock(c *C) {
 	rowKey := tablecodec.EncodeRowKeyWithHandle(1, 1)
 	indexKey := tablecodec.EncodeIndexSeekKey(1, 1, []byte{1})
@@ -15,11 +15,14 @@ package stmtctx
 
 import (
 	"math"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/memory"
 	"go.uber.org/zap"
 )
 
@@ -95,6 +98,11 @@ type StatementContext struct {
 
 		warnings   []SQLWarn
 		errorCount uint16
+
+		// execDetails is the sum of the execution details of the coprocessor
+		// tasks, allExecDetails keeps the details of every task.
+		execDetails    execdetails.ExecDetails
+		allExecDetails []*execdetails.ExecDetails
 	}
 	// PrevAffectedRows is the affected-rows value(DDL is 0, DML is the number of affected rows).
 	PrevAffectedRows int64
@@ -109,13 +117,19 @@ type StatementContext struct {
 	MaxRowID  int64
 
 	// Copied from SessionVars.TimeZone.
-	TimeZone       *time.Location
-	NotFillCache   bool
-	TableIDs       []int64
-	IndexNames     []string
-	nowTs          time.Time // use this variable for now/current_timestamp calculation/cache for one stmt
-	stmtTimeCached bool
-	StmtType       string
+	TimeZone   *time.Location
+	MemTracker *memory.Tracker
+	// DiskTracker tracks the disk usage of the executors which spill data to disk.
+	DiskTracker *memory.Tracker
+	// RuntimeStatsColl collects the runtime statistics of the executors, it's
+	// only set for EXPLAIN ANALYZE statements.
+	RuntimeStatsColl *execdetails.RuntimeStatsColl
+	NotFillCache     bool
+	TableIDs         []int64
+	IndexNames       []string
+	nowTs            time.Time // use this variable for now/current_timestamp calculation/cache for one stmt
+	stmtTimeCached   bool
+	StmtType         string
 }
 
 // StmtHints are SessionVars related sql hints.
@@ -124,11 +138,13 @@ type StmtHints struct {
 	HasAllowInSubqToJoinAndAggHint bool
 	HasMemQuotaHint                bool
 	HasReplicaReadHint             bool
+	HasMaxExecutionTime            bool
 
 	// Hint Information
 	AllowInSubqToJoinAndAgg bool
 	MemQuotaQuery           int64
 	ReplicaRead             byte
+	MaxExecutionTime        uint64
 }
 
 // GetNowTsCached getter for nowTs, if not set get now time and cache it
@@ -346,6 +362,8 @@ func (sc *StatementContext) ResetForRetry() {
 	sc.mu.touched = 0
 	sc.mu.errorCount = 0
 	sc.mu.warnings = nil
+	sc.mu.execDetails = execdetails.ExecDetails{}
+	sc.mu.allExecDetails = nil
 	sc.mu.Unlock()
 	sc.MaxRowID = 0
 	sc.BaseRowID = 0
@@ -353,6 +371,55 @@ func (sc *StatementContext) ResetForRetry() {
 	sc.IndexNames = sc.IndexNames[:0]
 }
 
+// MergeExecDetails merges the execution details of a coprocessor task into
+// the statement context.
+func (sc *StatementContext) MergeExecDetails(details *execdetails.ExecDetails) {
+	if details == nil {
+		return
+	}
+	sc.mu.Lock()
+	sc.mu.execDetails.Merge(details)
+	sc.mu.allExecDetails = append(sc.mu.allExecDetails, details)
+	sc.mu.Unlock()
+}
+
+// GetExecDetails gets the sum of the execution details of the coprocessor tasks.
+func (sc *StatementContext) GetExecDetails() execdetails.ExecDetails {
+	sc.mu.Lock()
+	details := sc.mu.execDetails
+	sc.mu.Unlock()
+	return details
+}
+
+// CopTasksDetails returns some useful information of the coprocessor tasks
+// during execution.
+func (sc *StatementContext) CopTasksDetails() *CopTasksDetails {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	n := len(sc.mu.allExecDetails)
+	d := &CopTasksDetails{NumCopTasks: n}
+	if n == 0 {
+		return d
+	}
+	d.AvgProcessTime = sc.mu.execDetails.ProcessTime / time.Duration(n)
+	d.AvgWaitTime = sc.mu.execDetails.WaitTime / time.Duration(n)
+
+	sort.Slice(sc.mu.allExecDetails, func(i, j int) bool {
+		return sc.mu.allExecDetails[i].ProcessTime < sc.mu.allExecDetails[j].ProcessTime
+	})
+	d.P90ProcessTime = sc.mu.allExecDetails[n*9/10].ProcessTime
+	d.MaxProcessTime = sc.mu.allExecDetails[n-1].ProcessTime
+	d.MaxProcessAddress = sc.mu.allExecDetails[n-1].CalleeAddress
+
+	sort.Slice(sc.mu.allExecDetails, func(i, j int) bool {
+		return sc.mu.allExecDetails[i].WaitTime < sc.mu.allExecDetails[j].WaitTime
+	})
+	d.P90WaitTime = sc.mu.allExecDetails[n*9/10].WaitTime
+	d.MaxWaitTime = sc.mu.allExecDetails[n-1].WaitTime
+	d.MaxWaitAddress = sc.mu.allExecDetails[n-1].CalleeAddress
+	return d
+}
+
 // ShouldClipToZero indicates whether values less than 0 should be clipped to 0 for unsigned integer types.
 // This is the case for `insert`, `update`, `alter table` and `load data infile` statements, when not in strict SQL mode.
 // see https://dev.mysql.com/doc/refman/5.7/en/out-of-range-and-overflow.html
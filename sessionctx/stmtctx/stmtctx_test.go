@@ -14,9 +14,13 @@
 package stmtctx_test
 
 import (
+	"fmt"
+	"testing"
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
-	"testing"
+	"github.com/pingcap/tidb/util/execdetails"
 )
 
 func TestT(t *testing.T) {
@@ -49,3 +53,36 @@ func (s *stmtctxSuit) TestStatementContextPushDownFLags(c *C) {
 		c.Assert(got, Equals, tt.out, Commentf("get %v, want %v", got, tt.out))
 	}
 }
+
+func (s *stmtctxSuit) TestCopTasksDetails(c *C) {
+	ctx := new(stmtctx.StatementContext)
+	c.Assert(ctx.CopTasksDetails().NumCopTasks, Equals, 0)
+	ctx.MergeExecDetails(nil)
+	for i := 0; i < 100; i++ {
+		ctx.MergeExecDetails(&execdetails.ExecDetails{
+			CalleeAddress: fmt.Sprintf("%v", i+1),
+			ProcessTime:   time.Second * time.Duration(i+1),
+			WaitTime:      time.Millisecond * time.Duration(i+1),
+			BackoffTime:   time.Millisecond,
+		})
+	}
+	details := ctx.GetExecDetails()
+	c.Assert(details.ProcessTime, Equals, 5050*time.Second)
+	c.Assert(details.WaitTime, Equals, 5050*time.Millisecond)
+	c.Assert(details.BackoffTime, Equals, 100*time.Millisecond)
+	c.Assert(details.String(), Equals, "Process_time: 5050 Wait_time: 5.05 Backoff_time: 0.1")
+
+	d := ctx.CopTasksDetails()
+	c.Assert(d.NumCopTasks, Equals, 100)
+	c.Assert(d.AvgProcessTime, Equals, time.Second*101/2)
+	c.Assert(d.P90ProcessTime, Equals, time.Second*91)
+	c.Assert(d.MaxProcessTime, Equals, time.Second*100)
+	c.Assert(d.MaxProcessAddress, Equals, "100")
+	c.Assert(d.AvgWaitTime, Equals, time.Millisecond*101/2)
+	c.Assert(d.P90WaitTime, Equals, time.Millisecond*91)
+	c.Assert(d.MaxWaitTime, Equals, time.Millisecond*100)
+	c.Assert(d.MaxWaitAddress, Equals, "100")
+
+	ctx.ResetForRetry()
+	c.Assert(ctx.CopTasksDetails().NumCopTasks, Equals, 0)
+}
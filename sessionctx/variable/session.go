@@ -14,6 +14,7 @@
 package variable
 
 import (
+	"bytes"
 	"crypto/tls"
 	"fmt"
 	"strconv"
@@ -23,6 +24,7 @@ import (
 	"time"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/parser/mysql"
@@ -30,6 +32,8 @@ import (
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/stmtsummary"
 )
 
 // Error instances.
@@ -126,6 +130,7 @@ func (ib *WriteStmtBufs) clean() {
 // SessionVars is to handle user-defined or global variables in the current session.
 type SessionVars struct {
 	Concurrency
+	MemQuota
 	BatchSize
 	// UsersLock is a lock for user defined variables.
 	UsersLock sync.RWMutex
@@ -174,6 +179,9 @@ type SessionVars struct {
 	// CurrentDB is the default database of this session.
 	CurrentDB string
 
+	// User is the user identity of this session, in the form of user@host.
+	User string
+
 	// StrictSQLMode indicates if the session is in strict mode.
 	StrictSQLMode bool
 
@@ -183,6 +191,10 @@ type SessionVars struct {
 	// InRestrictedSQL indicates if the session is handling restricted SQL execution.
 	InRestrictedSQL bool
 
+	// SnapshotTS is the timestamp to read the history data, it is only set by the internal
+	// reads such as dumping the statistics of a history version, 0 means the latest data is read.
+	SnapshotTS uint64
+
 	// GlobalVarsAccessor is used to set and get global variables.
 	GlobalVarsAccessor GlobalVarAccessor
 
@@ -246,6 +258,13 @@ type SessionVars struct {
 	// EnableCascadesPlanner enables the cascades planner.
 	EnableCascadesPlanner bool
 
+	// CascadesJoinReorderBudget is the maximum number of join expressions in one group generated by the join
+	// reorder rules of the cascades planner.
+	CascadesJoinReorderBudget int
+
+	// UsePlanBaselines indicates whether the SQL bindings are applied when optimizing the queries.
+	UsePlanBaselines bool
+
 	// EnableVectorizedExpression  enables the vectorized expression evaluation.
 	EnableVectorizedExpression bool
 
@@ -264,6 +283,14 @@ type SessionVars struct {
 	// HashJoin.
 	EnableRadixJoin bool
 
+	// EnableRuntimeFilter indicates whether the planner is allowed to push
+	// runtime filters of hash joins down to the probe side.
+	EnableRuntimeFilter bool
+
+	// EnableIndexMerge indicates whether the planner considers the index merge
+	// paths without the USE_INDEX_MERGE hint.
+	EnableIndexMerge bool
+
 	// ConstraintCheckInPlace indicates whether to check the constraint when the SQL executing.
 	ConstraintCheckInPlace bool
 
@@ -341,7 +368,6 @@ func NewSessionVars() *SessionVars {
 		Users:                       make(map[string]string),
 		systems:                     make(map[string]string),
 		TxnCtx:                      &TransactionContext{},
-		KVVars:                      kv.NewVariables(),
 		StrictSQLMode:               true,
 		Status:                      mysql.ServerStatusAutocommit,
 		StmtCtx:                     new(stmtctx.StatementContext),
@@ -359,7 +385,10 @@ func NewSessionVars() *SessionVars {
 		MemoryFactor:                DefOptMemoryFactor,
 		DiskFactor:                  DefOptDiskFactor,
 		ConcurrencyFactor:           DefOptConcurrencyFactor,
+		CascadesJoinReorderBudget:   DefOptCascadesJoinReorderBudget,
 		EnableRadixJoin:             false,
+		EnableRuntimeFilter:         DefTiDBEnableRuntimeFilter,
+		EnableIndexMerge:            DefTiDBEnableIndexMerge,
 		EnableVectorizedExpression:  DefEnableVectorizedExpression,
 		CommandValue:                uint32(mysql.ComSleep),
 		TiDBOptJoinReorderThreshold: DefTiDBOptJoinReorderThreshold,
@@ -368,7 +397,10 @@ func NewSessionVars() *SessionVars {
 		EnableNoopFuncs:             DefTiDBEnableNoopFuncs,
 		replicaRead:                 kv.ReplicaReadLeader,
 		AllowRemoveAutoInc:          DefTiDBAllowRemoveAutoInc,
+		UsePlanBaselines:            DefTiDBUsePlanBaselines,
+		SlowQueryFile:               config.GetGlobalConfig().Log.SlowQueryFile,
 	}
+	vars.KVVars = kv.NewVariables(&vars.Killed)
 	vars.Concurrency = Concurrency{
 		IndexLookupConcurrency:     DefIndexLookupConcurrency,
 		IndexSerialScanConcurrency: DefIndexSerialScanConcurrency,
@@ -378,6 +410,12 @@ func NewSessionVars() *SessionVars {
 		DistSQLScanConcurrency:     DefDistSQLScanConcurrency,
 		HashAggPartialConcurrency:  DefTiDBHashAggPartialConcurrency,
 		HashAggFinalConcurrency:    DefTiDBHashAggFinalConcurrency,
+		SortConcurrency:            DefTiDBSortConcurrency,
+		MergeJoinConcurrency:       DefTiDBMergeJoinConcurrency,
+	}
+	vars.MemQuota = MemQuota{
+		MemQuotaHashAgg: DefTiDBMemQuotaHashAgg,
+		MemQuotaQuery:   DefTiDBMemQuotaQuery,
 	}
 	vars.BatchSize = BatchSize{
 		IndexLookupSize: DefIndexLookupSize,
@@ -602,6 +640,14 @@ func (s *SessionVars) SetSystemVar(name string, val string) error {
 		s.HashAggPartialConcurrency = tidbOptPositiveInt32(val, DefTiDBHashAggPartialConcurrency)
 	case TiDBHashAggFinalConcurrency:
 		s.HashAggFinalConcurrency = tidbOptPositiveInt32(val, DefTiDBHashAggFinalConcurrency)
+	case TiDBSortConcurrency:
+		s.SortConcurrency = tidbOptPositiveInt32(val, DefTiDBSortConcurrency)
+	case TiDBMergeJoinConcurrency:
+		s.MergeJoinConcurrency = tidbOptPositiveInt32(val, DefTiDBMergeJoinConcurrency)
+	case TiDBMemQuotaHashAgg:
+		s.MemQuotaHashAgg = tidbOptInt64(val, DefTiDBMemQuotaHashAgg)
+	case TiDBMemQuotaQuery:
+		s.MemQuotaQuery = tidbOptInt64(val, DefTiDBMemQuotaQuery)
 	case TiDBDistSQLScanConcurrency:
 		s.DistSQLScanConcurrency = tidbOptPositiveInt32(val, DefDistSQLScanConcurrency)
 	case TiDBIndexSerialScanConcurrency:
@@ -620,12 +666,30 @@ func (s *SessionVars) SetSystemVar(name string, val string) error {
 		s.InitChunkSize = tidbOptPositiveInt32(val, DefInitChunkSize)
 	case TiDBGeneralLog:
 		atomic.StoreUint32(&ProcessGeneralLog, uint32(tidbOptPositiveInt32(val, DefTiDBGeneralLog)))
+	case TiDBSlowLogThreshold:
+		atomic.StoreUint64(&config.GetGlobalConfig().Log.SlowThreshold, uint64(tidbOptInt64(val, DefTiDBSlowLogThreshold)))
+	case TiDBEnableStmtSummary:
+		stmtsummary.StmtSummaryByDigestMap.SetEnabled(TiDBOptOn(val))
+	case TiDBStmtSummaryRefreshInterval:
+		stmtsummary.StmtSummaryByDigestMap.SetRefreshInterval(tidbOptInt64(val, stmtsummary.DefRefreshInterval))
+	case TiDBStmtSummaryHistorySize:
+		stmtsummary.StmtSummaryByDigestMap.SetHistorySize(tidbOptPositiveInt32(val, stmtsummary.DefHistorySize))
+	case TiDBStmtSummaryMaxStmtCount:
+		stmtsummary.StmtSummaryByDigestMap.SetMaxStmtCount(uint(tidbOptPositiveInt32(val, stmtsummary.DefMaxStmtCount)))
 	case TiDBEnableCascadesPlanner:
 		s.EnableCascadesPlanner = TiDBOptOn(val)
+	case TiDBOptCascadesJoinReorderBudget:
+		s.CascadesJoinReorderBudget = int(tidbOptInt64(val, DefOptCascadesJoinReorderBudget))
+	case TiDBUsePlanBaselines:
+		s.UsePlanBaselines = TiDBOptOn(val)
 	case TiDBDDLReorgPriority:
 		s.setDDLReorgPriority(val)
 	case TiDBEnableRadixJoin:
 		s.EnableRadixJoin = TiDBOptOn(val)
+	case TiDBEnableRuntimeFilter:
+		s.EnableRuntimeFilter = TiDBOptOn(val)
+	case TiDBEnableIndexMerge:
+		s.EnableIndexMerge = TiDBOptOn(val)
 	case TiDBEnableVectorizedExpression:
 		s.EnableVectorizedExpression = TiDBOptOn(val)
 	case TiDBOptJoinReorderThreshold:
@@ -725,10 +789,24 @@ type Concurrency struct {
 	// HashAggFinalConcurrency is the number of concurrent hash aggregation final worker.
 	HashAggFinalConcurrency int
 
+	// SortConcurrency is the number of concurrent sort worker.
+	SortConcurrency int
+
+	// MergeJoinConcurrency is the number of concurrent merge join worker.
+	MergeJoinConcurrency int
+
 	// IndexSerialScanConcurrency is the number of concurrent index serial scan worker.
 	IndexSerialScanConcurrency int
 }
 
+// MemQuota defines memory quota values.
+type MemQuota struct {
+	// MemQuotaHashAgg defines the memory quota for the partial results of a hash aggregation.
+	MemQuotaHashAgg int64
+	// MemQuotaQuery defines the memory quota for a query.
+	MemQuotaQuery int64
+}
+
 // BatchSize defines batch size values.
 type BatchSize struct {
 
@@ -741,3 +819,165 @@ type BatchSize struct {
 	// MaxChunkSize defines max row count of a Chunk during query execution.
 	MaxChunkSize int
 }
+
+const (
+	// SlowLogRowPrefixStr is slow log row prefix.
+	SlowLogRowPrefixStr = "# "
+	// SlowLogSpaceMarkStr is slow log space mark.
+	SlowLogSpaceMarkStr = ": "
+	// SlowLogSQLSuffixStr is slow log suffix.
+	SlowLogSQLSuffixStr = ";"
+	// SlowLogTimeStr is slow log field name.
+	SlowLogTimeStr = "Time"
+	// SlowLogStartPrefixStr is slow log start row prefix.
+	SlowLogStartPrefixStr = SlowLogRowPrefixStr + SlowLogTimeStr + SlowLogSpaceMarkStr
+	// SlowLogTxnStartTSStr is slow log field name.
+	SlowLogTxnStartTSStr = "Txn_start_ts"
+	// SlowLogUserStr is slow log field name.
+	SlowLogUserStr = "User"
+	// SlowLogConnIDStr is slow log field name.
+	SlowLogConnIDStr = "Conn_ID"
+	// SlowLogQueryTimeStr is slow log field name.
+	SlowLogQueryTimeStr = "Query_time"
+	// SlowLogParseTimeStr is the parse sql time.
+	SlowLogParseTimeStr = "Parse_time"
+	// SlowLogCompileTimeStr is the compile plan time.
+	SlowLogCompileTimeStr = "Compile_time"
+	// SlowLogDBStr is slow log field name.
+	SlowLogDBStr = "DB"
+	// SlowLogIsInternalStr is slow log field name.
+	SlowLogIsInternalStr = "Is_internal"
+	// SlowLogIndexNamesStr is slow log field name.
+	SlowLogIndexNamesStr = "Index_names"
+	// SlowLogDigestStr is slow log field name.
+	SlowLogDigestStr = "Digest"
+	// SlowLogQuerySQLStr is slow log field name.
+	SlowLogQuerySQLStr = "Query" // use for slow log table, slow log will not print this field name but print sql directly.
+	// SlowLogNumCopTasksStr is the number of cop-tasks.
+	SlowLogNumCopTasksStr = "Num_cop_tasks"
+	// SlowLogCopProcAvg is the average process time of all cop-tasks.
+	SlowLogCopProcAvg = "Cop_proc_avg"
+	// SlowLogCopProcP90 is the p90 process time of all cop-tasks.
+	SlowLogCopProcP90 = "Cop_proc_p90"
+	// SlowLogCopProcMax is the max process time of all cop-tasks.
+	SlowLogCopProcMax = "Cop_proc_max"
+	// SlowLogCopProcAddr is the address of TiKV where the cop-task which cost max process time run.
+	SlowLogCopProcAddr = "Cop_proc_addr"
+	// SlowLogCopWaitAvg is the average wait time of all cop-tasks.
+	SlowLogCopWaitAvg = "Cop_wait_avg"
+	// SlowLogCopWaitP90 is the p90 wait time of all cop-tasks.
+	SlowLogCopWaitP90 = "Cop_wait_p90"
+	// SlowLogCopWaitMax is the max wait time of all cop-tasks.
+	SlowLogCopWaitMax = "Cop_wait_max"
+	// SlowLogCopWaitAddr is the address of TiKV where the cop-task which cost wait process time run.
+	SlowLogCopWaitAddr = "Cop_wait_addr"
+	// SlowLogMemMax is the max number bytes of memory used in this statement.
+	SlowLogMemMax = "Mem_max"
+	// SlowLogSucc is used to indicate whether this sql execute successfully.
+	SlowLogSucc = "Succ"
+	// SlowLogPlan is used to record the query plan.
+	SlowLogPlan = "Plan"
+)
+
+// SlowQueryLogItems is a collection of items that should be included in the
+// slow query log.
+type SlowQueryLogItems struct {
+	TxnTS       uint64
+	SQL         string
+	Digest      string
+	TimeTotal   time.Duration
+	TimeParse   time.Duration
+	TimeCompile time.Duration
+	IndexNames  string
+	ExecDetail  execdetails.ExecDetails
+	CopTasks    *stmtctx.CopTasksDetails
+	MemMax      int64
+	Succ        bool
+	Plan        string
+}
+
+// SlowLogFormat uses for formatting slow log.
+// The slow log output is like below:
+// # Time: 2020-04-28T15:24:04.309074+08:00
+// # Txn_start_ts: 406315658548871171
+// # User: root@127.0.0.1
+// # Conn_ID: 6
+// # Query_time: 4.895492
+// # Parse_time: 0.4
+// # Compile_time: 0.2
+// # Process_time: 0.161 Wait_time: 0.025 Backoff_time: 0.02
+// # DB: test
+// # Index_names: [t1:a,t2:b]
+// # Is_internal: false
+// # Digest: 42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772
+// # Num_cop_tasks: 10
+// # Cop_proc_avg: 1 Cop_proc_p90: 2 Cop_proc_max: 3 Cop_proc_addr: 10.6.131.78
+// # Cop_wait_avg: 0.05 Cop_wait_p90: 0.6 Cop_wait_max: 0.8 Cop_wait_addr: 10.6.131.79
+// # Mem_max: 525211
+// # Succ: true
+// # Plan: tidb_decode_plan('...')
+// select * from t_slim;
+func (s *SessionVars) SlowLogFormat(logItems *SlowQueryLogItems) string {
+	var buf bytes.Buffer
+
+	writeSlowLogItem(&buf, SlowLogTxnStartTSStr, strconv.FormatUint(logItems.TxnTS, 10))
+	if s.User != "" {
+		writeSlowLogItem(&buf, SlowLogUserStr, s.User)
+	}
+	if s.ConnectionID != 0 {
+		writeSlowLogItem(&buf, SlowLogConnIDStr, strconv.FormatUint(s.ConnectionID, 10))
+	}
+	writeSlowLogItem(&buf, SlowLogQueryTimeStr, strconv.FormatFloat(logItems.TimeTotal.Seconds(), 'f', -1, 64))
+	writeSlowLogItem(&buf, SlowLogParseTimeStr, strconv.FormatFloat(logItems.TimeParse.Seconds(), 'f', -1, 64))
+	writeSlowLogItem(&buf, SlowLogCompileTimeStr, strconv.FormatFloat(logItems.TimeCompile.Seconds(), 'f', -1, 64))
+
+	if execDetailStr := logItems.ExecDetail.String(); len(execDetailStr) > 0 {
+		buf.WriteString(SlowLogRowPrefixStr + execDetailStr + "\n")
+	}
+
+	if len(s.CurrentDB) > 0 {
+		writeSlowLogItem(&buf, SlowLogDBStr, s.CurrentDB)
+	}
+	if len(logItems.IndexNames) > 0 {
+		writeSlowLogItem(&buf, SlowLogIndexNamesStr, logItems.IndexNames)
+	}
+
+	writeSlowLogItem(&buf, SlowLogIsInternalStr, strconv.FormatBool(s.InRestrictedSQL))
+	if len(logItems.Digest) > 0 {
+		writeSlowLogItem(&buf, SlowLogDigestStr, logItems.Digest)
+	}
+	if logItems.CopTasks != nil {
+		writeSlowLogItem(&buf, SlowLogNumCopTasksStr, strconv.Itoa(logItems.CopTasks.NumCopTasks))
+		if logItems.CopTasks.NumCopTasks > 0 {
+			// make the result stable
+			buf.WriteString(SlowLogRowPrefixStr +
+				SlowLogCopProcAvg + SlowLogSpaceMarkStr + strconv.FormatFloat(logItems.CopTasks.AvgProcessTime.Seconds(), 'f', -1, 64) + " " +
+				SlowLogCopProcP90 + SlowLogSpaceMarkStr + strconv.FormatFloat(logItems.CopTasks.P90ProcessTime.Seconds(), 'f', -1, 64) + " " +
+				SlowLogCopProcMax + SlowLogSpaceMarkStr + strconv.FormatFloat(logItems.CopTasks.MaxProcessTime.Seconds(), 'f', -1, 64) + " " +
+				SlowLogCopProcAddr + SlowLogSpaceMarkStr + logItems.CopTasks.MaxProcessAddress + "\n")
+			buf.WriteString(SlowLogRowPrefixStr +
+				SlowLogCopWaitAvg + SlowLogSpaceMarkStr + strconv.FormatFloat(logItems.CopTasks.AvgWaitTime.Seconds(), 'f', -1, 64) + " " +
+				SlowLogCopWaitP90 + SlowLogSpaceMarkStr + strconv.FormatFloat(logItems.CopTasks.P90WaitTime.Seconds(), 'f', -1, 64) + " " +
+				SlowLogCopWaitMax + SlowLogSpaceMarkStr + strconv.FormatFloat(logItems.CopTasks.MaxWaitTime.Seconds(), 'f', -1, 64) + " " +
+				SlowLogCopWaitAddr + SlowLogSpaceMarkStr + logItems.CopTasks.MaxWaitAddress + "\n")
+		}
+	}
+	if logItems.MemMax > 0 {
+		writeSlowLogItem(&buf, SlowLogMemMax, strconv.FormatInt(logItems.MemMax, 10))
+	}
+	writeSlowLogItem(&buf, SlowLogSucc, strconv.FormatBool(logItems.Succ))
+	if len(logItems.Plan) != 0 {
+		writeSlowLogItem(&buf, SlowLogPlan, logItems.Plan)
+	}
+
+	buf.WriteString(logItems.SQL)
+	if len(logItems.SQL) == 0 || logItems.SQL[len(logItems.SQL)-1] != ';' {
+		buf.WriteString(SlowLogSQLSuffixStr)
+	}
+	return buf.String()
+}
+
+// writeSlowLogItem writes a slow log item in the form of:  "# ${key}: ${value}"
+func writeSlowLogItem(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(SlowLogRowPrefixStr + key + SlowLogSpaceMarkStr + value + "\n")
+}
@@ -14,7 +14,12 @@
 package variable_test
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/mock"
 )
 
@@ -78,3 +83,67 @@ func (*testSessionSuite) TestSession(c *C) {
 	c.Assert(ss.CopiedRows(), Equals, uint64(0))
 	c.Assert(ss.WarningCount(), Equals, uint16(0))
 }
+
+func (*testSessionSuite) TestSlowLogFormat(c *C) {
+	ctx := mock.NewContext()
+	seVar := ctx.GetSessionVars()
+	c.Assert(seVar, NotNil)
+
+	seVar.User = "root@192.168.0.1"
+	seVar.ConnectionID = 1
+	seVar.CurrentDB = "test"
+	seVar.InRestrictedSQL = true
+	txnTS := uint64(406649736972468225)
+	costTime := time.Second
+	execDetail := execdetails.ExecDetails{
+		ProcessTime: 2 * time.Second,
+		WaitTime:    60 * time.Second,
+		BackoffTime: time.Millisecond,
+	}
+	copTasks := &stmtctx.CopTasksDetails{
+		NumCopTasks:       10,
+		AvgProcessTime:    time.Second,
+		P90ProcessTime:    time.Second * 2,
+		MaxProcessAddress: "10.6.131.78",
+		MaxProcessTime:    time.Second * 3,
+		AvgWaitTime:       time.Millisecond * 10,
+		P90WaitTime:       time.Millisecond * 20,
+		MaxWaitTime:       time.Millisecond * 30,
+		MaxWaitAddress:    "10.6.131.79",
+	}
+	resultString := `# Txn_start_ts: 406649736972468225
+# User: root@192.168.0.1
+# Conn_ID: 1
+# Query_time: 1
+# Parse_time: 0.00000001
+# Compile_time: 0.00000001
+# Process_time: 2 Wait_time: 60 Backoff_time: 0.001
+# DB: test
+# Index_names: [t1:a,t2:b]
+# Is_internal: true
+# Digest: 42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772
+# Num_cop_tasks: 10
+# Cop_proc_avg: 1 Cop_proc_p90: 2 Cop_proc_max: 3 Cop_proc_addr: 10.6.131.78
+# Cop_wait_avg: 0.01 Cop_wait_p90: 0.02 Cop_wait_max: 0.03 Cop_wait_addr: 10.6.131.79
+# Mem_max: 2333
+# Succ: true
+# Plan: tidb_decode_plan('xxx')
+select * from t;`
+	sql := "select * from t"
+	digest := "42a1c8aae6f133e934d4bf0147491709a8812ea05ff8819ec522780fe657b772"
+	logString := seVar.SlowLogFormat(&variable.SlowQueryLogItems{
+		TxnTS:       txnTS,
+		SQL:         sql,
+		Digest:      digest,
+		TimeTotal:   costTime,
+		TimeParse:   time.Duration(10),
+		TimeCompile: time.Duration(10),
+		IndexNames:  "[t1:a,t2:b]",
+		ExecDetail:  execDetail,
+		CopTasks:    copTasks,
+		MemMax:      2333,
+		Succ:        true,
+		Plan:        "tidb_decode_plan('xxx')",
+	})
+	c.Assert(logString, Equals, resultString)
+}
@@ -53,6 +53,21 @@ const (
 	// tidb_general_log is used to log every query in the server in info level.
 	TiDBGeneralLog = "tidb_general_log"
 
+	// tidb_slow_log_threshold is used to set the slow log threshold in the server.
+	TiDBSlowLogThreshold = "tidb_slow_log_threshold"
+
+	// tidb_enable_stmt_summary indicates whether the statement summary is enabled.
+	TiDBEnableStmtSummary = "tidb_enable_stmt_summary"
+
+	// tidb_stmt_summary_refresh_interval indicates the refresh interval in seconds for each statement summary.
+	TiDBStmtSummaryRefreshInterval = "tidb_stmt_summary_refresh_interval"
+
+	// tidb_stmt_summary_history_size indicates the history size of each statement summary.
+	TiDBStmtSummaryHistorySize = "tidb_stmt_summary_history_size"
+
+	// tidb_stmt_summary_max_stmt_count indicates the max number of statements kept in memory.
+	TiDBStmtSummaryMaxStmtCount = "tidb_stmt_summary_max_stmt_count"
+
 	// tidb_skip_isolation_level_check is used to control whether to return error when set unsupported transaction
 	// isolation level.
 	TiDBSkipIsolationLevelCheck = "tidb_skip_isolation_level_check"
@@ -62,6 +77,14 @@ const (
 
 	// TiDBAllowRemoveAutoInc indicates whether a user can drop the auto_increment column attribute or not.
 	TiDBAllowRemoveAutoInc = "tidb_allow_remove_auto_inc"
+
+	// tidb_mem_quota_hashagg is the memory quota of the partial results of a hash aggregation, in bytes.
+	// The partial results exceeding the quota are spilled to temporary files on disk.
+	TiDBMemQuotaHashAgg = "tidb_mem_quota_hashagg"
+
+	// tidb_mem_quota_query is the memory quota of a query, in bytes. The action taken when a query
+	// exceeds the quota is decided by the "oom-action" config.
+	TiDBMemQuotaQuery = "tidb_mem_quota_query"
 )
 
 // TiDB system variable names that both in session and global scope.
@@ -136,6 +159,22 @@ const (
 	// tidb_enable_cascades_planner is used to control whether to enable the cascades planner.
 	TiDBEnableCascadesPlanner = "tidb_enable_cascades_planner"
 
+	// tidb_opt_cascades_join_reorder_budget is the maximum number of join expressions the join reorder rules
+	// of the cascades planner can generate in one group. 0 means the joins are not reordered.
+	TiDBOptCascadesJoinReorderBudget = "tidb_opt_cascades_join_reorder_budget"
+
+	// tidb_use_plan_baselines is used to control whether to apply the SQL bindings when optimizing the queries.
+	TiDBUsePlanBaselines = "tidb_use_plan_baselines"
+
+	// tidb_capture_plan_baselines is used to control whether to capture the plans of the frequent statements as the SQL bindings.
+	TiDBCapturePlanBaselines = "tidb_capture_plan_baselines"
+
+	// tidb_evolve_plan_baselines is used to control whether to evolve the SQL bindings by verifying the new plans.
+	TiDBEvolvePlanBaselines = "tidb_evolve_plan_baselines"
+
+	// tidb_evolve_plan_task_max_time is the max time in seconds a verification of the new plan of a SQL binding can take.
+	TiDBEvolvePlanTaskMaxTime = "tidb_evolve_plan_task_max_time"
+
 	// tidb_skip_utf8_check skips the UTF8 validate process, validate UTF8 has performance cost, if we can make sure
 	// the input string values are valid, we can skip the check.
 	TiDBSkipUTF8Check = "tidb_skip_utf8_check"
@@ -156,6 +195,15 @@ const (
 	// The hash agg executor starts multiple concurrent final workers to do final aggregate works.
 	TiDBHashAggFinalConcurrency = "tidb_hashagg_final_concurrency"
 
+	// tidb_sort_concurrency is used for sort and topN executor.
+	// The sort executor starts multiple concurrent workers to sort the partitions of the input rows.
+	TiDBSortConcurrency = "tidb_sort_concurrency"
+
+	// tidb_merge_join_concurrency is used for merge join executor.
+	// When it's greater than 1, the rows of both sides of a merge join are hash partitioned by
+	// the join keys, and the partitions are joined by multiple concurrent merge join workers.
+	TiDBMergeJoinConcurrency = "tidb_merge_join_concurrency"
+
 	// tidb_backoff_lock_fast is used for tikv backoff base time in milliseconds.
 	TiDBBackoffLockFast = "tidb_backoff_lock_fast"
 
@@ -195,6 +243,14 @@ const (
 	// HashJoin.
 	TiDBEnableRadixJoin = "tidb_enable_radix_join"
 
+	// tidb_enable_runtime_filter indicates whether a hash join can build filters from its build side
+	// at runtime and push them down to the table reader of its probe side.
+	TiDBEnableRuntimeFilter = "tidb_enable_runtime_filter"
+
+	// tidb_enable_index_merge indicates whether the planner considers the index merge paths, which read
+	// a table by several indexes and merge the handles, for the conditions connected by OR.
+	TiDBEnableIndexMerge = "tidb_enable_index_merge"
+
 	// tidb_constraint_check_in_place indicates to check the constraint when the SQL executing.
 	// It could hurt the performance of bulking insert when it is ON.
 	TiDBConstraintCheckInPlace = "tidb_constraint_check_in_place"
@@ -237,12 +293,15 @@ const (
 	DefOptDiskFactor                 = 1.5
 	DefOptConcurrencyFactor          = 3.0
 	DefOptInSubqToJoinAndAgg         = true
+	DefOptCascadesJoinReorderBudget  = 64
 	DefCurretTS                      = 0
 	DefInitChunkSize                 = 32
 	DefMaxChunkSize                  = 1024
 	DefMaxPreparedStmtCount          = -1
 	DefWaitTimeout                   = 0
 	DefTiDBGeneralLog                = 0
+	DefTiDBSlowLogThreshold          = 300
+	DefTiDBEnableStmtSummary         = true
 	DefTiDBRetryLimit                = 10
 	DefTiDBDisableTxnAutoRetry       = true
 	DefTiDBConstraintCheckInPlace    = false
@@ -251,10 +310,17 @@ const (
 	DefTiDBDDLReorgWorkerCount       = 4
 	DefTiDBDDLReorgBatchSize         = 256
 	DefTiDBDDLErrorCountLimit        = 512
+	DefTiDBDDLSlowOprThreshold       = 300 // 300ms
 	DefTiDBMaxDeltaSchemaCount       = 1024
 	DefTiDBHashAggPartialConcurrency = 4
 	DefTiDBHashAggFinalConcurrency   = 4
+	DefTiDBSortConcurrency           = 4
+	DefTiDBMergeJoinConcurrency      = 1
+	DefTiDBMemQuotaHashAgg           = 32 << 30 // 32GB.
+	DefTiDBMemQuotaQuery             = 1 << 30  // 1GB.
 	DefTiDBUseRadixJoin              = false
+	DefTiDBEnableRuntimeFilter       = false
+	DefTiDBEnableIndexMerge          = false
 	DefEnableVectorizedExpression    = true
 	DefTiDBOptJoinReorderThreshold   = 0
 	DefTiDBSkipIsolationLevelCheck   = false
@@ -264,6 +330,10 @@ const (
 	DefTiDBEnableNoopFuncs           = false
 	DefTiDBAllowRemoveAutoInc        = false
 	DefInnodbLockWaitTimeout         = 50 // 50s
+	DefTiDBUsePlanBaselines          = true
+	DefTiDBCapturePlanBaselines      = false
+	DefTiDBEvolvePlanBaselines       = false
+	DefTiDBEvolvePlanTaskMaxTime     = 600 // 600s
 )
 
 // Process global variables.
@@ -279,4 +349,6 @@ var (
 	MinDDLReorgBatchSize  int32  = 32
 	ServerHostname, _            = os.Hostname()
 	MaxOfMaxAllowedPacket uint64 = 1073741824
+	// DDLSlowOprThreshold is the threshold in milliseconds to log a slow step of a DDL job.
+	DDLSlowOprThreshold uint32 = DefTiDBDDLSlowOprThreshold
 )
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/stmtsummary"
 )
 
 // secondsPerYear represents seconds in a normal year. Leap year is not considered here.
@@ -111,6 +112,16 @@ func GetSessionOnlySysVars(s *SessionVars, key string) (string, bool, error) {
 		return fmt.Sprintf("%d", s.TxnCtx.StartTS), true, nil
 	case TiDBGeneralLog:
 		return fmt.Sprintf("%d", atomic.LoadUint32(&ProcessGeneralLog)), true, nil
+	case TiDBSlowLogThreshold:
+		return strconv.FormatUint(atomic.LoadUint64(&config.GetGlobalConfig().Log.SlowThreshold), 10), true, nil
+	case TiDBEnableStmtSummary:
+		return BoolToIntStr(stmtsummary.StmtSummaryByDigestMap.Enabled()), true, nil
+	case TiDBStmtSummaryRefreshInterval:
+		return strconv.FormatInt(stmtsummary.StmtSummaryByDigestMap.RefreshInterval(), 10), true, nil
+	case TiDBStmtSummaryHistorySize:
+		return strconv.Itoa(stmtsummary.StmtSummaryByDigestMap.HistorySize()), true, nil
+	case TiDBStmtSummaryMaxStmtCount:
+		return strconv.Itoa(stmtsummary.StmtSummaryByDigestMap.MaxStmtCount()), true, nil
 	case TiDBConfig:
 		conf := config.GetGlobalConfig()
 		j, err := json.MarshalIndent(conf, "", "\t")
@@ -314,6 +325,8 @@ func ValidateSetSystemVar(vars *SessionVars, name string, value string) (string,
 		return checkUInt64SystemVar(name, value, 0, 2, vars)
 	case TiDBMaxDeltaSchemaCount:
 		return checkInt64SystemVar(name, value, 100, 16384, vars)
+	case TiDBEvolvePlanTaskMaxTime:
+		return checkInt64SystemVar(name, value, 1, secondsPerYear, vars)
 	case SessionTrackGtids:
 		if strings.EqualFold(value, "OFF") || value == "0" {
 			return "OFF", nil
@@ -363,8 +376,10 @@ func ValidateSetSystemVar(vars *SessionVars, name string, value string) (string,
 		}
 		return value, ErrWrongValueForVar.GenWithStackByArgs(name, value)
 	case TiDBSkipUTF8Check, TiDBOptAggPushDown, TiDBOptInSubqToJoinAndAgg,
-		TiDBEnableCascadesPlanner, TiDBEnableNoopFuncs,
-		TiDBScatterRegion, TiDBGeneralLog, TiDBConstraintCheckInPlace, TiDBEnableVectorizedExpression:
+		TiDBEnableCascadesPlanner, TiDBEnableNoopFuncs, TiDBUsePlanBaselines,
+		TiDBCapturePlanBaselines, TiDBEvolvePlanBaselines,
+		TiDBScatterRegion, TiDBGeneralLog, TiDBConstraintCheckInPlace, TiDBEnableVectorizedExpression,
+		TiDBEnableRuntimeFilter, TiDBEnableIndexMerge, TiDBEnableStmtSummary:
 		fallthrough
 	case GeneralLog, AvoidTemporalUpgrade, BigTables, CheckProxyUsers, LogBin,
 		CoreFile, EndMakersInJSON, SQLLogBin, OfflineMode, PseudoSlaveMode, LowPriorityUpdates,
@@ -420,6 +435,8 @@ func ValidateSetSystemVar(vars *SessionVars, name string, value string) (string,
 		TiDBHashJoinConcurrency,
 		TiDBHashAggPartialConcurrency,
 		TiDBHashAggFinalConcurrency,
+		TiDBSortConcurrency,
+		TiDBMergeJoinConcurrency,
 		TiDBDistSQLScanConcurrency,
 		TiDBIndexSerialScanConcurrency, TiDBDDLReorgWorkerCount,
 		TiDBBackoffLockFast, TiDBBackOffWeight:
@@ -431,7 +448,7 @@ func ValidateSetSystemVar(vars *SessionVars, name string, value string) (string,
 			return value, ErrWrongValueForVar.GenWithStackByArgs(name, value)
 		}
 		return value, nil
-	case TiDBOptCorrelationExpFactor:
+	case TiDBOptCorrelationExpFactor, TiDBOptCascadesJoinReorderBudget:
 		v, err := strconv.Atoi(value)
 		if err != nil {
 			return value, ErrWrongTypeForVar.GenWithStackByArgs(name)
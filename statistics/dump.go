@@ -0,0 +1,164 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// JSONTable is used for dumping statistics.
+type JSONTable struct {
+	DatabaseName string                 `json:"database_name"`
+	TableName    string                 `json:"table_name"`
+	Columns      map[string]*jsonColumn `json:"columns"`
+	Indices      map[string]*jsonColumn `json:"indices"`
+	Count        int64                  `json:"count"`
+	ModifyCount  int64                  `json:"modify_count"`
+}
+
+type jsonColumn struct {
+	Histogram         *tipb.Histogram `json:"histogram"`
+	CMSketch          *tipb.CMSketch  `json:"cm_sketch"`
+	NullCount         int64           `json:"null_count"`
+	TotColSize        int64           `json:"tot_col_size"`
+	LastUpdateVersion uint64          `json:"last_update_version"`
+}
+
+func dumpJSONCol(hist *Histogram, cms *CMSketch) *jsonColumn {
+	jsonCol := &jsonColumn{
+		Histogram:         HistogramToProto(hist),
+		NullCount:         hist.NullCount,
+		TotColSize:        hist.TotColSize,
+		LastUpdateVersion: hist.LastUpdateVersion,
+	}
+	if cms != nil {
+		jsonCol.CMSketch = CMSketchToProto(cms)
+	}
+	return jsonCol
+}
+
+// DumpStatsToJSON dumps the statistics stored in storage of the given table to a JSONTable.
+func (h *Handle) DumpStatsToJSON(dbName string, tableInfo *model.TableInfo) (*JSONTable, error) {
+	return h.dumpStatsToJSON(dbName, tableInfo, 0)
+}
+
+// DumpStatsToJSONBySnapshot dumps the statistics of the given table at the snapshot timestamp to a JSONTable.
+func (h *Handle) DumpStatsToJSONBySnapshot(dbName string, tableInfo *model.TableInfo, snapshot uint64) (*JSONTable, error) {
+	return h.dumpStatsToJSON(dbName, tableInfo, snapshot)
+}
+
+func (h *Handle) dumpStatsToJSON(dbName string, tableInfo *model.TableInfo, snapshot uint64) (*JSONTable, error) {
+	jsonTbl := &JSONTable{
+		DatabaseName: dbName,
+		TableName:    tableInfo.Name.L,
+		Columns:      make(map[string]*jsonColumn, len(tableInfo.Columns)),
+		Indices:      make(map[string]*jsonColumn, len(tableInfo.Indices)),
+	}
+	sql := fmt.Sprintf("select count, modify_count from mysql.stats_meta where table_id = %d", tableInfo.ID)
+	rows, _, err := h.execRestrictedSQL(sql, snapshot)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The table has not been analyzed, so there is nothing more to dump.
+	if len(rows) == 0 {
+		return jsonTbl, nil
+	}
+	jsonTbl.Count = rows[0].GetInt64(0)
+	jsonTbl.ModifyCount = rows[0].GetInt64(1)
+
+	tbl, err := h.tableStatsFromStorage(tableInfo, tableInfo.ID, snapshot)
+	if err != nil || tbl == nil {
+		return jsonTbl, errors.Trace(err)
+	}
+	for _, col := range tbl.Columns {
+		sc := &stmtctx.StatementContext{TimeZone: time.UTC}
+		hist, err := col.ConvertTo(sc, types.NewFieldType(mysql.TypeBlob))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jsonTbl.Columns[col.Info.Name.L] = dumpJSONCol(hist, col.CMSketch)
+	}
+	for _, idx := range tbl.Indices {
+		jsonTbl.Indices[idx.Info.Name.L] = dumpJSONCol(&idx.Histogram, idx.CMSketch)
+	}
+	return jsonTbl, nil
+}
+
+// LoadStatsFromJSON saves the statistics in the JSONTable to storage and refreshes the stats cache.
+// The table is looked up by its name, so the statistics can be loaded into another cluster.
+func (h *Handle) LoadStatsFromJSON(is infoschema.InfoSchema, jsonTbl *JSONTable) error {
+	tbl, err := is.TableByName(model.NewCIStr(jsonTbl.DatabaseName), model.NewCIStr(jsonTbl.TableName))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tableInfo := tbl.Meta()
+	for _, colInfo := range tableInfo.Columns {
+		jsonCol, ok := jsonTbl.Columns[colInfo.Name.L]
+		if !ok {
+			continue
+		}
+		hist := loadJSONCol(colInfo.ID, jsonCol)
+		err = h.SaveStatsToStorage(tableInfo.ID, jsonTbl.Count, 0, hist, CMSketchFromProto(jsonCol.CMSketch))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, idxInfo := range tableInfo.Indices {
+		jsonIdx, ok := jsonTbl.Indices[idxInfo.Name.L]
+		if !ok {
+			continue
+		}
+		hist := loadJSONCol(idxInfo.ID, jsonIdx)
+		err = h.SaveStatsToStorage(tableInfo.ID, jsonTbl.Count, 1, hist, CMSketchFromProto(jsonIdx.CMSketch))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(jsonTbl.Columns) > 0 || len(jsonTbl.Indices) > 0 {
+		if err = h.saveModifyCount(tableInfo.ID, jsonTbl.ModifyCount); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(h.Update(is))
+}
+
+func loadJSONCol(histID int64, jsonCol *jsonColumn) *Histogram {
+	hist := HistogramFromProto(jsonCol.Histogram)
+	hist.ID = histID
+	hist.NullCount = jsonCol.NullCount
+	hist.TotColSize = jsonCol.TotColSize
+	hist.LastUpdateVersion = jsonCol.LastUpdateVersion
+	return hist
+}
+
+// saveModifyCount sets the modify count of the table, since SaveStatsToStorage resets it.
+func (h *Handle) saveModifyCount(tableID int64, modifyCount int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	exec := h.mu.ctx.(sqlexec.SQLExecutor)
+	sql := fmt.Sprintf("update mysql.stats_meta set modify_count = %d where table_id = %d", modifyCount, tableID)
+	_, err := exec.Execute(context.Background(), sql)
+	return errors.Trace(err)
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/model"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
@@ -63,6 +64,9 @@ type Handle struct {
 
 	restrictedExec sqlexec.RestrictedSQLExecutor
 
+	// idxUsage collects the index usage of the queries, it is dumped to storage periodically.
+	idxUsage indexUsageCollector
+
 	lease atomic2.Duration
 }
 
@@ -86,6 +90,7 @@ func NewHandle(ctx sessionctx.Context, lease time.Duration) *Handle {
 	}
 	handle.mu.ctx = ctx
 	handle.statsCache.Store(statsCache{tables: make(map[int64]*Table)})
+	handle.idxUsage.usage = make(map[GlobalIndexID]IndexUsageInformation)
 	return handle
 }
 
@@ -140,7 +145,7 @@ func (h *Handle) Update(is infoschema.InfoSchema) error {
 			continue
 		}
 		tableInfo := table.Meta()
-		tbl, err := h.tableStatsFromStorage(tableInfo, physicalID)
+		tbl, err := h.tableStatsFromStorage(tableInfo, physicalID, 0)
 		// Error is not nil may mean that there are some ddl changes on this table, we will not update it.
 		if err != nil {
 			logutil.BgLogger().Debug("error occurred when read table stats", zap.String("table", tableInfo.Name.O), zap.Error(err))
@@ -220,16 +225,24 @@ func (sc statsCache) update(tables []*Table, deletedIDs []int64, newVersion uint
 	return newCache
 }
 
-func (h *Handle) cmSketchFromStorage(tblID int64, isIndex, histID int64) (_ *CMSketch, err error) {
+// execRestrictedSQL reads the latest statistics, or the statistics at the snapshot if it isn't 0.
+func (h *Handle) execRestrictedSQL(sql string, snapshot uint64) ([]chunk.Row, []*ast.ResultField, error) {
+	if snapshot != 0 {
+		return h.restrictedExec.ExecRestrictedSQLWithSnapshot(sql, snapshot)
+	}
+	return h.restrictedExec.ExecRestrictedSQL(sql)
+}
+
+func (h *Handle) cmSketchFromStorage(tblID int64, isIndex, histID int64, snapshot uint64) (_ *CMSketch, err error) {
 	selSQL := fmt.Sprintf("select cm_sketch from mysql.stats_histograms where table_id = %d and is_index = %d and hist_id = %d", tblID, isIndex, histID)
-	rows, _, err := h.restrictedExec.ExecRestrictedSQL(selSQL)
+	rows, _, err := h.execRestrictedSQL(selSQL, snapshot)
 	if err != nil || len(rows) == 0 {
 		return nil, err
 	}
 	return DecodeCMSketch(rows[0].GetBytes(0))
 }
 
-func (h *Handle) indexStatsFromStorage(row chunk.Row, table *Table, tableInfo *model.TableInfo) error {
+func (h *Handle) indexStatsFromStorage(row chunk.Row, table *Table, tableInfo *model.TableInfo, snapshot uint64) error {
 	histID := row.GetInt64(2)
 	distinct := row.GetInt64(3)
 	histVer := row.GetUint64(4)
@@ -240,11 +253,11 @@ func (h *Handle) indexStatsFromStorage(row chunk.Row, table *Table, tableInfo *m
 			continue
 		}
 		if idx == nil || idx.LastUpdateVersion < histVer {
-			hg, err := h.histogramFromStorage(table.PhysicalID, histID, types.NewFieldType(mysql.TypeBlob), distinct, 1, histVer, nullCount, 0)
+			hg, err := h.histogramFromStorage(table.PhysicalID, histID, types.NewFieldType(mysql.TypeBlob), distinct, 1, histVer, nullCount, 0, snapshot)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			cms, err := h.cmSketchFromStorage(table.PhysicalID, 1, idxInfo.ID)
+			cms, err := h.cmSketchFromStorage(table.PhysicalID, 1, idxInfo.ID, snapshot)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -260,7 +273,7 @@ func (h *Handle) indexStatsFromStorage(row chunk.Row, table *Table, tableInfo *m
 	return nil
 }
 
-func (h *Handle) columnStatsFromStorage(row chunk.Row, table *Table, tableInfo *model.TableInfo) error {
+func (h *Handle) columnStatsFromStorage(row chunk.Row, table *Table, tableInfo *model.TableInfo, snapshot uint64) error {
 	histID := row.GetInt64(2)
 	distinct := row.GetInt64(3)
 	histVer := row.GetUint64(4)
@@ -272,11 +285,11 @@ func (h *Handle) columnStatsFromStorage(row chunk.Row, table *Table, tableInfo *
 			continue
 		}
 		if col == nil || col.LastUpdateVersion < histVer {
-			hg, err := h.histogramFromStorage(table.PhysicalID, histID, &colInfo.FieldType, distinct, 0, histVer, nullCount, totColSize)
+			hg, err := h.histogramFromStorage(table.PhysicalID, histID, &colInfo.FieldType, distinct, 0, histVer, nullCount, totColSize, snapshot)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			cms, err := h.cmSketchFromStorage(table.PhysicalID, 0, colInfo.ID)
+			cms, err := h.cmSketchFromStorage(table.PhysicalID, 0, colInfo.ID, snapshot)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -308,12 +321,13 @@ func (h *Handle) columnStatsFromStorage(row chunk.Row, table *Table, tableInfo *
 	return nil
 }
 
-// tableStatsFromStorage loads table stats info from storage.
-func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo, physicalID int64) (_ *Table, err error) {
+// tableStatsFromStorage loads table stats info from storage, the latest stats are loaded if the snapshot is 0.
+func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo, physicalID int64, snapshot uint64) (_ *Table, err error) {
 	table, ok := h.statsCache.Load().(statsCache).tables[physicalID]
 	// If table stats is pseudo, we also need to copy it, since we will use the column stats when
 	// the average error rate of it is small.
-	if !ok {
+	// The cached stats are newer than the history ones, so they are not reused when reading a snapshot.
+	if !ok || snapshot != 0 {
 		histColl := HistColl{
 			PhysicalID:     physicalID,
 			HavePhysicalID: true,
@@ -329,16 +343,16 @@ func (h *Handle) tableStatsFromStorage(tableInfo *model.TableInfo, physicalID in
 	}
 	table.Pseudo = false
 	selSQL := fmt.Sprintf("select table_id, is_index, hist_id, distinct_count, version, null_count, tot_col_size, stats_ver, flag, correlation, last_analyze_pos from mysql.stats_histograms where table_id = %d", physicalID)
-	rows, _, err := h.restrictedExec.ExecRestrictedSQL(selSQL)
+	rows, _, err := h.execRestrictedSQL(selSQL, snapshot)
 	// Check deleted table.
 	if err != nil || len(rows) == 0 {
 		return nil, nil
 	}
 	for _, row := range rows {
 		if row.GetInt64(1) > 0 {
-			err = h.indexStatsFromStorage(row, table, tableInfo)
+			err = h.indexStatsFromStorage(row, table, tableInfo, snapshot)
 		} else {
-			err = h.columnStatsFromStorage(row, table, tableInfo)
+			err = h.columnStatsFromStorage(row, table, tableInfo, snapshot)
 		}
 		if err != nil {
 			return nil, err
@@ -417,9 +431,9 @@ func execSQLs(ctx context.Context, exec sqlexec.SQLExecutor, sqls []string) erro
 	return nil
 }
 
-func (h *Handle) histogramFromStorage(tableID int64, colID int64, tp *types.FieldType, distinct int64, isIndex int, ver uint64, nullCount int64, totColSize int64) (_ *Histogram, err error) {
+func (h *Handle) histogramFromStorage(tableID int64, colID int64, tp *types.FieldType, distinct int64, isIndex int, ver uint64, nullCount int64, totColSize int64, snapshot uint64) (_ *Histogram, err error) {
 	selSQL := fmt.Sprintf("select count, repeats, lower_bound, upper_bound from mysql.stats_buckets where table_id = %d and is_index = %d and hist_id = %d order by bucket_id", tableID, isIndex, colID)
-	rows, fields, err := h.restrictedExec.ExecRestrictedSQL(selSQL)
+	rows, fields, err := h.execRestrictedSQL(selSQL, snapshot)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
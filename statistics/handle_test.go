@@ -14,6 +14,7 @@
 package statistics_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 	"unsafe"
@@ -123,6 +124,93 @@ func (s *testStatsSuite) TestStatsStoreAndLoad(c *C) {
 	assertTableEqual(c, statsTbl1, statsTbl2)
 }
 
+func (s *testStatsSuite) TestDumpAndLoadStats(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (c1 int, c2 varchar(20), index idx_t(c2))")
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t values (%d, '%d')", i, i%10))
+	}
+	testKit.MustExec("analyze table t")
+	do := s.do
+	h := do.StatsHandle()
+	tbl, err := do.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	statsTbl1 := h.GetTableStats(tbl.Meta())
+
+	jsonTbl, err := h.DumpStatsToJSON("test", tbl.Meta())
+	c.Assert(err, IsNil)
+	c.Assert(jsonTbl.Count, Equals, int64(100))
+	c.Assert(jsonTbl.Columns, HasLen, 2)
+	c.Assert(jsonTbl.Indices, HasLen, 1)
+	data, err := json.Marshal(jsonTbl)
+	c.Assert(err, IsNil)
+
+	// Load the stats into a newly created table with the same name.
+	testKit.MustExec("drop table t")
+	testKit.MustExec("create table t (c1 int, c2 varchar(20), index idx_t(c2))")
+	h.Clear()
+	loadTbl := &statistics.JSONTable{}
+	c.Assert(json.Unmarshal(data, loadTbl), IsNil)
+	is := do.InfoSchema()
+	c.Assert(h.LoadStatsFromJSON(is, loadTbl), IsNil)
+	tbl, err = is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	statsTbl2 := h.GetTableStats(tbl.Meta())
+	c.Assert(statsTbl2.Pseudo, IsFalse)
+	assertTableEqual(c, statsTbl1, statsTbl2)
+
+	// A table without statistics dumps nothing but its name.
+	testKit.MustExec("create table t1 (a int)")
+	tbl, err = do.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t1"))
+	c.Assert(err, IsNil)
+	jsonTbl, err = h.DumpStatsToJSON("test", tbl.Meta())
+	c.Assert(err, IsNil)
+	c.Assert(jsonTbl.TableName, Equals, "t1")
+	c.Assert(jsonTbl.Columns, HasLen, 0)
+}
+
+func (s *testStatsSuite) TestIndexUsage(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	defer testKit.MustExec("delete from mysql.schema_index_usage")
+	h := s.do.StatsHandle()
+	c.Assert(h.DumpIndexUsageToKV(), IsNil)
+	usage, err := h.GetIndexUsage()
+	c.Assert(err, IsNil)
+	c.Assert(usage, HasLen, 0)
+
+	h.UpdateIndexUsage(1, 1, 10)
+	h.UpdateIndexUsage(1, 1, 5)
+	h.UpdateIndexUsage(1, 2, 0)
+	// The usage in memory is returned before it is dumped.
+	usage, err = h.GetIndexUsage()
+	c.Assert(err, IsNil)
+	c.Assert(usage, HasLen, 2)
+	c.Assert(usage[statistics.GlobalIndexID{TableID: 1, IndexID: 1}].QueryCount, Equals, int64(2))
+	c.Assert(h.DumpIndexUsageToKV(), IsNil)
+	usage, err = h.GetIndexUsage()
+	c.Assert(err, IsNil)
+	c.Assert(usage, HasLen, 2)
+	c.Assert(usage[statistics.GlobalIndexID{TableID: 1, IndexID: 1}].QueryCount, Equals, int64(2))
+	c.Assert(usage[statistics.GlobalIndexID{TableID: 1, IndexID: 1}].RowsSelected, Equals, int64(15))
+	c.Assert(usage[statistics.GlobalIndexID{TableID: 1, IndexID: 2}].QueryCount, Equals, int64(1))
+
+	// The usage dumped later is merged into the stored one.
+	h.UpdateIndexUsage(1, 1, 3)
+	usage, err = h.GetIndexUsage()
+	c.Assert(err, IsNil)
+	c.Assert(usage[statistics.GlobalIndexID{TableID: 1, IndexID: 1}].RowsSelected, Equals, int64(18))
+	c.Assert(h.DumpIndexUsageToKV(), IsNil)
+	usage, err = h.GetIndexUsage()
+	c.Assert(err, IsNil)
+	info := usage[statistics.GlobalIndexID{TableID: 1, IndexID: 1}]
+	c.Assert(info.QueryCount, Equals, int64(3))
+	c.Assert(info.RowsSelected, Equals, int64(18))
+	c.Assert(info.LastUsedAt, Not(Equals), "")
+}
+
 func (s *testStatsSuite) TestEmptyTable(c *C) {
 	defer cleanEnv(c, s.store, s.do)
 	testKit := testkit.NewTestKit(c, s.store)
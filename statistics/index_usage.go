@@ -0,0 +1,155 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// IndexUsageTimeFormat is the format of the last used time of an index.
+const IndexUsageTimeFormat = "2006-01-02 15:04:05"
+
+// GlobalIndexID is the key of the index usage, the table ID is the physical table ID.
+type GlobalIndexID struct {
+	TableID int64
+	IndexID int64
+}
+
+// IndexUsageInformation is the usage of an index.
+type IndexUsageInformation struct {
+	QueryCount   int64
+	RowsSelected int64
+	LastUsedAt   string
+}
+
+// merge merges the other usage into the current one.
+func (u IndexUsageInformation) merge(other IndexUsageInformation) IndexUsageInformation {
+	u.QueryCount += other.QueryCount
+	u.RowsSelected += other.RowsSelected
+	// The last used time has a fixed format, so it can be compared as string.
+	if other.LastUsedAt > u.LastUsedAt {
+		u.LastUsedAt = other.LastUsedAt
+	}
+	return u
+}
+
+// indexUsageCollector collects the index usage in memory before it is dumped to storage.
+type indexUsageCollector struct {
+	sync.Mutex
+	usage map[GlobalIndexID]IndexUsageInformation
+	// dumpMu is held while the usage is being dumped, so that GetIndexUsage
+	// doesn't miss the usage being written, which is neither in memory nor
+	// in storage.
+	dumpMu sync.RWMutex
+}
+
+// UpdateIndexUsage records one access of the index which reads rowsSelected rows.
+func (h *Handle) UpdateIndexUsage(tableID, indexID, rowsSelected int64) {
+	id := GlobalIndexID{TableID: tableID, IndexID: indexID}
+	info := IndexUsageInformation{QueryCount: 1, RowsSelected: rowsSelected, LastUsedAt: time.Now().Format(IndexUsageTimeFormat)}
+	h.idxUsage.Lock()
+	h.idxUsage.usage[id] = h.idxUsage.usage[id].merge(info)
+	h.idxUsage.Unlock()
+}
+
+// DumpIndexUsageToKV merges the index usage collected in memory into mysql.schema_index_usage.
+func (h *Handle) DumpIndexUsageToKV() error {
+	h.idxUsage.dumpMu.Lock()
+	defer h.idxUsage.dumpMu.Unlock()
+	h.idxUsage.Lock()
+	usage := h.idxUsage.usage
+	h.idxUsage.usage = make(map[GlobalIndexID]IndexUsageInformation)
+	h.idxUsage.Unlock()
+	if len(usage) == 0 {
+		return nil
+	}
+	err := h.saveIndexUsage(usage)
+	if err != nil {
+		// Put the usage back, so that it can be dumped next time.
+		h.idxUsage.Lock()
+		for id, info := range usage {
+			h.idxUsage.usage[id] = h.idxUsage.usage[id].merge(info)
+		}
+		h.idxUsage.Unlock()
+	}
+	return errors.Trace(err)
+}
+
+func (h *Handle) saveIndexUsage(usage map[GlobalIndexID]IndexUsageInformation) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ctx := context.Background()
+	exec := h.mu.ctx.(sqlexec.SQLExecutor)
+	_, err = exec.Execute(ctx, "begin")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		err = finishTransaction(ctx, exec, err)
+	}()
+	sqls := make([]string, 0, len(usage))
+	for id, info := range usage {
+		sql := fmt.Sprintf("select query_count, rows_selected, last_used_at from mysql.schema_index_usage where table_id = %d and index_id = %d", id.TableID, id.IndexID)
+		var rss []sqlexec.RecordSet
+		rss, err = exec.Execute(ctx, sql)
+		if err != nil {
+			return
+		}
+		rs := rss[0]
+		req := rs.NewChunk()
+		err = rs.Next(ctx, req)
+		if closeErr := rs.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return
+		}
+		if req.NumRows() > 0 {
+			row := req.GetRow(0)
+			old := IndexUsageInformation{QueryCount: row.GetInt64(0), RowsSelected: row.GetInt64(1), LastUsedAt: row.GetString(2)}
+			info = old.merge(info)
+		}
+		sqls = append(sqls, fmt.Sprintf("replace into mysql.schema_index_usage (table_id, index_id, query_count, rows_selected, last_used_at) values (%d, %d, %d, %d, '%s')",
+			id.TableID, id.IndexID, info.QueryCount, info.RowsSelected, info.LastUsedAt))
+	}
+	return execSQLs(ctx, exec, sqls)
+}
+
+// GetIndexUsage returns the index usage dumped to storage, merged with the
+// usage collected in memory which is not dumped yet.
+func (h *Handle) GetIndexUsage() (map[GlobalIndexID]IndexUsageInformation, error) {
+	h.idxUsage.dumpMu.RLock()
+	defer h.idxUsage.dumpMu.RUnlock()
+	rows, _, err := h.restrictedExec.ExecRestrictedSQL("select table_id, index_id, query_count, rows_selected, last_used_at from mysql.schema_index_usage")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	usage := make(map[GlobalIndexID]IndexUsageInformation, len(rows))
+	for _, row := range rows {
+		id := GlobalIndexID{TableID: row.GetInt64(0), IndexID: row.GetInt64(1)}
+		usage[id] = IndexUsageInformation{QueryCount: row.GetInt64(2), RowsSelected: row.GetInt64(3), LastUsedAt: row.GetString(4)}
+	}
+	h.idxUsage.Lock()
+	for id, info := range h.idxUsage.usage {
+		usage[id] = usage[id].merge(info)
+	}
+	h.idxUsage.Unlock()
+	return usage, nil
+}
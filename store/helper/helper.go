@@ -0,0 +1,124 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+)
+
+const (
+	mvccGetMaxBackoff = 20000
+	mvccGetTimeout    = time.Minute
+)
+
+// Helper is a middleware to get some information from tikv/pd. It can be used for TiDB's http api or sql functions.
+type Helper struct {
+	Store       tikv.Storage
+	RegionCache *tikv.RegionCache
+}
+
+// NewHelper creates a Helper of the store.
+func NewHelper(store tikv.Storage) *Helper {
+	return &Helper{
+		Store:       store,
+		RegionCache: store.GetRegionCache(),
+	}
+}
+
+// GetMvccByEncodedKey get the MVCC value by the specific encoded key.
+func (h *Helper) GetMvccByEncodedKey(encodedKey kv.Key) (*tikvrpc.MvccGetByKeyResponse, error) {
+	bo := tikv.NewBackoffer(context.Background(), mvccGetMaxBackoff)
+	for {
+		keyLocation, err := h.RegionCache.LocateKey(bo, encodedKey)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		tikvReq := tikvrpc.NewRequest(tikvrpc.CmdMvccGetByKey, &tikvrpc.MvccGetByKeyRequest{Key: encodedKey})
+		kvResp, err := h.Store.SendReq(bo, tikvReq, keyLocation.Region, mvccGetTimeout)
+		if err != nil {
+			logutil.BgLogger().Info("get MVCC by encoded key failed",
+				zap.Stringer("encodeKey", encodedKey),
+				zap.Reflect("region", keyLocation.Region),
+				zap.Error(err))
+			return nil, errors.Trace(err)
+		}
+		data := kvResp.Resp.(*tikvrpc.MvccGetByKeyResponse)
+		if regionErr := data.GetRegionError(); regionErr != nil {
+			if err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String())); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		if len(data.Error) > 0 {
+			return nil, errors.New(data.Error)
+		}
+		return data, nil
+	}
+}
+
+// GetMvccByStartTs gets the MVCC info of the first key in [startKey, endKey) written by the transaction
+// with the startTS, the key of the result is nil if no such a key.
+func (h *Helper) GetMvccByStartTs(startTS uint64, startKey, endKey kv.Key) (*tikvrpc.MvccGetByStartTsResponse, error) {
+	bo := tikv.NewBackoffer(context.Background(), mvccGetMaxBackoff)
+	for {
+		curRegion, err := h.RegionCache.LocateKey(bo, startKey)
+		if err != nil {
+			logutil.BgLogger().Error("get MVCC by startTS failed", zap.Uint64("txnStartTS", startTS),
+				zap.Stringer("startKey", startKey), zap.Error(err))
+			return nil, errors.Trace(err)
+		}
+
+		tikvReq := tikvrpc.NewRequest(tikvrpc.CmdMvccGetByStartTs, &tikvrpc.MvccGetByStartTsRequest{
+			StartTs:  startTS,
+			StartKey: startKey,
+			EndKey:   endKey,
+		})
+		kvResp, err := h.Store.SendReq(bo, tikvReq, curRegion.Region, mvccGetTimeout)
+		if err != nil {
+			logutil.BgLogger().Error("get MVCC by startTS failed",
+				zap.Uint64("txnStartTS", startTS),
+				zap.Stringer("startKey", startKey),
+				zap.Reflect("region", curRegion.Region),
+				zap.Error(err))
+			return nil, errors.Trace(err)
+		}
+		data := kvResp.Resp.(*tikvrpc.MvccGetByStartTsResponse)
+		if regionErr := data.GetRegionError(); regionErr != nil {
+			if err = bo.Backoff(tikv.BoRegionMiss, errors.New(regionErr.String())); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		if len(data.Error) > 0 {
+			return nil, errors.New(data.Error)
+		}
+		if len(data.Key) > 0 {
+			return data, nil
+		}
+
+		if len(curRegion.EndKey) == 0 || (len(endKey) > 0 && curRegion.Contains(endKey)) {
+			return data, nil
+		}
+		startKey = curRegion.EndKey
+	}
+}
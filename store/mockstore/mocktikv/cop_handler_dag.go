@@ -72,6 +72,9 @@ func (h *rpcHandler) handleCopDAGRequest(req *coprocessor.Request) *coprocessor.
 	}
 
 	selResp := h.initSelectResponse(err, dagCtx.evalCtx.sc.GetWarnings(), e.Counts())
+	if dagReq.GetCollectExecutionSummaries() {
+		selResp.ExecutionSummaries = collectExecutionSummaries(e)
+	}
 	if err == nil {
 		err = h.fillUpData4SelectResponse(selResp, dagReq, rows)
 	}
@@ -152,6 +155,9 @@ func (h *rpcHandler) buildDAG(ctx *dagContext, executors []*tipb.Executor) (exec
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if ctx.dagReq.GetCollectExecutionSummaries() {
+			curr = &execSummaryCollector{executor: curr}
+		}
 		curr.SetSrcExec(src)
 		src = curr
 	}
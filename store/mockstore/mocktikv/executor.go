@@ -16,6 +16,7 @@ package mocktikv
 import (
 	"bytes"
 	"context"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/expression"
@@ -581,3 +582,50 @@ func convertToExprs(sc *stmtctx.StatementContext, fieldTps []*types.FieldType, p
 	}
 	return exprs, nil
 }
+
+// execSummaryCollector wraps an executor to collect its execution summary,
+// which is reported to TiDB if the DAG request asks for it.
+type execSummaryCollector struct {
+	executor
+
+	timeProcessed   time.Duration
+	numProducedRows uint64
+	numIterations   uint64
+}
+
+func (e *execSummaryCollector) Next(ctx context.Context) ([][]byte, error) {
+	start := time.Now()
+	row, err := e.executor.Next(ctx)
+	e.timeProcessed += time.Since(start)
+	e.numIterations++
+	if row != nil {
+		e.numProducedRows++
+	}
+	return row, err
+}
+
+func (e *execSummaryCollector) summary() *tipb.ExecutorExecutionSummary {
+	timeProcessedNs := uint64(e.timeProcessed)
+	return &tipb.ExecutorExecutionSummary{
+		TimeProcessedNs: &timeProcessedNs,
+		NumProducedRows: &e.numProducedRows,
+		NumIterations:   &e.numIterations,
+	}
+}
+
+// collectExecutionSummaries returns the execution summaries of the executors
+// in the same order as the executors in the DAG request.
+func collectExecutionSummaries(root executor) []*tipb.ExecutorExecutionSummary {
+	var summaries []*tipb.ExecutorExecutionSummary
+	for e := root; e != nil; e = e.GetSrcExec() {
+		collector, ok := e.(*execSummaryCollector)
+		if !ok {
+			return nil
+		}
+		summaries = append(summaries, collector.summary())
+	}
+	for i, j := 0, len(summaries)-1; i < j; i, j = i+1, j-1 {
+		summaries[i], summaries[j] = summaries[j], summaries[i]
+	}
+	return summaries
+}
@@ -679,3 +679,43 @@ func (s *testMVCCLevelDB) TestTxnHeartBeat(c *C) {
 	_, err = s.store.TxnHeartBeat([]byte("pk"), 5, 1000)
 	c.Assert(err, NotNil)
 }
+
+func (s *testMVCCLevelDB) TestMvccGetByKey(c *C) {
+	s.mustPutOK(c, "k", "v1", 5, 10)
+	s.mustDeleteOK(c, "k", 15, 20)
+	s.mustPrewriteOK(c, putMutations("k", "v2"), "k", 25)
+
+	debugger, ok := s.store.(MVCCDebugger)
+	c.Assert(ok, IsTrue)
+	info, err := debugger.MvccGetByKey([]byte("k"))
+	c.Assert(err, IsNil)
+	c.Assert(info.Lock, NotNil)
+	c.Assert(info.Lock.StartTs, Equals, uint64(25))
+	c.Assert(string(info.Lock.Primary), Equals, "k")
+	c.Assert(string(info.Lock.ShortValue), Equals, "v2")
+	c.Assert(info.Writes, HasLen, 2)
+	c.Assert(info.Writes[0].Type, Equals, kvrpcpb.Op_Del)
+	c.Assert(info.Writes[0].StartTs, Equals, uint64(15))
+	c.Assert(info.Writes[0].CommitTs, Equals, uint64(20))
+	c.Assert(info.Writes[1].Type, Equals, kvrpcpb.Op_Put)
+	c.Assert(string(info.Writes[1].ShortValue), Equals, "v1")
+
+	info, err = debugger.MvccGetByKey([]byte("not_exist"))
+	c.Assert(err, IsNil)
+	c.Assert(info.Lock, IsNil)
+	c.Assert(info.Writes, HasLen, 0)
+
+	s.mustPutOK(c, "k1", "v", 30, 35)
+	info, key, err := debugger.MvccGetByStartTS(nil, nil, 30)
+	c.Assert(err, IsNil)
+	c.Assert(string(key), Equals, "k1")
+	c.Assert(info.Writes, HasLen, 1)
+	info, key, err = debugger.MvccGetByStartTS(nil, nil, 25)
+	c.Assert(err, IsNil)
+	c.Assert(string(key), Equals, "k")
+	c.Assert(info.Lock, NotNil)
+	info, key, err = debugger.MvccGetByStartTS([]byte("k1"), nil, 5)
+	c.Assert(err, IsNil)
+	c.Assert(key, IsNil)
+	c.Assert(info, IsNil)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/google/btree"
 	"github.com/pingcap-incubator/tinykv/proto/pkg/kvrpcpb"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/util/codec"
 )
 
@@ -258,6 +259,15 @@ type RawKV interface {
 	RawDeleteRange(startKey, endKey []byte)
 }
 
+// MVCCDebugger is for debugging.
+type MVCCDebugger interface {
+	// MvccGetByKey returns the lock and all the write records of the key.
+	MvccGetByKey(key []byte) (*tikvrpc.MvccInfo, error)
+	// MvccGetByStartTS returns the first key in [startKey, endKey) written by the transaction
+	// with the startTS, and its lock and write records.
+	MvccGetByStartTS(startKey, endKey []byte, startTS uint64) (*tikvrpc.MvccInfo, []byte, error)
+}
+
 // Pair is a KV pair read from MvccStore or an error if any occurs.
 type Pair struct {
 	Key   []byte
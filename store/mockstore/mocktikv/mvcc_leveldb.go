@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/goleveldb/leveldb/util"
 	"github.com/pingcap/tidb/parser/terror"
 	"github.com/pingcap/tidb/store/tikv/oracle"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/logutil"
 	"go.uber.org/zap"
@@ -1250,3 +1251,93 @@ var valueTypeOpMap = [...]kvrpcpb.Op{
 	typeDelete:   kvrpcpb.Op_Del,
 	typeRollback: kvrpcpb.Op_Rollback,
 }
+
+// MvccGetByKey implements the MVCCDebugger interface.
+func (mvcc *MVCCLevelDB) MvccGetByKey(key []byte) (*tikvrpc.MvccInfo, error) {
+	mvcc.mu.RLock()
+	defer mvcc.mu.RUnlock()
+
+	return mvcc.mvccGetByKeyNoLock(key)
+}
+
+// mvccGetByKeyNoLock reads the lock and all the versions of the key, the newer version comes first.
+func (mvcc *MVCCLevelDB) mvccGetByKeyNoLock(key []byte) (*tikvrpc.MvccInfo, error) {
+	info := &tikvrpc.MvccInfo{}
+	iter := newIterator(mvcc.db, &util.Range{
+		Start: mvccEncode(key, lockVer),
+	})
+	defer iter.Release()
+
+	dec1 := lockDecoder{expectKey: key}
+	ok, err := dec1.Decode(iter)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if ok {
+		info.Lock = &tikvrpc.MvccLock{
+			Type:       dec1.lock.op,
+			StartTs:    dec1.lock.startTS,
+			Primary:    dec1.lock.primary,
+			ShortValue: dec1.lock.value,
+		}
+	}
+
+	dec2 := valueDecoder{expectKey: key}
+	for iter.Valid() {
+		ok, err = dec2.Decode(iter)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !ok {
+			break
+		}
+		value := dec2.value
+		info.Writes = append(info.Writes, &tikvrpc.MvccWrite{
+			Type:       valueTypeOpMap[value.valueType],
+			StartTs:    value.startTS,
+			CommitTs:   value.commitTS,
+			ShortValue: value.value,
+		})
+	}
+	return info, nil
+}
+
+// MvccGetByStartTS implements the MVCCDebugger interface.
+func (mvcc *MVCCLevelDB) MvccGetByStartTS(startKey, endKey []byte, startTS uint64) (*tikvrpc.MvccInfo, []byte, error) {
+	mvcc.mu.RLock()
+	defer mvcc.mu.RUnlock()
+
+	iter, _, err := newScanIterator(mvcc.db, startKey, endKey)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	defer iter.Release()
+
+	// Find the first key which is locked or written by the transaction.
+	for ; iter.Valid(); iter.Next() {
+		key, ver, err := mvccDecode(iter.Key())
+		if err != nil {
+			// Skip the raw kv pairs which are not encoded.
+			continue
+		}
+		var ts uint64
+		if ver == lockVer {
+			var lock mvccLock
+			if err = lock.UnmarshalBinary(iter.Value()); err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			ts = lock.startTS
+		} else {
+			var value mvccValue
+			if err = value.UnmarshalBinary(iter.Value()); err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			ts = value.startTS
+		}
+		if ts == startTS {
+			info, err := mvcc.mvccGetByKeyNoLock(key)
+			return info, key, errors.Trace(err)
+		}
+	}
+	return nil, nil, errors.Trace(iter.Error())
+}
@@ -14,6 +14,7 @@
 package mocktikv
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -346,6 +347,56 @@ func (h *rpcHandler) handleKvRawScan(req *kvrpcpb.RawScanRequest) *kvrpcpb.RawSc
 	}
 }
 
+func (h *rpcHandler) handleMvccGetByKey(req *tikvrpc.MvccGetByKeyRequest) *tikvrpc.MvccGetByKeyResponse {
+	debugger, ok := h.mvccStore.(MVCCDebugger)
+	if !ok {
+		return &tikvrpc.MvccGetByKeyResponse{
+			Error: "not implement",
+		}
+	}
+
+	if !h.checkKeyInRegion(req.Key) {
+		panic("MvccGetByKey: key not in region")
+	}
+	info, err := debugger.MvccGetByKey(req.Key)
+	if err != nil {
+		return &tikvrpc.MvccGetByKeyResponse{
+			Error: err.Error(),
+		}
+	}
+	return &tikvrpc.MvccGetByKeyResponse{
+		Info: info,
+	}
+}
+
+func (h *rpcHandler) handleMvccGetByStartTS(req *tikvrpc.MvccGetByStartTsRequest) *tikvrpc.MvccGetByStartTsResponse {
+	debugger, ok := h.mvccStore.(MVCCDebugger)
+	if !ok {
+		return &tikvrpc.MvccGetByStartTsResponse{
+			Error: "not implement",
+		}
+	}
+
+	startKey := MvccKey(h.startKey).Raw()
+	if bytes.Compare(req.StartKey, startKey) > 0 {
+		startKey = req.StartKey
+	}
+	endKey := MvccKey(h.endKey).Raw()
+	if len(req.EndKey) > 0 && (len(endKey) == 0 || bytes.Compare(req.EndKey, endKey) < 0) {
+		endKey = req.EndKey
+	}
+	info, key, err := debugger.MvccGetByStartTS(startKey, endKey, req.StartTs)
+	if err != nil {
+		return &tikvrpc.MvccGetByStartTsResponse{
+			Error: err.Error(),
+		}
+	}
+	return &tikvrpc.MvccGetByStartTsResponse{
+		Info: info,
+		Key:  key,
+	}
+}
+
 // RPCClient sends kv RPC calls to mock cluster. RPCClient mocks the behavior of
 // a rpc client at tikv's side.
 type RPCClient struct {
@@ -534,6 +585,20 @@ func (c *RPCClient) SendRequest(ctx context.Context, addr string, req *tikvrpc.R
 			panic(fmt.Sprintf("unknown coprocessor request type: %v", r.GetTp()))
 		}
 		resp.Resp = res
+	case tikvrpc.CmdMvccGetByKey:
+		r := req.MvccGetByKey()
+		if err := handler.checkRequestContext(reqCtx); err != nil {
+			resp.Resp = &tikvrpc.MvccGetByKeyResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.Resp = handler.handleMvccGetByKey(r)
+	case tikvrpc.CmdMvccGetByStartTs:
+		r := req.MvccGetByStartTs()
+		if err := handler.checkRequestContext(reqCtx); err != nil {
+			resp.Resp = &tikvrpc.MvccGetByStartTsResponse{RegionError: err}
+			return resp, nil
+		}
+		resp.Resp = handler.handleMvccGetByStartTS(r)
 	default:
 		return nil, errors.Errorf("unsupported this request type %v", req.Type)
 	}
@@ -23,11 +23,13 @@ import (
 	pb "github.com/pingcap-incubator/tinykv/proto/pkg/kvrpcpb"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/parser/terror"
 
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/tracing"
 	"go.uber.org/zap"
 )
 
@@ -248,6 +250,11 @@ func (c *twoPhaseCommitter) doActionOnKeys(bo *Backoffer, action twoPhaseCommitA
 	if len(keys) == 0 {
 		return nil
 	}
+	if span := tracing.SpanFromContext(bo.ctx); span != nil {
+		span1 := span.StartChild("twoPhaseCommitter." + action.String() + "Keys")
+		defer span1.Finish()
+		bo.ctx = tracing.ContextWithSpan(bo.ctx, span1)
+	}
 	groups, firstRegion, err := c.store.regionCache.GroupKeysByRegion(bo, keys, nil)
 	if err != nil {
 		return errors.Trace(err)
@@ -543,6 +550,8 @@ func (c *twoPhaseCommitter) cleanupKeys(bo *Backoffer, keys [][]byte) error {
 
 // execute executes the two-phase commit protocol.
 func (c *twoPhaseCommitter) execute(ctx context.Context) (err error) {
+	span, ctx := tracing.ChildSpanFromContext(ctx, "twoPhaseCommitter.execute")
+	defer span.Finish()
 	defer func() {
 		// Always clean up all written keys if the txn does not commit.
 		c.mu.RLock()
@@ -553,7 +562,9 @@ func (c *twoPhaseCommitter) execute(ctx context.Context) (err error) {
 			c.cleanWg.Add(1)
 			go func() {
 				cleanupKeysCtx := context.WithValue(context.Background(), txnStartKey, ctx.Value(txnStartKey))
+				start := time.Now()
 				err := c.cleanupKeys(NewBackoffer(cleanupKeysCtx, cleanupMaxBackoff).WithVars(c.txn.vars), c.keys)
+				metrics.TiKVTxnCmdHistogram.WithLabelValues("cleanup").Observe(time.Since(start).Seconds())
 				if err != nil {
 					logutil.Logger(ctx).Info("2PC cleanup failed",
 						zap.Error(err),
@@ -568,8 +579,10 @@ func (c *twoPhaseCommitter) execute(ctx context.Context) (err error) {
 		c.txn.commitTS = c.commitTS
 	}()
 
+	start := time.Now()
 	prewriteBo := NewBackoffer(ctx, PrewriteMaxBackoff).WithVars(c.txn.vars)
 	err = c.prewriteKeys(prewriteBo, c.keys)
+	metrics.TiKVTxnCmdHistogram.WithLabelValues("prewrite").Observe(time.Since(start).Seconds())
 	if err != nil {
 		logutil.Logger(ctx).Debug("2PC failed on prewrite",
 			zap.Error(err),
@@ -577,7 +590,9 @@ func (c *twoPhaseCommitter) execute(ctx context.Context) (err error) {
 		return errors.Trace(err)
 	}
 
+	start = time.Now()
 	commitTS, err := c.store.getTimestampWithRetry(NewBackoffer(ctx, tsoMaxBackoff).WithVars(c.txn.vars))
+	metrics.TiKVTxnCmdHistogram.WithLabelValues("get_commit_ts").Observe(time.Since(start).Seconds())
 	if err != nil {
 		logutil.Logger(ctx).Warn("2PC get commitTS failed",
 			zap.Error(err),
@@ -593,7 +608,10 @@ func (c *twoPhaseCommitter) execute(ctx context.Context) (err error) {
 		return errors.Trace(err)
 	}
 	c.commitTS = commitTS
-	if err = c.checkSchemaValid(); err != nil {
+	checkSpan, _ := tracing.ChildSpanFromContext(ctx, "twoPhaseCommitter.checkSchemaValid")
+	err = c.checkSchemaValid()
+	checkSpan.Finish()
+	if err != nil {
 		return errors.Trace(err)
 	}
 
@@ -603,8 +621,10 @@ func (c *twoPhaseCommitter) execute(ctx context.Context) (err error) {
 		return err
 	}
 
+	start = time.Now()
 	commitBo := NewBackoffer(ctx, CommitMaxBackoff).WithVars(c.txn.vars)
 	err = c.commitKeys(commitBo, c.keys)
+	metrics.TiKVTxnCmdHistogram.WithLabelValues("commit").Observe(time.Since(start).Seconds())
 	if err != nil {
 		if undeterminedErr := c.getUndeterminedErr(); undeterminedErr != nil {
 			logutil.Logger(ctx).Error("2PC commit result undetermined",
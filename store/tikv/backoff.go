@@ -19,11 +19,13 @@ import (
 	"math"
 	"math/rand"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/parser/mysql"
 	"github.com/pingcap/tidb/parser/terror"
 
@@ -262,7 +264,13 @@ func (b *Backoffer) BackoffWithMaxSleep(typ backoffType, maxSleepMs int, err err
 		return errors.Trace(err)
 	default:
 	}
+	if b.vars != nil && b.vars.Killed != nil {
+		if atomic.LoadUint32(b.vars.Killed) == 1 {
+			return ErrQueryInterrupted
+		}
+	}
 
+	metrics.TiKVBackoffCounter.WithLabelValues(typ.String()).Inc()
 	b.errors = append(b.errors, errors.Errorf("%s at %s", err.Error(), time.Now().Format(time.RFC3339Nano)))
 	b.types = append(b.types, typ)
 	if b.noop || (b.maxSleep > 0 && b.totalSleep >= b.maxSleep) {
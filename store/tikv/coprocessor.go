@@ -29,8 +29,11 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/tracing"
 	"go.uber.org/zap"
 )
 
@@ -81,6 +84,8 @@ type copTask struct {
 	respChan  chan *copResponse
 	storeAddr string
 	cmdType   tikvrpc.CmdType
+	// sendTime is the time when the task is sent to the workers.
+	sendTime time.Time
 }
 
 func (r *copTask) String() string {
@@ -355,6 +360,12 @@ type copIteratorWorker struct {
 	clientHelper
 
 	replicaReadSeed uint32
+	// reportedBackoff is the backoff time in milliseconds of the current task
+	// which has been reported by the responses.
+	reportedBackoff int
+	// waitTime is the time the current task waits in the task channel before
+	// it's picked up, it's reported by the first response of the task.
+	waitTime time.Duration
 }
 
 // copIteratorTaskSender sends tasks to taskCh then wait for the workers to exit.
@@ -369,6 +380,7 @@ type copIteratorTaskSender struct {
 
 type copResponse struct {
 	pbResp   *coprocessor.Response
+	detail   *execdetails.ExecDetails
 	err      error
 	respSize int64
 	respTime time.Duration
@@ -397,6 +409,11 @@ func (rs *copResponse) RespTime() time.Duration {
 	return rs.respTime
 }
 
+// GetExecDetails implements the kv.ResultSubset GetExecDetails interface.
+func (rs *copResponse) GetExecDetails() *execdetails.ExecDetails {
+	return rs.detail
+}
+
 const minLogCopTaskTime = 300 * time.Millisecond
 
 // run is a worker function that get a copTask from channel, handle it and
@@ -410,6 +427,8 @@ func (worker *copIteratorWorker) run(ctx context.Context) {
 		}
 
 		bo := NewBackoffer(ctx, copNextMaxBackoff).WithVars(worker.vars)
+		worker.reportedBackoff = 0
+		worker.waitTime = time.Since(task.sendTime)
 		worker.handleTask(bo, task, respCh)
 		close(task.respChan)
 		select {
@@ -499,6 +518,7 @@ func (it *copIterator) recvFromRespCh(ctx context.Context, respCh <-chan *copRes
 }
 
 func (sender *copIteratorTaskSender) sendToTaskCh(t *copTask) (exit bool) {
+	t.sendTime = time.Now()
 	select {
 	case sender.taskCh <- t:
 	case <-sender.finishCh:
@@ -578,6 +598,11 @@ func (worker *copIteratorWorker) handleTask(bo *Backoffer, task *copTask, respCh
 			worker.sendToRespCh(resp, respCh, false)
 		}
 	}()
+	if span := tracing.SpanFromContext(bo.ctx); span != nil {
+		span1 := span.StartChild("copIteratorWorker.handleTask")
+		defer span1.Finish()
+		bo.ctx = tracing.ContextWithSpan(bo.ctx, span1)
+	}
 	remainTasks := []*copTask{task}
 	for len(remainTasks) > 0 {
 		tasks, err := worker.handleTaskOnce(bo, remainTasks[0], respCh)
@@ -603,6 +628,10 @@ func (worker *copIteratorWorker) handleTaskOnce(bo *Backoffer, task *copTask, ch
 		}
 	})
 
+	if worker.vars != nil && worker.vars.Killed != nil && atomic.LoadUint32(worker.vars.Killed) == 1 {
+		return nil, ErrQueryInterrupted
+	}
+
 	req := tikvrpc.NewRequest(task.cmdType, &coprocessor.Request{
 		Tp:      worker.req.Tp,
 		StartTs: worker.req.StartTs,
@@ -617,11 +646,17 @@ func (worker *copIteratorWorker) handleTaskOnce(bo *Backoffer, task *copTask, ch
 	// Set task.storeAddr field so its task.String() method have the store address information.
 	task.storeAddr = storeAddr
 	costTime := time.Since(startTime)
+	metrics.TiKVCoprocessorHistogram.Observe(costTime.Seconds())
 	if costTime > minLogCopTaskTime {
 		worker.logTimeCopTask(costTime, task, bo, resp)
 	}
 
-	return worker.handleCopResponse(bo, rpcCtx, &copResponse{pbResp: resp.Resp.(*coprocessor.Response)}, task, ch)
+	copResp := &copResponse{
+		pbResp:   resp.Resp.(*coprocessor.Response),
+		detail:   &execdetails.ExecDetails{CalleeAddress: storeAddr, ProcessTime: costTime},
+		respTime: costTime,
+	}
+	return worker.handleCopResponse(bo, rpcCtx, copResp, task, ch)
 }
 
 type minCommitTSPushed struct {
@@ -734,6 +769,11 @@ func (worker *copIteratorWorker) handleCopResponse(bo *Backoffer, rpcCtx *RPCCon
 			zap.Error(err))
 		return nil, errors.Trace(err)
 	}
+	// The backoff of a task is reported by the first response after it.
+	resp.detail.BackoffTime = time.Duration(bo.totalSleep-worker.reportedBackoff) * time.Millisecond
+	worker.reportedBackoff = bo.totalSleep
+	resp.detail.WaitTime = worker.waitTime
+	worker.waitTime = 0
 	worker.sendToRespCh(resp, ch, true)
 	return nil, nil
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/store/tikv/oracle/oracles"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
@@ -190,10 +191,14 @@ func (s *tikvStore) runSafePointChecker() {
 		case spCachedTime := <-time.After(d):
 			cachedSafePoint, err := loadSafePoint(s.GetSafePointKV())
 			if err == nil {
+				metrics.TiKVLoadSafepointCounter.WithLabelValues("ok").Inc()
+				if cachedSafePoint > 0 {
+					metrics.TiKVSafePointGauge.Set(float64(oracle.GetTimeFromTS(cachedSafePoint).Unix()))
+				}
 				s.UpdateSPCache(cachedSafePoint, spCachedTime)
 				d = gcSafePointUpdateInterval
 			} else {
-
+				metrics.TiKVLoadSafepointCounter.WithLabelValues("fail").Inc()
 				logutil.BgLogger().Error("fail to load safepoint from pd", zap.Error(err))
 				d = gcSafePointQuickRepeatInterval
 			}
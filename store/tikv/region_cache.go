@@ -29,7 +29,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/kv"
-
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/util/logutil"
 	"go.uber.org/zap"
 )
@@ -365,6 +365,7 @@ func (c *RegionCache) findRegionByKey(bo *Backoffer, key []byte, isEndKey bool)
 	r = c.searchCachedRegion(key, isEndKey)
 	if r == nil {
 		// load region when it is not exists or expired.
+		metrics.TiKVRegionCacheCounter.WithLabelValues("miss").Inc()
 		lr, err := c.loadRegion(bo, key, isEndKey)
 		if err != nil {
 			// no region data, return error if failure.
@@ -376,6 +377,7 @@ func (c *RegionCache) findRegionByKey(bo *Backoffer, key []byte, isEndKey bool)
 		c.mu.Unlock()
 	} else if r.needReload() {
 		// load region when it be marked as need reload.
+		metrics.TiKVRegionCacheCounter.WithLabelValues("reload").Inc()
 		lr, err := c.loadRegion(bo, key, isEndKey)
 		if err != nil {
 			// ignore error and use old region info.
@@ -387,6 +389,8 @@ func (c *RegionCache) findRegionByKey(bo *Backoffer, key []byte, isEndKey bool)
 			c.insertRegionToCache(r)
 			c.mu.Unlock()
 		}
+	} else {
+		metrics.TiKVRegionCacheCounter.WithLabelValues("hit").Inc()
 	}
 	return r, nil
 }
@@ -413,6 +417,7 @@ func (c *RegionCache) LocateRegionByID(bo *Backoffer, regionID uint64) (*KeyLoca
 	c.mu.RUnlock()
 	if r != nil {
 		if r.needReload() {
+			metrics.TiKVRegionCacheCounter.WithLabelValues("reload").Inc()
 			lr, err := c.loadRegionByID(bo, regionID)
 			if err != nil {
 				// ignore error and use old region info.
@@ -424,6 +429,8 @@ func (c *RegionCache) LocateRegionByID(bo *Backoffer, regionID uint64) (*KeyLoca
 				c.insertRegionToCache(r)
 				c.mu.Unlock()
 			}
+		} else {
+			metrics.TiKVRegionCacheCounter.WithLabelValues("hit").Inc()
 		}
 		loc := &KeyLocation{
 			Region:   r.VerID(),
@@ -433,6 +440,7 @@ func (c *RegionCache) LocateRegionByID(bo *Backoffer, regionID uint64) (*KeyLoca
 		return loc, nil
 	}
 
+	metrics.TiKVRegionCacheCounter.WithLabelValues("miss").Inc()
 	r, err := c.loadRegionByID(bo, regionID)
 	if err != nil {
 		return nil, errors.Trace(err)
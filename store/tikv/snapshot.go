@@ -90,6 +90,29 @@ func (s *tikvSnapshot) Get(ctx context.Context, k kv.Key) ([]byte, error) {
 	return val, nil
 }
 
+// BatchGet gets all the keys' value from kv-server and returns a map contains key/value pairs.
+// The map will not contain nonexistent keys. The keys are read one by one, and
+// the results are cached in the snapshot.
+func (s *tikvSnapshot) BatchGet(ctx context.Context, keys []kv.Key) (map[string][]byte, error) {
+	m := make(map[string][]byte, len(keys))
+	ctx = context.WithValue(ctx, txnStartKey, s.version.Ver)
+	bo := NewBackoffer(ctx, batchGetMaxBackoff)
+	for _, k := range keys {
+		val, err := s.get(bo, k)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if s.cached == nil {
+			s.cached = make(map[string][]byte, len(keys))
+		}
+		s.cached[string(k)] = val
+		if len(val) > 0 {
+			m[string(k)] = val
+		}
+	}
+	return m, nil
+}
+
 func (s *tikvSnapshot) get(bo *Backoffer, k kv.Key) ([]byte, error) {
 	// Check the cached values first.
 	if s.cached != nil {
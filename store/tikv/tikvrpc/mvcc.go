@@ -0,0 +1,91 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikvrpc
+
+import (
+	"github.com/pingcap-incubator/tinykv/proto/pkg/errorpb"
+	"github.com/pingcap-incubator/tinykv/proto/pkg/kvrpcpb"
+)
+
+// The MVCC debug messages are not a part of the TinyKV protocol, so they are
+// defined here and only served by the mock TiKV.
+
+// MvccGetByKeyRequest is the request to get all the MVCC versions of a key.
+type MvccGetByKeyRequest struct {
+	Context *kvrpcpb.Context
+	Key     []byte
+}
+
+// MvccGetByKeyResponse is the response of MvccGetByKeyRequest.
+type MvccGetByKeyResponse struct {
+	RegionError *errorpb.Error `json:"region_error,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Info        *MvccInfo      `json:"info"`
+}
+
+// GetRegionError returns the region error of the response.
+func (resp *MvccGetByKeyResponse) GetRegionError() *errorpb.Error {
+	if resp == nil {
+		return nil
+	}
+	return resp.RegionError
+}
+
+// MvccGetByStartTsRequest is the request to get the MVCC versions of the first key
+// written by the transaction with the start ts. The search range is the intersection
+// of the region and [StartKey, EndKey), an empty key means no limit.
+type MvccGetByStartTsRequest struct {
+	Context  *kvrpcpb.Context
+	StartTs  uint64
+	StartKey []byte
+	EndKey   []byte
+}
+
+// MvccGetByStartTsResponse is the response of MvccGetByStartTsRequest.
+type MvccGetByStartTsResponse struct {
+	RegionError *errorpb.Error `json:"region_error,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Key         []byte         `json:"key"`
+	Info        *MvccInfo      `json:"info"`
+}
+
+// GetRegionError returns the region error of the response.
+func (resp *MvccGetByStartTsResponse) GetRegionError() *errorpb.Error {
+	if resp == nil {
+		return nil
+	}
+	return resp.RegionError
+}
+
+// MvccInfo contains the lock and all the write records of a key.
+type MvccInfo struct {
+	Lock   *MvccLock    `json:"lock,omitempty"`
+	Writes []*MvccWrite `json:"writes,omitempty"`
+}
+
+// MvccLock is the lock of a key.
+type MvccLock struct {
+	Type       kvrpcpb.Op `json:"type"`
+	StartTs    uint64     `json:"start_ts"`
+	Primary    []byte     `json:"primary"`
+	ShortValue []byte     `json:"short_value,omitempty"`
+}
+
+// MvccWrite is a committed or rolled back write record of a key, the newer record comes first.
+type MvccWrite struct {
+	Type       kvrpcpb.Op `json:"type"`
+	StartTs    uint64     `json:"start_ts"`
+	CommitTs   uint64     `json:"commit_ts"`
+	ShortValue []byte     `json:"short_value,omitempty"`
+}
@@ -44,6 +44,9 @@ const (
 	CmdRawScan
 
 	CmdCop CmdType = 512 + iota
+
+	CmdMvccGetByKey CmdType = 1024 + iota
+	CmdMvccGetByStartTs
 )
 
 func (t CmdType) String() string {
@@ -72,6 +75,10 @@ func (t CmdType) String() string {
 		return "Cop"
 	case CmdCheckTxnStatus:
 		return "CheckTxnStatus"
+	case CmdMvccGetByKey:
+		return "MvccGetByKey"
+	case CmdMvccGetByStartTs:
+		return "MvccGetByStartTS"
 	}
 	return "Unknown"
 }
@@ -159,6 +166,16 @@ func (req *Request) CheckTxnStatus() *kvrpcpb.CheckTxnStatusRequest {
 	return req.req.(*kvrpcpb.CheckTxnStatusRequest)
 }
 
+// MvccGetByKey returns MvccGetByKeyRequest in request.
+func (req *Request) MvccGetByKey() *MvccGetByKeyRequest {
+	return req.req.(*MvccGetByKeyRequest)
+}
+
+// MvccGetByStartTs returns MvccGetByStartTsRequest in request.
+func (req *Request) MvccGetByStartTs() *MvccGetByStartTsRequest {
+	return req.req.(*MvccGetByStartTsRequest)
+}
+
 // Response wraps all kv/coprocessor responses.
 type Response struct {
 	Resp interface{}
@@ -198,6 +215,10 @@ func SetContext(req *Request, region *metapb.Region, peer *metapb.Peer) error {
 		req.Cop().Context = ctx
 	case CmdCheckTxnStatus:
 		req.CheckTxnStatus().Context = ctx
+	case CmdMvccGetByKey:
+		req.MvccGetByKey().Context = ctx
+	case CmdMvccGetByStartTs:
+		req.MvccGetByStartTs().Context = ctx
 	default:
 		return fmt.Errorf("invalid request type %v", req.Type)
 	}
@@ -258,6 +279,14 @@ func GenRegionErrorResp(req *Request, e *errorpb.Error) (*Response, error) {
 		p = &kvrpcpb.CheckTxnStatusResponse{
 			RegionError: e,
 		}
+	case CmdMvccGetByKey:
+		p = &MvccGetByKeyResponse{
+			RegionError: e,
+		}
+	case CmdMvccGetByStartTs:
+		p = &MvccGetByStartTsResponse{
+			RegionError: e,
+		}
 	default:
 		return nil, fmt.Errorf("invalid request type %v", req.Type)
 	}
@@ -312,6 +341,8 @@ func CallRPC(ctx context.Context, client tikvpb.TikvClient, req *Request) (*Resp
 		resp.Resp, err = client.Coprocessor(ctx, req.Cop())
 	case CmdCheckTxnStatus:
 		resp.Resp, err = client.KvCheckTxnStatus(ctx, req.CheckTxnStatus())
+	case CmdMvccGetByKey, CmdMvccGetByStartTs:
+		return nil, errors.Errorf("%v is not supported by TinyKV", req.Type)
 	default:
 		return nil, errors.Errorf("invalid request type: %v", req.Type)
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/parser/terror"
 	"github.com/pingcap/tidb/server"
 	"github.com/pingcap/tidb/session"
@@ -91,6 +92,7 @@ var (
 func main() {
 	flag.Parse()
 	registerStores()
+	registerMetrics()
 
 	configWarning := loadConfig()
 	overrideConfig()
@@ -117,6 +119,10 @@ func syncLog() {
 	}
 }
 
+func registerMetrics() {
+	metrics.RegisterMetrics()
+}
+
 func registerStores() {
 	err := kvstore.Register("tikv", tikv.Driver{})
 	terror.MustNil(err)
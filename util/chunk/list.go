@@ -14,8 +14,12 @@
 package chunk
 
 import (
+	"fmt"
+
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/stringutil"
 )
 
 // List holds a slice of chunks, use to append rows with max chunk size properly handled.
@@ -27,7 +31,8 @@ type List struct {
 	chunks        []*Chunk
 	freelist      []*Chunk
 
-	consumedIdx int // chunk index in "chunks", has been consumed.
+	memTracker  *memory.Tracker // track memory usage.
+	consumedIdx int             // chunk index in "chunks", has been consumed.
 }
 
 // RowPtr is used to get a row from a list.
@@ -37,17 +42,25 @@ type RowPtr struct {
 	RowIdx uint32
 }
 
+var chunkListLabel fmt.Stringer = stringutil.StringerStr("chunk.List")
+
 // NewList creates a new List with field types, init chunk size and max chunk size.
 func NewList(fieldTypes []*types.FieldType, initChunkSize, maxChunkSize int) *List {
 	l := &List{
 		fieldTypes:    fieldTypes,
 		initChunkSize: initChunkSize,
 		maxChunkSize:  maxChunkSize,
+		memTracker:    memory.NewTracker(chunkListLabel, -1),
 		consumedIdx:   -1,
 	}
 	return l
 }
 
+// GetMemTracker returns the memory tracker of this List.
+func (l *List) GetMemTracker() *memory.Tracker {
+	return l.memTracker
+}
+
 // Len returns the length of the List.
 func (l *List) Len() int {
 	return l.length
@@ -70,6 +83,7 @@ func (l *List) AppendRow(row Row) RowPtr {
 		newChk := l.allocChunk()
 		l.chunks = append(l.chunks, newChk)
 		if chkIdx != l.consumedIdx {
+			l.memTracker.Consume(l.chunks[chkIdx].MemoryUsage())
 			l.consumedIdx = chkIdx
 		}
 		chkIdx++
@@ -90,8 +104,10 @@ func (l *List) Add(chk *Chunk) {
 		panic("chunk appended to List should have at least 1 row")
 	}
 	if chkIdx := len(l.chunks) - 1; l.consumedIdx != chkIdx {
+		l.memTracker.Consume(l.chunks[chkIdx].MemoryUsage())
 		l.consumedIdx = chkIdx
 	}
+	l.memTracker.Consume(chk.MemoryUsage())
 	l.consumedIdx++
 	l.chunks = append(l.chunks, chk)
 	l.length += chk.NumRows()
@@ -102,6 +118,7 @@ func (l *List) allocChunk() (chk *Chunk) {
 		lastIdx := len(l.freelist) - 1
 		chk = l.freelist[lastIdx]
 		l.freelist = l.freelist[:lastIdx]
+		l.memTracker.Consume(-chk.MemoryUsage())
 		chk.Reset()
 		return
 	}
@@ -119,6 +136,9 @@ func (l *List) GetRow(ptr RowPtr) Row {
 
 // Reset resets the List.
 func (l *List) Reset() {
+	if lastIdx := len(l.chunks) - 1; lastIdx != l.consumedIdx {
+		l.memTracker.Consume(l.chunks[lastIdx].MemoryUsage())
+	}
 	l.freelist = append(l.freelist, l.chunks...)
 	l.chunks = l.chunks[:0]
 	l.length = 0
@@ -137,6 +157,7 @@ func (l *List) preAlloc4Row(row Row) (ptr RowPtr) {
 		newChk := l.allocChunk()
 		l.chunks = append(l.chunks, newChk)
 		if chkIdx != l.consumedIdx {
+			l.memTracker.Consume(l.chunks[chkIdx].MemoryUsage())
 			l.consumedIdx = chkIdx
 		}
 		chkIdx++
@@ -149,3 +149,32 @@ func BenchmarkPreAllocChunk(b *testing.B) {
 		}
 	}
 }
+
+func (s *testChunkSuite) TestListMemoryUsage(c *check.C) {
+	fieldTypes := make([]*types.FieldType, 0, 5)
+	fieldTypes = append(fieldTypes, &types.FieldType{Tp: mysql.TypeFloat})
+	fieldTypes = append(fieldTypes, &types.FieldType{Tp: mysql.TypeVarchar})
+	fieldTypes = append(fieldTypes, &types.FieldType{Tp: mysql.TypeJSON})
+	fieldTypes = append(fieldTypes, &types.FieldType{Tp: mysql.TypeDatetime})
+	fieldTypes = append(fieldTypes, &types.FieldType{Tp: mysql.TypeDuration})
+
+	srcChk := NewChunkWithCapacity(fieldTypes, 1)
+	srcChk.AppendFloat32(0, 12.4)
+	srcChk.AppendString(1, "123")
+	srcChk.AppendNull(2)
+	srcChk.AppendNull(3)
+	srcChk.AppendNull(4)
+
+	list := NewList(fieldTypes, 1, 2)
+	c.Assert(list.GetMemTracker().BytesConsumed(), check.Equals, int64(0))
+
+	list.AppendRow(srcChk.GetRow(0))
+	c.Assert(list.GetMemTracker().BytesConsumed(), check.Equals, int64(0))
+
+	memUsage := list.chunks[0].MemoryUsage()
+	list.Reset()
+	c.Assert(list.GetMemTracker().BytesConsumed(), check.Equals, memUsage)
+
+	list.Add(srcChk)
+	c.Assert(list.GetMemTracker().BytesConsumed(), check.Equals, memUsage+srcChk.MemoryUsage())
+}
@@ -0,0 +1,282 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execdetails
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// ExecDetails contains the execution details of a coprocessor task, which is
+// sent to a single region.
+type ExecDetails struct {
+	// CalleeAddress is the address of the store which handles the task.
+	CalleeAddress string
+	// ProcessTime is the time spent on processing the task by the store.
+	ProcessTime time.Duration
+	// WaitTime is the time the task waits before it's processed.
+	WaitTime time.Duration
+	// BackoffTime is the time spent on backing off before the task succeeds.
+	BackoffTime time.Duration
+}
+
+const (
+	// ProcessTimeStr represents the sum of process time of all the coprocessor tasks.
+	ProcessTimeStr = "Process_time"
+	// WaitTimeStr means the time of all coprocessor wait.
+	WaitTimeStr = "Wait_time"
+	// BackoffTimeStr means the time of all back-off.
+	BackoffTimeStr = "Backoff_time"
+)
+
+// Merge adds the times of another ExecDetails into this one.
+func (d *ExecDetails) Merge(other *ExecDetails) {
+	d.ProcessTime += other.ProcessTime
+	d.WaitTime += other.WaitTime
+	d.BackoffTime += other.BackoffTime
+}
+
+// String implements the fmt.Stringer interface, it's used in the slow log,
+// the zero times are omitted.
+func (d ExecDetails) String() string {
+	parts := make([]string, 0, 3)
+	if d.ProcessTime > 0 {
+		parts = append(parts, ProcessTimeStr+": "+strconv.FormatFloat(d.ProcessTime.Seconds(), 'f', -1, 64))
+	}
+	if d.WaitTime > 0 {
+		parts = append(parts, WaitTimeStr+": "+strconv.FormatFloat(d.WaitTime.Seconds(), 'f', -1, 64))
+	}
+	if d.BackoffTime > 0 {
+		parts = append(parts, BackoffTimeStr+": "+strconv.FormatFloat(d.BackoffTime.Seconds(), 'f', -1, 64))
+	}
+	return strings.Join(parts, " ")
+}
+
+// RuntimeStatsColl collects the runtime statistics of the executors of a
+// statement, the statistics are keyed by the explain ID of the plans.
+type RuntimeStatsColl struct {
+	mu          sync.Mutex
+	rootStats   map[string]*RuntimeStats
+	copStats    map[string]*CopRuntimeStats
+	readerStats map[string]*ReaderRuntimeStats
+}
+
+// NewRuntimeStatsColl creates a new runtime statistics collector.
+func NewRuntimeStatsColl() *RuntimeStatsColl {
+	return &RuntimeStatsColl{
+		rootStats:   make(map[string]*RuntimeStats),
+		copStats:    make(map[string]*CopRuntimeStats),
+		readerStats: make(map[string]*ReaderRuntimeStats),
+	}
+}
+
+// GetRootStats gets the runtime statistics of a root executor, it's created
+// if not exists. Several executors, e.g. the ones running in different
+// workers, may share the same statistics if they are built from the same plan.
+func (e *RuntimeStatsColl) GetRootStats(planID string) *RuntimeStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats, ok := e.rootStats[planID]
+	if !ok {
+		stats = &RuntimeStats{}
+		e.rootStats[planID] = stats
+	}
+	return stats
+}
+
+// GetCopStats gets the runtime statistics of a coprocessor executor, it's
+// created if not exists.
+func (e *RuntimeStatsColl) GetCopStats(planID string) *CopRuntimeStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats, ok := e.copStats[planID]
+	if !ok {
+		stats = &CopRuntimeStats{}
+		e.copStats[planID] = stats
+	}
+	return stats
+}
+
+// GetReaderStats gets the coprocessor task statistics of a reader executor,
+// it's created if not exists.
+func (e *RuntimeStatsColl) GetReaderStats(planID string) *ReaderRuntimeStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats, ok := e.readerStats[planID]
+	if !ok {
+		stats = &ReaderRuntimeStats{}
+		e.readerStats[planID] = stats
+	}
+	return stats
+}
+
+// RecordOneCopTask records the execution summary of a coprocessor executor in
+// a coprocessor task.
+func (e *RuntimeStatsColl) RecordOneCopTask(planID string, summary *tipb.ExecutorExecutionSummary) {
+	e.GetCopStats(planID).RecordOneCopTask(summary)
+}
+
+// RecordOneReaderTask records a coprocessor task sent by a reader executor.
+func (e *RuntimeStatsColl) RecordOneReaderTask(planID string, respTime time.Duration, detail *ExecDetails) {
+	e.GetReaderStats(planID).RecordOneCopTask(respTime, detail)
+}
+
+// ExistsRootStats checks whether the runtime statistics of a root executor exist.
+func (e *RuntimeStatsColl) ExistsRootStats(planID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, exists := e.rootStats[planID]
+	return exists
+}
+
+// ExistsCopStats checks whether the runtime statistics of a coprocessor executor exist.
+func (e *RuntimeStatsColl) ExistsCopStats(planID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, exists := e.copStats[planID]
+	return exists
+}
+
+// ExistsReaderStats checks whether the coprocessor task statistics of a reader executor exist.
+func (e *RuntimeStatsColl) ExistsReaderStats(planID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, exists := e.readerStats[planID]
+	return exists
+}
+
+// RuntimeStats collects the runtime statistics of a root executor.
+type RuntimeStats struct {
+	// loop is the number of the calls of Next.
+	loop int32
+	// consume is the wall time spent in Next, in nanoseconds.
+	consume int64
+	// rows is the number of the rows returned by Next.
+	rows int64
+}
+
+// Record records a call of Next.
+func (e *RuntimeStats) Record(d time.Duration, rowNum int) {
+	atomic.AddInt32(&e.loop, 1)
+	atomic.AddInt64(&e.consume, int64(d))
+	atomic.AddInt64(&e.rows, int64(rowNum))
+}
+
+// Loop returns the number of the calls of Next.
+func (e *RuntimeStats) Loop() int32 {
+	return atomic.LoadInt32(&e.loop)
+}
+
+// Rows returns the number of the rows returned by Next.
+func (e *RuntimeStats) Rows() int64 {
+	return atomic.LoadInt64(&e.rows)
+}
+
+// String implements the fmt.Stringer interface.
+func (e *RuntimeStats) String() string {
+	return fmt.Sprintf("time:%v, loops:%d, rows:%d", time.Duration(atomic.LoadInt64(&e.consume)), e.Loop(), e.Rows())
+}
+
+// CopRuntimeStats collects the runtime statistics of a coprocessor executor,
+// every coprocessor task reports a summary of the executor.
+type CopRuntimeStats struct {
+	sync.Mutex
+
+	procTimes []time.Duration
+	rows      uint64
+	iters     uint64
+}
+
+// RecordOneCopTask records the execution summary of a coprocessor task.
+func (e *CopRuntimeStats) RecordOneCopTask(summary *tipb.ExecutorExecutionSummary) {
+	e.Lock()
+	defer e.Unlock()
+	e.procTimes = append(e.procTimes, time.Duration(summary.GetTimeProcessedNs()))
+	e.rows += summary.GetNumProducedRows()
+	e.iters += summary.GetNumIterations()
+}
+
+// String implements the fmt.Stringer interface.
+func (e *CopRuntimeStats) String() string {
+	e.Lock()
+	defer e.Unlock()
+	if len(e.procTimes) == 0 {
+		return ""
+	}
+	procTimes := sortedDurations(e.procTimes)
+	return fmt.Sprintf("proc max:%v, min:%v, p80:%v, p95:%v, rows:%v, iters:%v, tasks:%v",
+		procTimes[len(procTimes)-1], procTimes[0], percentile(procTimes, 0.8), percentile(procTimes, 0.95),
+		e.rows, e.iters, len(procTimes))
+}
+
+// ReaderRuntimeStats collects the coprocessor tasks sent by a reader
+// executor, there is a task for every region the reader reads.
+type ReaderRuntimeStats struct {
+	sync.Mutex
+
+	respTimes   []time.Duration
+	backoffTime time.Duration
+}
+
+// RecordOneCopTask records the response time and the execution details of a
+// coprocessor task.
+func (e *ReaderRuntimeStats) RecordOneCopTask(respTime time.Duration, detail *ExecDetails) {
+	e.Lock()
+	defer e.Unlock()
+	e.respTimes = append(e.respTimes, respTime)
+	if detail != nil {
+		e.backoffTime += detail.BackoffTime
+	}
+}
+
+// String implements the fmt.Stringer interface.
+func (e *ReaderRuntimeStats) String() string {
+	e.Lock()
+	defer e.Unlock()
+	if len(e.respTimes) == 0 {
+		return ""
+	}
+	respTimes := sortedDurations(e.respTimes)
+	var sum time.Duration
+	for _, t := range respTimes {
+		sum += t
+	}
+	return fmt.Sprintf("cop_task: {num: %d, max: %v, min: %v, avg: %v, p95: %v, backoff: %v}",
+		len(respTimes), respTimes[len(respTimes)-1], respTimes[0], sum/time.Duration(len(respTimes)),
+		percentile(respTimes, 0.95), e.backoffTime)
+}
+
+func sortedDurations(durations []time.Duration) []time.Duration {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// percentile returns the p-th percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
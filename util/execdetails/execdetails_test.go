@@ -0,0 +1,69 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execdetails
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+func TestT(t *testing.T) {
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testSuite{})
+
+type testSuite struct{}
+
+func (s *testSuite) TestRootRuntimeStats(c *check.C) {
+	coll := NewRuntimeStatsColl()
+	c.Assert(coll.ExistsRootStats("HashAgg_1"), check.IsFalse)
+	stats := coll.GetRootStats("HashAgg_1")
+	c.Assert(coll.ExistsRootStats("HashAgg_1"), check.IsTrue)
+	c.Assert(coll.GetRootStats("HashAgg_1"), check.Equals, stats)
+
+	stats.Record(time.Second, 10)
+	stats.Record(time.Second, 0)
+	c.Assert(stats.Loop(), check.Equals, int32(2))
+	c.Assert(stats.Rows(), check.Equals, int64(10))
+	c.Assert(stats.String(), check.Equals, "time:2s, loops:2, rows:10")
+}
+
+func (s *testSuite) TestCopRuntimeStats(c *check.C) {
+	newSummary := func(procNs, rows, iters uint64) *tipb.ExecutorExecutionSummary {
+		return &tipb.ExecutorExecutionSummary{
+			TimeProcessedNs: &procNs,
+			NumProducedRows: &rows,
+			NumIterations:   &iters,
+		}
+	}
+	coll := NewRuntimeStatsColl()
+	c.Assert(coll.ExistsCopStats("TableScan_1"), check.IsFalse)
+	coll.RecordOneCopTask("TableScan_1", newSummary(uint64(time.Millisecond), 1, 1))
+	coll.RecordOneCopTask("TableScan_1", newSummary(uint64(3*time.Millisecond), 2, 1))
+	coll.RecordOneCopTask("TableScan_1", newSummary(uint64(2*time.Millisecond), 3, 2))
+	c.Assert(coll.ExistsCopStats("TableScan_1"), check.IsTrue)
+	c.Assert(coll.GetCopStats("TableScan_1").String(), check.Equals,
+		"proc max:3ms, min:1ms, p80:3ms, p95:3ms, rows:6, iters:4, tasks:3")
+	c.Assert(coll.GetCopStats("Selection_2").String(), check.Equals, "")
+
+	coll.RecordOneReaderTask("TableReader_3", 2*time.Millisecond, &ExecDetails{BackoffTime: 2 * time.Millisecond})
+	coll.RecordOneReaderTask("TableReader_3", 4*time.Millisecond, &ExecDetails{})
+	c.Assert(coll.ExistsReaderStats("TableReader_3"), check.IsTrue)
+	c.Assert(coll.GetReaderStats("TableReader_3").String(), check.Equals,
+		"cop_task: {num: 2, max: 4ms, min: 2ms, avg: 3ms, p95: 4ms, backoff: 2ms}")
+}
@@ -40,6 +40,8 @@ const (
 	defaultLogLevel  = log.InfoLevel
 	// DefaultQueryLogMaxLen is the default max length of the query in the log.
 	DefaultQueryLogMaxLen = 4096
+	// DefaultSlowThreshold is the default slow log threshold in millisecond.
+	DefaultSlowThreshold = 300
 )
 
 // EmptyFileLogConfig is an empty FileLogConfig.
@@ -245,6 +247,11 @@ func InitZapLogger(cfg *LogConfig) error {
 	}
 	zaplog.ReplaceGlobals(gl, props)
 
+	SlowQueryLogger, err = newSlowQueryLogger(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	return nil
 }
 
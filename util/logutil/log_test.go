@@ -19,10 +19,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	zaplog "github.com/pingcap/log"
@@ -149,6 +151,30 @@ func (s *testLogSuite) TestZapLoggerWithKeys(c *C) {
 	os.Remove(fileCfg.Filename)
 }
 
+func (s *testLogSuite) TestSlowQueryLogger(c *C) {
+	fileName := "slow_query"
+	conf := NewLogConfig("info", DefaultLogFormat, EmptyFileLogConfig, false)
+	conf.SlowQueryFile = fileName
+	err := InitZapLogger(conf)
+	c.Assert(err, IsNil)
+	defer os.Remove(fileName)
+
+	SlowQueryLogger.Debug("debug msg")
+	SlowQueryLogger.Warn("# Query_time: 1\nselect 1;", zap.String("ignored", "true"))
+	c.Assert(SlowQueryLogger.Sync(), IsNil)
+
+	content, err := ioutil.ReadFile(fileName)
+	c.Assert(err, IsNil)
+	lines := strings.Split(string(content), "\n")
+	c.Assert(lines, HasLen, 4)
+	c.Assert(strings.HasPrefix(lines[0], "# Time: "), IsTrue)
+	_, err = time.Parse(SlowLogTimeFormat, strings.TrimPrefix(lines[0], "# Time: "))
+	c.Assert(err, IsNil)
+	c.Assert(lines[1], Equals, "# Query_time: 1")
+	c.Assert(lines[2], Equals, "select 1;")
+	c.Assert(lines[3], Equals, "")
+}
+
 func (s *testLogSuite) testZapLogger(ctx context.Context, c *C, fileName, pattern string) {
 	Logger(ctx).Debug("debug msg", zap.String("test with key", "true"))
 	Logger(ctx).Info("info msg", zap.String("test with key", "true"))
@@ -0,0 +1,74 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"time"
+
+	"github.com/pingcap/errors"
+	zaplog "github.com/pingcap/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlowLogTimeFormat is the time format of the "# Time:" line of the slow log.
+const SlowLogTimeFormat = time.RFC3339Nano
+
+// SlowQueryLogger is used to log the slow queries, it writes to the slow
+// query file if it's configured, or to the general log otherwise.
+var SlowQueryLogger = zaplog.L()
+
+var slowLogBufferPool = buffer.NewPool()
+
+// newSlowQueryLogger creates the logger of the slow queries, whose entries are
+// written in the format of the slow log instead of the general log.
+func newSlowQueryLogger(cfg *LogConfig) (*zap.Logger, error) {
+	sqConfig := cfg.Config
+	if len(cfg.SlowQueryFile) != 0 {
+		sqConfig.File = zaplog.FileLogConfig{
+			MaxSize:  cfg.File.MaxSize,
+			Filename: cfg.SlowQueryFile,
+		}
+	}
+	logger, prop, err := zaplog.InitLogger(&sqConfig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	newCore := zapcore.NewCore(&slowLogEncoder{ObjectEncoder: zapcore.NewMapObjectEncoder()}, prop.Syncer, prop.Level)
+	logger = logger.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return newCore
+	}))
+	return logger, nil
+}
+
+// slowLogEncoder encodes an entry as a slow log entry, the message is expected
+// to be formatted by the caller already, so the fields are ignored.
+type slowLogEncoder struct {
+	zapcore.ObjectEncoder
+}
+
+// Clone implements the zapcore.Encoder interface.
+func (e *slowLogEncoder) Clone() zapcore.Encoder { return e }
+
+// EncodeEntry implements the zapcore.Encoder interface.
+func (e *slowLogEncoder) EncodeEntry(entry zapcore.Entry, _ []zapcore.Field) (*buffer.Buffer, error) {
+	b := slowLogBufferPool.Get()
+	b.AppendString("# Time: ")
+	b.AppendString(entry.Time.Format(SlowLogTimeFormat))
+	b.AppendByte('\n')
+	b.AppendString(entry.Message)
+	b.AppendByte('\n')
+	return b, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/parser/terror"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+)
+
+// ErrMemExceedThreshold is returned when the memory usage of a query exceeds its quota
+// and the query is canceled.
+var ErrMemExceedThreshold = terror.ClassUtil.New(mysql.ErrMemExceedThreshold, mysql.MySQLErrName[mysql.ErrMemExceedThreshold])
+
+// ActionOnExceed is the action taken when memory usage exceeds memory quota.
+// NOTE: All the implementors should be thread-safe.
+type ActionOnExceed interface {
+	// Action will be called when memory usage exceeds memory quota by the
+	// corresponding Tracker.
+	Action(t *Tracker)
+	// SetFallback sets a fallback action which will be triggered if itself has
+	// already been triggered.
+	SetFallback(a ActionOnExceed)
+}
+
+// LogOnExceed logs a warning only once when memory usage exceeds memory quota.
+type LogOnExceed struct {
+	mutex    sync.Mutex // For synchronization.
+	acted    bool
+	ConnID   uint64
+	fallback ActionOnExceed
+}
+
+// Action logs a warning only once when memory usage exceeds memory quota.
+func (a *LogOnExceed) Action(t *Tracker) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if !a.acted {
+		a.acted = true
+		logutil.BgLogger().Warn("memory exceeds quota",
+			zap.Uint64("connID", a.ConnID),
+			zap.Error(errMemExceed(t)),
+			zap.String("tracker", t.String()))
+		return
+	}
+	if a.fallback != nil {
+		a.fallback.Action(t)
+	}
+}
+
+// SetFallback sets a fallback action.
+func (a *LogOnExceed) SetFallback(fallback ActionOnExceed) {
+	a.fallback = fallback
+}
+
+// CancelOnExceed cancels the query when memory usage exceeds memory quota.
+// The error is recorded on the root Tracker and returned by "Tracker.Err()",
+// executors check it before fetching the next batch of rows.
+type CancelOnExceed struct {
+	mutex  sync.Mutex // For synchronization.
+	acted  bool
+	ConnID uint64
+}
+
+// Action cancels the query when memory usage exceeds memory quota.
+func (a *CancelOnExceed) Action(t *Tracker) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.acted {
+		return
+	}
+	a.acted = true
+	err := errMemExceed(t)
+	logutil.BgLogger().Warn("memory exceeds quota, cancel the query",
+		zap.Uint64("connID", a.ConnID),
+		zap.Error(err))
+	t.cancel(err)
+}
+
+// SetFallback sets a fallback action, CancelOnExceed is the last action
+// of a chain so the fallback is never called.
+func (a *CancelOnExceed) SetFallback(ActionOnExceed) {}
+
+func errMemExceed(t *Tracker) error {
+	return ErrMemExceedThreshold.GenWithStackByArgs(fmt.Sprintf("%v", t.label), t.BytesConsumed(), t.bytesLimit, "")
+}
+
+func init() {
+	utilMySQLErrCodes := map[terror.ErrCode]uint16{
+		mysql.ErrMemExceedThreshold: mysql.ErrMemExceedThreshold,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassUtil] = utilMySQLErrCodes
+}
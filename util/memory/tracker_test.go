@@ -0,0 +1,128 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/stringutil"
+)
+
+func TestT(t *testing.T) {
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testSuite{})
+
+type testSuite struct{}
+
+func (s *testSuite) TestConsume(c *check.C) {
+	root := NewTracker(stringutil.StringerStr("root"), -1)
+	child1 := NewTracker(stringutil.StringerStr("child1"), -1)
+	child2 := NewTracker(stringutil.StringerStr("child2"), -1)
+	child1.AttachTo(root)
+	child2.AttachTo(root)
+
+	child1.Consume(100)
+	child2.Consume(200)
+	c.Assert(root.BytesConsumed(), check.Equals, int64(300))
+	child1.Consume(-50)
+	c.Assert(child1.BytesConsumed(), check.Equals, int64(50))
+	c.Assert(child1.MaxConsumed(), check.Equals, int64(100))
+	c.Assert(root.BytesConsumed(), check.Equals, int64(250))
+	c.Assert(root.MaxConsumed(), check.Equals, int64(300))
+
+	child2.Detach()
+	c.Assert(root.BytesConsumed(), check.Equals, int64(50))
+	c.Assert(child2.BytesConsumed(), check.Equals, int64(200))
+
+	child2.AttachTo(child1)
+	c.Assert(child1.BytesConsumed(), check.Equals, int64(250))
+	c.Assert(root.BytesConsumed(), check.Equals, int64(250))
+}
+
+func (s *testSuite) TestReplaceChild(c *check.C) {
+	root := NewTracker(stringutil.StringerStr("root"), -1)
+	oldChild := NewTracker(stringutil.StringerStr("old"), -1)
+	newChild := NewTracker(stringutil.StringerStr("new"), -1)
+	oldChild.AttachTo(root)
+	oldChild.Consume(100)
+	newChild.Consume(10)
+
+	root.ReplaceChild(oldChild, newChild)
+	c.Assert(root.BytesConsumed(), check.Equals, int64(10))
+	root.ReplaceChild(newChild, nil)
+	c.Assert(root.BytesConsumed(), check.Equals, int64(0))
+}
+
+type mockAction struct {
+	called   bool
+	fallback ActionOnExceed
+}
+
+func (a *mockAction) SetFallback(fallback ActionOnExceed) {
+	a.fallback = fallback
+}
+
+func (a *mockAction) Action(t *Tracker) {
+	if a.called && a.fallback != nil {
+		a.fallback.Action(t)
+		return
+	}
+	a.called = true
+}
+
+func (s *testSuite) TestActionOnExceed(c *check.C) {
+	root := NewTracker(stringutil.StringerStr("root"), 100)
+	child := NewTracker(stringutil.StringerStr("child"), -1)
+	child.AttachTo(root)
+	root.SetActionOnExceed(&CancelOnExceed{})
+	spill := &mockAction{}
+	root.FallbackOldAndSetNewAction(spill)
+
+	child.Consume(99)
+	c.Assert(spill.called, check.IsFalse)
+	c.Assert(child.Err(), check.IsNil)
+
+	// The first action spills, the query is canceled only if the memory
+	// usage exceeds the quota again.
+	child.Consume(1)
+	c.Assert(spill.called, check.IsTrue)
+	c.Assert(child.Err(), check.IsNil)
+	child.Consume(1)
+	c.Assert(ErrMemExceedThreshold.Equal(child.Err()), check.IsTrue)
+	c.Assert(root.Err(), check.Equals, child.Err())
+}
+
+func (s *testSuite) TestSearchTracker(c *check.C) {
+	root := NewTracker(stringutil.StringerStr("root"), -1)
+	child := NewTracker(stringutil.StringerStr("child"), -1)
+	grandChild := NewTracker(stringutil.StringerStr("grandChild"), -1)
+	child.AttachTo(root)
+	grandChild.AttachTo(child)
+
+	c.Assert(root.SearchTracker("root"), check.Equals, root)
+	c.Assert(root.SearchTracker("grandChild"), check.Equals, grandChild)
+	c.Assert(child.SearchTracker("root"), check.IsNil)
+	grandChild.Detach()
+	c.Assert(root.SearchTracker("grandChild"), check.IsNil)
+}
+
+func (s *testSuite) TestFormatBytes(c *check.C) {
+	c.Assert(FormatBytes(1), check.Equals, "1 Bytes")
+	c.Assert(FormatBytes(1<<10+512), check.Equals, "1.5000 KB")
+	c.Assert(FormatBytes(3<<20), check.Equals, "3.0000 MB")
+	c.Assert(FormatBytes(5<<30), check.Equals, "5.0000 GB")
+}
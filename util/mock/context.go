@@ -25,7 +25,10 @@ import (
 	"github.com/pingcap/tidb/parser/model"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/stringutil"
 )
 
 var _ sessionctx.Context = (*Context)(nil)
@@ -216,6 +219,11 @@ func (c *Context) HasLockedTables() bool {
 	return false
 }
 
+// GetSessionManager implements the sessionctx.Context interface.
+func (c *Context) GetSessionManager() util.SessionManager {
+	return nil
+}
+
 // PrepareTxnFuture implements the sessionctx.Context interface.
 func (c *Context) PrepareTxnFuture(ctx context.Context) {
 }
@@ -236,6 +244,7 @@ func NewContext() *Context {
 	sctx.sessionVars.InitChunkSize = 2
 	sctx.sessionVars.MaxChunkSize = 32
 	sctx.sessionVars.StmtCtx.TimeZone = time.UTC
+	sctx.sessionVars.StmtCtx.MemTracker = memory.NewTracker(stringutil.StringerStr("mock.NewContext"), -1)
 	sctx.sessionVars.GlobalVarsAccessor = variable.NewMockGlobalAccessor()
 	if err := sctx.GetSessionVars().SetSystemVar(variable.MaxAllowedPacket, "67108864"); err != nil {
 		panic(err)
@@ -0,0 +1,198 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plancodec
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pingcap/errors"
+)
+
+const (
+	lineBreaker    = '\n'
+	separator      = '\t'
+	separatorAlias = ' '
+	// fieldNum is the number of the fields of an encoded plan node, which are
+	// the depth, the id, the task type, the estimated row count and the
+	// operator info.
+	fieldNum = 5
+)
+
+var fieldEscaper = strings.NewReplacer(string(separator), string(separatorAlias), string(lineBreaker), string(separatorAlias))
+
+// EncodePlanNode encodes a plan node into a line of the encoded plan, the
+// node is at the depth of the plan tree, the root node is at depth 0.
+func EncodePlanNode(depth int, id, taskType string, estRows float64, operatorInfo string, buf *bytes.Buffer) {
+	buf.WriteString(strconv.Itoa(depth))
+	buf.WriteByte(separator)
+	buf.WriteString(fieldEscaper.Replace(id))
+	buf.WriteByte(separator)
+	buf.WriteString(fieldEscaper.Replace(taskType))
+	buf.WriteByte(separator)
+	buf.WriteString(strconv.FormatFloat(estRows, 'f', 2, 64))
+	buf.WriteByte(separator)
+	buf.WriteString(fieldEscaper.Replace(operatorInfo))
+	buf.WriteByte(lineBreaker)
+}
+
+// Compress compresses the encoded plan nodes into a compact string, which
+// can be decoded by DecodePlan.
+func Compress(input []byte) (string, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if _, err = w.Write(input); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = w.Close(); err != nil {
+		return "", errors.Trace(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func decompress(str string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// planNode is a decoded plan node.
+type planNode struct {
+	depth  int
+	fields []string
+}
+
+// DecodePlan decodes a plan string compressed by Compress, and renders it as
+// a table whose columns are the id, the task type, the estimated row count
+// and the operator info, the tree structure of the plan is shown by the
+// indent of the ids just like the result of EXPLAIN.
+func DecodePlan(planString string) (string, error) {
+	if len(planString) == 0 {
+		return "", nil
+	}
+	data, err := decompress(planString)
+	if err != nil {
+		return "", errors.Errorf("invalid encoded plan: %v", err)
+	}
+	nodes := make([]planNode, 0, 8)
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), string(lineBreaker)), string(lineBreaker)) {
+		fields := strings.Split(line, string(separator))
+		if len(fields) != fieldNum {
+			return "", errors.Errorf("invalid encoded plan node: %s", line)
+		}
+		depth, err := strconv.Atoi(fields[0])
+		if err != nil || depth < 0 || (len(nodes) == 0 && depth != 0) {
+			return "", errors.Errorf("invalid depth of encoded plan node: %s", line)
+		}
+		if len(nodes) > 0 && depth > nodes[len(nodes)-1].depth+1 {
+			return "", errors.Errorf("invalid depth of encoded plan node: %s", line)
+		}
+		nodes = append(nodes, planNode{depth: depth, fields: fields[1:]})
+	}
+	for i := range nodes {
+		nodes[i].fields[0] = prefixOfNode(nodes, i) + nodes[i].fields[0]
+	}
+	return renderTable([]string{"id", "task", "estRows", "operator info"}, nodes), nil
+}
+
+// isLastChild checks whether the i-th node is the last child of its parent,
+// i.e. no sibling follows it before the sub-tree of its parent ends.
+func isLastChild(nodes []planNode, i int) bool {
+	for j := i + 1; j < len(nodes); j++ {
+		if nodes[j].depth < nodes[i].depth {
+			return true
+		}
+		if nodes[j].depth == nodes[i].depth {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixOfNode builds the tree indent of the i-th node, which is the same as
+// the indent of EXPLAIN. Every ancestor except the root contributes a vertical
+// line if it has following siblings, the node itself is attached by a corner
+// if it's the last child, or by a tee otherwise.
+func prefixOfNode(nodes []planNode, i int) string {
+	depth := nodes[i].depth
+	if depth == 0 {
+		return ""
+	}
+	// ancestors[d] is the ancestor of the node at depth d.
+	ancestors := make([]int, depth)
+	for j, d := i-1, depth-1; j >= 0 && d > 0; j-- {
+		if nodes[j].depth == d {
+			ancestors[d] = j
+			d--
+		}
+	}
+	var buf bytes.Buffer
+	for d := 1; d < depth; d++ {
+		if isLastChild(nodes, ancestors[d]) {
+			buf.WriteString("  ")
+		} else {
+			buf.WriteString("│ ")
+		}
+	}
+	if isLastChild(nodes, i) {
+		buf.WriteString("└─")
+	} else {
+		buf.WriteString("├─")
+	}
+	return buf.String()
+}
+
+// renderTable renders the nodes as a table, every column is padded to the
+// width of its widest cell.
+func renderTable(header []string, nodes []planNode) string {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, node := range nodes {
+		for i, field := range node.fields {
+			if w := utf8.RuneCountInString(field); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	var buf bytes.Buffer
+	writeRow := func(fields []string) {
+		buf.WriteByte(lineBreaker)
+		for i, field := range fields {
+			buf.WriteByte(separator)
+			buf.WriteString(field)
+			if i+1 < len(fields) {
+				buf.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(field)))
+			}
+		}
+	}
+	writeRow(header)
+	for _, node := range nodes {
+		writeRow(node.fields)
+	}
+	return buf.String()
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plancodec
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testPlanCodecSuite{})
+
+type testPlanCodecSuite struct{}
+
+func (s *testPlanCodecSuite) TestEncodeDecodePlan(c *C) {
+	var buf bytes.Buffer
+	EncodePlanNode(0, "HashLeftJoin_8", "root", 12487.5, "inner join, equal:[eq(test.t1.a, test.t2.a)]", &buf)
+	EncodePlanNode(1, "TableReader_11", "root", 9990, "data:Selection_10", &buf)
+	EncodePlanNode(2, "Selection_10", "cop", 9990, "not(isnull(test.t1.a))", &buf)
+	EncodePlanNode(3, "TableScan_9", "cop", 10000, "table:t1,\trange:[-inf,+inf]", &buf)
+	EncodePlanNode(1, "TableReader_14", "root", 10000, "data:TableScan_13", &buf)
+	EncodePlanNode(2, "TableScan_13", "cop", 10000, "table:t2, range:[-inf,+inf]", &buf)
+	encoded, err := Compress(buf.Bytes())
+	c.Assert(err, IsNil)
+
+	decoded, err := DecodePlan(encoded)
+	c.Assert(err, IsNil)
+	c.Assert(decoded, Equals, "\n"+
+		"\tid               \ttask\testRows \toperator info\n"+
+		"\tHashLeftJoin_8   \troot\t12487.50\tinner join, equal:[eq(test.t1.a, test.t2.a)]\n"+
+		"\t├─TableReader_11 \troot\t9990.00 \tdata:Selection_10\n"+
+		"\t│ └─Selection_10 \tcop \t9990.00 \tnot(isnull(test.t1.a))\n"+
+		"\t│   └─TableScan_9\tcop \t10000.00\ttable:t1, range:[-inf,+inf]\n"+
+		"\t└─TableReader_14 \troot\t10000.00\tdata:TableScan_13\n"+
+		"\t  └─TableScan_13 \tcop \t10000.00\ttable:t2, range:[-inf,+inf]")
+
+	decoded, err = DecodePlan("")
+	c.Assert(err, IsNil)
+	c.Assert(decoded, Equals, "")
+
+	_, err = DecodePlan("not a plan")
+	c.Assert(err, NotNil)
+
+	buf.Reset()
+	EncodePlanNode(1, "TableScan_1", "cop", 1, "", &buf)
+	encoded, err = Compress(buf.Bytes())
+	c.Assert(err, IsNil)
+	_, err = DecodePlan(encoded)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+)
+
+// ProcessInfo is a struct used for show processlist statement.
+type ProcessInfo struct {
+	ID      uint64
+	User    string
+	Host    string
+	DB      string
+	Command byte
+	Time    time.Time
+	State   uint16
+	Info    string
+	StmtCtx *stmtctx.StatementContext
+}
+
+// ToRow returns []interface{} for the row data of "show processlist".
+// The last two columns are the current and the peak memory usage of the
+// running statement, in bytes.
+func (pi *ProcessInfo) ToRow(full bool) []interface{} {
+	var info interface{}
+	if len(pi.Info) > 0 {
+		if full {
+			info = pi.Info
+		} else {
+			info = fmt.Sprintf("%.100v", pi.Info)
+		}
+	}
+	var db interface{}
+	if len(pi.DB) > 0 {
+		db = pi.DB
+	}
+	var mem, maxMem int64
+	if pi.StmtCtx != nil && pi.StmtCtx.MemTracker != nil {
+		mem = pi.StmtCtx.MemTracker.BytesConsumed()
+		maxMem = pi.StmtCtx.MemTracker.MaxConsumed()
+	}
+	t := uint64(time.Since(pi.Time) / time.Second)
+	return []interface{}{
+		pi.ID,
+		pi.User,
+		pi.Host,
+		db,
+		mysql.Command2Str[pi.Command],
+		t,
+		fmt.Sprintf("%d", pi.State),
+		info,
+		mem,
+		maxMem,
+	}
+}
+
+// SessionManager is an interface for session manage. Show processlist and
+// kill statement rely on this interface.
+type SessionManager interface {
+	// ShowProcessList returns map[connectionID]ProcessInfo
+	ShowProcessList() map[uint64]*ProcessInfo
+	GetProcessInfo(id uint64) (*ProcessInfo, bool)
+	// Kill kills the connection or its running query, an error is returned
+	// if the connection doesn't exist.
+	Kill(connectionID uint64, query bool) error
+}
@@ -35,6 +35,9 @@ import (
 type RestrictedSQLExecutor interface {
 	// ExecRestrictedSQL run sql statement in ctx with some restriction.
 	ExecRestrictedSQL(sql string) ([]chunk.Row, []*ast.ResultField, error)
+	// ExecRestrictedSQLWithSnapshot run sql statement in ctx with some restriction, and it reads
+	// the data at the snapshot timestamp.
+	ExecRestrictedSQLWithSnapshot(sql string, snapshot uint64) ([]chunk.Row, []*ast.ResultField, error)
 }
 
 // SQLExecutor is an interface provides executing normal sql statement.
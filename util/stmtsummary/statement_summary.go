@@ -0,0 +1,554 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmtsummary
+
+import (
+	"container/list"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/plancodec"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefRefreshInterval is the default length of a summary window in seconds.
+	DefRefreshInterval = 1800
+	// DefHistorySize is the default number of the windows kept for a statement.
+	DefHistorySize = 24
+	// DefMaxStmtCount is the default number of the statements kept in the summary.
+	DefMaxStmtCount = 200
+
+	// timeFormat is the format of the time columns of the summary tables.
+	timeFormat = "2006-01-02 15:04:05"
+)
+
+// stmtSummaryByDigestKey defines key for stmtSummaryByDigestMap.summaryMap.
+type stmtSummaryByDigestKey struct {
+	// Same statements may appear in different schema, but they refer to different tables.
+	schemaName string
+	digest     string
+	// The digest of the plan, the same statement may have different plans.
+	planDigest string
+}
+
+// stmtSummaryByDigestMap is a LRU cache that stores statement summaries.
+type stmtSummaryByDigestMap struct {
+	// It's rare to read concurrently, so RWMutex is not needed.
+	sync.Mutex
+	summaryMap map[stmtSummaryByDigestKey]*list.Element
+	// lruList keeps the summaries in the order of the last access, the front
+	// is the most recently used one.
+	lruList *list.List
+
+	// enabled indicates whether the summary is enabled, it's 1 if enabled.
+	enabled int32
+	// refreshInterval is the length of a summary window in seconds.
+	refreshInterval int64
+	// historySize is the number of the windows kept for a statement.
+	historySize int32
+	// maxStmtCount is the number of the statements kept in the summary, the
+	// least recently used ones are evicted if there are more.
+	maxStmtCount uint32
+}
+
+// StmtSummaryByDigestMap is a global map containing all statement summaries.
+var StmtSummaryByDigestMap = newStmtSummaryByDigestMap()
+
+// stmtSummaryByDigest is the summary for each type of statements, it keeps
+// a summary for each window in which the statement is executed.
+type stmtSummaryByDigest struct {
+	key stmtSummaryByDigestKey
+	// history is the list of *stmtSummaryByDigestElement, ordered by the
+	// begin time of the windows.
+	history *list.List
+}
+
+// stmtSummaryByDigestElement is the summary of a statement in a window.
+type stmtSummaryByDigestElement struct {
+	beginTime int64
+	endTime   int64
+
+	// basic
+	stmtType      string
+	normalizedSQL string
+	sampleSQL     string
+	samplePlan    string
+	sampleUser    string
+	indexNames    []string
+	execCount     int64
+	sumErrors     int64
+	// latency
+	sumLatency        time.Duration
+	maxLatency        time.Duration
+	minLatency        time.Duration
+	latencyHistogram  latencyHistogram
+	sumParseLatency   time.Duration
+	maxParseLatency   time.Duration
+	sumCompileLatency time.Duration
+	maxCompileLatency time.Duration
+	// coprocessor
+	sumNumCopTasks       int64
+	maxCopProcessTime    time.Duration
+	maxCopProcessAddress string
+	maxCopWaitTime       time.Duration
+	maxCopWaitAddress    string
+	// execution details, the process and wait time are the sums of those of
+	// the coprocessor tasks.
+	sumProcessTime time.Duration
+	maxProcessTime time.Duration
+	sumWaitTime    time.Duration
+	maxWaitTime    time.Duration
+	sumBackoffTime time.Duration
+	maxBackoffTime time.Duration
+	// other
+	sumMem          int64
+	maxMem          int64
+	sumAffectedRows uint64
+	firstSeen       time.Time
+	lastSeen        time.Time
+}
+
+// StmtExecInfo records execution information of each statement.
+type StmtExecInfo struct {
+	SchemaName     string
+	OriginalSQL    string
+	NormalizedSQL  string
+	Digest         string
+	PlanDigest     string
+	EncodedPlan    string
+	StmtType       string
+	User           string
+	TotalLatency   time.Duration
+	ParseLatency   time.Duration
+	CompileLatency time.Duration
+	StmtCtx        *stmtctx.StatementContext
+	CopTasks       *stmtctx.CopTasksDetails
+	ExecDetail     *execdetails.ExecDetails
+	MemMax         int64
+	StartTime      time.Time
+	Succeed        bool
+}
+
+// newStmtSummaryByDigestMap creates an empty stmtSummaryByDigestMap.
+func newStmtSummaryByDigestMap() *stmtSummaryByDigestMap {
+	return &stmtSummaryByDigestMap{
+		summaryMap:      make(map[stmtSummaryByDigestKey]*list.Element),
+		lruList:         list.New(),
+		enabled:         1,
+		refreshInterval: DefRefreshInterval,
+		historySize:     DefHistorySize,
+		maxStmtCount:    DefMaxStmtCount,
+	}
+}
+
+// AddStatement adds a statement to StmtSummaryByDigestMap.
+func (ssMap *stmtSummaryByDigestMap) AddStatement(sei *StmtExecInfo) {
+	if !ssMap.Enabled() {
+		return
+	}
+	intervalSeconds := ssMap.RefreshInterval()
+	historySize := ssMap.HistorySize()
+	maxStmtCount := ssMap.MaxStmtCount()
+
+	key := stmtSummaryByDigestKey{
+		schemaName: sei.SchemaName,
+		digest:     sei.Digest,
+		planDigest: sei.PlanDigest,
+	}
+	now := time.Now().Unix()
+	beginTime := now - now%intervalSeconds
+
+	ssMap.Lock()
+	defer ssMap.Unlock()
+	var summary *stmtSummaryByDigest
+	if elem, ok := ssMap.summaryMap[key]; ok {
+		ssMap.lruList.MoveToFront(elem)
+		summary = elem.Value.(*stmtSummaryByDigest)
+	} else {
+		summary = &stmtSummaryByDigest{key: key, history: list.New()}
+		ssMap.summaryMap[key] = ssMap.lruList.PushFront(summary)
+		ssMap.evict(maxStmtCount)
+	}
+	summary.add(sei, beginTime, intervalSeconds, historySize)
+}
+
+// evict removes the least recently used summaries until there are at most
+// maxStmtCount ones.
+func (ssMap *stmtSummaryByDigestMap) evict(maxStmtCount int) {
+	for ssMap.lruList.Len() > maxStmtCount {
+		back := ssMap.lruList.Back()
+		delete(ssMap.summaryMap, back.Value.(*stmtSummaryByDigest).key)
+		ssMap.lruList.Remove(back)
+	}
+}
+
+// Clear removes all statement summaries.
+func (ssMap *stmtSummaryByDigestMap) Clear() {
+	ssMap.Lock()
+	defer ssMap.Unlock()
+	ssMap.summaryMap = make(map[stmtSummaryByDigestKey]*list.Element)
+	ssMap.lruList.Init()
+}
+
+// ToCurrentDatum converts the summaries of the current window to datums.
+func (ssMap *stmtSummaryByDigestMap) ToCurrentDatum() [][]types.Datum {
+	now := time.Now().Unix()
+	ssMap.Lock()
+	defer ssMap.Unlock()
+	rows := make([][]types.Datum, 0, ssMap.lruList.Len())
+	for elem := ssMap.lruList.Front(); elem != nil; elem = elem.Next() {
+		summary := elem.Value.(*stmtSummaryByDigest)
+		back := summary.history.Back()
+		if back == nil {
+			continue
+		}
+		ssElement := back.Value.(*stmtSummaryByDigestElement)
+		if ssElement.beginTime <= now && now < ssElement.endTime {
+			rows = append(rows, ssElement.toDatum(summary))
+		}
+	}
+	return rows
+}
+
+// ToHistoryDatum converts the summaries of all the windows to datums.
+func (ssMap *stmtSummaryByDigestMap) ToHistoryDatum() [][]types.Datum {
+	ssMap.Lock()
+	defer ssMap.Unlock()
+	rows := make([][]types.Datum, 0, ssMap.lruList.Len())
+	for elem := ssMap.lruList.Front(); elem != nil; elem = elem.Next() {
+		summary := elem.Value.(*stmtSummaryByDigest)
+		for e := summary.history.Front(); e != nil; e = e.Next() {
+			rows = append(rows, e.Value.(*stmtSummaryByDigestElement).toDatum(summary))
+		}
+	}
+	return rows
+}
+
+// SetEnabled enables or disables the statement summary, the summaries are
+// cleared when it's disabled.
+func (ssMap *stmtSummaryByDigestMap) SetEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&ssMap.enabled, 1)
+		return
+	}
+	atomic.StoreInt32(&ssMap.enabled, 0)
+	ssMap.Clear()
+}
+
+// Enabled returns whether the statement summary is enabled.
+func (ssMap *stmtSummaryByDigestMap) Enabled() bool {
+	return atomic.LoadInt32(&ssMap.enabled) == 1
+}
+
+// SetRefreshInterval sets the length of a summary window in seconds, it
+// takes effect from the next window.
+func (ssMap *stmtSummaryByDigestMap) SetRefreshInterval(seconds int64) {
+	if seconds <= 0 {
+		seconds = DefRefreshInterval
+	}
+	atomic.StoreInt64(&ssMap.refreshInterval, seconds)
+}
+
+// RefreshInterval gets the length of a summary window in seconds.
+func (ssMap *stmtSummaryByDigestMap) RefreshInterval() int64 {
+	return atomic.LoadInt64(&ssMap.refreshInterval)
+}
+
+// SetHistorySize sets the number of the windows kept for a statement.
+func (ssMap *stmtSummaryByDigestMap) SetHistorySize(size int) {
+	if size <= 0 {
+		size = DefHistorySize
+	}
+	atomic.StoreInt32(&ssMap.historySize, int32(size))
+}
+
+// HistorySize gets the number of the windows kept for a statement.
+func (ssMap *stmtSummaryByDigestMap) HistorySize() int {
+	return int(atomic.LoadInt32(&ssMap.historySize))
+}
+
+// SetMaxStmtCount sets the number of the statements kept in the summary, the
+// exceeded ones are evicted immediately.
+func (ssMap *stmtSummaryByDigestMap) SetMaxStmtCount(count uint) {
+	if count == 0 {
+		count = DefMaxStmtCount
+	}
+	atomic.StoreUint32(&ssMap.maxStmtCount, uint32(count))
+	ssMap.Lock()
+	ssMap.evict(int(count))
+	ssMap.Unlock()
+}
+
+// MaxStmtCount gets the number of the statements kept in the summary.
+func (ssMap *stmtSummaryByDigestMap) MaxStmtCount() int {
+	return int(atomic.LoadUint32(&ssMap.maxStmtCount))
+}
+
+// add adds a statement to the summary of the window beginning at beginTime,
+// a new window is started if the last one has ended.
+func (ssbd *stmtSummaryByDigest) add(sei *StmtExecInfo, beginTime int64, intervalSeconds int64, historySize int) {
+	var ssElement *stmtSummaryByDigestElement
+	if back := ssbd.history.Back(); back != nil {
+		lastElement := back.Value.(*stmtSummaryByDigestElement)
+		if lastElement.beginTime >= beginTime {
+			ssElement = lastElement
+		}
+	}
+	if ssElement == nil {
+		ssElement = newStmtSummaryByDigestElement(sei, beginTime, intervalSeconds)
+		ssbd.history.PushBack(ssElement)
+	}
+	for ssbd.history.Len() > historySize {
+		ssbd.history.Remove(ssbd.history.Front())
+	}
+	ssElement.add(sei)
+}
+
+func newStmtSummaryByDigestElement(sei *StmtExecInfo, beginTime int64, intervalSeconds int64) *stmtSummaryByDigestElement {
+	return &stmtSummaryByDigestElement{
+		beginTime:     beginTime,
+		endTime:       beginTime + intervalSeconds,
+		stmtType:      sei.StmtType,
+		normalizedSQL: sei.NormalizedSQL,
+		minLatency:    sei.TotalLatency,
+		firstSeen:     sei.StartTime,
+		lastSeen:      sei.StartTime,
+	}
+}
+
+// add adds a statement to the summary of a window.
+func (ssElement *stmtSummaryByDigestElement) add(sei *StmtExecInfo) {
+	// The samples are kept up to date.
+	ssElement.sampleSQL = sei.OriginalSQL
+	ssElement.samplePlan = sei.EncodedPlan
+	if len(sei.User) > 0 {
+		ssElement.sampleUser = sei.User
+	}
+
+	ssElement.execCount++
+	if !sei.Succeed {
+		ssElement.sumErrors++
+	}
+
+	// latency
+	ssElement.sumLatency += sei.TotalLatency
+	if sei.TotalLatency > ssElement.maxLatency {
+		ssElement.maxLatency = sei.TotalLatency
+	}
+	if sei.TotalLatency < ssElement.minLatency {
+		ssElement.minLatency = sei.TotalLatency
+	}
+	ssElement.latencyHistogram.add(sei.TotalLatency)
+	ssElement.sumParseLatency += sei.ParseLatency
+	if sei.ParseLatency > ssElement.maxParseLatency {
+		ssElement.maxParseLatency = sei.ParseLatency
+	}
+	ssElement.sumCompileLatency += sei.CompileLatency
+	if sei.CompileLatency > ssElement.maxCompileLatency {
+		ssElement.maxCompileLatency = sei.CompileLatency
+	}
+
+	// coprocessor
+	if sei.CopTasks != nil {
+		ssElement.sumNumCopTasks += int64(sei.CopTasks.NumCopTasks)
+		if sei.CopTasks.MaxProcessTime > ssElement.maxCopProcessTime {
+			ssElement.maxCopProcessTime = sei.CopTasks.MaxProcessTime
+			ssElement.maxCopProcessAddress = sei.CopTasks.MaxProcessAddress
+		}
+		if sei.CopTasks.MaxWaitTime > ssElement.maxCopWaitTime {
+			ssElement.maxCopWaitTime = sei.CopTasks.MaxWaitTime
+			ssElement.maxCopWaitAddress = sei.CopTasks.MaxWaitAddress
+		}
+	}
+
+	// execution details
+	if sei.ExecDetail != nil {
+		ssElement.sumProcessTime += sei.ExecDetail.ProcessTime
+		if sei.ExecDetail.ProcessTime > ssElement.maxProcessTime {
+			ssElement.maxProcessTime = sei.ExecDetail.ProcessTime
+		}
+		ssElement.sumWaitTime += sei.ExecDetail.WaitTime
+		if sei.ExecDetail.WaitTime > ssElement.maxWaitTime {
+			ssElement.maxWaitTime = sei.ExecDetail.WaitTime
+		}
+		ssElement.sumBackoffTime += sei.ExecDetail.BackoffTime
+		if sei.ExecDetail.BackoffTime > ssElement.maxBackoffTime {
+			ssElement.maxBackoffTime = sei.ExecDetail.BackoffTime
+		}
+	}
+
+	// other
+	if sei.StmtCtx != nil {
+		ssElement.sumAffectedRows += sei.StmtCtx.AffectedRows()
+		ssElement.indexNames = mergeIndexNames(ssElement.indexNames, sei.StmtCtx.IndexNames)
+	}
+	ssElement.sumMem += sei.MemMax
+	if sei.MemMax > ssElement.maxMem {
+		ssElement.maxMem = sei.MemMax
+	}
+	if sei.StartTime.Before(ssElement.firstSeen) {
+		ssElement.firstSeen = sei.StartTime
+	}
+	if ssElement.lastSeen.Before(sei.StartTime) {
+		ssElement.lastSeen = sei.StartTime
+	}
+}
+
+// mergeIndexNames merges the index names used by an execution into the sorted
+// and deduplicated index names of a summary.
+func mergeIndexNames(indexNames, newNames []string) []string {
+	for _, name := range newNames {
+		idx := sort.SearchStrings(indexNames, name)
+		if idx < len(indexNames) && indexNames[idx] == name {
+			continue
+		}
+		indexNames = append(indexNames, "")
+		copy(indexNames[idx+1:], indexNames[idx:])
+		indexNames[idx] = name
+	}
+	return indexNames
+}
+
+func (ssElement *stmtSummaryByDigestElement) toDatum(ssbd *stmtSummaryByDigest) []types.Datum {
+	plan, err := plancodec.DecodePlan(ssElement.samplePlan)
+	if err != nil {
+		logutil.BgLogger().Error("decode plan in statement summary failed", zap.String("plan", ssElement.samplePlan), zap.Error(err))
+		plan = ""
+	}
+	return types.MakeDatums(
+		time.Unix(ssElement.beginTime, 0).Format(timeFormat),
+		time.Unix(ssElement.endTime, 0).Format(timeFormat),
+		ssElement.stmtType,
+		convertEmptyToNil(ssbd.key.schemaName),
+		ssbd.key.digest,
+		ssElement.normalizedSQL,
+		convertEmptyToNil(strings.Join(ssElement.indexNames, ",")),
+		convertEmptyToNil(ssElement.sampleUser),
+		ssElement.execCount,
+		ssElement.sumErrors,
+		int64(ssElement.sumLatency),
+		int64(ssElement.maxLatency),
+		int64(ssElement.minLatency),
+		avgInt(int64(ssElement.sumLatency), ssElement.execCount),
+		int64(ssElement.latencyHistogram.percentile(0.5, ssElement.minLatency, ssElement.maxLatency)),
+		int64(ssElement.latencyHistogram.percentile(0.95, ssElement.minLatency, ssElement.maxLatency)),
+		int64(ssElement.latencyHistogram.percentile(0.99, ssElement.minLatency, ssElement.maxLatency)),
+		avgInt(int64(ssElement.sumParseLatency), ssElement.execCount),
+		int64(ssElement.maxParseLatency),
+		avgInt(int64(ssElement.sumCompileLatency), ssElement.execCount),
+		int64(ssElement.maxCompileLatency),
+		ssElement.sumNumCopTasks,
+		avgInt(int64(ssElement.sumProcessTime), ssElement.sumNumCopTasks),
+		int64(ssElement.maxCopProcessTime),
+		convertEmptyToNil(ssElement.maxCopProcessAddress),
+		avgInt(int64(ssElement.sumWaitTime), ssElement.sumNumCopTasks),
+		int64(ssElement.maxCopWaitTime),
+		convertEmptyToNil(ssElement.maxCopWaitAddress),
+		avgInt(int64(ssElement.sumProcessTime), ssElement.execCount),
+		int64(ssElement.maxProcessTime),
+		avgInt(int64(ssElement.sumWaitTime), ssElement.execCount),
+		int64(ssElement.maxWaitTime),
+		avgInt(int64(ssElement.sumBackoffTime), ssElement.execCount),
+		int64(ssElement.maxBackoffTime),
+		avgInt(ssElement.sumMem, ssElement.execCount),
+		ssElement.maxMem,
+		avgFloat(int64(ssElement.sumAffectedRows), ssElement.execCount),
+		ssElement.firstSeen.Format(timeFormat),
+		ssElement.lastSeen.Format(timeFormat),
+		ssElement.sampleSQL,
+		convertEmptyToNil(ssbd.key.planDigest),
+		plan,
+	)
+}
+
+func convertEmptyToNil(str string) interface{} {
+	if str == "" {
+		return nil
+	}
+	return str
+}
+
+// avgInt is used to calculate average of int values, it's 0 if there's no value.
+func avgInt(sum int64, count int64) int64 {
+	if count > 0 {
+		return sum / count
+	}
+	return 0
+}
+
+// avgFloat is used to calculate average of float values, it's 0 if there's no value.
+func avgFloat(sum int64, count int64) float64 {
+	if count > 0 {
+		return float64(sum) / float64(count)
+	}
+	return 0
+}
+
+// latencyBuckets is the number of the buckets of latencyHistogram, the i-th
+// bucket counts the latencies in (2^(i-1), 2^i] microseconds, and the last
+// one counts all the larger latencies.
+const latencyBuckets = 40
+
+// latencyHistogram counts the latencies in exponential buckets, so the
+// percentiles can be estimated with bounded memory.
+type latencyHistogram [latencyBuckets]int64
+
+func (h *latencyHistogram) add(latency time.Duration) {
+	us := int64(latency / time.Microsecond)
+	idx := 0
+	if us > 1 {
+		idx = int(math.Ceil(math.Log2(float64(us))))
+	}
+	if idx >= latencyBuckets {
+		idx = latencyBuckets - 1
+	}
+	h[idx]++
+}
+
+// percentile estimates the p-th percentile of the latencies by the upper bound
+// of the bucket it falls in, which is clipped by the min and max latencies.
+func (h *latencyHistogram) percentile(p float64, minLatency, maxLatency time.Duration) time.Duration {
+	var total int64
+	for _, cnt := range h {
+		total += cnt
+	}
+	if total == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(float64(total) * p))
+	var cumulative int64
+	for i, cnt := range h {
+		cumulative += cnt
+		if cumulative >= target {
+			latency := time.Duration(1<<uint(i)) * time.Microsecond
+			if latency > maxLatency {
+				latency = maxLatency
+			}
+			if latency < minLatency {
+				latency = minLatency
+			}
+			return latency
+		}
+	}
+	return maxLatency
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmtsummary
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/execdetails"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testStmtSummarySuite{})
+
+type testStmtSummarySuite struct {
+	ssMap *stmtSummaryByDigestMap
+}
+
+func (s *testStmtSummarySuite) SetUpTest(c *C) {
+	s.ssMap = newStmtSummaryByDigestMap()
+}
+
+func generateAnyExecInfo() *StmtExecInfo {
+	sc := &stmtctx.StatementContext{IndexNames: []string{"t1:a"}}
+	sc.AddAffectedRows(10)
+	return &StmtExecInfo{
+		SchemaName:     "schema_name",
+		OriginalSQL:    "original_sql1",
+		NormalizedSQL:  "normalized_sql",
+		Digest:         "digest",
+		PlanDigest:     "plan_digest",
+		StmtType:       "Select",
+		User:           "user",
+		TotalLatency:   10000 * time.Microsecond,
+		ParseLatency:   100,
+		CompileLatency: 1000,
+		StmtCtx:        sc,
+		CopTasks: &stmtctx.CopTasksDetails{
+			NumCopTasks:       10,
+			MaxProcessAddress: "127.0.0.1",
+			MaxProcessTime:    15000,
+			MaxWaitAddress:    "127.0.0.1",
+			MaxWaitTime:       1500,
+		},
+		ExecDetail: &execdetails.ExecDetails{
+			ProcessTime: 50000,
+			WaitTime:    5000,
+			BackoffTime: 80,
+		},
+		MemMax:    10000,
+		StartTime: time.Date(2019, 1, 1, 10, 10, 10, 10, time.UTC),
+		Succeed:   true,
+	}
+}
+
+// Test stmtSummaryByDigestMap.AddStatement.
+func (s *testStmtSummarySuite) TestAddStatement(c *C) {
+	stmtExecInfo1 := generateAnyExecInfo()
+	s.ssMap.AddStatement(stmtExecInfo1)
+	c.Assert(s.ssMap.summaryMap, HasLen, 1)
+
+	stmtExecInfo2 := generateAnyExecInfo()
+	stmtExecInfo2.OriginalSQL = "original_sql2"
+	stmtExecInfo2.User = "user2"
+	stmtExecInfo2.TotalLatency = 20000 * time.Microsecond
+	stmtExecInfo2.ParseLatency = 300
+	stmtExecInfo2.StmtCtx.IndexNames = []string{"t2:b", "t1:a"}
+	stmtExecInfo2.CopTasks.MaxProcessAddress = "127.0.0.2"
+	stmtExecInfo2.CopTasks.MaxProcessTime = 25000
+	stmtExecInfo2.ExecDetail.ProcessTime = 70000
+	stmtExecInfo2.MemMax = 20000
+	stmtExecInfo2.StartTime = time.Date(2019, 1, 1, 10, 10, 20, 10, time.UTC)
+	stmtExecInfo2.Succeed = false
+	s.ssMap.AddStatement(stmtExecInfo2)
+	c.Assert(s.ssMap.summaryMap, HasLen, 1)
+
+	key := stmtSummaryByDigestKey{schemaName: "schema_name", digest: "digest", planDigest: "plan_digest"}
+	summary := s.ssMap.summaryMap[key].Value.(*stmtSummaryByDigest)
+	c.Assert(summary.history.Len(), Equals, 1)
+	element := summary.history.Back().Value.(*stmtSummaryByDigestElement)
+	c.Assert(element.endTime-element.beginTime, Equals, int64(DefRefreshInterval))
+	c.Assert(element.sampleSQL, Equals, "original_sql2")
+	c.Assert(element.sampleUser, Equals, "user2")
+	c.Assert(element.indexNames, DeepEquals, []string{"t1:a", "t2:b"})
+	c.Assert(element.execCount, Equals, int64(2))
+	c.Assert(element.sumErrors, Equals, int64(1))
+	c.Assert(element.sumLatency, Equals, 30000*time.Microsecond)
+	c.Assert(element.maxLatency, Equals, 20000*time.Microsecond)
+	c.Assert(element.minLatency, Equals, 10000*time.Microsecond)
+	c.Assert(element.sumParseLatency, Equals, time.Duration(400))
+	c.Assert(element.maxParseLatency, Equals, time.Duration(300))
+	c.Assert(element.sumNumCopTasks, Equals, int64(20))
+	c.Assert(element.maxCopProcessTime, Equals, time.Duration(25000))
+	c.Assert(element.maxCopProcessAddress, Equals, "127.0.0.2")
+	c.Assert(element.sumProcessTime, Equals, time.Duration(120000))
+	c.Assert(element.maxProcessTime, Equals, time.Duration(70000))
+	c.Assert(element.sumBackoffTime, Equals, time.Duration(160))
+	c.Assert(element.sumMem, Equals, int64(30000))
+	c.Assert(element.maxMem, Equals, int64(20000))
+	c.Assert(element.sumAffectedRows, Equals, uint64(20))
+	c.Assert(element.firstSeen, Equals, stmtExecInfo1.StartTime)
+	c.Assert(element.lastSeen, Equals, stmtExecInfo2.StartTime)
+
+	// Different plan digests are summarized separately.
+	stmtExecInfo3 := generateAnyExecInfo()
+	stmtExecInfo3.PlanDigest = "plan_digest2"
+	s.ssMap.AddStatement(stmtExecInfo3)
+	c.Assert(s.ssMap.summaryMap, HasLen, 2)
+
+	// The statements are not summarized when it's disabled.
+	s.ssMap.SetEnabled(false)
+	c.Assert(s.ssMap.summaryMap, HasLen, 0)
+	s.ssMap.AddStatement(generateAnyExecInfo())
+	c.Assert(s.ssMap.summaryMap, HasLen, 0)
+	s.ssMap.SetEnabled(true)
+	s.ssMap.AddStatement(generateAnyExecInfo())
+	c.Assert(s.ssMap.summaryMap, HasLen, 1)
+}
+
+// Test the rolling windows of the summary.
+func (s *testStmtSummarySuite) TestHistory(c *C) {
+	s.ssMap.SetHistorySize(2)
+	s.ssMap.AddStatement(generateAnyExecInfo())
+	key := stmtSummaryByDigestKey{schemaName: "schema_name", digest: "digest", planDigest: "plan_digest"}
+	summary := s.ssMap.summaryMap[key].Value.(*stmtSummaryByDigest)
+
+	// Move the window backward to pretend that it has ended.
+	for i := 1; i <= 3; i++ {
+		element := summary.history.Back().Value.(*stmtSummaryByDigestElement)
+		element.beginTime -= DefRefreshInterval
+		element.endTime -= DefRefreshInterval
+		c.Assert(s.ssMap.ToCurrentDatum(), HasLen, 0)
+		s.ssMap.AddStatement(generateAnyExecInfo())
+		c.Assert(s.ssMap.ToCurrentDatum(), HasLen, 1)
+	}
+	c.Assert(summary.history.Len(), Equals, 2)
+	c.Assert(s.ssMap.ToHistoryDatum(), HasLen, 2)
+	for e := summary.history.Front(); e != nil; e = e.Next() {
+		c.Assert(e.Value.(*stmtSummaryByDigestElement).execCount, Equals, int64(1))
+	}
+}
+
+// Test the least recently used statements are evicted.
+func (s *testStmtSummarySuite) TestMaxStmtCount(c *C) {
+	s.ssMap.SetMaxStmtCount(10)
+	for i := 0; i < 20; i++ {
+		stmtExecInfo := generateAnyExecInfo()
+		stmtExecInfo.Digest = fmt.Sprintf("digest%d", i)
+		s.ssMap.AddStatement(stmtExecInfo)
+	}
+	c.Assert(s.ssMap.summaryMap, HasLen, 10)
+	for i := 10; i < 20; i++ {
+		key := stmtSummaryByDigestKey{schemaName: "schema_name", digest: fmt.Sprintf("digest%d", i), planDigest: "plan_digest"}
+		_, ok := s.ssMap.summaryMap[key]
+		c.Assert(ok, IsTrue)
+	}
+
+	s.ssMap.SetMaxStmtCount(5)
+	c.Assert(s.ssMap.summaryMap, HasLen, 5)
+	c.Assert(s.ssMap.MaxStmtCount(), Equals, 5)
+}
+
+// Test converting the summaries to datums.
+func (s *testStmtSummarySuite) TestToDatum(c *C) {
+	stmtExecInfo := generateAnyExecInfo()
+	s.ssMap.AddStatement(stmtExecInfo)
+	rows := s.ssMap.ToCurrentDatum()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows, DeepEquals, s.ssMap.ToHistoryDatum())
+
+	key := stmtSummaryByDigestKey{schemaName: "schema_name", digest: "digest", planDigest: "plan_digest"}
+	element := s.ssMap.summaryMap[key].Value.(*stmtSummaryByDigest).history.Back().Value.(*stmtSummaryByDigestElement)
+	expectedDatum := []interface{}{
+		time.Unix(element.beginTime, 0).Format(timeFormat), time.Unix(element.endTime, 0).Format(timeFormat),
+		"Select", "schema_name", "digest", "normalized_sql", "t1:a", "user",
+		1, 0, 10000000, 10000000, 10000000, 10000000, 10000000, 10000000, 10000000,
+		100, 100, 1000, 1000,
+		10, 5000, 15000, "127.0.0.1", 500, 1500, "127.0.0.1",
+		50000, 50000, 5000, 5000, 80, 80, 10000, 10000, 10.0,
+		stmtExecInfo.StartTime.Format(timeFormat), stmtExecInfo.StartTime.Format(timeFormat),
+		"original_sql1", "plan_digest", "",
+	}
+	c.Assert(rows[0], HasLen, len(expectedDatum))
+	for i, d := range expectedDatum {
+		str, err := rows[0][i].ToString()
+		c.Assert(err, IsNil)
+		expected, err := types.NewDatum(d).ToString()
+		c.Assert(err, IsNil)
+		c.Assert(str, Equals, expected, Commentf("column %d", i))
+	}
+}
+
+// Test the percentiles estimated by latencyHistogram.
+func (s *testStmtSummarySuite) TestLatencyPercentile(c *C) {
+	var h latencyHistogram
+	c.Assert(h.percentile(0.99, 0, 0), Equals, time.Duration(0))
+	for i := 1; i <= 100; i++ {
+		h.add(time.Duration(i) * time.Millisecond)
+	}
+	// 50ms is in the bucket (32768us, 65536us].
+	c.Assert(h.percentile(0.5, time.Millisecond, 100*time.Millisecond), Equals, 65536*time.Microsecond)
+	// 95ms is in the bucket (65536us, 131072us], which is clipped by the max latency.
+	c.Assert(h.percentile(0.95, time.Millisecond, 100*time.Millisecond), Equals, 100*time.Millisecond)
+	c.Assert(h.percentile(0.01, 2*time.Millisecond, 100*time.Millisecond), Equals, 2*time.Millisecond)
+}
@@ -0,0 +1,213 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Span records the time spent on an operation. The spans of a trace form a
+// tree, the root is created by NewRecordedTrace and the others are created by
+// ChildSpanFromContext. All the methods of a nil *Span are no-ops, so callers
+// don't need to check whether the context is traced.
+type Span struct {
+	name  string
+	start time.Time
+	// aggregated indicates the span is started by StartAggregatedChild, it
+	// records all the calls of a repeated operation.
+	aggregated bool
+
+	mu struct {
+		sync.Mutex
+		finished bool
+		duration time.Duration
+		children []*Span
+		// aggregatedChildren are the children started by StartAggregatedChild,
+		// indexed by their keys.
+		aggregatedChildren map[string]*Span
+		// calls is the number of the calls recorded by an aggregated span,
+		// running is the number of the unfinished ones, and resumed is the
+		// time when running became positive.
+		calls   int
+		running int
+		resumed time.Time
+	}
+}
+
+type spanKey struct{}
+
+// NewRecordedTrace creates the root span of a trace.
+func NewRecordedTrace(name string) *Span {
+	return &Span{name: name, start: time.Now()}
+}
+
+// SpanFromContext returns the span carried by ctx, nil is returned if ctx
+// isn't traced.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan returns a new context carrying span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// ChildSpanFromContext starts a child span of the span carried by ctx and
+// returns it with a context carrying it. If ctx isn't traced, nil and ctx
+// itself are returned.
+func ChildSpanFromContext(ctx context.Context, name string) (*Span, context.Context) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return nil, ctx
+	}
+	child := parent.StartChild(name)
+	return child, ContextWithSpan(ctx, child)
+}
+
+// StartChild starts a child span of s. It's safe to be called concurrently.
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := &Span{name: name, start: time.Now()}
+	s.mu.Lock()
+	s.mu.children = append(s.mu.children, child)
+	s.mu.Unlock()
+	return child
+}
+
+// StartAggregatedChild starts a call of the child span of s identified by
+// key. All the calls with the same key share one span, whose duration is the
+// time when at least one of the calls is running, so a repeated operation like
+// the Next calls of an executor doesn't add a span for every call. Every call
+// should be ended by Finish. It's safe to be called concurrently.
+func (s *Span) StartAggregatedChild(key, name string) *Span {
+	if s == nil {
+		return nil
+	}
+	now := time.Now()
+	s.mu.Lock()
+	child, ok := s.mu.aggregatedChildren[key]
+	if !ok {
+		child = &Span{name: name, start: now, aggregated: true}
+		if s.mu.aggregatedChildren == nil {
+			s.mu.aggregatedChildren = make(map[string]*Span)
+		}
+		s.mu.aggregatedChildren[key] = child
+		s.mu.children = append(s.mu.children, child)
+	}
+	s.mu.Unlock()
+
+	child.mu.Lock()
+	child.mu.calls++
+	if child.mu.running == 0 {
+		child.mu.resumed = now
+	}
+	child.mu.running++
+	child.mu.Unlock()
+	return child
+}
+
+// Finish records the end of the operation, only the first call takes effect.
+// For an aggregated span, it records the end of one call.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.aggregated {
+		if s.mu.running > 0 {
+			s.mu.running--
+			if s.mu.running == 0 {
+				s.mu.duration += time.Since(s.mu.resumed)
+			}
+		}
+	} else if !s.mu.finished {
+		s.mu.finished = true
+		s.mu.duration = time.Since(s.start)
+	}
+	s.mu.Unlock()
+}
+
+// Name returns the operation name of the span.
+func (s *Span) Name() string {
+	if s == nil {
+		return ""
+	}
+	return s.name
+}
+
+// StartTime returns the time when the span started.
+func (s *Span) StartTime() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return s.start
+}
+
+// Duration returns the time spent on the operation. For an unfinished span,
+// it's the time elapsed so far.
+func (s *Span) Duration() time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.aggregated {
+		if s.mu.running > 0 {
+			return s.mu.duration + time.Since(s.mu.resumed)
+		}
+		return s.mu.duration
+	}
+	if !s.mu.finished {
+		return time.Since(s.start)
+	}
+	return s.mu.duration
+}
+
+// Calls returns the number of the calls recorded by an aggregated span, it's
+// 1 for the other spans.
+func (s *Span) Calls() int {
+	if s == nil {
+		return 0
+	}
+	if !s.aggregated {
+		return 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.calls
+}
+
+// Children returns the child spans ordered by their start time.
+func (s *Span) Children() []*Span {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	children := make([]*Span, len(s.mu.children))
+	copy(children, s.mu.children)
+	s.mu.Unlock()
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].start.Before(children[j].start)
+	})
+	return children
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testTracingSuite{})
+
+type testTracingSuite struct{}
+
+func (s *testTracingSuite) TestUntracedContext(c *C) {
+	ctx := context.Background()
+	c.Assert(SpanFromContext(ctx), IsNil)
+	span, ctx1 := ChildSpanFromContext(ctx, "child")
+	c.Assert(span, IsNil)
+	c.Assert(ctx1, Equals, ctx)
+	// The methods of a nil span are no-ops.
+	span.Finish()
+	c.Assert(span.Name(), Equals, "")
+	c.Assert(span.Duration(), Equals, time.Duration(0))
+	c.Assert(span.Children(), IsNil)
+}
+
+func (s *testTracingSuite) TestSpanTree(c *C) {
+	root := NewRecordedTrace("root")
+	ctx := ContextWithSpan(context.Background(), root)
+	c.Assert(SpanFromContext(ctx), Equals, root)
+
+	child1, ctx1 := ChildSpanFromContext(ctx, "child1")
+	c.Assert(SpanFromContext(ctx1), Equals, child1)
+	grandChild, _ := ChildSpanFromContext(ctx1, "grandChild")
+	grandChild.Finish()
+	child1.Finish()
+	duration := child1.Duration()
+	child1.Finish()
+	c.Assert(child1.Duration(), Equals, duration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			span, _ := ChildSpanFromContext(ctx, "concurrent")
+			span.Finish()
+		}()
+	}
+	wg.Wait()
+	root.Finish()
+
+	children := root.Children()
+	c.Assert(children, HasLen, 11)
+	c.Assert(children[0].Name(), Equals, "child1")
+	for i := 1; i < len(children); i++ {
+		c.Assert(children[i].StartTime().Before(children[i-1].StartTime()), IsFalse)
+	}
+	c.Assert(child1.Children(), HasLen, 1)
+	c.Assert(child1.Children()[0].Name(), Equals, "grandChild")
+	c.Assert(root.Duration() >= child1.Duration(), IsTrue)
+}
+
+func (s *testTracingSuite) TestAggregatedSpan(c *C) {
+	root := NewRecordedTrace("root")
+	c.Assert(root.Calls(), Equals, 1)
+
+	first := root.StartAggregatedChild("key1", "next")
+	first.Finish()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			span := root.StartAggregatedChild("key1", "next")
+			c.Assert(span, Equals, first)
+			span.Finish()
+		}()
+	}
+	wg.Wait()
+	duration := first.Duration()
+	// Finishing more times than the calls is ignored.
+	first.Finish()
+	c.Assert(first.Duration(), Equals, duration)
+	c.Assert(first.Calls(), Equals, 11)
+
+	// The spans with different keys are different children.
+	other := root.StartAggregatedChild("key2", "next")
+	c.Assert(other, Not(Equals), first)
+	other.Finish()
+	other = root.StartAggregatedChild("key2", "next")
+	c.Assert(other.Duration() >= time.Duration(0), IsTrue)
+	other.Finish()
+	root.Finish()
+	c.Assert(other.Calls(), Equals, 2)
+	c.Assert(root.Children(), HasLen, 2)
+}